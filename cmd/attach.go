@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/models/anthropic"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// maxAttachmentSize is the largest file --attach sends inline; providers
+// reject larger inline data
+const maxAttachmentSize = 20 * 1024 * 1024
+
+// loadAttachments reads the --attach files into message parts, refusing media
+// the model in modelString does not accept. Models that take uploads accept
+// larger files when uploadThreshold is set, since those are uploaded.
+func loadAttachments(paths []string, modelString string, uploadThreshold int64) ([]schema.ChatMessagePart, error) {
+	limit := maxAttachmentSize
+	if uploadThreshold > 0 && models.AcceptsDocuments(modelString) {
+		limit = anthropic.MaxUploadSize
+	}
+
+	var parts []schema.ChatMessagePart
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment: %v", err)
+		}
+		if len(data) > limit {
+			return nil, fmt.Errorf("attachment %s is larger than %d MB", path, limit/1024/1024)
+		}
+
+		mimeType, _, _ := strings.Cut(mime.TypeByExtension(filepath.Ext(path)), ";")
+		if mimeType == "" {
+			mimeType, _, _ = strings.Cut(http.DetectContentType(data), ";")
+		}
+		url := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+
+		switch {
+		case strings.HasPrefix(mimeType, "image/"):
+			if !models.AcceptsImages(modelString) {
+				return nil, fmt.Errorf("cannot attach %s: %s does not accept image input", path, modelString)
+			}
+			parts = append(parts, schema.ChatMessagePart{
+				Type:     schema.ChatMessagePartTypeImageURL,
+				ImageURL: &schema.ChatMessageImageURL{URL: url, MIMEType: mimeType},
+			})
+		case strings.HasPrefix(mimeType, "audio/"):
+			if !models.AcceptsAudio(modelString) {
+				return nil, fmt.Errorf("cannot attach %s: %s does not accept audio input (use a Gemini model)", path, modelString)
+			}
+			parts = append(parts, schema.ChatMessagePart{
+				Type:     schema.ChatMessagePartTypeAudioURL,
+				AudioURL: &schema.ChatMessageAudioURL{URL: url, MIMEType: mimeType},
+			})
+		case mimeType == "application/pdf" || strings.HasPrefix(mimeType, "text/"):
+			if !models.AcceptsDocuments(modelString) {
+				return nil, fmt.Errorf("cannot attach %s: %s does not accept documents (use a Claude model)", path, modelString)
+			}
+			parts = append(parts, schema.ChatMessagePart{
+				Type:    schema.ChatMessagePartTypeFileURL,
+				FileURL: &schema.ChatMessageFileURL{URL: url, MIMEType: mimeType, Name: filepath.Base(path)},
+			})
+		default:
+			return nil, fmt.Errorf("cannot attach %s: unsupported type %s (images, audio, PDF and text only)", path, mimeType)
+		}
+	}
+	return parts, nil
+}
+
+// userMessage returns the user message for prompt with its attachments
+func userMessage(prompt string, attachments []schema.ChatMessagePart) *schema.Message {
+	if len(attachments) == 0 {
+		return schema.UserMessage(prompt)
+	}
+	// Providers take either Content or MultiContent, so the prompt is a part too
+	parts := append([]schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: prompt}}, attachments...)
+	return &schema.Message{Role: schema.User, MultiContent: parts}
+}
+
+// audioExtensions maps common audio types to the extension players expect;
+// mime.ExtensionsByType lists them alphabetically, e.g. .m2a for MP3
+var audioExtensions = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/wav":   ".wav",
+	"audio/x-wav": ".wav",
+	"audio/ogg":   ".ogg",
+	"audio/webm":  ".webm",
+	"audio/flac":  ".flac",
+	"audio/aac":   ".aac",
+	"audio/mp4":   ".m4a",
+}
+
+// saveToolAudio writes the audio blocks among media to dir and returns the
+// paths written
+func saveToolAudio(dir string, media []ui.ToolMedia) ([]string, error) {
+	var paths []string
+	for i, item := range media {
+		if item.Kind != "audio" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(item.Data)
+		if err != nil {
+			return paths, fmt.Errorf("invalid audio data: %v", err)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return paths, err
+		}
+
+		ext, ok := audioExtensions[item.MIMEType]
+		if !ok {
+			ext = ".bin"
+			if exts, _ := mime.ExtensionsByType(item.MIMEType); len(exts) > 0 {
+				ext = exts[0]
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprintf("audio-%s-%d%s", time.Now().Format("20060102-150405"), i+1, ext))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
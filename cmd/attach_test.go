@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+func TestLoadAttachments(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "chart.png")
+	audio := filepath.Join(dir, "memo.mp3")
+	text := filepath.Join(dir, "notes.txt")
+	os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n"), 0o644)
+	os.WriteFile(audio, []byte("ID3\x04"), 0o644)
+	os.WriteFile(text, []byte("hello"), 0o644)
+
+	parts, err := loadAttachments([]string{image, audio}, "google:gemini-2.5-flash", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || parts[0].Type != schema.ChatMessagePartTypeImageURL ||
+		!strings.HasPrefix(parts[0].ImageURL.URL, "data:image/png;base64,") ||
+		parts[1].Type != schema.ChatMessagePartTypeAudioURL || parts[1].AudioURL.MIMEType != "audio/mpeg" {
+		t.Errorf("unexpected parts %+v", parts)
+	}
+
+	if _, err := loadAttachments([]string{audio}, "anthropic:claude-sonnet-4-20250514", 0); err == nil {
+		t.Error("expected audio to be refused for a model without audio input")
+	}
+	if _, err := loadAttachments([]string{text}, "google:gemini-2.5-flash", 0); err == nil {
+		t.Error("expected text files to be refused for a model without documents")
+	}
+	docs, err := loadAttachments([]string{text}, "anthropic:claude-sonnet-4-20250514", 5*1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Type != schema.ChatMessagePartTypeFileURL ||
+		docs[0].FileURL.Name != "notes.txt" || docs[0].FileURL.MIMEType != "text/plain" {
+		t.Errorf("unexpected document parts %+v", docs)
+	}
+
+	msg := userMessage("Describe these", parts)
+	if msg.Content != "" || len(msg.MultiContent) != 3 || msg.MultiContent[0].Text != "Describe these" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if msg := userMessage("Hi", nil); msg.Content != "Hi" || msg.MultiContent != nil {
+		t.Errorf("unexpected message without attachments %+v", msg)
+	}
+}
+
+func TestSaveToolAudio(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "audio")
+	media := []ui.ToolMedia{
+		{Kind: "image", MIMEType: "image/png", Data: "iVBORw0KGgo="},
+		{Kind: "audio", MIMEType: "audio/mpeg", Data: base64.StdEncoding.EncodeToString([]byte("ID3\x04"))},
+	}
+
+	paths, err := saveToolAudio(dir, media)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || filepath.Ext(paths[0]) != ".mp3" {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+	if data, err := os.ReadFile(paths[0]); err != nil || string(data) != "ID3\x04" {
+		t.Errorf("saved audio differs: %q, %v", data, err)
+	}
+}
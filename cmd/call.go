@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/spf13/cobra"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+var callArgsFile string
+
+var callCmd = &cobra.Command{
+	Use:   "call <tool> [json-arguments]",
+	Short: "Invoke an MCP tool directly, without the model",
+	Long: `Call a single tool from the configured MCP servers and print its raw result.
+
+This is useful for checking that a server works before involving the model.
+Tool names use the same server__tool form shown by /tools, and only the
+server that owns the tool is started.
+
+Arguments are a JSON object, given inline or read from a file with --json
+("-" reads standard input).
+
+Examples:
+  mcphost call fs__read_file '{"path": "README.md"}'
+  mcphost call fetch__fetch --json args.json
+  echo '{"command": "ls"}' | mcphost call bash__run_shell_cmd --json -
+
+Exits with status 1 when the tool reports an error.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		toolArgs := "{}"
+		switch {
+		case len(args) == 2 && callArgsFile != "":
+			return fmt.Errorf("pass arguments inline or with --json, not both")
+		case len(args) == 2:
+			toolArgs = args[1]
+		case callArgsFile != "":
+			data, err := readCallArgsFile(callArgsFile)
+			if err != nil {
+				return err
+			}
+			toolArgs = data
+		}
+		return runCall(ctx, args[0], toolArgs)
+	},
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callArgsFile, "json", "", "read tool arguments from a JSON file (- for stdin)")
+	rootCmd.AddCommand(callCmd)
+}
+
+func readCallArgsFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read arguments: %w", err)
+	}
+	return string(data), nil
+}
+
+func runCall(ctx context.Context, toolName, toolArgs string) error {
+	mcpConfig, err := config.LoadAndValidateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load MCP config: %w", err)
+	}
+
+	// Only start the server that owns the tool
+	serverName, _, ok := strings.Cut(toolName, "__")
+	serverConfig, found := mcpConfig.MCPServers[serverName]
+	if !ok || !found {
+		names := make([]string, 0, len(mcpConfig.MCPServers))
+		for name := range mcpConfig.MCPServers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("tool %q does not belong to a configured server (expected server__tool, servers: %s)",
+			toolName, strings.Join(names, ", "))
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	manager := tools.NewMCPToolManager()
+	defer manager.Close()
+	if err := manager.LoadTools(loadCtx, &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{serverName: serverConfig},
+		Debug:      mcpConfig.Debug,
+	}); err != nil {
+		return err
+	}
+
+	result, isError, err := callTool(ctx, manager.GetTools(), toolName, toolArgs)
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	if isError {
+		return fmt.Errorf("tool %s returned an error", toolName)
+	}
+	return nil
+}
+
+// parseCallCommand splits an interactive "/call tool {json}" line into the
+// tool name and its arguments
+func parseCallCommand(input string) (toolName, toolArgs string, ok bool) {
+	rest, found := strings.CutPrefix(input, "/call")
+	if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest)
+	toolName, toolArgs, _ = strings.Cut(rest, " ")
+	toolArgs = strings.TrimSpace(toolArgs)
+	if toolArgs == "" {
+		toolArgs = "{}"
+	}
+	return toolName, toolArgs, true
+}
+
+// handleCallCommand runs /call in interactive mode. The call is not added to
+// the conversation, so the model never sees it.
+func handleCallCommand(ctx context.Context, availableTools []tool.BaseTool, cli *ui.CLI, toolName, toolArgs string) {
+	if toolName == "" {
+		cli.DisplayError(fmt.Errorf("usage: /call <tool> [json-arguments]"))
+		return
+	}
+	cli.DisplayToolCallMessage(toolName, toolArgs)
+	result, isError, err := callTool(ctx, availableTools, toolName, toolArgs)
+	if err != nil {
+		cli.DisplayError(err)
+		return
+	}
+	cli.DisplayToolMessage(toolName, toolArgs, result, isError)
+}
+
+// callTool invokes the named tool and returns its indented raw result and
+// whether the server flagged it as an error
+func callTool(ctx context.Context, availableTools []tool.BaseTool, toolName, toolArgs string) (string, bool, error) {
+	if !json.Valid([]byte(toolArgs)) {
+		return "", false, fmt.Errorf("arguments for %s are not valid JSON: %s", toolName, toolArgs)
+	}
+
+	var names []string
+	for _, t := range availableTools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			continue
+		}
+		if info.Name != toolName {
+			names = append(names, info.Name)
+			continue
+		}
+		invokable, ok := t.(tool.InvokableTool)
+		if !ok {
+			return "", false, fmt.Errorf("tool %s cannot be invoked", toolName)
+		}
+		raw, err := invokable.InvokableRun(ctx, toolArgs)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to call %s: %w", toolName, err)
+		}
+
+		var parsed struct {
+			IsError bool `json:"isError"`
+		}
+		_ = json.Unmarshal([]byte(raw), &parsed)
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, []byte(raw), "", "  ") == nil {
+			raw = pretty.String()
+		}
+		return raw, parsed.IsError, nil
+	}
+
+	sort.Strings(names)
+	return "", false, fmt.Errorf("unknown tool %q (available: %s)", toolName, strings.Join(names, ", "))
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type fakeTool struct {
+	name   string
+	result string
+	args   string
+}
+
+func (f *fakeTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: f.name}, nil
+}
+
+func (f *fakeTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	f.args = argumentsInJSON
+	return f.result, nil
+}
+
+func TestParseCallCommand(t *testing.T) {
+	tests := []struct {
+		input, name, args string
+		ok                bool
+	}{
+		{`/call fs__read_file {"path": "README.md"}`, "fs__read_file", `{"path": "README.md"}`, true},
+		{"/call fs__list_directory", "fs__list_directory", "{}", true},
+		{"/call", "", "{}", true},
+		{"/callme", "", "", false},
+		{"/tools", "", "", false},
+	}
+	for _, tt := range tests {
+		name, args, ok := parseCallCommand(tt.input)
+		if ok != tt.ok || name != tt.name || (ok && args != tt.args) {
+			t.Errorf("parseCallCommand(%q) = %q, %q, %v", tt.input, name, args, ok)
+		}
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	ok := &fakeTool{name: "fs__read_file", result: `{"content":[{"type":"text","text":"hello"}]}`}
+	failing := &fakeTool{name: "fs__write_file", result: `{"content":[{"type":"text","text":"denied"}],"isError":true}`}
+	available := []tool.BaseTool{ok, failing}
+
+	result, isError, err := callTool(context.Background(), available, "fs__read_file", `{"path":"a"}`)
+	if err != nil || isError {
+		t.Fatalf("unexpected failure: %v %v", err, isError)
+	}
+	if ok.args != `{"path":"a"}` {
+		t.Errorf("arguments not passed through, got %q", ok.args)
+	}
+	if !strings.Contains(result, "\n  \"content\"") {
+		t.Errorf("expected indented raw result, got %s", result)
+	}
+
+	if _, isError, _ := callTool(context.Background(), available, "fs__write_file", "{}"); !isError {
+		t.Error("expected isError from the server to be reported")
+	}
+
+	if _, _, err := callTool(context.Background(), available, "fs__nope", "{}"); err == nil || !strings.Contains(err.Error(), "fs__read_file") {
+		t.Errorf("expected unknown tool error listing available tools, got %v", err)
+	}
+	if _, _, err := callTool(context.Background(), available, "fs__read_file", "{bad"); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+}
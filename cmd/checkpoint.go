@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/session"
+)
+
+// checkpointRun saves the conversation to the session each time the agent
+// adds a message during a run, so a crash mid-turn keeps the prompt and the
+// responses and tool results so far. history is the conversation before the
+// run. The returned function ends the checkpoints and returns the messages
+// the run added up to its last checkpoint, which for a failed run are those
+// before it failed: they are not kept, so the session goes back to history.
+func checkpointRun(mcpAgent *agent.Agent, sessionManager *session.Manager, history []*schema.Message) func(err error) []*schema.Message {
+	var latest []*schema.Message
+	mcpAgent.SetCheckpointHandler(func(messages []*schema.Message) {
+		latest = messages
+		if sessionManager != nil {
+			// A failed save is reported when the turn's history is saved
+			sessionManager.ReplaceAllMessages(messages)
+		}
+	})
+	return func(err error) []*schema.Message {
+		mcpAgent.SetCheckpointHandler(nil)
+		if err != nil && sessionManager != nil {
+			sessionManager.ReplaceAllMessages(history)
+		}
+		// The run's messages follow history, and the system prompt the
+		// agent adds when history has none
+		start := min(len(history), len(latest))
+		if len(latest) > 0 && latest[0].Role == schema.System && (len(history) == 0 || history[0].Role != schema.System) {
+			start = min(start+1, len(latest))
+		}
+		return latest[start:]
+	}
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// displaySources shows the sources a response cited (--citations) below it.
+// In quiet mode they go to stdout after the response, so scripts capturing
+// the answer get its sources with it.
+func displaySources(cli *ui.CLI, quiet bool, citations []agent.Citation) {
+	if cli == nil || quiet {
+		fmt.Print("\n\n" + agent.FormatSources(citations) + "\n")
+		return
+	}
+	cli.DisplayInfo(agent.FormatSources(citations))
+}
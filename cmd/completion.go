@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/auth"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/session"
+)
+
+// completionProviders are the providers models.CreateProvider knows how to build
+var completionProviders = []string{"anthropic", "openai", "google", "azure", "groq", "xai", "deepseek", "ollama", "llamacpp", "openai-compatible"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for mcphost.
+
+Besides subcommands and flags, the scripts complete --model from the models
+registry (type the provider first, e.g. "anthropic:<TAB>") and session files
+for --session, --load-session and --save-session.
+
+Bash:
+  source <(mcphost completion bash)
+  # or install permanently
+  mcphost completion bash > /etc/bash_completion.d/mcphost
+
+Zsh:
+  mcphost completion zsh > "${fpath[1]}/_mcphost"
+
+Fish:
+  mcphost completion fish > ~/.config/fish/completions/mcphost.fish
+
+PowerShell:
+  mcphost completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := cmd.Root()
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return root.GenZshCompletion(os.Stdout)
+		case "fish":
+			return root.GenFishCompletion(os.Stdout, true)
+		default:
+			return root.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+	},
+}
+
+func init() {
+	// Replace cobra's default completion command with ours
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+// registerFlagCompletions attaches dynamic completions to the root flags; it is
+// called from the root command's init once the flags are defined
+func registerFlagCompletions() {
+	rootCmd.RegisterFlagCompletionFunc("model", completeModels)
+	rootCmd.RegisterFlagCompletionFunc("tool-choice", cobra.FixedCompletions(
+		[]string{agent.ToolChoiceAuto, agent.ToolChoiceNone, agent.ToolChoiceRequired}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("tool-emulation", cobra.FixedCompletions(
+		models.ToolEmulationModes, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("azure-auth", cobra.FixedCompletions(
+		auth.AzureAuthMethods, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("quiet", cobra.FixedCompletions(
+		[]string{"status", "tools", "all"}, cobra.ShellCompDirectiveNoFileComp))
+	for _, name := range []string{"session", "load-session", "save-session"} {
+		rootCmd.RegisterFlagCompletionFunc(name, completeSessions)
+	}
+}
+
+// completeModels completes "provider:" prefixes and then the registry's models
+// for that provider
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	provider, _, hasProvider := strings.Cut(toComplete, ":")
+	if !hasProvider {
+		var providers []string
+		for _, p := range completionProviders {
+			if strings.HasPrefix(p, toComplete) {
+				providers = append(providers, p+":")
+			}
+		}
+		return providers, cobra.ShellCompDirectiveNoSpace
+	}
+
+	registryModels, err := models.GetGlobalRegistry().GetModelsForProvider(provider)
+	if err != nil {
+		// Ollama and other local models are not in the registry
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for id, info := range registryModels {
+		candidate := provider + ":" + id
+		if strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, candidate+"\t"+info.Name)
+		}
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessions completes session files in the current directory and the
+// state directory
+func completeSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	if dir, err := config.StatePath("sessions"); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != session.ExtJSON && ext != session.ExtJSONL) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if strings.HasPrefix(path, toComplete) {
+				completions = append(completions, path)
+			}
+		}
+	}
+	if len(completions) == 0 {
+		return []string{"json", "jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	return completions, cobra.ShellCompDirectiveDefault
+}
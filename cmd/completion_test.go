@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteModels(t *testing.T) {
+	providers, directive := completeModels(rootCmd, nil, "an")
+	if len(providers) != 1 || providers[0] != "anthropic:" {
+		t.Errorf("expected anthropic: provider completion, got %v", providers)
+	}
+	if directive != cobra.ShellCompDirectiveNoSpace {
+		t.Errorf("expected provider completion to suppress the trailing space")
+	}
+
+	completions, _ := completeModels(rootCmd, nil, "anthropic:claude-sonnet-4")
+	if len(completions) == 0 {
+		t.Fatal("expected registry models for anthropic")
+	}
+	for _, c := range completions {
+		if !strings.HasPrefix(c, "anthropic:claude-sonnet-4") {
+			t.Errorf("completion %q does not match the typed prefix", c)
+		}
+	}
+
+	if completions, _ := completeModels(rootCmd, nil, "ollama:"); len(completions) != 0 {
+		t.Errorf("expected no registry completions for ollama, got %v", completions)
+	}
+}
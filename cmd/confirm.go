@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// newStepConfirmer returns the handler that asks on the terminal before each
+// step's tool calls run (--confirm-each-step), or nil when the mode is off
+func newStepConfirmer() agent.StepApprovalHandler {
+	if !viper.GetBool("confirm-each-step") {
+		return nil
+	}
+	return func(content string, toolCalls []schema.ToolCall) bool {
+		// The spinner reads keys too, so it has to go before the prompt
+		ui.StopActiveSpinner()
+
+		title := "Run this tool call?"
+		if len(toolCalls) > 1 {
+			title = fmt.Sprintf("Run these %d tool calls?", len(toolCalls))
+		}
+		approved := false
+		err := huh.NewConfirm().
+			Title(title).
+			Description(describeStep(toolCalls)).
+			Affirmative("Run").
+			Negative("Stop").
+			Value(&approved).
+			Run()
+		return err == nil && approved
+	}
+}
+
+// describeStep lists a step's tool calls with their full arguments, indented
+// when they are JSON
+func describeStep(toolCalls []schema.ToolCall) string {
+	var b strings.Builder
+	for i, call := range toolCalls {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, call.Function.Name)
+
+		args := strings.TrimSpace(call.Function.Arguments)
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(args), "   ", "  ") == nil {
+			args = indented.String()
+		}
+		if args == "" || args == "{}" {
+			args = "(no arguments)"
+		}
+		fmt.Fprintf(&b, "   %s\n", args)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
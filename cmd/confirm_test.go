@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestDescribeStep(t *testing.T) {
+	description := describeStep([]schema.ToolCall{
+		{Function: schema.FunctionCall{Name: "db__execute", Arguments: `{"sql": "DROP TABLE users", "confirm": true}`}},
+		{Function: schema.FunctionCall{Name: "fs__list", Arguments: "{}"}},
+		{Function: schema.FunctionCall{Name: "bash__run", Arguments: "rm -rf build"}},
+	})
+
+	want := `1. db__execute
+   {
+     "sql": "DROP TABLE users",
+     "confirm": true
+   }
+
+2. fs__list
+   (no arguments)
+
+3. bash__run
+   rm -rf build`
+	if description != want {
+		t.Errorf("unexpected description:\n%s\nwant:\n%s", description, want)
+	}
+}
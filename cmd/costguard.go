@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/tokens"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// defaultConfirmTokens is the prompt size, in estimated tokens, above which
+// mcphost asks before sending it
+const defaultConfirmTokens = 100000
+
+// mediaTokenEstimate is the rough size of an attached image or audio clip;
+// providers bill them by resolution or length, which is not known here
+const mediaTokenEstimate = 1600
+
+// errPromptNotSent ends an interactive prompt the user chose not to send
+var errPromptNotSent = errors.New("prompt not sent")
+
+// estimateTokens roughly counts the input tokens of messages: text by its
+// length and every image or audio part at mediaTokenEstimate
+func estimateTokens(messages []*schema.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += tokens.EstimateTokens(msg.Content)
+		for _, part := range msg.MultiContent {
+			if part.Type == schema.ChatMessagePartTypeText {
+				total += tokens.EstimateTokens(part.Text)
+			} else {
+				total += mediaTokenEstimate
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			total += tokens.EstimateTokens(call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// describePromptSize says how large the prompt and the whole request are and,
+// when the model's price is known, what the request's input costs
+func describePromptSize(modelString string, promptTokens, requestTokens int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "This prompt is about %s tokens", formatTokens(promptTokens))
+	if requestTokens > promptTokens {
+		fmt.Fprintf(&b, "; with the conversation the request is about %s", formatTokens(requestTokens))
+	}
+	provider, modelID, _ := strings.Cut(modelString, ":")
+	if info, err := models.GetGlobalRegistry().ValidateModel(provider, modelID); err == nil && info.Cost.Input > 0 {
+		cost := info.Cost.Price(models.TokenCounts{InputTokens: requestTokens})
+		fmt.Fprintf(&b, ", roughly $%.2f of input on %s", cost.Input, modelString)
+	}
+	return b.String() + "."
+}
+
+// formatTokens writes n with thousands separators
+func formatTokens(n int) string {
+	digits := fmt.Sprint(n)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// confirmPromptSize checks a prompt, the last of messages, against
+// --confirm-tokens before it is sent. Interactive runs ask, returning
+// errPromptNotSent when the user declines; other runs need --yes.
+func confirmPromptSize(cli *ui.CLI, messages []*schema.Message, interactive bool) error {
+	limit := viper.GetInt("confirm-tokens")
+	if limit <= 0 || viper.GetBool("yes") || len(messages) == 0 {
+		return nil
+	}
+	promptTokens := estimateTokens(messages[len(messages)-1:])
+	if promptTokens <= limit {
+		return nil
+	}
+	size := describePromptSize(viper.GetString("model"), promptTokens, estimateTokens(messages))
+
+	if !interactive || cli == nil {
+		return fmt.Errorf("%s That is over --confirm-tokens %d; pass --yes to send it", size, limit)
+	}
+	send := false
+	err := huh.NewConfirm().
+		Title("Send this large prompt?").
+		Description(size).
+		Affirmative("Send").
+		Negative("Cancel").
+		Value(&send).
+		Run()
+	if err != nil || !send {
+		return errPromptNotSent
+	}
+	return nil
+}
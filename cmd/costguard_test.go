@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	image := schema.ChatMessagePart{Type: schema.ChatMessagePartTypeImageURL, ImageURL: &schema.ChatMessageImageURL{URL: "data:image/png;base64,AAAA"}}
+	messages := []*schema.Message{
+		schema.UserMessage(strings.Repeat("a", 400)),
+		{Role: schema.User, MultiContent: []schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: strings.Repeat("b", 40)}, image}},
+	}
+	if got, want := estimateTokens(messages), 100+10+mediaTokenEstimate; got != want {
+		t.Errorf("estimateTokens = %d, want %d", got, want)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", 152340: "152,340", 1234567: "1,234,567"} {
+		if got := formatTokens(n); got != want {
+			t.Errorf("formatTokens(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestConfirmPromptSize(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("model", "anthropic:claude-sonnet-4-20250514")
+	viper.Set("confirm-tokens", 1000)
+
+	history := []*schema.Message{schema.UserMessage(strings.Repeat("x", 8000)), schema.AssistantMessage("ok", nil)}
+	if err := confirmPromptSize(nil, append(history, schema.UserMessage("short")), false); err != nil {
+		t.Errorf("expected a small prompt to pass whatever the history, got %v", err)
+	}
+
+	large := append(history, schema.UserMessage(strings.Repeat("y", 8000)))
+	err := confirmPromptSize(nil, large, false)
+	if err == nil || !strings.Contains(err.Error(), "--yes") || !strings.Contains(err.Error(), "2,000 tokens") {
+		t.Fatalf("expected a large prompt to need --yes, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "$") {
+		t.Errorf("expected the cost of a priced model, got %v", err)
+	}
+	if errors.Is(err, errPromptNotSent) {
+		t.Error("expected a non-interactive run to fail rather than skip the prompt")
+	}
+
+	viper.Set("yes", true)
+	if err := confirmPromptSize(nil, large, false); err != nil {
+		t.Errorf("expected --yes to send the prompt, got %v", err)
+	}
+	viper.Set("yes", false)
+	viper.Set("confirm-tokens", 0)
+	if err := confirmPromptSize(nil, large, false); err != nil {
+		t.Errorf("expected --confirm-tokens 0 never to ask, got %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	mcobra "github.com/muesli/mango-cobra"
+	"github.com/muesli/roff"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/hooks"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+var (
+	docsRaw    bool
+	docsManDir string
+)
+
+// docTopic is a page printed by `mcphost docs <topic>`. Pages are generated
+// from the code they describe so they cannot drift from it.
+type docTopic struct {
+	name     string
+	summary  string
+	generate func(ctx context.Context) (string, error)
+}
+
+var docTopics = []docTopic{
+	{"config", "Configuration file keys and MCP server entries", generateConfigDocs},
+	{"script", "Script files: frontmatter and variables", generateScriptDocs},
+	{"hooks", "Hook events, their JSON input and output", generateHooksDocs},
+	{"builtin", "Builtin MCP servers and their tools", generateBuiltinDocs},
+}
+
+var docsCmd = &cobra.Command{
+	Use:   "docs [topic]",
+	Short: "Show detailed documentation in the terminal",
+	Long: `Show detailed documentation for mcphost in the terminal.
+
+Topics:
+  config    Configuration file keys and MCP server entries
+  script    Script files: frontmatter and variables
+  hooks     Hook events, their JSON input and output
+  builtin   Builtin MCP servers and their tools
+
+Pages are rendered as markdown when writing to a terminal; use --raw to get the
+markdown source. With --man-dir, the mcphost(1) man page is written to the
+given directory instead.
+
+Examples:
+  mcphost docs hooks
+  mcphost docs config --raw > config.md
+  mcphost docs --man-dir ./man`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, topic := range docTopics {
+			names = append(names, topic.name+"\t"+topic.summary)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if docsManDir != "" {
+			return writeManPages(cmd.Root(), docsManDir)
+		}
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		for _, topic := range docTopics {
+			if topic.name != args[0] {
+				continue
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			page, err := topic.generate(ctx)
+			if err != nil {
+				return err
+			}
+			return printDocs(page)
+		}
+		return fmt.Errorf("unknown docs topic %q (available: config, script, hooks, builtin)", args[0])
+	},
+}
+
+func init() {
+	docsCmd.Flags().BoolVar(&docsRaw, "raw", false, "print markdown source instead of rendering it")
+	docsCmd.Flags().StringVar(&docsManDir, "man-dir", "", "write the mcphost(1) man page to this directory")
+	rootCmd.AddCommand(docsCmd)
+}
+
+// printDocs renders markdown for terminals and prints it as-is otherwise
+func printDocs(page string) error {
+	fd := int(os.Stdout.Fd())
+	if docsRaw || !term.IsTerminal(fd) {
+		_, err := fmt.Print(page)
+		return err
+	}
+
+	width, _, err := term.GetSize(fd)
+	if err != nil || width <= 0 {
+		width = 80
+	}
+	rendered, err := ui.GetMarkdownRenderer(width).Render(page)
+	if err != nil {
+		_, err = fmt.Print(page)
+		return err
+	}
+	_, err = fmt.Print(rendered)
+	return err
+}
+
+// writeManPages writes the mcphost(1) man page, covering every subcommand,
+// into dir
+func writeManPages(root *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create man directory: %w", err)
+	}
+
+	page, err := mcobra.NewManPage(1, root)
+	if err != nil {
+		return fmt.Errorf("failed to generate man page: %w", err)
+	}
+	path := filepath.Join(dir, root.Name()+".1")
+	if err := os.WriteFile(path, []byte(page.Build(roff.NewDocument())), 0644); err != nil {
+		return fmt.Errorf("failed to write man page: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+// mcpServerFieldDocs describes the fields of an mcpServers entry
+var mcpServerFieldDocs = map[string]string{
+	"type":          "`local`, `remote`, `builtin` or `openapi`",
+	"command":       "Command and arguments to start a local server",
+	"environment":   "Extra environment variables for a local server",
+	"url":           "Endpoint of a remote server, or the base URL of an openapi server's API",
+	"name":          "Name of a builtin server (see `mcphost docs builtin`)",
+	"options":       "Options passed to a builtin server",
+	"allowedTools":  "Only expose these tools from the server",
+	"excludedTools": "Hide these tools from the server",
+	"headers":       "HTTP headers for a remote or openapi server, as `Name: value`",
+	"spec":          "URL or file path of an openapi server's OpenAPI 3 spec",
+	"operations":    "operationIds an openapi server exposes as tools (default: all)",
+}
+
+func generateConfigDocs(ctx context.Context) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Configuration\n\n")
+	b.WriteString("MCPHost reads `.mcphost.yml` (or `.json`) from the current directory, then from your home " +
+		"directory, or the file given with `--config`. Values support `${env://NAME:-default}` substitution. " +
+		"Every command-line flag below can be set in the file under the same name; flags take precedence.\n\n")
+
+	b.WriteString("## Settings\n\n")
+	b.WriteString("| Key | Type | Default | Description |\n|---|---|---|---|\n")
+	writeFlagTable(&b, rootCmd.PersistentFlags(), "", "config")
+	writeFlagTable(&b, serveCmd.Flags(), "serve.", "api-key")
+	b.WriteString("\n`serve.api-keys` takes a list of `{name, key, requests-per-minute}` entries.\n\n")
+	b.WriteString("`webhooks` takes a list of `{url, secret, events}` entries, notified when a `--prompt` run " +
+		"(`run`) or a serve-mode job (`job`) finishes.\n\n")
+	b.WriteString("`smtp` takes `{host, port, username, password, from}` and is used by `--email-results`.\n\n")
+	b.WriteString("`guardrail` takes `{action, deny, model, policy, command, timeout, message}` and checks final " +
+		"responses before they are shown or returned, blocking or annotating the ones that fail.\n\n")
+	b.WriteString("`tools` maps tool names to `{command, description, params, dir, timeout}`. Each tool runs its " +
+		"command with `sh -c`, replacing `{param}` placeholders with the shell-quoted arguments, and is served as " +
+		"`tools__<name>`. `params` maps names to `{type, description, required, default}`.\n\n")
+
+	b.WriteString("## MCP Servers\n\n")
+	b.WriteString("Servers are configured under `mcpServers`, keyed by a name that prefixes their tools " +
+		"(`<name>__<tool>`).\n\n")
+	b.WriteString("| Field | Type | Description |\n|---|---|---|\n")
+	t := reflect.TypeOf(config.MCPServerConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		key := jsonName(t.Field(i))
+		desc, documented := mcpServerFieldDocs[key]
+		if !documented {
+			continue // legacy fields
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", key, typeName(t.Field(i).Type), desc)
+	}
+
+	b.WriteString("\n```yaml\nmcpServers:\n  filesystem:\n    type: builtin\n    name: fs\n    options:\n" +
+		"      allowed_directories: [\"/tmp\"]\n  github:\n    type: local\n" +
+		"    command: [\"npx\", \"-y\", \"@modelcontextprotocol/server-github\"]\n" +
+		"    environment:\n      GITHUB_TOKEN: \"${env://GITHUB_TOKEN}\"\n```\n")
+	return b.String(), nil
+}
+
+// writeFlagTable writes a table row per flag in set, as config keys with the given prefix
+func writeFlagTable(b *strings.Builder, set *pflag.FlagSet, prefix string, skip ...string) {
+	var flags []*pflag.Flag
+	set.VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden && !slices.Contains(skip, f.Name) {
+			flags = append(flags, f)
+		}
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	for _, f := range flags {
+		def := f.DefValue
+		if def == "" || def == "[]" {
+			def = "-"
+		} else {
+			def = "`" + def + "`"
+		}
+		fmt.Fprintf(b, "| `%s%s` | %s | %s | %s |\n", prefix, f.Name, f.Value.Type(), def, f.Usage)
+	}
+}
+
+func generateScriptDocs(ctx context.Context) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Scripts\n\n")
+	b.WriteString("Run with `mcphost script <file>`, or make the file executable with a " +
+		"`#!/usr/bin/env -S mcphost script` shebang.\n\n")
+	b.WriteString("```\n" + scriptCmd.Long + "\n```\n\n")
+	b.WriteString("## Frontmatter keys\n\n")
+	b.WriteString("The frontmatter accepts the same keys as the configuration file:\n\n")
+
+	t := reflect.TypeOf(config.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonName(t.Field(i)); key != "" {
+			fmt.Fprintf(&b, "- `%s`\n", key)
+		}
+	}
+	b.WriteString("\nSee `mcphost docs config` for what each key does.\n")
+	return b.String(), nil
+}
+
+func generateHooksDocs(ctx context.Context) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Hooks\n\n")
+	b.WriteString("Hooks are shell commands run at points in the agent lifecycle. They are loaded from " +
+		"`$XDG_CONFIG_HOME/mcphost/hooks.yml` and then `.mcphost/hooks.yml`, and receive a JSON " +
+		"document on stdin. Run `mcphost hooks init` for an example configuration.\n\n")
+	b.WriteString("```yaml\nhooks:\n  PreToolUse:\n    - matcher: \"bash\"\n      hooks:\n" +
+		"        - type: command\n          command: \"/path/to/validator.sh\"\n          timeout: 5\n```\n\n")
+
+	b.WriteString("## Events\n\n")
+	for _, event := range hooks.AllEvents {
+		fmt.Fprintf(&b, "### %s\n\n%s.", event, event.Description())
+		if event.RequiresMatcher() {
+			b.WriteString(" `matcher` is a regular expression matched against the tool name.")
+		}
+		b.WriteString("\n\nInput fields:\n\n")
+		writeStructFields(&b, reflect.TypeOf(hooks.InputFor(event)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Presets\n\n")
+	b.WriteString("Builtin hooks written in Go, enabled by name with `presets: [name, ...]` under `hooks`:\n\n")
+	for _, name := range hooks.PresetNames() {
+		event, description, _ := hooks.PresetInfo(name)
+		fmt.Fprintf(&b, "- `%s` (%s): %s\n", name, event, description)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Output\n\n")
+	b.WriteString("Exit code 0 continues; exit code 2 blocks the prompt or tool call and reports stderr as " +
+		"the reason. A hook may instead print a JSON object to stdout:\n\n")
+	writeStructFields(&b, reflect.TypeOf(hooks.HookOutput{}))
+	b.WriteString("\nHooks receive the active state directory in `$" + config.StateDirEnv + "`.\n")
+	return b.String(), nil
+}
+
+// writeStructFields lists the JSON fields of t, flattening embedded structs
+func writeStructFields(b *strings.Builder, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			writeStructFields(b, field.Type)
+			continue
+		}
+		if key := jsonName(field); key != "" {
+			fmt.Fprintf(b, "- `%s` (%s)\n", key, typeName(field.Type))
+		}
+	}
+}
+
+// builtinOptionDocs describes the options accepted by builtin servers
+var builtinOptionDocs = map[string]string{
+	"fs":         "`allowed_directories`: directories the server may access (default: the current directory)",
+	"commands":   "`tools`: command tools, usually defined in the top-level `tools` config section instead",
+	"todo":       "`persist`: keep the list in the state directory's `todos.json` across sessions (default: false)",
+	"scratchpad": "`max_chars`: total length of all notes at most (default: 4000)",
+	"data":       "`allowed_directories`: directories `load_csv` may read (default: the current directory); `max_rows`: rows `query_sql` returns at most (default: 100)",
+}
+
+func generateBuiltinDocs(ctx context.Context) (string, error) {
+	registry := builtin.NewRegistry()
+	names := registry.ListServers()
+	sort.Strings(names)
+
+	// Load every builtin server in-process to list its real tool set
+	cfg := &config.Config{MCPServers: make(map[string]config.MCPServerConfig)}
+	for _, name := range names {
+		cfg.MCPServers[name] = config.MCPServerConfig{Type: "builtin", Name: name}
+	}
+	manager := tools.NewMCPToolManager()
+	loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := manager.LoadTools(loadCtx, cfg); err != nil {
+		return "", fmt.Errorf("failed to load builtin servers: %w", err)
+	}
+	defer manager.Close()
+
+	byServer := make(map[string][]tools.ToolDetail)
+	for _, detail := range manager.GetToolDetails() {
+		byServer[detail.Server] = append(byServer[detail.Server], detail)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Builtin Servers\n\n")
+	b.WriteString("Builtin servers run in-process and need no installation. Plugins installed in the plugin " +
+		"directory (`$MCPHOST_PLUGIN_DIR` or `<state-dir>/plugins`) are listed alongside them:\n\n")
+	b.WriteString("```yaml\nmcpServers:\n  my-name:\n    type: builtin\n    name: <server>\n    options: {}\n```\n\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "## %s\n\n", name)
+		if opts, ok := builtinOptionDocs[name]; ok {
+			fmt.Fprintf(&b, "Options: %s\n\n", opts)
+		}
+		if plugin, ok := registry.Plugin(name); ok {
+			writePluginDocs(&b, plugin)
+		}
+		for _, detail := range byServer[name] {
+			summary, _, _ := strings.Cut(strings.TrimSpace(detail.Description), "\n")
+			fmt.Fprintf(&b, "- `%s` - %s\n", detail.OriginalName, summary)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// writePluginDocs describes a plugin server and its options
+func writePluginDocs(b *strings.Builder, plugin builtin.Plugin) {
+	fmt.Fprintf(b, "Plugin `%s`", plugin.Path)
+	if plugin.Version != "" {
+		fmt.Fprintf(b, " (version %s)", plugin.Version)
+	}
+	b.WriteString(".")
+	if plugin.Description != "" {
+		b.WriteString(" " + plugin.Description)
+	}
+	b.WriteString("\n\n")
+	if plugin.Options == nil || len(plugin.Options.Properties) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(plugin.Options.Properties))
+	for name := range plugin.Options.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b.WriteString("Options:\n")
+	for _, name := range names {
+		option := plugin.Options.Properties[name]
+		fmt.Fprintf(b, "- `%s`", name)
+		if option.Type != "" {
+			fmt.Fprintf(b, " (%s", option.Type)
+			if slices.Contains(plugin.Options.Required, name) {
+				b.WriteString(", required")
+			}
+			b.WriteString(")")
+		}
+		if option.Description != "" {
+			b.WriteString(": " + option.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// jsonName returns the JSON key of a struct field, or "" when it is not serialized
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// typeName returns a short, user-facing name for a field type
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "object"
+		}
+		return "list of " + typeName(t.Elem())
+	case reflect.Map, reflect.Interface, reflect.Struct:
+		return "object"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	}
+	return t.Kind().String()
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/osi4iot/mcphost/internal/hooks"
+)
+
+func TestDocsTopicsAreGenerated(t *testing.T) {
+	for _, topic := range docTopics {
+		t.Run(topic.name, func(t *testing.T) {
+			page, err := topic.generate(context.Background())
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+			if !strings.HasPrefix(page, "# ") {
+				t.Errorf("expected page to start with a heading, got %q", page[:min(len(page), 40)])
+			}
+		})
+	}
+}
+
+func TestConfigDocsCoverFlags(t *testing.T) {
+	page, _ := generateConfigDocs(context.Background())
+	for _, key := range []string{"`model`", "`max-steps`", "`state-dir`", "`serve.addr`", "`webhooks`", "`allowedTools`"} {
+		if !strings.Contains(page, key) {
+			t.Errorf("config docs missing %s", key)
+		}
+	}
+}
+
+func TestHooksDocsCoverEvents(t *testing.T) {
+	page, _ := generateHooksDocs(context.Background())
+	for _, event := range hooks.AllEvents {
+		if !strings.Contains(page, "### "+string(event)) {
+			t.Errorf("hooks docs missing event %s", event)
+		}
+	}
+	if !strings.Contains(page, "`tool_input`") {
+		t.Error("hooks docs missing PreToolUse input fields")
+	}
+}
@@ -0,0 +1,500 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"github.com/osi4iot/mcphost/internal/auth"
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// doctorStatus is the outcome of a single check
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorResult is one line of the doctor report
+type doctorResult struct {
+	Name   string
+	Status doctorStatus
+	Detail string
+	Fix    string // what to do about a warning or failure
+}
+
+// runtimeHints explains how to install the runtimes stdio servers commonly use
+var runtimeHints = map[string]string{
+	"node": "install Node.js from https://nodejs.org (provides node and npx)",
+	"npx":  "install Node.js from https://nodejs.org (provides node and npx)",
+	"uvx":  "install uv from https://docs.astral.sh/uv/ (provides uvx)",
+	"uv":   "install uv from https://docs.astral.sh/uv/",
+	"bunx": "install Bun from https://bun.sh (provides bunx)",
+	"bun":  "install Bun from https://bun.sh",
+	"deno": "install Deno from https://deno.com",
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, provider and MCP server problems",
+	Long: `Check that mcphost is ready to run and print concrete fixes for anything that
+is not:
+
+  - the config file parses and validates
+  - the model is known and the provider has credentials
+  - a custom --provider-url endpoint lists the model and accepts tool calls
+  - the provider answers a minimal request
+  - every MCP server starts and lists its tools
+  - commands used by local servers (node, npx, uvx, ...) are installed
+  - plugins in the plugin directory answer the handshake
+  - the terminal supports the interactive UI
+
+--offline skips the checks that contact the provider or start MCP servers.
+Exits with status 1 when any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return runDoctor(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(ctx context.Context) error {
+	var results []doctorResult
+	section := func(title string, checks []doctorResult) {
+		fmt.Println(lipgloss.NewStyle().Bold(true).Render(title))
+		for _, r := range checks {
+			printDoctorResult(r)
+		}
+		fmt.Println()
+		results = append(results, checks...)
+	}
+
+	mcpConfig, configChecks := checkDoctorConfig()
+	section("Configuration", configChecks)
+	section("Provider", checkDoctorProvider(ctx))
+	if mcpConfig != nil && len(mcpConfig.MCPServers) > 0 {
+		section("MCP servers", checkDoctorServers(ctx, mcpConfig))
+	}
+	if plugins := checkDoctorPlugins(); len(plugins) > 0 {
+		section("Plugins", plugins)
+	}
+	section("Terminal", checkDoctorTerminal())
+
+	failures := 0
+	for _, r := range results {
+		if r.Status == doctorFail {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	fmt.Println("Everything looks good.")
+	return nil
+}
+
+func printDoctorResult(r doctorResult) {
+	icon := lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✓")
+	switch r.Status {
+	case doctorWarn:
+		icon = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("!")
+	case doctorFail:
+		icon = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("✗")
+	}
+	line := fmt.Sprintf("  %s %s", icon, r.Name)
+	if r.Detail != "" {
+		line += ": " + r.Detail
+	}
+	fmt.Println(line)
+	if r.Fix != "" && r.Status != doctorOK {
+		fmt.Printf("      → %s\n", r.Fix)
+	}
+}
+
+// checkDoctorConfig reports which config file is in use and whether it validates
+func checkDoctorConfig() (*config.Config, []doctorResult) {
+	var results []doctorResult
+	if used := viper.ConfigFileUsed(); used != "" {
+		results = append(results, doctorResult{Name: "Config file", Status: doctorOK, Detail: used})
+	} else if configFile != "" {
+		results = append(results, doctorResult{Name: "Config file", Status: doctorOK, Detail: configFile})
+	} else {
+		results = append(results, doctorResult{
+			Name: "Config file", Status: doctorWarn, Detail: "none found",
+			Fix: "run `mcphost setup` to create ~/.mcphost.yml",
+		})
+	}
+
+	mcpConfig, err := config.LoadAndValidateConfig()
+	if err != nil {
+		results = append(results, doctorResult{
+			Name: "Config contents", Status: doctorFail, Detail: err.Error(),
+			Fix: "see `mcphost docs config` for the expected format",
+		})
+		return nil, results
+	}
+	results = append(results, doctorResult{
+		Name: "Config contents", Status: doctorOK,
+		Detail: fmt.Sprintf("%d MCP server(s) configured", len(mcpConfig.MCPServers)),
+	})
+	return mcpConfig, results
+}
+
+// checkDoctorProvider validates the model string, looks for credentials and
+// makes a minimal request to the provider
+func checkDoctorProvider(ctx context.Context) []doctorResult {
+	modelString := viper.GetString("model")
+	provider, modelName, ok := strings.Cut(modelString, ":")
+	if !ok || modelName == "" {
+		return []doctorResult{{
+			Name: "Model", Status: doctorFail, Detail: fmt.Sprintf("%q is not in provider:model format", modelString),
+			Fix: "set --model or `model:` in the config, e.g. anthropic:claude-sonnet-4-20250514",
+		}}
+	}
+
+	var results []doctorResult
+	if !models.IsLocal(provider) && provider != models.CompatibleProvider {
+		if _, err := models.GetGlobalRegistry().ValidateModel(provider, modelName); err != nil {
+			fix := "run `mcphost docs config` or use shell completion for --model to see known models"
+			if suggestions := models.GetGlobalRegistry().SuggestModels(provider, modelName); len(suggestions) > 0 {
+				fix = "did you mean " + strings.Join(suggestions, ", ") + "?"
+			}
+			results = append(results, doctorResult{Name: "Model", Status: doctorWarn, Detail: err.Error(), Fix: fix})
+		} else {
+			results = append(results, doctorResult{Name: "Model", Status: doctorOK, Detail: modelString})
+		}
+	} else {
+		results = append(results, doctorResult{Name: "Model", Status: doctorOK, Detail: modelString})
+	}
+
+	results = append(results, checkDoctorCredentials(provider))
+	if results[len(results)-1].Status == doctorFail {
+		return results
+	}
+
+	if viper.GetBool("offline") {
+		return results
+	}
+	if endpoint, ok := checkDoctorEndpoint(ctx); ok {
+		results = append(results, endpoint)
+		if endpoint.Status == doctorFail {
+			return results
+		}
+	}
+	return append(results, pingProvider(ctx, modelString))
+}
+
+// checkDoctorEndpoint probes a custom provider endpoint afresh, reporting
+// false when there is none to probe
+func checkDoctorEndpoint(ctx context.Context) (doctorResult, bool) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	profile, err := models.ProbeEndpoint(ctx, BuildProviderConfig(""))
+	if err != nil {
+		return doctorResult{
+			Name: "Endpoint", Status: doctorFail, Detail: err.Error(),
+			Fix: "check --provider-url, the API key and the model name",
+		}, true
+	}
+	if profile == nil {
+		return doctorResult{}, false
+	}
+	detail := profile.URL + " answers, with native tool calls"
+	if !profile.Tools {
+		detail = profile.URL + " answers, without native tool calls"
+	}
+	if len(profile.Warnings) > 0 {
+		return doctorResult{
+			Name: "Endpoint", Status: doctorWarn, Detail: detail + "; " + strings.Join(profile.Warnings, "; "),
+			Fix: "check the model name and whether the server supports tools",
+		}, true
+	}
+	return doctorResult{Name: "Endpoint", Status: doctorOK, Detail: detail}, true
+}
+
+// checkDoctorCredentials looks for the provider's API key where the provider would
+func checkDoctorCredentials(provider string) doctorResult {
+	flagKey := viper.GetString("provider-api-key")
+	found := func(source string) doctorResult {
+		return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "found (" + source + ")"}
+	}
+	if flagKey != "" {
+		return found("provider-api-key")
+	}
+
+	switch provider {
+	case "anthropic":
+		if _, source, err := auth.GetAnthropicAPIKey(""); err == nil {
+			return found(source)
+		}
+		return doctorResult{
+			Name: "Credentials", Status: doctorFail, Detail: "no Anthropic API key",
+			Fix: "set ANTHROPIC_API_KEY, run `mcphost auth login anthropic`, or set provider-api-key",
+		}
+	case "ollama":
+		return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "not required for Ollama"}
+	case models.LlamaCppProvider:
+		return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "not required for llama.cpp"}
+	case "azure":
+		return checkDoctorAzureCredentials()
+	case models.CompatibleProvider:
+		if viper.GetString("openai-compatible.api-key") != "" {
+			return found("openai-compatible.api-key")
+		}
+		return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "none set, which most OpenAI-compatible servers accept"}
+	}
+
+	envVars := map[string][]string{
+		"openai":   {"OPENAI_API_KEY"},
+		"google":   {"GOOGLE_API_KEY", "GEMINI_API_KEY", "GOOGLE_GENERATIVE_AI_API_KEY"},
+		"groq":     {"GROQ_API_KEY"},
+		"xai":      {"XAI_API_KEY"},
+		"deepseek": {"DEEPSEEK_API_KEY"},
+	}[provider]
+	for _, name := range envVars {
+		if os.Getenv(name) != "" {
+			return found("$" + name)
+		}
+	}
+	if len(envVars) == 0 {
+		return doctorResult{
+			Name: "Credentials", Status: doctorFail, Detail: fmt.Sprintf("unsupported provider %q", provider),
+			Fix: "use one of: " + strings.Join(completionProviders, ", "),
+		}
+	}
+	return doctorResult{
+		Name: "Credentials", Status: doctorFail, Detail: "no API key for " + provider,
+		Fix: fmt.Sprintf("set %s or provider-api-key", envVars[0]),
+	}
+}
+
+// checkDoctorAzureCredentials looks for the Azure OpenAI API key, or gets a
+// Microsoft Entra ID token when there is none or --azure-auth asks for one
+func checkDoctorAzureCredentials() doctorResult {
+	method := viper.GetString("azure-auth")
+	hasKey := os.Getenv("AZURE_OPENAI_API_KEY") != ""
+	if method == auth.AzureAuthKey || (hasKey && (method == "" || method == auth.AzureAuthAuto)) {
+		if hasKey {
+			return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "found ($AZURE_OPENAI_API_KEY)"}
+		}
+		return doctorResult{
+			Name: "Credentials", Status: doctorFail, Detail: "no API key for azure",
+			Fix: "set AZURE_OPENAI_API_KEY or provider-api-key, or use --azure-auth auto for Microsoft Entra ID",
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	credential, err := auth.NewAzureCredential(method)
+	if err == nil {
+		_, err = credential.Token(ctx)
+	}
+	if err != nil {
+		return doctorResult{
+			Name: "Credentials", Status: doctorFail, Detail: err.Error(),
+			Fix: "set AZURE_OPENAI_API_KEY, run `az login`, or set AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET",
+		}
+	}
+	return doctorResult{Name: "Credentials", Status: doctorOK, Detail: "found (Microsoft Entra ID)"}
+}
+
+// pingProvider sends a tiny request to check the key, endpoint and model work
+func pingProvider(ctx context.Context, modelString string) doctorResult {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	providerConfig := BuildProviderConfig("")
+	providerConfig.MaxTokens = 16
+
+	start := time.Now()
+	result, err := models.CreateProvider(ctx, providerConfig)
+	if err == nil {
+		if result.Release != nil {
+			defer result.Release()
+		}
+		_, err = result.Model.Generate(ctx, []*schema.Message{schema.UserMessage("Reply with OK.")})
+	}
+	if err != nil {
+		return doctorResult{
+			Name: "Live request", Status: doctorFail, Detail: err.Error(),
+			Fix: "check the API key, --provider-url and network access; use --offline to skip this check",
+		}
+	}
+	return doctorResult{
+		Name: "Live request", Status: doctorOK,
+		Detail: fmt.Sprintf("%s answered in %s", modelString, time.Since(start).Round(time.Millisecond)),
+	}
+}
+
+// checkDoctorServers checks command prerequisites and starts each server
+func checkDoctorServers(ctx context.Context, mcpConfig *config.Config) []doctorResult {
+	names := make([]string, 0, len(mcpConfig.MCPServers))
+	for name := range mcpConfig.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []doctorResult
+	for _, name := range names {
+		serverConfig := mcpConfig.MCPServers[name]
+		if r, ok := checkStdioCommand(name, serverConfig); ok {
+			results = append(results, r)
+			if r.Status == doctorFail {
+				continue
+			}
+		}
+		if viper.GetBool("offline") {
+			continue
+		}
+		results = append(results, startDoctorServer(ctx, name, serverConfig, mcpConfig.Debug))
+	}
+	return results
+}
+
+// checkStdioCommand verifies that a stdio server's command (and the runtime it
+// needs) is on PATH. ok is false for servers that do not run a command.
+func checkStdioCommand(name string, serverConfig config.MCPServerConfig) (doctorResult, bool) {
+	if serverConfig.GetTransportType() != "stdio" {
+		return doctorResult{}, false
+	}
+	command := serverConfig.Command
+	if len(command) == 0 {
+		return doctorResult{
+			Name: name, Status: doctorFail, Detail: "local server has no command",
+			Fix: "set `command` for this server",
+		}, true
+	}
+
+	required := []string{command[0]}
+	if base := filepath.Base(command[0]); base == "npx" {
+		required = append(required, "node")
+	}
+	for _, bin := range required {
+		if _, err := exec.LookPath(bin); err != nil {
+			fix := runtimeHints[filepath.Base(bin)]
+			if fix == "" {
+				fix = fmt.Sprintf("install %s or use its full path in `command`", bin)
+			}
+			return doctorResult{
+				Name: name, Status: doctorFail, Detail: fmt.Sprintf("%s not found on PATH", bin), Fix: fix,
+			}, true
+		}
+	}
+	return doctorResult{Name: name, Status: doctorOK, Detail: command[0] + " found"}, true
+}
+
+// startDoctorServer connects to a single server and lists its tools
+func startDoctorServer(ctx context.Context, name string, serverConfig config.MCPServerConfig, debug bool) doctorResult {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	manager := tools.NewMCPToolManager()
+	defer manager.Close()
+	err := manager.LoadTools(ctx, &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{name: serverConfig},
+		Debug:      debug,
+	})
+	if err != nil {
+		fix := "run with --debug to see the server's output"
+		if ctx.Err() != nil {
+			fix = "the server did not answer within 30s; check it starts on its own"
+		}
+		return doctorResult{Name: name, Status: doctorFail, Detail: err.Error(), Fix: fix}
+	}
+
+	count := len(manager.GetToolDetails())
+	if count == 0 {
+		return doctorResult{
+			Name: name, Status: doctorWarn, Detail: "started but exposes no tools",
+			Fix: "check allowedTools/excludedTools for this server",
+		}
+	}
+	return doctorResult{Name: name, Status: doctorOK, Detail: fmt.Sprintf("started, %d tool(s)", count)}
+}
+
+// checkDoctorPlugins reports the plugins loaded from the plugin directory and
+// the executables there that failed the handshake
+func checkDoctorPlugins() []doctorResult {
+	plugins, errs := builtin.LoadPlugins()
+	registry := builtin.NewRegistry()
+	dir, _ := builtin.PluginDir()
+
+	var results []doctorResult
+	for _, plugin := range plugins {
+		r := doctorResult{Name: plugin.Name, Status: doctorOK, Detail: plugin.Path}
+		if plugin.Version != "" {
+			r.Detail = fmt.Sprintf("%s (version %s)", plugin.Path, plugin.Version)
+		}
+		if _, ok := registry.Plugin(plugin.Name); !ok {
+			r.Status = doctorWarn
+			r.Detail = fmt.Sprintf("%s is ignored: a builtin server is already called %s", plugin.Path, plugin.Name)
+			r.Fix = "rename the plugin"
+		}
+		results = append(results, r)
+	}
+	for _, err := range errs {
+		results = append(results, doctorResult{
+			Name: "Plugin", Status: doctorWarn, Detail: err.Error(),
+			Fix: fmt.Sprintf("fix or remove the executable in %s; plugins must print a manifest when run with %s", dir, builtin.PluginInfoFlag),
+		})
+	}
+	return results
+}
+
+// checkDoctorTerminal reports whether the interactive UI will render properly
+func checkDoctorTerminal() []doctorResult {
+	var results []doctorResult
+	stdinTTY := term.IsTerminal(int(os.Stdin.Fd()))
+	stdoutTTY := term.IsTerminal(int(os.Stdout.Fd()))
+	if stdinTTY && stdoutTTY {
+		results = append(results, doctorResult{Name: "Interactive", Status: doctorOK, Detail: "stdin and stdout are terminals"})
+	} else {
+		results = append(results, doctorResult{
+			Name: "Interactive", Status: doctorWarn, Detail: "not running in a terminal",
+			Fix: "interactive mode needs a TTY; use --prompt for scripts and pipes",
+		})
+	}
+
+	if stdoutTTY {
+		if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width < 60 {
+			results = append(results, doctorResult{
+				Name: "Width", Status: doctorWarn, Detail: fmt.Sprintf("%d columns", width),
+				Fix: "widen the terminal or use --compact",
+			})
+		}
+	}
+
+	profile := lipgloss.ColorProfile().Name()
+	r := doctorResult{Name: "Colors", Status: doctorOK, Detail: profile}
+	if stdoutTTY && profile == "Ascii" && os.Getenv("NO_COLOR") == "" {
+		r.Status = doctorWarn
+		r.Detail = fmt.Sprintf("no color support detected (TERM=%q)", os.Getenv("TERM"))
+		r.Fix = "set TERM=xterm-256color or use --compact"
+	}
+	return append(results, r)
+}
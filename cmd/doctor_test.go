@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+func TestCheckStdioCommand(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "my-server")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	if _, ok := checkStdioCommand("remote", config.MCPServerConfig{Type: "remote", URL: "http://example.com"}); ok {
+		t.Error("expected remote servers to be skipped")
+	}
+
+	r, ok := checkStdioCommand("local", config.MCPServerConfig{Type: "local", Command: []string{"my-server", "--flag"}})
+	if !ok || r.Status != doctorOK {
+		t.Errorf("expected command on PATH to pass, got %+v", r)
+	}
+
+	r, _ = checkStdioCommand("files", config.MCPServerConfig{Type: "local", Command: []string{"npx", "-y", "server"}})
+	if r.Status != doctorFail || !strings.Contains(r.Fix, "Node.js") {
+		t.Errorf("expected missing npx to suggest installing Node.js, got %+v", r)
+	}
+
+	r, _ = checkStdioCommand("py", config.MCPServerConfig{Type: "local", Command: []string{"uvx", "mcp-server-git"}})
+	if r.Status != doctorFail || !strings.Contains(r.Fix, "uv") {
+		t.Errorf("expected missing uvx to suggest installing uv, got %+v", r)
+	}
+}
+
+func TestCheckDoctorCredentials(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	t.Setenv("OPENAI_API_KEY", "")
+
+	if r := checkDoctorCredentials("openai"); r.Status != doctorFail || !strings.Contains(r.Fix, "OPENAI_API_KEY") {
+		t.Errorf("expected missing key to fail with a fix, got %+v", r)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	if r := checkDoctorCredentials("openai"); r.Status != doctorOK {
+		t.Errorf("expected key from environment to pass, got %+v", r)
+	}
+
+	if r := checkDoctorCredentials("ollama"); r.Status != doctorOK {
+		t.Errorf("expected ollama to need no key, got %+v", r)
+	}
+	if r := checkDoctorCredentials("nope"); r.Status != doctorFail {
+		t.Errorf("expected unknown provider to fail, got %+v", r)
+	}
+}
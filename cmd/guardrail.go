@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/guardrail"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// newGuard builds the response guardrail from the guardrail config section,
+// or returns nil when no check is configured. The model review uses the
+// guardrail task model, falling back to the main model.
+func newGuard(mcpAgent *agent.Agent) (*guardrail.Guard, error) {
+	var cfg guardrail.Config
+	if err := viper.UnmarshalKey("guardrail", &cfg); err != nil {
+		return nil, fmt.Errorf("invalid guardrail config: %v", err)
+	}
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	return guardrail.New(cfg, mcpAgent.TaskModel(config.TaskGuardrail))
+}
+
+// displayGuardrailVerdict tells the operator that a response was blocked or
+// annotated, and why
+func displayGuardrailVerdict(cli *ui.CLI, quiet bool, verdict guardrail.Verdict) {
+	message := fmt.Sprintf("Response annotated by guardrail: %s", strings.Join(verdict.Reasons, "; "))
+	if verdict.Action == guardrail.ActionBlock {
+		message = fmt.Sprintf("Response blocked by guardrail: %s", strings.Join(verdict.Reasons, "; "))
+	}
+	if cli == nil || quiet {
+		fmt.Fprintln(os.Stderr, message)
+		return
+	}
+	cli.DisplayError(fmt.Errorf("%s", message))
+}
@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"text/tabwriter"
+	"time"
 
+	"github.com/osi4iot/mcphost/internal/agent"
 	"github.com/osi4iot/mcphost/internal/hooks"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
+// asyncHookGrace is how long mcphost waits at exit for async hooks still running
+const asyncHookGrace = 5 * time.Second
+
 var hooksCmd = &cobra.Command{
 	Use:   "hooks",
 	Short: "Manage MCPHost hooks",
@@ -26,7 +33,7 @@ var hooksListCmd = &cobra.Command{
 		}
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "EVENT\tMATCHER\tCOMMAND\tTIMEOUT")
+		fmt.Fprintln(w, "EVENT\tMATCHER\tCOMMAND\tTIMEOUT\tASYNC")
 
 		for event, matchers := range config.Hooks {
 			for _, matcher := range matchers {
@@ -35,12 +42,32 @@ var hooksListCmd = &cobra.Command{
 					if hook.Timeout > 0 {
 						timeout = fmt.Sprintf("%ds", hook.Timeout)
 					}
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-						event, matcher.Matcher, hook.Command, timeout)
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\n",
+						event, matcher.Matcher, hook.Command, timeout, hook.Async)
 				}
 			}
 		}
 
+		for _, name := range config.Presets {
+			event, _, _ := hooks.PresetInfo(name)
+			fmt.Fprintf(w, "%s\t\tpreset: %s\t\t%v\n", event, name, false)
+		}
+
+		return w.Flush()
+	},
+}
+
+var hooksPresetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "List the builtin hook presets",
+	Long:  "List the builtin hook presets, which are enabled by name under hooks.presets in a hooks configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PRESET\tEVENT\tDESCRIPTION")
+		for _, name := range hooks.PresetNames() {
+			event, description, _ := hooks.PresetInfo(name)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, event, description)
+		}
 		return w.Flush()
 	},
 }
@@ -77,7 +104,7 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `mkdir -p "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs" && jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] $ " + .tool_input.command' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/bash-commands.log"`,
+								Command: `mkdir -p "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs" && jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] $ " + .tool_input.command' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/bash-commands.log"`,
 								Timeout: 5,
 							},
 						},
@@ -87,7 +114,7 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), event: "pre", tool: .tool_name, input: .tool_input}' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/all-tools.jsonl"`,
+								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), event: "pre", tool: .tool_name, input: .tool_input}' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/all-tools.jsonl"`,
 								Timeout: 5,
 							},
 						},
@@ -100,7 +127,7 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), cmd: .tool_input.command, exit: .tool_response._meta.exit, stdout: (.tool_response._meta.stdout | rtrimstr("\n") | .[0:100]), stderr: (.tool_response._meta.stderr | rtrimstr("\n"))}' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/bash-audit.jsonl"`,
+								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), cmd: .tool_input.command, exit: .tool_response._meta.exit, stdout: (.tool_response._meta.stdout | rtrimstr("\n") | .[0:100]), stderr: (.tool_response._meta.stderr | rtrimstr("\n"))}' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/bash-audit.jsonl"`,
 								Timeout: 5,
 							},
 						},
@@ -110,7 +137,7 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), tool: .tool_name, response_preview: (.tool_response | tostring | .[0:200])}' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/mcp-tools.jsonl"`,
+								Command: `jq -c '{time: now | strftime("%Y-%m-%d %H:%M:%S"), tool: .tool_name, response_preview: (.tool_response | tostring | .[0:200])}' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/mcp-tools.jsonl"`,
 								Timeout: 5,
 							},
 						},
@@ -122,7 +149,8 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `mkdir -p "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs" && jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] " + .prompt' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/prompts.log"`,
+								Command: `mkdir -p "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs" && jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] " + .prompt' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/prompts.log"`,
+								Async:   true,
 							},
 						},
 					},
@@ -133,7 +161,21 @@ var hooksInitCmd = &cobra.Command{
 						Hooks: []hooks.HookEntry{
 							{
 								Type:    "command",
-								Command: `jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] Session " + .session_id + " stopped"' >> "${XDG_CONFIG_HOME:-$HOME/.config}/mcphost/logs/sessions.log"`,
+								Command: `jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] Session " + .session_id + " stopped"' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/sessions.log"`,
+								Async:   true,
+							},
+						},
+					},
+				},
+				// Notification - runs when a model call or tool runs long, or
+				// mcphost waits for input; swap in notify-send or a webhook
+				hooks.Notification: {
+					{
+						Hooks: []hooks.HookEntry{
+							{
+								Type:    "command",
+								Command: `jq -r '"[" + (now | strftime("%Y-%m-%d %H:%M:%S")) + "] " + .message' >> "${MCPHOST_STATE_DIR:-$HOME/.mcphost}/logs/notifications.log"`,
+								Async:   true,
 							},
 						},
 					},
@@ -161,9 +203,54 @@ var hooksInitCmd = &cobra.Command{
 	},
 }
 
+// toolHookMetadata describes a tool for the input of tool hooks
+func toolHookMetadata(mcpAgent *agent.Agent, toolName string) hooks.ToolMetadata {
+	for _, detail := range mcpAgent.GetToolDetails() {
+		if detail.Name != toolName {
+			continue
+		}
+		metadata := hooks.ToolMetadata{ServerName: detail.Server}
+		if a := detail.Annotations; a != nil {
+			metadata.ToolAnnotations = &hooks.ToolAnnotations{
+				Title:           a.Title,
+				ReadOnlyHint:    a.ReadOnlyHint,
+				DestructiveHint: a.DestructiveHint,
+				IdempotentHint:  a.IdempotentHint,
+				OpenWorldHint:   a.OpenWorldHint,
+			}
+		}
+		return metadata
+	}
+	return hooks.ToolMetadata{}
+}
+
+// watchHookReports prints the executor's reports on slow, failed and async
+// hooks to stderr in debug mode
+func watchHookReports(executor *hooks.Executor) {
+	if executor == nil || !viper.GetBool("debug") {
+		return
+	}
+	go func() {
+		for report := range executor.Reports() {
+			fmt.Fprintf(os.Stderr, "Hook: %s\n", report)
+		}
+	}()
+}
+
+// waitForAsyncHooks gives async hooks still running a moment to finish
+// before mcphost exits
+func waitForAsyncHooks(executor *hooks.Executor) {
+	ctx, cancel := context.WithTimeout(context.Background(), asyncHookGrace)
+	defer cancel()
+	if err := executor.Wait(ctx); err != nil && viper.GetBool("debug") {
+		fmt.Fprintf(os.Stderr, "Hook: async hooks still running after %s were stopped\n", asyncHookGrace)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(hooksCmd)
 	hooksCmd.AddCommand(hooksListCmd)
 	hooksCmd.AddCommand(hooksValidateCmd)
 	hooksCmd.AddCommand(hooksInitCmd)
+	hooksCmd.AddCommand(hooksPresetsCmd)
 }
@@ -0,0 +1,514 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+var (
+	inspectURL       string
+	inspectTransport string
+	inspectHeaders   []string
+	inspectList      bool
+)
+
+// inspectBack is the select value used for "go back" entries
+const inspectBack = "\x00back"
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [server | -- command [args...]]",
+	Short: "Browse and exercise the tools, resources and prompts of one MCP server",
+	Long: `Connect to a single MCP server and interactively browse what it offers:
+tools with their input schemas, resources and resource templates, and prompts.
+Any of them can be exercised from the browser - call a tool with JSON
+arguments, read a resource, or render a prompt.
+
+The server can be one from the config file, a builtin server by name, or an
+ad-hoc server:
+
+  mcphost inspect filesystem
+  mcphost inspect fetch
+  mcphost inspect -- npx -y @modelcontextprotocol/server-everything
+  mcphost inspect --url https://example.com/mcp --header "Authorization: Bearer ..."
+
+With --list (or when not running in a terminal) everything is printed once
+instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		name, serverConfig, err := resolveInspectServer(args, cmd.ArgsLenAtDash())
+		if err != nil {
+			return err
+		}
+		return runInspect(ctx, name, serverConfig)
+	},
+}
+
+func init() {
+	inspectCmd.Flags().StringVar(&inspectURL, "url", "", "inspect an ad-hoc remote server at this URL")
+	inspectCmd.Flags().StringVar(&inspectTransport, "transport", "streamable", "transport for --url: streamable or sse")
+	inspectCmd.Flags().StringArrayVar(&inspectHeaders, "header", nil, "HTTP header for --url, as \"Name: value\" (repeatable)")
+	inspectCmd.Flags().BoolVar(&inspectList, "list", false, "print the server's tools, resources and prompts and exit")
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// resolveInspectServer works out which server to connect to from the
+// arguments: an ad-hoc command after "--", --url, a configured server or a
+// builtin server name
+func resolveInspectServer(args []string, dash int) (string, config.MCPServerConfig, error) {
+	switch {
+	case dash >= 0:
+		if dash > 0 {
+			return "", config.MCPServerConfig{}, fmt.Errorf("pass either a server name or a command after --, not both")
+		}
+		if len(args) == 0 {
+			return "", config.MCPServerConfig{}, fmt.Errorf("no command given after --")
+		}
+		return "adhoc", config.MCPServerConfig{Type: "local", Command: args}, nil
+	case inspectURL != "":
+		if len(args) > 0 {
+			return "", config.MCPServerConfig{}, fmt.Errorf("pass either a server name or --url, not both")
+		}
+		if inspectTransport != "streamable" && inspectTransport != "sse" {
+			return "", config.MCPServerConfig{}, fmt.Errorf("unsupported transport %q (use streamable or sse)", inspectTransport)
+		}
+		return "adhoc", config.MCPServerConfig{Transport: inspectTransport, URL: inspectURL, Headers: inspectHeaders}, nil
+	case len(args) != 1:
+		return "", config.MCPServerConfig{}, fmt.Errorf("specify a server name, --url, or a command after --")
+	}
+
+	name := args[0]
+	mcpConfig, err := config.LoadAndValidateConfig()
+	if err != nil {
+		return "", config.MCPServerConfig{}, fmt.Errorf("failed to load MCP config: %w", err)
+	}
+	if serverConfig, ok := mcpConfig.MCPServers[name]; ok {
+		return name, serverConfig, nil
+	}
+	for _, builtinName := range builtin.NewRegistry().ListServers() {
+		if builtinName == name {
+			return name, config.MCPServerConfig{Type: "builtin", Name: name}, nil
+		}
+	}
+
+	names := make([]string, 0, len(mcpConfig.MCPServers))
+	for configured := range mcpConfig.MCPServers {
+		names = append(names, configured)
+	}
+	sort.Strings(names)
+	return "", config.MCPServerConfig{}, fmt.Errorf("no server named %q (configured: %s)", name, strings.Join(names, ", "))
+}
+
+// inspection is everything a server lists
+type inspection struct {
+	Info      *mcp.InitializeResult
+	Tools     []mcp.Tool
+	Resources []mcp.Resource
+	Templates []mcp.ResourceTemplate
+	Prompts   []mcp.Prompt
+}
+
+func runInspect(ctx context.Context, name string, serverConfig config.MCPServerConfig) error {
+	loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	manager := tools.NewMCPToolManager()
+	defer manager.Close()
+	if err := manager.LoadTools(loadCtx, &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{name: serverConfig},
+	}); err != nil {
+		return err
+	}
+	mcpClient, info, ok := manager.GetClient(name)
+	if !ok {
+		return fmt.Errorf("server %s is not connected", name)
+	}
+
+	ins, err := loadInspection(ctx, mcpClient, info)
+	if err != nil {
+		return err
+	}
+	if inspectList || !isInteractiveTerminal() {
+		printInspection(os.Stdout, ins)
+		return nil
+	}
+	return browseInspection(ctx, mcpClient, ins)
+}
+
+// loadInspection lists the tools, resources and prompts the server advertises
+func loadInspection(ctx context.Context, c client.MCPClient, info *mcp.InitializeResult) (*inspection, error) {
+	ins := &inspection{Info: info}
+	caps := mcp.ServerCapabilities{}
+	if info != nil {
+		caps = info.Capabilities
+	}
+
+	if caps.Tools != nil || info == nil {
+		result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		ins.Tools = result.Tools
+	}
+	if caps.Resources != nil {
+		resources, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		ins.Resources = resources.Resources
+		// Templates are optional even for servers with resources
+		if templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{}); err == nil {
+			ins.Templates = templates.ResourceTemplates
+		}
+	}
+	if caps.Prompts != nil {
+		prompts, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+		ins.Prompts = prompts.Prompts
+	}
+
+	sort.Slice(ins.Tools, func(i, j int) bool { return ins.Tools[i].Name < ins.Tools[j].Name })
+	sort.Slice(ins.Prompts, func(i, j int) bool { return ins.Prompts[i].Name < ins.Prompts[j].Name })
+	return ins, nil
+}
+
+// printInspection writes a plain-text report of everything the server lists
+func printInspection(w io.Writer, ins *inspection) {
+	heading := lipgloss.NewStyle().Bold(true)
+	if ins.Info != nil {
+		fmt.Fprintf(w, "%s %s (protocol %s)\n", ins.Info.ServerInfo.Name, ins.Info.ServerInfo.Version, ins.Info.ProtocolVersion)
+		if ins.Info.Instructions != "" {
+			fmt.Fprintf(w, "\n%s\n", ins.Info.Instructions)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", heading.Render(fmt.Sprintf("Tools (%d)", len(ins.Tools))))
+	for _, t := range ins.Tools {
+		fmt.Fprintln(w)
+		writeToolDetail(w, t)
+	}
+
+	if len(ins.Resources) > 0 || len(ins.Templates) > 0 {
+		fmt.Fprintf(w, "\n%s\n", heading.Render(fmt.Sprintf("Resources (%d)", len(ins.Resources)+len(ins.Templates))))
+		for _, r := range ins.Resources {
+			fmt.Fprintf(w, "  %s  %s %s\n", r.URI, r.Name, r.MIMEType)
+		}
+		for _, r := range ins.Templates {
+			fmt.Fprintf(w, "  %s  %s (template)\n", templateURI(r), r.Name)
+		}
+	}
+
+	if len(ins.Prompts) > 0 {
+		fmt.Fprintf(w, "\n%s\n", heading.Render(fmt.Sprintf("Prompts (%d)", len(ins.Prompts))))
+		for _, p := range ins.Prompts {
+			writePromptDetail(w, p)
+		}
+	}
+}
+
+func writeToolDetail(w io.Writer, t mcp.Tool) {
+	fmt.Fprintf(w, "%s\n", lipgloss.NewStyle().Bold(true).Render(t.Name))
+	if t.Description != "" {
+		fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(strings.TrimSpace(t.Description), "\n", "\n  "))
+	}
+	fmt.Fprintf(w, "  input schema:\n%s\n", indentJSON(t.InputSchema, "    "))
+}
+
+func writePromptDetail(w io.Writer, p mcp.Prompt) {
+	fmt.Fprintf(w, "  %s", p.Name)
+	if p.Description != "" {
+		fmt.Fprintf(w, " - %s", p.Description)
+	}
+	fmt.Fprintln(w)
+	for _, arg := range p.Arguments {
+		required := ""
+		if arg.Required {
+			required = " (required)"
+		}
+		fmt.Fprintf(w, "      %s%s: %s\n", arg.Name, required, arg.Description)
+	}
+}
+
+func templateURI(r mcp.ResourceTemplate) string {
+	if r.URITemplate == nil || r.URITemplate.Template == nil {
+		return ""
+	}
+	return r.URITemplate.Raw()
+}
+
+// indentJSON pretty-prints v with every line prefixed
+func indentJSON(v any, prefix string) string {
+	data, err := json.MarshalIndent(v, prefix, "  ")
+	if err != nil {
+		return prefix + fmt.Sprint(v)
+	}
+	return prefix + string(data)
+}
+
+// argumentSkeleton builds example arguments from a tool's input schema, with
+// a zero value for each property, as a starting point for editing
+func argumentSkeleton(inputSchema mcp.ToolInputSchema) string {
+	args := make(map[string]any, len(inputSchema.Properties))
+	for name, prop := range inputSchema.Properties {
+		propSchema, _ := prop.(map[string]any)
+		var value any = ""
+		if def, ok := propSchema["default"]; ok {
+			value = def
+		} else {
+			switch propSchema["type"] {
+			case "number", "integer":
+				value = 0
+			case "boolean":
+				value = false
+			case "array":
+				value = []any{}
+			case "object":
+				value = map[string]any{}
+			}
+		}
+		args[name] = value
+	}
+	data, _ := json.MarshalIndent(args, "", "  ")
+	return string(data)
+}
+
+// browseInspection is the interactive top-level menu
+func browseInspection(ctx context.Context, c client.MCPClient, ins *inspection) error {
+	printInspectionHeader(ins)
+	for {
+		options := []huh.Option[string]{huh.NewOption(fmt.Sprintf("Tools (%d)", len(ins.Tools)), "tools")}
+		if len(ins.Resources) > 0 || len(ins.Templates) > 0 {
+			options = append(options, huh.NewOption(fmt.Sprintf("Resources (%d)", len(ins.Resources)+len(ins.Templates)), "resources"))
+		}
+		if len(ins.Prompts) > 0 {
+			options = append(options, huh.NewOption(fmt.Sprintf("Prompts (%d)", len(ins.Prompts)), "prompts"))
+		}
+		options = append(options, huh.NewOption("Reload lists", "reload"), huh.NewOption("Quit", "quit"))
+
+		var choice string
+		if err := huh.NewSelect[string]().Title("Inspect").Options(options...).Value(&choice).Run(); err != nil {
+			return ignoreAbort(err)
+		}
+
+		var err error
+		switch choice {
+		case "tools":
+			err = browseTools(ctx, c, ins.Tools)
+		case "resources":
+			err = browseResources(ctx, c, ins)
+		case "prompts":
+			err = browsePrompts(ctx, c, ins.Prompts)
+		case "reload":
+			var reloaded *inspection
+			if reloaded, err = loadInspection(ctx, c, ins.Info); err == nil {
+				ins = reloaded
+				fmt.Printf("Reloaded: %d tools, %d resources, %d prompts\n\n", len(ins.Tools), len(ins.Resources)+len(ins.Templates), len(ins.Prompts))
+			}
+		case "quit":
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func printInspectionHeader(ins *inspection) {
+	if ins.Info == nil {
+		return
+	}
+	fmt.Println(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("%s %s", ins.Info.ServerInfo.Name, ins.Info.ServerInfo.Version)))
+	fmt.Printf("protocol %s\n", ins.Info.ProtocolVersion)
+	if ins.Info.Instructions != "" {
+		fmt.Printf("\n%s\n", ins.Info.Instructions)
+	}
+	fmt.Println()
+}
+
+// ignoreAbort treats Ctrl+C / Esc in a form as a normal exit
+func ignoreAbort(err error) error {
+	if errors.Is(err, huh.ErrUserAborted) {
+		return nil
+	}
+	return err
+}
+
+func browseTools(ctx context.Context, c client.MCPClient, serverTools []mcp.Tool) error {
+	for {
+		options := []huh.Option[string]{huh.NewOption("← back", inspectBack)}
+		for _, t := range serverTools {
+			options = append(options, huh.NewOption(t.Name, t.Name))
+		}
+		var name string
+		if err := huh.NewSelect[string]().Title("Tools").Options(options...).Height(15).Value(&name).Run(); err != nil || name == inspectBack {
+			return ignoreAbort(err)
+		}
+
+		var selected mcp.Tool
+		for _, t := range serverTools {
+			if t.Name == name {
+				selected = t
+			}
+		}
+		writeToolDetail(os.Stdout, selected)
+		fmt.Println()
+
+		args := argumentSkeleton(selected.InputSchema)
+		call := true
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewConfirm().Title("Call "+selected.Name+"?").Value(&call),
+		), huh.NewGroup(
+			huh.NewText().
+				Title("Arguments (JSON)").
+				Lines(8).
+				Validate(func(s string) error {
+					if !json.Valid([]byte(s)) {
+						return fmt.Errorf("not valid JSON")
+					}
+					return nil
+				}).
+				Value(&args),
+		).WithHideFunc(func() bool { return !call })).Run()
+		if err != nil {
+			return ignoreAbort(err)
+		}
+		if !call {
+			continue
+		}
+
+		result, err := c.CallTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: selected.Name, Arguments: json.RawMessage(args)},
+		})
+		if err != nil {
+			fmt.Printf("✗ %s failed: %v\n\n", selected.Name, err)
+			continue
+		}
+		status := "✓"
+		if result.IsError {
+			status = "✗ (isError)"
+		}
+		fmt.Printf("%s %s result:\n%s\n\n", status, selected.Name, indentJSON(result, "  "))
+	}
+}
+
+func browseResources(ctx context.Context, c client.MCPClient, ins *inspection) error {
+	for {
+		options := []huh.Option[string]{huh.NewOption("← back", inspectBack)}
+		for _, r := range ins.Resources {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s  %s", r.URI, r.Name), r.URI))
+		}
+		for _, r := range ins.Templates {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s  %s (template)", templateURI(r), r.Name), templateURI(r)))
+		}
+		var uri string
+		if err := huh.NewSelect[string]().Title("Resources").Options(options...).Height(15).Value(&uri).Run(); err != nil || uri == inspectBack {
+			return ignoreAbort(err)
+		}
+
+		if strings.Contains(uri, "{") {
+			// Let the user fill in the template variables
+			if err := huh.NewInput().Title("Resource URI").Value(&uri).Run(); err != nil {
+				return ignoreAbort(err)
+			}
+		}
+
+		result, err := c.ReadResource(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: uri}})
+		if err != nil {
+			fmt.Printf("✗ failed to read %s: %v\n\n", uri, err)
+			continue
+		}
+		for _, content := range result.Contents {
+			switch content := content.(type) {
+			case mcp.TextResourceContents:
+				fmt.Printf("%s (%s)\n%s\n\n", content.URI, content.MIMEType, content.Text)
+			case mcp.BlobResourceContents:
+				fmt.Printf("%s (%s): %d bytes of base64 data\n\n", content.URI, content.MIMEType, len(content.Blob))
+			default:
+				fmt.Printf("%s\n\n", indentJSON(content, ""))
+			}
+		}
+	}
+}
+
+func browsePrompts(ctx context.Context, c client.MCPClient, prompts []mcp.Prompt) error {
+	for {
+		options := []huh.Option[string]{huh.NewOption("← back", inspectBack)}
+		for _, p := range prompts {
+			options = append(options, huh.NewOption(p.Name, p.Name))
+		}
+		var name string
+		if err := huh.NewSelect[string]().Title("Prompts").Options(options...).Height(15).Value(&name).Run(); err != nil || name == inspectBack {
+			return ignoreAbort(err)
+		}
+
+		var selected mcp.Prompt
+		for _, p := range prompts {
+			if p.Name == name {
+				selected = p
+			}
+		}
+		writePromptDetail(os.Stdout, selected)
+		fmt.Println()
+
+		values := make([]string, len(selected.Arguments))
+		var fields []huh.Field
+		for i, arg := range selected.Arguments {
+			input := huh.NewInput().Title(arg.Name).Description(arg.Description).Value(&values[i])
+			if arg.Required {
+				input = input.Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("%s is required", arg.Name)
+					}
+					return nil
+				})
+			}
+			fields = append(fields, input)
+		}
+		if len(fields) > 0 {
+			if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+				return ignoreAbort(err)
+			}
+		}
+
+		args := make(map[string]string, len(values))
+		for i, arg := range selected.Arguments {
+			if values[i] != "" {
+				args[arg.Name] = values[i]
+			}
+		}
+		result, err := c.GetPrompt(ctx, mcp.GetPromptRequest{Params: mcp.GetPromptParams{Name: name, Arguments: args}})
+		if err != nil {
+			fmt.Printf("✗ failed to get prompt %s: %v\n\n", name, err)
+			continue
+		}
+		for _, msg := range result.Messages {
+			if text, ok := msg.Content.(mcp.TextContent); ok {
+				fmt.Printf("[%s]\n%s\n\n", msg.Role, text.Text)
+			} else {
+				fmt.Printf("[%s]\n%s\n\n", msg.Role, indentJSON(msg.Content, ""))
+			}
+		}
+	}
+}
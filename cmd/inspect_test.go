@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+func TestArgumentSkeleton(t *testing.T) {
+	skeleton := argumentSkeleton(mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]any{
+			"path":    map[string]any{"type": "string"},
+			"limit":   map[string]any{"type": "integer"},
+			"recurse": map[string]any{"type": "boolean"},
+			"format":  map[string]any{"type": "string", "default": "text"},
+		},
+	})
+
+	var args map[string]any
+	if err := json.Unmarshal([]byte(skeleton), &args); err != nil {
+		t.Fatalf("skeleton is not valid JSON: %v", err)
+	}
+	if args["path"] != "" || args["limit"] != float64(0) || args["recurse"] != false || args["format"] != "text" {
+		t.Errorf("unexpected skeleton: %s", skeleton)
+	}
+}
+
+func TestResolveInspectServerAdHoc(t *testing.T) {
+	name, serverConfig, err := resolveInspectServer([]string{"npx", "-y", "server"}, 0)
+	if err != nil || name != "adhoc" || serverConfig.GetTransportType() != "stdio" || serverConfig.Command[0] != "npx" {
+		t.Errorf("unexpected ad-hoc command config: %q %+v %v", name, serverConfig, err)
+	}
+	if _, _, err := resolveInspectServer([]string{"fs", "npx"}, 1); err == nil {
+		t.Error("expected an error for a name and a command together")
+	}
+
+	inspectURL = "http://localhost:8080/mcp"
+	defer func() { inspectURL = "" }()
+	_, serverConfig, err = resolveInspectServer(nil, -1)
+	if err != nil || serverConfig.GetTransportType() != "streamable" || serverConfig.URL != inspectURL {
+		t.Errorf("unexpected ad-hoc URL config: %+v %v", serverConfig, err)
+	}
+}
+
+func TestLoadInspectionBuiltin(t *testing.T) {
+	ctx := context.Background()
+	manager := tools.NewMCPToolManager()
+	defer manager.Close()
+	err := manager.LoadTools(ctx, &config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"fs": {Type: "builtin", Name: "fs", Options: map[string]any{"allowed_directories": []string{t.TempDir()}}},
+	}})
+	if err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	c, info, ok := manager.GetClient("fs")
+	if !ok || info == nil {
+		t.Fatal("expected a connected client with server info")
+	}
+
+	ins, err := loadInspection(ctx, c, info)
+	if err != nil {
+		t.Fatalf("loadInspection failed: %v", err)
+	}
+	if len(ins.Tools) == 0 {
+		t.Fatal("expected the fs server to list tools")
+	}
+
+	var out bytes.Buffer
+	printInspection(&out, ins)
+	if !strings.Contains(out.String(), "read_file") || !strings.Contains(out.String(), "input schema") {
+		t.Errorf("report is missing tool details:\n%s", out.String())
+	}
+}
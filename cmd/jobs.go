@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/server"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+var (
+	jobsServer    string
+	jobsAPIKey    string
+	jobsFollow    bool
+	submitDetach  bool
+	submitSession string
+)
+
+// jobsPollInterval is how often waiting commands check on a job
+var jobsPollInterval = time.Second
+
+var submitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Run a prompt as a background job on an MCPHost server",
+	Long: `Submit a prompt to a running "mcphost serve" as a background job.
+
+The server runs the job with its own model and MCP servers, so it keeps going
+after this command exits. Without --detach, submit waits for the job, printing
+its tool calls to stderr and the final response to stdout. With --detach it
+prints the job ID and returns immediately; check on the job later with
+"mcphost jobs".
+
+The server is found with --server, $MCPHOST_SERVER or serve.addr from the
+config file, and authenticated with --api-key or $MCPHOST_API_KEY.
+
+Examples:
+  mcphost submit -p "Audit every Dockerfile in this repo" --detach
+  mcphost submit -p "Continue" --session sess_1234
+  mcphost submit -p "Run the tests" --workdir /src/api --env GOFLAGS=-count=1
+  MCPHOST_SERVER=http://build-box:8080 mcphost submit -p "Run the nightly report"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompt := viper.GetString("prompt")
+		if prompt == "" {
+			return fmt.Errorf("a prompt is required (use --prompt/-p)")
+		}
+
+		// The directory is on the server's host, so it is checked there
+		env, err := workspace.ParseEnv(envFlags)
+		if err != nil {
+			return err
+		}
+
+		client := newJobsClient()
+		var job server.Job
+		req := server.JobRequest{Prompt: prompt, SessionID: submitSession, WorkDir: workDirFlag, Env: env}
+		if err := client.do(cmd.Context(), http.MethodPost, "/v1/jobs", req, &job); err != nil {
+			return err
+		}
+		if submitDetach {
+			fmt.Println(job.ID)
+			fmt.Fprintf(os.Stderr, "Job submitted; check on it with: mcphost jobs status %s\n", job.ID)
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := client.followLogs(ctx, job.ID, os.Stderr); err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintf(os.Stderr, "\nStopped waiting; job %s keeps running on the server\n", job.ID)
+				return nil
+			}
+			return err
+		}
+		return client.printResult(cmd.Context(), job.ID)
+	},
+}
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background jobs on an MCPHost server",
+	Long: `List, inspect, cancel and delete the background jobs of a running "mcphost serve".
+
+Jobs are submitted with "mcphost submit". The server is found with --server,
+$MCPHOST_SERVER or serve.addr from the config file, and authenticated with
+--api-key or $MCPHOST_API_KEY.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List background jobs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var resp struct {
+			Jobs []server.Job `json:"jobs"`
+		}
+		if err := newJobsClient().do(cmd.Context(), http.MethodGet, "/v1/jobs", nil, &resp); err != nil {
+			return err
+		}
+		if len(resp.Jobs) == 0 {
+			fmt.Println("No jobs")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tSUBMITTED\tDURATION\tPROMPT")
+		for _, job := range resp.Jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				job.ID, job.Status, job.CreatedAt.Local().Format("2006-01-02 15:04"), jobDuration(&job), truncateLine(job.Prompt, 50))
+		}
+		return w.Flush()
+	},
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show a job's status and result",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var job server.Job
+		if err := newJobsClient().do(cmd.Context(), http.MethodGet, "/v1/jobs/"+args[0], nil, &job); err != nil {
+			return err
+		}
+
+		fmt.Printf("Job:       %s\n", job.ID)
+		fmt.Printf("Status:    %s\n", job.Status)
+		fmt.Printf("Session:   %s\n", job.SessionID)
+		fmt.Printf("Submitted: %s\n", job.CreatedAt.Local().Format(time.DateTime))
+		if d := jobDuration(&job); d != "" {
+			fmt.Printf("Duration:  %s\n", d)
+		}
+		fmt.Printf("Prompt:    %s\n", truncateLine(job.Prompt, 200))
+		if job.Error != "" {
+			fmt.Printf("Error:     %s\n", job.Error)
+		}
+		if job.Result != nil {
+			fmt.Printf("Tokens:    %d in, %d out\n", job.Result.InputTokens, job.Result.OutputTokens)
+			fmt.Printf("\n%s\n", job.Result.Response)
+		}
+		return nil
+	},
+}
+
+var jobsLogsCmd = &cobra.Command{
+	Use:   "logs <id>",
+	Short: "Show a job's tool calls and response",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newJobsClient()
+		if jobsFollow {
+			ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := client.followLogs(ctx, args[0], os.Stdout); err != nil && ctx.Err() == nil {
+				return err
+			}
+			return nil
+		}
+
+		var logs server.JobLogs
+		if err := client.do(cmd.Context(), http.MethodGet, "/v1/jobs/"+args[0]+"/logs", nil, &logs); err != nil {
+			return err
+		}
+		for _, event := range logs.Events {
+			printJobEvent(os.Stdout, event)
+		}
+		if !jobDone(logs.Status) {
+			fmt.Printf("(job is %s; use --follow to keep watching)\n", logs.Status)
+		}
+		return nil
+	},
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a queued or running job",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := newJobsClient().do(cmd.Context(), http.MethodPost, "/v1/jobs/"+args[0]+"/cancel", nil, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Cancelled job %s\n", args[0])
+		return nil
+	},
+}
+
+var jobsDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a finished job and its logs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := newJobsClient().do(cmd.Context(), http.MethodDelete, "/v1/jobs/"+args[0], nil, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted job %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	addJobsClientFlags(submitCmd.Flags())
+	submitCmd.Flags().BoolVar(&submitDetach, "detach", false, "print the job ID and return without waiting for the job")
+	submitCmd.Flags().StringVar(&submitSession, "session", "", "server session to continue (default: a new session)")
+
+	addJobsClientFlags(jobsCmd.PersistentFlags())
+	jobsLogsCmd.Flags().BoolVarP(&jobsFollow, "follow", "f", false, "keep printing events until the job finishes")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsLogsCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+	jobsCmd.AddCommand(jobsDeleteCmd)
+	rootCmd.AddCommand(submitCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
+
+func addJobsClientFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&jobsServer, "server", "", "URL of the MCPHost server (default: $MCPHOST_SERVER or serve.addr)")
+	flags.StringVar(&jobsAPIKey, "api-key", "", "API key for the server (default: $MCPHOST_API_KEY)")
+}
+
+// jobsClient talks to the job endpoints of an "mcphost serve" instance
+type jobsClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func newJobsClient() *jobsClient {
+	baseURL := jobsServer
+	if baseURL == "" {
+		baseURL = os.Getenv("MCPHOST_SERVER")
+	}
+	if baseURL == "" {
+		baseURL = serveAddrURL(viper.GetString("serve.addr"))
+	}
+	apiKey := jobsAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("MCPHOST_API_KEY")
+	}
+	return &jobsClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// serveAddrURL returns the URL to reach a server listening on addr
+func serveAddrURL(addr string) string {
+	if addr == "" {
+		addr = "127.0.0.1:8080"
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response
+// into out when it is not nil
+func (c *jobsClient) do(ctx context.Context, method, path string, body, out any) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return fmt.Errorf("cannot reach MCPHost server at %s (is \"mcphost serve\" running?): %v", c.baseURL, err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, apiErr.Error)
+		}
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// followLogs prints the job's events to w as they arrive until it finishes
+func (c *jobsClient) followLogs(ctx context.Context, id string, w io.Writer) error {
+	after := 0
+	for {
+		var logs server.JobLogs
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/jobs/%s/logs?after=%d", id, after), nil, &logs); err != nil {
+			return err
+		}
+		for _, event := range logs.Events {
+			printJobEvent(w, event)
+		}
+		after = logs.Next
+		if jobDone(logs.Status) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jobsPollInterval):
+		}
+	}
+}
+
+// printResult prints a finished job's response, or returns its error
+func (c *jobsClient) printResult(ctx context.Context, id string) error {
+	var job server.Job
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs/"+id, nil, &job); err != nil {
+		return err
+	}
+	switch job.Status {
+	case server.JobSucceeded:
+		fmt.Println(job.Result.Response)
+		return nil
+	case server.JobCancelled:
+		return fmt.Errorf("job %s was cancelled", id)
+	default:
+		return fmt.Errorf("job %s %s: %s", id, job.Status, job.Error)
+	}
+}
+
+// printJobEvent writes one log event as a line
+func printJobEvent(w io.Writer, event server.JobEvent) {
+	label := event.Type
+	if event.Tool != "" {
+		label += " " + event.Tool
+	}
+	fmt.Fprintf(w, "%s  %s  %s\n", event.Time.Local().Format(time.TimeOnly), label, truncateLine(event.Message, 100))
+}
+
+func jobDone(status string) bool {
+	return (&server.Job{Status: status}).Done()
+}
+
+// jobDuration returns how long the job ran, or has been running
+func jobDuration(job *server.Job) string {
+	if job.StartedAt == nil {
+		return ""
+	}
+	end := time.Now()
+	if job.FinishedAt != nil {
+		end = *job.FinishedAt
+	}
+	return end.Sub(*job.StartedAt).Round(time.Second).String()
+}
+
+// truncateLine flattens s to one line of at most n runes
+func truncateLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
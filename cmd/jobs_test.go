@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/server"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// jobsTestAgent calls one tool and echoes the prompt
+type jobsTestAgent struct{}
+
+func (jobsTestAgent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*schema.Message,
+	onToolCall agent.ToolCallHandler, onToolExecution agent.ToolExecutionHandler, onToolResult agent.ToolResultHandler,
+	onResponse agent.ResponseHandler, onToolCallContent agent.ToolCallContentHandler, onStreamingResponse agent.StreamingResponseHandler) (*agent.GenerateWithLoopResult, error) {
+	onToolCall("fs__list_directory", `{"path":"."}`)
+	onToolResult("fs__list_directory", `{"path":"."}`, "go.mod\nmain.go", false)
+	response := schema.AssistantMessage("done: "+messages[len(messages)-1].Content, nil)
+	return &agent.GenerateWithLoopResult{FinalResponse: response, ConversationMessages: append(messages, response)}, nil
+}
+
+func (jobsTestAgent) GetServerStatuses() []tools.ServerStatus { return nil }
+func (jobsTestAgent) GetToolDetails() []tools.ToolDetail      { return nil }
+
+func TestJobsClient(t *testing.T) {
+	srv := httptest.NewServer(server.New(&server.Config{
+		Agent:   jobsTestAgent{},
+		APIKeys: []server.APIKey{{Name: "cli", Key: "secret"}},
+	}).Handler())
+	defer srv.Close()
+	jobsPollInterval = 10 * time.Millisecond
+
+	client := &jobsClient{baseURL: srv.URL, apiKey: "secret", http: srv.Client()}
+	var job server.Job
+	if err := client.do(context.Background(), http.MethodPost, "/v1/jobs", server.JobRequest{Prompt: "list files"}, &job); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := client.followLogs(context.Background(), job.ID, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"tool_call fs__list_directory", "tool_result fs__list_directory  go.mod main.go", "response  done: list files"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("logs missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Server errors are reported with their message
+	err := client.do(context.Background(), http.MethodPost, "/v1/jobs/"+job.ID+"/cancel", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "already succeeded") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	client.apiKey = ""
+	if err := client.do(context.Background(), http.MethodGet, "/v1/jobs", nil, nil); err == nil || !strings.Contains(err.Error(), "missing API key") {
+		t.Errorf("expected auth error, got %v", err)
+	}
+}
+
+func TestServeAddrURL(t *testing.T) {
+	for addr, want := range map[string]string{
+		"":               "http://127.0.0.1:8080",
+		":9000":          "http://127.0.0.1:9000",
+		"0.0.0.0:8080":   "http://127.0.0.1:8080",
+		"localhost:8080": "http://localhost:8080",
+		"[::1]:8080":     "http://[::1]:8080",
+	} {
+		if got := serveAddrURL(addr); got != want {
+			t.Errorf("serveAddrURL(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/webhook"
+)
+
+// networkBuiltins are builtin servers whose whole purpose is reaching the network
+var networkBuiltins = map[string]bool{
+	"fetch": true,
+	"http":  true,
+	"image": true, // calls an image generation API
+}
+
+// isLocalModel reports whether the model in modelString ("provider:model")
+// runs locally
+func isLocalModel(modelString string) bool {
+	provider, _, _ := strings.Cut(modelString, ":")
+	return models.IsLocal(provider)
+}
+
+// checkLocalOnly returns an error listing everything in the setup that would
+// leave the machine: a cloud provider, an Ollama host that is not on this
+// machine, a cloud shadow model, remote webhooks, a remote SMTP server,
+// remote MCP servers and network builtins. It checks configuration
+// only; what a local stdio server does once started is up to that server.
+func checkLocalOnly(modelString, providerURL string, mcpConfig *config.Config) error {
+	var problems []string
+
+	provider, _, _ := strings.Cut(modelString, ":")
+	switch provider {
+	case "ollama":
+		host := providerURL
+		if host == "" {
+			host = os.Getenv("OLLAMA_HOST")
+		}
+		if host != "" && !isLocalAddress(host) {
+			problems = append(problems, fmt.Sprintf("ollama host %q is not on this machine", host))
+		}
+	case models.LlamaCppProvider:
+		if providerURL != "" && !isLocalAddress(providerURL) {
+			problems = append(problems, fmt.Sprintf("llama.cpp server %q is not on this machine", providerURL))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("model %q uses a cloud provider; only ollama and llamacpp models run locally", modelString))
+	}
+	if shadowModel := viper.GetString("shadow-model"); shadowModel != "" && !isLocalModel(shadowModel) {
+		problems = append(problems, fmt.Sprintf("shadow model %q uses a cloud provider", shadowModel))
+	}
+	if host := viper.GetString("smtp.host"); host != "" && !isLocalAddress(host) {
+		problems = append(problems, fmt.Sprintf("smtp host %q is not on this machine", host))
+	} else if host == "" && len(viper.GetStringSlice("email-results")) > 0 {
+		problems = append(problems, "--email-results mails results without an smtp host on this machine")
+	}
+	// An invalid webhooks section fails when the webhooks are loaded
+	var hooks []webhook.Config
+	if viper.UnmarshalKey("webhooks", &hooks) == nil {
+		for _, hook := range hooks {
+			if !isLocalAddress(hook.URL) {
+				problems = append(problems, fmt.Sprintf("webhook %s is not on this machine", hook.URL))
+			}
+		}
+	}
+
+	if mcpConfig != nil {
+		tasks := make([]string, 0, len(mcpConfig.Models))
+		for task := range mcpConfig.Models {
+			tasks = append(tasks, task)
+		}
+		sort.Strings(tasks)
+
+		for _, task := range tasks {
+			// The default task model is the main model, checked above
+			taskModel := mcpConfig.Models[task]
+			if task != config.TaskDefault && !isLocalModel(taskModel) {
+				problems = append(problems, fmt.Sprintf("%s model %q uses a cloud provider", task, taskModel))
+			}
+		}
+
+		steps := make([]string, 0, len(mcpConfig.Router))
+		for step := range mcpConfig.Router {
+			steps = append(steps, step)
+		}
+		sort.Strings(steps)
+
+		for _, step := range steps {
+			if routeModel := mcpConfig.Router[step]; !isLocalModel(routeModel) {
+				problems = append(problems, fmt.Sprintf("router %s model %q uses a cloud provider", step, routeModel))
+			}
+		}
+
+		names := make([]string, 0, len(mcpConfig.MCPServers))
+		for name := range mcpConfig.MCPServers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			server := mcpConfig.MCPServers[name]
+			switch transport := server.GetTransportType(); transport {
+			case "stdio":
+			case "inprocess":
+				if networkBuiltins[server.Name] {
+					problems = append(problems, fmt.Sprintf("server %q uses the %s builtin, which makes network requests", name, server.Name))
+				}
+				if summarizer, _ := server.Options["summarizer_model"].(string); summarizer != "" && !isLocalModel(summarizer) {
+					problems = append(problems, fmt.Sprintf("server %q summarizes with %q, which uses a cloud provider", name, summarizer))
+				}
+			case "openapi":
+				if strings.Contains(server.Spec, "://") && !isLocalAddress(server.Spec) {
+					problems = append(problems, fmt.Sprintf("server %q loads its OpenAPI spec from %s", name, server.Spec))
+				}
+				if server.URL == "" {
+					problems = append(problems, fmt.Sprintf("server %q calls the API its OpenAPI spec points at; set url to a local address", name))
+				} else if !isLocalAddress(server.URL) {
+					problems = append(problems, fmt.Sprintf("server %q calls the API at %s", name, server.URL))
+				}
+			default:
+				if !isLocalAddress(server.URL) {
+					problems = append(problems, fmt.Sprintf("server %q connects to %s over %s", name, server.URL, transport))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("--local-only: refusing to start:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// isLocalAddress reports whether a URL or host[:port] points at this machine
+func isLocalAddress(address string) bool {
+	if address == "" {
+		return false
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+func TestCheckLocalOnly(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+
+	local := &config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"files":  {Type: "local", Command: []string{"mcp-files"}},
+		"fs":     {Type: "builtin", Name: "fs"},
+		"devbox": {Type: "remote", URL: "http://127.0.0.1:8080/mcp"},
+		"api":    {Type: "openapi", Spec: "./openapi.yaml", URL: "http://localhost:3000"},
+	}}
+	if err := checkLocalOnly("ollama:qwen3", "", local); err != nil {
+		t.Errorf("expected local setup to pass, got %v", err)
+	}
+	if err := checkLocalOnly("ollama:qwen3", "http://localhost:11434", local); err != nil {
+		t.Errorf("expected localhost Ollama to pass, got %v", err)
+	}
+	if err := checkLocalOnly("llamacpp:/models/qwen3.gguf", "", local); err != nil {
+		t.Errorf("expected a GGUF file to pass, got %v", err)
+	}
+	if err := checkLocalOnly("llamacpp:qwen3.gguf", "http://gpu-box.internal:8080", local); err == nil || !strings.Contains(err.Error(), "llama.cpp server") {
+		t.Errorf("expected a remote llama.cpp server to be refused, got %v", err)
+	}
+
+	remote := &config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"search": {Type: "remote", URL: "https://api.example.com/mcp"},
+		"web":    {Type: "builtin", Name: "fetch"},
+		"legacy": {Transport: "sse", URL: "https://sse.example.com"},
+		"rest":   {Type: "openapi", Spec: "./openapi.yaml"},
+		"paint":  {Type: "builtin", Name: "image"},
+		"intra":  {Type: "builtin", Name: "http", Options: map[string]any{"summarizer_model": "openai:gpt-4o-mini"}},
+	}}
+	err := checkLocalOnly("anthropic:claude-sonnet-4-20250514", "", remote)
+	if err == nil {
+		t.Fatal("expected remote setup to be refused")
+	}
+	for _, want := range []string{"cloud provider", `"search"`, `"web"`, `"legacy"`, `"rest"`, `"paint"`, `"intra" summarizes`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	cloudTask := &config.Config{Models: map[string]string{"summarize": "openai:gpt-4o-mini"}}
+	if err := checkLocalOnly("ollama:qwen3", "", cloudTask); err == nil || !strings.Contains(err.Error(), "summarize model") {
+		t.Errorf("expected cloud summarize model to be refused, got %v", err)
+	}
+	cloudRoute := &config.Config{Router: map[string]string{"tools": "ollama:qwen2.5", "final": "anthropic:claude-sonnet-4-20250514"}}
+	if err := checkLocalOnly("ollama:qwen3", "", cloudRoute); err == nil || !strings.Contains(err.Error(), "router final model") || strings.Contains(err.Error(), "router tools") {
+		t.Errorf("expected only the cloud final model to be refused, got %v", err)
+	}
+
+	defer viper.Reset()
+	viper.Set("shadow-model", "openai:gpt-4o-mini")
+	if err := checkLocalOnly("ollama:qwen3", "", local); err == nil || !strings.Contains(err.Error(), "shadow model") {
+		t.Errorf("expected cloud shadow model to be refused, got %v", err)
+	}
+	viper.Set("shadow-model", "")
+	viper.Set("webhooks", []map[string]any{
+		{"url": "http://127.0.0.1:9000/hooks"},
+		{"url": "https://hooks.example.com/mcphost"},
+	})
+	if err := checkLocalOnly("ollama:qwen3", "", local); err == nil || !strings.Contains(err.Error(), "hooks.example.com") || strings.Contains(err.Error(), "127.0.0.1") {
+		t.Errorf("expected only the remote webhook to be refused, got %v", err)
+	}
+	viper.Set("webhooks", nil)
+
+	viper.Set("email-results", []string{"me@example.com"})
+	if err := checkLocalOnly("ollama:qwen3", "", local); err == nil || !strings.Contains(err.Error(), "--email-results") {
+		t.Errorf("expected emailed results without an smtp host to be refused, got %v", err)
+	}
+	viper.Set("smtp.host", "localhost")
+	if err := checkLocalOnly("ollama:qwen3", "", local); err != nil {
+		t.Errorf("expected a local SMTP server to pass, got %v", err)
+	}
+	viper.Set("smtp.host", "smtp.example.com")
+	if err := checkLocalOnly("ollama:qwen3", "", local); err == nil || !strings.Contains(err.Error(), "smtp.example.com") {
+		t.Errorf("expected a remote SMTP server to be refused, got %v", err)
+	}
+	viper.Set("email-results", nil)
+	viper.Set("smtp.host", "")
+
+	t.Setenv("OLLAMA_HOST", "gpu-box.internal:11434")
+	if err := checkLocalOnly("ollama:qwen3", "", nil); err == nil || !strings.Contains(err.Error(), "gpu-box.internal") {
+		t.Errorf("expected remote Ollama host to be refused, got %v", err)
+	}
+}
+
+func TestIsLocalAddress(t *testing.T) {
+	cases := map[string]bool{
+		"http://localhost:11434": true,
+		"127.0.0.1:11434":        true,
+		"http://[::1]:8080/mcp":  true,
+		"0.0.0.0":                true,
+		"https://api.openai.com": false,
+		"10.0.0.5:11434":         false,
+		"":                       false,
+	}
+	for address, want := range cases {
+		if got := isLocalAddress(address); got != want {
+			t.Errorf("isLocalAddress(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestLocalOnlySkipsRegistryRefresh(t *testing.T) {
+	defer viper.Reset()
+	if !refreshesModelRegistry() {
+		t.Error("expected the registry to be refreshed by default")
+	}
+	viper.Set("local-only", true)
+	if refreshesModelRegistry() {
+		t.Error("expected --local-only to skip the registry refresh")
+	}
+	viper.Set("local-only", false)
+	viper.Set("offline", true)
+	if refreshesModelRegistry() {
+		t.Error("expected --offline to skip the registry refresh")
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// withMemory adds the remembered preferences to the system prompt unless
+// --no-memory is set
+func withMemory(systemPrompt string) (string, error) {
+	if viper.GetBool("no-memory") {
+		return systemPrompt, nil
+	}
+	prompt, err := config.WithMemory(systemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to load remembered preferences: %v", err)
+	}
+	return prompt, nil
+}
+
+// parseMemoryCommand returns the subcommand of an interactive "/memory" line
+func parseMemoryCommand(input string) (string, bool) {
+	rest, found := strings.CutPrefix(input, "/memory")
+	if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// handleMemoryCommand runs /memory view, edit or clear. Changes apply to new
+// sessions, since the current system prompt is already set.
+func handleMemoryCommand(cli *ui.CLI, subcommand string) {
+	path, err := config.MemoryPath()
+	if err != nil {
+		cli.DisplayError(err)
+		return
+	}
+
+	switch subcommand {
+	case "", "view":
+		memory, err := config.LoadMemory()
+		if err != nil {
+			cli.DisplayError(err)
+			return
+		}
+		if memory == "" {
+			cli.DisplayInfo(fmt.Sprintf("No remembered preferences yet. Add the memory builtin server so the model can save them, or use /memory edit.\n\nFile: %s", path))
+			return
+		}
+		cli.DisplayInfo(fmt.Sprintf("## Remembered Preferences\n\n%s\n\nFile: %s", memory, path))
+	case "edit":
+		if err := editMemory(path); err != nil {
+			cli.DisplayError(err)
+			return
+		}
+		cli.DisplayInfo("Memory saved. Changes apply to new sessions.")
+	case "clear":
+		if err := config.ClearMemory(); err != nil {
+			cli.DisplayError(err)
+			return
+		}
+		cli.DisplayInfo("Remembered preferences cleared. Changes apply to new sessions.")
+	default:
+		cli.DisplayError(fmt.Errorf("usage: /memory [view|edit|clear]"))
+	}
+}
+
+// editMemory opens the memory file in $VISUAL or $EDITOR, creating it first
+func editMemory(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	file.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %v", fields[0], err)
+	}
+	return nil
+}
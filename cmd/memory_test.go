@@ -0,0 +1,22 @@
+package cmd
+
+import "testing"
+
+func TestParseMemoryCommand(t *testing.T) {
+	tests := []struct {
+		input, subcommand string
+		ok                bool
+	}{
+		{"/memory", "", true},
+		{"/memory view", "view", true},
+		{"/memory  clear ", "clear", true},
+		{"/memoryview", "", false},
+		{"/tools", "", false},
+	}
+	for _, tt := range tests {
+		subcommand, ok := parseMemoryCommand(tt.input)
+		if ok != tt.ok || subcommand != tt.subcommand {
+			t.Errorf("parseMemoryCommand(%q) = %q, %v", tt.input, subcommand, ok)
+		}
+	}
+}
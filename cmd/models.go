@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// modelsUpdateTimeout bounds a download of the models.dev data
+const modelsUpdateTimeout = time.Minute
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Manage the model registry",
+	Long: `Commands for the registry of known models, used to validate model names and
+to look up their prices, context windows and capabilities.
+
+The registry is built into mcphost and extended with the models.dev data
+cached in the state directory.`,
+}
+
+var modelsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download the latest models.dev data to the registry cache",
+	Long: `Download the latest model data from models.dev to the registry cache, so
+models released after this build are known.
+
+mcphost also refreshes the cache in the background at startup once it is a
+week old, unless --offline is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.StatePath("cache", "models.json")
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), modelsUpdateTimeout)
+		defer cancel()
+		providers, count, err := models.UpdateModelsCache(ctx, path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Updated the model registry: %d models from %d providers (%s)\n", count, providers, path)
+		return nil
+	},
+}
+
+func init() {
+	modelsCmd.AddCommand(modelsUpdateCmd)
+	rootCmd.AddCommand(modelsCmd)
+}
+
+// loadModelRegistry extends the built-in model registry with the cached
+// models.dev data, and refreshes a stale cache in the background for the
+// next run when refreshesModelRegistry allows it
+func loadModelRegistry() {
+	path, err := config.StatePath("cache", "models.json")
+	if err != nil {
+		return
+	}
+	if err := models.LoadModelsCache(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Ignoring the model registry cache: %v\n", err)
+	}
+	if !refreshesModelRegistry() || !models.ModelsCacheStale(path) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), modelsUpdateTimeout)
+		defer cancel()
+		if _, _, err := models.UpdateModelsCache(ctx, path); err != nil && debugMode {
+			fmt.Fprintf(os.Stderr, "Warning: Could not refresh the model registry: %v\n", err)
+		}
+	}()
+}
+
+// refreshesModelRegistry reports whether startup may download the models.dev
+// data: not with --offline, and not with --local-only, which promises nothing
+// leaves the machine
+func refreshesModelRegistry() bool {
+	return !viper.GetBool("offline") && !viper.GetBool("local-only")
+}
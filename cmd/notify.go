@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/email"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/postmortem"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/webhook"
+)
+
+// loadWebhooks reads the webhooks config section
+func loadWebhooks() (*webhook.Notifier, error) {
+	if !viper.IsSet("webhooks") {
+		return nil, nil
+	}
+	var hooks []webhook.Config
+	if err := viper.UnmarshalKey("webhooks", &hooks); err != nil {
+		return nil, fmt.Errorf("invalid webhooks config: %v", err)
+	}
+	if err := webhook.Validate(hooks); err != nil {
+		return nil, err
+	}
+	return webhook.New(hooks), nil
+}
+
+// runNotifiers tell other systems how a non-interactive run went
+type runNotifiers struct {
+	webhooks *webhook.Notifier
+	emailTo  []string
+	smtp     email.SMTPConfig
+}
+
+// loadRunNotifiers reads the webhooks and, when --email-results is set, the
+// smtp config section
+func loadRunNotifiers() (*runNotifiers, error) {
+	webhooks, err := loadWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	n := &runNotifiers{webhooks: webhooks}
+
+	if recipients := viper.GetStringSlice("email-results"); len(recipients) > 0 {
+		if n.emailTo, err = email.ParseRecipients(recipients); err != nil {
+			return nil, err
+		}
+		if err := viper.UnmarshalKey("smtp", &n.smtp); err != nil {
+			return nil, fmt.Errorf("invalid smtp config: %v", err)
+		}
+		if err := n.smtp.Validate(); err != nil {
+			return nil, fmt.Errorf("--email-results needs SMTP settings in the config file: %v", err)
+		}
+	}
+	return n, nil
+}
+
+// runDone reports the outcome of a non-interactive run. conversation holds
+// the run's new messages; runErr is the error it failed with, and report its
+// post-mortem.
+func (n *runNotifiers) runDone(ctx context.Context, prompt string, started time.Time,
+	conversation []*schema.Message, runErr error, report *postmortem.Report, sessionManager *session.Manager) {
+	if n.webhooks == nil && len(n.emailTo) == 0 {
+		return
+	}
+	payload := runPayload(prompt, started, conversation, runErr)
+	payload.PostMortem = report
+	if sessionManager != nil {
+		payload.Session = sessionManager.GetFilePath()
+	}
+
+	if n.webhooks != nil {
+		// Deliver even when the run was cancelled, but don't hold up exit forever
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := n.webhooks.Send(sendCtx, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if len(n.emailTo) > 0 {
+		msg := resultEmail(payload, runToolCalls(conversation))
+		msg.To = n.emailTo
+		if err := email.Send(n.smtp, msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to email results: %v\n", err)
+		}
+	}
+}
+
+// runPayload summarizes a finished run
+func runPayload(prompt string, started time.Time, conversation []*schema.Message, runErr error) *webhook.Payload {
+	modelName := viper.GetString("model")
+	payload := &webhook.Payload{
+		Event:      webhook.EventRun,
+		Status:     webhook.StatusSucceeded,
+		Model:      modelName,
+		Prompt:     prompt,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+	}
+	switch {
+	case runErr != nil && runErr.Error() == "generation cancelled by user":
+		payload.Status = webhook.StatusCancelled
+	case runErr != nil:
+		payload.Status = webhook.StatusFailed
+		payload.Error = runErr.Error()
+	}
+
+	var counts models.TokenCounts
+	for _, msg := range conversation {
+		if msg.Role != schema.Assistant {
+			continue
+		}
+		if c, ok := models.TokenCountsFromMessage(msg); ok {
+			counts.Add(c)
+		}
+		payload.Response = msg.Content
+	}
+	payload.Usage = webhook.NewUsage(modelName, counts)
+	return payload
+}
+
+// toolCallSummary is one tool call made during a run
+type toolCallSummary struct {
+	name    string
+	args    string
+	isError bool
+}
+
+// runToolCalls lists the tool calls in conversation, in order
+func runToolCalls(conversation []*schema.Message) []toolCallSummary {
+	var calls []toolCallSummary
+	index := make(map[string]int) // tool call ID -> position in calls
+	for _, msg := range conversation {
+		switch msg.Role {
+		case schema.Assistant:
+			for _, call := range msg.ToolCalls {
+				index[call.ID] = len(calls)
+				calls = append(calls, toolCallSummary{name: call.Function.Name, args: call.Function.Arguments})
+			}
+		case schema.Tool:
+			i, ok := index[msg.ToolCallID]
+			if !ok {
+				continue
+			}
+			var result struct {
+				IsError bool `json:"isError"`
+			}
+			if json.Unmarshal([]byte(msg.Content), &result) == nil {
+				calls[i].isError = result.IsError
+			}
+		}
+	}
+	return calls
+}
+
+// resultEmail renders a run summary as an email
+func resultEmail(p *webhook.Payload, calls []toolCallSummary) email.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Status:   %s\n", p.Status)
+	fmt.Fprintf(&b, "Model:    %s\n", p.Model)
+	fmt.Fprintf(&b, "Duration: %s\n", p.FinishedAt.Sub(p.StartedAt).Round(time.Second))
+	fmt.Fprintf(&b, "Tokens:   %d in, %d out", p.Usage.InputTokens+p.Usage.CacheReadTokens+p.Usage.CacheWriteTokens, p.Usage.OutputTokens)
+	if p.Usage.Cost > 0 {
+		fmt.Fprintf(&b, " ($%.4f)", p.Usage.Cost)
+	}
+	b.WriteString("\n")
+	if p.Session != "" {
+		fmt.Fprintf(&b, "Session:  %s\n", p.Session)
+	}
+	if p.Error != "" {
+		fmt.Fprintf(&b, "Error:    %s\n", p.Error)
+	}
+
+	writeSection := func(title, text string) {
+		fmt.Fprintf(&b, "\n%s\n%s\n%s\n", title, strings.Repeat("-", len(title)), text)
+	}
+	writeSection("Prompt", p.Prompt)
+	if p.Response != "" {
+		writeSection("Response", p.Response)
+	}
+	if p.PostMortem != nil {
+		_, details, _ := strings.Cut(strings.TrimRight(p.PostMortem.String(), "\n"), "\n")
+		writeSection("Post-mortem", details)
+	}
+	if len(calls) > 0 {
+		var lines []string
+		for i, call := range calls {
+			line := fmt.Sprintf("%d. %s %s", i+1, call.name, truncateLine(call.args, 200))
+			if call.isError {
+				line += " (error)"
+			}
+			lines = append(lines, line)
+		}
+		writeSection(fmt.Sprintf("Tool calls (%d)", len(calls)), strings.Join(lines, "\n"))
+	}
+
+	return email.Message{
+		Subject: fmt.Sprintf("[mcphost] %s: %s", p.Status, truncateLine(p.Prompt, 60)),
+		Body:    b.String(),
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/postmortem"
+	"github.com/osi4iot/mcphost/internal/webhook"
+)
+
+func TestResultEmail(t *testing.T) {
+	conversation := []*schema.Message{
+		schema.UserMessage("Summarize the logs"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path":"app.log"}`}},
+			{ID: "2", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path":"missing.log"}`}},
+		}),
+		schema.ToolMessage(`{"content":[{"type":"text","text":"..."}]}`, "1"),
+		schema.ToolMessage(`{"content":[{"type":"text","text":"not found"}],"isError":true}`, "2"),
+		schema.AssistantMessage("Three errors, all from the cache.", nil),
+	}
+
+	calls := runToolCalls(conversation)
+	if len(calls) != 2 || calls[0].isError || !calls[1].isError {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+
+	payload := runPayload("Summarize the logs", time.Now().Add(-time.Minute), conversation, nil)
+	if payload.Status != webhook.StatusSucceeded || payload.Response != "Three errors, all from the cache." {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	msg := resultEmail(payload, calls)
+	if msg.Subject != "[mcphost] succeeded: Summarize the logs" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, want := range []string{
+		"Status:   succeeded",
+		"Duration: 1m0s",
+		"Response\n--------\nThree errors, all from the cache.",
+		"Tool calls (2)",
+		`1. fs__read_file {"path":"app.log"}`,
+		`2. fs__read_file {"path":"missing.log"} (error)`,
+	} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("email body missing %q:\n%s", want, msg.Body)
+		}
+	}
+
+	failed := runPayload("Summarize the logs", time.Now(), nil, errors.New("provider timeout"))
+	if failed.Status != webhook.StatusFailed || !strings.Contains(resultEmail(failed, nil).Body, "Error:    provider timeout") {
+		t.Errorf("unexpected failed payload %+v", failed)
+	}
+	failed.PostMortem = postmortem.Analyze(conversation, errors.New("provider timeout"))
+	if body := resultEmail(failed, nil).Body; !strings.Contains(body, "Post-mortem\n-----------\n  Category:     timeout") {
+		t.Errorf("expected the post-mortem in the email:\n%s", body)
+	}
+}
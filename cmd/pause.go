@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// maxPausedArgsLen bounds the tool arguments shown for each call while paused
+const maxPausedArgsLen = 80
+
+// parsePauseCommand recognises /pause, /resume, /step and /abort
+func parsePauseCommand(input string) (string, bool) {
+	switch command := strings.TrimSpace(input); command {
+	case "/pause", "/resume", "/step", "/abort":
+		return command, true
+	}
+	return "", false
+}
+
+// handleIdlePauseCommand handles the pause commands typed at the prompt
+// between runs: /pause and /step make the next run pause before its first
+// step, /resume withdraws that
+func handleIdlePauseCommand(mcpAgent *agent.Agent, cli *ui.CLI, command string) {
+	switch command {
+	case "/pause", "/step":
+		mcpAgent.Pause()
+		cli.DisplayInfo("The next run will pause before its first step.")
+	case "/resume":
+		mcpAgent.CancelPause()
+		cli.DisplayInfo("The next run will not pause.")
+	case "/abort":
+		cli.DisplayInfo("No run is paused.")
+	}
+}
+
+// pauseRun holds a paused run: it shows what the run has done so far and
+// reads /resume, /step or /abort from the prompt. Anything else typed is
+// queued as an instruction for the next step.
+func pauseRun(mcpAgent *agent.Agent, cli *ui.CLI, state agent.LoopState) agent.PauseAction {
+	cli.DisplayInfo(describeLoopState(state))
+	for {
+		input, err := cli.GetPrompt()
+		if err != nil {
+			return agent.PauseAbort
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+
+		command, ok := parsePauseCommand(input)
+		switch {
+		case !ok && strings.HasPrefix(input, "/"):
+			cli.DisplayError(fmt.Errorf("the run is paused: use /resume, /step or /abort"))
+		case !ok:
+			mcpAgent.Interject(input)
+			cli.DisplayInfo("Queued for the next step.")
+		case command == "/resume":
+			return agent.PauseResume
+		case command == "/step":
+			return agent.PauseStep
+		case command == "/abort":
+			return agent.PauseAbort
+		default:
+			cli.DisplayInfo("Already paused.")
+		}
+	}
+}
+
+// describeLoopState summarises a paused run: its tool calls so far, with
+// whether each failed, and the conversation size
+func describeLoopState(state agent.LoopState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Paused before step %d\n\n", state.Step+1)
+
+	failed := make(map[string]bool)
+	for _, msg := range state.Added {
+		if msg.Role == schema.Tool && (strings.HasPrefix(msg.Content, "Tool execution ") || strings.HasPrefix(msg.Content, "Tool not found")) {
+			failed[msg.ToolCallID] = true
+		}
+	}
+
+	calls := 0
+	for _, msg := range state.Added {
+		for _, call := range msg.ToolCalls {
+			calls++
+			args := strings.Join(strings.Fields(call.Function.Arguments), " ")
+			if runes := []rune(args); len(runes) > maxPausedArgsLen {
+				args = string(runes[:maxPausedArgsLen-3]) + "..."
+			}
+			status := ""
+			if failed[call.ID] {
+				status = " (failed)"
+			}
+			fmt.Fprintf(&b, "%d. `%s` `%s`%s\n", calls, call.Function.Name, args, status)
+		}
+	}
+	if calls == 0 {
+		b.WriteString("No tool calls yet in this run.\n")
+	}
+
+	fmt.Fprintf(&b, "\n%d model calls and %d tool calls so far; the next call sees %d messages.\n\n",
+		state.Step, calls, len(state.Messages))
+	b.WriteString("Type `/resume` to continue, `/step` to run one step and pause again, `/abort` to stop the run, or an instruction to add before the next step.")
+	return b.String()
+}
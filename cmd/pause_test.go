@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+)
+
+func TestParsePauseCommand(t *testing.T) {
+	for _, input := range []string{"/pause", "/resume", " /step ", "/abort"} {
+		if _, ok := parsePauseCommand(input); !ok {
+			t.Errorf("expected %q to be a pause command", input)
+		}
+	}
+	for _, input := range []string{"/pauses", "/step 2", "pause", "/help"} {
+		if _, ok := parsePauseCommand(input); ok {
+			t.Errorf("expected %q not to be a pause command", input)
+		}
+	}
+}
+
+func TestDescribeLoopState(t *testing.T) {
+	added := []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}},
+			{ID: "2", Function: schema.FunctionCall{Name: "db__query", Arguments: `{"sql": "` + strings.Repeat("x", 200) + `"}`}},
+		}),
+		schema.ToolMessage("module example", "1"),
+		schema.ToolMessage("Tool execution error: no such table", "2"),
+	}
+	messages := append([]*schema.Message{schema.UserMessage("check the module")}, added...)
+
+	description := describeLoopState(agent.LoopState{Step: 1, Messages: messages, Added: added})
+	for _, want := range []string{
+		"## Paused before step 2",
+		"1. `fs__read_file` `{\"path\": \"go.mod\"}`\n",
+		"2. `db__query`",
+		"...` (failed)",
+		"1 model calls and 2 tool calls so far; the next call sees 4 messages",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("expected %q in:\n%s", want, description)
+		}
+	}
+
+	if description := describeLoopState(agent.LoopState{}); !strings.Contains(description, "No tool calls yet") {
+		t.Errorf("expected an empty run to say so, got:\n%s", description)
+	}
+}
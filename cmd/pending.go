@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// pendingToolCall is a tool call printed by --stop-on-tool-calls with --quiet,
+// one JSON object per line
+type pendingToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// validateStopOnToolCalls rejects --stop-on-tool-calls outside a single
+// --prompt run: a conversation that goes on after it would hold tool calls
+// nothing answered
+func validateStopOnToolCalls(hasPrompt bool) error {
+	if !viper.GetBool("stop-on-tool-calls") {
+		return nil
+	}
+	if !hasPrompt {
+		return fmt.Errorf("--stop-on-tool-calls can only be used with --prompt/-p")
+	}
+	if noExitFlag {
+		return fmt.Errorf("--stop-on-tool-calls cannot be used with --no-exit")
+	}
+	return nil
+}
+
+// displayPendingToolCalls shows the tool calls a --stop-on-tool-calls run
+// stopped at. In quiet mode they are the only output, as JSON lines.
+func displayPendingToolCalls(cli *ui.CLI, quiet bool, calls []schema.ToolCall) {
+	if cli == nil || quiet {
+		for _, call := range calls {
+			arguments := json.RawMessage(call.Function.Arguments)
+			if strings.TrimSpace(call.Function.Arguments) == "" {
+				arguments = json.RawMessage("{}")
+			} else if !json.Valid(arguments) {
+				arguments, _ = json.Marshal(call.Function.Arguments)
+			}
+			line, _ := json.Marshal(pendingToolCall{ID: call.ID, Name: call.Function.Name, Arguments: arguments})
+			fmt.Println(string(line))
+		}
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("## Tool calls not run\n")
+	for _, call := range calls {
+		fmt.Fprintf(&b, "\n- `%s` (%s): `%s`", call.Function.Name, call.ID, call.Function.Arguments)
+	}
+	cli.DisplayInfo(b.String())
+}
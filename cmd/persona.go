@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// personaFlags are the root command's flags, which persona settings give way
+// to. They are set in init, since rootCmd runs selectPersona.
+var personaFlags *pflag.FlagSet
+
+// selectPersona applies the settings of the persona called name that the
+// command line doesn't set itself, so --persona reviewer -m other:model still
+// uses the other model. It returns nil when name is empty.
+func selectPersona(name string) (*config.Persona, error) {
+	if name == "" {
+		return nil, nil
+	}
+	persona, err := config.LoadPersona(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if persona.SystemPrompt != "" && !personaFlags.Changed("system-prompt") {
+		viper.Set("system-prompt", persona.SystemPrompt)
+	}
+	if persona.Model != "" && !personaFlags.Changed("model") {
+		viper.Set("model", persona.Model)
+	}
+	if persona.Temperature != nil && !personaFlags.Changed("temperature") {
+		viper.Set("temperature", *persona.Temperature)
+	}
+	if err := applyPersonaTheme(name); err != nil {
+		return nil, err
+	}
+	return persona, nil
+}
+
+// applyPersonaTheme switches to the theme of the persona called name, if it
+// sets one
+func applyPersonaTheme(name string) error {
+	theme, ok, err := config.LoadPersonaTheme(name)
+	if err != nil || !ok {
+		return err
+	}
+	ui.SetTheme(configToUiTheme(theme))
+	return nil
+}
+
+// recordPersona records the persona and the model it runs on in the session's
+// metadata
+func recordPersona(sessionManager *session.Manager, name string) {
+	if sessionManager == nil {
+		return
+	}
+	metadata := sessionManager.GetSession().Metadata
+	metadata.Persona = name
+	if provider, model, ok := strings.Cut(viper.GetString("model"), ":"); ok {
+		metadata.Provider = provider
+		metadata.Model = model
+	}
+	sessionManager.SetMetadata(metadata)
+}
+
+// parsePersonaCommand recognises /persona [name] and returns the name
+func parsePersonaCommand(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if input != "/persona" && !strings.HasPrefix(input, "/persona ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(input, "/persona")), true
+}
+
+// handlePersonaCommand lists the personas, or switches to the one called name
+// for the rest of the session: its system prompt replaces the conversation's,
+// and its model, temperature, tools and theme apply from the next prompt on
+func handlePersonaCommand(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, name string, messages *[]*schema.Message, sessionManager *session.Manager) {
+	if name == "" {
+		names := config.PersonaNames()
+		if len(names) == 0 {
+			cli.DisplayInfo("No personas are configured. Add them under personas: in the config file.")
+			return
+		}
+		current := viper.GetString("persona")
+		var list strings.Builder
+		list.WriteString("## Personas\n\n")
+		for _, n := range names {
+			if strings.EqualFold(n, current) {
+				fmt.Fprintf(&list, "- **%s** (current)\n", n)
+			} else {
+				fmt.Fprintf(&list, "- %s\n", n)
+			}
+		}
+		list.WriteString("\nSwitch with /persona <name>.")
+		cli.DisplayInfo(list.String())
+		return
+	}
+
+	persona, err := config.LoadPersona(name)
+	if err != nil {
+		cli.DisplayError(err)
+		return
+	}
+
+	systemPrompt := mcpAgent.SystemPrompt()
+	if persona.SystemPrompt != "" {
+		if systemPrompt, err = config.LoadSystemPrompt(persona.SystemPrompt); err == nil {
+			systemPrompt, err = withMemory(systemPrompt)
+		}
+		if err != nil {
+			cli.DisplayError(fmt.Errorf("failed to load the system prompt of persona %s: %v", name, err))
+			return
+		}
+	}
+
+	if persona.Model != "" || persona.Temperature != nil {
+		previousModel, previousTemperature := viper.Get("model"), viper.Get("temperature")
+		if persona.Model != "" {
+			viper.Set("model", persona.Model)
+		}
+		if persona.Temperature != nil {
+			viper.Set("temperature", *persona.Temperature)
+		}
+		if err := mcpAgent.SetModel(ctx, BuildProviderConfig(systemPrompt)); err != nil {
+			viper.Set("model", previousModel)
+			viper.Set("temperature", previousTemperature)
+			cli.DisplayError(fmt.Errorf("failed to switch to persona %s: %v", name, err))
+			return
+		}
+		if _, model, ok := strings.Cut(viper.GetString("model"), ":"); ok {
+			cli.SetModelName(model)
+		}
+	}
+
+	if err := applyPersonaTheme(name); err != nil {
+		cli.DisplayError(err)
+	}
+	mcpAgent.SetToolFilter(persona.AllowsTool)
+	viper.Set("persona", name)
+
+	// The conversation keeps the system prompt it started with, so it is replaced
+	mcpAgent.SetSystemPrompt(systemPrompt)
+	if len(*messages) > 0 && (*messages)[0].Role == schema.System {
+		updated := append([]*schema.Message{schema.SystemMessage(systemPrompt)}, (*messages)[1:]...)
+		replaceMessagesHistory(messages, sessionManager, cli, updated)
+	}
+	recordPersona(sessionManager, name)
+
+	cli.DisplayInfo(fmt.Sprintf("Switched to persona %s (%s, %d tools).", name, viper.GetString("model"), len(mcpAgent.GetTools())))
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/hooks"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// errPlanCancelled ends a planned run whose plan the user turned down
+var errPlanCancelled = errors.New("plan cancelled by user")
+
+// parsePlanCommand recognises /plan <task> and returns the task
+func parsePlanCommand(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if input != "/plan" && !strings.HasPrefix(input, "/plan ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(input, "/plan")), true
+}
+
+// runPlannedStep is runAgenticStep in plan mode: the model first drafts a
+// plan for the task without running tools, the user approves or edits it, and
+// the run then carries it out with the plan pinned and its steps checked off.
+// The plan's progress is shown when the run ends.
+func runPlannedStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, messages []*schema.Message, config AgenticLoopConfig, hookExecutor *hooks.Executor) (*schema.Message, []*schema.Message, error) {
+	spinner := ui.NewSpinner("Drafting a plan...")
+	spinner.Start()
+	plan, err := mcpAgent.DraftPlan(ctx, messages)
+	spinner.Stop()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if plan, err = reviewPlan(cli, plan); err != nil {
+		return nil, nil, err
+	}
+
+	mcpAgent.SetPlan(plan)
+	defer mcpAgent.SetPlan(nil)
+	response, conversationMessages, err := runAgenticStep(ctx, mcpAgent, cli, messages, config, hookExecutor)
+	if progress := mcpAgent.CurrentPlan(); progress != nil {
+		cli.DisplayInfo(fmt.Sprintf("## Plan\n\n%d of %d steps done.\n\n%s",
+			len(progress.Steps)-progress.Remaining(), len(progress.Steps), progress))
+	}
+	return response, conversationMessages, err
+}
+
+// reviewPlan shows the drafted plan until the user runs it, as drafted or
+// after editing it, or cancels it
+func reviewPlan(cli *ui.CLI, plan *agent.Plan) (*agent.Plan, error) {
+	for {
+		cli.DisplayInfo("## Proposed Plan\n\n" + numberedSteps(plan))
+
+		choice := "run"
+		err := huh.NewSelect[string]().
+			Title("Run this plan?").
+			Options(
+				huh.NewOption("Run it", "run"),
+				huh.NewOption("Edit it first", "edit"),
+				huh.NewOption("Cancel", "cancel"),
+			).
+			Value(&choice).
+			Run()
+		if err != nil || choice == "cancel" {
+			return nil, errPlanCancelled
+		}
+		if choice == "run" {
+			return plan, nil
+		}
+
+		text := numberedSteps(plan)
+		err = huh.NewText().
+			Title("Edit the plan").
+			Description("One step per numbered line; other lines are ignored").
+			CharLimit(0).
+			Lines(len(plan.Steps) + 2).
+			Value(&text).
+			Run()
+		if err != nil {
+			continue
+		}
+		if edited := agent.ParsePlan(text); edited != nil {
+			plan = edited
+		} else {
+			cli.DisplayError(fmt.Errorf("the edited plan has no numbered steps; keeping the previous one"))
+		}
+	}
+}
+
+// numberedSteps lists a plan's steps for review, without their progress
+func numberedSteps(plan *agent.Plan) string {
+	var b strings.Builder
+	for i, step := range plan.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step.Text)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
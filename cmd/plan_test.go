@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+)
+
+func TestParsePlanCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		task  string
+		ok    bool
+	}{
+		{"/plan migrate the users", "migrate the users", true},
+		{"  /plan  ", "", true},
+		{"/planet", "", false},
+		{"plan the trip", "", false},
+	}
+	for _, tt := range tests {
+		task, ok := parsePlanCommand(tt.input)
+		if task != tt.task || ok != tt.ok {
+			t.Errorf("parsePlanCommand(%q) = %q, %v; want %q, %v", tt.input, task, ok, tt.task, tt.ok)
+		}
+	}
+}
+
+func TestNumberedSteps(t *testing.T) {
+	plan := agent.ParsePlan("- Read the schema\n- [x] Write the migration")
+	if got := numberedSteps(plan); got != "1. Read the schema\n2. Write the migration" {
+		t.Errorf("unexpected steps %q", got)
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/postmortem"
+)
+
+// postMortemSummaryTimeout bounds the model call summarizing a failed run
+const postMortemSummaryTimeout = time.Minute
+
+// reportFailure writes the post-mortem of a non-interactive run that failed
+// with runErr to stderr and returns it for the run's webhooks and email. With
+// --postmortem-summary a model adds its account of what went wrong. Runs the
+// user cancelled or aborted get none.
+func reportFailure(ctx context.Context, mcpAgent *agent.Agent, conversation []*schema.Message, runErr error) *postmortem.Report {
+	if runErr == nil || runErr.Error() == "generation cancelled by user" ||
+		errors.Is(runErr, agent.ErrAborted) || errors.Is(runErr, context.Canceled) {
+		return nil
+	}
+	report := postmortem.Analyze(conversation, runErr)
+
+	if viper.GetBool("postmortem-summary") {
+		// The run's context may be what failed; the summary gets its own time
+		summaryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), postMortemSummaryTimeout)
+		defer cancel()
+		summary, err := mcpAgent.SummarizeFailure(summaryCtx, report.String(), conversation)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		report.Summary = summary
+	}
+
+	fmt.Fprint(os.Stderr, "\n"+report.String())
+	return report
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// probeTimeout bounds the startup check of a custom provider endpoint
+const probeTimeout = 30 * time.Second
+
+// probeProviderEndpoint checks a custom provider endpoint before the first
+// request, so a wrong URL, key or model fails at startup with an explanation
+// instead of mid-run. What a probe finds is cached in the state directory
+// for a day; --no-probe and --offline skip the check.
+func probeProviderEndpoint(ctx context.Context, modelConfig *models.ProviderConfig) error {
+	if viper.GetBool("no-probe") || viper.GetBool("offline") {
+		return nil
+	}
+	cachePath, cacheErr := config.StatePath("cache", "endpoints.json")
+	if cacheErr == nil {
+		if profile := models.CachedEndpointProfile(cachePath, modelConfig); profile != nil {
+			models.UseEndpointProfile(profile)
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	profile, err := models.ProbeEndpoint(ctx, modelConfig)
+	if err != nil {
+		return fmt.Errorf("provider endpoint check failed: %v (use --no-probe to skip this check)", err)
+	}
+	if profile == nil {
+		return nil
+	}
+	for _, warning := range profile.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	models.UseEndpointProfile(profile)
+	if cacheErr == nil {
+		if err := models.SaveEndpointProfile(cachePath, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not cache the provider endpoint check: %v\n", err)
+		}
+	}
+	return nil
+}
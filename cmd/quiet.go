@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// quietLevel is how much --quiet hides; each level hides what the ones
+// before it do
+type quietLevel int
+
+const (
+	quietOff    quietLevel = iota
+	quietStatus            // spinners, usage and other status lines
+	quietTools             // tool calls and their results too
+	quietAll               // everything but the final answer; needs --prompt
+)
+
+// quietLevels maps the values of --quiet to levels; a bare --quiet is "all"
+var quietLevels = map[string]quietLevel{
+	"":       quietOff,
+	"off":    quietOff,
+	"status": quietStatus,
+	"tools":  quietTools,
+	"all":    quietAll,
+}
+
+// parseQuietLevel reads the value of --quiet. Interactive runs, without a
+// prompt, cannot hide everything since nothing would be left to chat with.
+func parseQuietLevel(value string, hasPrompt bool) (quietLevel, error) {
+	level, ok := quietLevels[value]
+	if !ok {
+		return quietOff, fmt.Errorf("invalid --quiet %q: use status, tools or all", value)
+	}
+	if level == quietAll && !hasPrompt {
+		return quietOff, fmt.Errorf("--quiet=all can only be used with --prompt/-p; use --quiet=tools or --quiet=status while chatting")
+	}
+	return level, nil
+}
+
+// showOutput reports whether the conversation is shown on cli at all
+func (c AgenticLoopConfig) showOutput(cli *ui.CLI) bool {
+	return cli != nil && c.Quiet < quietAll
+}
+
+// showTools reports whether tool calls and their results are shown
+func (c AgenticLoopConfig) showTools(cli *ui.CLI) bool {
+	return cli != nil && c.Quiet < quietTools
+}
+
+// showStatus reports whether spinners, usage and status lines are shown
+func (c AgenticLoopConfig) showStatus(cli *ui.CLI) bool {
+	return cli != nil && c.Quiet < quietStatus
+}
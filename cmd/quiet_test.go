@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+func TestParseQuietLevel(t *testing.T) {
+	tests := []struct {
+		value     string
+		hasPrompt bool
+		want      quietLevel
+		wantErr   bool
+	}{
+		{"", false, quietOff, false},
+		{"status", false, quietStatus, false},
+		{"tools", false, quietTools, false},
+		{"all", true, quietAll, false},
+		{"all", false, quietOff, true}, // nothing left to chat with
+		{"loud", true, quietOff, true},
+	}
+	for _, tt := range tests {
+		got, err := parseQuietLevel(tt.value, tt.hasPrompt)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseQuietLevel(%q, %v) = %v, %v; want %v, error %v", tt.value, tt.hasPrompt, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestQuietLevelsAddUp(t *testing.T) {
+	cli := &ui.CLI{}
+	tests := []struct {
+		level                 quietLevel
+		output, tools, status bool
+	}{
+		{quietOff, true, true, true},
+		{quietStatus, true, true, false},
+		{quietTools, true, false, false},
+		{quietAll, false, false, false},
+	}
+	for _, tt := range tests {
+		config := AgenticLoopConfig{Quiet: tt.level}
+		if config.showOutput(cli) != tt.output || config.showTools(cli) != tt.tools || config.showStatus(cli) != tt.status {
+			t.Errorf("level %d shows output %v, tools %v, status %v", tt.level,
+				config.showOutput(cli), config.showTools(cli), config.showStatus(cli))
+		}
+	}
+	if (AgenticLoopConfig{}).showOutput(nil) {
+		t.Error("expected nothing shown without a CLI")
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,14 +15,20 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/auth"
+	"github.com/osi4iot/mcphost/internal/clock"
 	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/guardrail"
 	"github.com/osi4iot/mcphost/internal/hooks"
 	"github.com/osi4iot/mcphost/internal/models"
 	"github.com/osi4iot/mcphost/internal/session"
 	"github.com/osi4iot/mcphost/internal/tokens"
 	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/turn"
 	"github.com/osi4iot/mcphost/internal/ui"
+	"github.com/osi4iot/mcphost/internal/workspace"
 
+	"github.com/osi4iot/mcphost/internal/i18n"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -34,8 +41,26 @@ var (
 	providerAPIKey   string
 	debugMode        bool
 	promptFlag       string
-	quietFlag        bool
+	quietFlag        string
 	noExitFlag       bool
+	stdinFlag        bool
+	teeFlag          string
+	teeToolsFlag     bool
+	scrubPIIFlag     bool
+	scrubPatterns    []string
+	localOnlyFlag    bool
+	samplingPolicy   string
+	attachFiles      []string
+	uploadThreshold  int
+	noPromptCache    bool
+	noProbeFlag      bool
+	offlineFlag      bool
+	azureAuthFlag    string
+	shadowModelFlag  string
+	thinkingFlag     bool
+	thinkingBudget   int
+	audioDir         string
+	emailResults     []string
 	maxSteps         int
 	streamFlag       bool           // Enable streaming output
 	compactMode      bool           // Enable compact output mode
@@ -47,21 +72,81 @@ var (
 	sessionPath     string
 
 	// Model generation parameters
-	maxTokens     int
-	temperature   float32
-	topP          float32
-	topK          int32
-	stopSequences []string
+	maxTokens         int
+	temperature       float32
+	topP              float32
+	topK              int32
+	stopSequences     []string
+	parallelToolCalls bool
+	toolChoice        string
+	toolEmulation     string
 
 	// Ollama-specific parameters
-	numGPU  int32
-	mainGPU int32
+	numGPU    int32
+	mainGPU   int32
+	numCtx    int
+	numThread int
+	keepAlive time.Duration
 
 	// Hooks control
 	noHooks bool
 
+	// Skip remembered preferences
+	noMemory bool
+
+	// Follow-up task extraction
+	extractTasks     bool
+	extractTasksTool string
+
+	// Model summaries of failed runs
+	postMortemSummary bool
+
+	// UI language
+	langFlag string
+
+	// Ask before each step's tool calls run
+	confirmEachStep bool
+
+	// Conversation length limit
+	maxHistoryMessages int
+	historyTrimPolicy  string
+	historyKeepTurns   int
+
+	// Send repeated tool results in full
+	keepDuplicateToolResults bool
+
+	// Draft and approve a plan before each run
+	planFlag bool
+
+	// Return the model's tool calls instead of running them
+	stopOnToolCalls bool
+
+	// Have the model cite tool result sources and list them below responses
+	citationsFlag bool
+
+	// Ask before sending prompts over this many estimated tokens
+	confirmTokens int
+	yesFlag       bool
+
 	// TLS configuration
 	tlsSkipVerify bool
+
+	// Provider HTTP timeouts
+	providerTimeout        time.Duration
+	providerConnectTimeout time.Duration
+
+	// State directory for sessions, caches, credentials and logs
+	stateDirFlag string
+
+	// Time for every timestamp, for tests and replays
+	fixedTimeFlag string
+
+	// Directory and extra environment the session's tools run in
+	workDirFlag string
+	envFlags    []string
+
+	// Named set of settings from the personas: section
+	personaFlag string
 )
 
 // agentUIAdapter adapts agent.Agent to ui.AgentInterface
@@ -73,6 +158,16 @@ func (a *agentUIAdapter) GetLoadingMessage() string {
 	return a.agent.GetLoadingMessage()
 }
 
+func (a *agentUIAdapter) GetParameterWarnings() []string {
+	var warnings []string
+	for _, adjustment := range a.agent.GetParameterAdjustments() {
+		if !adjustment.Defaulted {
+			warnings = append(warnings, adjustment.String())
+		}
+	}
+	return warnings
+}
+
 func (a *agentUIAdapter) GetTools() []any {
 	tools := a.agent.GetTools()
 	result := make([]any, len(tools))
@@ -86,6 +181,19 @@ func (a *agentUIAdapter) GetLoadedServerNames() []string {
 	return a.agent.GetLoadedServerNames()
 }
 
+func (a *agentUIAdapter) GetSamplingUsage() []ui.SamplingUsage {
+	var result []ui.SamplingUsage
+	for _, usage := range a.agent.GetSamplingUsage() {
+		result = append(result, ui.SamplingUsage{
+			Server:   usage.Server,
+			Requests: usage.Requests,
+			Tokens:   usage.Tokens,
+			Cost:     usage.Cost,
+		})
+	}
+	return result
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "mcphost",
 	Short: "Chat with AI models through a unified interface",
@@ -97,6 +205,7 @@ Available models can be specified using the --model flag:
 - Anthropic Claude (default): anthropic:claude-sonnet-4-20250514
 - OpenAI: openai:gpt-4
 - Ollama models: ollama:modelname
+- GGUF files run by llama.cpp: llamacpp:/path/to/model.gguf
 - Google: google:modelname
 
 Examples:
@@ -136,10 +245,13 @@ func InitConfig() {
 			os.Exit(1)
 		}
 	} else {
-		// Ensure a config file exists (create default if none found)
-		if err := config.EnsureConfigExists(); err != nil {
-			// If we can't create config, continue silently (non-fatal)
-			fmt.Fprintf(os.Stderr, "Warning: Could not create default config file: %v\n", err)
+		// Ensure a config file exists (create default if none found). Interactive
+		// first runs get the setup wizard instead, see runFirstRunSetup.
+		if !isInteractiveTerminal() {
+			if err := config.EnsureConfigExists(); err != nil {
+				// If we can't create config, continue silently (non-fatal)
+				fmt.Fprintf(os.Stderr, "Warning: Could not create default config file: %v\n", err)
+			}
 		}
 
 		// Find home directory
@@ -189,6 +301,43 @@ func InitConfig() {
 	viper.SetEnvPrefix("MCPHOST")
 	viper.AutomaticEnv()
 
+	// Resolve the state directory and export it so hooks write to the same place
+	if err := config.SetStateDir(viper.GetString("state-dir")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if dir, err := config.StateDir(); err == nil {
+		os.Setenv(config.StateDirEnv, dir)
+	}
+
+	// Models released after this build come from the models.dev cache
+	loadModelRegistry()
+
+	// Models on OpenAI-compatible servers get the capabilities declared for them
+	var compatible models.CompatibleConfig
+	if err := viper.UnmarshalKey("openai-compatible", &compatible); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid openai-compatible config: %v\n", err)
+		os.Exit(1)
+	}
+	models.RegisterCompatibleModels(compatible)
+
+	// Fine-tunes and proxy models declared in the config file join the registry
+	var customModels []models.CustomModel
+	if err := viper.UnmarshalKey("custom-models", &customModels); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid custom-models config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := models.RegisterCustomModels(customModels); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Stop the clock for tests and replays
+	if err := clock.SetFixed(viper.GetString("fixed-time")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Load hooks configuration unless disabled
 	if !viper.GetBool("no-hooks") {
 		hooksConfig, err := hooks.LoadHooksConfig()
@@ -229,7 +378,16 @@ func LoadConfigWithEnvSubstitution(configPath string) error {
 
 	// Use viper to parse the processed content
 	viper.SetConfigType(configType)
-	return viper.ReadConfig(strings.NewReader(processedContent))
+	if err := viper.ReadConfig(strings.NewReader(processedContent)); err != nil {
+		return err
+	}
+
+	// models.default stands in for the --model flag default; an explicit
+	// model from a flag, the environment or the config file still wins
+	if defaultModel := viper.GetString("models." + config.TaskDefault); defaultModel != "" {
+		viper.SetDefault("model", defaultModel)
+	}
+	return nil
 }
 
 func configToUiTheme(theme config.Theme) ui.Theme {
@@ -270,14 +428,19 @@ func init() {
 	rootCmd.PersistentFlags().
 		StringVarP(&modelFlag, "model", "m", "anthropic:claude-sonnet-4-20250514",
 			"model to use (format: provider:model)")
+	rootCmd.PersistentFlags().
+		StringVar(&shadowModelFlag, "shadow-model", "", "mirror each model call to this model (provider:model), recording its responses and costs to the usage ledger without using them")
 	rootCmd.PersistentFlags().
 		BoolVar(&debugMode, "debug", false, "enable debug logging")
 	rootCmd.PersistentFlags().
 		StringVarP(&promptFlag, "prompt", "p", "", "run in non-interactive mode with the given prompt")
 	rootCmd.PersistentFlags().
-		BoolVar(&quietFlag, "quiet", false, "suppress all output (only works with --prompt)")
+		StringVar(&quietFlag, "quiet", "", "hide output: status (spinners and usage), tools (tool calls too) or all (everything but the answer; needs --prompt); a bare --quiet is all")
+	rootCmd.PersistentFlags().Lookup("quiet").NoOptDefVal = "all"
 	rootCmd.PersistentFlags().
 		BoolVar(&noExitFlag, "no-exit", false, "prevent non-interactive mode from exiting, show input prompt instead")
+	rootCmd.PersistentFlags().
+		BoolVar(&stdinFlag, "stdin", false, "read stdin until it is closed and add it to the --prompt")
 	rootCmd.PersistentFlags().
 		IntVar(&maxSteps, "max-steps", 0, "maximum number of agent steps (0 for unlimited)")
 	rootCmd.PersistentFlags().
@@ -286,6 +449,36 @@ func init() {
 		BoolVar(&compactMode, "compact", false, "enable compact output mode without fancy styling")
 	rootCmd.PersistentFlags().
 		BoolVar(&noHooks, "no-hooks", false, "disable all hooks execution")
+	rootCmd.PersistentFlags().
+		BoolVar(&noMemory, "no-memory", false, "don't add remembered preferences (~/.mcphost/memory.md) to the system prompt")
+	rootCmd.PersistentFlags().
+		BoolVar(&extractTasks, "extract-tasks", false, "after each response, add the action items it leaves open to the todo list (~/.mcphost/todos.json)")
+	rootCmd.PersistentFlags().
+		StringVar(&extractTasksTool, "extract-tasks-tool", "", "with --extract-tasks, send each task to this tool (server__tool) instead of the todo list")
+	rootCmd.PersistentFlags().
+		BoolVar(&postMortemSummary, "postmortem-summary", false, "when a --prompt or script run fails, have a model summarize what went wrong in its post-mortem (the postmortem task model, else the main model)")
+	rootCmd.PersistentFlags().
+		StringVar(&langFlag, "lang", "", "language for the interface, e.g. en or es (default: from LANG)")
+	rootCmd.PersistentFlags().
+		BoolVar(&confirmEachStep, "confirm-each-step", false, "show each step's tool calls with their arguments and ask before running them")
+	rootCmd.PersistentFlags().
+		IntVar(&maxHistoryMessages, "max-history-messages", 0, "keep at most this many messages in the conversation, trimming the oldest (0 for no limit)")
+	rootCmd.PersistentFlags().
+		StringVar(&historyTrimPolicy, "history-trim-policy", session.TrimDropOldest, "how --max-history-messages trims: drop-oldest or drop-oldest-tool-results-first")
+	rootCmd.PersistentFlags().
+		IntVar(&historyKeepTurns, "history-keep-turns", 2, "most recent turns --max-history-messages never trims")
+	rootCmd.PersistentFlags().
+		BoolVar(&keepDuplicateToolResults, "keep-duplicate-tool-results", false, "send every tool result in full instead of replacing ones a later call in the same turn repeated")
+	rootCmd.PersistentFlags().
+		BoolVar(&stopOnToolCalls, "stop-on-tool-calls", false, "with --prompt, stop once the model asks for tool calls and print them instead of running them")
+	rootCmd.PersistentFlags().
+		BoolVar(&citationsFlag, "citations", false, "have the model cite the URLs and files its tool results came from as [n], and list the cited sources below each response")
+	rootCmd.PersistentFlags().
+		BoolVar(&planFlag, "plan", false, "have the model draft a plan for each prompt and ask you to approve or edit it before anything runs")
+	rootCmd.PersistentFlags().
+		IntVar(&confirmTokens, "confirm-tokens", defaultConfirmTokens, "ask before sending a prompt estimated above this many tokens, showing its cost; without a terminal --yes is needed (0 to never ask)")
+	rootCmd.PersistentFlags().
+		BoolVarP(&yesFlag, "yes", "y", false, "send large prompts without asking (see --confirm-tokens)")
 
 	// Session management flags
 	rootCmd.PersistentFlags().
@@ -294,11 +487,45 @@ func init() {
 		StringVar(&loadSessionPath, "load-session", "", "load session from file at startup")
 	rootCmd.PersistentFlags().
 		StringVarP(&sessionPath, "session", "s", "", "session file to load and update")
+	rootCmd.PersistentFlags().
+		StringVar(&teeFlag, "tee", "", "append the assistant's output to this file as it is generated")
+	rootCmd.PersistentFlags().
+		BoolVar(&teeToolsFlag, "tee-tools", false, "also write tool call summaries to the --tee file")
+	rootCmd.PersistentFlags().
+		BoolVar(&scrubPIIFlag, "scrub-pii", false, "mask emails, phone numbers and IPs before sending messages to remote providers")
+	rootCmd.PersistentFlags().
+		StringSliceVar(&scrubPatterns, "scrub-pattern", nil, "additional regular expression to mask with --scrub-pii (repeatable)")
+	rootCmd.PersistentFlags().
+		BoolVar(&localOnlyFlag, "local-only", false, "refuse to start unless the model and all MCP servers run on this machine")
+	rootCmd.PersistentFlags().
+		StringVar(&samplingPolicy, "sampling-approval", "", "policy for MCP server sampling requests: ask, allow or deny (default ask)")
+	rootCmd.PersistentFlags().
+		StringSliceVar(&attachFiles, "attach", nil, "image, audio, PDF or text file to send with --prompt (repeatable)")
+	rootCmd.PersistentFlags().
+		IntVar(&uploadThreshold, "upload-threshold", 5, "upload attachments larger than this many MB with the Anthropic Files API instead of sending them inline (0 to never upload)")
+	rootCmd.PersistentFlags().
+		BoolVar(&noPromptCache, "no-prompt-cache", false, "don't mark the system prompt and tool definitions as cacheable for Anthropic models")
+	rootCmd.PersistentFlags().
+		StringVar(&audioDir, "audio-dir", "", "save audio returned by tools to this directory")
+	rootCmd.PersistentFlags().
+		StringSliceVar(&emailResults, "email-results", nil, "email the final response and tool calls of --prompt and script runs to these addresses (needs smtp config)")
 
 	flags := rootCmd.PersistentFlags()
 	flags.StringVar(&providerURL, "provider-url", "", "base URL for the provider API (applies to OpenAI, Anthropic, Ollama, and Google)")
+	flags.BoolVar(&noProbeFlag, "no-probe", false, "don't check a custom --provider-url endpoint at startup")
+	flags.BoolVar(&offlineFlag, "offline", false, "make no network lookups at startup: no model registry refresh and no --provider-url check")
 	flags.StringVar(&providerAPIKey, "provider-api-key", "", "API key for the provider (applies to OpenAI, Anthropic, and Google)")
+	flags.StringVar(&azureAuthFlag, "azure-auth", auth.AzureAuthAuto, "Azure OpenAI authentication: auto (the API key, else Microsoft Entra ID), key, client-secret, managed-identity or cli")
 	flags.BoolVar(&tlsSkipVerify, "tls-skip-verify", false, "skip TLS certificate verification (WARNING: insecure, use only for self-signed certificates)")
+	flags.DurationVar(&providerTimeout, "provider-timeout", 0, "timeout for provider requests; while a response streams, the longest allowed gap between chunks (0 for none)")
+	flags.DurationVar(&providerConnectTimeout, "provider-connect-timeout", 0, "timeout for connecting to the provider, including the TLS handshake (0 for the default)")
+	personaFlags = flags
+	flags.StringVar(&personaFlag, "persona", "", "persona from the personas: config section, setting the system prompt, model, temperature, tools and theme")
+	flags.StringVar(&workDirFlag, "workdir", "", "directory the bash, fs and stdio MCP servers run in (default: the current directory)")
+	flags.StringArrayVar(&envFlags, "env", nil, "KEY=VALUE set in the environment of the bash and stdio MCP servers (repeatable)")
+	flags.StringVar(&stateDirFlag, "state-dir", "", "directory for sessions, caches, credentials and logs (default ~/.mcphost)")
+	flags.StringVar(&fixedTimeFlag, "fixed-time", "", "RFC 3339 time used for every timestamp shown or saved, so tests and replays give the same output")
+	flags.MarkHidden("fixed-time") // For tests and replays, hidden from help
 
 	// Model generation parameters
 	flags.IntVar(&maxTokens, "max-tokens", 4096, "maximum number of tokens in the response")
@@ -306,39 +533,175 @@ func init() {
 	flags.Float32Var(&topP, "top-p", 0.95, "controls diversity via nucleus sampling (0.0-1.0)")
 	flags.Int32Var(&topK, "top-k", 40, "controls diversity by limiting top K tokens to sample from")
 	flags.StringSliceVar(&stopSequences, "stop-sequences", nil, "custom stop sequences (comma-separated)")
-
-	// Ollama-specific parameters
-	flags.Int32Var(&numGPU, "num-gpu-layers", -1, "number of model layers to offload to GPU for Ollama models (-1 for auto-detect)")
+	flags.BoolVar(&parallelToolCalls, "parallel-tool-calls", true, "let the model make several tool calls in one response (OpenAI and Anthropic)")
+	flags.StringVar(&toolChoice, "tool-choice", agent.ToolChoiceAuto, "whether the model calls tools: auto, none, required, or a tool name (server__tool) to call first")
+	flags.StringVar(&toolEmulation, "tool-emulation", models.ToolEmulationAuto, "emulate tool calls through the model's text output: auto (for models without native tool calls), always or never")
+	flags.BoolVar(&thinkingFlag, "thinking", false, "let the model think before it answers: Anthropic extended thinking, or the reasoning effort of OpenAI reasoning models")
+	flags.IntVar(&thinkingBudget, "thinking-budget", models.DefaultThinkingBudget, "tokens the model may spend thinking with --thinking")
+
+	// Ollama-specific parameters, also used for llamacpp models but keep-alive
+	flags.Int32Var(&numGPU, "num-gpu-layers", -1, "number of model layers to offload to GPU for Ollama and llamacpp models (-1 for auto-detect)")
 	flags.MarkHidden("num-gpu-layers") // Advanced option, hidden from help
-	flags.Int32Var(&mainGPU, "main-gpu", 0, "main GPU device to use for Ollama models")
+	flags.Int32Var(&mainGPU, "main-gpu", 0, "main GPU device to use for Ollama and llamacpp models")
+	flags.IntVar(&numCtx, "num-ctx", 0, "context window of Ollama and llamacpp models in tokens (0 for the model's default)")
+	flags.IntVar(&numThread, "num-thread", 0, "CPU threads Ollama and llamacpp models use (0 for the runtime's choice)")
+	flags.DurationVar(&keepAlive, "keep-alive", 0, "how long Ollama keeps the model loaded after a request (0 for Ollama's default, negative to keep it loaded)")
 
 	// Bind flags to viper for config file support
 	viper.BindPFlag("system-prompt", rootCmd.PersistentFlags().Lookup("system-prompt"))
 	viper.BindPFlag("model", rootCmd.PersistentFlags().Lookup("model"))
+	viper.BindPFlag("shadow-model", rootCmd.PersistentFlags().Lookup("shadow-model"))
 	viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 	viper.BindPFlag("prompt", rootCmd.PersistentFlags().Lookup("prompt"))
 	viper.BindPFlag("max-steps", rootCmd.PersistentFlags().Lookup("max-steps"))
 	viper.BindPFlag("stream", rootCmd.PersistentFlags().Lookup("stream"))
 	viper.BindPFlag("compact", rootCmd.PersistentFlags().Lookup("compact"))
 	viper.BindPFlag("no-hooks", rootCmd.PersistentFlags().Lookup("no-hooks"))
+	viper.BindPFlag("no-memory", rootCmd.PersistentFlags().Lookup("no-memory"))
+	viper.BindPFlag("extract-tasks", rootCmd.PersistentFlags().Lookup("extract-tasks"))
+	viper.BindPFlag("extract-tasks-tool", rootCmd.PersistentFlags().Lookup("extract-tasks-tool"))
+	viper.BindPFlag("postmortem-summary", rootCmd.PersistentFlags().Lookup("postmortem-summary"))
+	viper.BindPFlag("lang", rootCmd.PersistentFlags().Lookup("lang"))
+	viper.BindPFlag("confirm-each-step", rootCmd.PersistentFlags().Lookup("confirm-each-step"))
+	viper.BindPFlag("max-history-messages", rootCmd.PersistentFlags().Lookup("max-history-messages"))
+	viper.BindPFlag("history-trim-policy", rootCmd.PersistentFlags().Lookup("history-trim-policy"))
+	viper.BindPFlag("history-keep-turns", rootCmd.PersistentFlags().Lookup("history-keep-turns"))
+	viper.BindPFlag("keep-duplicate-tool-results", rootCmd.PersistentFlags().Lookup("keep-duplicate-tool-results"))
+	viper.BindPFlag("plan", rootCmd.PersistentFlags().Lookup("plan"))
+	viper.BindPFlag("stop-on-tool-calls", rootCmd.PersistentFlags().Lookup("stop-on-tool-calls"))
+	viper.BindPFlag("citations", rootCmd.PersistentFlags().Lookup("citations"))
+	viper.BindPFlag("confirm-tokens", rootCmd.PersistentFlags().Lookup("confirm-tokens"))
+	viper.BindPFlag("yes", rootCmd.PersistentFlags().Lookup("yes"))
+	viper.BindPFlag("tee", rootCmd.PersistentFlags().Lookup("tee"))
+	viper.BindPFlag("tee-tools", rootCmd.PersistentFlags().Lookup("tee-tools"))
+	viper.BindPFlag("scrub-pii", rootCmd.PersistentFlags().Lookup("scrub-pii"))
+	viper.BindPFlag("scrub-patterns", rootCmd.PersistentFlags().Lookup("scrub-pattern"))
+	viper.BindPFlag("local-only", rootCmd.PersistentFlags().Lookup("local-only"))
+	viper.BindPFlag("sampling-approval", rootCmd.PersistentFlags().Lookup("sampling-approval"))
+	viper.BindPFlag("upload-threshold", rootCmd.PersistentFlags().Lookup("upload-threshold"))
+	viper.BindPFlag("no-prompt-cache", rootCmd.PersistentFlags().Lookup("no-prompt-cache"))
+	viper.BindPFlag("audio-dir", rootCmd.PersistentFlags().Lookup("audio-dir"))
+	viper.BindPFlag("email-results", rootCmd.PersistentFlags().Lookup("email-results"))
 	viper.BindPFlag("provider-url", rootCmd.PersistentFlags().Lookup("provider-url"))
+	viper.BindPFlag("no-probe", rootCmd.PersistentFlags().Lookup("no-probe"))
+	viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+	viper.BindPFlag("azure-auth", rootCmd.PersistentFlags().Lookup("azure-auth"))
 	viper.BindPFlag("provider-api-key", rootCmd.PersistentFlags().Lookup("provider-api-key"))
 	viper.BindPFlag("max-tokens", rootCmd.PersistentFlags().Lookup("max-tokens"))
 	viper.BindPFlag("temperature", rootCmd.PersistentFlags().Lookup("temperature"))
 	viper.BindPFlag("top-p", rootCmd.PersistentFlags().Lookup("top-p"))
 	viper.BindPFlag("top-k", rootCmd.PersistentFlags().Lookup("top-k"))
 	viper.BindPFlag("stop-sequences", rootCmd.PersistentFlags().Lookup("stop-sequences"))
+	viper.BindPFlag("parallel-tool-calls", rootCmd.PersistentFlags().Lookup("parallel-tool-calls"))
+	viper.BindPFlag("tool-choice", rootCmd.PersistentFlags().Lookup("tool-choice"))
+	viper.BindPFlag("tool-emulation", rootCmd.PersistentFlags().Lookup("tool-emulation"))
+	viper.BindPFlag("thinking", rootCmd.PersistentFlags().Lookup("thinking"))
+	viper.BindPFlag("thinking-budget", rootCmd.PersistentFlags().Lookup("thinking-budget"))
 	viper.BindPFlag("num-gpu-layers", rootCmd.PersistentFlags().Lookup("num-gpu-layers"))
 	viper.BindPFlag("main-gpu", rootCmd.PersistentFlags().Lookup("main-gpu"))
+	viper.BindPFlag("num-ctx", rootCmd.PersistentFlags().Lookup("num-ctx"))
+	viper.BindPFlag("num-thread", rootCmd.PersistentFlags().Lookup("num-thread"))
+	viper.BindPFlag("keep-alive", rootCmd.PersistentFlags().Lookup("keep-alive"))
 	viper.BindPFlag("tls-skip-verify", rootCmd.PersistentFlags().Lookup("tls-skip-verify"))
+	viper.BindPFlag("provider-timeout", rootCmd.PersistentFlags().Lookup("provider-timeout"))
+	viper.BindPFlag("provider-connect-timeout", rootCmd.PersistentFlags().Lookup("provider-connect-timeout"))
+	viper.BindPFlag("persona", rootCmd.PersistentFlags().Lookup("persona"))
+	viper.BindPFlag("state-dir", rootCmd.PersistentFlags().Lookup("state-dir"))
+	viper.BindEnv("state-dir", config.StateDirEnv)
+	viper.BindPFlag("fixed-time", rootCmd.PersistentFlags().Lookup("fixed-time"))
+	viper.BindEnv("fixed-time", clock.FixedTimeEnv)
 
 	// Defaults are already set in flag definitions, no need to duplicate in viper
 
+	registerFlagCompletions()
+
 	// Add subcommands
 	rootCmd.AddCommand(authCmd)
 }
 
+// BuildProviderConfig creates the model provider configuration from the
+// current viper settings (flags, environment and config file)
+func BuildProviderConfig(systemPrompt string) *models.ProviderConfig {
+	temperature := float32(viper.GetFloat64("temperature"))
+	topP := float32(viper.GetFloat64("top-p"))
+	topK := int32(viper.GetInt("top-k"))
+	numGPU := int32(viper.GetInt("num-gpu-layers"))
+	mainGPU := int32(viper.GetInt("main-gpu"))
+
+	config := &models.ProviderConfig{
+		ModelString:    viper.GetString("model"),
+		SystemPrompt:   systemPrompt,
+		ProviderAPIKey: viper.GetString("provider-api-key"),
+		ProviderURL:    viper.GetString("provider-url"),
+		MaxTokens:      viper.GetInt("max-tokens"),
+		Temperature:    &temperature,
+		TopP:           &topP,
+		TopK:           &topK,
+		StopSequences:  viper.GetStringSlice("stop-sequences"),
+		ToolChoice:     viper.GetString("tool-choice"),
+		ToolEmulation:  viper.GetString("tool-emulation"),
+		AzureAuth:      viper.GetString("azure-auth"),
+		NumGPU:         &numGPU,
+		MainGPU:        &mainGPU,
+		TLSSkipVerify:  viper.GetBool("tls-skip-verify"),
+		RequestTimeout: viper.GetDuration("provider-timeout"),
+		ConnectTimeout: viper.GetDuration("provider-connect-timeout"),
+		TaskModels:     viper.GetStringMapString("models"),
+		RouteModels:    viper.GetStringMapString("router"),
+		ShadowModel:    viper.GetString("shadow-model"),
+
+		UploadThreshold: int64(viper.GetInt("upload-threshold")) * 1024 * 1024,
+		NoPromptCache:   viper.GetBool("no-prompt-cache"),
+		NumCtx:          viper.GetInt("num-ctx"),
+		NumThread:       viper.GetInt("num-thread"),
+	}
+	if keepAlive := viper.GetDuration("keep-alive"); keepAlive != 0 {
+		config.KeepAlive = &keepAlive
+	}
+	config.ParallelToolCalls = parallelToolCallsSetting()
+	config.ThinkingBudget = thinkingBudgetSetting()
+	config.DefaultParameters = defaultedParameters()
+	return config
+}
+
+// parallelToolCallsSetting returns the parallel-tool-calls setting, or nil
+// when it was not set and each provider keeps its default
+func parallelToolCallsSetting() *bool {
+	if !viper.IsSet("parallel-tool-calls") {
+		return nil
+	}
+	parallel := viper.GetBool("parallel-tool-calls")
+	return &parallel
+}
+
+// thinkingBudgetSetting returns the thinking budget with --thinking, or 0
+// without it
+func thinkingBudgetSetting() int {
+	if !viper.GetBool("thinking") {
+		return 0
+	}
+	if budget := viper.GetInt("thinking-budget"); budget > 0 {
+		return budget
+	}
+	return models.DefaultThinkingBudget
+}
+
+// defaultedParameters reports which generation parameters were left at their
+// defaults rather than set by flag, environment or config file
+func defaultedParameters() map[string]bool {
+	defaulted := make(map[string]bool)
+	for _, name := range []string{"max-tokens", "temperature", "top-p", "top-k", "stop-sequences", "parallel-tool-calls", "tool-choice", "thinking-budget"} {
+		defaulted[name] = !viper.IsSet(name)
+	}
+	return defaulted
+}
+
 func runMCPHost(ctx context.Context) error {
+	if shouldRunSetupWizard() {
+		if err := runFirstRunSetup(ctx); err != nil {
+			return fmt.Errorf("setup failed: %v", err)
+		}
+	}
 	return runNormalMode(ctx)
 }
 
@@ -347,12 +710,47 @@ func runNormalMode(ctx context.Context) error {
 	tokens.InitializeTokenCounters()
 
 	// Validate flag combinations
-	if quietFlag && promptFlag == "" {
-		return fmt.Errorf("--quiet flag can only be used with --prompt/-p")
+	quiet, err := parseQuietLevel(quietFlag, promptFlag != "")
+	if err != nil {
+		return err
 	}
 	if noExitFlag && promptFlag == "" {
 		return fmt.Errorf("--no-exit flag can only be used with --prompt/-p")
 	}
+	if len(attachFiles) > 0 && promptFlag == "" {
+		return fmt.Errorf("--attach flag can only be used with --prompt/-p")
+	}
+
+	// Tools run in the session's workspace rather than the current directory
+	env, err := workspace.ParseEnv(envFlags)
+	if err != nil {
+		return err
+	}
+	ws, err := workspace.New(workDirFlag, env)
+	if err != nil {
+		return err
+	}
+	ctx = workspace.With(ctx, ws)
+
+	// Text piped in with --stdin goes after the prompt; --no-exit then carries
+	// on reading the terminal, which the UI reopens since stdin is used up.
+	// Without the flag stdin is left alone: a program that starts mcphost
+	// may hand it a pipe it never closes.
+	if stdinFlag && promptFlag == "" {
+		return fmt.Errorf("--stdin can only be used with --prompt/-p")
+	}
+	if stdinFlag {
+		piped, err := pipedInput()
+		if err != nil {
+			return err
+		}
+		if piped != "" && noExitFlag {
+			if err := checkTerminalInput(); err != nil {
+				return err
+			}
+		}
+		promptFlag = withPipedInput(promptFlag, piped)
+	}
 
 	// Set up logging
 	if debugMode {
@@ -361,7 +759,6 @@ func runNormalMode(ctx context.Context) error {
 
 	// Load MCP configuration
 	var mcpConfig *config.Config
-	var err error
 
 	if scriptMCPConfig != nil {
 		// Use script-provided config
@@ -374,42 +771,64 @@ func runNormalMode(ctx context.Context) error {
 		}
 	}
 
+	if viper.GetBool("local-only") {
+		if err := checkLocalOnly(viper.GetString("model"), viper.GetString("provider-url"), mcpConfig); err != nil {
+			return err
+		}
+	}
+
+	if err := i18n.SetLanguage(viper.GetString("lang")); err != nil {
+		return err
+	}
+
+	if viper.GetBool("confirm-each-step") && quiet == quietAll {
+		return fmt.Errorf("--confirm-each-step asks on the terminal and cannot be used with --quiet=all")
+	}
+	if viper.GetBool("plan") && quiet == quietAll {
+		return fmt.Errorf("--plan asks on the terminal and cannot be used with --quiet=all")
+	}
+	if err := validateStopOnToolCalls(promptFlag != ""); err != nil {
+		return err
+	}
+	if err := historyTrimOptions().Validate(); err != nil {
+		return err
+	}
+
 	// Update debug mode from viper
 	if viper.GetBool("debug") && !debugMode {
 		debugMode = viper.GetBool("debug")
 		log.SetFlags(log.LstdFlags | log.Lshortfile)
 	}
 
+	// A persona stands in for the settings the command line leaves unset
+	persona, err := selectPersona(viper.GetString("persona"))
+	if err != nil {
+		return err
+	}
+
 	systemPrompt, err := config.LoadSystemPrompt(viper.GetString("system-prompt"))
 	if err != nil {
 		return fmt.Errorf("failed to load system prompt: %v", err)
 	}
+	if systemPrompt, err = withMemory(systemPrompt); err != nil {
+		return err
+	}
 
 	// Create model configuration
-	temperature := float32(viper.GetFloat64("temperature"))
-	topP := float32(viper.GetFloat64("top-p"))
-	topK := int32(viper.GetInt("top-k"))
-	numGPU := int32(viper.GetInt("num-gpu-layers"))
-	mainGPU := int32(viper.GetInt("main-gpu"))
+	modelConfig := BuildProviderConfig(systemPrompt)
+	if err := probeProviderEndpoint(ctx, modelConfig); err != nil {
+		return err
+	}
 
-	modelConfig := &models.ProviderConfig{
-		ModelString:    viper.GetString("model"),
-		SystemPrompt:   systemPrompt,
-		ProviderAPIKey: viper.GetString("provider-api-key"),
-		ProviderURL:    viper.GetString("provider-url"),
-		MaxTokens:      viper.GetInt("max-tokens"),
-		Temperature:    &temperature,
-		TopP:           &topP,
-		TopK:           &topK,
-		StopSequences:  viper.GetStringSlice("stop-sequences"),
-		NumGPU:         &numGPU,
-		MainGPU:        &mainGPU,
-		TLSSkipVerify:  viper.GetBool("tls-skip-verify"),
+	// Read attachments before starting servers, so a bad file fails fast
+	attachments, err := loadAttachments(attachFiles, modelConfig.ModelString, modelConfig.UploadThreshold)
+	if err != nil {
+		return err
 	}
 
 	// Create spinner function for agent creation
 	var spinnerFunc agent.SpinnerFunc
-	if !quietFlag {
+	if quiet < quietStatus {
 		spinnerFunc = func(message string, fn func() error) error {
 			tempCli, tempErr := ui.NewCLI(viper.GetBool("debug"), viper.GetBool("compact"))
 			if tempErr == nil {
@@ -429,20 +848,43 @@ func runNormalMode(ctx context.Context) error {
 		debugLogger = bufferedLogger
 	}
 
+	scrubber, err := NewScrubber()
+	if err != nil {
+		return err
+	}
+
 	mcpAgent, err := agent.CreateAgent(ctx, &agent.AgentCreationOptions{ModelConfig: modelConfig,
 		MCPConfig:        mcpConfig,
 		SystemPrompt:     systemPrompt,
 		MaxSteps:         viper.GetInt("max-steps"),
 		StreamingEnabled: viper.GetBool("stream"),
 		ShowSpinner:      true,
-		Quiet:            quietFlag,
+		Quiet:            quiet >= quietStatus,
 		SpinnerFunc:      spinnerFunc,
 		DebugLogger:      debugLogger,
+		Scrubber:         scrubber,
+
+		SamplingApprovalHandler:  NewSamplingApprover(mcpConfig, promptFlag == ""),
+		KeepDuplicateToolResults: viper.GetBool("keep-duplicate-tool-results"),
+		StopOnToolCalls:          viper.GetBool("stop-on-tool-calls"),
+		StepApprovalHandler:      newStepConfirmer(),
+		Citations:                viper.GetBool("citations"),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create agent: %v", err)
 	}
 	defer mcpAgent.Close()
+	if persona != nil {
+		mcpAgent.SetToolFilter(persona.AllowsTool)
+	}
+	if err := recordShadowCalls(mcpAgent, nil); err != nil {
+		return err
+	}
+
+	guard, err := newGuard(mcpAgent)
+	if err != nil {
+		return err
+	}
 
 	// Initialize hook executor if hooks are configured
 	// Get model name for display
@@ -453,11 +895,12 @@ func runNormalMode(ctx context.Context) error {
 		modelName = parts[1]
 	}
 
+	// Generate a session ID for this run, used by hooks and extracted tasks
+	sessionID := fmt.Sprintf("mcphost-%d", time.Now().Unix())
+
 	var hookExecutor *hooks.Executor
 	if hooksConfig := viper.Get("hooks"); hooksConfig != nil {
 		if hc, ok := hooksConfig.(*hooks.HookConfig); ok {
-			// Generate a session ID for this run
-			sessionID := fmt.Sprintf("mcphost-%d", time.Now().Unix())
 			transcriptPath := "" // We could add transcript logging later
 			hookExecutor = hooks.NewExecutor(hc, sessionID, transcriptPath)
 
@@ -466,6 +909,8 @@ func runNormalMode(ctx context.Context) error {
 			hookExecutor.SetInteractive(promptFlag == "") // Interactive if no prompt flag
 		}
 	}
+	watchHookReports(hookExecutor)
+	defer waitForAsyncHooks(hookExecutor)
 
 	// Create an adapter for the agent to match the UI interface
 	agentAdapter := &agentUIAdapter{agent: mcpAgent}
@@ -476,7 +921,7 @@ func runNormalMode(ctx context.Context) error {
 		ModelString:    modelString,
 		Debug:          viper.GetBool("debug"),
 		Compact:        viper.GetBool("compact"),
-		Quiet:          quietFlag,
+		Quiet:          quiet == quietAll,
 		ShowDebug:      false, // Will be handled separately below
 		ProviderAPIKey: viper.GetString("provider-api-key"),
 	})
@@ -495,7 +940,7 @@ func runNormalMode(ctx context.Context) error {
 	}
 
 	// Display debug configuration if debug mode is enabled
-	if !quietFlag && cli != nil && viper.GetBool("debug") {
+	if cli != nil && viper.GetBool("debug") {
 		debugConfig := map[string]any{
 			"model":         viper.GetString("model"),
 			"max-steps":     viper.GetInt("max-steps"),
@@ -512,10 +957,26 @@ func runNormalMode(ctx context.Context) error {
 			debugConfig["tls-skip-verify"] = true
 		}
 
-		// Add Ollama-specific parameters if using Ollama
-		if strings.HasPrefix(viper.GetString("model"), "ollama:") {
+		if timeout := viper.GetDuration("provider-timeout"); timeout > 0 {
+			debugConfig["provider-timeout"] = timeout.String()
+		}
+		if timeout := viper.GetDuration("provider-connect-timeout"); timeout > 0 {
+			debugConfig["provider-connect-timeout"] = timeout.String()
+		}
+
+		// Add Ollama-specific parameters if using a local model
+		if provider, _, _ := strings.Cut(viper.GetString("model"), ":"); models.IsLocal(provider) {
 			debugConfig["num-gpu-layers"] = viper.GetInt("num-gpu-layers")
 			debugConfig["main-gpu"] = viper.GetInt("main-gpu")
+			if numCtx := viper.GetInt("num-ctx"); numCtx > 0 {
+				debugConfig["num-ctx"] = numCtx
+			}
+			if numThread := viper.GetInt("num-thread"); numThread > 0 {
+				debugConfig["num-thread"] = numThread
+			}
+			if keepAlive := viper.GetDuration("keep-alive"); keepAlive != 0 {
+				debugConfig["keep-alive"] = keepAlive.String()
+			}
 		}
 
 		// Only include non-empty stop sequences
@@ -529,6 +990,28 @@ func runNormalMode(ctx context.Context) error {
 			debugConfig["provider-api-key"] = "[SET]"
 		}
 
+		if taskModels := viper.GetStringMapString("models"); len(taskModels) > 0 {
+			debugConfig["models"] = taskModels
+		}
+		if routeModels := viper.GetStringMapString("router"); len(routeModels) > 0 {
+			debugConfig["router"] = routeModels
+		}
+		if shadowModel := viper.GetString("shadow-model"); shadowModel != "" {
+			debugConfig["shadow-model"] = shadowModel
+		}
+		if mcpConfig.SamplingApproval != "" {
+			debugConfig["sampling-approval"] = mcpConfig.SamplingApproval
+		}
+
+		// List parameters ignored or adjusted for this model
+		if adjustments := mcpAgent.GetParameterAdjustments(); len(adjustments) > 0 {
+			unsupported := make(map[string]string, len(adjustments))
+			for _, adjustment := range adjustments {
+				unsupported[adjustment.Parameter] = adjustment.Message
+			}
+			debugConfig["unsupported-params"] = unsupported
+		}
+
 		// Add MCP server configuration for debugging
 		if len(mcpConfig.MCPServers) > 0 {
 			mcpServers := make(map[string]any)
@@ -585,14 +1068,6 @@ func runNormalMode(ctx context.Context) error {
 		serverNames = append(serverNames, name)
 	}
 
-	tools := mcpAgent.GetTools()
-	var toolNames []string
-	for _, tool := range tools {
-		if info, err := tool.Info(ctx); err == nil {
-			toolNames = append(toolNames, info.Name)
-		}
-	}
-
 	// Main interaction logic
 	var messages []*schema.Message
 	var sessionManager *session.Manager
@@ -600,6 +1075,9 @@ func runNormalMode(ctx context.Context) error {
 		_, err := os.Stat(sessionPath)
 		if os.IsNotExist(err) {
 			content := []byte("{}")
+			if session.IsJSONL(sessionPath) {
+				content = nil
+			}
 			if err := os.WriteFile(sessionPath, content, 0664); err != nil {
 				panic(err)
 			}
@@ -608,9 +1086,12 @@ func runNormalMode(ctx context.Context) error {
 		saveSessionPath = sessionPath
 	}
 
+	// Sessions given on the command line are plain file paths
+	sessionStore := session.NewFileStore("")
+
 	// Load existing session if specified
 	if loadSessionPath != "" {
-		loadedSession, err := session.LoadFromFile(loadSessionPath)
+		loadedSession, err := sessionStore.Load(ctx, loadSessionPath)
 		if err != nil {
 			return fmt.Errorf("failed to load session: %v", err)
 		}
@@ -620,12 +1101,20 @@ func runNormalMode(ctx context.Context) error {
 			messages = append(messages, msg.ConvertToSchemaMessage())
 		}
 
+		// A session saved by a run that stopped while running tools can end
+		// with tool calls that have no results, which providers reject
+		if repaired := session.DropUnansweredToolCalls(messages); len(repaired) < len(messages) {
+			fmt.Fprintf(os.Stderr, "Warning: Dropping %d message(s) of an unfinished tool step from the end of the session\n", len(messages)-len(repaired))
+			messages = repaired
+			loadedSession.Messages = loadedSession.Messages[:len(repaired)]
+		}
+
 		// If we're also saving, use the loaded session with the session manager
 		if saveSessionPath != "" {
-			sessionManager = session.NewManagerWithSession(loadedSession, saveSessionPath)
+			sessionManager = session.NewManagerWithStore(loadedSession, sessionStore, saveSessionPath)
 		}
 
-		if !quietFlag && cli != nil {
+		if cli != nil {
 			// Create a map of tool call IDs to tool calls for quick lookup
 			toolCallMap := make(map[string]session.ToolCall)
 			for _, sessionMsg := range loadedSession.Messages {
@@ -670,33 +1159,7 @@ func runNormalMode(ctx context.Context) error {
 							}
 
 							// Parse tool result content - it might be JSON-encoded MCP content
-							resultContent := sessionMsg.Content
-
-							// Try to parse as MCP content structure
-							var mcpContent struct {
-								Content []struct {
-									Type string `json:"type"`
-									Text string `json:"text"`
-								} `json:"content"`
-							}
-
-							// First try to unmarshal as-is
-							if err := json.Unmarshal([]byte(sessionMsg.Content), &mcpContent); err == nil {
-								// Extract text from MCP content structure
-								if len(mcpContent.Content) > 0 && mcpContent.Content[0].Type == "text" {
-									resultContent = mcpContent.Content[0].Text
-								}
-							} else {
-								// If that fails, try unquoting first (in case it's double-encoded)
-								var unquoted string
-								if err := json.Unmarshal([]byte(sessionMsg.Content), &unquoted); err == nil {
-									if err := json.Unmarshal([]byte(unquoted), &mcpContent); err == nil {
-										if len(mcpContent.Content) > 0 && mcpContent.Content[0].Type == "text" {
-											resultContent = mcpContent.Content[0].Text
-										}
-									}
-								}
-							}
+							resultContent, _ := ui.ParseToolResult(sessionMsg.Content)
 
 							// Display tool result (assuming no error for saved results)
 							cli.DisplayToolMessage(toolCall.Name, argsStr, resultContent, false)
@@ -707,7 +1170,7 @@ func runNormalMode(ctx context.Context) error {
 		}
 	} else if saveSessionPath != "" {
 		// Only saving, create new session manager
-		sessionManager = session.NewManager(saveSessionPath)
+		sessionManager = session.NewManagerWithStore(nil, sessionStore, saveSessionPath)
 
 		// Set metadata
 		sessionManager.SetMetadata(session.Metadata{
@@ -716,18 +1179,16 @@ func runNormalMode(ctx context.Context) error {
 			Model:          modelName,
 		})
 	}
+	if persona != nil {
+		recordPersona(sessionManager, viper.GetString("persona"))
+	}
 
 	// Check if running in non-interactive mode
 	if promptFlag != "" {
-		return runNonInteractiveMode(ctx, mcpAgent, cli, promptFlag, modelName, messages, quietFlag, noExitFlag, mcpConfig, sessionManager, hookExecutor)
+		return runNonInteractiveMode(ctx, mcpAgent, cli, promptFlag, attachments, modelName, messages, quiet, noExitFlag, mcpConfig, sessionManager, sessionID, guard, hookExecutor)
 	}
 
-	// Quiet mode is not allowed in interactive mode
-	if quietFlag {
-		return fmt.Errorf("--quiet flag can only be used with --prompt/-p")
-	}
-
-	return runInteractiveMode(ctx, mcpAgent, cli, serverNames, toolNames, modelName, messages, sessionManager, hookExecutor)
+	return runInteractiveMode(ctx, mcpAgent, cli, serverNames, modelName, messages, quiet, sessionManager, sessionID, guard, hookExecutor)
 }
 
 // AgenticLoopConfig configures the behavior of the unified agentic loop
@@ -737,21 +1198,38 @@ type AgenticLoopConfig struct {
 	InitialPrompt    string // initial prompt for non-interactive mode
 	ContinueAfterRun bool   // true to continue to interactive mode after initial run (--no-exit)
 
+	// Attachments are the images and audio sent with the initial prompt (--attach)
+	Attachments []schema.ChatMessagePart
+
 	// UI configuration
-	Quiet bool // suppress all output except final response
+	Quiet quietLevel // how much output --quiet hides
 
 	// Context data
 	ServerNames    []string         // for slash commands
-	ToolNames      []string         // for slash commands
 	ModelName      string           // for display
 	MCPConfig      *config.Config   // for continuing to interactive mode
 	SessionManager *session.Manager // for session persistence
+	SessionID      string           // tags extracted follow-up tasks
+	Guard          *guardrail.Guard // checks final responses before they are shown (optional)
+	Tee            *teeWriter       // copy of the assistant's output (--tee), opened by runAgenticLoop
+
+	// Stats collects the telemetry of each run for /stats, created by runAgenticLoop
+	Stats *conversationStats
+}
+
+// historyTrimOptions returns the conversation length limit (--max-history-messages)
+func historyTrimOptions() session.TrimOptions {
+	return session.TrimOptions{
+		MaxMessages: viper.GetInt("max-history-messages"),
+		Policy:      viper.GetString("history-trim-policy"),
+		KeepTurns:   viper.GetInt("history-keep-turns"),
+	}
 }
 
 // addMessagesToHistory adds messages to the conversation history and saves to session if available
 func addMessagesToHistory(messages *[]*schema.Message, sessionManager *session.Manager, cli *ui.CLI, newMessages ...*schema.Message) {
-	// Add to local history
-	*messages = append(*messages, newMessages...)
+	// Add to local history, trimmed to the configured length
+	*messages = session.TrimMessages(append(*messages, newMessages...), historyTrimOptions())
 
 	// Save to session if session manager is available
 	if sessionManager != nil {
@@ -767,8 +1245,8 @@ func addMessagesToHistory(messages *[]*schema.Message, sessionManager *session.M
 
 // replaceMessagesHistory replaces the conversation history and saves to session if available
 func replaceMessagesHistory(messages *[]*schema.Message, sessionManager *session.Manager, cli *ui.CLI, newMessages []*schema.Message) {
-	// Replace local history
-	*messages = newMessages
+	// Replace local history, trimmed to the configured length
+	*messages = session.TrimMessages(newMessages, historyTrimOptions())
 
 	// Save to session if session manager is available
 	if sessionManager != nil {
@@ -784,8 +1262,22 @@ func replaceMessagesHistory(messages *[]*schema.Message, sessionManager *session
 
 // runAgenticLoop handles all execution modes with a single unified loop
 func runAgenticLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, messages []*schema.Message, config AgenticLoopConfig, hookExecutor *hooks.Executor) error {
+	if config.Tee == nil {
+		tee, err := openTee(viper.GetString("tee"), viper.GetBool("tee-tools"))
+		if err != nil {
+			return err
+		}
+		defer tee.Close()
+		config.Tee = tee
+	}
+	if config.Stats == nil {
+		config.Stats = &conversationStats{}
+	}
+
 	// Handle initial prompt for non-interactive modes
 	if !config.IsInteractive && config.InitialPrompt != "" {
+		turnCtx := startTurn(ctx, hookExecutor)
+
 		// Execute UserPromptSubmit hooks for non-interactive mode
 		if hookExecutor != nil {
 			input := &hooks.UserPromptSubmitInput{
@@ -793,7 +1285,7 @@ func runAgenticLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 				Prompt:      config.InitialPrompt,
 			}
 
-			hookOutput, err := hookExecutor.ExecuteHooks(ctx, hooks.UserPromptSubmit, input)
+			hookOutput, err := hookExecutor.ExecuteHooks(turnCtx, hooks.UserPromptSubmit, input)
 			if err != nil {
 				// Log error but don't fail
 				if debugMode {
@@ -807,16 +1299,35 @@ func runAgenticLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 			}
 		}
 
+		// Large prompts need --yes before they are sent
+		if err := confirmPromptSize(cli, append(messages, userMessage(config.InitialPrompt, config.Attachments)), false); err != nil {
+			return err
+		}
+
 		// Display user message (skip if quiet)
-		if !config.Quiet && cli != nil {
+		if config.showOutput(cli) {
 			cli.DisplayUserMessage(config.InitialPrompt)
 		}
 
+		notifiers, err := loadRunNotifiers()
+		if err != nil {
+			return err
+		}
+
 		// Create temporary messages with user input for processing (don't add to history yet)
-		tempMessages := append(messages, schema.UserMessage(config.InitialPrompt))
+		tempMessages := append(messages, turnMessage(turnCtx, userMessage(config.InitialPrompt, config.Attachments)))
 
 		// Process the initial prompt with tool calls
-		_, conversationMessages, err := runAgenticStep(ctx, mcpAgent, cli, tempMessages, config, hookExecutor)
+		step := runAgenticStep
+		if viper.GetBool("plan") {
+			step = runPlannedStep
+		}
+		started := time.Now()
+		endCheckpoints := checkpointRun(mcpAgent, config.SessionManager, messages)
+		_, conversationMessages, err := step(turnCtx, mcpAgent, cli, tempMessages, config, hookExecutor)
+		report := reportFailure(ctx, mcpAgent, endCheckpoints(err), err)
+		notifiers.runDone(ctx, config.InitialPrompt, started,
+			conversationMessages[min(len(messages), len(conversationMessages)):], err, report, config.SessionManager)
 		if err != nil {
 			// Check if this was a user cancellation
 			if err.Error() == "generation cancelled by user" && cli != nil {
@@ -850,13 +1361,111 @@ func runAgenticLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 	return nil
 }
 
+// startTurn begins the turn answering a user prompt: the returned context
+// carries its new ID, which hooks fired from now on receive as well
+func startTurn(ctx context.Context, hookExecutor *hooks.Executor) context.Context {
+	id := turn.NewID()
+	if hookExecutor != nil {
+		hookExecutor.SetTurnID(id)
+	}
+	return turn.WithID(ctx, id)
+}
+
+// turnMessage marks msg, the prompt starting a turn, with the turn of ctx
+func turnMessage(ctx context.Context, msg *schema.Message) *schema.Message {
+	turn.Mark(msg, turn.ID(ctx))
+	return msg
+}
+
 // runAgenticStep processes a single step of the agentic loop (handles tool calls)
 func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, messages []*schema.Message, config AgenticLoopConfig, hookExecutor *hooks.Executor) (*schema.Message, []*schema.Message, error) {
 	var currentSpinner *ui.Spinner
 
+	// Notification hooks fire when a model call or a tool runs long; each
+	// starts a timer that the next stage of the step stops
+	stopNotify := func() {}
+	notifyAfter := func(reason hooks.NotificationReason, toolName string) {
+		stopNotify()
+		stopNotify = hookExecutor.NotifyAfter(reason, toolName)
+	}
+	notifyAfter(hooks.NotifyLongGeneration, "")
+	defer func() { stopNotify() }()
+
+	// Instructions typed into the spinners (Tab) steer the agent's next model
+	// call; each is shown as a user message once the agent picks it up. Ctrl+P
+	// or /pause holds the run before its next step until /resume, /step or /abort.
+	var interjector *ui.Interjector
+	// Spinners show the step the run is on and how long it has taken
+	progress := &ui.RunProgress{Started: time.Now(), Step: mcpAgent.Progress}
+	newSpinner := func(message string) *ui.Spinner {
+		return ui.NewProgressSpinner(message, interjector, progress)
+	}
+	if config.showOutput(cli) {
+		interjector = ui.NewInterjector(mcpAgent.Interject, mcpAgent.Pause)
+		mcpAgent.SetInterjectionHandler(func(message string) {
+			interjector.Delivered()
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
+			}
+			cli.DisplayUserMessage(message)
+			if config.showStatus(cli) {
+				currentSpinner = newSpinner(i18n.T("spinner.thinking"))
+				currentSpinner.Start()
+			}
+		})
+		mcpAgent.SetPauseHandler(func(state agent.LoopState) agent.PauseAction {
+			interjector.Paused()
+			stopNotify()
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
+			}
+			action := pauseRun(mcpAgent, cli, state)
+			if action != agent.PauseAbort {
+				notifyAfter(hooks.NotifyLongGeneration, "")
+			}
+			if action != agent.PauseAbort && config.showStatus(cli) {
+				currentSpinner = newSpinner(i18n.T("spinner.thinking"))
+				currentSpinner.Start()
+			}
+			return action
+		})
+		// Reasoning comes before the answer or tool calls it led to; when
+		// it streams, its latest lines show until it is done
+		var thinking strings.Builder
+		mcpAgent.SetThinkingHandler(func(chunk string) {
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
+			}
+			thinking.WriteString(chunk)
+			cli.StreamReasoning(thinking.String())
+		})
+		mcpAgent.SetReasoningHandler(func(reasoning string) {
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
+			}
+			thinking.Reset()
+			cli.DisplayReasoning(reasoning)
+			if config.showStatus(cli) {
+				currentSpinner = newSpinner(i18n.T("spinner.thinking"))
+				currentSpinner.Start()
+			}
+		})
+		defer func() {
+			mcpAgent.SetInterjectionHandler(nil)
+			mcpAgent.SetPauseHandler(nil)
+			mcpAgent.SetReasoningHandler(nil)
+			mcpAgent.SetThinkingHandler(nil)
+			mcpAgent.CancelPause() // single-stepping ends with the run
+		}()
+	}
+
 	// Start initial spinner (skip if quiet)
-	if !config.Quiet && cli != nil {
-		currentSpinner = ui.NewSpinner("Thinking...")
+	if config.showStatus(cli) {
+		currentSpinner = newSpinner(i18n.T("spinner.thinking"))
 		currentSpinner.Start()
 	}
 
@@ -866,8 +1475,20 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 	var lastDisplayedContent string
 	var streamingContent strings.Builder
 	var streamingStarted bool
-	if cli != nil && !config.Quiet {
+	// Responses are not streamed when a guardrail has to check them first
+	var deltas *hooks.DeltaStream
+	if config.Guard == nil {
+		deltas = hookExecutor.StreamDeltas()
+		defer deltas.Close()
+	}
+	if (config.showOutput(cli) || config.Tee != nil || deltas != nil) && config.Guard == nil {
 		streamingCallback = func(chunk string) {
+			config.Tee.Chunk(chunk)
+			deltas.Write(chunk)
+			if !config.showOutput(cli) {
+				return
+			}
+
 			// Stop spinner before first chunk if still running
 			if currentSpinner != nil {
 				currentSpinner.Stop()
@@ -906,25 +1527,30 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 			// Store tool info for use in execution handler
 			currentToolName = toolName
 			currentToolArgs = toolArgs
+			config.Tee.ToolCall(toolName, toolArgs)
+			stopNotify()
 
-			if !config.Quiet && cli != nil {
-				// Stop spinner before displaying tool call
-				if currentSpinner != nil {
-					currentSpinner.Stop()
-					currentSpinner = nil
-				}
+			// Stop spinner before displaying tool call
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
+			}
+			if config.showTools(cli) {
 				cli.DisplayToolCallMessage(toolName, toolArgs)
 			}
 		},
 		// Tool execution handler - called when tool execution starts/ends
 		func(toolName string, isStarting bool) {
 			if isStarting {
+				notifyAfter(hooks.NotifyLongTool, toolName)
+
 				// Execute PreToolUse hooks
 				if hookExecutor != nil {
 					input := &hooks.PreToolUseInput{
-						CommonInput: hookExecutor.PopulateCommonFields(hooks.PreToolUse),
-						ToolName:    currentToolName,
-						ToolInput:   json.RawMessage(currentToolArgs),
+						CommonInput:  hookExecutor.PopulateCommonFields(hooks.PreToolUse),
+						ToolMetadata: toolHookMetadata(mcpAgent, currentToolName),
+						ToolName:     currentToolName,
+						ToolInput:    json.RawMessage(currentToolArgs),
 					}
 
 					hookOutput, err := hookExecutor.ExecuteHooks(ctx, hooks.PreToolUse, input)
@@ -942,20 +1568,22 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 						if blockReason == "" {
 							blockReason = "Tool execution blocked by security policy"
 						}
-						if !config.Quiet && cli != nil {
+						if config.showTools(cli) {
 							cli.DisplayInfo(fmt.Sprintf("Tool execution blocked by hook: %s", blockReason))
 						}
 					}
 				}
 
-				if !config.Quiet && cli != nil {
+				if config.showStatus(cli) {
 					// Start spinner for tool execution
-					currentSpinner = ui.NewSpinner(fmt.Sprintf("Executing %s...", toolName))
+					currentSpinner = newSpinner(i18n.T("spinner.executing", toolName))
 					currentSpinner.Start()
 				}
 			} else {
+				notifyAfter(hooks.NotifyLongGeneration, "")
+
 				// Stop spinner when tool execution completes
-				if !config.Quiet && cli != nil && currentSpinner != nil {
+				if currentSpinner != nil {
 					currentSpinner.Stop()
 					currentSpinner = nil
 				}
@@ -963,6 +1591,9 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 		},
 		// Tool result handler - called when a tool execution completes
 		func(toolName, toolArgs, result string, isError bool) {
+			// Record the final result, including any block override below
+			defer func() { config.Tee.ToolResult(toolName, result, isError) }()
+
 			// Check if this tool was blocked
 			if toolIsBlocked {
 				// Reset the flag for next tool
@@ -974,7 +1605,7 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 				isError = true
 
 				// Display the blocked message
-				if !config.Quiet && cli != nil {
+				if config.showTools(cli) {
 					cli.DisplayToolMessage(toolName, toolArgs, fmt.Sprintf("Tool execution blocked: %s", blockReason), true)
 				}
 
@@ -988,6 +1619,7 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 			if hookExecutor != nil && result != "" {
 				input := &hooks.PostToolUseInput{
 					CommonInput:  hookExecutor.PopulateCommonFields(hooks.PostToolUse),
+					ToolMetadata: toolHookMetadata(mcpAgent, currentToolName),
 					ToolName:     currentToolName,
 					ToolInput:    json.RawMessage(currentToolArgs),
 					ToolResponse: json.RawMessage(result),
@@ -1010,58 +1642,44 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 				return
 			}
 
-			if !config.Quiet && cli != nil {
+			if config.showTools(cli) {
 				// Parse tool result content - it might be JSON-encoded MCP content
-				resultContent := result
-
-				// Try to parse as MCP content structure
-				var mcpContent struct {
-					Content []struct {
-						Type string `json:"type"`
-						Text string `json:"text"`
-					} `json:"content"`
-				}
+				resultContent, media := ui.ParseToolResult(result)
 
-				// First try to unmarshal as-is
-				if err := json.Unmarshal([]byte(result), &mcpContent); err == nil {
-					// Extract text from MCP content structure
-					if len(mcpContent.Content) > 0 && mcpContent.Content[0].Type == "text" {
-						resultContent = mcpContent.Content[0].Text
+				cli.DisplayToolMessage(toolName, toolArgs, resultContent, isError)
+				cli.DisplayImages(media)
+				if dir := viper.GetString("audio-dir"); dir != "" {
+					paths, err := saveToolAudio(dir, media)
+					for _, path := range paths {
+						cli.DisplayInfo(fmt.Sprintf("Saved audio to %s", path))
 					}
-				} else {
-					// If that fails, try unquoting first (in case it's double-encoded)
-					var unquoted string
-					if err := json.Unmarshal([]byte(result), &unquoted); err == nil {
-						if err := json.Unmarshal([]byte(unquoted), &mcpContent); err == nil {
-							if len(mcpContent.Content) > 0 && mcpContent.Content[0].Type == "text" {
-								resultContent = mcpContent.Content[0].Text
-							}
-						}
+					if err != nil {
+						cli.DisplayError(fmt.Errorf("failed to save audio: %v", err))
 					}
 				}
-
-				cli.DisplayToolMessage(toolName, toolArgs, resultContent, isError)
-				// Reset streaming state for next LLM call
-				responseWasStreamed = false
-				streamingStarted = false
+			}
+			// Reset streaming state for next LLM call
+			responseWasStreamed = false
+			streamingStarted = false
+			if config.showStatus(cli) {
 				// Start spinner again for next LLM call
-				currentSpinner = ui.NewSpinner("Thinking...")
+				currentSpinner = newSpinner(i18n.T("spinner.thinking"))
 				currentSpinner.Start()
 			}
 		},
 		// Response handler - called when the LLM generates a response
 		func(content string) {
-			if !config.Quiet && cli != nil {
-				// Stop spinner when we get the final response
-				if currentSpinner != nil {
-					currentSpinner.Stop()
-					currentSpinner = nil
-				}
+			stopNotify()
+			// Stop spinner when we get the final response
+			if currentSpinner != nil {
+				currentSpinner.Stop()
+				currentSpinner = nil
 			}
 		},
 		// Tool call content handler - called when content accompanies tool calls
 		func(content string) {
-			if !config.Quiet && cli != nil && !responseWasStreamed {
+			config.Tee.Message(content)
+			if config.showOutput(cli) && !responseWasStreamed {
 				// Only display if content wasn't already streamed
 				// Stop spinner before displaying content
 				if currentSpinner != nil {
@@ -1070,9 +1688,6 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 				}
 				cli.DisplayAssistantMessageWithModel(content, config.ModelName)
 				lastDisplayedContent = content
-				// Start spinner again for tool calls
-				currentSpinner = ui.NewSpinner("Thinking...")
-				currentSpinner.Start()
 			} else if responseWasStreamed {
 				// Content was already streamed, just track it and manage spinner
 				lastDisplayedContent = content
@@ -1080,8 +1695,10 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 					currentSpinner.Stop()
 					currentSpinner = nil
 				}
+			}
+			if config.showStatus(cli) {
 				// Start spinner again for tool calls
-				currentSpinner = ui.NewSpinner("Thinking...")
+				currentSpinner = newSpinner(i18n.T("spinner.thinking"))
 				currentSpinner.Start()
 			}
 		},
@@ -1089,12 +1706,14 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 	)
 
 	// Make sure spinner is stopped if still running
-	if !config.Quiet && cli != nil && currentSpinner != nil {
+	if currentSpinner != nil {
 		currentSpinner.Stop()
 	}
 
 	if err != nil {
-		if !config.Quiet && cli != nil {
+		mcpAgent.TakeInterjections() // drop instructions for the failed run
+		config.Tee.Message("")       // end any partially streamed message
+		if config.showOutput(cli) && !errors.Is(err, agent.ErrAborted) {
 			cli.DisplayError(fmt.Errorf("agent error: %v", err))
 		}
 		return nil, nil, err
@@ -1102,6 +1721,23 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 
 	// Get the final response and conversation messages
 	response := result.FinalResponse
+	config.Stats.record(result.Telemetry)
+
+	// Check the response before anything shows or records it; the guarded
+	// text replaces it in the conversation as well
+	var verdict guardrail.Verdict
+	if config.Guard != nil {
+		var guardSpinner *ui.Spinner
+		if config.showStatus(cli) {
+			guardSpinner = ui.NewSpinner(i18n.T("spinner.checking_response"))
+			guardSpinner.Start()
+		}
+		response.Content, verdict = config.Guard.Apply(ctx, response.Content)
+		if guardSpinner != nil {
+			guardSpinner.Stop()
+		}
+	}
+	config.Tee.Message(response.Content)
 	conversationMessages := result.ConversationMessages
 
 	// Extract the last user message for usage tracking (do this once)
@@ -1116,32 +1752,69 @@ func runAgenticStep(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, mes
 		}
 	}
 
-	// Update usage tracking for ALL responses (streaming and non-streaming)
-	if !config.Quiet && cli != nil {
-		cli.UpdateUsageFromResponse(response, lastUserMessage)
+	// Update usage tracking for ALL responses (streaming and non-streaming),
+	// counting every model call made during this turn
+	if config.showOutput(cli) {
+		var turnResponses []*schema.Message
+		for _, msg := range conversationMessages[min(len(messages), len(conversationMessages)):] {
+			if msg.Role == schema.Assistant {
+				turnResponses = append(turnResponses, msg)
+			}
+		}
+		if len(turnResponses) == 0 || turnResponses[len(turnResponses)-1] != response {
+			turnResponses = append(turnResponses, response)
+		}
+		cli.UpdateUsageFromTurn(turnResponses, lastUserMessage)
 	}
 
 	// Display assistant response with model name
 	// Skip if: quiet mode, same content already displayed, or if streaming completed the full response
 	streamedFullResponse := responseWasStreamed && streamingContent.String() == response.Content
-	if !config.Quiet && cli != nil && response.Content != lastDisplayedContent && response.Content != "" && !streamedFullResponse {
+	if config.showOutput(cli) && response.Content != lastDisplayedContent && response.Content != "" && !streamedFullResponse {
 		if err := cli.DisplayAssistantMessageWithModel(response.Content, config.ModelName); err != nil {
 			cli.DisplayError(fmt.Errorf("display error: %v", err))
 			return nil, nil, err
 		}
-	} else if config.Quiet {
+	} else if config.Quiet == quietAll && len(result.PendingToolCalls) == 0 {
 		// In quiet mode, only output the final response content to stdout
 		fmt.Print(response.Content)
 	}
 
+	if verdict.Flagged {
+		displayGuardrailVerdict(cli, config.Quiet == quietAll, verdict)
+	}
+
+	if len(result.PendingToolCalls) > 0 {
+		displayPendingToolCalls(cli, config.Quiet == quietAll, result.PendingToolCalls)
+	}
+
+	// A blocked response no longer says what the sources were cited for
+	if len(result.Citations) > 0 && verdict.Action != guardrail.ActionBlock {
+		displaySources(cli, config.Quiet == quietAll, result.Citations)
+	}
+
 	// Display usage information immediately after the response (for both streaming and non-streaming)
-	if !config.Quiet && cli != nil {
+	if config.showStatus(cli) {
 		cli.DisplayUsageAfterResponse()
 	}
 
 	// Execute Stop hook after agent has finished responding
 	executeStopHook(hookExecutor, response, "completed", config.ModelName)
 
+	// Record the action items the response leaves open (--extract-tasks)
+	if viper.GetBool("extract-tasks") {
+		extractFollowUpTasks(ctx, mcpAgent, cli, response.Content, config)
+	}
+
+	// Instructions sent after the final model call start the next turn
+	if pending := mcpAgent.TakeInterjections(); len(pending) > 0 {
+		for _, message := range pending {
+			cli.DisplayUserMessage(message)
+			conversationMessages = append(conversationMessages, schema.UserMessage(message))
+		}
+		return runAgenticStep(ctx, mcpAgent, cli, conversationMessages, config, hookExecutor)
+	}
+
 	// Return the final response and all conversation messages
 	return response, conversationMessages, nil
 }
@@ -1184,9 +1857,11 @@ func executeStopHook(hookExecutor *hooks.Executor, response *schema.Message, sto
 func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, messages []*schema.Message, config AgenticLoopConfig, hookExecutor *hooks.Executor) error {
 	for {
 		// Get user input
+		stopNotify := hookExecutor.NotifyAfter(hooks.NotifyWaitingForInput, "")
 		prompt, err := cli.GetPrompt()
+		stopNotify()
 		if err == io.EOF {
-			fmt.Println("\n  Goodbye!")
+			fmt.Println("\n  " + i18n.T("goodbye"))
 			return nil
 		}
 		if err != nil {
@@ -1196,6 +1871,7 @@ func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI,
 		if prompt == "" {
 			continue
 		}
+		turnCtx := startTurn(ctx, hookExecutor)
 
 		// Execute UserPromptSubmit hooks
 		if hookExecutor != nil {
@@ -1204,7 +1880,7 @@ func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI,
 				Prompt:      prompt,
 			}
 
-			hookOutput, err := hookExecutor.ExecuteHooks(ctx, hooks.UserPromptSubmit, input)
+			hookOutput, err := hookExecutor.ExecuteHooks(turnCtx, hooks.UserPromptSubmit, input)
 			if err != nil {
 				// Log error but don't fail
 				if debugMode {
@@ -1228,9 +1904,48 @@ func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI,
 				return nil // Exit interactive loop gracefully
 			}
 		}
+		// Invoke a tool directly, bypassing the model
+		if toolName, toolArgs, ok := parseCallCommand(prompt); ok {
+			handleCallCommand(turnCtx, mcpAgent.GetTools(), cli, toolName, toolArgs)
+			continue
+		}
+
+		if subcommand, ok := parseMemoryCommand(prompt); ok {
+			handleMemoryCommand(cli, subcommand)
+			continue
+		}
+
+		if command, ok := parsePauseCommand(prompt); ok {
+			handleIdlePauseCommand(mcpAgent, cli, command)
+			continue
+		}
+
+		if name, ok := parsePersonaCommand(prompt); ok {
+			handlePersonaCommand(ctx, mcpAgent, cli, name, &messages, config.SessionManager)
+			continue
+		}
+
+		if isStatsCommand(prompt) {
+			handleStatsCommand(cli, messages, config.Stats)
+			continue
+		}
+
+		// /plan <task> plans a single task; --plan plans every one
+		step := runAgenticStep
+		if task, ok := parsePlanCommand(prompt); ok {
+			if task == "" {
+				cli.DisplayError(fmt.Errorf("usage: /plan <task>"))
+				continue
+			}
+			prompt = task
+			step = runPlannedStep
+		} else if viper.GetBool("plan") {
+			step = runPlannedStep
+		}
+
 		// Handle slash commands
 		if cli.IsSlashCommand(prompt) {
-			result := cli.HandleSlashCommand(prompt, config.ServerNames, config.ToolNames)
+			result := cli.HandleSlashCommand(prompt, config.ServerNames, currentToolNames(ctx, mcpAgent))
 			if result.Handled {
 				// If the command was to clear history, clear the messages slice and session
 				if result.ClearHistory {
@@ -1244,17 +1959,30 @@ func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI,
 			continue
 		}
 
+		// Large prompts are sent only once the user confirms them
+		if err := confirmPromptSize(cli, append(messages, schema.UserMessage(prompt)), true); err != nil {
+			cli.DisplayInfo("Prompt not sent.")
+			continue
+		}
+
 		// Display user message
 		cli.DisplayUserMessage(prompt)
 
 		// Create temporary messages with user input for processing
-		tempMessages := append(messages, schema.UserMessage(prompt))
-		// Process the user input with tool calls
-		_, conversationMessages, err := runAgenticStep(ctx, mcpAgent, cli, tempMessages, config, hookExecutor)
+		tempMessages := append(messages, turnMessage(turnCtx, schema.UserMessage(prompt)))
+		// Process the user input with tool calls, saving each message as it
+		// is added
+		endCheckpoints := checkpointRun(mcpAgent, config.SessionManager, messages)
+		_, conversationMessages, err := step(turnCtx, mcpAgent, cli, tempMessages, config, hookExecutor)
+		endCheckpoints(err)
 		if err != nil {
 			// Check if this was a user cancellation
 			if err.Error() == "generation cancelled by user" {
 				cli.DisplayCancellation()
+			} else if errors.Is(err, agent.ErrAborted) {
+				cli.DisplayInfo("Run aborted. Its messages were not added to the conversation.")
+			} else if errors.Is(err, errPlanCancelled) {
+				cli.DisplayInfo("Plan cancelled. Nothing was run.")
 			} else {
 				cli.DisplayError(fmt.Errorf("agent error: %v", err))
 			}
@@ -1262,56 +1990,64 @@ func runInteractiveLoop(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI,
 		}
 
 		// Only add to history after successful completion
-		// conversationMessages already includes the user message, tool calls, and final response
-		addMessagesToHistory(&messages, config.SessionManager, cli, conversationMessages...)
+		// conversationMessages already includes the earlier history, the user
+		// message, tool calls, and final response
+		replaceMessagesHistory(&messages, config.SessionManager, cli, conversationMessages)
 	}
 }
 
 // runNonInteractiveMode handles the non-interactive mode execution
-func runNonInteractiveMode(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, prompt, modelName string, messages []*schema.Message, quiet, noExit bool, mcpConfig *config.Config, sessionManager *session.Manager, hookExecutor *hooks.Executor) error {
+func runNonInteractiveMode(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, prompt string, attachments []schema.ChatMessagePart, modelName string, messages []*schema.Message, quiet quietLevel, noExit bool, mcpConfig *config.Config, sessionManager *session.Manager, sessionID string, guard *guardrail.Guard, hookExecutor *hooks.Executor) error {
 	// Prepare data for slash commands (needed if continuing to interactive mode)
 	var serverNames []string
 	for name := range mcpConfig.MCPServers {
 		serverNames = append(serverNames, name)
 	}
 
-	tools := mcpAgent.GetTools()
-	var toolNames []string
-	for _, tool := range tools {
-		if info, err := tool.Info(ctx); err == nil {
-			toolNames = append(toolNames, info.Name)
-		}
-	}
-
 	// Configure and run unified agentic loop
 	config := AgenticLoopConfig{
 		IsInteractive:    false,
 		InitialPrompt:    prompt,
+		Attachments:      attachments,
 		ContinueAfterRun: noExit,
 		Quiet:            quiet,
 		ServerNames:      serverNames,
-		ToolNames:        toolNames,
 		ModelName:        modelName,
 		MCPConfig:        mcpConfig,
 		SessionManager:   sessionManager,
+		SessionID:        sessionID,
+		Guard:            guard,
 	}
 
 	return runAgenticLoop(ctx, mcpAgent, cli, messages, config, hookExecutor)
 }
 
+// currentToolNames returns the names of the tools the agent offers now, which
+// change when servers announce new tools
+func currentToolNames(ctx context.Context, mcpAgent *agent.Agent) []string {
+	var toolNames []string
+	for _, tool := range mcpAgent.GetTools() {
+		if info, err := tool.Info(ctx); err == nil {
+			toolNames = append(toolNames, info.Name)
+		}
+	}
+	return toolNames
+}
+
 // runInteractiveMode handles the interactive mode execution
-func runInteractiveMode(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, serverNames, toolNames []string, modelName string, messages []*schema.Message, sessionManager *session.Manager, hookExecutor *hooks.Executor) error {
+func runInteractiveMode(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, serverNames []string, modelName string, messages []*schema.Message, quiet quietLevel, sessionManager *session.Manager, sessionID string, guard *guardrail.Guard, hookExecutor *hooks.Executor) error {
 	// Configure and run unified agentic loop
 	config := AgenticLoopConfig{
 		IsInteractive:    true,
 		InitialPrompt:    "",
 		ContinueAfterRun: false,
-		Quiet:            false,
+		Quiet:            quiet,
 		ServerNames:      serverNames,
-		ToolNames:        toolNames,
 		ModelName:        modelName,
 		MCPConfig:        nil, // Not needed for pure interactive mode
 		SessionManager:   sessionManager,
+		SessionID:        sessionID,
+		Guard:            guard,
 	}
 
 	return runAgenticLoop(ctx, mcpAgent, cli, messages, config, hookExecutor)
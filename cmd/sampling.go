@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/charmbracelet/huh"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// NewSamplingApprover builds the approval handler for MCP sampling requests
+// from the sampling-approval policy. Interactive sessions ask on the terminal
+// for servers whose policy is ask. Other runs, including serve, have no one to
+// ask and deny them; the allow policy opts a server in explicitly.
+func NewSamplingApprover(mcpConfig *config.Config, interactive bool) tools.SamplingApprovalHandler {
+	if !interactive {
+		return tools.NewSamplingApprover(mcpConfig, nil)
+	}
+
+	// Servers sample from background goroutines; ask one question at a time
+	var mu sync.Mutex
+	return tools.NewSamplingApprover(mcpConfig, func(serverName, preview string) (bool, string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// The spinner reads keys too, so it has to go before the prompt
+		ui.StopActiveSpinner()
+
+		allow := false
+		err := huh.NewConfirm().
+			Title(fmt.Sprintf("MCP server %s wants to use your model", serverName)).
+			Description(preview).
+			Affirmative("Allow").
+			Negative("Deny").
+			Value(&allow).
+			Run()
+		if err != nil {
+			return false, fmt.Sprintf("approval prompt failed: %v", err)
+		}
+		if !allow {
+			return false, "denied by the user"
+		}
+		return true, ""
+	})
+}
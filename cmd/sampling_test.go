@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+func TestNewSamplingApproverNonInteractive(t *testing.T) {
+	mcpConfig := &config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"notes":  {Type: "local", Command: []string{"notes-mcp"}},
+		"search": {Type: "local", Command: []string{"search-mcp"}, SamplingApproval: config.SamplingDeny},
+		"tasks":  {Type: "local", Command: []string{"tasks-mcp"}, SamplingApproval: config.SamplingAllow},
+	}}
+	approve := NewSamplingApprover(mcpConfig, false)
+
+	// With no one to ask, the default policy fails closed
+	if allow, _ := approve("notes", "Summarize"); allow {
+		t.Error("expected the request to be denied with no one to approve it")
+	}
+	if allow, _ := approve("search", "Summarize"); allow {
+		t.Error("expected a server whose policy is deny to be refused")
+	}
+	if allow, reason := approve("tasks", "Summarize"); !allow {
+		t.Errorf("expected a server opted in with allow to be allowed, got %q", reason)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/osi4iot/mcphost/internal/agent"
 	"github.com/osi4iot/mcphost/internal/config"
 	"github.com/osi4iot/mcphost/internal/hooks"
+	"github.com/osi4iot/mcphost/internal/i18n"
 	"github.com/osi4iot/mcphost/internal/models"
 	"github.com/osi4iot/mcphost/internal/tools"
 	"github.com/osi4iot/mcphost/internal/ui"
@@ -129,6 +130,9 @@ func overrideConfigWithFrontmatter(scriptFile string, variables map[string]strin
 	if len(scriptConfig.StopSequences) > 0 && !flagChanged("stop-sequences") {
 		viper.Set("stop-sequences", scriptConfig.StopSequences)
 	}
+	if scriptConfig.ToolChoice != "" && !flagChanged("tool-choice") {
+		viper.Set("tool-choice", scriptConfig.ToolChoice)
+	}
 	if scriptConfig.NoExit && !flagChanged("no-exit") {
 		// Set the global noExitFlag variable if it wasn't explicitly set via command line
 		noExitFlag = scriptConfig.NoExit
@@ -139,6 +143,9 @@ func overrideConfigWithFrontmatter(scriptFile string, variables map[string]strin
 	if scriptConfig.TLSSkipVerify && !flagChanged("tls-skip-verify") {
 		viper.Set("tls-skip-verify", scriptConfig.TLSSkipVerify)
 	}
+	if len(scriptConfig.EmailResults) > 0 && !flagChanged("email-results") {
+		viper.Set("email-results", scriptConfig.EmailResults)
+	}
 }
 
 // parseCustomVariables extracts custom variables from command line arguments
@@ -211,7 +218,7 @@ func runScriptCommand(ctx context.Context, scriptFile string, variables map[stri
 
 	// Get MCP config - use script servers if available, otherwise use global viper config
 	var mcpConfig *config.Config
-	if len(scriptConfig.MCPServers) > 0 {
+	if len(scriptConfig.MCPServers) > 0 || len(scriptConfig.Tools) > 0 {
 		// Load base config and merge with script config
 		baseConfig, err := config.LoadAndValidateConfig()
 		if err != nil {
@@ -394,6 +401,9 @@ func parseScriptContent(content string, variables map[string]string) (*config.Co
 		if stopSequences := frontmatterViper.GetStringSlice("stop-sequences"); len(stopSequences) > 0 {
 			scriptConfig.StopSequences = stopSequences
 		}
+		if toolChoice := frontmatterViper.GetString("tool-choice"); toolChoice != "" {
+			scriptConfig.ToolChoice = toolChoice
+		}
 		if noExit := frontmatterViper.GetBool("no-exit"); noExit {
 			scriptConfig.NoExit = noExit
 		}
@@ -509,6 +519,27 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		log.SetFlags(log.LstdFlags | log.Lshortfile)
 	}
 
+	if err := i18n.SetLanguage(viper.GetString("lang")); err != nil {
+		return err
+	}
+
+	quiet, err := parseQuietLevel(quietFlag, prompt != "")
+	if err != nil {
+		return err
+	}
+	if viper.GetBool("confirm-each-step") && quiet == quietAll {
+		return fmt.Errorf("--confirm-each-step asks on the terminal and cannot be used with --quiet=all")
+	}
+	if viper.GetBool("plan") && quiet == quietAll {
+		return fmt.Errorf("--plan asks on the terminal and cannot be used with --quiet=all")
+	}
+	if err := validateStopOnToolCalls(true); err != nil {
+		return err
+	}
+	if err := historyTrimOptions().Validate(); err != nil {
+		return err
+	}
+
 	// Get final values from viper and script config
 	finalModel := viper.GetString("model")
 	if finalModel == "" && mcpConfig.Model != "" {
@@ -535,6 +566,12 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		finalProviderURL = mcpConfig.ProviderURL
 	}
 
+	if viper.GetBool("local-only") {
+		if err := checkLocalOnly(finalModel, finalProviderURL, mcpConfig); err != nil {
+			return err
+		}
+	}
+
 	finalProviderAPIKey := viper.GetString("provider-api-key")
 	if finalProviderAPIKey == "" && mcpConfig.ProviderAPIKey != "" {
 		finalProviderAPIKey = mcpConfig.ProviderAPIKey
@@ -582,6 +619,9 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 	if err != nil {
 		return fmt.Errorf("failed to load system prompt: %v", err)
 	}
+	if systemPrompt, err = withMemory(systemPrompt); err != nil {
+		return err
+	}
 
 	// Create model configuration
 	modelConfig := &models.ProviderConfig{
@@ -594,7 +634,24 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		TopP:           &finalTopP,
 		TopK:           &finalTopK,
 		StopSequences:  finalStopSequences,
+		ToolChoice:     viper.GetString("tool-choice"),
+		ToolEmulation:  viper.GetString("tool-emulation"),
+		AzureAuth:      viper.GetString("azure-auth"),
 		TLSSkipVerify:  viper.GetBool("tls-skip-verify"),
+		RequestTimeout: viper.GetDuration("provider-timeout"),
+		ConnectTimeout: viper.GetDuration("provider-connect-timeout"),
+		TaskModels:     viper.GetStringMapString("models"),
+		RouteModels:    viper.GetStringMapString("router"),
+		ShadowModel:    viper.GetString("shadow-model"),
+
+		UploadThreshold: int64(viper.GetInt("upload-threshold")) * 1024 * 1024,
+		NoPromptCache:   viper.GetBool("no-prompt-cache"),
+	}
+	modelConfig.ParallelToolCalls = parallelToolCallsSetting()
+	modelConfig.ThinkingBudget = thinkingBudgetSetting()
+	modelConfig.DefaultParameters = defaultedParameters()
+	if err := probeProviderEndpoint(ctx, modelConfig); err != nil {
+		return err
 	}
 
 	// Create the agent using the factory (scripts don't need spinners)
@@ -604,6 +661,11 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		debugLogger = tools.NewSimpleDebugLogger(true)
 	}
 
+	scrubber, err := NewScrubber()
+	if err != nil {
+		return err
+	}
+
 	mcpAgent, err := agent.CreateAgent(ctx, &agent.AgentCreationOptions{
 		ModelConfig:      modelConfig,
 		MCPConfig:        mcpConfig,
@@ -611,14 +673,29 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		MaxSteps:         finalMaxSteps,
 		StreamingEnabled: viper.GetBool("stream"),
 		ShowSpinner:      false, // Scripts don't need spinners
-		Quiet:            quietFlag,
+		Quiet:            quiet >= quietStatus,
 		SpinnerFunc:      nil, // No spinner function needed
 		DebugLogger:      debugLogger,
+		Scrubber:         scrubber,
+
+		SamplingApprovalHandler:  NewSamplingApprover(mcpConfig, prompt == ""),
+		KeepDuplicateToolResults: viper.GetBool("keep-duplicate-tool-results"),
+		StopOnToolCalls:          viper.GetBool("stop-on-tool-calls"),
+		StepApprovalHandler:      newStepConfirmer(),
+		Citations:                viper.GetBool("citations"),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create agent: %v", err)
 	}
 	defer mcpAgent.Close()
+	if err := recordShadowCalls(mcpAgent, nil); err != nil {
+		return err
+	}
+
+	guard, err := newGuard(mcpAgent)
+	if err != nil {
+		return err
+	}
 
 	// Get model name for display
 	parts := strings.SplitN(finalModel, ":", 2)
@@ -636,7 +713,7 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		ModelString:    finalModel,
 		Debug:          finalDebug,
 		Compact:        finalCompact,
-		Quiet:          quietFlag,
+		Quiet:          quiet == quietAll,
 		ShowDebug:      false, // Will be handled separately below
 		ProviderAPIKey: finalProviderAPIKey,
 	})
@@ -645,7 +722,7 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 	}
 
 	// Display debug configuration if debug mode is enabled
-	if !quietFlag && cli != nil && finalDebug {
+	if cli != nil && finalDebug {
 		debugConfig := map[string]any{
 			"model":         finalModel,
 			"max-steps":     finalMaxSteps,
@@ -670,12 +747,13 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		cli.DisplayDebugConfig(debugConfig)
 	}
 
+	// Generate a session ID for this run, used by hooks and extracted tasks
+	sessionID := fmt.Sprintf("mcphost-%d", time.Now().Unix())
+
 	// Initialize hooks
 	var hookExecutor *hooks.Executor
 	if hooksConfig := viper.Get("hooks"); hooksConfig != nil {
 		if hc, ok := hooksConfig.(*hooks.HookConfig); ok {
-			// Generate a session ID for this run
-			sessionID := fmt.Sprintf("mcphost-%d", time.Now().Unix())
 			transcriptPath := "" // We could add transcript logging later
 			hookExecutor = hooks.NewExecutor(hc, sessionID, transcriptPath)
 
@@ -684,6 +762,8 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 			hookExecutor.SetInteractive(prompt == "")
 		}
 	}
+	watchHookReports(hookExecutor)
+	defer waitForAsyncHooks(hookExecutor)
 
 	// Prepare data for slash commands
 	var serverNames []string
@@ -691,25 +771,18 @@ func runScriptMode(ctx context.Context, mcpConfig *config.Config, prompt string,
 		serverNames = append(serverNames, name)
 	}
 
-	tools := mcpAgent.GetTools()
-	var toolNames []string
-	for _, tool := range tools {
-		if info, err := tool.Info(ctx); err == nil {
-			toolNames = append(toolNames, info.Name)
-		}
-	}
-
 	// Configure and run unified agentic loop
 	var messages []*schema.Message
 	config := AgenticLoopConfig{
 		IsInteractive:    prompt == "", // If no prompt, start in interactive mode
 		InitialPrompt:    prompt,
 		ContinueAfterRun: noExit,
-		Quiet:            quietFlag,
+		Quiet:            quiet,
 		ServerNames:      serverNames,
-		ToolNames:        toolNames,
 		ModelName:        modelName,
 		MCPConfig:        mcpConfig,
+		SessionID:        sessionID,
+		Guard:            guard,
 	}
 
 	return runAgenticLoop(ctx, mcpAgent, cli, messages, config, hookExecutor)
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/osi4iot/mcphost/internal/scrub"
+	"github.com/spf13/viper"
+)
+
+// NewScrubber builds the PII scrubber from --scrub-pii and scrub-patterns, or
+// returns nil when scrubbing is off
+func NewScrubber() (*scrub.Scrubber, error) {
+	if !viper.GetBool("scrub-pii") {
+		return nil, nil
+	}
+	return scrub.New(viper.GetStringSlice("scrub-patterns"))
+}
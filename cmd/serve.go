@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/metrics"
+	"github.com/osi4iot/mcphost/internal/server"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+var (
+	serveAddr        string
+	serveSessionsDir string
+	serveSessionFmt  string
+	serveNoMetrics   bool
+	serveAPIKeys     []string
+	serveRateLimit   int
+	serveLedgerPath  string
+	serveJobsDir     string
+	serveJobWorkers  int
+	servePublicURL   string
+	serveGRPCAddr    string
+
+	// How long, and how many, finished jobs are kept
+	serveJobRetention time.Duration
+	serveMaxJobs      int
+
+	// Limit the work_dir and env requests may set
+	serveWorkspaceRoots []string
+	serveWorkspaceEnv   []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run MCPHost as an HTTP API server",
+	Long: `Run MCPHost as a long-lived HTTP API server.
+
+The server loads the configured model and MCP servers once and exposes them over
+a small JSON API:
+
+  POST   /v1/prompt          {"prompt": "...", "session_id": "..."}
+  GET    /v1/sessions        list stored sessions
+  GET    /v1/sessions/{id}   fetch a session
+  DELETE /v1/sessions/{id}   delete a session
+  GET    /v1/servers         MCP server status
+  GET    /v1/tools           available tools and their schemas
+  GET    /v1/usage           usage totals for the calling API key
+  POST   /v1/jobs            {"prompt": "...", "session_id": "..."} run in the background
+  GET    /v1/jobs            list background jobs
+  GET    /v1/jobs/{id}       job status and result
+  GET    /v1/jobs/{id}/logs  tool calls and response so far (?after=N for newer ones)
+  POST   /v1/jobs/{id}/cancel  cancel a queued or running job
+  DELETE /v1/jobs/{id}       delete a finished job
+  GET    /healthz            liveness probe
+  GET    /metrics            Prometheus metrics (disable with --no-metrics)
+
+With --grpc-addr the same prompts, event streams, tools and sessions are also
+served over gRPC (proto/mcphost/v1/mcphost.proto); the sdk/mcphostpb package
+has the generated Go client.
+
+When API keys are configured (--api-key or serve.api-keys in the config file),
+every endpoint except /healthz and /metrics requires "Authorization: Bearer <key>"
+or "X-API-Key: <key>", sent as metadata on gRPC calls, and each key gets its own
+rate limit and usage record.
+Jobs and sessions are only visible to the key that created them.
+
+Requests may set a work_dir inside a --workspace-root, and env variables named
+with --workspace-env; without them, both are refused.
+
+Use "mcphost submit" and "mcphost jobs" to work with jobs from the command line.
+Webhooks in the config file are notified as jobs finish.
+
+Examples:
+  mcphost serve --addr :8080 --api-key "$MCPHOST_API_KEY" --rate-limit 30
+  mcphost serve --state-dir /var/lib/mcphost -m ollama:qwen2.5:3b
+  mcphost serve --addr :8080 --grpc-addr :9090`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServe(cmd.Context())
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "address to listen on")
+	serveCmd.Flags().StringVar(&serveSessionsDir, "sessions-dir", "", "directory to persist sessions in (default: <state-dir>/sessions)")
+	serveCmd.Flags().StringVar(&serveSessionFmt, "session-format", "json", "format for stored sessions: json (rewritten each turn) or jsonl (appended)")
+	serveCmd.Flags().BoolVar(&serveNoMetrics, "no-metrics", false, "disable the Prometheus /metrics endpoint")
+	serveCmd.Flags().StringSliceVar(&serveAPIKeys, "api-key", nil, "API key accepted by the server (repeatable)")
+	serveCmd.Flags().IntVar(&serveRateLimit, "rate-limit", 0, "requests per minute allowed for each --api-key (0 for unlimited)")
+	serveCmd.Flags().StringVar(&serveLedgerPath, "usage-ledger", "", "JSONL file to record per-key usage in (default: <state-dir>/usage.jsonl)")
+	serveCmd.Flags().StringVar(&serveJobsDir, "jobs-dir", "", "directory to persist background jobs in (default: <state-dir>/jobs)")
+	serveCmd.Flags().IntVar(&serveJobWorkers, "job-workers", 1, "number of background jobs to run at once")
+	serveCmd.Flags().DurationVar(&serveJobRetention, "job-retention", 7*24*time.Hour, "how long finished jobs are kept (0 to keep them until --max-jobs)")
+	serveCmd.Flags().IntVar(&serveMaxJobs, "max-jobs", 1000, "number of jobs kept, dropping the oldest finished ones first (0 for no limit)")
+	serveCmd.Flags().StringVar(&serveGRPCAddr, "grpc-addr", "", "address to also serve the gRPC API on (default: no gRPC)")
+	serveCmd.Flags().StringSliceVar(&serveWorkspaceRoots, "workspace-root", nil, "directory under which requests may set their work_dir (repeatable; default: work_dir is refused)")
+	serveCmd.Flags().StringSliceVar(&serveWorkspaceEnv, "workspace-env", nil, "environment variable requests may set in their env (repeatable; default: env is refused)")
+	serveCmd.Flags().StringVar(&servePublicURL, "public-url", "", "URL clients reach the server at, for session links in webhooks (default: from --addr)")
+
+	viper.BindPFlag("serve.addr", serveCmd.Flags().Lookup("addr"))
+	viper.BindPFlag("serve.sessions-dir", serveCmd.Flags().Lookup("sessions-dir"))
+	viper.BindPFlag("serve.session-format", serveCmd.Flags().Lookup("session-format"))
+	viper.BindPFlag("serve.no-metrics", serveCmd.Flags().Lookup("no-metrics"))
+	viper.BindPFlag("serve.rate-limit", serveCmd.Flags().Lookup("rate-limit"))
+	viper.BindPFlag("serve.usage-ledger", serveCmd.Flags().Lookup("usage-ledger"))
+	viper.BindPFlag("serve.jobs-dir", serveCmd.Flags().Lookup("jobs-dir"))
+	viper.BindPFlag("serve.job-workers", serveCmd.Flags().Lookup("job-workers"))
+	viper.BindPFlag("serve.job-retention", serveCmd.Flags().Lookup("job-retention"))
+	viper.BindPFlag("serve.max-jobs", serveCmd.Flags().Lookup("max-jobs"))
+	viper.BindPFlag("serve.public-url", serveCmd.Flags().Lookup("public-url"))
+	viper.BindPFlag("serve.grpc-addr", serveCmd.Flags().Lookup("grpc-addr"))
+	viper.BindPFlag("serve.workspace-roots", serveCmd.Flags().Lookup("workspace-root"))
+	viper.BindPFlag("serve.workspace-env", serveCmd.Flags().Lookup("workspace-env"))
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mcpConfig, err := config.LoadAndValidateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load MCP config: %v", err)
+	}
+
+	if viper.GetBool("local-only") {
+		if err := checkLocalOnly(viper.GetString("model"), viper.GetString("provider-url"), mcpConfig); err != nil {
+			return err
+		}
+	}
+
+	systemPrompt, err := config.LoadSystemPrompt(viper.GetString("system-prompt"))
+	if err != nil {
+		return fmt.Errorf("failed to load system prompt: %v", err)
+	}
+
+	modelConfig := BuildProviderConfig(systemPrompt)
+	if err := probeProviderEndpoint(ctx, modelConfig); err != nil {
+		return err
+	}
+
+	scrubber, err := NewScrubber()
+	if err != nil {
+		return err
+	}
+
+	mcpAgent, err := agent.CreateAgent(ctx, &agent.AgentCreationOptions{
+		ModelConfig:      modelConfig,
+		MCPConfig:        mcpConfig,
+		SystemPrompt:     systemPrompt,
+		MaxSteps:         viper.GetInt("max-steps"),
+		StreamingEnabled: viper.GetBool("stream"),
+		Quiet:            true,
+		Scrubber:         scrubber,
+
+		// No terminal to listen on; requests are cancelled by the client
+		DisableESCListener: true,
+
+		SamplingApprovalHandler:  NewSamplingApprover(mcpConfig, false),
+		KeepDuplicateToolResults: viper.GetBool("keep-duplicate-tool-results"),
+		Citations:                viper.GetBool("citations"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create agent: %v", err)
+	}
+	defer mcpAgent.Close()
+	for _, adjustment := range mcpAgent.GetParameterAdjustments() {
+		if !adjustment.Defaulted {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", adjustment)
+		}
+	}
+
+	sessionsDir := viper.GetString("serve.sessions-dir")
+	if sessionsDir == "" {
+		if sessionsDir, err = config.StatePath("sessions"); err != nil {
+			return err
+		}
+	}
+	var store *session.FileStore
+	switch format := viper.GetString("serve.session-format"); format {
+	case "", "json":
+		store = session.NewFileStore(sessionsDir)
+	case "jsonl":
+		store = session.NewFileStoreWithExtension(sessionsDir, session.ExtJSONL)
+	default:
+		return fmt.Errorf("unsupported session format %q (use json or jsonl)", format)
+	}
+
+	var m *metrics.Metrics
+	if !viper.GetBool("serve.no-metrics") {
+		m = metrics.New()
+	}
+
+	apiKeys, err := loadServeAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	usageLedger, err := openUsageLedger()
+	if err != nil {
+		return err
+	}
+	if err := recordShadowCalls(mcpAgent, usageLedger); err != nil {
+		return err
+	}
+
+	jobsDir := viper.GetString("serve.jobs-dir")
+	if jobsDir == "" {
+		if jobsDir, err = config.StatePath("jobs"); err != nil {
+			return err
+		}
+	}
+
+	webhooks, err := loadWebhooks()
+	if err != nil {
+		return err
+	}
+	guard, err := newGuard(mcpAgent)
+	if err != nil {
+		return err
+	}
+	addr := viper.GetString("serve.addr")
+	grpcAddr := viper.GetString("serve.grpc-addr")
+	publicURL := viper.GetString("serve.public-url")
+	if publicURL == "" {
+		publicURL = serveAddrURL(addr)
+	}
+
+	srv := server.New(&server.Config{
+		Agent:       mcpAgent,
+		Store:       store,
+		ModelString: viper.GetString("model"),
+		Metrics:     m,
+		Ledger:      usageLedger,
+		APIKeys:     apiKeys,
+		JobsDir:     jobsDir,
+		JobWorkers:  viper.GetInt("serve.job-workers"),
+		Webhooks:    webhooks,
+		BaseURL:     publicURL,
+		Guard:       guard,
+		GRPCAddr:    grpcAddr,
+		Workspaces: workspace.Policy{
+			Roots:   viper.GetStringSlice("serve.workspace-roots"),
+			EnvKeys: viper.GetStringSlice("serve.workspace-env"),
+		},
+		JobRetention: viper.GetDuration("serve.job-retention"),
+		MaxJobs:      viper.GetInt("serve.max-jobs"),
+	})
+
+	for _, listenAddr := range []string{addr, grpcAddr} {
+		if listenAddr != "" && len(apiKeys) == 0 && !isLoopbackAddr(listenAddr) {
+			fmt.Fprintf(os.Stderr, "Warning: serving on %s without API keys; anyone who can reach it can use your model and tools\n", listenAddr)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "MCPHost API listening on %s (model %s)\n", addr, viper.GetString("model"))
+	if grpcAddr != "" {
+		fmt.Fprintf(os.Stderr, "MCPHost gRPC API listening on %s\n", grpcAddr)
+	}
+	return srv.ListenAndServe(ctx, addr)
+}
+
+// openUsageLedger opens the usage ledger: serve.usage-ledger, or usage.jsonl
+// in the state directory
+func openUsageLedger() (*ledger.Ledger, error) {
+	ledgerPath := viper.GetString("serve.usage-ledger")
+	if ledgerPath == "" {
+		var err error
+		if ledgerPath, err = config.StatePath("usage.jsonl"); err != nil {
+			return nil, err
+		}
+	}
+	usageLedger, err := ledger.Open(ledgerPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %v", err)
+	}
+	return usageLedger, nil
+}
+
+// loadServeAPIKeys combines keys from the config file (serve.api-keys) with
+// keys passed via --api-key
+func loadServeAPIKeys() ([]server.APIKey, error) {
+	var keys []server.APIKey
+	if viper.IsSet("serve.api-keys") {
+		if err := viper.UnmarshalKey("serve.api-keys", &keys); err != nil {
+			return nil, fmt.Errorf("invalid serve.api-keys config: %v", err)
+		}
+	}
+	for i, key := range serveAPIKeys {
+		keys = append(keys, server.APIKey{
+			Name:              fmt.Sprintf("flag-%d", i+1),
+			Key:               key,
+			RequestsPerMinute: viper.GetInt("serve.rate-limit"),
+		})
+	}
+
+	seen := make(map[string]bool)
+	for i, key := range keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("serve.api-keys[%d]: key must not be empty", i)
+		}
+		if key.Name == "" {
+			keys[i].Name = fmt.Sprintf("key-%d", i+1)
+		}
+		if seen[keys[i].Name] {
+			return nil, fmt.Errorf("duplicate API key name %q", keys[i].Name)
+		}
+		seen[keys[i].Name] = true
+	}
+	return keys, nil
+}
+
+// isLoopbackAddr reports whether addr only listens on a loopback interface
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/osi4iot/mcphost/internal/auth"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// setupAnswers collects the choices made in the setup wizard
+type setupAnswers struct {
+	Provider string
+	Model    string
+	APIKey   string   // written to the config file; Anthropic keys go to the credential store instead
+	Servers  []string // builtin servers to enable
+	FSDir    string   // allowed directory for the fs server
+}
+
+// setupFile is the config file written by the wizard
+type setupFile struct {
+	Model          string                 `yaml:"model"`
+	ProviderAPIKey string                 `yaml:"provider-api-key,omitempty"`
+	MCPServers     map[string]setupServer `yaml:"mcpServers,omitempty"`
+}
+
+type setupServer struct {
+	Type    string         `yaml:"type"`
+	Name    string         `yaml:"name"`
+	Options map[string]any `yaml:"options,omitempty"`
+}
+
+// setupServerNames maps builtin server names to the config keys the wizard uses
+var setupServerNames = map[string]string{
+	"fs":    "filesystem",
+	"fetch": "fetch",
+	"bash":  "bash",
+}
+
+// setupDefaultModels are preselected for each provider
+var setupDefaultModels = map[string]string{
+	"anthropic": "claude-sonnet-4-20250514",
+	"openai":    "gpt-4o",
+	"google":    "gemini-2.5-flash",
+	"groq":      "llama-3.3-70b-versatile",
+	"xai":       "grok-4",
+	"deepseek":  "deepseek-chat",
+}
+
+// setupKeyEnvVars are the environment variables each provider reads its API key from
+var setupKeyEnvVars = map[string][]string{
+	"anthropic": {"ANTHROPIC_API_KEY"},
+	"openai":    {"OPENAI_API_KEY"},
+	"google":    {"GOOGLE_API_KEY", "GEMINI_API_KEY"},
+	"groq":      {"GROQ_API_KEY"},
+	"xai":       {"XAI_API_KEY"},
+	"deepseek":  {"DEEPSEEK_API_KEY"},
+}
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Interactively create ~/.mcphost.yml",
+	Long: `Walk through choosing a provider, authenticating, picking a model and enabling
+starter MCP servers, then write the result to ~/.mcphost.yml.
+
+The wizard also runs automatically the first time mcphost is started in a
+terminal without a config file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInteractiveTerminal() {
+			return fmt.Errorf("setup needs an interactive terminal")
+		}
+		if existing, _ := config.FindUserConfig(); existing != "" {
+			overwrite := false
+			err := huh.NewConfirm().
+				Title(fmt.Sprintf("%s already exists. Overwrite it?", existing)).
+				Value(&overwrite).
+				Run()
+			if err != nil || !overwrite {
+				return nil
+			}
+		}
+		_, err := runSetupWizard(cmd.Context())
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+}
+
+// isInteractiveTerminal reports whether both stdin and stdout are terminals
+func isInteractiveTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// shouldRunSetupWizard reports whether this is an interactive first run: no
+// config file anywhere and nothing that would be confused by a prompt
+func shouldRunSetupWizard() bool {
+	if promptFlag != "" || configFile != "" || scriptMCPConfig != nil || viper.ConfigFileUsed() != "" {
+		return false
+	}
+	if existing, err := config.FindUserConfig(); err != nil || existing != "" {
+		return false
+	}
+	return isInteractiveTerminal()
+}
+
+// runFirstRunSetup runs the wizard and loads the config it wrote. If the user
+// backs out, the commented default config is written instead so the wizard
+// does not come back on every start.
+func runFirstRunSetup(ctx context.Context) error {
+	path, err := runSetupWizard(ctx)
+	if errors.Is(err, huh.ErrUserAborted) {
+		fmt.Println("Setup skipped. Run `mcphost setup` any time to configure mcphost.")
+		return config.EnsureConfigExists()
+	}
+	if err != nil {
+		return err
+	}
+	return LoadConfigWithEnvSubstitution(path)
+}
+
+// runSetupWizard asks the setup questions and writes ~/.mcphost.yml, returning its path
+func runSetupWizard(ctx context.Context) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	answers := setupAnswers{}
+
+	ollamaModels := detectOllamaModels(ctx)
+	ollamaLabel := "Ollama (local models)"
+	if len(ollamaModels) > 0 {
+		ollamaLabel = fmt.Sprintf("Ollama (detected, %d models installed)", len(ollamaModels))
+		answers.Provider = "ollama"
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title("Welcome to MCPHost").
+			Description("Let's set up a model and a few tools. This writes ~/.mcphost.yml,\nwhich you can edit later (see `mcphost docs config`)."),
+		huh.NewSelect[string]().
+			Title("Which model provider do you want to use?").
+			Options(
+				huh.NewOption("Anthropic (Claude)", "anthropic"),
+				huh.NewOption("OpenAI", "openai"),
+				huh.NewOption("Google (Gemini)", "google"),
+				huh.NewOption("Groq", "groq"),
+				huh.NewOption("xAI (Grok)", "xai"),
+				huh.NewOption("DeepSeek", "deepseek"),
+				huh.NewOption(ollamaLabel, "ollama"),
+			).
+			Value(&answers.Provider),
+	)).Run()
+	if err != nil {
+		return "", err
+	}
+
+	if answers.Provider == "ollama" {
+		err = askOllamaModel(&answers, ollamaModels)
+	} else {
+		err = askProviderModel(&answers)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cwd, _ := os.Getwd()
+	answers.FSDir = cwd
+	answers.Servers = []string{"fs", "fetch"}
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[string]().
+			Title("Which builtin tools should the model have?").
+			Options(
+				huh.NewOption("fs - read and write files", "fs"),
+				huh.NewOption("fetch - fetch web pages", "fetch"),
+				huh.NewOption("bash - run shell commands", "bash"),
+			).
+			Value(&answers.Servers),
+	), huh.NewGroup(
+		huh.NewInput().
+			Title("Which directory may the fs tools access?").
+			Value(&answers.FSDir),
+	).WithHideFunc(func() bool {
+		for _, s := range answers.Servers {
+			if s == "fs" {
+				return false
+			}
+		}
+		return true
+	})).Run()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := buildSetupConfig(answers)
+	if err != nil {
+		return "", err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	path := filepath.Join(home, ".mcphost.yml")
+	// The file may hold an API key
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", fmt.Errorf("failed to write config: %v", err)
+	}
+
+	fmt.Printf("✅ Wrote %s (model %s:%s)\n\n", path, answers.Provider, answers.Model)
+	return path, nil
+}
+
+// askProviderModel picks a registry model and sets up authentication for a
+// hosted provider
+func askProviderModel(answers *setupAnswers) error {
+	registryModels, err := models.GetGlobalRegistry().GetModelsForProvider(answers.Provider)
+	if err != nil {
+		return err
+	}
+	var options []huh.Option[string]
+	for id, info := range registryModels {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s)", info.Name, id), id))
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].Value < options[j].Value })
+	answers.Model = setupDefaultModels[answers.Provider]
+
+	method := "paste"
+	methods := []huh.Option[string]{}
+	for _, envVar := range setupKeyEnvVars[answers.Provider] {
+		if os.Getenv(envVar) != "" {
+			methods = append(methods, huh.NewOption("Use $"+envVar+" from the environment", "env"))
+			method = "env"
+			break
+		}
+	}
+	if answers.Provider == "anthropic" {
+		methods = append(methods, huh.NewOption("Log in with your Claude account (OAuth)", "oauth"))
+	}
+	methods = append(methods,
+		huh.NewOption("Paste an API key", "paste"),
+		huh.NewOption("Skip for now", "skip"),
+	)
+
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewSelect[string]().
+			Title("Model").
+			Options(options...).
+			Height(10).
+			Value(&answers.Model),
+		huh.NewSelect[string]().
+			Title("How should mcphost authenticate?").
+			Options(methods...).
+			Value(&method),
+	), huh.NewGroup(
+		huh.NewInput().
+			Title("API key").
+			EchoMode(huh.EchoModePassword).
+			Validate(func(s string) error {
+				if s == "" {
+					return fmt.Errorf("API key is required")
+				}
+				return nil
+			}).
+			Value(&answers.APIKey),
+	).WithHideFunc(func() bool { return method != "paste" })).Run()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case method == "oauth":
+		return loginAnthropic()
+	case method == "paste" && answers.Provider == "anthropic":
+		// Keep Anthropic keys in the credential store rather than the config file
+		cm, err := auth.NewCredentialManager()
+		if err != nil {
+			return err
+		}
+		if err := cm.SetAnthropicCredentials(answers.APIKey); err != nil {
+			return err
+		}
+		answers.APIKey = ""
+	}
+	return nil
+}
+
+// askOllamaModel picks one of the installed Ollama models, or asks for a name
+func askOllamaModel(answers *setupAnswers, installed []string) error {
+	if len(installed) == 0 {
+		answers.Model = "qwen2.5:3b"
+		return huh.NewInput().
+			Title("Ollama model").
+			Description("No local Ollama server was found; make sure it is running and the model is pulled.").
+			Value(&answers.Model).
+			Run()
+	}
+
+	answers.Model = installed[0]
+	return huh.NewSelect[string]().
+		Title("Ollama model").
+		Options(huh.NewOptions(installed...)...).
+		Value(&answers.Model).
+		Run()
+}
+
+// detectOllamaModels lists the models of a local Ollama server, if one is running
+func detectOllamaModels(ctx context.Context) []string {
+	baseURL := "http://localhost:11434"
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		baseURL = host
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&tags) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildSetupConfig renders the wizard answers as a config file
+func buildSetupConfig(answers setupAnswers) ([]byte, error) {
+	file := setupFile{
+		Model:          answers.Provider + ":" + answers.Model,
+		ProviderAPIKey: answers.APIKey,
+		MCPServers:     make(map[string]setupServer),
+	}
+	for _, name := range answers.Servers {
+		server := setupServer{Type: "builtin", Name: name}
+		if name == "fs" && answers.FSDir != "" {
+			server.Options = map[string]any{"allowed_directories": []string{answers.FSDir}}
+		}
+		file.MCPServers[setupServerNames[name]] = server
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render config: %v", err)
+	}
+	header := "# MCPHost configuration, created by `mcphost setup`\n" +
+		"# See `mcphost docs config` for every available setting.\n\n"
+	return append([]byte(header), data...), nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildSetupConfig(t *testing.T) {
+	content, err := buildSetupConfig(setupAnswers{
+		Provider: "openai",
+		Model:    "gpt-4o",
+		APIKey:   "sk-test",
+		Servers:  []string{"fs", "bash"},
+		FSDir:    "/home/me/projects",
+	})
+	if err != nil {
+		t.Fatalf("buildSetupConfig failed: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "# MCPHost configuration") {
+		t.Errorf("expected header comment, got %q", content)
+	}
+
+	var parsed struct {
+		Model          string `yaml:"model"`
+		ProviderAPIKey string `yaml:"provider-api-key"`
+		MCPServers     map[string]struct {
+			Type    string         `yaml:"type"`
+			Name    string         `yaml:"name"`
+			Options map[string]any `yaml:"options"`
+		} `yaml:"mcpServers"`
+	}
+	if err := yaml.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("generated config is not valid YAML: %v", err)
+	}
+	if parsed.Model != "openai:gpt-4o" || parsed.ProviderAPIKey != "sk-test" {
+		t.Errorf("unexpected model settings: %+v", parsed)
+	}
+	if len(parsed.MCPServers) != 2 || parsed.MCPServers["bash"].Name != "bash" {
+		t.Errorf("unexpected servers: %+v", parsed.MCPServers)
+	}
+	dirs, _ := parsed.MCPServers["filesystem"].Options["allowed_directories"].([]any)
+	if len(dirs) != 1 || dirs[0] != "/home/me/projects" {
+		t.Errorf("unexpected fs options: %+v", parsed.MCPServers["filesystem"].Options)
+	}
+}
+
+func TestBuildSetupConfigOmitsEmptyKey(t *testing.T) {
+	content, _ := buildSetupConfig(setupAnswers{Provider: "ollama", Model: "qwen2.5:3b"})
+	if strings.Contains(string(content), "provider-api-key") {
+		t.Errorf("expected no API key in config, got %s", content)
+	}
+}
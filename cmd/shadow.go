@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// shadowSource is the ledger source of shadow model calls, which usage
+// totals leave out
+const shadowSource = "shadow"
+
+// recordShadowCalls records each call of the agent's shadow model to the
+// usage ledger, opening the default one when usageLedger is nil. Agents
+// without a shadow model record nothing.
+func recordShadowCalls(mcpAgent *agent.Agent, usageLedger *ledger.Ledger) error {
+	if mcpAgent.ShadowModel() == "" {
+		return nil
+	}
+	if usageLedger == nil {
+		var err error
+		if usageLedger, err = openUsageLedger(); err != nil {
+			return err
+		}
+	}
+	mcpAgent.SetShadowHandler(func(call agent.ShadowCall) {
+		// The shadow model is never shown, so neither are its bookkeeping errors
+		usageLedger.Record(shadowEntry(call))
+	})
+	return nil
+}
+
+// shadowEntry is the ledger entry of a shadow model call
+func shadowEntry(call agent.ShadowCall) ledger.Entry {
+	entry := ledger.Entry{
+		Source:    shadowSource,
+		TurnID:    call.TurnID,
+		Model:     call.Model,
+		Shadows:   call.Shadows,
+		LatencyMS: call.Duration.Milliseconds(),
+	}
+	if call.Main != nil {
+		if counts, ok := models.TokenCountsFromMessage(call.Main); ok {
+			entry.ShadowsCost = ledger.EstimateCost(call.Shadows, counts)
+		}
+	}
+	if call.Err != nil {
+		entry.Error = call.Err.Error()
+		return entry
+	}
+	if call.Response == nil {
+		entry.Error = "no response"
+		return entry
+	}
+	if counts, ok := models.TokenCountsFromMessage(call.Response); ok {
+		entry.InputTokens = counts.InputTokens
+		entry.OutputTokens = counts.OutputTokens
+		entry.CacheReadTokens = counts.CacheReadTokens
+		entry.CacheWriteTokens = counts.CacheWriteTokens
+		entry.ReasoningTokens = counts.ReasoningTokens
+	}
+	entry.Response = call.Response.Content
+	for _, toolCall := range call.Response.ToolCalls {
+		entry.ToolCalls = append(entry.ToolCalls, toolCall.Function.Name+" "+toolCall.Function.Arguments)
+	}
+	return entry
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+)
+
+func TestShadowEntry(t *testing.T) {
+	withUsage := func(msg *schema.Message, prompt, completion int) *schema.Message {
+		msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: prompt, CompletionTokens: completion}}
+		return msg
+	}
+	toolCall := []schema.ToolCall{{ID: "call-1", Function: schema.FunctionCall{Name: "fs__grep", Arguments: `{"pattern":"TODO"}`}}}
+	entry := shadowEntry(agent.ShadowCall{
+		Model:    "openai:gpt-4o-mini",
+		Shadows:  "anthropic:claude-sonnet-4-20250514",
+		TurnID:   "turn_abc",
+		Response: withUsage(schema.AssistantMessage("Looking.", toolCall), 1000, 100),
+		Main:     withUsage(schema.AssistantMessage("", nil), 1000, 100),
+		Duration: 1500 * time.Millisecond,
+	})
+	if entry.Source != shadowSource || entry.TurnID != "turn_abc" || entry.InputTokens != 1000 || entry.OutputTokens != 100 || entry.LatencyMS != 1500 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.Response != "Looking." || len(entry.ToolCalls) != 1 || entry.ToolCalls[0] != `fs__grep {"pattern":"TODO"}` {
+		t.Errorf("expected the shadow model's answer, got %q %v", entry.Response, entry.ToolCalls)
+	}
+	if entry.ShadowsCost <= 0 {
+		t.Errorf("expected the main model's call priced, got %v", entry.ShadowsCost)
+	}
+
+	failed := shadowEntry(agent.ShadowCall{Model: "openai:gpt-4o-mini", Err: errors.New("429 too many requests")})
+	if failed.Error != "429 too many requests" || failed.Response != "" {
+		t.Errorf("expected the failure recorded, got %+v", failed)
+	}
+}
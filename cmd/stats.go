@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/tokens"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// conversationStats collects the telemetry of each run in this process, for
+// /stats. Its methods are safe to call on a nil *conversationStats.
+type conversationStats struct {
+	mu   sync.Mutex
+	runs []agent.RunTelemetry
+}
+
+// record adds the telemetry of a completed run
+func (s *conversationStats) record(telemetry agent.RunTelemetry) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, telemetry)
+}
+
+// telemetry returns the telemetry of the runs recorded so far
+func (s *conversationStats) telemetry() []agent.RunTelemetry {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]agent.RunTelemetry(nil), s.runs...)
+}
+
+// statsSummary sums up a conversation for /stats
+type statsSummary struct {
+	Turns        int
+	ToolCalls    map[string]int
+	TokensByRole map[schema.RoleType]int // estimated from the messages' text
+	Usage        models.TokenCounts      // as reported by the provider
+	Cost         float64
+
+	// From the telemetry of the runs in this process
+	ModelTime   time.Duration
+	ToolTime    time.Duration
+	LongestTool agent.ToolTiming
+}
+
+// summarizeStats sums up messages, using runs for the time spent and pricing
+// the reported usage as modelString
+func summarizeStats(messages []*schema.Message, runs []agent.RunTelemetry, modelString string) statsSummary {
+	summary := statsSummary{
+		ToolCalls:    make(map[string]int),
+		TokensByRole: make(map[schema.RoleType]int),
+	}
+	for _, msg := range messages {
+		text := msg.Content
+		switch msg.Role {
+		case schema.User:
+			summary.Turns++
+		case schema.Assistant:
+			for _, call := range msg.ToolCalls {
+				summary.ToolCalls[call.Function.Name]++
+				text += call.Function.Arguments
+			}
+			if counts, ok := models.TokenCountsFromMessage(msg); ok {
+				summary.Usage.Add(counts)
+			}
+		}
+		summary.TokensByRole[msg.Role] += tokens.EstimateTokens(text)
+	}
+	summary.Cost = ledger.EstimateCost(modelString, summary.Usage)
+
+	for _, run := range runs {
+		summary.ModelTime += run.ModelTime
+		summary.ToolTime += run.ToolTime()
+		for _, call := range run.ToolCalls {
+			if call.Duration > summary.LongestTool.Duration {
+				summary.LongestTool = call
+			}
+		}
+	}
+	return summary
+}
+
+// markdown renders the summary for display
+func (s statsSummary) markdown() string {
+	var b strings.Builder
+	b.WriteString("## Conversation Statistics\n\n")
+	fmt.Fprintf(&b, "- Turns: %d\n", s.Turns)
+
+	total := 0
+	names := make([]string, 0, len(s.ToolCalls))
+	for name, count := range s.ToolCalls {
+		names = append(names, name)
+		total += count
+	}
+	// Most used first
+	sort.Slice(names, func(i, j int) bool {
+		if s.ToolCalls[names[i]] != s.ToolCalls[names[j]] {
+			return s.ToolCalls[names[i]] > s.ToolCalls[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintf(&b, "- Tool calls: %d\n", total)
+	for _, name := range names {
+		fmt.Fprintf(&b, "  - %s: %d\n", name, s.ToolCalls[name])
+	}
+
+	b.WriteString("- Estimated tokens by role:\n")
+	for _, role := range []schema.RoleType{schema.System, schema.User, schema.Assistant, schema.Tool} {
+		if n := s.TokensByRole[role]; n > 0 {
+			fmt.Fprintf(&b, "  - %s: %d\n", role, n)
+		}
+	}
+	if s.Usage.PromptTokens() > 0 || s.Usage.OutputTokens > 0 {
+		fmt.Fprintf(&b, "- Reported tokens: %d input, %d output\n", s.Usage.PromptTokens(), s.Usage.OutputTokens)
+	}
+
+	if s.ModelTime > 0 || s.ToolTime > 0 {
+		fmt.Fprintf(&b, "- Time in the model: %s\n", roundDuration(s.ModelTime))
+		fmt.Fprintf(&b, "- Time in tools: %s\n", roundDuration(s.ToolTime))
+	}
+	if s.LongestTool.Name != "" {
+		fmt.Fprintf(&b, "- Longest tool call: %s (%s)\n", s.LongestTool.Name, roundDuration(s.LongestTool.Duration))
+	}
+	if s.Cost > 0 {
+		fmt.Fprintf(&b, "- Estimated cost: $%.4f\n", s.Cost)
+	}
+	return b.String()
+}
+
+// roundDuration rounds d for display
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
+
+// isStatsCommand recognises /stats
+func isStatsCommand(input string) bool {
+	return strings.TrimSpace(input) == "/stats"
+}
+
+// handleStatsCommand shows the statistics of the conversation so far. Times
+// cover the runs of this process, not those of a loaded session.
+func handleStatsCommand(cli *ui.CLI, messages []*schema.Message, stats *conversationStats) {
+	summary := summarizeStats(messages, stats.telemetry(), viper.GetString("model"))
+	cli.DisplayInfo(summary.markdown())
+}
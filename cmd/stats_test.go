@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+)
+
+func TestSummarizeStats(t *testing.T) {
+	withCalls := schema.AssistantMessage("", []schema.ToolCall{
+		{ID: "1", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}},
+		{ID: "2", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.sum"}`}},
+		{ID: "3", Function: schema.FunctionCall{Name: "bash__run_shell", Arguments: `{"command": "go test ./..."}`}},
+	})
+	withCalls.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 1000, CompletionTokens: 100}}
+	answer := schema.AssistantMessage("All tests pass.", nil)
+	answer.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 2000, CompletionTokens: 50}}
+	messages := []*schema.Message{
+		schema.SystemMessage("You are helpful."),
+		schema.UserMessage("Run the tests"),
+		withCalls,
+		schema.ToolMessage("module example", "1"),
+		schema.ToolMessage("", "2"),
+		schema.ToolMessage("ok", "3"),
+		answer,
+		schema.UserMessage("Thanks"),
+		schema.AssistantMessage("You're welcome.", nil),
+	}
+	runs := []agent.RunTelemetry{
+		{ModelTime: 3 * time.Second, ToolCalls: []agent.ToolTiming{
+			{Name: "fs__read_file", Duration: 10 * time.Millisecond},
+			{Name: "fs__read_file", Duration: 20 * time.Millisecond},
+			{Name: "bash__run_shell", Duration: 4 * time.Second},
+		}},
+		{ModelTime: time.Second},
+	}
+
+	summary := summarizeStats(messages, runs, "anthropic:claude-sonnet-4-20250514")
+	if summary.Turns != 2 {
+		t.Errorf("expected 2 turns, got %d", summary.Turns)
+	}
+	if summary.ToolCalls["fs__read_file"] != 2 || summary.ToolCalls["bash__run_shell"] != 1 {
+		t.Errorf("unexpected tool calls %v", summary.ToolCalls)
+	}
+	if summary.Usage.PromptTokens() != 3000 || summary.Usage.OutputTokens != 150 {
+		t.Errorf("unexpected usage %+v", summary.Usage)
+	}
+	if summary.Cost <= 0 {
+		t.Error("expected a cost for a priced model")
+	}
+	if summary.TokensByRole[schema.Assistant] == 0 || summary.TokensByRole[schema.Tool] == 0 {
+		t.Errorf("expected tokens for every role, got %v", summary.TokensByRole)
+	}
+	if summary.ModelTime != 4*time.Second || summary.ToolTime != 4030*time.Millisecond {
+		t.Errorf("unexpected times %s in the model, %s in tools", summary.ModelTime, summary.ToolTime)
+	}
+	if summary.LongestTool.Name != "bash__run_shell" {
+		t.Errorf("unexpected longest tool call %+v", summary.LongestTool)
+	}
+
+	out := summary.markdown()
+	for _, want := range []string{"Turns: 2", "Tool calls: 3", "fs__read_file: 2", "Time in tools: 4s", "Longest tool call: bash__run_shell (4s)", "Estimated cost: $"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "fs__read_file") > strings.Index(out, "bash__run_shell: 1") {
+		t.Error("expected the most used tool first")
+	}
+
+	// Without telemetry or reported usage, e.g. for a loaded session
+	out = summarizeStats(messages[:2], nil, "unknown:model").markdown()
+	if strings.Contains(out, "Time in") || strings.Contains(out, "cost") {
+		t.Errorf("expected no times or cost without telemetry, got\n%s", out)
+	}
+}
+
+func TestIsStatsCommand(t *testing.T) {
+	if !isStatsCommand(" /stats ") || isStatsCommand("/stats now") || isStatsCommand("/status") {
+		t.Error("expected only /stats to be recognised")
+	}
+}
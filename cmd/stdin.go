@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// maxPipedInput bounds the text read from stdin into the prompt
+const maxPipedInput = 10 * 1024 * 1024
+
+// pipedInput returns the text piped or redirected into mcphost, reading
+// until stdin is closed. A terminal is refused rather than waited on.
+func pipedInput() (string, error) {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+	if stat.Mode()&os.ModeCharDevice != 0 {
+		return "", fmt.Errorf("--stdin: stdin is a terminal; pipe or redirect the text in")
+	}
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxPipedInput+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+	if len(data) > maxPipedInput {
+		return "", fmt.Errorf("stdin is larger than %d MB", maxPipedInput/1024/1024)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// withPipedInput appends the text piped into mcphost to the prompt
+func withPipedInput(prompt, piped string) string {
+	if strings.TrimSpace(piped) == "" {
+		return prompt
+	}
+	return prompt + "\n\n" + piped
+}
+
+// checkTerminalInput makes sure the interactive prompt that follows a piped
+// --no-exit run can read from the terminal, as the UI does once stdin is used
+func checkTerminalInput() error {
+	path := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		path = "CONIN$"
+	}
+	tty, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("--no-exit continues on the terminal, but stdin is piped and no terminal can be opened: %v", err)
+	}
+	return tty.Close()
+}
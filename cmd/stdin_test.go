@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWithPipedInput(t *testing.T) {
+	if got := withPipedInput("analyze", ""); got != "analyze" {
+		t.Errorf("expected the prompt alone without piped text, got %q", got)
+	}
+	if got := withPipedInput("analyze", " \n"); got != "analyze" {
+		t.Errorf("expected blank piped text to be ignored, got %q", got)
+	}
+	if got, want := withPipedInput("analyze", "line 1\nline 2"), "analyze\n\nline 1\nline 2"; got != want {
+		t.Errorf("withPipedInput = %q, want %q", got, want)
+	}
+}
+
+func TestPipedInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin; r.Close() }()
+
+	w.WriteString("error: disk full\n\n")
+	w.Close()
+	got, err := pipedInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "error: disk full" {
+		t.Errorf("pipedInput = %q, want the piped text without trailing newlines", got)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/ui"
+)
+
+// extractFollowUpTasks records the action items a final response leaves open,
+// tagged with the session id: in the persisted todo list read by the todo
+// builtin with persist enabled, or through extract-tasks-tool when it is set.
+// Failures are reported without interrupting the conversation.
+func extractFollowUpTasks(ctx context.Context, mcpAgent *agent.Agent, cli *ui.CLI, response string, config AgenticLoopConfig) {
+	report := func(err error) {
+		if config.showOutput(cli) {
+			cli.DisplayError(err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	tasks, err := mcpAgent.ExtractFollowUpTasks(ctx, response)
+	if err != nil {
+		report(err)
+		return
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	var added []string
+	if toolName := viper.GetString("extract-tasks-tool"); toolName != "" {
+		added, err = sendFollowUpTasks(ctx, mcpAgent, toolName, tasks, config.SessionID)
+	} else {
+		added, err = addFollowUpTodos(tasks, config.SessionID)
+	}
+	if err != nil {
+		report(err)
+	}
+	if len(added) == 0 || !config.showOutput(cli) {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Follow-up Tasks\n\nAdded %d task(s):\n", len(added))
+	for _, task := range added {
+		fmt.Fprintf(&b, "\n- %s", task)
+	}
+	cli.DisplayInfo(b.String())
+}
+
+// addFollowUpTodos adds the tasks to the persisted todo list and returns the
+// ones that were not already open there
+func addFollowUpTodos(tasks []agent.FollowUpTask, sessionID string) ([]string, error) {
+	todos := make([]builtin.TodoInfo, len(tasks))
+	for i, task := range tasks {
+		todos[i] = builtin.TodoInfo{Content: task.Content, Priority: task.Priority, Session: sessionID}
+	}
+	added, err := builtin.AddTodos(todos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save follow-up tasks: %v", err)
+	}
+	contents := make([]string, len(added))
+	for i, todo := range added {
+		contents[i] = todo.Content
+	}
+	return contents, nil
+}
+
+// sendFollowUpTasks calls the tool once per task with content, priority and
+// session arguments, stopping at the first failure
+func sendFollowUpTasks(ctx context.Context, mcpAgent *agent.Agent, toolName string, tasks []agent.FollowUpTask, sessionID string) ([]string, error) {
+	var sent []string
+	for _, task := range tasks {
+		args, err := json.Marshal(map[string]string{
+			"content":  task.Content,
+			"priority": task.Priority,
+			"session":  sessionID,
+		})
+		if err != nil {
+			return sent, err
+		}
+		result, isError, err := callTool(ctx, mcpAgent.GetTools(), toolName, string(args))
+		if err != nil {
+			return sent, fmt.Errorf("failed to send follow-up task: %w", err)
+		}
+		if isError {
+			return sent, fmt.Errorf("%s rejected follow-up task %q: %s", toolName, task.Content, result)
+		}
+		sent = append(sent, task.Content)
+	}
+	return sent, nil
+}
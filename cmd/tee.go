@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// teeWriter copies assistant output to a file as it is generated, so long
+// responses survive terminal scrollback and crashes. Every write goes straight
+// to the file. A nil *teeWriter discards everything.
+type teeWriter struct {
+	mu       sync.Mutex
+	file     *os.File
+	tools    bool // also record tool calls and their outcome
+	streamed bool // the current message arrived as streamed chunks
+	pending  bool // a message was written and needs a separator before the next
+}
+
+// openTee opens path for appending; an empty path disables tee mode
+func openTee(path string, includeTools bool) (*teeWriter, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tee file: %w", err)
+	}
+	return &teeWriter{file: f, tools: includeTools}, nil
+}
+
+// Chunk records a piece of a streamed message
+func (t *teeWriter) Chunk(chunk string) {
+	if t == nil || chunk == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.streamed {
+		t.separate()
+		t.streamed = true
+	}
+	t.file.WriteString(chunk)
+}
+
+// Message ends the current message. content is only written when the message
+// was not streamed, otherwise it is already in the file.
+func (t *teeWriter) Message(content string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.streamed {
+		t.streamed = false
+		t.pending = true
+		return
+	}
+	if content == "" {
+		return
+	}
+	t.separate()
+	t.file.WriteString(content)
+	t.pending = true
+}
+
+// ToolCall records that a tool is being called, when tool summaries are enabled
+func (t *teeWriter) ToolCall(toolName, toolArgs string) {
+	if t == nil || !t.tools {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.streamed = false
+	t.separate()
+	fmt.Fprintf(t.file, "> tool call: `%s` %s", toolName, toolArgs)
+	t.pending = true
+}
+
+// ToolResult records the outcome of a tool call, when tool summaries are enabled
+func (t *teeWriter) ToolResult(toolName, result string, isError bool) {
+	if t == nil || !t.tools {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := "ok"
+	if isError {
+		status = "error"
+	}
+	t.separate()
+	fmt.Fprintf(t.file, "> tool result: `%s` %s (%d bytes)", toolName, status, len(result))
+	t.pending = true
+}
+
+// separate puts a blank line between consecutive entries
+func (t *teeWriter) separate() {
+	if t.pending {
+		t.file.WriteString("\n\n")
+		t.pending = false
+	}
+}
+
+// Close finishes the file with a newline and closes it
+func (t *teeWriter) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending || t.streamed {
+		t.file.WriteString("\n\n")
+	}
+	return t.file.Close()
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTeeWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.md")
+	tee, err := openTee(path, true)
+	if err != nil {
+		t.Fatalf("openTee failed: %v", err)
+	}
+
+	// A streamed message with a tool call, then a non-streamed final answer
+	tee.Chunk("Let me ")
+	tee.Chunk("look.")
+	tee.Message("Let me look.")
+	tee.ToolCall("fs__read_file", `{"path":"a"}`)
+	tee.ToolResult("fs__read_file", "hello", false)
+	tee.Message("Done.")
+	if err := tee.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "Let me look.\n\n" +
+		"> tool call: `fs__read_file` {\"path\":\"a\"}\n\n" +
+		"> tool result: `fs__read_file` ok (5 bytes)\n\n" +
+		"Done.\n\n"
+	if string(data) != want {
+		t.Errorf("unexpected tee output:\n%q\nwant:\n%q", data, want)
+	}
+
+	// Appends rather than truncating, and skips tool summaries by default
+	tee, _ = openTee(path, false)
+	tee.ToolCall("fs__read_file", "{}")
+	tee.Chunk("Again.")
+	tee.Message("Again.")
+	tee.Close()
+	data, _ = os.ReadFile(path)
+	if string(data) != want+"Again.\n\n" {
+		t.Errorf("expected second run to be appended, got %q", data)
+	}
+}
+
+func TestTeeWriterNil(t *testing.T) {
+	tee, err := openTee("", true)
+	if err != nil || tee != nil {
+		t.Fatalf("expected no tee for an empty path, got %v %v", tee, err)
+	}
+	// All methods are no-ops on a nil writer
+	tee.Chunk("x")
+	tee.Message("x")
+	tee.ToolCall("a", "{}")
+	tee.ToolResult("a", "", false)
+	if err := tee.Close(); err != nil {
+		t.Errorf("Close on nil tee failed: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osi4iot/mcphost/internal/upgrade"
+)
+
+var (
+	upgradeCheck bool
+	upgradeForce bool
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade mcphost to the latest release",
+	Long: `Check GitHub for a newer mcphost release and install it in place.
+
+The release archive for this platform is downloaded, verified against the
+release's checksums.txt, and swapped in atomically; if anything fails the
+current binary is left untouched.
+
+With --check nothing is installed: the command prints the latest version and
+exits with status 1 when an upgrade is available, which makes it usable as a
+freshness check when building CI images.
+
+Set GITHUB_TOKEN to avoid GitHub's anonymous API rate limit.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return runUpgrade(ctx, cmd.Root().Version)
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "only report whether an upgrade is available (exit 1 if so)")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "reinstall even if already on the latest version")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgrade(ctx context.Context, current string) error {
+	client := upgrade.NewClient()
+	release, err := client.Latest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current == "" {
+		current = "dev"
+	}
+	outdated := upgrade.IsNewer(release.Version(), current)
+	if upgradeCheck {
+		if !outdated {
+			fmt.Printf("mcphost %s is up to date\n", current)
+			return nil
+		}
+		fmt.Printf("mcphost %s is available (current: %s)\n%s\n", release.Version(), current, release.HTMLURL)
+		return fmt.Errorf("upgrade available")
+	}
+	if !outdated && !upgradeForce {
+		fmt.Printf("mcphost %s is already the latest version\n", current)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to resolve the running binary: %w", err)
+	}
+
+	fmt.Printf("Downloading mcphost %s for %s/%s...\n", release.Version(), runtime.GOOS, runtime.GOARCH)
+	binary, err := client.DownloadBinary(ctx, release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	if err := upgrade.Replace(exe, binary); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Upgraded %s from %s to %s\n", exe, current, release.Version())
+	return nil
+}
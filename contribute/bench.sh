@@ -0,0 +1,46 @@
+#!/bin/bash
+# Runs the agent loop, provider and session benchmarks and compares them with
+# a baseline:
+#
+#   ./contribute/bench.sh baseline   # on main: record bench_baseline.txt
+#   ./contribute/bench.sh            # on a branch: compare with the baseline
+#
+# The comparison fails when a benchmark got slower, or allocates more, by more
+# than MAX_REGRESSION percent (10 by default) with statistical significance.
+
+set -euo pipefail
+
+cd "$(dirname "$0")/.."
+
+PACKAGES="./internal/agent ./internal/session ./internal/models/anthropic ./internal/models/gemini"
+COUNT="${COUNT:-10}"
+MAX_REGRESSION="${MAX_REGRESSION:-10}"
+BENCHSTAT="go run golang.org/x/perf/cmd/benchstat@latest"
+
+run_benchmarks() {
+	go test -run '^$' -bench . -benchmem -count "$COUNT" $PACKAGES > "$1"
+}
+
+if [ "${1:-}" = "baseline" ]; then
+	run_benchmarks bench_baseline.txt
+	echo "Baseline written to bench_baseline.txt"
+	exit 0
+fi
+
+if [ ! -f bench_baseline.txt ]; then
+	echo "No bench_baseline.txt; run '$0 baseline' on the base branch first" >&2
+	exit 1
+fi
+
+run_benchmarks bench_output.txt
+$BENCHSTAT bench_baseline.txt bench_output.txt
+
+# In CSV output the next to last column is the change, "~" when not significant
+regressions=$($BENCHSTAT -format csv bench_baseline.txt bench_output.txt 2>/dev/null |
+	awk -F, -v max="$MAX_REGRESSION" '$(NF-1) ~ /^\+[0-9.]+%$/ { change = substr($(NF-1), 2) + 0; if (change > max) print $1 " " $(NF-1) }')
+if [ -n "$regressions" ]; then
+	echo
+	echo "Regressions over ${MAX_REGRESSION}%:" >&2
+	echo "$regressions" >&2
+	exit 1
+fi
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cloudwego/eino/components/model"
@@ -11,8 +12,12 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/osi4iot/mcphost/internal/config"
 	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+	"github.com/osi4iot/mcphost/internal/scrub"
 	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/turn"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +29,39 @@ type AgentConfig struct {
 	MaxSteps         int
 	StreamingEnabled bool
 	DebugLogger      tools.DebugLogger // Optional debug logger
+
+	// DisableESCListener skips the terminal ESC key listener used to cancel
+	// non-streaming generation; embedders cancel through the context instead.
+	DisableESCListener bool
+
+	// ToolApprovalHandler, when set, is consulted before every tool execution
+	ToolApprovalHandler ToolApprovalHandler
+
+	// StepApprovalHandler, when set, is consulted once per step before any of
+	// the step's tool calls run
+	StepApprovalHandler StepApprovalHandler
+
+	// SamplingApprovalHandler, when set, is consulted before an MCP server's
+	// sampling request is sent to the model
+	SamplingApprovalHandler tools.SamplingApprovalHandler
+
+	// Scrubber, when set, masks personal data in everything sent to remote
+	// providers. Local Ollama models are not wrapped.
+	Scrubber *scrub.Scrubber
+
+	// KeepDuplicateToolResults sends every tool result in full, instead of
+	// replacing results that a later call in the same turn repeated
+	KeepDuplicateToolResults bool
+
+	// StopOnToolCalls ends the run as soon as the model asks for tool calls,
+	// without running them, so the caller can run them itself and continue
+	// the conversation with their results
+	StopOnToolCalls bool
+
+	// Citations numbers the URLs and files tool results came from, asks the
+	// model to cite them inline as [n] and returns the cited ones with the
+	// final response
+	Citations bool
 }
 
 // ToolCallHandler is a function type for handling tool calls as they happen
@@ -44,15 +82,123 @@ type StreamingResponseHandler func(content string)
 // ToolCallContentHandler is a function type for handling content that accompanies tool calls
 type ToolCallContentHandler func(content string)
 
+// InterjectionHandler is called when a message the user sent mid-run is added
+// to the conversation
+type InterjectionHandler func(message string)
+
+// PauseAction is what a paused loop does next
+type PauseAction int
+
+const (
+	PauseResume PauseAction = iota // run on until paused again
+	PauseStep                      // run one step, then pause again
+	PauseAbort                     // stop the run
+)
+
+// CheckpointHandler is told about the conversation each time the loop adds a
+// completed message to it: a model response, a tool result or an
+// interjection. messages is the whole conversation so far, with the results
+// of ephemeral servers already summarized, and must not be modified.
+type CheckpointHandler func(messages []*schema.Message)
+
+// LoopState describes a loop paused before one of its model calls
+type LoopState struct {
+	Step     int               // model calls made so far in this run
+	Messages []*schema.Message // the whole conversation the next call would see
+	Added    []*schema.Message // the part of Messages added during this run
+}
+
+// PauseHandler is called when the loop pauses and blocks until the user
+// decides how to go on
+type PauseHandler func(state LoopState) PauseAction
+
+// ErrAborted is returned by a run the user aborted while it was paused
+var ErrAborted = errors.New("run aborted by user")
+
+// ToolApprovalHandler decides whether a tool call may run. When allow is false
+// the tool is not executed and reason is returned to the model instead.
+type ToolApprovalHandler func(toolName, toolArgs string) (allow bool, reason string)
+
+// StepApprovalHandler decides whether the run may go on to execute a model
+// response's tool calls. content is the text that came with them. When it
+// returns false, none of the calls run and the run ends.
+type StepApprovalHandler func(content string, toolCalls []schema.ToolCall) bool
+
+// Messages recorded when the user declines a step, so the conversation stays
+// valid and the model knows what happened on the next turn
+const (
+	stepDeclinedToolMessage = "Tool execution skipped: the user declined this step and stopped the run"
+	stepDeclinedResponse    = "Stopped before running the tools: the step was not approved."
+)
+
 // Agent is the agent with real-time tool call display.
 type Agent struct {
 	toolManager      *tools.MCPToolManager
 	model            model.ToolCallingChatModel
+	taskModels       map[string]model.ToolCallingChatModel // models for auxiliary tasks, by task type
+	routeModels      map[string]model.ToolCallingChatModel // models for steps of the loop, by step
 	maxSteps         int
 	systemPrompt     string
 	loadingMessage   string // Message from provider loading (e.g., GPU fallback info)
+	adjustments      []models.ParameterAdjustment
 	providerType     string // Provider type for streaming behavior
 	streamingEnabled bool   // Whether streaming is enabled
+	escListener      bool   // Whether ESC cancels non-streaming generation
+	approveTool      ToolApprovalHandler
+	approveStep      StepApprovalHandler
+	keepDuplicates   bool     // Whether repeated tool results are sent in full
+	ephemeral        []string // Servers whose tool results only last for their turn
+	scratchpads      []string // read_notes tools whose notes are shown before each call
+	acceptsImages    bool     // Whether the model is shown images returned by tools
+	acceptsAudio     bool     // Whether the model is given audio returned by tools
+	withoutTools     bool     // Whether the model can't call tools, natively or emulated
+	toolChoice       string   // auto, none, required or a tool name
+	stopOnToolCalls  bool     // Whether tool calls are returned to the caller unexecuted
+	citations        bool     // Whether the model is asked to cite tool result sources
+
+	scrubber *scrub.Scrubber // masks personal data sent to remote models, or nil
+
+	modelName   string                     // the main model's model string
+	shadowName  string                     // the shadow model's model string, or ""
+	shadowModel model.ToolCallingChatModel // mirrored each model call, or nil
+	shadowCalls sync.WaitGroup             // shadow model calls still running
+	shadowStop  context.Context            // cancelled by Close, guarded by controlMu
+	closeShadow context.CancelFunc         // cancels shadowStop
+
+	controlMu      sync.Mutex          // guards the run controls below
+	interjections  []string            // user messages waiting for the next model call
+	onInterjection InterjectionHandler // told about each interjection as it is added
+	pauseRequested bool                // pause before the next model call
+	onPause        PauseHandler        // holds the loop while paused
+	onCheckpoint   CheckpointHandler   // told about the conversation as it grows
+	onReasoning    ReasoningHandler    // given each model call's reasoning
+	onThinking     ReasoningHandler    // given each model call's reasoning as it streams
+	toolFilter     ToolFilter          // limits the tools offered to the model, or nil
+	onShadow       ShadowHandler       // given each shadow model call
+	plan           *Plan               // approved plan shown before every call, or nil
+	step           int                 // model call the running loop is on, from 1
+
+	releaseModel func() // frees what the main model holds, or nil
+	owned        releases
+}
+
+// releases collects the Release funcs of the models an agent created, so it
+// frees them when it is closed, or when creating it fails part way
+type releases []func()
+
+// add keeps result's Release, if any
+func (r *releases) add(result *models.ProviderResult) {
+	if result.Release != nil {
+		*r = append(*r, result.Release)
+	}
+}
+
+// release calls the Release funcs kept and forgets them
+func (r *releases) release() {
+	for _, release := range *r {
+		release()
+	}
+	*r = nil
 }
 
 // NewAgent creates an agent with MCP tool integration and real-time tool call display
@@ -62,46 +208,197 @@ func NewAgent(ctx context.Context, config *AgentConfig) (*Agent, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create model provider: %v", err)
 	}
+	// Models created for the agent are freed when creating it fails part way
+	var owned releases
+	created := false
+	defer func() {
+		if !created {
+			owned.add(providerResult)
+			owned.release()
+		}
+	}()
+
+	// Determine provider type from model string
+	providerType := "default"
+	if config.ModelConfig != nil && config.ModelConfig.ModelString != "" {
+		parts := strings.SplitN(config.ModelConfig.ModelString, ":", 2)
+		if len(parts) >= 1 {
+			providerType = parts[0]
+		}
+	}
+
+	chatModel := providerResult.Model
+	if config.Scrubber != nil && !models.IsLocal(providerType) {
+		chatModel = scrub.WrapModel(chatModel, config.Scrubber)
+	}
 
 	// Create and load MCP tools
 	toolManager := tools.NewMCPToolManager()
 
 	// Set the model for sampling support
-	toolManager.SetModel(providerResult.Model)
+	toolManager.SetModel(chatModel)
+	toolManager.SetProviderConfig(config.ModelConfig)
+
+	// Builtin summarize/extract tools use the summarize task model when one is configured
+	summaryName, summaryModel, err := createSummaryModel(ctx, config.ModelConfig, config.Scrubber, &owned)
+	if err != nil {
+		return nil, err
+	}
+	toolManager.SetSummaryModel(summaryModel)
+
+	// Sampling requests may use any configured model, picked by the server's preferences
+	samplingModels := []tools.SamplingModel{{Name: config.ModelConfig.ModelString, Model: chatModel}}
+	if summaryModel != nil {
+		samplingModels = append(samplingModels, tools.SamplingModel{
+			Name:  summaryName,
+			Model: summaryModel,
+		})
+	}
+	toolManager.SetSamplingModels(samplingModels)
+	toolManager.SetSamplingApprover(config.SamplingApprovalHandler)
 
 	// Set the debug logger if provided
 	if config.DebugLogger != nil {
 		toolManager.SetDebugLogger(config.DebugLogger)
 	}
 
-	if err := toolManager.LoadTools(ctx, config.MCPConfig); err != nil {
-		return nil, fmt.Errorf("failed to load MCP tools: %v", err)
+	// Extraction and guardrail passes use their task models when configured
+	taskModels, err := createAuxiliaryModels(ctx, config.ModelConfig, config.Scrubber, &owned)
+	if err != nil {
+		return nil, err
+	}
+	routeModels, err := createRouteModels(ctx, config.ModelConfig, config.Scrubber, &owned)
+	if err != nil {
+		return nil, err
+	}
+	shadowName, shadowModel, err := createShadowModel(ctx, config.ModelConfig, config.Scrubber, &owned)
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine provider type from model string
-	providerType := "default"
-	if config.ModelConfig != nil && config.ModelConfig.ModelString != "" {
-		parts := strings.SplitN(config.ModelConfig.ModelString, ":", 2)
-		if len(parts) >= 1 {
-			providerType = parts[0]
-		}
+	if err := toolManager.LoadTools(ctx, config.MCPConfig); err != nil {
+		return nil, fmt.Errorf("failed to load MCP tools: %v", err)
 	}
 
+	created = true
 	return &Agent{
 		toolManager:      toolManager,
-		model:            providerResult.Model,
+		model:            chatModel,
+		taskModels:       taskModels,
+		routeModels:      routeModels,
 		maxSteps:         config.MaxSteps, // Keep 0 for infinite, handle in loop
 		systemPrompt:     config.SystemPrompt,
 		loadingMessage:   providerResult.Message,
+		adjustments:      providerResult.Adjustments,
 		providerType:     providerType,
 		streamingEnabled: config.StreamingEnabled,
+		escListener:      !config.DisableESCListener,
+		approveTool:      config.ToolApprovalHandler,
+		approveStep:      config.StepApprovalHandler,
+		keepDuplicates:   config.KeepDuplicateToolResults,
+		ephemeral:        ephemeralServers(config.MCPConfig),
+		scratchpads:      scratchpadTools(config.MCPConfig),
+		acceptsImages:    models.AcceptsImages(config.ModelConfig.ModelString),
+		acceptsAudio:     models.AcceptsAudio(config.ModelConfig.ModelString),
+		withoutTools:     !providerResult.Emulated && !models.SupportsTools(config.ModelConfig.ModelString),
+		toolChoice:       config.ModelConfig.ToolChoice,
+		stopOnToolCalls:  config.StopOnToolCalls,
+		citations:        config.Citations,
+		scrubber:         config.Scrubber,
+		modelName:        config.ModelConfig.ModelString,
+		shadowName:       shadowName,
+		shadowModel:      shadowModel,
+		releaseModel:     providerResult.Release,
+		owned:            owned,
 	}, nil
 }
 
+// createSummaryModel creates the model configured for the summarize task
+func createSummaryModel(ctx context.Context, modelConfig *models.ProviderConfig, scrubber *scrub.Scrubber, owned *releases) (string, model.ToolCallingChatModel, error) {
+	return createTaskModel(ctx, modelConfig, config.TaskSummarize, scrubber, owned)
+}
+
+// createAuxiliaryModels creates the models configured for the agent's own
+// auxiliary tasks, leaving out tasks that use the main model
+func createAuxiliaryModels(ctx context.Context, modelConfig *models.ProviderConfig, scrubber *scrub.Scrubber, owned *releases) (map[string]model.ToolCallingChatModel, error) {
+	taskModels := make(map[string]model.ToolCallingChatModel)
+	for _, task := range []string{config.TaskExtract, config.TaskGuardrail, config.TaskPostMortem} {
+		_, taskModel, err := createTaskModel(ctx, modelConfig, task, scrubber, owned)
+		if err != nil {
+			return nil, err
+		}
+		if taskModel != nil {
+			taskModels[task] = taskModel
+		}
+	}
+	return taskModels, nil
+}
+
+// createTaskModel creates the model configured for a task and returns it with
+// its model string, or a nil model when that task uses the main model
+func createTaskModel(ctx context.Context, modelConfig *models.ProviderConfig, task string, scrubber *scrub.Scrubber, owned *releases) (string, model.ToolCallingChatModel, error) {
+	taskConfig := modelConfig.ForTask(task)
+	if taskConfig == nil {
+		return "", nil, nil
+	}
+
+	chatModel, err := createModel(ctx, taskConfig, scrubber, owned)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create %s model: %v", task, err)
+	}
+	return taskConfig.ModelString, chatModel, nil
+}
+
+// createModel creates the model modelConfig describes, behind the scrubber
+// unless it runs locally, and adds its Release to owned
+func createModel(ctx context.Context, modelConfig *models.ProviderConfig, scrubber *scrub.Scrubber, owned *releases) (model.ToolCallingChatModel, error) {
+	result, err := models.CreateProvider(ctx, modelConfig)
+	if err != nil {
+		return nil, err
+	}
+	owned.add(result)
+	if provider, _, _ := strings.Cut(modelConfig.ModelString, ":"); scrubber != nil && !models.IsLocal(provider) {
+		return scrub.WrapModel(result.Model, scrubber), nil
+	}
+	return result.Model, nil
+}
+
+// availableTools returns the tools currently offered by the MCP servers, as
+// tool infos for the model and a map from tool name to tool
+func (a *Agent) availableTools(ctx context.Context) ([]*schema.ToolInfo, map[string]tool.BaseTool) {
+	var toolInfos []*schema.ToolInfo
+	toolMap := make(map[string]tool.BaseTool)
+
+	for _, t := range a.toolManager.GetTools() {
+		info, err := t.Info(ctx)
+		if err != nil {
+			continue
+		}
+		if info == nil || !a.offersTool(info.Name) {
+			continue
+		}
+		toolInfos = append(toolInfos, info)
+		toolMap[info.Name] = t
+	}
+	return toolInfos, toolMap
+}
+
 // GenerateWithLoopResult contains the result and conversation history
 type GenerateWithLoopResult struct {
 	FinalResponse        *schema.Message
 	ConversationMessages []*schema.Message // All messages in the conversation (including tool calls and results)
+
+	// PendingToolCalls are the tool calls the run stopped at with
+	// StopOnToolCalls. They have not run; the conversation continues once a
+	// tool message with each one's result follows ConversationMessages.
+	PendingToolCalls []schema.ToolCall
+
+	// Telemetry is the time the run spent on model calls and tool calls
+	Telemetry RunTelemetry
+
+	// Citations are the sources the final response cites, in number order,
+	// when the agent was created with Citations
+	Citations []Citation
 }
 
 // GenerateWithLoop processes messages with a custom loop that displays tool calls in real-time
@@ -111,43 +408,52 @@ func (a *Agent) GenerateWithLoop(ctx context.Context, messages []*schema.Message
 	return a.GenerateWithLoopAndStreaming(ctx, messages, onToolCall, onToolExecution, onToolResult, onResponse, onToolCallContent, nil)
 }
 
-// GenerateWithLoopAndStreaming processes messages with a custom loop that displays tool calls in real-time and supports streaming callbacks
+// GenerateWithLoopAndStreaming processes messages with a custom loop that displays tool calls in real-time and supports streaming callbacks.
+// The run belongs to the turn carried by ctx, or to a new one, and the messages it adds are marked with it.
 func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*schema.Message,
 	onToolCall ToolCallHandler, onToolExecution ToolExecutionHandler, onToolResult ToolResultHandler, onResponse ResponseHandler, onToolCallContent ToolCallContentHandler, onStreamingResponse StreamingResponseHandler) (*GenerateWithLoopResult, error) {
+	ctx = turn.Ensure(ctx)
+	var telemetry RunTelemetry
+	result, err := a.generateWithLoop(ctx, messages, &telemetry, onToolCall, onToolExecution, onToolResult, onResponse, onToolCallContent, onStreamingResponse)
+	if result != nil {
+		result.Telemetry = telemetry
+		markTurn(turn.ID(ctx), messages, result)
+	}
+	return result, err
+}
 
-	// Create a copy of messages to avoid modifying the original
-	workingMessages := make([]*schema.Message, len(messages))
-	copy(workingMessages, messages)
-
-	// Add system prompt if provided
-	if a.systemPrompt != "" {
-		hasSystemMessage := false
-		if len(workingMessages) > 0 && workingMessages[0].Role == schema.System {
-			hasSystemMessage = true
-		}
-
-		if !hasSystemMessage {
-			systemMsg := schema.SystemMessage(a.systemPrompt)
-			workingMessages = append([]*schema.Message{systemMsg}, workingMessages...)
+// markTurn marks the messages a run added to the conversation, those not in
+// its input, with the run's turn
+func markTurn(id string, input []*schema.Message, result *GenerateWithLoopResult) {
+	seen := make(map[*schema.Message]bool, len(input))
+	for _, msg := range input {
+		seen[msg] = true
+	}
+	for _, msg := range result.ConversationMessages {
+		if !seen[msg] && msg.Role != schema.System {
+			turn.Mark(msg, id)
 		}
 	}
+	turn.Mark(result.FinalResponse, id)
+}
 
-	// Get available tools
-	availableTools := a.toolManager.GetTools()
-	var toolInfos []*schema.ToolInfo
-	toolMap := make(map[string]tool.BaseTool)
+// generateWithLoop is the loop of GenerateWithLoopAndStreaming
+func (a *Agent) generateWithLoop(ctx context.Context, messages []*schema.Message, telemetry *RunTelemetry,
+	onToolCall ToolCallHandler, onToolExecution ToolExecutionHandler, onToolResult ToolResultHandler, onResponse ResponseHandler, onToolCallContent ToolCallContentHandler, onStreamingResponse StreamingResponseHandler) (*GenerateWithLoopResult, error) {
 
-	for _, t := range availableTools {
-		info, err := t.Info(ctx)
-		if err != nil {
-			continue
-		}
-		if info == nil {
-			continue
-		}
-		toolInfos = append(toolInfos, info)
-		toolMap[info.Name] = t
+	// The run appends to its own slice, with room for a few steps, so the
+	// caller's slice is never modified. The messages themselves are shared
+	// rather than copied: a session manager recognises the history it handed
+	// out by pointer and only converts and saves what the run added.
+	needsSystem := a.systemPrompt != "" && (len(messages) == 0 || messages[0].Role != schema.System)
+	workingMessages := make([]*schema.Message, 0, len(messages)+16)
+	if needsSystem {
+		workingMessages = append(workingMessages, schema.SystemMessage(a.systemPrompt))
 	}
+	workingMessages = append(workingMessages, messages...)
+
+	runStart := len(workingMessages)
+	defer a.setStep(0)
 
 	// Main loop
 	for step := 0; a.maxSteps == 0 || step < a.maxSteps; step++ {
@@ -157,15 +463,55 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 			return nil, ctx.Err()
 		default:
 		}
+		a.setStep(step + 1)
+
+		// A requested pause holds the loop here, once the previous step's
+		// tool calls have finished
+		if handler := a.takePause(); handler != nil {
+			switch handler(LoopState{Step: step, Messages: workingMessages, Added: workingMessages[runStart:]}) {
+			case PauseStep:
+				a.Pause()
+			case PauseAbort:
+				return nil, ErrAborted
+			}
+		}
+
+		// A result the model fetched again this turn only needs sending once
+		if !a.keepDuplicates {
+			dedupeToolResults(workingMessages[runStart:])
+		}
+
+		// Messages the user sent while tools ran steer the next call
+		for _, message := range a.TakeInterjections() {
+			workingMessages = append(workingMessages, schema.UserMessage(message))
+			a.checkpoint(ctx, workingMessages, runStart)
+			if handler := a.interjectionHandler(); handler != nil {
+				handler(message)
+			}
+		}
+
+		// Get available tools every step, since servers may add or remove tools mid-session
+		toolInfos, toolMap := a.availableTools(ctx)
 
 		// Call the LLM with cancellation support
-		response, err := a.generateWithCancellationAndStreaming(ctx, workingMessages, toolInfos, onStreamingResponse)
+		callMessages := a.withScratchpad(ctx, workingMessages, toolMap)
+		callMessages, toolInfos = a.withPlan(callMessages, toolInfos, toolMap)
+		callMessages = a.withCitations(callMessages, workingMessages[runStart:])
+		toolInfos, toolChoice, err := a.withToolChoice(step, toolInfos)
 		if err != nil {
 			return nil, err
 		}
+		callStart := time.Now()
+		response, err := a.generateRouted(ctx, step, callMessages, toolInfos, toolChoice, onStreamingResponse)
+		telemetry.ModelTime += time.Since(callStart)
+		if err != nil {
+			return nil, err
+		}
+		a.takeReasoning(response)
 
 		// Add response to working messages
 		workingMessages = append(workingMessages, response)
+		a.checkpoint(ctx, workingMessages, runStart)
 
 		// Check if this is a tool call or final response
 		if len(response.ToolCalls) > 0 {
@@ -174,6 +520,34 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 				onToolCallContent(response.Content)
 			}
 
+			// The caller runs the calls itself and continues with their results
+			if a.stopOnToolCalls {
+				dropEphemeralResults(workingMessages[runStart:], a.ephemeral)
+				return &GenerateWithLoopResult{
+					FinalResponse:        response,
+					ConversationMessages: workingMessages,
+					PendingToolCalls:     response.ToolCalls,
+				}, nil
+			}
+
+			// In confirmation mode nothing in the step runs until it is approved
+			if a.approveStep != nil && !a.approveStep(response.Content, response.ToolCalls) {
+				for _, toolCall := range response.ToolCalls {
+					skipped := schema.ToolMessage(stepDeclinedToolMessage, toolCall.ID)
+					toolresult.MarkError(skipped)
+					workingMessages = append(workingMessages, skipped)
+				}
+				finalResponse := schema.AssistantMessage(stepDeclinedResponse, nil)
+				dropEphemeralResults(workingMessages[runStart:], a.ephemeral)
+				return &GenerateWithLoopResult{
+					FinalResponse:        finalResponse,
+					ConversationMessages: append(workingMessages, finalResponse),
+				}, nil
+			}
+
+			// Media from this step's tool results, shown to the model after them
+			var media []toolMedia
+
 			// Handle tool calls
 			for _, toolCall := range response.ToolCalls {
 				// Notify about tool call
@@ -181,6 +555,25 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 					onToolCall(toolCall.Function.Name, toolCall.Function.Arguments)
 				}
 
+				// Ask the approval handler, if any, before executing
+				if a.approveTool != nil {
+					if allow, reason := a.approveTool(toolCall.Function.Name, toolCall.Function.Arguments); !allow {
+						if reason == "" {
+							reason = "denied by approval policy"
+						}
+						errorMsg := fmt.Sprintf("Tool execution denied: %s", reason)
+						toolMessage := schema.ToolMessage(errorMsg, toolCall.ID)
+						toolresult.MarkError(toolMessage)
+						workingMessages = append(workingMessages, toolMessage)
+						a.checkpoint(ctx, workingMessages, runStart)
+
+						if onToolResult != nil {
+							onToolResult(toolCall.Function.Name, toolCall.Function.Arguments, errorMsg, true)
+						}
+						continue
+					}
+				}
+
 				// Execute the tool
 				if selectedTool, exists := toolMap[toolCall.Function.Name]; exists {
 					// Notify tool execution start
@@ -194,17 +587,27 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 						arguments = "{}"
 					}
 
+					toolStart := time.Now()
 					output, err := selectedTool.(tool.InvokableTool).InvokableRun(ctx, arguments)
+					telemetry.ToolCalls = append(telemetry.ToolCalls, ToolTiming{Name: toolCall.Function.Name, Duration: time.Since(toolStart)})
 
 					// Notify tool execution end
 					if onToolExecution != nil {
 						onToolExecution(toolCall.Function.Name, false)
 					}
 
+					// A cancelled context aborts the whole run rather than
+					// feeding the cancellation back to the model as a tool error
+					if ctxErr := ctx.Err(); ctxErr != nil {
+						return nil, ctxErr
+					}
+
 					if err != nil {
 						errorMsg := fmt.Sprintf("Tool execution error: %v", err)
 						toolMessage := schema.ToolMessage(errorMsg, toolCall.ID)
+						toolresult.MarkError(toolMessage)
 						workingMessages = append(workingMessages, toolMessage)
+						a.checkpoint(ctx, workingMessages, runStart)
 
 						if onToolResult != nil {
 							onToolResult(toolCall.Function.Name, toolCall.Function.Arguments, errorMsg, true)
@@ -219,8 +622,18 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 							}
 						}
 
-						toolMessage := schema.ToolMessage(output, toolCall.ID)
+						text, returned := splitToolResultMedia(toolCall.Function.Name, output)
+						for _, item := range returned {
+							if (item.kind == mediaImage && a.acceptsImages) || (item.kind == mediaAudio && a.acceptsAudio) {
+								media = append(media, item)
+							}
+						}
+						toolMessage := schema.ToolMessage(text, toolCall.ID)
+						if isError {
+							toolresult.MarkError(toolMessage)
+						}
 						workingMessages = append(workingMessages, toolMessage)
+						a.checkpoint(ctx, workingMessages, runStart)
 
 						if onToolResult != nil {
 							onToolResult(toolCall.Function.Name, toolCall.Function.Arguments, output, isError)
@@ -229,36 +642,195 @@ func (a *Agent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*sc
 				} else {
 					errorMsg := fmt.Sprintf("Tool not found: %s", toolCall.Function.Name)
 					toolMessage := schema.ToolMessage(errorMsg, toolCall.ID)
+					toolresult.MarkError(toolMessage)
 					workingMessages = append(workingMessages, toolMessage)
+					a.checkpoint(ctx, workingMessages, runStart)
 
 					if onToolResult != nil {
 						onToolResult(toolCall.Function.Name, toolCall.Function.Arguments, errorMsg, true)
 					}
 				}
 			}
+
+			if len(media) > 0 {
+				workingMessages = append(workingMessages, toolMediaMessage(media))
+				a.checkpoint(ctx, workingMessages, runStart)
+			}
 		} else {
 			// This is a final response
 			if onResponse != nil && response.Content != "" {
 				onResponse(response.Content)
 			}
+			citations := a.cited(response, workingMessages[runStart:])
+			// The history keeps only summaries of ephemeral servers' results
+			dropEphemeralResults(workingMessages[runStart:], a.ephemeral)
 			return &GenerateWithLoopResult{
 				FinalResponse:        response,
 				ConversationMessages: workingMessages,
+				Citations:            citations,
 			}, nil
 		}
 	}
 
 	// If we reach here, we've exceeded max steps
 	finalResponse := schema.AssistantMessage("Maximum number of steps reached.", nil)
+	dropEphemeralResults(workingMessages[runStart:], a.ephemeral)
 	return &GenerateWithLoopResult{
 		FinalResponse:        finalResponse,
 		ConversationMessages: workingMessages,
 	}, nil
 }
 
-// GetTools returns the list of available tools
+// Progress returns the model call the running loop is on, counted from 1 and
+// 0 between runs, and the step limit, 0 when unlimited. Safe to call from any
+// goroutine.
+func (a *Agent) Progress() (step, maxSteps int) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	return a.step, a.maxSteps
+}
+
+// setStep records the model call the loop is on for Progress
+func (a *Agent) setStep(step int) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.step = step
+}
+
+// Interject queues a user message for the running loop. It is added to the
+// conversation before the loop's next model call, after the results of the
+// tool calls in progress. Safe to call from any goroutine.
+func (a *Agent) Interject(message string) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.interjections = append(a.interjections, message)
+}
+
+// TakeInterjections removes and returns the queued messages, such as ones that
+// arrived after the loop's final model call
+func (a *Agent) TakeInterjections() []string {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	messages := a.interjections
+	a.interjections = nil
+	return messages
+}
+
+// SetInterjectionHandler sets the function told about each queued message as
+// the loop adds it to the conversation; nil removes it
+func (a *Agent) SetInterjectionHandler(handler InterjectionHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onInterjection = handler
+}
+
+// interjectionHandler returns the current interjection handler
+func (a *Agent) interjectionHandler() InterjectionHandler {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	return a.onInterjection
+}
+
+// SetCheckpointHandler sets the function told about the conversation as each
+// message is added during a run, so it can be saved before the run ends; nil
+// removes it
+func (a *Agent) SetCheckpointHandler(handler CheckpointHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onCheckpoint = handler
+}
+
+// checkpoint marks the message just added to messages with the run's turn and
+// tells the checkpoint handler, if any, about the conversation so far
+func (a *Agent) checkpoint(ctx context.Context, messages []*schema.Message, runStart int) {
+	turn.Mark(messages[len(messages)-1], turn.ID(ctx))
+
+	a.controlMu.Lock()
+	handler := a.onCheckpoint
+	a.controlMu.Unlock()
+	// A step's tool calls are handed over only with all their results, so a
+	// save cut short mid-step never holds calls a provider would reject
+	if handler == nil || stepInProgress(messages) {
+		return
+	}
+	messages = messages[:len(messages):len(messages)]
+	if len(a.ephemeral) > 0 {
+		messages = append(messages[:runStart:runStart], messages[runStart:]...)
+		dropEphemeralResults(messages[runStart:], a.ephemeral)
+	}
+	handler(messages)
+}
+
+// stepInProgress reports whether messages end with tool calls that do not all
+// have their results yet
+func stepInProgress(messages []*schema.Message) bool {
+	step := len(messages) - 1
+	for step >= 0 && messages[step].Role == schema.Tool {
+		step--
+	}
+	if step < 0 || len(messages[step].ToolCalls) == 0 {
+		return false
+	}
+	return len(messages)-1-step < len(messages[step].ToolCalls)
+}
+
+// Pause asks the loop to pause before its next model call, letting the tool
+// calls in progress finish. A pause requested between runs applies to the
+// start of the next run. Safe to call from any goroutine.
+func (a *Agent) Pause() {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.pauseRequested = true
+}
+
+// CancelPause withdraws a pause the loop has not reached yet
+func (a *Agent) CancelPause() {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.pauseRequested = false
+}
+
+// SetPauseHandler sets the function that holds the loop while it is paused;
+// without one, a requested pause waits until one is set
+func (a *Agent) SetPauseHandler(handler PauseHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onPause = handler
+}
+
+// takePause returns the pause handler when a pause is due, clearing the request
+func (a *Agent) takePause() PauseHandler {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	if !a.pauseRequested || a.onPause == nil {
+		return nil
+	}
+	a.pauseRequested = false
+	return a.onPause
+}
+
+// GetTools returns the list of available tools, limited by the tool filter
 func (a *Agent) GetTools() []tool.BaseTool {
-	return a.toolManager.GetTools()
+	return a.filterTools(context.Background(), a.toolManager.GetTools())
+}
+
+// GetServerStatuses returns the connection status of every configured MCP server
+func (a *Agent) GetServerStatuses() []tools.ServerStatus {
+	return a.toolManager.GetServerStatuses()
+}
+
+// GetToolDetails returns every available tool with its server and input schema
+func (a *Agent) GetToolDetails() []tools.ToolDetail {
+	return a.toolManager.GetToolDetails()
+}
+
+// TaskModel returns the model configured for an auxiliary task type, or the
+// main model when the task has none
+func (a *Agent) TaskModel(task string) model.ToolCallingChatModel {
+	if taskModel, ok := a.taskModels[task]; ok {
+		return taskModel
+	}
+	return a.model
 }
 
 // GetLoadingMessage returns the loading message from provider creation (e.g., GPU fallback info)
@@ -266,47 +838,66 @@ func (a *Agent) GetLoadingMessage() string {
 	return a.loadingMessage
 }
 
+// GetParameterAdjustments returns the generation parameters that were dropped or
+// changed because the provider or model does not support them
+func (a *Agent) GetParameterAdjustments() []models.ParameterAdjustment {
+	return a.adjustments
+}
+
+// GetSamplingUsage returns the token usage of MCP sampling requests by server
+func (a *Agent) GetSamplingUsage() []tools.SamplingUsage {
+	return a.toolManager.SamplingUsage()
+}
+
 // GetLoadedServerNames returns the names of successfully loaded MCP servers
 func (a *Agent) GetLoadedServerNames() []string {
 	return a.toolManager.GetLoadedServerNames()
 }
 
 // generateWithCancellationAndStreaming calls the LLM with ESC key cancellation support and streaming callbacks
-func (a *Agent) generateWithCancellationAndStreaming(ctx context.Context, messages []*schema.Message, toolInfos []*schema.ToolInfo, streamingCallback StreamingResponseHandler) (*schema.Message, error) {
+func (a *Agent) generateWithCancellationAndStreaming(ctx context.Context, chatModel model.ToolCallingChatModel, messages []*schema.Message, toolInfos []*schema.ToolInfo, toolChoice *schema.ToolChoice, streamingCallback StreamingResponseHandler) (*schema.Message, error) {
+	opts := callOptions(toolInfos, toolChoice)
+
 	// Check if streaming is enabled
 	if !a.streamingEnabled {
 		// Use traditional non-streaming approach
-		return a.generateWithoutStreaming(ctx, messages, toolInfos)
+		return a.generateWithoutStreaming(ctx, chatModel, messages, opts)
 	}
 
 	// Try streaming first if no tools are expected or if we can detect tool calls early
 	if len(toolInfos) == 0 {
 		// No tools available, use streaming directly
-		return a.generateWithStreamingAndCallback(ctx, messages, toolInfos, streamingCallback)
+		return a.generateWithStreamingAndCallback(ctx, chatModel, messages, opts, streamingCallback)
 	}
 
 	// Try streaming with tool call detection
-	return a.generateWithStreamingFirstAndCallback(ctx, messages, toolInfos, streamingCallback)
+	return a.generateWithStreamingFirstAndCallback(ctx, chatModel, messages, opts, streamingCallback)
 }
 
 // generateWithStreamingAndCallback uses streaming for responses without tool calls with real-time callbacks
-func (a *Agent) generateWithStreamingAndCallback(ctx context.Context, messages []*schema.Message, toolInfos []*schema.ToolInfo, callback StreamingResponseHandler) (*schema.Message, error) {
+func (a *Agent) generateWithStreamingAndCallback(ctx context.Context, chatModel model.ToolCallingChatModel, messages []*schema.Message, opts []model.Option, callback StreamingResponseHandler) (*schema.Message, error) {
 	// Try streaming first
-	reader, err := a.model.Stream(ctx, messages, model.WithTools(toolInfos))
+	reader, err := chatModel.Stream(ctx, messages, opts...)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		// Fallback to non-streaming if streaming fails
-		return a.model.Generate(ctx, messages, model.WithTools(toolInfos))
+		return chatModel.Generate(ctx, messages, opts...)
 	}
 
 	// Use streaming with callback for real-time display
-	response, err := StreamWithCallback(ctx, reader, func(chunk string) {
+	response, err := streamWithReasoning(ctx, reader, a.reportReasoning, a.reportThinking, func(chunk string) {
 		if callback != nil {
 			callback(chunk)
 		}
 	})
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		// Fallback to non-streaming on error
-		return a.model.Generate(ctx, messages, model.WithTools(toolInfos))
+		return chatModel.Generate(ctx, messages, opts...)
 	}
 
 	// Return the complete streamed response (with tool calls if any)
@@ -314,23 +905,29 @@ func (a *Agent) generateWithStreamingAndCallback(ctx context.Context, messages [
 }
 
 // generateWithStreamingFirstAndCallback attempts streaming first with provider-aware tool call detection and callbacks
-func (a *Agent) generateWithStreamingFirstAndCallback(ctx context.Context, messages []*schema.Message, toolInfos []*schema.ToolInfo, callback StreamingResponseHandler) (*schema.Message, error) {
+func (a *Agent) generateWithStreamingFirstAndCallback(ctx context.Context, chatModel model.ToolCallingChatModel, messages []*schema.Message, opts []model.Option, callback StreamingResponseHandler) (*schema.Message, error) {
 	// Try streaming first
-	reader, err := a.model.Stream(ctx, messages, model.WithTools(toolInfos))
+	reader, err := chatModel.Stream(ctx, messages, opts...)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		// Fallback to non-streaming if streaming fails
-		return a.model.Generate(ctx, messages, model.WithTools(toolInfos))
+		return chatModel.Generate(ctx, messages, opts...)
 	}
 
 	// Use streaming with callback for real-time display
-	response, err := StreamWithCallback(ctx, reader, func(chunk string) {
+	response, err := streamWithReasoning(ctx, reader, a.reportReasoning, a.reportThinking, func(chunk string) {
 		if callback != nil {
 			callback(chunk)
 		}
 	})
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		// Fallback to non-streaming on error
-		return a.model.Generate(ctx, messages, model.WithTools(toolInfos))
+		return chatModel.Generate(ctx, messages, opts...)
 	}
 
 	// Return the complete streamed response (with tool calls if any)
@@ -339,7 +936,18 @@ func (a *Agent) generateWithStreamingFirstAndCallback(ctx context.Context, messa
 }
 
 // generateWithoutStreaming uses the traditional non-streaming approach
-func (a *Agent) generateWithoutStreaming(ctx context.Context, messages []*schema.Message, toolInfos []*schema.ToolInfo) (*schema.Message, error) {
+func (a *Agent) generateWithoutStreaming(ctx context.Context, chatModel model.ToolCallingChatModel, messages []*schema.Message, opts []model.Option) (*schema.Message, error) {
+	if !a.escListener {
+		message, err := chatModel.Generate(ctx, messages, opts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to generate response: %v", err)
+		}
+		return message, nil
+	}
+
 	// Create a cancellable context for just this LLM call
 	llmCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -376,7 +984,7 @@ func (a *Agent) generateWithoutStreaming(ctx context.Context, messages []*schema
 
 	// Now start the LLM generation
 	go func() {
-		message, err := a.model.Generate(llmCtx, messages, model.WithTools(toolInfos))
+		message, err := chatModel.Generate(llmCtx, messages, opts...)
 		if err != nil {
 			err = fmt.Errorf("failed to generate response: %v", err)
 		}
@@ -486,7 +1094,13 @@ func (a *Agent) listenForESC(stopChan chan bool, readyChan chan bool) bool {
 	}
 }
 
-// Close closes the agent and cleans up resources
+// Close closes the agent and cleans up resources, after cancelling the shadow
+// model calls still running
 func (a *Agent) Close() error {
+	a.stopShadowCalls()
+	if a.releaseModel != nil {
+		a.releaseModel()
+	}
+	a.owned.release()
 	return a.toolManager.Close()
 }
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// benchSteps is how many tool calling steps a benchmarked run makes before
+// it answers
+const benchSteps = 5
+
+// stepModel calls a missing tool on each of its first steps calls, then
+// answers
+type stepModel struct {
+	steps int
+	calls int
+}
+
+func (m *stepModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.calls++
+	if m.calls > m.steps {
+		return schema.AssistantMessage("done", nil), nil
+	}
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       fmt.Sprintf("call-%d", m.calls),
+		Function: schema.FunctionCall{Name: "missing__tool", Arguments: `{"path": "README.md"}`},
+	}}), nil
+}
+
+func (m *stepModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *stepModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// benchHistory returns a conversation of turns, each a prompt, a tool call,
+// its result and an answer
+func benchHistory(turns int) []*schema.Message {
+	history := make([]*schema.Message, 0, turns*4)
+	for i := 0; i < turns; i++ {
+		id := fmt.Sprintf("hist-%d", i)
+		history = append(history,
+			schema.UserMessage(fmt.Sprintf("Question %d about the repository layout", i)),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}}}),
+			schema.ToolMessage("module example.com/demo\n\ngo 1.24\n", id),
+			schema.AssistantMessage(fmt.Sprintf("Answer %d: it is a Go module.", i), nil),
+		)
+	}
+	return history
+}
+
+// BenchmarkGenerateWithLoop runs a few tool calling steps on top of histories
+// of growing length, to catch per-step costs that grow with the conversation
+func BenchmarkGenerateWithLoop(b *testing.B) {
+	for _, turns := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("history=%d", turns*4), func(b *testing.B) {
+			messages := append(benchHistory(turns), schema.UserMessage("And the tests?"))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				a := &Agent{toolManager: tools.NewMCPToolManager(), model: &stepModel{steps: benchSteps}, systemPrompt: "be brief"}
+				if _, err := a.GenerateWithLoop(context.Background(), messages, nil, nil, nil, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
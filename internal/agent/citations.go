@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+// citationArguments are the tool arguments that say where a result came
+// from, in the order they are looked for. URLs come first, since HTTP tools
+// also take a "path" into the response.
+var citationArguments = []string{"url", "uri", "endpoint", "file_path", "filepath", "file", "filename", "path"}
+
+// citationPattern matches inline citation markers: "[2]" or "[1, 3]"
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Citation is a source a tool result came from, numbered for the model to
+// cite it with
+type Citation struct {
+	Number int    `json:"number"` // cited as [Number]
+	Source string `json:"source"` // the URL or file path
+	Tool   string `json:"tool"`   // the tool that read it
+}
+
+// citationSources numbers the sources of the successful tool results in msgs
+// by first appearance. Results of calls without a URL or path argument are
+// not sources.
+func citationSources(msgs []*schema.Message) []Citation {
+	calls := make(map[string]schema.ToolCall)
+	numbers := make(map[string]bool)
+	var sources []Citation
+	for _, msg := range msgs {
+		switch msg.Role {
+		case schema.Assistant:
+			for _, call := range msg.ToolCalls {
+				calls[call.ID] = call
+			}
+		case schema.Tool:
+			call, ok := calls[msg.ToolCallID]
+			if !ok || toolresult.IsError(msg) {
+				continue
+			}
+			source := citationSource(call.Function.Arguments)
+			if source == "" || numbers[source] {
+				continue
+			}
+			numbers[source] = true
+			sources = append(sources, Citation{Number: len(sources) + 1, Source: source, Tool: call.Function.Name})
+		}
+	}
+	return sources
+}
+
+// citationSource returns the URL or path a tool call's arguments name, or ""
+func citationSource(arguments string) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return ""
+	}
+	for _, name := range citationArguments {
+		if value, ok := args[name].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// withCitations returns the messages for a model call with the sources of
+// the run's tool results listed in the system prompt, so the model can cite
+// them. added is the part of the conversation added during the run.
+func (a *Agent) withCitations(msgs, added []*schema.Message) []*schema.Message {
+	if !a.citations {
+		return msgs
+	}
+	sources := citationSources(added)
+	if len(sources) == 0 {
+		return msgs
+	}
+
+	var b strings.Builder
+	b.WriteString("## Citations\n\n")
+	b.WriteString("When your answer uses information from one of these tool results, cite it inline with its number in square brackets, like [1]. ")
+	b.WriteString("Cite only the sources you used. A list of the cited sources is added below your answer, so don't write one yourself.\n\n")
+	for _, source := range sources {
+		fmt.Fprintf(&b, "[%d] %s (%s)\n", source.Number, source.Source, source.Tool)
+	}
+	return withSystemBlock(msgs, strings.TrimSuffix(b.String(), "\n"))
+}
+
+// cited returns the sources of the run's tool results that response cites,
+// in number order. Markers that match no source are ignored.
+func (a *Agent) cited(response *schema.Message, added []*schema.Message) []Citation {
+	if !a.citations || response == nil {
+		return nil
+	}
+	sources := citationSources(added)
+	if len(sources) == 0 {
+		return nil
+	}
+
+	marked := make(map[int]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(response.Content, -1) {
+		for _, field := range strings.Split(match[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+				marked[n] = true
+			}
+		}
+	}
+	var citations []Citation
+	for _, source := range sources {
+		if marked[source.Number] {
+			citations = append(citations, source)
+		}
+	}
+	return citations
+}
+
+// FormatSources renders cited sources as the footer shown below a response
+func FormatSources(citations []Citation) string {
+	var b strings.Builder
+	b.WriteString("Sources:")
+	for _, c := range citations {
+		fmt.Fprintf(&b, "\n[%d] %s", c.Number, c.Source)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+// citedRun is a run that fetched a page twice, read a file, listed a
+// directory and failed to fetch a second page
+func citedRun() []*schema.Message {
+	failed := schema.ToolMessage("Tool execution error: 404", "call-5")
+	toolresult.MarkError(failed)
+	return []*schema.Message{
+		schema.UserMessage("what changed?"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call-1", Function: schema.FunctionCall{Name: "fetch__fetch", Arguments: `{"url": "https://example.com/changelog"}`}},
+			{ID: "call-2", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "CHANGELOG.md"}`}},
+			{ID: "call-3", Function: schema.FunctionCall{Name: "fs__list_directory", Arguments: `{}`}},
+		}),
+		schema.ToolMessage("the changelog", "call-1"),
+		schema.ToolMessage("# Changelog", "call-2"),
+		schema.ToolMessage("CHANGELOG.md", "call-3"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call-4", Function: schema.FunctionCall{Name: "fetch__fetch", Arguments: `{"url": "https://example.com/changelog"}`}},
+			{ID: "call-5", Function: schema.FunctionCall{Name: "fetch__fetch", Arguments: `{"url": "https://example.com/missing"}`}},
+		}),
+		schema.ToolMessage("the changelog", "call-4"),
+		failed,
+	}
+}
+
+func TestCitationSources(t *testing.T) {
+	sources := citationSources(citedRun())
+	want := []Citation{
+		{Number: 1, Source: "https://example.com/changelog", Tool: "fetch__fetch"},
+		{Number: 2, Source: "CHANGELOG.md", Tool: "fs__read_file"},
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("expected %d sources, got %+v", len(want), sources)
+	}
+	for i := range want {
+		if sources[i] != want[i] {
+			t.Errorf("source %d: expected %+v, got %+v", i, want[i], sources[i])
+		}
+	}
+
+	// A URL wins over an HTTP tool's path into the response
+	if source := citationSource(`{"path": "data.items", "url": "https://api.example.com"}`); source != "https://api.example.com" {
+		t.Errorf("expected the URL, got %q", source)
+	}
+}
+
+func TestWithCitations(t *testing.T) {
+	msgs := []*schema.Message{schema.SystemMessage("You are helpful."), schema.UserMessage("what changed?")}
+	a := &Agent{}
+	if got := a.withCitations(msgs, citedRun()); len(got) != 2 || got[0] != msgs[0] {
+		t.Error("expected no sources listed without citations")
+	}
+
+	a.citations = true
+	got := a.withCitations(msgs, citedRun())
+	if !strings.Contains(got[0].Content, "[1] https://example.com/changelog (fetch__fetch)\n[2] CHANGELOG.md (fs__read_file)") {
+		t.Errorf("expected the sources in the system prompt, got %q", got[0].Content)
+	}
+	if msgs[0].Content != "You are helpful." {
+		t.Error("expected the conversation's system prompt to be left alone")
+	}
+	if got := a.withCitations(msgs, msgs); got[0] != msgs[0] {
+		t.Error("expected nothing added to a run without sources")
+	}
+}
+
+func TestCited(t *testing.T) {
+	a := &Agent{citations: true}
+	response := schema.AssistantMessage("Version 2 dropped Go 1.21 [2] and added plugins [1, 2]. See [7].", nil)
+	citations := a.cited(response, citedRun())
+	if len(citations) != 2 || citations[0].Number != 1 || citations[1].Number != 2 {
+		t.Fatalf("expected sources 1 and 2, got %+v", citations)
+	}
+	if footer := FormatSources(citations); footer != "Sources:\n[1] https://example.com/changelog\n[2] CHANGELOG.md" {
+		t.Errorf("unexpected footer %q", footer)
+	}
+
+	if citations := a.cited(schema.AssistantMessage("Nothing to cite.", nil), citedRun()); citations != nil {
+		t.Errorf("expected no citations, got %+v", citations)
+	}
+}
@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/turn"
+)
+
+// scriptedModel calls a missing tool on its first call and answers on the
+// second, running onCall as each call starts
+type scriptedModel struct {
+	calls  [][]*schema.Message
+	onCall func(call int)
+}
+
+func (m *scriptedModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.calls = append(m.calls, input)
+	if m.onCall != nil {
+		m.onCall(len(m.calls))
+	}
+	if len(m.calls) == 1 {
+		return schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call-1",
+			Function: schema.FunctionCall{Name: "missing__tool", Arguments: "{}"},
+		}}), nil
+	}
+	return schema.AssistantMessage("done", nil), nil
+}
+
+func (m *scriptedModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *scriptedModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestInterjectBeforeNextModelCall(t *testing.T) {
+	llm := &scriptedModel{}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm}
+	llm.onCall = func(call int) {
+		if call == 1 {
+			a.Interject("use the staging database instead")
+		}
+	}
+
+	var announced []string
+	a.SetInterjectionHandler(func(message string) { announced = append(announced, message) })
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("count the users")},
+		nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(llm.calls) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(llm.calls))
+	}
+
+	// The interjection follows the tool result, so the tool call stays answered
+	second := llm.calls[1]
+	if last := second[len(second)-1]; last.Role != schema.User || last.Content != "use the staging database instead" {
+		t.Errorf("expected the interjection last in the second call, got %s %q", last.Role, last.Content)
+	}
+	if previous := second[len(second)-2]; previous.Role != schema.Tool {
+		t.Errorf("expected the tool result before the interjection, got %s", previous.Role)
+	}
+
+	if len(announced) != 1 {
+		t.Errorf("expected the handler to be told once, got %v", announced)
+	}
+	if result.ConversationMessages[3].Content != "use the staging database instead" {
+		t.Errorf("expected the interjection in the conversation, got %q", result.ConversationMessages[3].Content)
+	}
+	if pending := a.TakeInterjections(); len(pending) != 0 {
+		t.Errorf("expected no pending interjections, got %v", pending)
+	}
+}
+
+func TestTakeInterjectionsAfterFinalCall(t *testing.T) {
+	a := &Agent{}
+	a.Interject("one more thing")
+	a.Interject("and another")
+	if pending := a.TakeInterjections(); len(pending) != 2 || pending[0] != "one more thing" {
+		t.Fatalf("expected both interjections in order, got %v", pending)
+	}
+	if pending := a.TakeInterjections(); pending != nil {
+		t.Errorf("expected the queue to be empty, got %v", pending)
+	}
+}
+
+func TestPauseBetweenSteps(t *testing.T) {
+	llm := &scriptedModel{}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm}
+
+	// A pause requested before the run holds it before the first call
+	a.Pause()
+	var paused []LoopState
+	a.SetPauseHandler(func(state LoopState) PauseAction {
+		paused = append(paused, state)
+		return PauseStep
+	})
+
+	_, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("count the users")},
+		nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Single-stepping pauses before each model call
+	if len(paused) != 2 {
+		t.Fatalf("expected 2 pauses, got %d", len(paused))
+	}
+	if paused[0].Step != 0 || len(paused[0].Added) != 0 {
+		t.Errorf("expected the first pause before anything ran, got %+v", paused[0])
+	}
+	if paused[1].Step != 1 || len(paused[1].Added) != 2 || paused[1].Added[1].Role != schema.Tool {
+		t.Errorf("expected the second pause after the tool result, got step %d with %d added", paused[1].Step, len(paused[1].Added))
+	}
+	a.CancelPause()
+
+	llm.calls = nil
+	a.Pause()
+	a.SetPauseHandler(func(LoopState) PauseAction { return PauseAbort })
+	if _, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("count the users")},
+		nil, nil, nil, nil, nil); !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+	if len(llm.calls) != 0 {
+		t.Errorf("expected no model calls after aborting, got %d", len(llm.calls))
+	}
+}
+
+func TestStepApproval(t *testing.T) {
+	llm := &scriptedModel{}
+	var asked [][]schema.ToolCall
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm, approveStep: func(_ string, toolCalls []schema.ToolCall) bool {
+		asked = append(asked, toolCalls)
+		return false
+	}}
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("count the users")},
+		nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 1 || asked[0][0].Function.Name != "missing__tool" {
+		t.Fatalf("expected one approval request for the tool call, got %v", asked)
+	}
+	if len(llm.calls) != 1 {
+		t.Errorf("expected the run to stop after a declined step, got %d model calls", len(llm.calls))
+	}
+
+	// The declined call is answered, so the conversation can continue
+	messages := result.ConversationMessages
+	if len(messages) != 4 || messages[2].Role != schema.Tool || messages[2].ToolCallID != "call-1" {
+		t.Fatalf("expected the skipped tool call to be answered, got %d messages", len(messages))
+	}
+	if result.FinalResponse != messages[3] || result.FinalResponse.Content != stepDeclinedResponse {
+		t.Errorf("unexpected final response %q", result.FinalResponse.Content)
+	}
+}
+
+func TestCheckpointsFollowEachMessage(t *testing.T) {
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: &scriptedModel{}}
+	var checkpoints [][]*schema.Message
+	a.SetCheckpointHandler(func(messages []*schema.Message) {
+		checkpoints = append(checkpoints, messages)
+	})
+
+	ctx := turn.WithID(context.Background(), "turn_1")
+	result, err := a.GenerateWithLoop(ctx, []*schema.Message{schema.UserMessage("go")}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The tool call waits for its result; the result and the answer each
+	// make a checkpoint
+	if len(checkpoints) != 2 {
+		t.Fatalf("expected 2 checkpoints, got %d", len(checkpoints))
+	}
+	for i, messages := range checkpoints {
+		if len(messages) != i+3 {
+			t.Errorf("checkpoint %d: expected %d messages, got %d", i, i+3, len(messages))
+		}
+		if got := turn.Of(messages[len(messages)-1]); got != "turn_1" {
+			t.Errorf("checkpoint %d: expected the new message marked with the turn, got %q", i, got)
+		}
+	}
+	if checkpoints[0][2].Role != schema.Tool || checkpoints[1][3] != result.FinalResponse {
+		t.Error("expected the checkpoints to hold the tool result and then the answer")
+	}
+}
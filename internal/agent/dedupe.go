@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// minDedupeLength is the shortest tool result worth replacing with a stub
+const minDedupeLength = 256
+
+// dedupeToolResults replaces tool results that a later call of the same tool
+// returned again, word for word, with a short stub, so a file the model reads
+// several times in one turn is only sent once. The latest copy is kept. msgs
+// is modified in place; the replaced messages themselves are not. It returns
+// the number of results replaced.
+func dedupeToolResults(msgs []*schema.Message) int {
+	toolNames := make(map[string]string)
+	for _, msg := range msgs {
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+	}
+
+	type resultKey struct{ tool, content string }
+	seen := make(map[resultKey]bool)
+	replaced := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.Role != schema.Tool || len(msg.Content) < minDedupeLength {
+			continue
+		}
+		name := toolNames[msg.ToolCallID]
+		key := resultKey{tool: name, content: msg.Content}
+		if !seen[key] {
+			seen[key] = true
+			continue
+		}
+		stub := *msg
+		stub.Content = fmt.Sprintf("(same as the later %s result below)", name)
+		msgs[i] = &stub
+		replaced++
+	}
+	return replaced
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func toolCall(id, name string) *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: name}}})
+}
+
+func TestDedupeToolResults(t *testing.T) {
+	file := strings.Repeat("package main\n", 40)
+	original := schema.ToolMessage(file, "1")
+	msgs := []*schema.Message{
+		schema.UserMessage("fix the build"),
+		toolCall("1", "fs__read_file"),
+		original,
+		toolCall("2", "fs__read_file"),
+		schema.ToolMessage(file, "2"),
+		toolCall("3", "fs__search"),
+		schema.ToolMessage(file, "3"), // same text from another tool
+		toolCall("4", "fs__read_file"),
+		schema.ToolMessage("ok", "4"),
+		toolCall("5", "fs__read_file"),
+		schema.ToolMessage("ok", "5"), // too short to be worth replacing
+	}
+
+	if replaced := dedupeToolResults(msgs); replaced != 1 {
+		t.Fatalf("expected 1 result replaced, got %d", replaced)
+	}
+	if msgs[2].Content != "(same as the later fs__read_file result below)" || msgs[2].ToolCallID != "1" {
+		t.Errorf("expected the older result to be a stub, got %q", msgs[2].Content)
+	}
+	if original.Content != file {
+		t.Error("expected the original message to be left alone")
+	}
+	if msgs[4].Content != file || msgs[6].Content != file || msgs[8].Content != "ok" {
+		t.Error("expected the latest, other-tool and short results to be kept")
+	}
+
+	// Running again before the next call finds nothing new
+	if replaced := dedupeToolResults(msgs); replaced != 0 {
+		t.Errorf("expected no further replacements, got %d", replaced)
+	}
+}
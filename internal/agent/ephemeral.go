@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// maxEphemeralPreview bounds the first line quoted in an ephemeral result's summary
+const maxEphemeralPreview = 80
+
+// ephemeralServers returns the servers whose tool results are kept for the
+// current turn only, sorted
+func ephemeralServers(mcpConfig *config.Config) []string {
+	if mcpConfig == nil {
+		return nil
+	}
+	var servers []string
+	for name, server := range mcpConfig.MCPServers {
+		if server.EphemeralResults {
+			servers = append(servers, name)
+		}
+	}
+	sort.Strings(servers)
+	return servers
+}
+
+// dropEphemeralResults replaces the results of ephemeral servers' tools with a
+// one-line summary, once the turn that used them is over. msgs is modified in
+// place; the replaced messages themselves are not.
+func dropEphemeralResults(msgs []*schema.Message, servers []string) {
+	if len(servers) == 0 {
+		return
+	}
+	toolNames := make(map[string]string)
+	for _, msg := range msgs {
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+	}
+
+	for i, msg := range msgs {
+		if msg.Role != schema.Tool {
+			continue
+		}
+		name := toolNames[msg.ToolCallID]
+		if !isEphemeralTool(name, servers) {
+			continue
+		}
+		summary := *msg
+		summary.Content = summarizeEphemeralResult(name, msg.Content)
+		msgs[i] = &summary
+	}
+}
+
+// isEphemeralTool reports whether a prefixed tool name belongs to one of servers
+func isEphemeralTool(toolName string, servers []string) bool {
+	for _, server := range servers {
+		if strings.HasPrefix(toolName, server+"__") {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeEphemeralResult describes a dropped result by its size and first line
+func summarizeEphemeralResult(toolName, content string) string {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if runes := []rune(firstLine); len(runes) > maxEphemeralPreview {
+		firstLine = string(runes[:maxEphemeralPreview-3]) + "..."
+	}
+	lines := strings.Count(content, "\n") + 1
+	return fmt.Sprintf("(%s result from an earlier turn, not kept: %d bytes, %d lines, starting %q)", toolName, len(content), lines, firstLine)
+}
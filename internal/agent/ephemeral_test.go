@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+func TestDropEphemeralResults(t *testing.T) {
+	servers := ephemeralServers(&config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"fetch":      {EphemeralResults: true},
+		"filesystem": {},
+	}})
+	if len(servers) != 1 || servers[0] != "fetch" {
+		t.Fatalf("expected only fetch to be ephemeral, got %v", servers)
+	}
+
+	page := "<!doctype html>\n" + strings.Repeat("<p>news</p>\n", 500)
+	fetched := schema.ToolMessage(page, "1")
+	msgs := []*schema.Message{
+		schema.UserMessage("summarize the front page"),
+		toolCall("1", "fetch__fetch"),
+		fetched,
+		toolCall("2", "filesystem__read_file"),
+		schema.ToolMessage("notes", "2"),
+		toolCall("3", "fetcher__get"), // a different server sharing the prefix
+		schema.ToolMessage("kept", "3"),
+		schema.AssistantMessage("Mostly news.", nil),
+	}
+
+	dropEphemeralResults(msgs, servers)
+
+	want := `(fetch__fetch result from an earlier turn, not kept: 6016 bytes, 502 lines, starting "<!doctype html>")`
+	if msgs[2].Content != want || msgs[2].ToolCallID != "1" {
+		t.Errorf("unexpected summary %q", msgs[2].Content)
+	}
+	if fetched.Content != page {
+		t.Error("expected the original message to be left alone")
+	}
+	if msgs[4].Content != "notes" || msgs[6].Content != "kept" {
+		t.Error("expected other servers' results to be kept")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/osi4iot/mcphost/internal/config"
 	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/scrub"
 	"github.com/osi4iot/mcphost/internal/tools"
 )
 
@@ -20,13 +21,38 @@ type AgentCreationOptions struct {
 	SystemPrompt     string
 	MaxSteps         int
 	StreamingEnabled bool
-	ShowSpinner      bool              // For Ollama models
+	ShowSpinner      bool              // For Ollama and llama.cpp models
 	Quiet            bool              // Skip spinner if quiet
 	SpinnerFunc      SpinnerFunc       // Function to show spinner (provided by caller)
 	DebugLogger      tools.DebugLogger // Optional debug logger
+
+	// DisableESCListener turns off ESC-to-cancel (for non-terminal embedders)
+	DisableESCListener bool
+
+	// ToolApprovalHandler is consulted before every tool execution (optional)
+	ToolApprovalHandler ToolApprovalHandler
+
+	// StepApprovalHandler is consulted before each step's tool calls run (optional)
+	StepApprovalHandler StepApprovalHandler
+
+	// SamplingApprovalHandler is consulted before every MCP sampling request (optional)
+	SamplingApprovalHandler tools.SamplingApprovalHandler
+
+	// Scrubber masks personal data sent to remote providers (optional)
+	Scrubber *scrub.Scrubber
+
+	// KeepDuplicateToolResults turns off replacing repeated tool results
+	KeepDuplicateToolResults bool
+
+	// StopOnToolCalls returns the model's tool calls unexecuted
+	StopOnToolCalls bool
+
+	// Citations asks the model to cite the sources of tool results
+	Citations bool
 }
 
-// CreateAgent creates an agent with optional spinner for Ollama models
+// CreateAgent creates an agent with optional spinner for local models, which
+// take a while to load
 func CreateAgent(ctx context.Context, opts *AgentCreationOptions) (*Agent, error) {
 	agentConfig := &AgentConfig{
 		ModelConfig:      opts.ModelConfig,
@@ -35,14 +61,29 @@ func CreateAgent(ctx context.Context, opts *AgentCreationOptions) (*Agent, error
 		MaxSteps:         opts.MaxSteps,
 		StreamingEnabled: opts.StreamingEnabled,
 		DebugLogger:      opts.DebugLogger,
+
+		DisableESCListener:  opts.DisableESCListener,
+		ToolApprovalHandler: opts.ToolApprovalHandler,
+		StepApprovalHandler: opts.StepApprovalHandler,
+		Scrubber:            opts.Scrubber,
+
+		SamplingApprovalHandler:  opts.SamplingApprovalHandler,
+		KeepDuplicateToolResults: opts.KeepDuplicateToolResults,
+		StopOnToolCalls:          opts.StopOnToolCalls,
+		Citations:                opts.Citations,
 	}
 
 	var agent *Agent
 	var err error
 
-	// Show spinner for Ollama models if requested and not quiet
-	if opts.ShowSpinner && strings.HasPrefix(opts.ModelConfig.ModelString, "ollama:") && !opts.Quiet && opts.SpinnerFunc != nil {
-		err = opts.SpinnerFunc("Loading Ollama model...", func() error {
+	// Show spinner for local models if requested and not quiet
+	provider, _, _ := strings.Cut(opts.ModelConfig.ModelString, ":")
+	if opts.ShowSpinner && models.IsLocal(provider) && !opts.Quiet && opts.SpinnerFunc != nil {
+		message := "Loading Ollama model..."
+		if provider == models.LlamaCppProvider {
+			message = "Loading GGUF model with llama.cpp..."
+		}
+		err = opts.SpinnerFunc(message, func() error {
 			agent, err = NewAgent(ctx, agentConfig)
 			return err
 		})
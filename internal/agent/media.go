@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Kinds of media a tool result can contain
+const (
+	mediaImage = "image"
+	mediaAudio = "audio"
+)
+
+// toolMedia is an image or audio block taken out of a tool result
+type toolMedia struct {
+	toolName string
+	kind     string
+	mimeType string
+	data     string // base64
+}
+
+// mediaPlaceholder describes an image or audio block in text
+func mediaPlaceholder(kind, mimeType, data string) string {
+	return fmt.Sprintf("[%s: %s, %d KB]", kind, mimeType, (base64.StdEncoding.DecodedLen(len(data))+1023)/1024)
+}
+
+// splitToolResultMedia takes the image and audio blocks out of an MCP tool
+// result. It returns the result with each block replaced by a text
+// placeholder, since tool messages are text, and the blocks themselves.
+func splitToolResultMedia(toolName, output string) (string, []toolMedia) {
+	if !strings.Contains(output, `"image"`) && !strings.Contains(output, `"audio"`) {
+		return output, nil
+	}
+	var result mcp.CallToolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return output, nil
+	}
+
+	var media []toolMedia
+	for i, content := range result.Content {
+		var item toolMedia
+		if image, ok := mcp.AsImageContent(content); ok {
+			item = toolMedia{toolName: toolName, kind: mediaImage, mimeType: image.MIMEType, data: image.Data}
+		} else if audio, ok := mcp.AsAudioContent(content); ok {
+			item = toolMedia{toolName: toolName, kind: mediaAudio, mimeType: audio.MIMEType, data: audio.Data}
+		}
+		if item.data == "" {
+			continue
+		}
+		media = append(media, item)
+		result.Content[i] = mcp.NewTextContent(mediaPlaceholder(item.kind, item.mimeType, item.data))
+	}
+	if len(media) == 0 {
+		return output, nil
+	}
+
+	text, err := json.Marshal(result)
+	if err != nil {
+		return output, nil
+	}
+	return string(text), media
+}
+
+// toolMediaMessage returns a user message showing the model the media its
+// tool calls returned, since most providers take media only in user turns
+func toolMediaMessage(media []toolMedia) *schema.Message {
+	var names []string
+	var kinds []string
+	for _, item := range media {
+		if !slices.Contains(names, item.toolName) {
+			names = append(names, item.toolName)
+		}
+		if !slices.Contains(kinds, item.kind) {
+			kinds = append(kinds, item.kind)
+		}
+	}
+
+	// The caption is a text part rather than Content: providers reject
+	// messages with both, and saved sessions keep the text parts
+	parts := []schema.ChatMessagePart{{
+		Type: schema.ChatMessagePartTypeText,
+		Text: fmt.Sprintf("[%d %s item(s) returned by %s]", len(media), strings.Join(kinds, "/"), strings.Join(names, ", ")),
+	}}
+	for _, item := range media {
+		parts = append(parts, mediaPart(item.kind, item.mimeType, item.data))
+	}
+	return &schema.Message{Role: schema.User, MultiContent: parts}
+}
+
+// mediaPart returns a message part carrying base64 image or audio data as a
+// data URL
+func mediaPart(kind, mimeType, data string) schema.ChatMessagePart {
+	url := "data:" + mimeType + ";base64," + data
+	if kind == mediaAudio {
+		return schema.ChatMessagePart{
+			Type:     schema.ChatMessagePartTypeAudioURL,
+			AudioURL: &schema.ChatMessageAudioURL{URL: url, MIMEType: mimeType},
+		}
+	}
+	return schema.ChatMessagePart{
+		Type:     schema.ChatMessagePartTypeImageURL,
+		ImageURL: &schema.ChatMessageImageURL{URL: url, MIMEType: mimeType},
+	}
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSplitToolResultMedia(t *testing.T) {
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent("Screenshot taken"),
+			mcp.NewImageContent("iVBORw0KGgo=", "image/png"),
+			mcp.NewAudioContent("SUQzBA==", "audio/mpeg"),
+		},
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, media := splitToolResultMedia("capture", string(output))
+	if len(media) != 2 || media[0].kind != mediaImage || media[1].kind != mediaAudio || media[1].mimeType != "audio/mpeg" {
+		t.Fatalf("unexpected media %+v", media)
+	}
+	if strings.Contains(text, "iVBORw0KGgo=") || strings.Contains(text, "SUQzBA==") ||
+		!strings.Contains(text, "[image: image/png, 1 KB]") || !strings.Contains(text, "[audio: audio/mpeg, 1 KB]") ||
+		!strings.Contains(text, "Screenshot taken") {
+		t.Errorf("media not replaced by placeholders: %s", text)
+	}
+
+	// Results without media, or that aren't MCP results, pass through
+	plain := `{"content":[{"type":"text","text":"an image of a cat"}]}`
+	if text, media := splitToolResultMedia("t", plain); text != plain || media != nil {
+		t.Errorf("text-only result changed: %s", text)
+	}
+	if text, media := splitToolResultMedia("t", `"image"`); text != `"image"` || media != nil {
+		t.Errorf("non-MCP output changed: %s", text)
+	}
+
+	msg := toolMediaMessage(media)
+	if msg.Role != schema.User || msg.Content != "" || len(msg.MultiContent) != 3 {
+		t.Fatalf("unexpected media message %+v", msg)
+	}
+	if msg.MultiContent[0].Text != "[2 image/audio item(s) returned by capture]" {
+		t.Errorf("unexpected caption %q", msg.MultiContent[0].Text)
+	}
+	if msg.MultiContent[1].ImageURL.URL != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("unexpected image part %+v", msg.MultiContent[1])
+	}
+	if msg.MultiContent[2].Type != schema.ChatMessagePartTypeAudioURL || msg.MultiContent[2].AudioURL.URL != "data:audio/mpeg;base64,SUQzBA==" {
+		t.Errorf("unexpected audio part %+v", msg.MultiContent[2])
+	}
+}
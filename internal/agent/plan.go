@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CompletePlanStepTool is the tool the model calls to check off a step of the
+// pinned plan. It is offered only while a plan is pinned.
+const CompletePlanStepTool = "plan__complete_step"
+
+// planStepPattern matches a numbered or bulleted line, with an optional
+// checkbox: "1. Read the schema", "2) ...", "- [x] ..."
+var planStepPattern = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*+])\s+(?:\[[ xX]\]\s+)?(.+)$`)
+
+// PlanStep is one step of a plan
+type PlanStep struct {
+	Text string
+	Done bool
+}
+
+// Plan is a list of steps the user approved before the run that carries
+// them out
+type Plan struct {
+	Steps []PlanStep
+}
+
+// ParsePlan reads the numbered or bulleted steps from text, ignoring other
+// lines. It returns nil when text has no steps.
+func ParsePlan(text string) *Plan {
+	var plan Plan
+	for _, line := range strings.Split(text, "\n") {
+		match := planStepPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if step := strings.TrimSpace(match[1]); step != "" {
+			plan.Steps = append(plan.Steps, PlanStep{Text: step})
+		}
+	}
+	if len(plan.Steps) == 0 {
+		return nil
+	}
+	return &plan
+}
+
+// String lists the steps, numbered and checked off, one per line
+func (p *Plan) String() string {
+	var b strings.Builder
+	for i, step := range p.Steps {
+		check := " "
+		if step.Done {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "%d. [%s] %s\n", i+1, check, step.Text)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Remaining returns the number of steps not done yet
+func (p *Plan) Remaining() int {
+	remaining := 0
+	for _, step := range p.Steps {
+		if !step.Done {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// DraftPlan asks the model for a plan for the task at the end of messages,
+// without running any tools. The model is told which tools the plan can use.
+func (a *Agent) DraftPlan(ctx context.Context, messages []*schema.Message) (*Plan, error) {
+	msgs := append([]*schema.Message{}, messages...)
+	if a.systemPrompt != "" && (len(msgs) == 0 || msgs[0].Role != schema.System) {
+		msgs = append([]*schema.Message{schema.SystemMessage(a.systemPrompt)}, msgs...)
+	}
+
+	instructions := draftPlanPrompt
+	if toolInfos, _ := a.availableTools(ctx); len(toolInfos) > 0 {
+		names := make([]string, len(toolInfos))
+		for i, info := range toolInfos {
+			names[i] = info.Name
+		}
+		instructions += "\n\nTools you will be able to use: " + strings.Join(names, ", ")
+	}
+
+	reply, err := a.model.Generate(ctx, withSystemBlock(msgs, instructions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft a plan: %v", err)
+	}
+	plan := ParsePlan(reply.Content)
+	if plan == nil {
+		return nil, fmt.Errorf("the model's plan has no numbered steps: %q", reply.Content)
+	}
+	return plan, nil
+}
+
+// SetPlan pins plan for the following runs: it is shown to the model before
+// every step, and the model checks its steps off with CompletePlanStepTool.
+// A nil plan unpins it. Safe to call from any goroutine.
+func (a *Agent) SetPlan(plan *Plan) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.plan = plan
+}
+
+// CurrentPlan returns a copy of the pinned plan with its progress, or nil
+func (a *Agent) CurrentPlan() *Plan {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	if a.plan == nil {
+		return nil
+	}
+	return &Plan{Steps: append([]PlanStep{}, a.plan.Steps...)}
+}
+
+// withPlan adds the pinned plan, if any, to a model call: its steps go at the
+// end of the system prompt and its tool joins the others
+func (a *Agent) withPlan(msgs []*schema.Message, toolInfos []*schema.ToolInfo, toolMap map[string]tool.BaseTool) ([]*schema.Message, []*schema.ToolInfo) {
+	plan := a.CurrentPlan()
+	if plan == nil {
+		return msgs, toolInfos
+	}
+	toolMap[CompletePlanStepTool] = planStepTool{agent: a}
+	block := "## Plan\n\n" + pinnedPlanPrompt + "\n\n" + plan.String()
+	return withSystemBlock(msgs, block), append(toolInfos, planStepToolInfo)
+}
+
+// completeStep checks off step n (from 1) of the pinned plan
+func (a *Agent) completeStep(n int) (*Plan, error) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	if a.plan == nil {
+		return nil, fmt.Errorf("no plan is pinned")
+	}
+	if n < 1 || n > len(a.plan.Steps) {
+		return nil, fmt.Errorf("the plan has no step %d", n)
+	}
+	a.plan.Steps[n-1].Done = true
+	return &Plan{Steps: append([]PlanStep{}, a.plan.Steps...)}, nil
+}
+
+var planStepToolInfo = &schema.ToolInfo{
+	Name: CompletePlanStepTool,
+	Desc: "Checks off a step of the approved plan once it is done.",
+	ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"step": {Type: schema.Integer, Desc: "Number of the step that is done", Required: true},
+	}),
+}
+
+// planStepTool is CompletePlanStepTool, run by the agent itself
+type planStepTool struct {
+	agent *Agent
+}
+
+func (t planStepTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return planStepToolInfo, nil
+}
+
+// InvokableRun returns an MCP tool result, like the servers' tools
+func (t planStepTool) InvokableRun(_ context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	output, err := json.Marshal(t.run(argumentsInJSON))
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// run checks off the step named in the arguments
+func (t planStepTool) run(argumentsInJSON string) *mcp.CallToolResult {
+	var args struct {
+		Step int `json:"step"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid arguments: %v", err))
+	}
+	plan, err := t.agent.completeStep(args.Step)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	if remaining := plan.Remaining(); remaining > 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Step %d done; %d of %d steps remain.", args.Step, remaining, len(plan.Steps)))
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Step %d done; every step of the plan is done.", args.Step))
+}
+
+const draftPlanPrompt = `## Planning
+
+Before doing anything, write a plan for the user's latest request. You cannot run tools now; the user will review the plan, and it is carried out once they approve it.
+
+Reply with only a numbered list of steps, one line each, in the order you will do them. Each step is a short imperative sentence naming what you will do and, where it matters, the tool or file involved. Use as few steps as the task needs, and end with how you will check the result.`
+
+const pinnedPlanPrompt = `The user approved this plan. Work through it in order and call ` + CompletePlanStepTool + ` with a step's number as soon as that step is done. If the plan turns out to be wrong or impossible, stop and tell the user instead of quietly doing something else.`
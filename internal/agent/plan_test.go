@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// replyModel answers each call with the next of its replies
+type replyModel struct {
+	replies []*schema.Message
+	calls   [][]*schema.Message
+}
+
+func (m *replyModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.calls = append(m.calls, input)
+	if len(m.calls) > len(m.replies) {
+		return nil, errors.New("no reply left")
+	}
+	return m.replies[len(m.calls)-1], nil
+}
+
+func (m *replyModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *replyModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestParsePlan(t *testing.T) {
+	plan := ParsePlan("Here is the plan:\n\n1. Read the schema\n2) Write the migration\n- [x] Run it on staging\n\nLet me know.")
+	if plan == nil || len(plan.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %+v", plan)
+	}
+	if plan.Steps[1].Text != "Write the migration" || plan.Steps[2].Text != "Run it on staging" || plan.Steps[2].Done {
+		t.Errorf("unexpected steps %+v", plan.Steps)
+	}
+	if ParsePlan("I would read the schema first.") != nil {
+		t.Error("expected no plan without a list")
+	}
+
+	plan.Steps[0].Done = true
+	if got := plan.String(); got != "1. [x] Read the schema\n2. [ ] Write the migration\n3. [ ] Run it on staging" {
+		t.Errorf("unexpected plan %q", got)
+	}
+	if plan.Remaining() != 2 {
+		t.Errorf("expected 2 remaining steps, got %d", plan.Remaining())
+	}
+}
+
+func TestDraftPlan(t *testing.T) {
+	llm := &replyModel{replies: []*schema.Message{schema.AssistantMessage("1. Read the schema\n2. Write the migration", nil)}}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm, systemPrompt: "You are helpful."}
+
+	plan, err := a.DraftPlan(context.Background(), []*schema.Message{schema.UserMessage("migrate the users")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 2 {
+		t.Errorf("expected 2 steps, got %+v", plan.Steps)
+	}
+	system := llm.calls[0][0]
+	if system.Role != schema.System || !strings.HasPrefix(system.Content, "You are helpful.") || !strings.Contains(system.Content, "## Planning") {
+		t.Errorf("expected the planning instructions after the system prompt, got %q", system.Content)
+	}
+
+	llm = &replyModel{replies: []*schema.Message{schema.AssistantMessage("Sure, I can do that.", nil)}}
+	a.model = llm
+	if _, err := a.DraftPlan(context.Background(), []*schema.Message{schema.UserMessage("migrate the users")}); err == nil {
+		t.Error("expected an error for a reply without steps")
+	}
+}
+
+func TestPinnedPlan(t *testing.T) {
+	llm := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call-1", Function: schema.FunctionCall{Name: CompletePlanStepTool, Arguments: `{"step": 1}`}},
+			{ID: "call-2", Function: schema.FunctionCall{Name: CompletePlanStepTool, Arguments: `{"step": 5}`}},
+		}),
+		schema.AssistantMessage("done", nil),
+	}}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm}
+	a.SetPlan(ParsePlan("1. Read the schema\n2. Write the migration"))
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("migrate the users")},
+		nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every call sees the plan with its progress so far
+	if first := llm.calls[0][0]; first.Role != schema.System || !strings.Contains(first.Content, "1. [ ] Read the schema") {
+		t.Errorf("expected the plan before the first call, got %q", first.Content)
+	}
+	if second := llm.calls[1][0]; !strings.Contains(second.Content, "1. [x] Read the schema\n2. [ ] Write the migration") {
+		t.Errorf("expected step 1 checked off before the second call, got %q", second.Content)
+	}
+	if result.ConversationMessages[0].Role != schema.User {
+		t.Error("expected the plan to stay out of the conversation")
+	}
+
+	messages := result.ConversationMessages
+	if !strings.Contains(messages[2].Content, "1 of 2 steps remain") || !strings.Contains(messages[3].Content, "no step 5") {
+		t.Errorf("unexpected tool results %q and %q", messages[2].Content, messages[3].Content)
+	}
+	if plan := a.CurrentPlan(); plan == nil || !plan.Steps[0].Done || plan.Steps[1].Done {
+		t.Errorf("unexpected progress %+v", plan)
+	}
+
+	a.SetPlan(nil)
+	if a.CurrentPlan() != nil {
+		t.Error("expected the plan to be unpinned")
+	}
+}
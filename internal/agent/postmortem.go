@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// maxPostMortemTranscript bounds the transcript sent for a failure summary,
+// keeping its end, where the run failed
+const maxPostMortemTranscript = 12000
+
+// SummarizeFailure asks the postmortem task model, or the main model when
+// none is configured, what went wrong in a failed run. report is the
+// post-mortem so far and conversation the messages the run added.
+func (a *Agent) SummarizeFailure(ctx context.Context, report string, conversation []*schema.Message) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range conversation {
+		fmt.Fprintf(&transcript, "[%s] %s\n", msg.Role, msg.Content)
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(&transcript, "[tool call] %s %s\n", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	text := transcript.String()
+	if len(text) > maxPostMortemTranscript {
+		text = "..." + text[len(text)-maxPostMortemTranscript:]
+	}
+
+	message, err := a.TaskModel(config.TaskPostMortem).Generate(ctx, []*schema.Message{
+		schema.SystemMessage(postMortemPrompt),
+		schema.UserMessage(report + "\nTranscript:\n" + text),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize the failure: %v", err)
+	}
+	return strings.TrimSpace(message.Content), nil
+}
+
+const postMortemPrompt = `You explain why an AI agent's run failed, for the person who started it.
+
+You get the run's post-mortem, with the error that ended it, followed by a transcript of the run. Reply in at most three sentences of plain text: what the agent was doing, what went wrong and the most likely cause, and what the person should change before running it again. Do not repeat the post-mortem's fields.`
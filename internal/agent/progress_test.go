@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+func TestProgress(t *testing.T) {
+	calls := []schema.ToolCall{{ID: "call-1", Function: schema.FunctionCall{Name: "fs__grep", Arguments: `{}`}}}
+	llm := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", calls),
+		schema.AssistantMessage("Done.", nil),
+	}}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm, maxSteps: 5}
+
+	var atToolCall, atResponse [2]int
+	_, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("find it")},
+		func(string, string) { atToolCall[0], atToolCall[1] = a.Progress() },
+		nil, nil,
+		func(string) { atResponse[0], atResponse[1] = a.Progress() },
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atToolCall != [2]int{1, 5} || atResponse != [2]int{2, 5} {
+		t.Errorf("progress at the tool call %v and the response %v, want [1 5] and [2 5]", atToolCall, atResponse)
+	}
+	if step, _ := a.Progress(); step != 0 {
+		t.Errorf("expected no step between runs, got %d", step)
+	}
+}
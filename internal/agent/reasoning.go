@@ -0,0 +1,63 @@
+package agent
+
+import "github.com/cloudwego/eino/schema"
+
+// ReasoningHandler is given the reasoning a model wrote before its answer or
+// tool calls, such as the reasoning_content of DeepSeek's reasoner. It is only
+// shown; the conversation sent back to the model does not keep it.
+type ReasoningHandler func(reasoning string)
+
+// reasoningExtraKey is where the OpenAI client also copies a response's
+// reasoning
+const reasoningExtraKey = "reasoning-content"
+
+// SetReasoningHandler sets the function given the reasoning of each model
+// call that has some; nil removes it
+func (a *Agent) SetReasoningHandler(handler ReasoningHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onReasoning = handler
+}
+
+// SetThinkingHandler sets the function given the reasoning of each streamed
+// model call in pieces as they arrive, before the reasoning handler is given
+// all of it; nil removes it
+func (a *Agent) SetThinkingHandler(handler ReasoningHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onThinking = handler
+}
+
+// reportThinking gives a piece of streamed reasoning to the thinking handler,
+// if any
+func (a *Agent) reportThinking(chunk string) {
+	a.controlMu.Lock()
+	handler := a.onThinking
+	a.controlMu.Unlock()
+	if handler != nil && chunk != "" {
+		handler(chunk)
+	}
+}
+
+// reportReasoning gives reasoning to the reasoning handler, if any
+func (a *Agent) reportReasoning(reasoning string) {
+	a.controlMu.Lock()
+	handler := a.onReasoning
+	a.controlMu.Unlock()
+	if handler != nil && reasoning != "" {
+		handler(reasoning)
+	}
+}
+
+// takeReasoning reports the reasoning left in response and removes it, so it
+// is not sent back with the conversation; DeepSeek rejects requests that
+// carry it
+func (a *Agent) takeReasoning(response *schema.Message) {
+	reasoning := response.ReasoningContent
+	if reasoning == "" {
+		reasoning, _ = response.Extra[reasoningExtraKey].(string)
+	}
+	response.ReasoningContent = ""
+	delete(response.Extra, reasoningExtraKey)
+	a.reportReasoning(reasoning)
+}
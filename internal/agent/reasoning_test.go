@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// reasoningModel is scriptedModel whose responses come with reasoning, the
+// way the OpenAI client returns DeepSeek's reasoning_content
+type reasoningModel struct {
+	scriptedModel
+}
+
+func (m *reasoningModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	response, err := m.scriptedModel.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reasoning := fmt.Sprintf("step %d", len(m.calls))
+	response.ReasoningContent = reasoning
+	response.Extra = map[string]any{reasoningExtraKey: reasoning}
+	return response, nil
+}
+
+func TestReasoningIsShownButNotSentBack(t *testing.T) {
+	llm := &reasoningModel{}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm}
+	var shown []string
+	a.SetReasoningHandler(func(reasoning string) { shown = append(shown, reasoning) })
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("go")}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shown) != 2 || shown[0] != "step 1" || shown[1] != "step 2" {
+		t.Errorf("expected the reasoning of both calls, got %v", shown)
+	}
+	for _, msg := range llm.calls[1] {
+		if msg.ReasoningContent != "" || msg.Extra[reasoningExtraKey] != nil {
+			t.Errorf("expected no reasoning sent back, got %q in a %s message", msg.ReasoningContent, msg.Role)
+		}
+	}
+	if result.FinalResponse.ReasoningContent != "" {
+		t.Errorf("expected no reasoning in the history, got %q", result.FinalResponse.ReasoningContent)
+	}
+}
+
+func TestStreamedReasoningComesFirst(t *testing.T) {
+	chunks := []*schema.Message{
+		{Role: schema.Assistant, ReasoningContent: "The user "},
+		{Role: schema.Assistant, ReasoningContent: "says hi."},
+		{Role: schema.Assistant, Content: "Hello"},
+		{Role: schema.Assistant, Content: "!"},
+	}
+
+	var events []string
+	msg, err := streamWithReasoning(context.Background(), schema.StreamReaderFromArray(chunks),
+		func(reasoning string) { events = append(events, "reasoning: "+reasoning) },
+		func(piece string) { events = append(events, "thinking: "+piece) },
+		func(chunk string) { events = append(events, "chunk: "+chunk) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"thinking: The user ", "thinking: says hi.", "reasoning: The user says hi.", "chunk: Hello", "chunk: !"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], events[i])
+		}
+	}
+	if msg.Content != "Hello!" || msg.ReasoningContent != "" {
+		t.Errorf("expected only the answer in the response, got %q / %q", msg.Content, msg.ReasoningContent)
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/scrub"
+)
+
+// createRouteModels creates the models the router gives steps of the loop,
+// leaving out steps that use the main model
+func createRouteModels(ctx context.Context, modelConfig *models.ProviderConfig, scrubber *scrub.Scrubber, owned *releases) (map[string]model.ToolCallingChatModel, error) {
+	routeModels := make(map[string]model.ToolCallingChatModel)
+	for _, step := range []string{config.RouteTools, config.RouteFinal} {
+		routeConfig := modelConfig.ForRoute(step)
+		if routeConfig == nil {
+			continue
+		}
+		// The tools model only ever makes tool calls
+		if step == config.RouteTools && !models.CallsTools(routeConfig) {
+			return nil, fmt.Errorf("router: the tools model %s does not support tool calls", routeConfig.ModelString)
+		}
+		chatModel, err := createModel(ctx, routeConfig, scrubber, owned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the router's %s model: %v", step, err)
+		}
+		routeModels[step] = chatModel
+	}
+	return routeModels, nil
+}
+
+// generateRouted makes the model call of a step. Without a router every call
+// goes to the main model. With a tools model, the calls that follow tool
+// results go to it, without streaming; when it answers instead of calling
+// more tools, its answer is dropped and the final model writes the one the
+// user sees. The final model, or the main one, makes a run's first call.
+// The shadow model gets one call per step, however many models made it.
+func (a *Agent) generateRouted(ctx context.Context, step int, messages []*schema.Message, toolInfos []*schema.ToolInfo, toolChoice *schema.ToolChoice, streamingCallback StreamingResponseHandler) (response *schema.Message, err error) {
+	mirrored := a.shadow(ctx, messages, callOptions(toolInfos, toolChoice))
+	defer func() { mirrored(response) }()
+
+	if toolsModel, ok := a.routeModels[config.RouteTools]; ok && step > 0 {
+		response, err := a.generateWithCancellationAndStreaming(ctx, toolsModel, messages, toolInfos, toolChoice, nil)
+		if err != nil || len(response.ToolCalls) > 0 {
+			return response, err
+		}
+	}
+
+	finalModel := a.model
+	if routed, ok := a.routeModels[config.RouteFinal]; ok {
+		finalModel = routed
+	}
+	return a.generateWithCancellationAndStreaming(ctx, finalModel, messages, toolInfos, toolChoice, streamingCallback)
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+func TestGenerateRouted(t *testing.T) {
+	call := func(id string) []schema.ToolCall {
+		return []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "fs__grep", Arguments: `{}`}}}
+	}
+	main := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", call("call-1")),
+		schema.AssistantMessage("The answer.", nil),
+	}}
+	cheap := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", call("call-2")),
+		schema.AssistantMessage("A cheap answer.", nil),
+	}}
+	a := &Agent{
+		toolManager: tools.NewMCPToolManager(),
+		model:       main,
+		routeModels: map[string]model.ToolCallingChatModel{config.RouteTools: cheap},
+		maxSteps:    10,
+	}
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("find it")}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinalResponse.Content != "The answer." {
+		t.Errorf("expected the main model's answer, got %q", result.FinalResponse.Content)
+	}
+	// The main model starts and answers; the tools model makes the steps between
+	if len(main.calls) != 2 || len(cheap.calls) != 2 {
+		t.Errorf("expected 2 calls to each model, got %d to the main one and %d to the tools one", len(main.calls), len(cheap.calls))
+	}
+	for _, msg := range result.ConversationMessages {
+		if msg.Content == "A cheap answer." {
+			t.Error("expected the tools model's answer to be dropped")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// scratchpadTools returns the read_notes tools of the configured scratchpad
+// builtin servers, sorted
+func scratchpadTools(mcpConfig *config.Config) []string {
+	if mcpConfig == nil {
+		return nil
+	}
+	var names []string
+	for name, server := range mcpConfig.MCPServers {
+		if server.Type == "builtin" && server.Name == builtin.ScratchpadServerName {
+			names = append(names, name+"__"+builtin.ReadNotesTool)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withScratchpad returns the messages for a model call with the scratchpad
+// notes appended to the system prompt. The notes are read fresh for every
+// call and never become part of the conversation, so the model sees their
+// current state without every edit piling up in the context.
+func (a *Agent) withScratchpad(ctx context.Context, msgs []*schema.Message, toolMap map[string]tool.BaseTool) []*schema.Message {
+	var blocks []string
+	for _, name := range a.scratchpads {
+		readNotes, ok := toolMap[name].(tool.InvokableTool)
+		if !ok {
+			continue
+		}
+		output, err := readNotes.InvokableRun(ctx, "{}")
+		if err != nil {
+			continue
+		}
+		if notes := toolResultText(output); notes != "" && notes != builtin.ScratchpadEmpty {
+			blocks = append(blocks, notes)
+		}
+	}
+	if len(blocks) == 0 {
+		return msgs
+	}
+
+	block := "## Scratchpad\n\nYour working notes, kept with write_note and shown before every step:\n\n" + strings.Join(blocks, "\n")
+	return withSystemBlock(msgs, block)
+}
+
+// withSystemBlock returns msgs with block appended to a copy of the leading
+// system message, or in a system message of its own when there is none
+func withSystemBlock(msgs []*schema.Message, block string) []*schema.Message {
+	withBlock := make([]*schema.Message, 0, len(msgs)+1)
+	if len(msgs) > 0 && msgs[0].Role == schema.System {
+		system := *msgs[0]
+		system.Content = strings.TrimRight(system.Content, "\n") + "\n\n" + block
+		withBlock = append(withBlock, &system)
+		msgs = msgs[1:]
+	} else {
+		withBlock = append(withBlock, schema.SystemMessage(block))
+	}
+	return append(withBlock, msgs...)
+}
+
+// toolResultText returns the text of an MCP tool's result, which tools return
+// as the JSON-encoded mcp.CallToolResult, or output itself when it is not one
+func toolResultText(output string) string {
+	var result mcp.CallToolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil || result.Content == nil {
+		return output
+	}
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := mcp.AsTextContent(content); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// staticTool returns a fixed output
+type staticTool struct{ output string }
+
+func (s staticTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "static"}, nil
+}
+
+func (s staticTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	return s.output, nil
+}
+
+func TestWithScratchpad(t *testing.T) {
+	tools := scratchpadTools(&config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"notes": {Type: "builtin", Name: builtin.ScratchpadServerName},
+		"fs":    {Type: "builtin", Name: "fs"},
+	}})
+	if len(tools) != 1 || tools[0] != "notes__read_notes" {
+		t.Fatalf("unexpected scratchpad tools %v", tools)
+	}
+
+	a := &Agent{scratchpads: tools}
+	msgs := []*schema.Message{schema.SystemMessage("You are helpful."), schema.UserMessage("migrate the users")}
+
+	// An empty scratchpad leaves the messages alone
+	empty := map[string]tool.BaseTool{"notes__read_notes": staticTool{output: builtin.ScratchpadEmpty}}
+	if got := a.withScratchpad(context.Background(), msgs, empty); len(got) != 2 || got[0] != msgs[0] {
+		t.Error("expected no change for an empty scratchpad")
+	}
+
+	// Tools return the JSON-encoded MCP result
+	notes := map[string]tool.BaseTool{"notes__read_notes": staticTool{output: `{"content":[{"type":"text","text":"1. Plan: copy, then verify"}]}`}}
+	got := a.withScratchpad(context.Background(), msgs, notes)
+	if len(got) != 2 || got[1] != msgs[1] {
+		t.Fatalf("expected the notes in the system prompt only, got %d messages", len(got))
+	}
+	if !strings.HasPrefix(got[0].Content, "You are helpful.\n\n## Scratchpad") || !strings.HasSuffix(got[0].Content, "1. Plan: copy, then verify") {
+		t.Errorf("unexpected system prompt %q", got[0].Content)
+	}
+	if msgs[0].Content != "You are helpful." {
+		t.Error("expected the conversation's system message to be left alone")
+	}
+
+	// Without a system prompt the notes get their own system message
+	got = a.withScratchpad(context.Background(), msgs[1:], notes)
+	if len(got) != 2 || got[0].Role != schema.System {
+		t.Errorf("expected a system message before the conversation, got %+v", got[0])
+	}
+}
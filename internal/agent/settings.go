@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/scrub"
+)
+
+// ToolFilter decides whether the model is offered a tool, by its prefixed
+// server__tool name
+type ToolFilter func(name string) bool
+
+// The setters below change what later runs use, e.g. when /persona switches
+// personas mid-session. They must not be called while a run is in progress.
+
+// SetSystemPrompt sets the system prompt added to conversations that don't
+// start with one
+func (a *Agent) SetSystemPrompt(prompt string) {
+	a.systemPrompt = prompt
+}
+
+// SystemPrompt returns the system prompt added to conversations
+func (a *Agent) SystemPrompt() string {
+	return a.systemPrompt
+}
+
+// SetModel replaces the main model with the one modelConfig describes.
+// Auxiliary task models and the models serving sampling requests are kept.
+func (a *Agent) SetModel(ctx context.Context, modelConfig *models.ProviderConfig) error {
+	providerResult, err := models.CreateProvider(ctx, modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create model provider: %v", err)
+	}
+
+	providerType, _, _ := strings.Cut(modelConfig.ModelString, ":")
+	chatModel := providerResult.Model
+	if a.scrubber != nil && !models.IsLocal(providerType) {
+		chatModel = scrub.WrapModel(chatModel, a.scrubber)
+	}
+
+	if a.releaseModel != nil {
+		a.releaseModel()
+	}
+	a.releaseModel = providerResult.Release
+	a.model = chatModel
+	a.providerType = providerType
+	a.loadingMessage = providerResult.Message
+	a.adjustments = providerResult.Adjustments
+	a.acceptsImages = models.AcceptsImages(modelConfig.ModelString)
+	a.acceptsAudio = models.AcceptsAudio(modelConfig.ModelString)
+	a.withoutTools = !providerResult.Emulated && !models.SupportsTools(modelConfig.ModelString)
+	a.toolChoice = modelConfig.ToolChoice
+	return nil
+}
+
+// SetToolFilter limits the tools offered to the model to those filter
+// accepts; nil offers every tool
+func (a *Agent) SetToolFilter(filter ToolFilter) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.toolFilter = filter
+}
+
+// offersTool reports whether the tool filter, if any, accepts name
+func (a *Agent) offersTool(name string) bool {
+	a.controlMu.Lock()
+	filter := a.toolFilter
+	a.controlMu.Unlock()
+	return filter == nil || filter(name)
+}
+
+// filterTools returns the tools the tool filter accepts
+func (a *Agent) filterTools(ctx context.Context, all []tool.BaseTool) []tool.BaseTool {
+	a.controlMu.Lock()
+	filter := a.toolFilter
+	a.controlMu.Unlock()
+	if filter == nil {
+		return all
+	}
+
+	var offered []tool.BaseTool
+	for _, t := range all {
+		if info, err := t.Info(ctx); err == nil && info != nil && filter(info.Name) {
+			offered = append(offered, t)
+		}
+	}
+	return offered
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/scrub"
+	"github.com/osi4iot/mcphost/internal/turn"
+)
+
+// shadowCallTimeout bounds a shadow model call, which outlives the run that
+// made it
+const shadowCallTimeout = 5 * time.Minute
+
+// shadowDrainTimeout bounds how long Close waits for the shadow model calls
+// it cancelled to return
+const shadowDrainTimeout = 5 * time.Second
+
+// ShadowCall is a model call of the loop as the shadow model answered it
+type ShadowCall struct {
+	Model    string          // the shadow model
+	Shadows  string          // the main model, whose call was mirrored
+	TurnID   string          // the turn the call was made in
+	Response *schema.Message // nil when the call failed
+	Main     *schema.Message // the main model's response, nil when it failed
+	Err      error
+	Duration time.Duration
+}
+
+// ShadowHandler is given each shadow model call once it completes, on the
+// call's own goroutine
+type ShadowHandler func(call ShadowCall)
+
+// createShadowModel creates the shadow model and returns it with its model
+// string, or a nil model when there is none
+func createShadowModel(ctx context.Context, modelConfig *models.ProviderConfig, scrubber *scrub.Scrubber, owned *releases) (string, model.ToolCallingChatModel, error) {
+	shadowConfig := modelConfig.ForShadow()
+	if shadowConfig == nil {
+		return "", nil, nil
+	}
+	chatModel, err := createModel(ctx, shadowConfig, scrubber, owned)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create shadow model: %v", err)
+	}
+	return shadowConfig.ModelString, chatModel, nil
+}
+
+// SetShadowHandler sets the function given each shadow model call; nil
+// removes it, and the shadow model's answers are dropped
+func (a *Agent) SetShadowHandler(handler ShadowHandler) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	a.onShadow = handler
+}
+
+// ShadowModel returns the model string of the shadow model, or "" without one
+func (a *Agent) ShadowModel() string {
+	return a.shadowName
+}
+
+// shadow mirrors a model call to the shadow model in the background. The run
+// neither waits for it nor sees its answer, which only goes to the shadow
+// handler once the returned function is given the main model's response;
+// Close cancels the calls still running and drops their answers.
+func (a *Agent) shadow(ctx context.Context, messages []*schema.Message, opts []model.Option) func(main *schema.Message) {
+	if a.shadowModel == nil {
+		return func(*schema.Message) {}
+	}
+	messages = append([]*schema.Message(nil), messages...)
+	turnID := turn.ID(ctx)
+	closed := a.shadowClosed()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowCallTimeout)
+	stop := context.AfterFunc(closed, cancel)
+	mainResponse := make(chan *schema.Message, 1)
+
+	a.shadowCalls.Add(1)
+	go func() {
+		defer a.shadowCalls.Done()
+		defer cancel()
+		defer stop()
+
+		start := time.Now()
+		response, err := a.shadowModel.Generate(ctx, messages, opts...)
+		if closed.Err() != nil {
+			return
+		}
+		call := ShadowCall{
+			Model:    a.shadowName,
+			Shadows:  a.modelName,
+			TurnID:   turnID,
+			Response: response,
+			Err:      err,
+			Duration: time.Since(start),
+			Main:     <-mainResponse,
+		}
+
+		a.controlMu.Lock()
+		handler := a.onShadow
+		a.controlMu.Unlock()
+		if handler != nil {
+			handler(call)
+		}
+	}()
+	return func(main *schema.Message) { mainResponse <- main }
+}
+
+// shadowClosed returns the context Close cancels to stop the shadow model
+// calls still running
+func (a *Agent) shadowClosed() context.Context {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	if a.shadowStop == nil {
+		a.shadowStop, a.closeShadow = context.WithCancel(context.Background())
+	}
+	return a.shadowStop
+}
+
+// stopShadowCalls cancels the shadow model calls still running and waits
+// briefly for them to return; answers they give after it are dropped
+func (a *Agent) stopShadowCalls() {
+	a.controlMu.Lock()
+	if a.closeShadow != nil {
+		a.closeShadow()
+	}
+	a.controlMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.shadowCalls.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shadowDrainTimeout):
+	}
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+// lockedModel lets shadow model calls, made on their own goroutines, share a
+// replyModel
+type lockedModel struct {
+	mu sync.Mutex
+	*replyModel
+}
+
+func (m *lockedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.replyModel.Generate(ctx, input, opts...)
+}
+
+func TestShadowModel(t *testing.T) {
+	toolCall := []schema.ToolCall{{ID: "call-1", Function: schema.FunctionCall{Name: "fs__grep", Arguments: `{}`}}}
+	main := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", toolCall),
+		schema.AssistantMessage("The answer.", nil),
+	}}
+	shadow := &lockedModel{replyModel: &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("A shadow answer.", nil),
+	}}}
+	a := &Agent{
+		toolManager: tools.NewMCPToolManager(),
+		model:       main,
+		maxSteps:    10,
+		modelName:   "anthropic:claude-sonnet-4-20250514",
+		shadowName:  "openai:gpt-4o-mini",
+		shadowModel: shadow,
+	}
+	var mu sync.Mutex
+	var calls []ShadowCall
+	a.SetShadowHandler(func(call ShadowCall) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	})
+
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("find it")}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.shadowCalls.Wait()
+
+	if result.FinalResponse.Content != "The answer." || len(main.calls) != 2 {
+		t.Errorf("expected the main model to run the loop, got %q after %d calls", result.FinalResponse.Content, len(main.calls))
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected both model calls mirrored, got %d", len(calls))
+	}
+	var answered, failed int
+	for _, call := range calls {
+		if call.Model != "openai:gpt-4o-mini" || call.Shadows != "anthropic:claude-sonnet-4-20250514" || call.Main == nil {
+			t.Errorf("unexpected shadow call %+v", call)
+		}
+		if call.Err != nil {
+			failed++
+		} else if call.Response.Content == "A shadow answer." {
+			answered++
+		}
+	}
+	// The shadow model has one reply; its failed second call doesn't fail the run
+	if answered != 1 || failed != 1 {
+		t.Errorf("expected one shadow answer and one failure, got %d and %d", answered, failed)
+	}
+}
+
+func TestShadowModelRoutedStep(t *testing.T) {
+	toolCall := []schema.ToolCall{{ID: "call-1", Function: schema.FunctionCall{Name: "fs__grep", Arguments: `{}`}}}
+	main := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("", toolCall),
+		schema.AssistantMessage("The answer.", nil),
+	}}
+	cheap := &replyModel{replies: []*schema.Message{schema.AssistantMessage("A cheap answer.", nil)}}
+	shadow := &lockedModel{replyModel: &replyModel{}}
+	a := &Agent{
+		toolManager: tools.NewMCPToolManager(),
+		model:       main,
+		routeModels: map[string]model.ToolCallingChatModel{config.RouteTools: cheap},
+		maxSteps:    10,
+		shadowModel: shadow,
+	}
+
+	if _, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("find it")}, nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	a.shadowCalls.Wait()
+	// The second step goes to the tools model and then the main one
+	if len(cheap.calls) != 1 || len(main.calls) != 2 {
+		t.Fatalf("expected the tools model's answer to be dropped, got %d and %d calls", len(cheap.calls), len(main.calls))
+	}
+	if len(shadow.calls) != 2 {
+		t.Errorf("expected one shadow call per step, got %d", len(shadow.calls))
+	}
+}
+
+// blockingModel answers only once its call is cancelled
+type blockingModel struct {
+	*replyModel
+}
+
+func (m blockingModel) Generate(ctx context.Context, _ []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCloseCancelsShadowCalls(t *testing.T) {
+	a := &Agent{
+		toolManager: tools.NewMCPToolManager(),
+		model:       &replyModel{replies: []*schema.Message{schema.AssistantMessage("The answer.", nil)}},
+		maxSteps:    10,
+		shadowModel: blockingModel{&replyModel{}},
+	}
+	handled := false
+	a.SetShadowHandler(func(ShadowCall) { handled = true })
+
+	if _, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("find it")}, nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	a.stopShadowCalls()
+	if elapsed := time.Since(start); elapsed > shadowDrainTimeout {
+		t.Errorf("expected Close to cancel the shadow call, took %s", elapsed)
+	}
+	a.shadowCalls.Wait()
+	if handled {
+		t.Error("expected the cancelled shadow call to be dropped")
+	}
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+)
+
+func TestStopOnToolCalls(t *testing.T) {
+	calls := []schema.ToolCall{{ID: "call-1", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}}}
+	llm := &replyModel{replies: []*schema.Message{
+		schema.AssistantMessage("Let me look.", calls),
+		schema.AssistantMessage("It is a Go module.", nil),
+	}}
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: llm, stopOnToolCalls: true}
+
+	executed := false
+	result, err := a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("what is this?")},
+		func(string, string) { executed = true }, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if executed || len(llm.calls) != 1 {
+		t.Fatalf("expected the run to stop at the tool calls, got %d model calls", len(llm.calls))
+	}
+	if len(result.PendingToolCalls) != 1 || result.PendingToolCalls[0].ID != "call-1" {
+		t.Errorf("unexpected pending calls %+v", result.PendingToolCalls)
+	}
+	if last := result.ConversationMessages[len(result.ConversationMessages)-1]; len(last.ToolCalls) != 1 {
+		t.Errorf("expected the conversation to end at the tool calls, got %+v", last)
+	}
+
+	// The caller's results continue the run
+	messages := append(result.ConversationMessages, schema.ToolMessage("module example.com/demo", "call-1"))
+	result, err = a.GenerateWithLoop(context.Background(), messages, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinalResponse.Content != "It is a Go module." || len(result.PendingToolCalls) != 0 {
+		t.Errorf("unexpected response %+v", result)
+	}
+	if got := llm.calls[1][len(llm.calls[1])-1]; got.Role != schema.Tool || got.ToolCallID != "call-1" {
+		t.Errorf("expected the result sent to the model, got %+v", got)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/usage"
 )
 
 // StreamWithCallback streams content with real-time callbacks and returns complete response
@@ -16,12 +18,31 @@ import (
 // - OpenAI/Others: Tool calls first or alone
 // - Mixed: Tool calls and content interleaved
 func StreamWithCallback(ctx context.Context, reader *schema.StreamReader[*schema.Message], callback func(string)) (*schema.Message, error) {
+	return streamWithReasoning(ctx, reader, nil, nil, callback)
+}
+
+// streamWithReasoning is StreamWithCallback for models that reason before
+// they answer. With onReasoning set, the reasoning is given to onThinking, if
+// set, piece by piece as it streams, then to onReasoning as soon as the
+// answer or tool calls start, or the stream ends, and is left out of the
+// response; otherwise it is kept in the response's ReasoningContent.
+func streamWithReasoning(ctx context.Context, reader *schema.StreamReader[*schema.Message], onReasoning, onThinking func(string), callback func(string)) (*schema.Message, error) {
 	defer reader.Close()
 
+	var reasoning strings.Builder
+	reasoningReported := false
+	reportReasoning := func() {
+		if onReasoning != nil && !reasoningReported && reasoning.Len() > 0 {
+			onReasoning(reasoning.String())
+			reasoningReported = true
+		}
+	}
+
 	var content strings.Builder
 	var accumulatedToolCalls map[string]*schema.ToolCall // Track tool calls by ID to handle incremental updates
 	var streamComplete bool
 	var finalResponseMeta *schema.ResponseMeta // Accumulate response metadata from all chunks
+	var usageDetails usage.Details             // Cache write and reasoning counts, carried outside ResponseMeta
 
 	accumulatedToolCalls = make(map[string]*schema.ToolCall)
 
@@ -42,6 +63,14 @@ func StreamWithCallback(ctx context.Context, reader *schema.StreamReader[*schema
 			return nil, err
 		}
 
+		reasoning.WriteString(msg.ReasoningContent)
+		if onReasoning != nil && onThinking != nil && msg.ReasoningContent != "" && !reasoningReported {
+			onThinking(msg.ReasoningContent)
+		}
+		if msg.Content != "" || len(msg.ToolCalls) > 0 {
+			reportReasoning()
+		}
+
 		// Call callback for each chunk if provided (for real-time display)
 		if callback != nil && msg.Content != "" {
 			callback(msg.Content)
@@ -53,30 +82,10 @@ func StreamWithCallback(ctx context.Context, reader *schema.StreamReader[*schema
 		// Accumulate response metadata - merge from multiple chunks for accuracy
 		if msg.ResponseMeta != nil {
 			if finalResponseMeta == nil {
-				// First metadata we've seen - use as base
 				finalResponseMeta = &schema.ResponseMeta{}
-				if msg.ResponseMeta.Usage != nil {
-					finalResponseMeta.Usage = &schema.TokenUsage{}
-				}
-			}
-
-			// Merge metadata intelligently to handle Anthropic's streaming behavior
-			if msg.ResponseMeta.Usage != nil && finalResponseMeta.Usage != nil {
-				usage := msg.ResponseMeta.Usage
-
-				// Take PromptTokens from first chunk that has them (usually non-zero)
-				if finalResponseMeta.Usage.PromptTokens == 0 && usage.PromptTokens > 0 {
-					finalResponseMeta.Usage.PromptTokens = usage.PromptTokens
-				}
-
-				// Always take the latest CompletionTokens (accumulates over chunks)
-				if usage.CompletionTokens > 0 {
-					finalResponseMeta.Usage.CompletionTokens = usage.CompletionTokens
-				}
-
-				// Calculate TotalTokens from the components
-				finalResponseMeta.Usage.TotalTokens = finalResponseMeta.Usage.PromptTokens + finalResponseMeta.Usage.CompletionTokens
 			}
+			finalResponseMeta.Usage = mergeStreamUsage(finalResponseMeta.Usage, msg.ResponseMeta.Usage)
+			usageDetails = usage.Max(usageDetails, usage.Get(msg))
 
 			// Preserve other metadata fields from the latest chunk
 			if msg.ResponseMeta.FinishReason != "" {
@@ -137,10 +146,37 @@ func StreamWithCallback(ctx context.Context, reader *schema.StreamReader[*schema
 	}
 
 	// Return complete message with all content, final tool calls, and preserved metadata
-	return &schema.Message{
+	response := &schema.Message{
 		Role:         schema.Assistant,
 		Content:      content.String(),
 		ToolCalls:    finalToolCalls,
 		ResponseMeta: finalResponseMeta, // Preserve usage and other metadata from streaming
-	}, nil
+	}
+	if onReasoning == nil {
+		response.ReasoningContent = reasoning.String()
+	}
+	reportReasoning()
+	usage.Set(response, usageDetails)
+	return response, nil
+}
+
+// mergeStreamUsage folds the usage reported by one stream chunk into the total
+// so far. Providers report usage differently while streaming: OpenAI sends it
+// once in a final chunk (after the chunk carrying the finish reason),
+// Anthropic sends input tokens when the message starts and a running output
+// count in message_delta events, and Gemini repeats running totals on every
+// chunk. All of these are cumulative, so each count keeps its largest value.
+func mergeStreamUsage(total, chunk *schema.TokenUsage) *schema.TokenUsage {
+	if chunk == nil {
+		return total
+	}
+	if total == nil {
+		total = &schema.TokenUsage{}
+	}
+
+	total.PromptTokens = max(total.PromptTokens, chunk.PromptTokens)
+	total.CompletionTokens = max(total.CompletionTokens, chunk.CompletionTokens)
+	total.PromptTokenDetails.CachedTokens = max(total.PromptTokenDetails.CachedTokens, chunk.PromptTokenDetails.CachedTokens)
+	total.TotalTokens = max(total.TotalTokens, chunk.TotalTokens, total.PromptTokens+total.CompletionTokens)
+	return total
 }
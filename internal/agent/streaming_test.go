@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestStreamWithCallbackUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []*schema.Message
+		wantPrompt int
+		wantOutput int
+	}{
+		{
+			// Final usage-only chunk after the chunk with the finish reason
+			name: "openai",
+			chunks: []*schema.Message{
+				{Role: schema.Assistant, Content: "Hel", ResponseMeta: &schema.ResponseMeta{}},
+				{Role: schema.Assistant, Content: "lo", ResponseMeta: &schema.ResponseMeta{FinishReason: "stop"}},
+				{ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14}}},
+			},
+			wantPrompt: 12,
+			wantOutput: 2,
+		},
+		{
+			// Input tokens at message_start, running output count in message_delta
+			name: "anthropic",
+			chunks: []*schema.Message{
+				{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 30, CompletionTokens: 1}}},
+				{Role: schema.Assistant, Content: "Hello"},
+				{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{FinishReason: "end_turn", Usage: &schema.TokenUsage{CompletionTokens: 9}}},
+			},
+			wantPrompt: 30,
+			wantOutput: 9,
+		},
+		{
+			// Running totals repeated on every chunk
+			name: "gemini",
+			chunks: []*schema.Message{
+				{Role: schema.Assistant, Content: "Hel", ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 8, CompletionTokens: 1}}},
+				{Role: schema.Assistant, Content: "lo", ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 8, CompletionTokens: 3}}},
+			},
+			wantPrompt: 8,
+			wantOutput: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := StreamWithCallback(context.Background(), schema.StreamReaderFromArray(tt.chunks), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+				t.Fatal("usage was dropped")
+			}
+			usage := msg.ResponseMeta.Usage
+			if usage.PromptTokens != tt.wantPrompt || usage.CompletionTokens != tt.wantOutput {
+				t.Errorf("usage = %d in / %d out, want %d / %d", usage.PromptTokens, usage.CompletionTokens, tt.wantPrompt, tt.wantOutput)
+			}
+			if usage.TotalTokens != tt.wantPrompt+tt.wantOutput {
+				t.Errorf("total = %d, want %d", usage.TotalTokens, tt.wantPrompt+tt.wantOutput)
+			}
+		})
+	}
+}
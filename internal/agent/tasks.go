@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// maxFollowUpTasks bounds the tasks taken from a single response
+const maxFollowUpTasks = 10
+
+// FollowUpTask is an action item left open by a response
+type FollowUpTask struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority"` // high, medium or low
+}
+
+// ExtractFollowUpTasks asks the extract task model, or the main model when
+// none is configured, for the action items a response leaves for later. It
+// returns no tasks when the response has none.
+func (a *Agent) ExtractFollowUpTasks(ctx context.Context, response string) ([]FollowUpTask, error) {
+	if strings.TrimSpace(response) == "" {
+		return nil, nil
+	}
+
+	message, err := a.TaskModel(config.TaskExtract).Generate(ctx, []*schema.Message{
+		schema.SystemMessage(followUpTasksPrompt),
+		schema.UserMessage(response),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract follow-up tasks: %v", err)
+	}
+	return parseFollowUpTasks(message.Content)
+}
+
+// parseFollowUpTasks reads the JSON array of tasks from the model's reply,
+// tolerating a code fence or text around it
+func parseFollowUpTasks(reply string) ([]FollowUpTask, error) {
+	start := strings.Index(reply, "[")
+	end := strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("follow-up task reply is not a JSON array: %q", reply)
+	}
+
+	var parsed []FollowUpTask
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse follow-up tasks: %v", err)
+	}
+
+	var tasks []FollowUpTask
+	for _, task := range parsed {
+		task.Content = strings.Join(strings.Fields(task.Content), " ")
+		if task.Content == "" {
+			continue
+		}
+		switch task.Priority = strings.ToLower(task.Priority); task.Priority {
+		case "high", "medium", "low":
+		default:
+			task.Priority = "medium"
+		}
+		tasks = append(tasks, task)
+		if len(tasks) == maxFollowUpTasks {
+			break
+		}
+	}
+	return tasks, nil
+}
+
+const followUpTasksPrompt = `You extract follow-up tasks from an assistant's response.
+
+List the concrete action items the response leaves open for later: next steps it recommends, work it says is still to be done, or things the user has to do or decide. Do not list work the response reports as already done, general advice, or questions answered in the response.
+
+Reply with only a JSON array, with no other text. Each item is an object with:
+  - "content": the task as a short imperative sentence that makes sense on its own, e.g. "Add an index on orders.customer_id"
+  - "priority": "high", "medium" or "low"
+
+Reply with [] when there are no action items.`
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowUpTasks(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    []FollowUpTask
+		wantErr string
+	}{
+		{
+			name:  "plain array",
+			reply: `[{"content": "Add an index on orders.customer_id", "priority": "high"}]`,
+			want:  []FollowUpTask{{Content: "Add an index on orders.customer_id", Priority: "high"}},
+		},
+		{
+			name:  "code fence and normalization",
+			reply: "```json\n[{\"content\": \"  Rotate the\\n API key \", \"priority\": \"URGENT\"}, {\"content\": \"\", \"priority\": \"low\"}, {\"content\": \"Update docs\", \"priority\": \"Low\"}]\n```",
+			want: []FollowUpTask{
+				{Content: "Rotate the API key", Priority: "medium"},
+				{Content: "Update docs", Priority: "low"},
+			},
+		},
+		{
+			name:  "no tasks",
+			reply: "[]",
+		},
+		{
+			name:    "not json",
+			reply:   "There are no action items.",
+			wantErr: "not a JSON array",
+		},
+		{
+			name:    "malformed",
+			reply:   `[{"content": }]`,
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFollowUpTasks(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFollowUpTasksLimit(t *testing.T) {
+	reply := "[" + strings.Repeat(`{"content": "Task", "priority": "low"},`, maxFollowUpTasks+5) + `{"content": "Last", "priority": "low"}]`
+	tasks, err := parseFollowUpTasks(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != maxFollowUpTasks {
+		t.Errorf("expected %d tasks, got %d", maxFollowUpTasks, len(tasks))
+	}
+}
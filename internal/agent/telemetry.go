@@ -0,0 +1,25 @@
+package agent
+
+import "time"
+
+// ToolTiming is how long one tool call ran
+type ToolTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// RunTelemetry is where a run spent its time: waiting for the model, and in
+// each tool call it made. Pauses and approvals count as neither.
+type RunTelemetry struct {
+	ModelTime time.Duration
+	ToolCalls []ToolTiming
+}
+
+// ToolTime returns the time spent in tool calls
+func (t RunTelemetry) ToolTime() time.Duration {
+	var total time.Duration
+	for _, call := range t.ToolCalls {
+		total += call.Duration
+	}
+	return total
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Tool choices besides a tool's name, as given to --tool-choice
+const (
+	ToolChoiceAuto     = "auto"     // the model decides; the default
+	ToolChoiceNone     = "none"     // the model answers without calling tools
+	ToolChoiceRequired = "required" // the model must call a tool
+)
+
+// withToolChoice returns the tools and tool choice for the model call of the
+// given step. "none" holds for the whole run. "required" and a tool's name
+// only hold for the run's first call, since a model that must call a tool on
+// every step could never give its answer; later calls are left to the model.
+func (a *Agent) withToolChoice(step int, toolInfos []*schema.ToolInfo) ([]*schema.ToolInfo, *schema.ToolChoice, error) {
+	// A model without tool calls, native or emulated, is offered none
+	if a.withoutTools {
+		return []*schema.ToolInfo{}, nil, nil
+	}
+
+	var choice schema.ToolChoice
+	switch a.toolChoice {
+	case "", ToolChoiceAuto:
+		return toolInfos, nil, nil
+	case ToolChoiceNone:
+		// Ollama has no tool choice, so it is offered no tools instead
+		if a.providerType == "ollama" {
+			return []*schema.ToolInfo{}, nil, nil
+		}
+		choice = schema.ToolChoiceForbidden
+		return toolInfos, &choice, nil
+	}
+
+	if step > 0 || len(toolInfos) == 0 {
+		return toolInfos, nil, nil
+	}
+	choice = schema.ToolChoiceForced
+	if a.toolChoice == ToolChoiceRequired {
+		return toolInfos, &choice, nil
+	}
+
+	// Forcing a call with a single tool offered makes the providers name it
+	for _, info := range toolInfos {
+		if info.Name == a.toolChoice {
+			return []*schema.ToolInfo{info}, &choice, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("tool choice %q is not an available tool; use auto, none, required or a tool name in server__tool form", a.toolChoice)
+}
+
+// callOptions returns the model options offering toolInfos with choice
+func callOptions(toolInfos []*schema.ToolInfo, choice *schema.ToolChoice) []model.Option {
+	opts := []model.Option{model.WithTools(toolInfos)}
+	if choice != nil {
+		opts = append(opts, model.WithToolChoice(*choice))
+	}
+	return opts
+}
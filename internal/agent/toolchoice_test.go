@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestWithToolChoice(t *testing.T) {
+	toolInfos := []*schema.ToolInfo{{Name: "fs__read_file"}, {Name: "git__git_status"}}
+
+	tests := []struct {
+		name      string
+		choice    string
+		provider  string
+		step      int
+		wantTools []string
+		want      *schema.ToolChoice
+		wantErr   bool
+	}{
+		{name: "auto", choice: "auto", wantTools: []string{"fs__read_file", "git__git_status"}},
+		{name: "unset", wantTools: []string{"fs__read_file", "git__git_status"}},
+		{name: "none", choice: "none", step: 3, wantTools: []string{"fs__read_file", "git__git_status"}, want: choiceOf(schema.ToolChoiceForbidden)},
+		{name: "none on ollama", choice: "none", provider: "ollama", wantTools: []string{}},
+		{name: "required", choice: "required", wantTools: []string{"fs__read_file", "git__git_status"}, want: choiceOf(schema.ToolChoiceForced)},
+		{name: "required after the first call", choice: "required", step: 1, wantTools: []string{"fs__read_file", "git__git_status"}},
+		{name: "named tool", choice: "git__git_status", wantTools: []string{"git__git_status"}, want: choiceOf(schema.ToolChoiceForced)},
+		{name: "named tool after the first call", choice: "git__git_status", step: 1, wantTools: []string{"fs__read_file", "git__git_status"}},
+		{name: "unknown tool", choice: "git__git_push", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{toolChoice: tt.choice, providerType: tt.provider}
+			gotTools, got, err := a.withToolChoice(tt.step, toolInfos)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(gotTools) != len(tt.wantTools) {
+				t.Fatalf("expected tools %v, got %d tools", tt.wantTools, len(gotTools))
+			}
+			for i, info := range gotTools {
+				if info.Name != tt.wantTools[i] {
+					t.Errorf("expected tools %v, got %s at %d", tt.wantTools, info.Name, i)
+				}
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("expected tool choice %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func choiceOf(choice schema.ToolChoice) *schema.ToolChoice {
+	return &choice
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/turn"
+)
+
+func TestRunMessagesMarkedWithTurn(t *testing.T) {
+	a := &Agent{toolManager: tools.NewMCPToolManager(), model: &scriptedModel{}, systemPrompt: "be brief"}
+	earlier := schema.UserMessage("earlier")
+	ctx := turn.WithID(context.Background(), "turn_1")
+
+	result, err := a.GenerateWithLoop(ctx, []*schema.Message{earlier}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn.Of(earlier) != "" {
+		t.Error("expected the run's input to be left unmarked")
+	}
+	added := 0
+	for _, msg := range result.ConversationMessages {
+		switch {
+		case msg == earlier:
+		case msg.Role == schema.System:
+			if turn.Of(msg) != "" {
+				t.Error("expected the system prompt to be left unmarked")
+			}
+		default:
+			added++
+			if got := turn.Of(msg); got != "turn_1" {
+				t.Errorf("expected %s message to be marked turn_1, got %q", msg.Role, got)
+			}
+		}
+	}
+	if added != 3 {
+		t.Errorf("expected the tool call, its result and the answer to be added, got %d messages", added)
+	}
+
+	// Runs outside a turn get one of their own
+	result, err = a.GenerateWithLoop(context.Background(), []*schema.Message{schema.UserMessage("again")}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := turn.Of(result.FinalResponse); got == "" || got == "turn_1" {
+		t.Errorf("expected a new turn for a run without one, got %q", got)
+	}
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Azure OpenAI authentication methods, as given to --azure-auth
+const (
+	AzureAuthAuto            = "auto"             // the API key if there is one, else the Entra ID chain
+	AzureAuthKey             = "key"              // API key only
+	AzureAuthClientSecret    = "client-secret"    // service principal from AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET
+	AzureAuthManagedIdentity = "managed-identity" // the managed identity of the Azure host
+	AzureAuthCLI             = "cli"              // the account logged in with `az login`
+)
+
+// AzureAuthMethods lists the valid --azure-auth values
+var AzureAuthMethods = []string{AzureAuthAuto, AzureAuthKey, AzureAuthClientSecret, AzureAuthManagedIdentity, AzureAuthCLI}
+
+// azureResource is what Azure OpenAI tokens are for
+const azureResource = "https://cognitiveservices.azure.com"
+
+// azureRefreshMargin is how long before it expires a token is replaced
+const azureRefreshMargin = 5 * time.Minute
+
+// Defaults for the token endpoints, overridden in tests
+var (
+	azureAuthorityHost = "https://login.microsoftonline.com"
+	azureIMDSEndpoint  = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureCLICommand    = func(ctx context.Context, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, "az", args...).Output()
+	}
+)
+
+// azureToken is an Entra ID access token
+type azureToken struct {
+	value     string
+	expiresOn time.Time
+}
+
+// azureSource gets a new token
+type azureSource struct {
+	name  string
+	fetch func(ctx context.Context, client *http.Client) (azureToken, error)
+}
+
+// AzureCredential gets Microsoft Entra ID tokens for Azure OpenAI and keeps
+// the current one until shortly before it expires
+type AzureCredential struct {
+	client  *http.Client
+	sources []azureSource // tried in order until one works; then only it is used
+
+	mu    sync.Mutex
+	token azureToken
+}
+
+// NewAzureCredential returns the Entra ID credential for method: one of the
+// client-secret, managed-identity and cli methods, or for auto the first of
+// them that works, in that order, with the client secret only tried when its
+// environment variables are set
+func NewAzureCredential(method string) (*AzureCredential, error) {
+	secret := azureSource{name: "client secret", fetch: clientSecretToken}
+	identity := azureSource{name: "managed identity", fetch: managedIdentityToken}
+	cli := azureSource{name: "az CLI", fetch: cliToken}
+
+	c := &AzureCredential{client: &http.Client{Timeout: 30 * time.Second}}
+	switch method {
+	case AzureAuthClientSecret:
+		c.sources = []azureSource{secret}
+	case AzureAuthManagedIdentity:
+		c.sources = []azureSource{identity}
+	case AzureAuthCLI:
+		c.sources = []azureSource{cli}
+	case "", AzureAuthAuto:
+		if os.Getenv("AZURE_CLIENT_SECRET") != "" {
+			c.sources = append(c.sources, secret)
+		}
+		c.sources = append(c.sources, identity, cli)
+	default:
+		return nil, fmt.Errorf("invalid Azure authentication %q: use %s", method, strings.Join(AzureAuthMethods, ", "))
+	}
+	return c, nil
+}
+
+// Token returns a valid access token, getting a new one when the current one
+// is about to expire
+func (c *AzureCredential) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token.value != "" && time.Until(c.token.expiresOn) > azureRefreshMargin {
+		return c.token.value, nil
+	}
+
+	var errs []string
+	for i, source := range c.sources {
+		token, err := source.fetch(ctx, c.client)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", source.name, err))
+			continue
+		}
+		c.sources = c.sources[i : i+1]
+		c.token = token
+		return token.value, nil
+	}
+	return "", fmt.Errorf("failed to get a Microsoft Entra ID token (%s)", strings.Join(errs, "; "))
+}
+
+// clientSecretToken gets a token for the service principal in the
+// environment with the OAuth client credentials flow
+func clientSecretToken(ctx context.Context, client *http.Client) (azureToken, error) {
+	tenant, clientID, secret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if tenant == "" || clientID == "" || secret == "" {
+		return azureToken{}, errors.New("set AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET")
+	}
+	authority := azureAuthorityHost
+	if host := os.Getenv("AZURE_AUTHORITY_HOST"); host != "" {
+		authority = host
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {secret},
+		"scope":         {azureResource + "/.default"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(authority, "/")+"/"+url.PathEscape(tenant)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return azureToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(client, req)
+}
+
+// managedIdentityToken gets a token for the managed identity of the host:
+// from the identity endpoint of App Service, Functions and Container Apps,
+// or from the instance metadata service of VMs and AKS. AZURE_CLIENT_ID picks
+// a user-assigned identity.
+func managedIdentityToken(ctx context.Context, client *http.Client) (azureToken, error) {
+	query := url.Values{"resource": {azureResource}}
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	endpoint, header := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER")
+	if endpoint != "" && header != "" {
+		query.Set("api-version", "2019-08-01")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return azureToken{}, err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", header)
+		return doTokenRequest(client, req)
+	}
+
+	// Off Azure the metadata service doesn't answer; don't wait long for it
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	query.Set("api-version", "2018-02-01")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return azureToken{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	return doTokenRequest(client, req)
+}
+
+// cliToken gets a token for the account logged in with the Azure CLI
+func cliToken(ctx context.Context, _ *http.Client) (azureToken, error) {
+	args := []string{"account", "get-access-token", "--resource", azureResource, "--output", "json"}
+	if tenant := os.Getenv("AZURE_TENANT_ID"); tenant != "" {
+		args = append(args, "--tenant", tenant)
+	}
+	output, err := azureCLICommand(ctx, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return azureToken{}, fmt.Errorf("%s (run `az login`)", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return azureToken{}, err
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresOn   string `json:"expiresOn"`  // local time, older CLIs
+		ExpiresAt   int64  `json:"expires_on"` // Unix time, newer CLIs
+	}
+	// The output is not echoed in errors, since it may hold the token
+	if err := json.Unmarshal(output, &token); err != nil {
+		return azureToken{}, fmt.Errorf("unexpected az output: %v", err)
+	}
+	if token.AccessToken == "" {
+		return azureToken{}, fmt.Errorf("unexpected az output: no accessToken")
+	}
+	expiresOn := time.Unix(token.ExpiresAt, 0)
+	if token.ExpiresAt == 0 {
+		if expiresOn, err = time.ParseInLocation("2006-01-02 15:04:05.999999", token.ExpiresOn, time.Local); err != nil {
+			return azureToken{}, fmt.Errorf("unexpected az token expiry %q", token.ExpiresOn)
+		}
+	}
+	return azureToken{value: token.AccessToken, expiresOn: expiresOn}, nil
+}
+
+// doTokenRequest sends a token request and reads the token from its response.
+// Endpoints give the expiry as expires_in or expires_on, in seconds, as
+// numbers or strings.
+func doTokenRequest(client *http.Client, req *http.Request) (azureToken, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return azureToken{}, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return azureToken{}, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+		ExpiresOn   json.Number `json:"expires_on"`
+	}
+	// The body is not echoed in errors, since it may hold the token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return azureToken{}, fmt.Errorf("unexpected token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return azureToken{}, fmt.Errorf("unexpected token response: no access_token")
+	}
+	expiresOn := time.Now().Add(time.Hour)
+	if seconds, err := strconv.ParseInt(token.ExpiresOn.String(), 10, 64); err == nil {
+		expiresOn = time.Unix(seconds, 0)
+	} else if seconds, err := strconv.ParseInt(token.ExpiresIn.String(), 10, 64); err == nil {
+		expiresOn = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return azureToken{value: token.AccessToken, expiresOn: expiresOn}, nil
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAzureCredential(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/tenant-1/oauth2/v2.0/token":
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "s3cret" || r.Form.Get("scope") != azureResource+"/.default" {
+				t.Errorf("unexpected token request %v", r.Form)
+			}
+			fmt.Fprintf(w, `{"access_token": "secret-token-%d", "expires_in": 3599}`, requests)
+		case r.URL.Path == "/tenant-2/oauth2/v2.0/token":
+			fmt.Fprint(w, `{"access_token": "leaked-token", "expires_in": 3599`)
+		case r.URL.Path == "/imds":
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != azureResource {
+				t.Errorf("unexpected metadata request %s %v", r.URL, r.Header)
+			}
+			fmt.Fprintf(w, `{"access_token": "identity-token", "expires_on": "%d"}`, time.Now().Add(time.Hour).Unix())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(host, imds string) { azureAuthorityHost, azureIMDSEndpoint = host, imds }(azureAuthorityHost, azureIMDSEndpoint)
+	azureAuthorityHost, azureIMDSEndpoint = server.URL, server.URL+"/imds"
+	t.Setenv("IDENTITY_ENDPOINT", "")
+
+	// The client secret is used when its environment is set, and its token
+	// kept until shortly before it expires
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+	t.Setenv("AZURE_CLIENT_ID", "client-1")
+	t.Setenv("AZURE_CLIENT_SECRET", "s3cret")
+	credential, err := NewAzureCredential(AzureAuthAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if token, err := credential.Token(context.Background()); err != nil || token != "secret-token-1" {
+			t.Fatalf("expected the client secret token, got %q, %v", token, err)
+		}
+	}
+	credential.token.expiresOn = time.Now().Add(time.Minute)
+	if token, _ := credential.Token(context.Background()); token != "secret-token-2" {
+		t.Errorf("expected a refreshed token, got %q", token)
+	}
+
+	// A response that doesn't parse is not echoed, since it may hold a token
+	t.Setenv("AZURE_TENANT_ID", "tenant-2")
+	credential, _ = NewAzureCredential(AzureAuthClientSecret)
+	if _, err := credential.Token(context.Background()); err == nil || strings.Contains(err.Error(), "leaked-token") {
+		t.Errorf("expected an error without the token, got %v", err)
+	}
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+
+	// Without it, the managed identity comes first
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	credential, _ = NewAzureCredential(AzureAuthAuto)
+	if token, err := credential.Token(context.Background()); err != nil || token != "identity-token" {
+		t.Errorf("expected the managed identity token, got %q, %v", token, err)
+	}
+
+	if _, err := NewAzureCredential("password"); err == nil {
+		t.Error("expected an unknown method to fail")
+	}
+}
+
+func TestAzureCLICredential(t *testing.T) {
+	defer func(command func(context.Context, ...string) ([]byte, error)) { azureCLICommand = command }(azureCLICommand)
+	var args []string
+	azureCLICommand = func(ctx context.Context, a ...string) ([]byte, error) {
+		args = a
+		return []byte(`{"accessToken": "cli-token", "expiresOn": "2099-01-01 12:00:00.000000"}`), nil
+	}
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+
+	credential, _ := NewAzureCredential(AzureAuthCLI)
+	if token, err := credential.Token(context.Background()); err != nil || token != "cli-token" {
+		t.Fatalf("expected the az token, got %q, %v", token, err)
+	}
+	if got := strings.Join(args, " "); !strings.Contains(got, "--resource "+azureResource) || !strings.HasSuffix(got, "--tenant tenant-1") {
+		t.Errorf("unexpected az arguments %q", got)
+	}
+	if credential.token.expiresOn.Year() != 2099 {
+		t.Errorf("expected the expiry az reported, got %v", credential.token.expiresOn)
+	}
+
+	azureCLICommand = func(ctx context.Context, a ...string) ([]byte, error) {
+		return []byte(`{"accessToken": "leaked-token", "expiresOn": 2099}`), nil
+	}
+	credential, _ = NewAzureCredential(AzureAuthCLI)
+	if _, err := credential.Token(context.Background()); err == nil || strings.Contains(err.Error(), "leaked-token") {
+		t.Errorf("expected an error without the token, got %v", err)
+	}
+
+	azureCLICommand = func(ctx context.Context, a ...string) ([]byte, error) {
+		return nil, errors.New("az: not found")
+	}
+	credential, _ = NewAzureCredential(AzureAuthCLI)
+	if _, err := credential.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "az CLI: az: not found") {
+		t.Errorf("expected the az failure explained, got %v", err)
+	}
+}
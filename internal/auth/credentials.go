@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/osi4iot/mcphost/internal/config"
 )
 
 // CredentialStore holds all stored credentials
@@ -59,6 +61,11 @@ func NewCredentialManager() (*CredentialManager, error) {
 
 // getCredentialsPath returns the path to the credentials file
 func getCredentialsPath() (string, error) {
+	// An explicit --state-dir keeps credentials with the rest of that setup's state
+	if dir, ok := config.StateDirOverride(); ok {
+		return filepath.Join(dir, "credentials.json"), nil
+	}
+
 	// Try XDG_CONFIG_HOME first
 	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
 		return filepath.Join(xdgConfig, ".mcphost", "credentials.json"), nil
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/osi4iot/mcphost/internal/config"
 )
 
 func TestCredentialManager(t *testing.T) {
@@ -250,3 +252,19 @@ func TestCredentialStorePersistence(t *testing.T) {
 		t.Errorf("Expected file permissions 0600, got %v", info.Mode().Perm())
 	}
 }
+
+func TestCredentialsPathFollowsStateDir(t *testing.T) {
+	stateDir := t.TempDir()
+	if err := config.SetStateDir(stateDir); err != nil {
+		t.Fatalf("SetStateDir failed: %v", err)
+	}
+	defer config.SetStateDir("")
+
+	path, err := getCredentialsPath()
+	if err != nil {
+		t.Fatalf("getCredentialsPath failed: %v", err)
+	}
+	if want := filepath.Join(stateDir, "credentials.json"); path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+}
@@ -3,12 +3,14 @@ package builtin
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/osi4iot/mcphost/internal/workspace"
 )
 
 const (
@@ -102,6 +104,13 @@ func executeBash(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToo
 	// Execute the command
 	cmd := exec.CommandContext(cmdCtx, "bash", "-c", command)
 
+	// Run in the session's workspace, if it has one
+	ws := workspace.From(ctx)
+	cmd.Dir = ws.Dir
+	if len(ws.Env) > 0 {
+		cmd.Env = append(os.Environ(), ws.Environ()...)
+	}
+
 	// Capture both stdout and stderr
 	output, err := cmd.CombinedOutput()
 
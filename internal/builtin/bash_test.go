@@ -2,9 +2,11 @@ package builtin
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/osi4iot/mcphost/internal/workspace"
 )
 
 func TestNewBashServer(t *testing.T) {
@@ -151,3 +153,33 @@ func TestToolNameChange(t *testing.T) {
 		t.Fatal("Expected result to have content")
 	}
 }
+
+func TestExecuteBashInWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	ws, err := workspace.New(dir, map[string]string{"PROJECT": "checkout-a"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "run_shell_cmd",
+			Arguments: map[string]any{
+				"command":     "pwd; echo $PROJECT",
+				"description": "Print directory and variable",
+			},
+		},
+	}
+	result, err := executeBash(workspace.With(context.Background(), ws), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	textContent, ok := mcp.AsTextContent(result.Content[0])
+	if !ok {
+		t.Fatal("Expected text content")
+	}
+	if !strings.Contains(textContent.Text, ws.Dir) || !strings.Contains(textContent.Text, "checkout-a") {
+		t.Errorf("Expected the command to run in %s with PROJECT set, got %q", ws.Dir, textContent.Text)
+	}
+}
@@ -0,0 +1,258 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+// commandPlaceholder matches {param} in a command tool's command
+var commandPlaceholder = regexp.MustCompile(`\{([a-zA-Z0-9_-]+)\}`)
+
+// commandTool is a configured tool that runs a shell command
+type commandTool struct {
+	name    string
+	config  config.CommandTool
+	timeout time.Duration
+}
+
+// NewCommandServer creates a server with a tool per entry of the tools:
+// config section
+func NewCommandServer(tools map[string]config.CommandTool) (*server.MCPServer, error) {
+	s := server.NewMCPServer("commands-server", "1.0.0", server.WithToolCapabilities(true))
+
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := &commandTool{name: name, config: tools[name], timeout: defaultTimeout}
+		if strings.TrimSpace(t.config.Command) == "" {
+			return nil, fmt.Errorf("tool %s: command is required", name)
+		}
+		if t.config.Timeout != "" {
+			d, err := time.ParseDuration(t.config.Timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("tool %s: invalid timeout %q", name, t.config.Timeout)
+			}
+			t.timeout = d
+		}
+		s.AddTool(t.tool(), t.execute)
+	}
+	return s, nil
+}
+
+// registerCommandServer registers the server behind the tools: config section
+func (r *Registry) registerCommandServer() {
+	r.servers["commands"] = func(options map[string]any, _ model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		// Options hold config.CommandTool values when added from the tools:
+		// section, and decoded maps when configured by hand
+		var tools map[string]config.CommandTool
+		if raw, ok := options["tools"]; ok {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tools option: %v", err)
+			}
+			if err := json.Unmarshal(data, &tools); err != nil {
+				return nil, fmt.Errorf("invalid tools option: %v", err)
+			}
+		}
+
+		server, err := NewCommandServer(tools)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create commands server: %v", err)
+		}
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
+
+// tool describes the command tool and its parameters
+func (t *commandTool) tool() mcp.Tool {
+	properties := make(map[string]any)
+	var required []string
+	for name, param := range t.config.Params {
+		prop := map[string]any{"type": paramType(param)}
+		if prop["type"] == "array" {
+			prop["items"] = map[string]any{"type": "string"}
+		}
+		if param.Description != "" {
+			prop["description"] = param.Description
+		}
+		if param.Default != nil {
+			prop["default"] = param.Default
+		}
+		properties[name] = prop
+		if param.Required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	inputSchema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+	raw, _ := json.Marshal(inputSchema)
+
+	description := t.config.Description
+	if description == "" {
+		description = fmt.Sprintf("Runs: %s", t.config.Command)
+	}
+	return mcp.NewToolWithRawSchema(t.name, description, raw)
+}
+
+// paramType returns the JSON type of a parameter, string by default
+func paramType(param config.CommandToolParam) string {
+	if param.Type == "" {
+		return "string"
+	}
+	return param.Type
+}
+
+// execute runs the command with the call's arguments substituted
+func (t *commandTool) execute(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	command, err := t.render(request.GetArguments())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
+
+	// Run in the session's workspace, if it has one; a relative dir is taken
+	// from there
+	ws := workspace.From(ctx)
+	cmd.Dir = t.config.Dir
+	switch {
+	case cmd.Dir == "":
+		cmd.Dir = ws.Dir
+	case ws.Dir != "" && !filepath.IsAbs(cmd.Dir):
+		cmd.Dir = filepath.Join(ws.Dir, cmd.Dir)
+	}
+	if len(ws.Env) > 0 {
+		cmd.Env = append(os.Environ(), ws.Environ()...)
+	}
+	cmd.WaitDelay = time.Second // don't wait on children still holding the output open
+	output, err := cmd.CombinedOutput()
+
+	text := string(output)
+	if len(text) > maxOutputLength {
+		text = text[:maxOutputLength] + "\n... (output truncated)"
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case cmdCtx.Err() == context.DeadlineExceeded:
+			err = fmt.Errorf("timed out after %s", t.timeout)
+		case errors.As(err, &exitErr):
+			err = fmt.Errorf("exit status %d", exitErr.ExitCode())
+		}
+		return mcp.NewToolResultError(strings.TrimSpace(fmt.Sprintf("Command failed: %v\n\n%s", err, text))), nil
+	}
+	if text == "" {
+		text = "(no output)"
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// render replaces each {param} placeholder with the shell-quoted argument, or
+// the parameter's default. Placeholders of missing optional parameters are
+// removed, and braces that name no parameter are left alone.
+func (t *commandTool) render(args map[string]any) (string, error) {
+	values := make(map[string]string, len(t.config.Params))
+	for name, param := range t.config.Params {
+		value, ok := args[name]
+		if !ok || value == nil {
+			value = param.Default
+		}
+		if value == nil {
+			if param.Required {
+				return "", fmt.Errorf("missing required parameter %s", name)
+			}
+			values[name] = ""
+			continue
+		}
+		quoted, err := quoteParam(value, paramType(param))
+		if err != nil {
+			return "", fmt.Errorf("parameter %s: %v", name, err)
+		}
+		values[name] = quoted
+	}
+
+	return commandPlaceholder.ReplaceAllStringFunc(t.config.Command, func(placeholder string) string {
+		if value, ok := values[placeholder[1:len(placeholder)-1]]; ok {
+			return value
+		}
+		return placeholder
+	}), nil
+}
+
+// quoteParam checks value against the parameter type and renders it for sh
+func quoteParam(value any, paramType string) (string, error) {
+	switch paramType {
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return "", fmt.Errorf("must be an array")
+		}
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = shellQuote(formatScalar(item))
+		}
+		return strings.Join(quoted, " "), nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "", fmt.Errorf("must be a boolean")
+		}
+	case "number", "integer":
+		n, ok := value.(float64)
+		if !ok {
+			if i, isInt := value.(int); isInt {
+				n, ok = float64(i), true
+			}
+		}
+		if !ok || (paramType == "integer" && n != float64(int64(n))) {
+			return "", fmt.Errorf("must be a %s", paramType)
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return "", fmt.Errorf("must be a string")
+		}
+	}
+	return shellQuote(formatScalar(value)), nil
+}
+
+// formatScalar renders a decoded JSON scalar
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
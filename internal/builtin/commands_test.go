@@ -0,0 +1,159 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+func TestCommandToolRender(t *testing.T) {
+	tool := &commandTool{name: "grep", config: config.CommandTool{
+		Command: "grep {flags} -n {pattern} {files} | awk '{print $1}'",
+		Params: map[string]config.CommandToolParam{
+			"pattern": {Required: true},
+			"files":   {Type: "array"},
+			"flags":   {Default: "-i"},
+			"context": {Type: "integer"},
+		},
+	}}
+
+	command, err := tool.render(map[string]any{
+		"pattern": "it's; rm -rf /",
+		"files":   []any{"a.go", "b c.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `grep '-i' -n 'it'\''s; rm -rf /' 'a.go' 'b c.go' | awk '{print $1}'`
+	if command != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, command)
+	}
+
+	if _, err := tool.render(map[string]any{}); err == nil || !strings.Contains(err.Error(), "missing required parameter pattern") {
+		t.Errorf("expected missing pattern to fail, got %v", err)
+	}
+	if _, err := tool.render(map[string]any{"pattern": "x", "context": 1.5}); err == nil || !strings.Contains(err.Error(), "must be a integer") {
+		t.Errorf("expected fractional integer to fail, got %v", err)
+	}
+	if _, err := tool.render(map[string]any{"pattern": 3.0}); err == nil || !strings.Contains(err.Error(), "must be a string") {
+		t.Errorf("expected number for a string parameter to fail, got %v", err)
+	}
+}
+
+func TestCommandServer(t *testing.T) {
+	registry := NewRegistry()
+	wrapper, err := registry.CreateServer("commands", map[string]any{
+		"tools": map[string]config.CommandTool{
+			"greet": {
+				Command:     "echo hello {name}",
+				Description: "Say hello",
+				Params:      map[string]config.CommandToolParam{"name": {Required: true, Description: "Who to greet"}},
+			},
+			"fail": {Command: "echo broken >&2; exit 3"},
+			"slow": {Command: "sleep 5", Timeout: "100ms"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewInProcessClient(wrapper.GetServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := make(map[string]mcp.Tool)
+	for _, tool := range list.Tools {
+		tools[tool.Name] = tool
+	}
+	if len(tools) != 3 {
+		t.Fatalf("expected three tools, got %v", tools)
+	}
+	if tools["greet"].Description != "Say hello" {
+		t.Errorf("unexpected description %q", tools["greet"].Description)
+	}
+	if required := tools["greet"].InputSchema.Required; len(required) != 1 || required[0] != "name" {
+		t.Errorf("expected name to be required, got %v", required)
+	}
+
+	call := func(name string, args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = args
+		result, err := c.CallTool(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	if text, isErr := call("greet", map[string]any{"name": "$USER"}); isErr || text != "hello $USER\n" {
+		t.Errorf("expected the argument to be passed literally, got %q (error %v)", text, isErr)
+	}
+	if text, isErr := call("fail", nil); !isErr || !strings.Contains(text, "exit status 3") || !strings.Contains(text, "broken") {
+		t.Errorf("expected exit status and output, got %q", text)
+	}
+	if text, isErr := call("slow", nil); !isErr || !strings.Contains(text, "timed out after 100ms") {
+		t.Errorf("expected timeout, got %q", text)
+	}
+
+	if _, err := registry.CreateServer("commands", map[string]any{
+		"tools": map[string]any{"bad": map[string]any{"command": "true", "timeout": "soon"}},
+	}, nil); err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("expected invalid timeout to fail, got %v", err)
+	}
+}
+
+func TestCommandToolInWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := workspace.New(dir, map[string]string{"PROJECT": "checkout-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := workspace.With(context.Background(), ws)
+
+	run := func(cfg config.CommandTool) string {
+		t.Helper()
+		tool := &commandTool{name: "where", config: cfg, timeout: defaultTimeout}
+		result, err := tool.execute(ctx, mcp.CallToolRequest{})
+		if err != nil || result.IsError {
+			t.Fatalf("unexpected failure: %v %v", err, result.Content)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	if got := run(config.CommandTool{Command: "pwd; echo $PROJECT"}); got != ws.Dir+"\ncheckout-a\n" {
+		t.Errorf("expected the workspace's directory and environment, got %q", got)
+	}
+	if got := run(config.CommandTool{Command: "pwd", Dir: "sub"}); got != filepath.Join(ws.Dir, "sub")+"\n" {
+		t.Errorf("expected a relative dir inside the workspace, got %q", got)
+	}
+	other := t.TempDir()
+	if got := run(config.CommandTool{Command: "pwd", Dir: other}); got != other+"\n" {
+		t.Errorf("expected an absolute dir to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,791 @@
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	dataDefaultMaxRows = 100
+	dataMaxFileSize    = 100 * 1024 * 1024
+	dataQueryTimeout   = 60 * time.Second
+	dataPreviewRows    = 5
+	dataTopValues      = 3
+)
+
+// dataIdentifierChars matches the runs of characters not allowed in generated
+// table and column names
+var dataIdentifierChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// DataServerOptions configures the data builtin server
+type DataServerOptions struct {
+	// AllowedDirectories limits the files load_csv may read (default: the
+	// current directory)
+	AllowedDirectories []string
+
+	// MaxRows is how many rows query_sql returns unless the call asks for
+	// fewer (default 100)
+	MaxRows int
+}
+
+// dataTable describes a file loaded into the data server's database
+type dataTable struct {
+	name    string
+	source  string
+	columns []dataColumn
+	rows    int
+}
+
+// dataColumn is a column of a loaded table and its inferred SQLite type
+type dataColumn struct {
+	name string
+	kind string // INTEGER, REAL or TEXT
+}
+
+// DataServer loads CSV, TSV and xlsx files into an in-memory SQLite database
+// the model can query, so only results rather than whole files reach it
+type DataServer struct {
+	opts        DataServerOptions
+	allowedDirs []string
+	db          *sql.DB
+	tables      map[string]*dataTable
+	mutex       sync.Mutex
+}
+
+// NewDataServer creates a new data analysis MCP server
+func NewDataServer(opts DataServerOptions) (*server.MCPServer, error) {
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = dataDefaultMaxRows
+	}
+	dirs := opts.AllowedDirectories
+	if len(dirs) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current working directory: %v", err)
+		}
+		dirs = []string{cwd}
+	}
+	allowedDirs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid directory %s: %v", dir, err)
+		}
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = real
+		}
+		allowedDirs = append(allowedDirs, abs)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database, so keep to one
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	ds := &DataServer{
+		opts:        opts,
+		allowedDirs: allowedDirs,
+		db:          db,
+		tables:      make(map[string]*dataTable),
+	}
+
+	s := server.NewMCPServer("data-server", "1.0.0", server.WithToolCapabilities(true))
+
+	loadTool := mcp.NewTool("load_csv",
+		mcp.WithDescription(dataLoadDescription),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Path of the .csv, .tsv or .xlsx file to load"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Table name to load the data into (default: derived from the file name). An existing table of the same name is replaced"),
+		),
+		mcp.WithString("delimiter",
+			mcp.Description("Field delimiter for text files, e.g. ';' or '\\t' (default: detected from the first line)"),
+		),
+		mcp.WithString("sheet",
+			mcp.Description("Worksheet to load from an xlsx file (default: the first sheet)"),
+		),
+		mcp.WithBoolean("header",
+			mcp.Description("Whether the first row holds column names (default: true)"),
+		),
+	)
+
+	describeTool := mcp.NewTool("describe",
+		mcp.WithDescription("Summarizes a loaded table: per column its type, non-null and distinct counts, min and max, mean and standard deviation for numbers, and the most common values for text. Without a table, lists the loaded tables."),
+		mcp.WithString("table",
+			mcp.Description("Name of the table to describe (default: list all tables)"),
+		),
+	)
+
+	queryTool := mcp.NewTool("query_sql",
+		mcp.WithDescription(dataQueryDescription),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("A SELECT, WITH or VALUES statement in SQLite syntax"),
+		),
+		mcp.WithNumber("max_rows",
+			mcp.Description(fmt.Sprintf("Maximum number of rows to return (default and maximum: %d)", opts.MaxRows)),
+		),
+	)
+
+	plotTool := mcp.NewTool("plot_ascii",
+		mcp.WithDescription(dataPlotDescription),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("Query returning the data to plot, see the kinds for the expected columns"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Chart kind (default: bar)"),
+			mcp.Enum("bar", "histogram", "line"),
+		),
+		mcp.WithNumber("bins",
+			mcp.Description("Number of histogram bins (default: 10)"),
+		),
+		mcp.WithNumber("width",
+			mcp.Description(fmt.Sprintf("Chart width in characters (default: %d)", dataPlotWidth)),
+		),
+		mcp.WithNumber("height",
+			mcp.Description(fmt.Sprintf("Line chart height in characters (default: %d)", dataPlotHeight)),
+		),
+	)
+
+	s.AddTool(loadTool, ds.executeLoadCSV)
+	s.AddTool(describeTool, ds.executeDescribe)
+	s.AddTool(queryTool, ds.executeQuerySQL)
+	s.AddTool(plotTool, ds.executePlotASCII)
+
+	return s, nil
+}
+
+// executeLoadCSV handles the load_csv tool execution
+func (ds *DataServer) executeLoadCSV(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil || strings.TrimSpace(path) == "" {
+		return mcp.NewToolResultError("path parameter is required and must be a non-empty string"), nil
+	}
+	path, err = ds.resolvePath(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read file: %v", err)), nil
+	}
+	if info.Size() > dataMaxFileSize {
+		return mcp.NewToolResultError(fmt.Sprintf("file is too large (%d bytes, limit %d)", info.Size(), dataMaxFileSize)), nil
+	}
+
+	var records [][]string
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		records, err = readXLSXSheet(path, request.GetString("sheet", ""))
+	} else {
+		records, err = readDelimitedFile(path, request.GetString("delimiter", ""))
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(records) == 0 {
+		return mcp.NewToolResultError("file has no rows"), nil
+	}
+
+	name := request.GetString("name", "")
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	table := &dataTable{name: dataIdentifier(name, "data"), source: path}
+
+	var header []string
+	if request.GetBool("header", true) {
+		header, records = records[0], records[1:]
+	}
+	table.columns = dataColumns(header, records)
+	table.rows = len(records)
+	if len(table.columns) == 0 {
+		return mcp.NewToolResultError("file has no columns"), nil
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	if err := ds.createTable(ctx, table, records); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load %s: %v", path, err)), nil
+	}
+	ds.tables[table.name] = table
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Loaded %d rows into table %s from %s\n\nColumns:\n", table.rows, table.name, path)
+	for _, column := range table.columns {
+		fmt.Fprintf(&sb, "- %s (%s)\n", column.name, column.kind)
+	}
+	if preview, _, err := ds.query(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteIdentifier(table.name), dataPreviewRows), dataPreviewRows); err == nil && len(preview.rows) > 0 {
+		sb.WriteString("\nFirst rows:\n\n")
+		sb.WriteString(preview.markdown())
+	}
+
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.Meta = &mcp.Meta{
+		AdditionalFields: map[string]any{
+			"table": table.name,
+			"rows":  table.rows,
+		},
+	}
+	return result, nil
+}
+
+// executeDescribe handles the describe tool execution
+func (ds *DataServer) executeDescribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	name := request.GetString("table", "")
+	if name == "" {
+		if len(ds.tables) == 0 {
+			return mcp.NewToolResultText("No tables loaded. Use load_csv first."), nil
+		}
+		names := make([]string, 0, len(ds.tables))
+		for name := range ds.tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var sb strings.Builder
+		for _, name := range names {
+			table := ds.tables[name]
+			fmt.Fprintf(&sb, "- %s: %d rows, %d columns (from %s)\n", name, table.rows, len(table.columns), table.source)
+		}
+		return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+	}
+
+	table, ok := ds.tables[name]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("table %s is not loaded", name)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dataQueryTimeout)
+	defer cancel()
+
+	summary := &dataResult{columns: []string{"column", "type", "non-null", "distinct", "min", "max", "mean", "std", "top values"}}
+	for _, column := range table.columns {
+		row, err := ds.describeColumn(ctx, table.name, column)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to describe %s: %v", column.name, err)), nil
+		}
+		summary.rows = append(summary.rows, row)
+	}
+
+	text := fmt.Sprintf("Table %s: %d rows from %s\n\n%s", table.name, table.rows, table.source, summary.markdown())
+	return mcp.NewToolResultText(strings.TrimRight(text, "\n")), nil
+}
+
+// describeColumn returns the describe row of one column
+func (ds *DataServer) describeColumn(ctx context.Context, table string, column dataColumn) ([]string, error) {
+	t, c := quoteIdentifier(table), quoteIdentifier(column.name)
+	row := []string{column.name, column.kind, "", "", "", "", "", "", ""}
+
+	var nonNull, distinct int64
+	var minValue, maxValue, mean, meanSquare any
+	err := ds.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT COUNT(%[1]s), COUNT(DISTINCT %[1]s), MIN(%[1]s), MAX(%[1]s), AVG(%[1]s), AVG(%[1]s * %[1]s) FROM %[2]s", c, t),
+	).Scan(&nonNull, &distinct, &minValue, &maxValue, &mean, &meanSquare)
+	if err != nil {
+		return nil, err
+	}
+	row[2], row[3] = strconv.FormatInt(nonNull, 10), strconv.FormatInt(distinct, 10)
+	row[4], row[5] = dataCell(minValue), dataCell(maxValue)
+	if nonNull == 0 {
+		row[4], row[5] = "", ""
+		return row, nil
+	}
+
+	if column.kind != "TEXT" {
+		m, _ := dataNumber(mean)
+		m2, _ := dataNumber(meanSquare)
+		variance := m2 - m*m
+		if variance < 0 {
+			variance = 0
+		}
+		row[6] = strconv.FormatFloat(m, 'g', 6, 64)
+		row[7] = strconv.FormatFloat(math.Sqrt(variance), 'g', 6, 64)
+		return row, nil
+	}
+
+	rows, err := ds.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %[1]s, COUNT(*) AS n FROM %[2]s WHERE %[1]s IS NOT NULL GROUP BY %[1]s ORDER BY n DESC, %[1]s LIMIT %[3]d", c, t, dataTopValues))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var top []string
+	for rows.Next() {
+		var value any
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		top = append(top, fmt.Sprintf("%s (%d)", truncateString(dataCell(value), 30), count))
+	}
+	row[8] = strings.Join(top, ", ")
+	return row, rows.Err()
+}
+
+// executeQuerySQL handles the query_sql tool execution
+func (ds *DataServer) executeQuerySQL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("sql")
+	if err != nil || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("sql parameter is required and must be a non-empty string"), nil
+	}
+	maxRows := int(request.GetFloat("max_rows", float64(ds.opts.MaxRows)))
+	if maxRows <= 0 || maxRows > ds.opts.MaxRows {
+		maxRows = ds.opts.MaxRows
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	result, total, err := ds.query(ctx, query, maxRows)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	text := result.markdown()
+	switch {
+	case total == 0:
+		text = "Query returned no rows"
+	case total > maxRows:
+		text += fmt.Sprintf("\n(showing %d of %d rows)", maxRows, total)
+	}
+	toolResult := mcp.NewToolResultText(strings.TrimRight(text, "\n"))
+	toolResult.Meta = &mcp.Meta{
+		AdditionalFields: map[string]any{
+			"rows":      total,
+			"truncated": total > maxRows,
+		},
+	}
+	return toolResult, nil
+}
+
+// dataResult holds the columns and rendered rows of a query
+type dataResult struct {
+	columns []string
+	rows    [][]string
+	values  [][]any
+}
+
+// query runs a read-only statement and returns at most maxRows of its rows
+// along with the total row count. The statement runs in a transaction that is
+// always rolled back, so it cannot change the loaded tables.
+func (ds *DataServer) query(ctx context.Context, query string, maxRows int) (*dataResult, int, error) {
+	if err := checkReadOnlySQL(query); err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dataQueryTimeout)
+	defer cancel()
+	tx, err := ds.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to start query: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query failed: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, fmt.Errorf("query failed: %v", err)
+	}
+	result := &dataResult{columns: columns}
+	total := 0
+	for rows.Next() {
+		total++
+		if total > maxRows {
+			continue
+		}
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, 0, fmt.Errorf("query failed: %v", err)
+		}
+		cells := make([]string, len(values))
+		for i, value := range values {
+			cells[i] = dataCell(value)
+		}
+		result.rows = append(result.rows, cells)
+		result.values = append(result.values, values)
+	}
+	if err := rows.Err(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, 0, fmt.Errorf("query timed out after %s", dataQueryTimeout)
+		}
+		return nil, 0, fmt.Errorf("query failed: %v", err)
+	}
+	return result, total, nil
+}
+
+// markdown renders the result as a markdown table
+func (r *dataResult) markdown() string {
+	var sb strings.Builder
+	writeRow := func(fields []string) {
+		cells := make([]string, len(fields))
+		for i, field := range fields {
+			cells[i] = strings.ReplaceAll(strings.ReplaceAll(field, "|", "\\|"), "\n", " ")
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	writeRow(r.columns)
+	sb.WriteString("|" + strings.Repeat(" --- |", len(r.columns)) + "\n")
+	for _, row := range r.rows {
+		writeRow(row)
+	}
+	return sb.String()
+}
+
+// checkReadOnlySQL accepts a single SELECT, WITH or VALUES statement
+func checkReadOnlySQL(query string) error {
+	statement := strings.TrimSpace(stripSQLComments(query))
+	statement = strings.TrimSpace(strings.TrimSuffix(statement, ";"))
+	keyword := strings.ToUpper(strings.SplitN(strings.Join(strings.Fields(statement), " "), " ", 2)[0])
+	switch keyword {
+	case "SELECT", "WITH", "VALUES":
+	case "":
+		return fmt.Errorf("sql is empty")
+	default:
+		return fmt.Errorf("only SELECT, WITH and VALUES statements are allowed, got %s", keyword)
+	}
+	if strings.Contains(stripSQLStrings(statement), ";") {
+		return fmt.Errorf("only a single statement is allowed")
+	}
+	return nil
+}
+
+// stripSQLComments removes -- and /* */ comments outside string literals
+func stripSQLComments(query string) string {
+	var sb strings.Builder
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\'' || query[i] == '"':
+			end := strings.IndexByte(query[i+1:], query[i])
+			if end < 0 {
+				sb.WriteString(query[i:])
+				return sb.String()
+			}
+			sb.WriteString(query[i : i+end+2])
+			i += end + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return sb.String()
+			}
+			i += end - 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return sb.String()
+			}
+			sb.WriteByte(' ')
+			i += end + 3
+		default:
+			sb.WriteByte(query[i])
+		}
+	}
+	return sb.String()
+}
+
+// stripSQLStrings blanks out string literals and quoted identifiers
+func stripSQLStrings(query string) string {
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// createTable replaces table with the records, using the inferred column types
+func (ds *DataServer) createTable(ctx context.Context, table *dataTable, records [][]string) error {
+	tx, err := ds.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	definitions := make([]string, len(table.columns))
+	placeholders := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		definitions[i] = quoteIdentifier(column.name) + " " + column.kind
+		placeholders[i] = "?"
+	}
+	name := quoteIdentifier(table.name)
+	if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(definitions, ", "))); err != nil {
+		return err
+	}
+
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", name, strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	args := make([]any, len(table.columns))
+	for _, record := range records {
+		for i, column := range table.columns {
+			args[i] = nil
+			if i < len(record) {
+				args[i] = dataValue(record[i], column.kind)
+			}
+		}
+		if _, err := insert.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// resolvePath returns the absolute path of a file inside the allowed directories
+func (ds *DataServer) resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %v", path, err)
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	for _, dir := range ds.allowedDirs {
+		if real == dir || strings.HasPrefix(real, dir+string(filepath.Separator)) {
+			return real, nil
+		}
+	}
+	return "", fmt.Errorf("access denied: %s is outside the allowed directories", path)
+}
+
+// readDelimitedFile reads a CSV or TSV file. An empty delimiter is detected
+// from the file extension or the first line.
+func readDelimitedFile(path, delimiter string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	var comma rune
+	switch delimiter {
+	case "":
+		comma = detectDelimiter(path, content)
+	case `\t`, "tab":
+		comma = '\t'
+	default:
+		runes := []rune(delimiter)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("delimiter must be a single character")
+		}
+		comma = runes[0]
+	}
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", filepath.Base(path), err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// detectDelimiter picks tab for .tsv files, otherwise whichever of comma,
+// semicolon, tab and pipe appears most often in the first line
+func detectDelimiter(path, content string) rune {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".tsv" || ext == ".tab" {
+		return '\t'
+	}
+	firstLine, _, _ := strings.Cut(content, "\n")
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if count := strings.Count(firstLine, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+// dataColumns names the columns after the header, or col1, col2... without
+// one, and infers each column's type from its values
+func dataColumns(header []string, records [][]string) []dataColumn {
+	width := len(header)
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+
+	columns := make([]dataColumn, width)
+	seen := make(map[string]bool)
+	for i := range columns {
+		fallback := fmt.Sprintf("col%d", i+1)
+		name := fallback
+		if i < len(header) {
+			name = dataIdentifier(header[i], fallback)
+		}
+		for base, n := name, 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[name] = true
+
+		kind := "INTEGER"
+		values := 0
+		for _, record := range records {
+			if i >= len(record) || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			values++
+			value := strings.TrimSpace(record[i])
+			if kind == "INTEGER" {
+				if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+					kind = "REAL"
+				}
+			}
+			if kind == "REAL" {
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					kind = "TEXT"
+					break
+				}
+			}
+		}
+		if values == 0 {
+			kind = "TEXT"
+		}
+		columns[i] = dataColumn{name: name, kind: kind}
+	}
+	return columns
+}
+
+// dataValue converts a field to the value stored for a column of kind
+func dataValue(field, kind string) any {
+	value := strings.TrimSpace(field)
+	if value == "" {
+		return nil
+	}
+	switch kind {
+	case "INTEGER":
+		n, _ := strconv.ParseInt(value, 10, 64)
+		return n
+	case "REAL":
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	}
+	return field
+}
+
+// dataIdentifier turns a header or file name into a lower-case SQL name made
+// of letters, digits and underscores, or fallback when nothing is left
+func dataIdentifier(name, fallback string) string {
+	id := strings.Trim(dataIdentifierChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_"), "_")
+	if id == "" {
+		return fallback
+	}
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	return id
+}
+
+// quoteIdentifier quotes a table or column name for SQLite
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// dataCell renders a value scanned from SQLite
+func dataCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return formatScalar(value)
+}
+
+// dataNumber converts a value scanned from SQLite to a float
+func dataNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case []byte:
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(v)), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+const dataLoadDescription = `Loads a CSV, TSV or Excel (.xlsx) file into a table of an in-memory SQLite database for analysis with describe, query_sql and plot_ascii.
+
+Use this instead of reading data files directly: only the columns, their types and the first few rows are returned, so large files stay out of the conversation.
+
+Usage notes:
+  - Column names are lower-cased with other characters replaced by underscores, e.g. "Unit Price" becomes unit_price
+  - Column types (INTEGER, REAL or TEXT) are inferred from the values; empty fields become NULL
+  - Excel dates are loaded as serial day numbers
+  - Tables last for the session; loading into an existing name replaces it`
+
+const dataQueryDescription = `Runs a read-only SQL query in SQLite syntax over the tables loaded with load_csv and returns the result as a markdown table.
+
+Usage notes:
+  - Only a single SELECT, WITH or VALUES statement is allowed
+  - Aggregate in SQL (GROUP BY, COUNT, SUM, AVG, window functions) rather than returning raw rows
+  - Results are capped at max_rows rows; the total row count is reported when more matched`
+
+const dataPlotDescription = `Plots the result of a SQL query as a text chart, to show the shape of the data without returning every row.
+
+Kinds and the columns they expect:
+  - bar: first column is the label, second the value, one bar per row (at most 50 rows)
+  - histogram: first column holds the numbers to bin
+  - line: first column is x and second y; with a single column, y is plotted against the row number`
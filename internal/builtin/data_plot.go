@@ -0,0 +1,242 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	dataPlotWidth     = 60
+	dataPlotHeight    = 15
+	dataPlotBins      = 10
+	dataPlotMaxBars   = 50
+	dataPlotMaxPoints = 100000
+)
+
+// executePlotASCII handles the plot_ascii tool execution
+func (ds *DataServer) executePlotASCII(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("sql")
+	if err != nil || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("sql parameter is required and must be a non-empty string"), nil
+	}
+	kind := request.GetString("kind", "bar")
+	width := int(request.GetFloat("width", dataPlotWidth))
+	height := int(request.GetFloat("height", dataPlotHeight))
+	bins := int(request.GetFloat("bins", dataPlotBins))
+	if width < 10 || width > 200 {
+		return mcp.NewToolResultError("width must be between 10 and 200"), nil
+	}
+	if height < 5 || height > 60 {
+		return mcp.NewToolResultError("height must be between 5 and 60"), nil
+	}
+	if bins < 1 || bins > 100 {
+		return mcp.NewToolResultError("bins must be between 1 and 100"), nil
+	}
+
+	ds.mutex.Lock()
+	result, total, err := ds.query(ctx, query, dataPlotMaxPoints)
+	ds.mutex.Unlock()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if total == 0 {
+		return mcp.NewToolResultError("query returned no rows to plot"), nil
+	}
+	if total > dataPlotMaxPoints {
+		return mcp.NewToolResultError(fmt.Sprintf("query returned %d rows, more than the %d a chart can use; aggregate or filter it first", total, dataPlotMaxPoints)), nil
+	}
+
+	var chart string
+	switch kind {
+	case "bar":
+		chart, err = plotBars(result, width)
+	case "histogram":
+		chart, err = plotHistogram(result, bins, width)
+	case "line":
+		chart, err = plotLine(result, width, height)
+	default:
+		err = fmt.Errorf("unknown chart kind %q (use bar, histogram or line)", kind)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText("```\n" + chart + "```"), nil
+}
+
+// plotColumn returns column i of the result as numbers, skipping NULLs
+func plotColumn(result *dataResult, i int) ([]float64, error) {
+	if i >= len(result.columns) {
+		return nil, fmt.Errorf("query must return at least %d columns", i+1)
+	}
+	values := make([]float64, 0, len(result.values))
+	for _, row := range result.values {
+		if row[i] == nil {
+			continue
+		}
+		n, ok := dataNumber(row[i])
+		if !ok {
+			return nil, fmt.Errorf("column %s has non-numeric value %q", result.columns[i], dataCell(row[i]))
+		}
+		values = append(values, n)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("column %s has no values", result.columns[i])
+	}
+	return values, nil
+}
+
+// plotBars draws one horizontal bar per (label, value) row
+func plotBars(result *dataResult, width int) (string, error) {
+	if len(result.columns) < 2 {
+		return "", fmt.Errorf("bar charts need a label and a value column")
+	}
+	if len(result.rows) > dataPlotMaxBars {
+		return "", fmt.Errorf("bar charts show at most %d bars, got %d rows", dataPlotMaxBars, len(result.rows))
+	}
+	labels := make([]string, len(result.rows))
+	values := make([]float64, len(result.rows))
+	for i, row := range result.values {
+		labels[i] = truncateString(result.rows[i][0], 20)
+		if row[1] != nil {
+			n, ok := dataNumber(row[1])
+			if !ok {
+				return "", fmt.Errorf("column %s has non-numeric value %q", result.columns[1], result.rows[i][1])
+			}
+			values[i] = n
+		}
+	}
+	return drawBars(labels, values, width), nil
+}
+
+// plotHistogram bins the first column into equal-width buckets
+func plotHistogram(result *dataResult, bins, width int) (string, error) {
+	values, err := plotColumn(result, 0)
+	if err != nil {
+		return "", err
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = math.Min(low, v), math.Max(high, v)
+	}
+	if low == high {
+		bins = 1
+	}
+	step := (high - low) / float64(bins)
+
+	counts := make([]float64, bins)
+	for _, v := range values {
+		bin := bins - 1
+		if step > 0 {
+			bin = min(int((v-low)/step), bins-1)
+		}
+		counts[bin]++
+	}
+	labels := make([]string, bins)
+	for i := range labels {
+		closing := ")"
+		if i == bins-1 {
+			closing = "]"
+		}
+		labels[i] = fmt.Sprintf("[%s, %s%s", plotNumber(low+step*float64(i)), plotNumber(low+step*float64(i+1)), closing)
+	}
+	return drawBars(labels, counts, width), nil
+}
+
+// drawBars renders labelled bars scaled to the largest absolute value
+func drawBars(labels []string, values []float64, width int) string {
+	labelWidth, largest := 0, 0.0
+	for i, label := range labels {
+		labelWidth = max(labelWidth, len([]rune(label)))
+		largest = math.Max(largest, math.Abs(values[i]))
+	}
+	var sb strings.Builder
+	for i, label := range labels {
+		length := 0
+		if largest > 0 {
+			length = int(math.Round(math.Abs(values[i]) / largest * float64(width)))
+		}
+		bar := strings.Repeat("█", length)
+		if values[i] < 0 {
+			bar = strings.Repeat("░", length)
+		}
+		fmt.Fprintf(&sb, "%s%s │%s %s\n", label, strings.Repeat(" ", labelWidth-len([]rune(label))), bar, plotNumber(values[i]))
+	}
+	return sb.String()
+}
+
+// plotLine draws y against x on a character grid
+func plotLine(result *dataResult, width, height int) (string, error) {
+	var xs, ys []float64
+	if len(result.columns) == 1 {
+		values, err := plotColumn(result, 0)
+		if err != nil {
+			return "", err
+		}
+		for i, v := range values {
+			xs, ys = append(xs, float64(i+1)), append(ys, v)
+		}
+	} else {
+		for _, row := range result.values {
+			if row[0] == nil || row[1] == nil {
+				continue
+			}
+			x, xOK := dataNumber(row[0])
+			y, yOK := dataNumber(row[1])
+			if !xOK || !yOK {
+				return "", fmt.Errorf("line charts need numeric x and y columns, got %q and %q", dataCell(row[0]), dataCell(row[1]))
+			}
+			xs, ys = append(xs, x), append(ys, y)
+		}
+		if len(xs) == 0 {
+			return "", fmt.Errorf("query returned no points to plot")
+		}
+	}
+
+	minX, maxX, minY, maxY := xs[0], xs[0], ys[0], ys[0]
+	for i := range xs {
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+	scale := func(v, low, high float64, size int) int {
+		if high == low {
+			return size / 2
+		}
+		return int(math.Round((v - low) / (high - low) * float64(size-1)))
+	}
+
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", width))
+	}
+	for i := range xs {
+		grid[height-1-scale(ys[i], minY, maxY, height)][scale(xs[i], minX, maxX, width)] = '•'
+	}
+
+	top, bottom := plotNumber(maxY), plotNumber(minY)
+	axisWidth := max(len(top), len(bottom))
+	var sb strings.Builder
+	for i, line := range grid {
+		label := ""
+		switch i {
+		case 0:
+			label = top
+		case height - 1:
+			label = bottom
+		}
+		fmt.Fprintf(&sb, "%*s │%s\n", axisWidth, label, strings.TrimRight(string(line), " "))
+	}
+	fmt.Fprintf(&sb, "%*s └%s\n", axisWidth, "", strings.Repeat("─", width))
+	left, right := plotNumber(minX), plotNumber(maxX)
+	fmt.Fprintf(&sb, "%*s  %s%*s\n", axisWidth, "", left, max(width-len(left), len(right)+1), right)
+	return sb.String(), nil
+}
+
+// plotNumber formats an axis or bar value compactly
+func plotNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
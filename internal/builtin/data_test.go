@@ -0,0 +1,184 @@
+package builtin
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testSalesCSV = `Region;Unit Price;Units;Date
+North;2.5;10;2024-01-01
+South;3;4;2024-01-02
+North;1.25;;2024-01-03
+East;4;7;2024-01-04
+`
+
+// startDataClient starts a data server limited to dir
+func startDataClient(t *testing.T, dir string, options map[string]any) *client.Client {
+	t.Helper()
+	options["allowed_directories"] = []any{dir}
+	wrapper, err := NewRegistry().CreateServer("data", options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewInProcessClient(wrapper.GetServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// callDataTool calls a data tool and returns its text and error flag
+func callDataTool(t *testing.T, c *client.Client, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := c.CallTool(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestDataServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Sales 2024.csv")
+	if err := os.WriteFile(path, []byte(testSalesCSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := startDataClient(t, dir, map[string]any{"max_rows": 2})
+
+	text, isErr := callDataTool(t, c, "load_csv", map[string]any{"path": path})
+	if isErr {
+		t.Fatalf("load_csv failed: %s", text)
+	}
+	for _, want := range []string{"Loaded 4 rows into table sales_2024", "- region (TEXT)", "- unit_price (REAL)", "- units (INTEGER)", "| North | 2.5 | 10 | 2024-01-01 |"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected load summary to contain %q, got\n%s", want, text)
+		}
+	}
+
+	text, isErr = callDataTool(t, c, "describe", map[string]any{"table": "sales_2024"})
+	if isErr {
+		t.Fatalf("describe failed: %s", text)
+	}
+	for _, want := range []string{"| units | INTEGER | 3 | 3 | 4 | 10 | 7 | 2.44949 |", "North (2), East (1), South (1)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected description to contain %q, got\n%s", want, text)
+		}
+	}
+
+	text, isErr = callDataTool(t, c, "query_sql", map[string]any{
+		"sql": "-- units by region\nSELECT region, SUM(units) AS units FROM sales_2024 GROUP BY region ORDER BY region;",
+	})
+	if isErr || !strings.Contains(text, "| East | 7 |") || !strings.Contains(text, "(showing 2 of 3 rows)") {
+		t.Errorf("unexpected query result %q", text)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM sales_2024",
+		"ATTACH DATABASE 'other.db' AS other",
+		"SELECT 1; DROP TABLE sales_2024",
+	} {
+		if text, isErr := callDataTool(t, c, "query_sql", map[string]any{"sql": query}); !isErr {
+			t.Errorf("expected %q to be refused, got %q", query, text)
+		}
+	}
+	callDataTool(t, c, "query_sql", map[string]any{"sql": "WITH x AS (SELECT 1) DELETE FROM sales_2024"})
+	if text, _ := callDataTool(t, c, "query_sql", map[string]any{"sql": "SELECT COUNT(*) AS n FROM sales_2024 WHERE region = 'a;b'"}); !strings.Contains(text, "| 0 |") {
+		t.Errorf("expected a quoted semicolon to be allowed, got %q", text)
+	}
+	if text, _ := callDataTool(t, c, "query_sql", map[string]any{"sql": "SELECT COUNT(*) AS n FROM sales_2024"}); !strings.Contains(text, "| 4 |") {
+		t.Errorf("expected writes to be rolled back, got %q", text)
+	}
+
+	text, isErr = callDataTool(t, c, "plot_ascii", map[string]any{"sql": "SELECT region, units FROM sales_2024 WHERE units IS NOT NULL", "width": 10})
+	if isErr || !strings.Contains(text, "North │██████████ 10") || !strings.Contains(text, "South │████ 4") {
+		t.Errorf("unexpected bar chart\n%s", text)
+	}
+	text, isErr = callDataTool(t, c, "plot_ascii", map[string]any{"sql": "SELECT units FROM sales_2024", "kind": "histogram", "bins": 2, "width": 10})
+	if isErr || !strings.Contains(text, "[4, 7)  │█████ 1") || !strings.Contains(text, "[7, 10] │██████████ 2") {
+		t.Errorf("unexpected histogram\n%s", text)
+	}
+	text, isErr = callDataTool(t, c, "plot_ascii", map[string]any{"sql": "SELECT units FROM sales_2024 WHERE units IS NOT NULL", "kind": "line", "width": 10, "height": 5})
+	if isErr || strings.Count(text, "•") != 3 || !strings.Contains(text, "10 │") {
+		t.Errorf("unexpected line chart\n%s", text)
+	}
+
+	if text, isErr := callDataTool(t, c, "load_csv", map[string]any{"path": "/etc/passwd"}); !isErr || !strings.Contains(text, "outside the allowed directories") {
+		t.Errorf("expected a file outside the allowed directories to be refused, got %q", text)
+	}
+}
+
+func TestDataServerXLSX(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Items" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst><si><t>item</t></si><si><t>qty</t></si><si><r><t>bolt</t></r><r><t>s</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>total</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="4"><c r="B4"><v>12</v></c></row>
+</sheetData></worksheet>`,
+	} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	records, err := readXLSXSheet(path, "Items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"item", "qty"}, {"bolts", "", "true"}, nil, {"", "12"}}
+	if len(records) != len(want) {
+		t.Fatalf("expected %q, got %q", want, records)
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d: expected %q, got %q", i, want[i], records[i])
+		}
+	}
+	if _, err := readXLSXSheet(path, "Missing"); err == nil || !strings.Contains(err.Error(), "sheets: Summary, Items") {
+		t.Errorf("expected unknown sheet to list the sheets, got %v", err)
+	}
+
+	c := startDataClient(t, dir, map[string]any{})
+	text, isErr := callDataTool(t, c, "load_csv", map[string]any{"path": path})
+	if isErr || !strings.Contains(text, "Loaded 0 rows into table book") || !strings.Contains(text, "- total (TEXT)") {
+		t.Errorf("expected the first sheet to load, got %q", text)
+	}
+}
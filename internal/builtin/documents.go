@@ -0,0 +1,349 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document kinds the fetch tools extract text from
+const (
+	documentPDF  = "pdf"
+	documentDocx = "docx"
+)
+
+const docxContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// documentKind returns the kind of document body is, or "" when it is not a
+// document with extractable text. Servers often send documents as
+// application/octet-stream, so the content is sniffed as well.
+func documentKind(contentType string, body []byte) string {
+	switch {
+	case strings.Contains(contentType, "application/pdf") || bytes.HasPrefix(body, []byte("%PDF-")):
+		return documentPDF
+	case strings.Contains(contentType, docxContentType):
+		return documentDocx
+	case bytes.HasPrefix(body, []byte("PK\x03\x04")) && bytes.Contains(body, []byte("word/document.xml")):
+		return documentDocx
+	}
+	return ""
+}
+
+// extractDocumentText returns the text of a PDF or docx document. pages
+// selects PDF pages, e.g. "1-3,7"; empty means all pages.
+func extractDocumentText(kind string, body []byte, pages string) (string, error) {
+	switch kind {
+	case documentPDF:
+		return extractPDFText(body, pages)
+	case documentDocx:
+		if pages != "" {
+			return "", fmt.Errorf("pages is only supported for PDF documents")
+		}
+		return extractDocxText(body)
+	}
+	return "", fmt.Errorf("unsupported document type %q", kind)
+}
+
+// extractPDFText returns the text of the selected pages, each headed by its number
+func extractPDFText(body []byte, pages string) (text string, err error) {
+	// The PDF parser panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PDF: %v", err)
+	}
+	total := reader.NumPage()
+	selected, err := parsePageRange(pages, total)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, num := range selected {
+		page := reader.Page(num)
+		if page.V.IsNull() {
+			continue
+		}
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from page %d: %v", num, err)
+		}
+		fmt.Fprintf(&sb, "--- Page %d of %d ---\n%s\n", num, total, strings.TrimSpace(pageText))
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("PDF has no extractable text on the selected pages")
+	}
+	return sb.String(), nil
+}
+
+// parsePageRange parses a page selection such as "1-3,7" against a document
+// of total pages. An empty selection means every page.
+func parsePageRange(spec string, total int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		pages := make([]int, total)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		return pages, nil
+	}
+
+	var pages []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(endStr)); err != nil {
+				return nil, fmt.Errorf("invalid page range %q", part)
+			}
+		}
+		if start < 1 || end < start || end > total {
+			return nil, fmt.Errorf("page range %q is outside the document's %d pages", part, total)
+		}
+		for page := start; page <= end; page++ {
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// extractDocxText returns the paragraphs of a Word document's main body
+func extractDocxText(body []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx: %v", err)
+	}
+	file, err := archive.Open("word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("docx has no word/document.xml: %v", err)
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	decoder := xml.NewDecoder(file)
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse docx: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br", "cr":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// xlsx parts read by readXLSXSheet
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	xlsxRichText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+	xlsxSharedStrings struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+	xlsxWorksheet struct {
+		Rows []struct {
+			Index int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Value  string       `xml:"v"`
+				Inline xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+// text returns the plain text of a shared or inline string
+func (r xlsxRichText) text() string {
+	if len(r.Runs) == 0 {
+		return r.Text
+	}
+	var sb strings.Builder
+	for _, run := range r.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// readXLSXSheet returns the cell values of a worksheet of an Excel workbook,
+// the first one when sheet is empty. Values are the cells' stored values, so
+// formulas give their last computed result and dates their serial number.
+func readXLSXSheet(path, sheet string) ([][]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %v", err)
+	}
+	defer archive.Close()
+
+	decode := func(name string, v any) error {
+		file, err := archive.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return xml.NewDecoder(file).Decode(v)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, fmt.Errorf("failed to read xlsx workbook: %v", err)
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("xlsx has no worksheets")
+	}
+	index := 0
+	if sheet != "" {
+		index = -1
+		names := make([]string, len(workbook.Sheets))
+		for i, s := range workbook.Sheets {
+			names[i] = s.Name
+			if s.Name == sheet {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("sheet %q not found (sheets: %s)", sheet, strings.Join(names, ", "))
+		}
+	}
+
+	var relationships xlsxRelationships
+	if err := decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, fmt.Errorf("failed to read xlsx relationships: %v", err)
+	}
+	target := ""
+	for _, rel := range relationships.Relationships {
+		if rel.ID == workbook.Sheets[index].ID {
+			target = rel.Target
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("sheet %q has no worksheet part", workbook.Sheets[index].Name)
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = "xl/" + target
+	}
+
+	// Workbooks without text cells have no shared strings part
+	var sharedStrings xlsxSharedStrings
+	if err := decode("xl/sharedStrings.xml", &sharedStrings); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read xlsx shared strings: %v", err)
+	}
+
+	var worksheet xlsxWorksheet
+	if err := decode(target, &worksheet); err != nil {
+		return nil, fmt.Errorf("failed to read xlsx worksheet: %v", err)
+	}
+
+	var records [][]string
+	for _, row := range worksheet.Rows {
+		rowIndex := len(records)
+		if row.Index > 0 {
+			rowIndex = row.Index - 1
+		}
+		// Rows without cells are left out of the sheet data
+		for len(records) < rowIndex {
+			records = append(records, nil)
+		}
+		var record []string
+		for _, cell := range row.Cells {
+			column := xlsxColumnIndex(cell.Ref)
+			if column < 0 {
+				column = len(record)
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+			switch cell.Type {
+			case "s":
+				if i, err := strconv.Atoi(cell.Value); err == nil && i >= 0 && i < len(sharedStrings.Items) {
+					record[column] = sharedStrings.Items[i].text()
+				}
+			case "inlineStr":
+				record[column] = cell.Inline.text()
+			case "b":
+				record[column] = map[string]string{"0": "false", "1": "true"}[cell.Value]
+			default:
+				record[column] = cell.Value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// xlsxColumnIndex returns the zero-based column of a cell reference such as
+// "AB12", or -1 when ref has no column letters
+func xlsxColumnIndex(ref string) int {
+	column := 0
+	letters := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return -1
+	}
+	return column - 1
+}
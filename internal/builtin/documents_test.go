@@ -0,0 +1,145 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testPDF builds a minimal PDF with one line of text per page
+func testPDF(pages ...string) []byte {
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// testDocx builds a minimal Word document with the given paragraphs
+func testDocx(t *testing.T, paragraphs ...string) []byte {
+	var body strings.Builder
+	for _, p := range paragraphs {
+		fmt.Fprintf(&body, "<w:p><w:r><w:t>%s</w:t></w:r></w:p>", p)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(file, `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body.String())
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	doc := testPDF("First page", "Second page", "Third page")
+
+	text, err := extractDocumentText(documentPDF, doc, "")
+	if err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	for _, want := range []string{"--- Page 1 of 3 ---", "First page", "Third page"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %q", want, text)
+		}
+	}
+
+	text, err = extractDocumentText(documentPDF, doc, "2-3")
+	if err != nil {
+		t.Fatalf("extract with pages failed: %v", err)
+	}
+	if strings.Contains(text, "First page") || !strings.Contains(text, "Second page") {
+		t.Errorf("page range not applied: %q", text)
+	}
+
+	if _, err := extractDocumentText(documentPDF, doc, "2-5"); err == nil {
+		t.Error("expected an error for pages beyond the document")
+	}
+	if _, err := extractDocumentText(documentPDF, []byte("%PDF-1.4 garbage"), ""); err == nil {
+		t.Error("expected an error for a malformed PDF")
+	}
+}
+
+func TestParsePageRange(t *testing.T) {
+	pages, err := parsePageRange(" 1-2, 4 ", 5)
+	if err != nil || fmt.Sprint(pages) != "[1 2 4]" {
+		t.Errorf("got %v, %v", pages, err)
+	}
+	for _, spec := range []string{"0", "3-1", "x", "6"} {
+		if _, err := parsePageRange(spec, 5); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestFetchDocuments(t *testing.T) {
+	pdfDoc := testPDF("Quarterly report")
+	docx := testDocx(t, "Heading", "Body text")
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			// Documents served without a precise type are sniffed
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pdfDoc)
+		case "/notes.docx":
+			w.Header().Set("Content-Type", docxContentType)
+			w.Write(docx)
+		}
+	}))
+	defer site.Close()
+
+	fetch := func(path string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"url": site.URL + path, "format": "markdown"}
+		result, err := executeFetch(context.Background(), request)
+		if err != nil || result.IsError {
+			t.Fatalf("fetch %s failed: %v %v", path, err, result)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	if text := fetch("/report.pdf"); !strings.Contains(text, "Quarterly report") {
+		t.Errorf("PDF text not extracted: %q", text)
+	}
+	if text := fetch("/notes.docx"); text != "Heading\nBody text" {
+		t.Errorf("docx text not extracted: %q", text)
+	}
+}
@@ -37,6 +37,9 @@ func NewFetchServer() (*server.MCPServer, error) {
 			mcp.Enum("text", "markdown", "html"),
 			mcp.Description("The format to return the content in (text, markdown, or html)"),
 		),
+		mcp.WithString("pages",
+			mcp.Description("Pages to extract from a PDF, e.g. '1-5' or '2,4-6' (default: all pages)"),
+		),
 		mcp.WithNumber("timeout",
 			mcp.Description("Optional timeout in seconds (max 120)"),
 			mcp.Min(0),
@@ -155,6 +158,21 @@ func executeFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		contentType = "unknown"
 	}
 
+	// PDF and Word documents are returned as their text, whatever the format
+	if kind := documentKind(contentType, bodyBytes); kind != "" {
+		text, err := extractDocumentText(kind, bodyBytes, request.GetString("pages", ""))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to extract text from %s: %v", kind, err)), nil
+		}
+		result := mcp.NewToolResultText(text)
+		result.Meta = &mcp.Meta{
+			AdditionalFields: map[string]any{
+				"title": fmt.Sprintf("%s (%s)", urlStr, contentType),
+			},
+		}
+		return result, nil
+	}
+
 	// Process content based on format
 	var output string
 	switch format {
@@ -266,4 +284,5 @@ Usage notes:
     - "text": Plain text extraction from HTML, or raw content for non-HTML
     - "markdown": HTML converted to markdown, or code-wrapped for non-HTML
     - "html": Raw HTML content
+  - PDF and Word (docx) documents are returned as extracted text in any format; use pages to select PDF pages
   - Timeout can be specified in seconds (default 30s, max 120s)`
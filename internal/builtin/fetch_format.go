@@ -0,0 +1,100 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultCSVRows is how many data rows a CSV table shows unless maxRows is given
+const defaultCSVRows = 50
+
+// formatJSONContent pretty-prints a JSON body, first narrowing it to the gjson
+// path when one is given
+func formatJSONContent(body []byte, path string) (string, error) {
+	if !json.Valid(body) {
+		return "", fmt.Errorf("response is not valid JSON")
+	}
+
+	raw := body
+	if path != "" {
+		result := gjson.GetBytes(body, path)
+		if !result.Exists() {
+			return "", fmt.Errorf("gjson path '%s' did not match any data", path)
+		}
+		raw = []byte(result.Raw)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, raw, "", "  "); err != nil {
+		return "", fmt.Errorf("failed to format JSON: %v", err)
+	}
+	return pretty.String(), nil
+}
+
+// csvDelimiter returns the field delimiter for CSV and TSV content types, or 0
+// when contentType is neither
+func csvDelimiter(contentType string) rune {
+	switch {
+	case strings.Contains(contentType, "text/csv"), strings.Contains(contentType, "application/csv"):
+		return ','
+	case strings.Contains(contentType, "text/tab-separated-values"):
+		return '\t'
+	}
+	return 0
+}
+
+// formatCSVTable renders CSV content as a markdown table of its header and at
+// most maxRows data rows
+func formatCSVTable(content string, delimiter rune, maxRows int) (string, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return "", fmt.Errorf("CSV is empty")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to parse CSV: %v", err)
+	}
+
+	var sb strings.Builder
+	writeRow := func(fields []string) {
+		cells := make([]string, len(header))
+		for i := range cells {
+			if i < len(fields) {
+				cells[i] = strings.ReplaceAll(strings.ReplaceAll(fields[i], "|", "\\|"), "\n", " ")
+			}
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	writeRow(header)
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse CSV: %v", err)
+		}
+		if rows < maxRows {
+			writeRow(record)
+		}
+		rows++
+	}
+
+	if rows > maxRows {
+		fmt.Fprintf(&sb, "\n(showing %d of %d rows)\n", maxRows, rows)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,29 @@
+package builtin
+
+import "testing"
+
+func TestFormatCSVTable(t *testing.T) {
+	// Ragged rows are padded or trimmed to the header, and pipes escaped
+	table, err := formatCSVTable("a\tb\nx|y\nmore\tcells\tthan header\n", '\t', 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "| a | b |\n| --- | --- |\n| x\\|y |  |\n| more | cells |\n"
+	if table != want {
+		t.Errorf("got %q, want %q", table, want)
+	}
+
+	if _, err := formatCSVTable("", ',', 10); err == nil {
+		t.Error("expected an error for empty CSV")
+	}
+}
+
+func TestFormatJSONContent(t *testing.T) {
+	if _, err := formatJSONContent([]byte(`{"a":1}`), "b"); err == nil {
+		t.Error("expected an error for a path that matches nothing")
+	}
+	out, err := formatJSONContent([]byte(`{"a":{"b":[1,2]}}`), "a.b.1")
+	if err != nil || out != "2" {
+		t.Errorf("got %q, %v", out, err)
+	}
+}
@@ -0,0 +1,412 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/tidwall/gjson"
+)
+
+// graphqlOperation is an operation defined in a GraphQL document
+type graphqlOperation struct {
+	kind string // query, mutation or subscription
+	name string // empty for anonymous operations
+}
+
+// newGraphQLQueryTool returns the graphql_query tool
+func newGraphQLQueryTool() mcp.Tool {
+	return mcp.NewTool("graphql_query",
+		mcp.WithDescription(httpGraphQLDescription),
+		mcp.WithString("endpoint",
+			mcp.Required(),
+			mcp.Description("The GraphQL endpoint URL (e.g., 'https://api.github.com/graphql')"),
+		),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The GraphQL document to execute"),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Optional values for the query's variables"),
+		),
+		mcp.WithString("operationName",
+			mcp.Description("Operation to run when the document defines several"),
+		),
+		mcp.WithObject("headers",
+			mcp.Description("Optional extra request headers, e.g. {\"Authorization\": \"Bearer ...\"}. Headers configured for the endpoint's host take precedence"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Optional gjson path to filter the response data (e.g., 'repository.issues.nodes.#.title')"),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Optional timeout in seconds (max 120)"),
+			mcp.Min(0),
+			mcp.Max(120),
+		),
+	)
+}
+
+// executeGraphQLQuery returns the graphql_query handler. Headers in
+// opts.GraphQLHeaders are added to requests for their host, and mutations
+// are refused unless opts.GraphQLMutations is set.
+func executeGraphQLQuery(opts HTTPServerOptions) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		endpoint, err := request.RequireString("endpoint")
+		if err != nil {
+			return mcp.NewToolResultError("endpoint parameter is required and must be a string"), nil
+		}
+		query, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultError("query parameter is required and must be a string"), nil
+		}
+		operationName := request.GetString("operationName", "")
+		path := request.GetString("path", "")
+		args := request.GetArguments()
+
+		parsedURL, err := url.Parse(endpoint)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return mcp.NewToolResultError("endpoint must be an http:// or https:// URL"), nil
+		}
+
+		// Check the document before sending it
+		operations, err := parseGraphQLOperations(query)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid GraphQL query: %v", err)), nil
+		}
+		operation, err := selectGraphQLOperation(operations, operationName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		switch {
+		case operation.kind == "subscription":
+			return mcp.NewToolResultError("subscriptions are not supported; use a query"), nil
+		case operation.kind == "mutation" && !opts.GraphQLMutations:
+			return mcp.NewToolResultError("mutations are disabled; set graphql_mutations: true in the http builtin options to allow them"), nil
+		}
+
+		payload := map[string]any{"query": query}
+		if variables, ok := args["variables"]; ok && variables != nil {
+			if _, ok := variables.(map[string]any); !ok {
+				return mcp.NewToolResultError("variables must be an object"), nil
+			}
+			payload["variables"] = variables
+		}
+		if operationName != "" {
+			payload["operationName"] = operationName
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode request: %v", err)), nil
+		}
+
+		timeout := httpDefaultFetchTimeout
+		if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
+			timeout = min(time.Duration(timeoutSec)*time.Second, httpMaxFetchTimeout)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create request: %v", err)), nil
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/graphql-response+json, application/json")
+		req.Header.Set("User-Agent", "mcphost")
+		if headers, ok := args["headers"].(map[string]any); ok {
+			for key, value := range headers {
+				req.Header.Set(key, fmt.Sprint(value))
+			}
+		}
+		for key, value := range opts.GraphQLHeaders[parsedURL.Hostname()] {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := httpNewClient(timeout).Do(req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("request failed: %v", err)), nil
+		}
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxResponseSize+1))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read response: %v", err)), nil
+		}
+		if len(respBody) > httpMaxResponseSize {
+			return mcp.NewToolResultError("response too large (exceeds 5MB limit)"), nil
+		}
+
+		return graphqlResult(resp, respBody, path), nil
+	}
+}
+
+// graphqlResult turns a GraphQL response into a tool result: the data,
+// filtered by path, followed by any errors the server reported
+func graphqlResult(resp *http.Response, body []byte, path string) *mcp.CallToolResult {
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+			Path    []any  `json:"path"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || (response.Data == nil && response.Errors == nil) {
+		return mcp.NewToolResultError(fmt.Sprintf("request failed with status code %d: %s", resp.StatusCode, truncateString(string(body), 500)))
+	}
+
+	var errorLines []string
+	for _, e := range response.Errors {
+		line := "- " + e.Message
+		if len(e.Path) > 0 {
+			parts := make([]string, len(e.Path))
+			for i, p := range e.Path {
+				parts[i] = fmt.Sprint(p)
+			}
+			line += fmt.Sprintf(" (at %s)", strings.Join(parts, "."))
+		}
+		errorLines = append(errorLines, line)
+	}
+	errorText := ""
+	if len(errorLines) > 0 {
+		errorText = "GraphQL errors:\n" + strings.Join(errorLines, "\n")
+	}
+
+	data := string(response.Data)
+	if data == "" || data == "null" {
+		if errorText == "" {
+			errorText = fmt.Sprintf("response has no data (status code %d)", resp.StatusCode)
+		}
+		return mcp.NewToolResultError(errorText)
+	}
+	if path != "" {
+		result := gjson.Get(data, path)
+		if !result.Exists() {
+			return mcp.NewToolResultError(strings.TrimSpace(fmt.Sprintf("gjson path '%s' did not match any data\n\n%s", path, errorText)))
+		}
+		data = result.Raw
+	}
+
+	text := data
+	if errorText != "" {
+		text += "\n\n" + errorText
+	}
+	result := mcp.NewToolResultText(text)
+	result.Meta = &mcp.Meta{
+		AdditionalFields: map[string]any{
+			"status":     resp.StatusCode,
+			"errorCount": len(response.Errors),
+		},
+	}
+	return result
+}
+
+// truncateString shortens s to at most n bytes
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// selectGraphQLOperation picks the operation a request runs
+func selectGraphQLOperation(operations []graphqlOperation, name string) (graphqlOperation, error) {
+	if name != "" {
+		for _, op := range operations {
+			if op.name == name {
+				return op, nil
+			}
+		}
+		return graphqlOperation{}, fmt.Errorf("operation %q is not defined in the query", name)
+	}
+	if len(operations) > 1 {
+		return graphqlOperation{}, fmt.Errorf("operationName is required when the query defines several operations")
+	}
+	return operations[0], nil
+}
+
+// parseGraphQLOperations checks that query is a well-formed executable
+// GraphQL document and lists its operations. It checks syntax only: strings,
+// brackets and the top-level definitions, not the schema.
+func parseGraphQLOperations(query string) ([]graphqlOperation, error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []graphqlOperation
+	var stack []string
+	expectDefinition := true // at depth 0, between definitions
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.value {
+		case "{", "(", "[":
+			if tok.kind == gqlPunct {
+				if len(stack) == 0 && tok.value == "{" && expectDefinition {
+					operations = append(operations, graphqlOperation{kind: "query"}) // shorthand query
+				}
+				if len(stack) == 0 && tok.value == "{" {
+					expectDefinition = false
+				}
+				stack = append(stack, tok.value)
+				continue
+			}
+		case "}", ")", "]":
+			if tok.kind == gqlPunct {
+				open := map[string]string{"}": "{", ")": "(", "]": "["}[tok.value]
+				if len(stack) == 0 || stack[len(stack)-1] != open {
+					return nil, fmt.Errorf("unexpected %q at line %d", tok.value, tok.line)
+				}
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 && tok.value == "}" {
+					expectDefinition = true
+				}
+				continue
+			}
+		}
+
+		if len(stack) > 0 || !expectDefinition {
+			continue
+		}
+		if tok.kind != gqlName {
+			return nil, fmt.Errorf("unexpected %q at line %d, expected an operation or fragment", tok.value, tok.line)
+		}
+		switch tok.value {
+		case "query", "mutation", "subscription":
+			op := graphqlOperation{kind: tok.value}
+			if i+1 < len(tokens) && tokens[i+1].kind == gqlName {
+				op.name = tokens[i+1].value
+				i++
+			}
+			operations = append(operations, op)
+		case "fragment":
+		default:
+			return nil, fmt.Errorf("unexpected %q at line %d, expected query, mutation, subscription or fragment", tok.value, tok.line)
+		}
+		expectDefinition = false
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+	if !expectDefinition {
+		return nil, fmt.Errorf("incomplete definition at end of query")
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("no query, mutation or subscription defined")
+	}
+	return operations, nil
+}
+
+// GraphQL token kinds
+const (
+	gqlPunct = iota
+	gqlName
+	gqlValue // numbers and strings
+)
+
+type gqlToken struct {
+	kind  int
+	value string
+	line  int
+}
+
+// lexGraphQL splits a GraphQL document into tokens, dropping whitespace,
+// commas and comments
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case strings.HasPrefix(src[i:], "\uFEFF"):
+			i += len("\uFEFF")
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], `"""`):
+			// Block strings end at the first """ not escaped as \"""
+			j := i + 3
+			for j < len(src) && !strings.HasPrefix(src[j:], `"""`) {
+				if strings.HasPrefix(src[j:], `\"""`) {
+					j += 4
+				} else {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated block string at line %d", line)
+			}
+			value := src[i : j+3]
+			tokens = append(tokens, gqlToken{gqlValue, value, line})
+			line += strings.Count(value, "\n")
+			i = j + 3
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				} else if src[j] == '\n' {
+					break
+				}
+			}
+			if j >= len(src) || src[j] != '"' {
+				return nil, fmt.Errorf("unterminated string at line %d", line)
+			}
+			tokens = append(tokens, gqlToken{gqlValue, src[i : j+1], line})
+			i = j + 1
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlPunct, "...", line})
+			i += 3
+		case strings.ContainsRune("!$&()[]{}:=@|", rune(c)):
+			tokens = append(tokens, gqlToken{gqlPunct, string(c), line})
+			i++
+		case c == '_' || isASCIILetter(c):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || isASCIILetter(src[j]) || isASCIIDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, gqlToken{gqlName, src[i:j], line})
+			i = j
+		case c == '-' || isASCIIDigit(c):
+			j := i + 1
+			for j < len(src) && (isASCIIDigit(src[j]) || strings.IndexByte(".eE+-", src[j]) >= 0) {
+				j++
+			}
+			tokens = append(tokens, gqlToken{gqlValue, src[i:j], line})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at line %d", c, line)
+		}
+	}
+	return tokens, nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+const httpGraphQLDescription = `Runs a GraphQL query against an API endpoint and returns the JSON data.
+
+Usage notes:
+  - Use this for GraphQL-first APIs such as GitHub or Shopify instead of fetch
+  - The query is checked for syntax before it is sent; pass variables separately instead of inlining values
+  - When the query defines several operations, name the one to run with operationName
+  - Use path (gjson syntax) to return only part of the data, e.g. 'repository.issues.nodes.#.title'
+  - GraphQL errors are listed after the data; partial data is still returned
+  - Authentication headers may already be configured for well-known endpoints; pass headers only when the user provides credentials
+  - Mutations are only allowed when the user has enabled them
+  - Responses are limited to 5MB`
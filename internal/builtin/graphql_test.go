@@ -0,0 +1,193 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseGraphQLOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []graphqlOperation
+		wantErr string
+	}{
+		{
+			name:  "shorthand query",
+			query: `{ viewer { login } }`,
+			want:  []graphqlOperation{{kind: "query"}},
+		},
+		{
+			name: "named operations and fragment",
+			query: `# Issues with comments
+query Issues($owner: String!, $first: Int = 10) {
+  repository(owner: $owner, name: "a } b") { ...Repo }
+}
+fragment Repo on Repository { name description(format: """multi
+line""") }
+mutation Close($id: ID!) { closeIssue(input: {issueId: $id}) { clientMutationId } }`,
+			want: []graphqlOperation{{kind: "query", name: "Issues"}, {kind: "mutation", name: "Close"}},
+		},
+		{
+			name:    "unbalanced braces",
+			query:   `query { viewer { login }`,
+			wantErr: `unclosed "{"`,
+		},
+		{
+			name:    "mismatched bracket",
+			query:   `query { viewer(first: 1] }`,
+			wantErr: `unexpected "]" at line 1`,
+		},
+		{
+			name:    "unterminated string",
+			query:   "query {\n  user(login: \"octocat) { id }\n}",
+			wantErr: "unterminated string at line 2",
+		},
+		{
+			name:    "schema definitions",
+			query:   `type Query { viewer: User }`,
+			wantErr: `unexpected "type"`,
+		},
+		{
+			name:    "fragment only",
+			query:   `fragment F on User { id }`,
+			wantErr: "no query, mutation or subscription",
+		},
+		{
+			name:    "invalid character",
+			query:   `query { viewer { login; } }`,
+			wantErr: "unexpected character ';'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGraphQLOperations(tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("operation %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteGraphQLQuery(t *testing.T) {
+	var lastRequest map[string]any
+	var lastAuth string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &lastRequest)
+		lastAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(lastRequest["query"].(string), "broken"):
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"errors":[{"message":"Field 'broken' doesn't exist"}]}`)
+		case strings.Contains(lastRequest["query"].(string), "partial"):
+			io.WriteString(w, `{"data":{"a":1,"b":null},"errors":[{"message":"not allowed","path":["b"]}]}`)
+		default:
+			io.WriteString(w, `{"data":{"repository":{"issues":{"nodes":[{"title":"One"},{"title":"Two"}]}}}}`)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+	hostname, _, _ := strings.Cut(host, ":")
+
+	handler := executeGraphQLQuery(HTTPServerOptions{
+		GraphQLHeaders: map[string]map[string]string{hostname: {"Authorization": "Bearer configured"}},
+	})
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["endpoint"] = testServer.URL
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "graphql_query"
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	result := call(map[string]any{
+		"query":     `query($owner: String!) { repository(owner: $owner) { issues { nodes { title } } } }`,
+		"variables": map[string]any{"owner": "octocat"},
+		"headers":   map[string]any{"Authorization": "Bearer model", "X-Extra": "1"},
+		"path":      "repository.issues.nodes.#.title",
+	})
+	if result.IsError || text(result) != `["One","Two"]` {
+		t.Errorf("expected filtered titles, got %q (error %v)", text(result), result.IsError)
+	}
+	if vars, _ := lastRequest["variables"].(map[string]any); vars["owner"] != "octocat" {
+		t.Errorf("expected variables to be sent, got %v", lastRequest)
+	}
+	if lastAuth != "Bearer configured" {
+		t.Errorf("expected configured header to take precedence, got %q", lastAuth)
+	}
+
+	result = call(map[string]any{"query": `{ partial }`})
+	if result.IsError || !strings.Contains(text(result), `{"a":1,"b":null}`) || !strings.Contains(text(result), "- not allowed (at b)") {
+		t.Errorf("expected partial data with errors, got %q", text(result))
+	}
+
+	result = call(map[string]any{"query": `{ broken }`})
+	if !result.IsError || !strings.Contains(text(result), "Field 'broken' doesn't exist") {
+		t.Errorf("expected GraphQL error, got %q", text(result))
+	}
+
+	requests := lastRequest
+	result = call(map[string]any{"query": `mutation { closeIssue(input: {}) { clientMutationId } }`})
+	if !result.IsError || !strings.Contains(text(result), "mutations are disabled") {
+		t.Errorf("expected mutation to be refused, got %q", text(result))
+	}
+	result = call(map[string]any{"query": `{ viewer { login }`})
+	if !result.IsError || !strings.Contains(text(result), "invalid GraphQL query") {
+		t.Errorf("expected invalid query to be refused, got %q", text(result))
+	}
+	if lastRequest["query"] != requests["query"] {
+		t.Error("expected refused queries not to be sent")
+	}
+}
+
+func TestHTTPServerGraphQLOptions(t *testing.T) {
+	registry := NewRegistry()
+	_, err := registry.CreateServer("http", map[string]any{
+		"graphql_headers": []any{
+			map[string]any{"host": "api.github.com", "headers": []any{"Authorization: Bearer token"}},
+		},
+		"graphql_mutations": true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("expected valid graphql options, got %v", err)
+	}
+
+	_, err = registry.CreateServer("http", map[string]any{
+		"graphql_headers": []any{map[string]any{"host": "api.github.com", "headers": []any{"no colon"}}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Name: value") {
+		t.Errorf("expected malformed header to fail, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/JohannesKaufmann/html-to-markdown"
@@ -16,6 +17,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/osi4iot/mcphost/internal/models"
 	"github.com/tidwall/gjson"
 )
 
@@ -28,10 +30,86 @@ const (
 // httpServerModel holds the model for the HTTP server
 var httpServerModel model.ToolCallingChatModel
 
-// NewHTTPServer creates a new HTTP MCP server
-func NewHTTPServer(llmModel model.ToolCallingChatModel) (*server.MCPServer, error) {
-	// Store the model globally for use in tool handlers
+// httpServerSummarizer holds the dedicated summarizer model, if configured
+var httpServerSummarizer *httpSummarizer
+
+// httpServerTransport enforces the rate limit, cache and robots.txt options,
+// or is nil when none are set
+var httpServerTransport http.RoundTripper
+
+// httpNewClient returns a client for the http tools with the server's options applied
+func httpNewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: httpServerTransport,
+	}
+}
+
+// httpSummarizer creates the model named by the summarizer_model option the
+// first time the summarize or extract tools need it
+type httpSummarizer struct {
+	config *models.ProviderConfig // the summarizer model's config
+	mu     sync.Mutex
+	model  model.ToolCallingChatModel
+}
+
+// newHTTPSummarizer returns the summarizer for modelString, configured like
+// the agent's model when its config is known, or nil when modelString is the
+// agent's model
+func newHTTPSummarizer(modelString string, providerConfig *models.ProviderConfig) *httpSummarizer {
+	if providerConfig == nil {
+		return &httpSummarizer{config: &models.ProviderConfig{ModelString: modelString}}
+	}
+	config := providerConfig.ForModel(modelString)
+	if config == nil {
+		return nil
+	}
+	return &httpSummarizer{config: config}
+}
+
+// get returns the summarizer model, creating it on first use. A failure is
+// not kept, so the next call tries again.
+func (s *httpSummarizer) get(ctx context.Context) (model.ToolCallingChatModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.model != nil {
+		return s.model, nil
+	}
+	result, err := models.CreateProvider(ctx, s.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summarizer model %s: %v", s.config.ModelString, err)
+	}
+	s.model = result.Model
+	return s.model, nil
+}
+
+// httpLLMModel returns the model for the summarize and extract tools: the
+// dedicated summarizer model when configured, otherwise the agent's model
+func httpLLMModel(ctx context.Context) (model.ToolCallingChatModel, error) {
+	if httpServerSummarizer != nil {
+		return httpServerSummarizer.get(ctx)
+	}
+	if httpServerModel == nil {
+		return nil, fmt.Errorf("LLM model not available")
+	}
+	return httpServerModel, nil
+}
+
+// NewHTTPServer creates a new HTTP MCP server. When opts.SummarizerModel is set
+// ("provider:model"), fetch_summarize and fetch_extract use that model instead
+// of llmModel.
+func NewHTTPServer(llmModel model.ToolCallingChatModel, opts HTTPServerOptions) (*server.MCPServer, error) {
+	// Store the models globally for use in tool handlers
 	httpServerModel = llmModel
+	httpServerSummarizer = nil
+	if opts.SummarizerModel != "" {
+		httpServerSummarizer = newHTTPSummarizer(opts.SummarizerModel, opts.ProviderConfig)
+	}
+
+	httpServerTransport = nil
+	if transport := newHTTPPolicyTransport(opts); transport != nil {
+		httpServerTransport = transport
+	}
 
 	s := server.NewMCPServer("http-server", "1.0.0", server.WithToolCapabilities(true))
 
@@ -44,12 +122,22 @@ func NewHTTPServer(llmModel model.ToolCallingChatModel) (*server.MCPServer, erro
 		),
 		mcp.WithString("format",
 			mcp.Required(),
-			mcp.Enum("html", "markdown"),
-			mcp.Description("The format to return the content in (html or markdown)"),
+			mcp.Enum("html", "markdown", "text", "json"),
+			mcp.Description("The format to return the content in (html, markdown, text, or json)"),
 		),
 		mcp.WithBoolean("bodyOnly",
 			mcp.Description("Extract only the <body> tag content (default: false)"),
 		),
+		mcp.WithString("path",
+			mcp.Description("Optional gjson path to filter the response with the json format (e.g., 'data.items.#.name')"),
+		),
+		mcp.WithNumber("maxRows",
+			mcp.Description("Maximum number of rows to show for CSV responses (default: 50)"),
+			mcp.Min(1),
+		),
+		mcp.WithString("pages",
+			mcp.Description("Pages to extract from a PDF, e.g. '1-5' or '2,4-6' (default: all pages)"),
+		),
 		mcp.WithNumber("timeout",
 			mcp.Description("Optional timeout in seconds (max 120)"),
 			mcp.Min(0),
@@ -58,9 +146,10 @@ func NewHTTPServer(llmModel model.ToolCallingChatModel) (*server.MCPServer, erro
 	)
 
 	s.AddTool(fetchTool, executeHTTPFetch)
+	s.AddTool(newGraphQLQueryTool(), executeGraphQLQuery(opts))
 
 	// Only add the summarize tool if we have a model
-	if llmModel != nil {
+	if llmModel != nil || opts.SummarizerModel != "" {
 		summarizeTool := mcp.NewTool("fetch_summarize",
 			mcp.WithDescription(httpSummarizeDescription),
 			mcp.WithString("url",
@@ -122,8 +211,8 @@ func executeHTTPFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	}
 
 	// Validate format
-	if format != "html" && format != "markdown" {
-		return mcp.NewToolResultError("format must be 'html' or 'markdown'"), nil
+	if format != "html" && format != "markdown" && format != "text" && format != "json" {
+		return mcp.NewToolResultError("format must be 'html', 'markdown', 'text', or 'json'"), nil
 	}
 
 	// Get bodyOnly parameter (optional, defaults to false)
@@ -161,9 +250,7 @@ func executeHTTPFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	}
 
 	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	client := httpNewClient(timeout)
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
@@ -173,7 +260,11 @@ func executeHTTPFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 
 	// Set headers to mimic a real browser
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+	if format == "json" {
+		req.Header.Set("Accept", "application/json, text/plain, */*")
+	} else {
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+	}
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
 
 	// Make the request
@@ -211,6 +302,45 @@ func executeHTTPFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		contentType = "unknown"
 	}
 
+	// PDF and Word documents are returned as their text, whatever the format
+	if kind := documentKind(contentType, bodyBytes); kind != "" {
+		text, err := extractDocumentText(kind, bodyBytes, request.GetString("pages", ""))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to extract text from %s: %v", kind, err)), nil
+		}
+		result := mcp.NewToolResultText(text)
+		result.Meta = &mcp.Meta{
+			AdditionalFields: map[string]any{
+				"title":       fmt.Sprintf("%s (%s)", urlStr, contentType),
+				"url":         urlStr,
+				"contentType": contentType,
+				"document":    kind,
+			},
+		}
+		return result, nil
+	}
+
+	// CSV is shown as a row-limited table unless the raw content was asked for
+	if delimiter := csvDelimiter(contentType); delimiter != 0 && format != "html" && format != "json" {
+		maxRows := request.GetInt("maxRows", defaultCSVRows)
+		if maxRows < 1 {
+			maxRows = defaultCSVRows
+		}
+		table, err := formatCSVTable(content, delimiter, maxRows)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result := mcp.NewToolResultText(table)
+		result.Meta = &mcp.Meta{
+			AdditionalFields: map[string]any{
+				"title":       fmt.Sprintf("%s (%s)", urlStr, contentType),
+				"url":         urlStr,
+				"contentType": contentType,
+			},
+		}
+		return result, nil
+	}
+
 	// Extract body content if requested
 	if bodyOnly && strings.Contains(contentType, "text/html") {
 		content, err = extractBodyContent(content)
@@ -235,6 +365,22 @@ func executeHTTPFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 			// Non-HTML content, wrap in code block
 			output = "```\n" + content + "\n```"
 		}
+
+	case "text":
+		if strings.Contains(contentType, "text/html") {
+			output, err = httpExtractTextFromHTML(content)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to extract text from HTML: %v", err)), nil
+			}
+		} else {
+			output = content
+		}
+
+	case "json":
+		output, err = formatJSONContent(bodyBytes, request.GetString("path", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 	}
 
 	// Create result with metadata
@@ -312,8 +458,9 @@ func executeHTTPFetchSummarize(ctx context.Context, request mcp.CallToolRequest)
 	}
 
 	// Check if we have a model available
-	if httpServerModel == nil {
-		return mcp.NewToolResultError("LLM model not available for summarization"), nil
+	llmModel, err := httpLLMModel(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("%v for summarization", err)), nil
 	}
 
 	// Create messages for the LLM
@@ -322,7 +469,7 @@ func executeHTTPFetchSummarize(ctx context.Context, request mcp.CallToolRequest)
 	}
 
 	// Generate summary using the model directly
-	response, err := httpServerModel.Generate(ctx, messages)
+	response, err := llmModel.Generate(ctx, messages)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Summarization failed: %v", err)), nil
 	}
@@ -359,8 +506,9 @@ func executeHTTPFetchExtract(ctx context.Context, request mcp.CallToolRequest) (
 	}
 
 	// Check if we have a model available
-	if httpServerModel == nil {
-		return mcp.NewToolResultError("LLM model not available for extraction"), nil
+	llmModel, err := httpLLMModel(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("%v for extraction", err)), nil
 	}
 
 	// Create extraction prompt
@@ -379,7 +527,7 @@ Please extract only the requested information. If the requested information is n
 	}
 
 	// Generate extraction using the model directly
-	response, err := httpServerModel.Generate(ctx, messages)
+	response, err := llmModel.Generate(ctx, messages)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Extraction failed: %v", err)), nil
 	}
@@ -421,9 +569,7 @@ func httpFetchAndExtractText(ctx context.Context, urlStr string) (string, error)
 	}
 
 	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	client := httpNewClient(timeout)
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
@@ -469,6 +615,9 @@ func httpFetchAndExtractText(ctx context.Context, urlStr string) (string, error)
 	contentType := resp.Header.Get("Content-Type")
 
 	// Extract text content
+	if kind := documentKind(contentType, bodyBytes); kind != "" {
+		return extractDocumentText(kind, bodyBytes, "")
+	}
 	if strings.Contains(contentType, "text/html") {
 		return httpExtractTextFromHTML(content)
 	}
@@ -546,9 +695,7 @@ func executeHTTPFetchFilteredJSON(ctx context.Context, request mcp.CallToolReque
 	}
 
 	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	client := httpNewClient(timeout)
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
@@ -645,10 +792,10 @@ func httpGetTextFromSamplingResult(result *mcp.CreateMessageResult) string {
 	return fmt.Sprintf("%v", result.Content)
 }
 
-const httpFetchDescription = `Performs HTTP GET requests and returns content in HTML or Markdown format.
+const httpFetchDescription = `Performs HTTP GET requests and returns content in HTML, Markdown, text or JSON format.
 
 - Fetches content from a specified URL using HTTP GET
-- Returns content in either original HTML or converted Markdown format
+- Returns content as original HTML, converted Markdown, plain text or pretty-printed JSON
 - Can optionally extract only the <body> tag content to reduce text size
 - Supports custom timeout configuration
 
@@ -656,10 +803,14 @@ Usage notes:
   - The URL must be a fully-formed valid URL
   - Only HTTP GET requests are supported
   - Maximum response size is 5MB
-  - Supports two output formats:
+  - Supports four output formats:
     - "html": Raw HTML content
     - "markdown": HTML converted to markdown format
+    - "text": Plain text extraction from HTML, or raw content for non-HTML
+    - "json": Pretty-printed JSON, optionally filtered with a gjson path (e.g., 'data.items.#.name')
   - Use bodyOnly=true to extract only the <body> tag content (useful for reducing text)
+  - CSV and TSV responses are returned as a markdown table of at most maxRows rows (default 50) in the markdown and text formats
+  - PDF and Word (docx) documents are returned as extracted text in any format; use pages to select PDF pages
   - Timeout can be specified in seconds (default 30s, max 120s)`
 
 const httpSummarizeDescription = `Fetches web content and returns an AI-generated summary using LLM sampling.
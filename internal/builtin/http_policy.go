@@ -0,0 +1,387 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// httpRobotsUserAgent is the product token matched against robots.txt groups
+const httpRobotsUserAgent = "mcphost"
+
+// HTTPServerOptions configures the http builtin server
+type HTTPServerOptions struct {
+	// SummarizerModel ("provider:model") is used by fetch_summarize and
+	// fetch_extract instead of the agent's model
+	SummarizerModel string
+
+	// ProviderConfig is the agent's model config, which the summarizer model
+	// is created from with its credentials and settings (optional)
+	ProviderConfig *models.ProviderConfig
+
+	// RateLimit is the maximum number of requests per second to each host
+	// (0 for no limit)
+	RateLimit float64
+
+	// CacheTTL enables the on-disk response cache: successful GET responses
+	// are reused for this long (0 disables caching)
+	CacheTTL time.Duration
+
+	// CacheDir holds cached responses (default <state dir>/cache/http)
+	CacheDir string
+
+	// RespectRobots refuses URLs disallowed by the host's robots.txt
+	RespectRobots bool
+
+	// GraphQLHeaders are added to graphql_query requests, by endpoint host,
+	// so credentials need not pass through the model
+	GraphQLHeaders map[string]map[string]string
+
+	// GraphQLMutations lets graphql_query run mutations, not just queries
+	GraphQLMutations bool
+}
+
+// httpPolicyTransport applies the rate limit, robots.txt and cache options to
+// every request the http builtin makes
+type httpPolicyTransport struct {
+	base http.RoundTripper
+
+	interval time.Duration // minimum time between requests to one host
+	limitMu  sync.Mutex
+	nextSlot map[string]time.Time
+
+	cacheTTL time.Duration
+	cacheDir string
+
+	respectRobots bool
+	robotsMu      sync.Mutex
+	robots        map[string]*httpRobotsRules // by scheme://host
+}
+
+// newHTTPPolicyTransport returns a transport enforcing opts, or nil when no
+// option needs one
+func newHTTPPolicyTransport(opts HTTPServerOptions) *httpPolicyTransport {
+	if opts.RateLimit <= 0 && opts.CacheTTL <= 0 && !opts.RespectRobots {
+		return nil
+	}
+	t := &httpPolicyTransport{
+		base:          http.DefaultTransport,
+		nextSlot:      make(map[string]time.Time),
+		cacheTTL:      opts.CacheTTL,
+		cacheDir:      opts.CacheDir,
+		respectRobots: opts.RespectRobots,
+		robots:        make(map[string]*httpRobotsRules),
+	}
+	if opts.RateLimit > 0 {
+		t.interval = time.Duration(float64(time.Second) / opts.RateLimit)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *httpPolicyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.respectRobots {
+		rules, err := t.robotsRules(req)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.allowed(req.URL.RequestURI()) {
+			return nil, fmt.Errorf("blocked by robots.txt: %s", req.URL.Redacted())
+		}
+	}
+
+	cacheable := t.cacheTTL > 0 && req.Method == http.MethodGet
+	if cacheable {
+		if resp := t.cached(req); resp != nil {
+			return resp, nil
+		}
+	}
+
+	if err := t.wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !cacheable || resp.StatusCode != http.StatusOK ||
+		strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, err
+	}
+
+	// Buffer the body to store it, leaving oversized responses to the caller's limit
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxResponseSize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) <= httpMaxResponseSize {
+		t.store(req, resp, body)
+	}
+	return resp, nil
+}
+
+// wait blocks until host may be sent another request under the rate limit
+func (t *httpPolicyTransport) wait(ctx context.Context, host string) error {
+	if t.interval <= 0 {
+		return nil
+	}
+
+	t.limitMu.Lock()
+	now := time.Now()
+	slot := t.nextSlot[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	t.nextSlot[host] = slot.Add(t.interval)
+	t.limitMu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// httpCacheEntry is a cached response as stored on disk
+type httpCacheEntry struct {
+	URL      string      `json:"url"`
+	StoredAt time.Time   `json:"stored_at"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+// httpDefaultCacheDir returns the cache directory under the state directory
+func httpDefaultCacheDir() (string, error) {
+	return config.StatePath("cache", "http")
+}
+
+// cachePath returns the file for req's response. The Accept header is part
+// of the key, since fetch_filtered_json asks for JSON where fetch asks for HTML.
+func (t *httpPolicyTransport) cachePath(req *http.Request) (string, error) {
+	dir := t.cacheDir
+	if dir == "" {
+		var err error
+		if dir, err = httpDefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// cached returns the stored response for req, or nil when there is none or it expired
+func (t *httpPolicyTransport) cached(req *http.Request) *http.Response {
+	path, err := t.cachePath(req)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry httpCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.StoredAt) > t.cacheTTL {
+		return nil
+	}
+
+	header := entry.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("X-Mcphost-Cache", "hit")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// store writes a response to the cache. Failures only cost a cache miss later.
+func (t *httpPolicyTransport) store(req *http.Request, resp *http.Response, body []byte) {
+	path, err := t.cachePath(req)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(httpCacheEntry{
+		URL:      req.URL.String(),
+		StoredAt: time.Now(),
+		Header:   resp.Header.Clone(),
+		Body:     body,
+	})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
+
+// robotsRules returns the robots.txt rules for req's host, fetching them once
+func (t *httpPolicyTransport) robotsRules(req *http.Request) (*httpRobotsRules, error) {
+	origin := req.URL.Scheme + "://" + req.URL.Host
+
+	t.robotsMu.Lock()
+	defer t.robotsMu.Unlock()
+	if rules, ok := t.robots[origin]; ok {
+		return rules, nil
+	}
+
+	robotsReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	if err := t.wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(robotsReq)
+	if err != nil {
+		// Not cached, so the next request tries again
+		return nil, fmt.Errorf("failed to fetch robots.txt: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var rules *httpRobotsRules
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		rules = parseRobots(io.LimitReader(resp.Body, 512*1024), httpRobotsUserAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// No robots.txt: everything is allowed
+		rules = &httpRobotsRules{}
+	default:
+		// Server errors mean the site can't say what's allowed; assume nothing is
+		rules = &httpRobotsRules{rules: []httpRobotsRule{{pattern: "/"}}}
+	}
+	t.robots[origin] = rules
+	return rules, nil
+}
+
+// httpRobotsRule is one Allow or Disallow line
+type httpRobotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// httpRobotsRules are the robots.txt rules that apply to our user agent
+type httpRobotsRules struct {
+	rules []httpRobotsRule
+}
+
+// parseRobots returns the rules of the group naming agent, or of the *
+// group when no group does
+func parseRobots(r io.Reader, agent string) *httpRobotsRules {
+	var specific, wildcard []httpRobotsRule
+	var matchesAgent, matchesWildcard, haveSpecific bool
+	inAgents := false // consecutive User-agent lines share a group
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				matchesAgent, matchesWildcard = false, false
+				inAgents = true
+			}
+			name := strings.ToLower(value)
+			if name == "*" {
+				matchesWildcard = true
+			} else if strings.Contains(strings.ToLower(agent), name) {
+				matchesAgent = true
+				haveSpecific = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue // an empty Disallow allows everything
+			}
+			rule := httpRobotsRule{pattern: value, allow: key == "allow"}
+			if matchesAgent {
+				specific = append(specific, rule)
+			}
+			if matchesWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if haveSpecific {
+		return &httpRobotsRules{rules: specific}
+	}
+	return &httpRobotsRules{rules: wildcard}
+}
+
+// allowed reports whether path may be fetched: the longest matching rule
+// decides, and Allow wins a tie
+func (r *httpRobotsRules) allowed(path string) bool {
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allow, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a robots.txt path pattern, where * matches any run of
+// characters and a trailing $ anchors the end
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,128 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestHTTPPolicyCacheAndRobots(t *testing.T) {
+	var pageHits atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\nAllow: /private/ok\n"))
+		default:
+			pageHits.Add(1)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("page " + r.URL.Path))
+		}
+	}))
+	defer site.Close()
+
+	if _, err := NewHTTPServer(nil, HTTPServerOptions{
+		CacheTTL:      time.Hour,
+		CacheDir:      t.TempDir(),
+		RespectRobots: true,
+	}); err != nil {
+		t.Fatalf("Failed to create HTTP server: %v", err)
+	}
+	defer NewHTTPServer(nil, HTTPServerOptions{})
+
+	fetch := func(path string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"url": site.URL + path, "format": "html"}
+		result, err := executeHTTPFetch(context.Background(), request)
+		if err != nil {
+			t.Fatalf("fetch %s failed: %v", path, err)
+		}
+		return result
+	}
+
+	// Repeated fetches of a page are served from the cache
+	for range 3 {
+		if result := fetch("/page"); result.IsError {
+			t.Fatalf("fetch returned an error: %v", result.Content)
+		}
+	}
+	if hits := pageHits.Load(); hits != 1 {
+		t.Errorf("expected 1 request to the site, got %d", hits)
+	}
+
+	// Disallowed paths are refused without being requested
+	result := fetch("/private/page")
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "robots.txt") {
+		t.Errorf("expected robots.txt refusal, got %v", result.Content)
+	}
+	if result := fetch("/private/ok"); result.IsError {
+		t.Errorf("longer Allow rule should win, got %v", result.Content)
+	}
+}
+
+func TestHTTPPolicyRateLimit(t *testing.T) {
+	transport := newHTTPPolicyTransport(HTTPServerOptions{RateLimit: 20})
+
+	start := time.Now()
+	for range 3 {
+		if err := transport.wait(context.Background(), "example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v, expected at least 100ms", elapsed)
+	}
+
+	// Other hosts have their own budget
+	start = time.Now()
+	if err := transport.wait(context.Background(), "other.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: mcphost
+User-agent: otherbot
+Disallow: /search
+Allow: /search/about
+Disallow: /*.pdf$
+
+User-agent: *
+Disallow: /
+`
+	rules := parseRobots(strings.NewReader(robots), httpRobotsUserAgent)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/search?q=go", false},
+		{"/search/about", true},
+		{"/docs/file.pdf", false},
+		{"/docs/file.pdf.html", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	// Without a group of its own the * group applies
+	rules = parseRobots(strings.NewReader("User-agent: *\nDisallow: /tmp/\n"), httpRobotsUserAgent)
+	if rules.allowed("/tmp/x") || !rules.allowed("/home") {
+		t.Error("wildcard group not applied")
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/osi4iot/mcphost/internal/models"
 )
 
 func TestNewHTTPServer(t *testing.T) {
-	server, err := NewHTTPServer(nil)
+	server, err := NewHTTPServer(nil, HTTPServerOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create HTTP server: %v", err)
 	}
@@ -70,6 +72,12 @@ func TestExecuteHTTPFetch(t *testing.T) {
 		case "/text":
 			w.Header().Set("Content-Type", "text/plain")
 			w.Write([]byte("This is plain text content"))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"users":[{"name":"Ada","age":36},{"name":"Linus","age":28}]}`))
+		case "/csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Write([]byte("name,role\nAda,admin\nLinus,dev\nGrace,ops\n"))
 		case "/large":
 			// Return content larger than 5MB
 			w.Header().Set("Content-Type", "text/plain")
@@ -162,6 +170,72 @@ func TestExecuteHTTPFetch(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "fetch HTML as text",
+			params: map[string]any{
+				"url":    testServer.URL + "/html",
+				"format": "text",
+			},
+			expectError: false,
+			checkResult: func(t *testing.T, result *mcp.CallToolResult) {
+				text := result.Content[0].(mcp.TextContent).Text
+				if strings.Contains(text, "<h1>") || !strings.Contains(text, "Hello World") {
+					t.Errorf("Expected plain text without tags, got %q", text)
+				}
+			},
+		},
+		{
+			name: "fetch JSON pretty-printed",
+			params: map[string]any{
+				"url":    testServer.URL + "/json",
+				"format": "json",
+			},
+			expectError: false,
+			checkResult: func(t *testing.T, result *mcp.CallToolResult) {
+				text := result.Content[0].(mcp.TextContent).Text
+				if !strings.Contains(text, "\n  \"users\": [") {
+					t.Errorf("Expected indented JSON, got %q", text)
+				}
+			},
+		},
+		{
+			name: "fetch JSON with gjson path",
+			params: map[string]any{
+				"url":    testServer.URL + "/json",
+				"format": "json",
+				"path":   "users.#.name",
+			},
+			expectError: false,
+			checkResult: func(t *testing.T, result *mcp.CallToolResult) {
+				if text := result.Content[0].(mcp.TextContent).Text; text != "[\n  \"Ada\",\n  \"Linus\"\n]" {
+					t.Errorf("Unexpected filtered JSON %q", text)
+				}
+			},
+		},
+		{
+			name: "fetch non-JSON as json",
+			params: map[string]any{
+				"url":    testServer.URL + "/text",
+				"format": "json",
+			},
+			expectError: true,
+		},
+		{
+			name: "fetch CSV as table",
+			params: map[string]any{
+				"url":     testServer.URL + "/csv",
+				"format":  "markdown",
+				"maxRows": 2,
+			},
+			expectError: false,
+			checkResult: func(t *testing.T, result *mcp.CallToolResult) {
+				text := result.Content[0].(mcp.TextContent).Text
+				want := "| name | role |\n| --- | --- |\n| Ada | admin |\n| Linus | dev |\n\n(showing 2 of 3 rows)\n"
+				if text != want {
+					t.Errorf("Unexpected CSV table %q", text)
+				}
+			},
+		},
 		{
 			name: "missing URL parameter",
 			params: map[string]any{
@@ -305,3 +379,48 @@ func TestExtractBodyContent(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPSummarizerModel(t *testing.T) {
+	registry := NewRegistry()
+	if _, err := registry.CreateServer("http", map[string]any{"summarizer_model": 42}, nil); err == nil {
+		t.Error("expected an error for a non-string summarizer_model")
+	}
+
+	// Without any model the summarize and extract tools have nothing to use
+	if _, err := NewHTTPServer(nil, HTTPServerOptions{}); err != nil {
+		t.Fatalf("Failed to create HTTP server: %v", err)
+	}
+	if _, err := httpLLMModel(context.Background()); err == nil {
+		t.Error("expected an error when no model is available")
+	}
+
+	// The summarizer is only created when first needed, and failures are reported then
+	if _, err := NewHTTPServer(nil, HTTPServerOptions{SummarizerModel: "bogus:model"}); err != nil {
+		t.Fatalf("Failed to create HTTP server: %v", err)
+	}
+	_, err := httpLLMModel(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "bogus:model") {
+		t.Errorf("expected summarizer creation error naming the model, got %v", err)
+	}
+}
+
+func TestHTTPSummarizerRetriesWithAgentConfig(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	main := &models.ProviderConfig{ModelString: "ollama:qwen3", ProviderURL: "http://localhost:11434", MaxTokens: 512}
+	if newHTTPSummarizer("ollama:qwen3", main) != nil {
+		t.Error("expected the agent's own model to need no summarizer")
+	}
+
+	summarizer := newHTTPSummarizer("openai:gpt-4o-mini", main)
+	if summarizer.config.MaxTokens != 512 || summarizer.config.ProviderURL != "" {
+		t.Errorf("expected the agent's settings without its provider URL, got %+v", summarizer.config)
+	}
+	if _, err := summarizer.get(context.Background()); err == nil {
+		t.Fatal("expected creating the summarizer without an API key to fail")
+	}
+	// A failure is not kept: once the key is there the next call succeeds
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	if model, err := summarizer.get(context.Background()); err != nil || model == nil {
+		t.Errorf("expected the summarizer to be created on retry, got %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"google.golang.org/genai"
+)
+
+const (
+	imageDefaultOpenAIModel = "gpt-image-1"
+	imageDefaultGoogleModel = "gemini-2.5-flash-image-preview"
+	imageDefaultOpenAIURL   = "https://api.openai.com/v1"
+	imageGenerateTimeout    = 180 * time.Second
+	imageThumbnailSize      = 256 // longest side of the returned thumbnail, in pixels
+	imageMaxResponseSize    = 50 * 1024 * 1024
+)
+
+// ImageServerOptions configures the image builtin server
+type ImageServerOptions struct {
+	// Provider is "openai" or "google". Empty picks the first provider with
+	// an API key in the environment.
+	Provider string
+
+	// Model overrides the provider's default image model
+	Model string
+
+	// OutputDir receives the generated images (default <state dir>/images)
+	OutputDir string
+
+	// BaseURL overrides the provider's API endpoint, e.g. for a proxy
+	BaseURL string
+}
+
+// imageServerOptions holds the options of the running image server
+var imageServerOptions ImageServerOptions
+
+// NewImageServer creates a new image generation MCP server
+func NewImageServer(opts ImageServerOptions) (*server.MCPServer, error) {
+	if opts.Provider != "" && opts.Provider != "openai" && opts.Provider != "google" {
+		return nil, fmt.Errorf("unsupported image provider %q (use openai or google)", opts.Provider)
+	}
+	imageServerOptions = opts
+
+	s := server.NewMCPServer("image-server", "1.0.0", server.WithToolCapabilities(true))
+
+	generateTool := mcp.NewTool("generate_image",
+		mcp.WithDescription(imageGenerateDescription),
+		mcp.WithString("prompt",
+			mcp.Required(),
+			mcp.Description("A detailed description of the image to generate"),
+		),
+		mcp.WithString("size",
+			mcp.Description("Image size for OpenAI models, e.g. '1024x1024', '1536x1024' or '1024x1536' (default: provider default)"),
+		),
+		mcp.WithString("filename",
+			mcp.Description("Optional file name for the image within the output directory (default: generated from the time)"),
+		),
+	)
+
+	s.AddTool(generateTool, executeGenerateImage)
+
+	return s, nil
+}
+
+// executeGenerateImage handles the generate_image tool execution
+func executeGenerateImage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	prompt, err := request.RequireString("prompt")
+	if err != nil || strings.TrimSpace(prompt) == "" {
+		return mcp.NewToolResultError("prompt parameter is required and must be a non-empty string"), nil
+	}
+
+	provider, apiKey, err := imageProviderCredentials(imageServerOptions.Provider)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, imageGenerateTimeout)
+	defer cancel()
+
+	var data []byte
+	var mimeType string
+	switch provider {
+	case "openai":
+		data, mimeType, err = imageGenerateOpenAI(ctx, apiKey, prompt, request.GetString("size", ""))
+	case "google":
+		data, mimeType, err = imageGenerateGoogle(ctx, apiKey, prompt)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("image generation failed: %v", err)), nil
+	}
+
+	path, err := imageSave(data, mimeType, request.GetString("filename", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save image: %v", err)), nil
+	}
+
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf("Image saved to %s", path)),
+		},
+	}
+	// The full image can be large, so only a thumbnail goes back to the model
+	if thumbnail, err := imageThumbnail(data, imageThumbnailSize); err == nil {
+		result.Content = append(result.Content,
+			mcp.NewImageContent(base64.StdEncoding.EncodeToString(thumbnail), "image/png"))
+	}
+	result.Meta = &mcp.Meta{
+		AdditionalFields: map[string]any{
+			"title":    fmt.Sprintf("Generated image (%s)", provider),
+			"path":     path,
+			"provider": provider,
+			"mimeType": mimeType,
+		},
+	}
+	return result, nil
+}
+
+// imageProviderCredentials returns the provider to use and its API key, read
+// from the same environment variables as the chat providers
+func imageProviderCredentials(provider string) (string, string, error) {
+	candidates := []string{"openai", "google"}
+	if provider != "" {
+		candidates = []string{provider}
+	}
+
+	registry := models.GetGlobalRegistry()
+	var tried []string
+	for _, candidate := range candidates {
+		envVars, err := registry.GetRequiredEnvVars(candidate)
+		if err != nil {
+			return "", "", err
+		}
+		for _, envVar := range envVars {
+			if key := os.Getenv(envVar); key != "" {
+				return candidate, key, nil
+			}
+		}
+		tried = append(tried, envVars...)
+	}
+	return "", "", fmt.Errorf("no image generation API key found; set one of %s", strings.Join(tried, ", "))
+}
+
+// imageGenerateOpenAI calls the OpenAI Images API
+func imageGenerateOpenAI(ctx context.Context, apiKey, prompt, size string) ([]byte, string, error) {
+	model := imageServerOptions.Model
+	if model == "" {
+		model = imageDefaultOpenAIModel
+	}
+	baseURL := imageServerOptions.BaseURL
+	if baseURL == "" {
+		baseURL = imageDefaultOpenAIURL
+	}
+
+	body := map[string]any{
+		"model":  model,
+		"prompt": prompt,
+		"n":      1,
+	}
+	if size != "" {
+		body["size"] = size
+	}
+	// DALL-E models return URLs unless asked for data; gpt-image models always return data
+	if strings.HasPrefix(model, "dall-e") {
+		body["response_format"] = "b64_json"
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/images/generations", bytes.NewReader(payload))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, imageMaxResponseSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var parsed struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, "", fmt.Errorf("unexpected response (status %d): %v", resp.StatusCode, err)
+	}
+	if parsed.Error != nil {
+		return nil, "", fmt.Errorf("%s", parsed.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+	if len(parsed.Data) == 0 || parsed.Data[0].B64JSON == "" {
+		return nil, "", fmt.Errorf("response contained no image")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parsed.Data[0].B64JSON)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid image data: %v", err)
+	}
+	return data, http.DetectContentType(data), nil
+}
+
+// imageGenerateGoogle asks a Gemini image model for an image response
+func imageGenerateGoogle(ctx context.Context, apiKey, prompt string) ([]byte, string, error) {
+	model := imageServerOptions.Model
+	if model == "" {
+		model = imageDefaultGoogleModel
+	}
+
+	clientConfig := &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	}
+	if imageServerOptions.BaseURL != "" {
+		clientConfig.HTTPOptions.BaseURL = imageServerOptions.BaseURL
+	}
+	client, err := genai.NewClient(ctx, clientConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Google client: %v", err)
+	}
+
+	resp, err := client.Models.GenerateContent(ctx, model, genai.Text(prompt), &genai.GenerateContentConfig{
+		ResponseModalities: []string{string(genai.ModalityText), string(genai.ModalityImage)},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	for _, candidate := range resp.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+				return part.InlineData.Data, part.InlineData.MIMEType, nil
+			}
+		}
+	}
+
+	// Models sometimes answer in text instead, e.g. when refusing a prompt
+	if text := strings.TrimSpace(resp.Text()); text != "" {
+		return nil, "", fmt.Errorf("model returned no image: %s", text)
+	}
+	return nil, "", fmt.Errorf("model returned no image")
+}
+
+// imageSave writes data to the output directory and returns its path
+func imageSave(data []byte, mimeType, filename string) (string, error) {
+	dir := imageServerOptions.OutputDir
+	if dir == "" {
+		var err error
+		if dir, err = config.StatePath("images"); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	ext := ".png"
+	switch mimeType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	case "image/gif":
+		ext = ".gif"
+	}
+
+	// Names are confined to the output directory
+	name := filepath.Base(filename)
+	if filename == "" || name == "." || name == string(filepath.Separator) {
+		name = "image-" + time.Now().Format("20060102-150405")
+	}
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+
+	// Never overwrite an earlier image
+	base, suffix := strings.TrimSuffix(name, filepath.Ext(name)), filepath.Ext(name)
+	path := filepath.Join(dir, name)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, i, suffix))
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// imageThumbnail returns a PNG of data scaled down so its longest side is at
+// most maxSide pixels
+func imageThumbnail(data []byte, maxSide int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+	scale := float64(maxSide) / float64(max(width, height))
+	if scale > 1 {
+		scale = 1
+	}
+	dstW, dstH := max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+
+	// Average the source pixels covered by each thumbnail pixel
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := range dstH {
+		y0, y1 := bounds.Min.Y+y*height/dstH, bounds.Min.Y+(y+1)*height/dstH
+		for x := range dstW {
+			x0, x1 := bounds.Min.X+x*width/dstW, bounds.Min.X+(x+1)*width/dstW
+			var r, g, b, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const imageGenerateDescription = `Generates an image from a text prompt and saves it to disk.
+
+- Uses OpenAI Images (gpt-image-1 by default) or Gemini image generation, whichever has an API key configured
+- Saves the full-size image to the configured output directory
+- Returns the saved file path and a small thumbnail of the image
+
+Usage notes:
+  - Write detailed prompts describing subject, style, composition and colors
+  - size is only used by OpenAI models
+  - filename is optional; existing files are never overwritten
+  - Generation can take up to a few minutes`
@@ -0,0 +1,142 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testPNG builds a solid-color PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{200, 40, 40, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageServerRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	if _, err := registry.CreateServer("image", map[string]any{}, nil); err != nil {
+		t.Fatalf("Failed to create image server through registry: %v", err)
+	}
+	if _, err := registry.CreateServer("image", map[string]any{"provider": "midjourney"}, nil); err == nil {
+		t.Error("expected an error for an unsupported provider")
+	}
+	if _, err := registry.CreateServer("image", map[string]any{"output_dir": 5}, nil); err == nil {
+		t.Error("expected an error for a non-string output_dir")
+	}
+}
+
+func TestGenerateImage(t *testing.T) {
+	imageData := testPNG(t, 600, 300)
+	encoded := base64.StdEncoding.EncodeToString(imageData)
+
+	var gotAuth string
+	var gotBody map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/images/generations":
+			gotAuth = r.Header.Get("Authorization")
+			json.NewDecoder(r.Body).Decode(&gotBody)
+			w.Write([]byte(`{"data":[{"b64_json":"` + encoded + `"}]}`))
+		case strings.HasSuffix(r.URL.Path, ":generateContent"):
+			w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Here you go"},{"inlineData":{"mimeType":"image/png","data":"` + encoded + `"}}]}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("GOOGLE_API_KEY", "google-test")
+	defer NewImageServer(ImageServerOptions{})
+
+	generate := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := executeGenerateImage(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.IsError {
+			t.Fatalf("generate_image failed: %v", result.Content)
+		}
+		return result
+	}
+
+	for _, provider := range []string{"openai", "google"} {
+		t.Run(provider, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := NewImageServer(ImageServerOptions{Provider: provider, OutputDir: dir, BaseURL: api.URL}); err != nil {
+				t.Fatal(err)
+			}
+
+			result := generate(map[string]any{"prompt": "a red square", "size": "1024x1024", "filename": "../square"})
+			path := result.Meta.AdditionalFields["path"].(string)
+			if path != filepath.Join(dir, "square.png") {
+				t.Errorf("image saved to %s, expected it in %s", path, dir)
+			}
+			if saved, err := os.ReadFile(path); err != nil || !bytes.Equal(saved, imageData) {
+				t.Errorf("saved image differs from the generated one: %v", err)
+			}
+
+			if len(result.Content) != 2 {
+				t.Fatalf("expected text and thumbnail content, got %d items", len(result.Content))
+			}
+			thumb, _ := base64.StdEncoding.DecodeString(result.Content[1].(mcp.ImageContent).Data)
+			config, err := png.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil || config.Width != imageThumbnailSize || config.Height != imageThumbnailSize/2 {
+				t.Errorf("unexpected thumbnail %+v: %v", config, err)
+			}
+
+			// A second image with the same name does not overwrite the first
+			result = generate(map[string]any{"prompt": "another", "filename": "square.png"})
+			if path := result.Meta.AdditionalFields["path"].(string); path != filepath.Join(dir, "square-2.png") {
+				t.Errorf("expected a new file name, got %s", path)
+			}
+		})
+	}
+
+	if gotAuth != "Bearer sk-test" || gotBody["model"] != imageDefaultOpenAIModel || gotBody["size"] != "1024x1024" {
+		t.Errorf("unexpected OpenAI request: auth %q body %v", gotAuth, gotBody)
+	}
+}
+
+func TestImageProviderCredentials(t *testing.T) {
+	for _, env := range []string{"OPENAI_API_KEY", "GOOGLE_API_KEY", "GEMINI_API_KEY", "GOOGLE_GENERATIVE_AI_API_KEY"} {
+		t.Setenv(env, "")
+	}
+	if _, _, err := imageProviderCredentials(""); err == nil {
+		t.Error("expected an error without any API key")
+	}
+
+	t.Setenv("GEMINI_API_KEY", "gemini-key")
+	provider, key, err := imageProviderCredentials("")
+	if err != nil || provider != "google" || key != "gemini-key" {
+		t.Errorf("got %s, %s, %v", provider, key, err)
+	}
+	if _, _, err := imageProviderCredentials("openai"); err == nil {
+		t.Error("expected an error when the chosen provider has no key")
+	}
+}
@@ -0,0 +1,55 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// NewMemoryServer creates a new MCP server that remembers user preferences
+// across sessions in the state directory's memory.md
+func NewMemoryServer() (*server.MCPServer, error) {
+	s := server.NewMCPServer("memory-server", "1.0.0", server.WithToolCapabilities(true))
+
+	rememberTool := mcp.NewTool("remember_preference",
+		mcp.WithDescription(memoryRememberDescription),
+		mcp.WithString("preference",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("The preference or fact as one short, self-contained sentence (at most %d characters)", config.MaxMemoryEntryLength)),
+		),
+	)
+
+	s.AddTool(rememberTool, executeRememberPreference)
+
+	return s, nil
+}
+
+// executeRememberPreference handles the remember_preference tool execution
+func executeRememberPreference(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	preference, err := request.RequireString("preference")
+	if err != nil {
+		return mcp.NewToolResultError("preference parameter is required and must be a string"), nil
+	}
+
+	added, err := config.AppendMemory(preference)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to remember preference: %v", err)), nil
+	}
+	if !added {
+		return mcp.NewToolResultText("This preference is already remembered."), nil
+	}
+	return mcp.NewToolResultText("Preference remembered. It will be included in future sessions."), nil
+}
+
+const memoryRememberDescription = `Stores a durable fact about the user or their preferences so it is available in all future sessions.
+
+Use this when the user states a lasting preference or asks you to remember something, for example:
+  - "I prefer metric units"
+  - "Always answer in Spanish"
+  - "My main project is a Go monorepo using Bazel"
+
+Do NOT use this for details of the current task, temporary state, or secrets such as passwords and API keys. Remembered preferences are added to the system prompt of every new session; the user can review them with /memory view.`
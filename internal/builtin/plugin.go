@@ -0,0 +1,262 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// Plugin handshake. mcphost runs a plugin executable with PluginInfoFlag and
+// reads a PluginInfo manifest from its stdout. To serve, it runs the
+// executable without arguments and speaks MCP over stdio, passing the server's
+// options as JSON in PluginOptionsEnv.
+const (
+	PluginInfoFlag   = "--mcphost-plugin-info"
+	PluginOptionsEnv = "MCPHOST_PLUGIN_OPTIONS"
+	PluginProtocol   = 1
+)
+
+// PluginDirEnv overrides the directory plugins are loaded from
+const PluginDirEnv = "MCPHOST_PLUGIN_DIR"
+
+// pluginInfoTimeout bounds how long a plugin may take to print its manifest
+const pluginInfoTimeout = 5 * time.Second
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// PluginInfo is the manifest a plugin prints when run with PluginInfoFlag
+type PluginInfo struct {
+	Protocol    int            `json:"protocol"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Version     string         `json:"version,omitempty"`
+	Options     *PluginOptions `json:"options,omitempty"`
+}
+
+// PluginOptions is the JSON Schema of a plugin's options object; only
+// properties and required are checked
+type PluginOptions struct {
+	Properties map[string]PluginOption `json:"properties,omitempty"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+// PluginOption is one property of PluginOptions
+type PluginOption struct {
+	Type        string `json:"type,omitempty"` // string, number, integer, boolean, array or object
+	Description string `json:"description,omitempty"`
+}
+
+// Plugin is an executable in the plugin directory that completed the handshake
+type Plugin struct {
+	PluginInfo
+	Path string `json:"path"`
+}
+
+// PluginDir returns the directory plugins are loaded from: $MCPHOST_PLUGIN_DIR,
+// or plugins/ in the state directory
+func PluginDir() (string, error) {
+	if dir := os.Getenv(PluginDirEnv); dir != "" {
+		return dir, nil
+	}
+	return config.StatePath("plugins")
+}
+
+var (
+	pluginsMu    sync.Mutex
+	pluginsCache = make(map[string]pluginScan) // by directory
+)
+
+// pluginScan is the result of scanning a plugin directory
+type pluginScan struct {
+	plugins []Plugin
+	errs    []error
+}
+
+// LoadPlugins returns the plugins in PluginDir and the errors of executables
+// that failed the handshake. Directories are scanned once per process.
+func LoadPlugins() ([]Plugin, []error) {
+	dir, err := PluginDir()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	scan, ok := pluginsCache[dir]
+	if !ok {
+		scan.plugins, scan.errs = DiscoverPlugins(dir)
+		pluginsCache[dir] = scan
+	}
+	return scan.plugins, scan.errs
+}
+
+// DiscoverPlugins runs the handshake with every executable in dir. A missing
+// directory has no plugins.
+func DiscoverPlugins(dir string) ([]Plugin, []error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, []error{fmt.Errorf("failed to read plugin directory: %v", err)}
+	}
+
+	var plugins []Plugin
+	var errs []error
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !isExecutable(path) {
+			continue
+		}
+		info, err := readPluginInfo(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %v", entry.Name(), err))
+			continue
+		}
+		if other, dup := seen[info.Name]; dup {
+			errs = append(errs, fmt.Errorf("plugin %s: name %q is already used by %s", entry.Name(), info.Name, other))
+			continue
+		}
+		seen[info.Name] = entry.Name()
+		plugins = append(plugins, Plugin{PluginInfo: *info, Path: path})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, errs
+}
+
+// isExecutable reports whether path is a regular file the user may run
+func isExecutable(path string) bool {
+	stat, err := os.Stat(path)
+	if err != nil || !stat.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return stat.Mode().Perm()&0o111 != 0
+}
+
+// readPluginInfo runs the handshake with the executable at path
+func readPluginInfo(path string) (*PluginInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginInfoTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, PluginInfoFlag)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("no manifest after %s", pluginInfoTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var info PluginInfo
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if info.Protocol != PluginProtocol {
+		return nil, fmt.Errorf("unsupported protocol %d (this mcphost speaks %d)", info.Protocol, PluginProtocol)
+	}
+	if !pluginNamePattern.MatchString(info.Name) {
+		return nil, fmt.Errorf("invalid name %q (use lowercase letters, digits, - and _)", info.Name)
+	}
+	return &info, nil
+}
+
+// validate checks options against the schema
+func (o *PluginOptions) validate(options map[string]any) error {
+	if o == nil {
+		return nil
+	}
+	for _, name := range o.Required {
+		if _, ok := options[name]; !ok {
+			return fmt.Errorf("option %s is required", name)
+		}
+	}
+	for name, value := range options {
+		prop, ok := o.Properties[name]
+		if !ok || prop.Type == "" {
+			continue
+		}
+		if !matchesJSONType(value, prop.Type) {
+			return fmt.Errorf("option %s must be of type %s", name, prop.Type)
+		}
+	}
+	return nil
+}
+
+// matchesJSONType reports whether a decoded config value has the JSON Schema type
+func matchesJSONType(value any, jsonType string) bool {
+	switch jsonType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number", "integer":
+		switch v := value.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return jsonType == "number" || v == float64(int64(v))
+		}
+		return false
+	case "array":
+		switch value.(type) {
+		case []any, []string:
+			return true
+		}
+		return false
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+// registerPlugins registers every plugin that does not shadow a builtin server
+func (r *Registry) registerPlugins() {
+	plugins, _ := LoadPlugins()
+	for _, plugin := range plugins {
+		if _, exists := r.servers[plugin.Name]; exists {
+			continue
+		}
+		r.plugins[plugin.Name] = plugin
+		r.servers[plugin.Name] = func(options map[string]any, _ model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+			if err := plugin.Options.validate(options); err != nil {
+				return nil, fmt.Errorf("plugin %s: %v", plugin.Name, err)
+			}
+			if options == nil {
+				options = map[string]any{}
+			}
+			encoded, err := json.Marshal(options)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %s: invalid options: %v", plugin.Name, err)
+			}
+			return &BuiltinServerWrapper{
+				command: plugin.Path,
+				env:     []string{PluginOptionsEnv + "=" + string(encoded)},
+			}, nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a shell script plugin that prints manifest for the handshake
+func writePlugin(t *testing.T, dir, file, manifest string) string {
+	t.Helper()
+	path := filepath.Join(dir, file)
+	script := "#!/bin/sh\nif [ \"$1\" = \"" + PluginInfoFlag + "\" ]; then\ncat <<'EOF'\n" + manifest + "\nEOF\nfi\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins")
+	}
+	dir := t.TempDir()
+	weather := writePlugin(t, dir, "weather", `{"protocol": 1, "name": "weather", "description": "Forecasts",
+		"options": {"properties": {"api_key": {"type": "string"}, "days": {"type": "integer"}}, "required": ["api_key"]}}`)
+	writePlugin(t, dir, "weather-copy", `{"protocol": 1, "name": "weather"}`)
+	writePlugin(t, dir, "future", `{"protocol": 2, "name": "future"}`)
+	writePlugin(t, dir, "shouty", `{"protocol": 1, "name": "Shouty"}`)
+	writePlugin(t, dir, "broken", `not json`)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0o644)
+
+	plugins, errs := DiscoverPlugins(dir)
+	if len(plugins) != 1 || plugins[0].Name != "weather" || plugins[0].Path != weather || plugins[0].Description != "Forecasts" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{"already used by weather", "unsupported protocol 2", `invalid name "Shouty"`, "plugin broken: invalid manifest"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing error %q in:\n%s", want, joined)
+		}
+	}
+
+	if plugins, errs := DiscoverPlugins(filepath.Join(dir, "missing")); plugins != nil || errs != nil {
+		t.Errorf("expected a missing directory to have no plugins, got %v %v", plugins, errs)
+	}
+}
+
+func TestRegistryPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins")
+	}
+	dir := t.TempDir()
+	t.Setenv(PluginDirEnv, dir)
+	weather := writePlugin(t, dir, "weather", `{"protocol": 1, "name": "weather",
+		"options": {"properties": {"api_key": {"type": "string"}, "days": {"type": "integer"}}, "required": ["api_key"]}}`)
+	writePlugin(t, dir, "fake-bash", `{"protocol": 1, "name": "bash"}`)
+
+	registry := NewRegistry()
+	if _, ok := registry.Plugin("weather"); !ok {
+		t.Fatal("expected weather plugin to be registered")
+	}
+	if _, ok := registry.Plugin("bash"); ok {
+		t.Error("plugins must not replace builtin servers")
+	}
+
+	wrapper, err := registry.CreateServer("weather", map[string]any{"api_key": "k", "days": float64(3)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command, env := wrapper.Command()
+	if command != weather || len(env) != 1 || env[0] != PluginOptionsEnv+`={"api_key":"k","days":3}` || wrapper.GetServer() != nil {
+		t.Errorf("unexpected plugin command %q %v", command, env)
+	}
+
+	for _, options := range []map[string]any{
+		nil,
+		{"api_key": 42},
+		{"api_key": "k", "days": 1.5},
+	} {
+		if _, err := registry.CreateServer("weather", options, nil); err == nil {
+			t.Errorf("expected options %v to be rejected", options)
+		}
+	}
+
+	// Builtin servers still run in-process
+	if wrapper, err := registry.CreateServer("bash", nil, nil); err != nil || wrapper.GetServer() == nil {
+		t.Errorf("expected in-process bash server, got %v", err)
+	}
+}
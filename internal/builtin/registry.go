@@ -3,15 +3,23 @@ package builtin
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/mark3labs/mcp-filesystem-server/filesystemserver"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/osi4iot/mcphost/internal/models"
 )
 
 // BuiltinServerWrapper wraps an external MCP server for builtin use
 type BuiltinServerWrapper struct {
 	server *server.MCPServer
+
+	// Plugins run as a separate process instead of in-process
+	command string
+	env     []string
 }
 
 // Initialize initializes the wrapped server
@@ -20,20 +28,30 @@ func (w *BuiltinServerWrapper) Initialize() error {
 	return nil
 }
 
-// GetServer returns the wrapped MCP server
+// GetServer returns the wrapped MCP server, or nil for plugins
 func (w *BuiltinServerWrapper) GetServer() *server.MCPServer {
 	return w.server
 }
 
+// Command returns the executable and extra environment of a plugin server,
+// which speaks MCP over stdio. It is empty for in-process servers.
+func (w *BuiltinServerWrapper) Command() (string, []string) {
+	return w.command, w.env
+}
+
 // Registry holds all available builtin servers
 type Registry struct {
 	servers map[string]func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error)
+	plugins map[string]Plugin
+
+	providerConfig *models.ProviderConfig // the agent's model config, or nil
 }
 
 // NewRegistry creates a new builtin server registry
 func NewRegistry() *Registry {
 	r := &Registry{
 		servers: make(map[string]func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error)),
+		plugins: make(map[string]Plugin),
 	}
 
 	// Register builtin servers
@@ -42,10 +60,25 @@ func NewRegistry() *Registry {
 	r.registerTodoServer()
 	r.registerFetchServer()
 	r.registerHTTPServer()
+	r.registerImageServer()
+	r.registerCommandServer()
+	r.registerDataServer()
+	r.registerUtilsServer()
+	r.registerMemoryServer()
+	r.registerScratchpadServer()
+
+	// Plugins come last so they cannot replace a builtin server
+	r.registerPlugins()
 
 	return r
 }
 
+// SetProviderConfig sets the agent's model config, which servers creating
+// models of their own start from
+func (r *Registry) SetProviderConfig(config *models.ProviderConfig) {
+	r.providerConfig = config
+}
+
 // CreateServer creates a new instance of a builtin server
 func (r *Registry) CreateServer(name string, options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
 	factory, exists := r.servers[name]
@@ -65,36 +98,18 @@ func (r *Registry) ListServers() []string {
 	return names
 }
 
+// Plugin returns the plugin registered under name, if the server is one
+func (r *Registry) Plugin(name string) (Plugin, bool) {
+	plugin, ok := r.plugins[name]
+	return plugin, ok
+}
+
 // registerFilesystemServer registers the filesystem server
 func (r *Registry) registerFilesystemServer() {
 	r.servers["fs"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
-		// Extract allowed directories from options
-		var allowedDirs []string
-		if dirs, ok := options["allowed_directories"]; ok {
-			switch v := dirs.(type) {
-			case []string:
-				allowedDirs = v
-			case []any:
-				allowedDirs = make([]string, len(v))
-				for i, dir := range v {
-					if s, ok := dir.(string); ok {
-						allowedDirs[i] = s
-					} else {
-						return nil, fmt.Errorf("allowed_directories must be an array of strings")
-					}
-				}
-			case string:
-				allowedDirs = []string{v}
-			default:
-				return nil, fmt.Errorf("allowed_directories must be a string or array of strings")
-			}
-		} else {
-			// Default to current working directory if no directories specified
-			cwd, err := os.Getwd()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get current working directory: %v", err)
-			}
-			allowedDirs = []string{cwd}
+		allowedDirs, err := FilesystemDirectories(options)
+		if err != nil {
+			return nil, err
 		}
 
 		// Create the filesystem server
@@ -107,6 +122,48 @@ func (r *Registry) registerFilesystemServer() {
 	}
 }
 
+// FilesystemDirectories returns the directories the fs server with options
+// may access: its allowed_directories, or the current working directory when
+// none are set
+func FilesystemDirectories(options map[string]any) ([]string, error) {
+	allowedDirs, err := allowedDirectoriesOption(options)
+	if err != nil || allowedDirs != nil {
+		return allowedDirs, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	return []string{cwd}, nil
+}
+
+// allowedDirectoriesOption returns the allowed_directories option, or nil when
+// it is not set
+func allowedDirectoriesOption(options map[string]any) ([]string, error) {
+	dirs, ok := options["allowed_directories"]
+	if !ok {
+		return nil, nil
+	}
+	switch v := dirs.(type) {
+	case []string:
+		return v, nil
+	case []any:
+		allowedDirs := make([]string, len(v))
+		for i, dir := range v {
+			s, ok := dir.(string)
+			if !ok {
+				return nil, fmt.Errorf("allowed_directories must be an array of strings")
+			}
+			allowedDirs[i] = s
+		}
+		return allowedDirs, nil
+	case string:
+		return []string{v}, nil
+	default:
+		return nil, fmt.Errorf("allowed_directories must be a string or array of strings")
+	}
+}
+
 // registerBashServer registers the bash server
 func (r *Registry) registerBashServer() {
 	r.servers["bash"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
@@ -123,8 +180,17 @@ func (r *Registry) registerBashServer() {
 // registerTodoServer registers the todo server
 func (r *Registry) registerTodoServer() {
 	r.servers["todo"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		var opts TodoServerOptions
+		if v, ok := options["persist"]; ok {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("persist must be a boolean")
+			}
+			opts.Persist = b
+		}
+
 		// Create the todo server
-		server, err := NewTodoServer()
+		server, err := NewTodoServer(opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create todo server: %v", err)
 		}
@@ -149,8 +215,95 @@ func (r *Registry) registerFetchServer() {
 // registerHTTPServer registers the HTTP server
 func (r *Registry) registerHTTPServer() {
 	r.servers["http"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		var opts HTTPServerOptions
+
+		// A dedicated summarizer model overrides the agent's model for summarize/extract
+		if v, ok := options["summarizer_model"]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("summarizer_model must be a string")
+			}
+			opts.SummarizerModel = s
+		}
+		opts.ProviderConfig = r.providerConfig
+
+		// Politeness and caching for research-heavy runs
+		if v, ok := options["rate_limit"]; ok {
+			switch n := v.(type) {
+			case float64:
+				opts.RateLimit = n
+			case int:
+				opts.RateLimit = float64(n)
+			default:
+				return nil, fmt.Errorf("rate_limit must be a number of requests per second")
+			}
+			if opts.RateLimit < 0 {
+				return nil, fmt.Errorf("rate_limit must not be negative")
+			}
+		}
+		if v, ok := options["cache_ttl"]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("cache_ttl must be a duration string such as \"1h\"")
+			}
+			ttl, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cache_ttl: %v", err)
+			}
+			opts.CacheTTL = ttl
+		}
+		if v, ok := options["cache_dir"]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("cache_dir must be a string")
+			}
+			opts.CacheDir = s
+		}
+		if v, ok := options["respect_robots"]; ok {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("respect_robots must be a boolean")
+			}
+			opts.RespectRobots = b
+		}
+		if v, ok := options["graphql_headers"]; ok {
+			// A list rather than a map keyed by host, since config keys
+			// cannot contain dots
+			entries, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("graphql_headers must be a list of {host, headers} entries")
+			}
+			opts.GraphQLHeaders = make(map[string]map[string]string, len(entries))
+			for i, e := range entries {
+				entry, ok := e.(map[string]any)
+				host, _ := entry["host"].(string)
+				headers, _ := entry["headers"].([]any)
+				if !ok || host == "" || len(headers) == 0 {
+					return nil, fmt.Errorf("graphql_headers[%d] must have a host and a list of headers", i)
+				}
+				if opts.GraphQLHeaders[host] == nil {
+					opts.GraphQLHeaders[host] = make(map[string]string)
+				}
+				for _, h := range headers {
+					header, _ := h.(string)
+					name, value, found := strings.Cut(header, ":")
+					if !found || strings.TrimSpace(name) == "" {
+						return nil, fmt.Errorf("graphql_headers[%d]: header %q must look like \"Name: value\"", i, header)
+					}
+					opts.GraphQLHeaders[host][strings.TrimSpace(name)] = strings.TrimSpace(value)
+				}
+			}
+		}
+		if v, ok := options["graphql_mutations"]; ok {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("graphql_mutations must be a boolean")
+			}
+			opts.GraphQLMutations = b
+		}
+
 		// Create the HTTP server
-		server, err := NewHTTPServer(model)
+		server, err := NewHTTPServer(model, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP server: %v", err)
 		}
@@ -158,3 +311,120 @@ func (r *Registry) registerHTTPServer() {
 		return &BuiltinServerWrapper{server: server}, nil
 	}
 }
+
+// registerImageServer registers the image generation server
+func (r *Registry) registerImageServer() {
+	r.servers["image"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		var opts ImageServerOptions
+		for key, field := range map[string]*string{
+			"provider":   &opts.Provider,
+			"model":      &opts.Model,
+			"output_dir": &opts.OutputDir,
+			"base_url":   &opts.BaseURL,
+		} {
+			if v, ok := options[key]; ok {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s must be a string", key)
+				}
+				*field = s
+			}
+		}
+
+		// Create the image server
+		server, err := NewImageServer(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create image server: %v", err)
+		}
+
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
+
+// registerDataServer registers the data analysis server
+func (r *Registry) registerDataServer() {
+	r.servers["data"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		var opts DataServerOptions
+		dirs, err := allowedDirectoriesOption(options)
+		if err != nil {
+			return nil, err
+		}
+		opts.AllowedDirectories = dirs
+
+		if v, ok := options["max_rows"]; ok {
+			switch n := v.(type) {
+			case float64:
+				opts.MaxRows = int(n)
+			case int:
+				opts.MaxRows = n
+			default:
+				return nil, fmt.Errorf("max_rows must be a number")
+			}
+			if opts.MaxRows <= 0 {
+				return nil, fmt.Errorf("max_rows must be positive")
+			}
+		}
+
+		// Create the data server
+		server, err := NewDataServer(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create data server: %v", err)
+		}
+
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
+
+// registerUtilsServer registers the time and calculator server
+func (r *Registry) registerUtilsServer() {
+	r.servers["utils"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		// Create the utils server
+		server, err := NewUtilsServer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create utils server: %v", err)
+		}
+
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
+
+// registerMemoryServer registers the preferences memory server
+func (r *Registry) registerMemoryServer() {
+	r.servers["memory"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		// Create the memory server
+		server, err := NewMemoryServer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create memory server: %v", err)
+		}
+
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
+
+// registerScratchpadServer registers the agent's working notes server
+func (r *Registry) registerScratchpadServer() {
+	r.servers[ScratchpadServerName] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
+		var opts ScratchpadServerOptions
+		if v, ok := options["max_chars"]; ok {
+			switch n := v.(type) {
+			case float64:
+				opts.MaxChars = int(n)
+			case int:
+				opts.MaxChars = n
+			default:
+				return nil, fmt.Errorf("max_chars must be a number")
+			}
+			if opts.MaxChars <= 0 {
+				return nil, fmt.Errorf("max_chars must be positive")
+			}
+		}
+
+		// Create the scratchpad server
+		server, err := NewScratchpadServer(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scratchpad server: %v", err)
+		}
+
+		return &BuiltinServerWrapper{server: server}, nil
+	}
+}
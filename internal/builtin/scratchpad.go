@@ -0,0 +1,144 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Names the agent uses to find the scratchpad and show it before each step
+const (
+	ScratchpadServerName = "scratchpad"
+	ReadNotesTool        = "read_notes"
+	ScratchpadEmpty      = "The scratchpad is empty."
+)
+
+// defaultScratchpadChars bounds the scratchpad when max_chars is not set
+const defaultScratchpadChars = 4000
+
+// ScratchpadServerOptions configures the scratchpad server
+type ScratchpadServerOptions struct {
+	// MaxChars bounds the total length of all notes, so the block shown
+	// before every step stays compact
+	MaxChars int
+}
+
+// scratchNote is one numbered note
+type scratchNote struct {
+	id   int
+	text string
+}
+
+// ScratchpadServer keeps the agent's working notes for the session, in memory
+type ScratchpadServer struct {
+	notes    []scratchNote
+	nextID   int
+	maxChars int
+	mutex    sync.Mutex
+}
+
+// NewScratchpadServer creates a new scratchpad MCP server
+func NewScratchpadServer(opts ScratchpadServerOptions) (*server.MCPServer, error) {
+	if opts.MaxChars <= 0 {
+		opts.MaxChars = defaultScratchpadChars
+	}
+	pad := &ScratchpadServer{nextID: 1, maxChars: opts.MaxChars}
+
+	s := server.NewMCPServer("scratchpad-server", "1.0.0", server.WithToolCapabilities(true))
+
+	writeTool := mcp.NewTool("write_note",
+		mcp.WithDescription(writeNoteDescription),
+		mcp.WithString("text",
+			mcp.Description("The note. Leave empty together with id to delete that note."),
+		),
+		mcp.WithNumber("id",
+			mcp.Description("Number of an existing note to replace or delete; omit to add a new note"),
+		),
+	)
+	readTool := mcp.NewTool(ReadNotesTool,
+		mcp.WithDescription("Returns all notes on the scratchpad, numbered. The notes are also shown to you before every step, so you rarely need this."),
+	)
+
+	s.AddTool(writeTool, pad.executeWriteNote)
+	s.AddTool(readTool, pad.executeReadNotes)
+
+	return s, nil
+}
+
+// executeWriteNote handles the write_note tool execution
+func (p *ScratchpadServer) executeWriteNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text := strings.TrimSpace(request.GetString("text", ""))
+	id := int(request.GetFloat("id", 0))
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if id == 0 {
+		if text == "" {
+			return mcp.NewToolResultError("text is required when adding a note"), nil
+		}
+		if err := p.checkSpace(len(text)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		p.notes = append(p.notes, scratchNote{id: p.nextID, text: text})
+		p.nextID++
+		return mcp.NewToolResultText(fmt.Sprintf("Added note %d.", p.nextID-1)), nil
+	}
+
+	for i, note := range p.notes {
+		if note.id != id {
+			continue
+		}
+		if text == "" {
+			p.notes = append(p.notes[:i], p.notes[i+1:]...)
+			return mcp.NewToolResultText(fmt.Sprintf("Deleted note %d.", id)), nil
+		}
+		if err := p.checkSpace(len(text) - len(note.text)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		p.notes[i].text = text
+		return mcp.NewToolResultText(fmt.Sprintf("Replaced note %d.", id)), nil
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("no note %d", id)), nil
+}
+
+// checkSpace returns an error when growing the notes by delta characters
+// would exceed the limit
+func (p *ScratchpadServer) checkSpace(delta int) error {
+	used := 0
+	for _, note := range p.notes {
+		used += len(note.text)
+	}
+	if used+delta > p.maxChars {
+		return fmt.Errorf("the scratchpad is full (%d of %d characters used): shorten or delete notes first", used, p.maxChars)
+	}
+	return nil
+}
+
+// executeReadNotes handles the read_notes tool execution
+func (p *ScratchpadServer) executeReadNotes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.notes) == 0 {
+		return mcp.NewToolResultText(ScratchpadEmpty), nil
+	}
+	var b strings.Builder
+	for _, note := range p.notes {
+		fmt.Fprintf(&b, "%d. %s\n", note.id, note.text)
+	}
+	return mcp.NewToolResultText(strings.TrimSuffix(b.String(), "\n")), nil
+}
+
+const writeNoteDescription = `Adds, replaces or deletes a note on your scratchpad: working memory for the current task that you see before every step.
+
+Use it for long, multi-step work to keep track of:
+  - the plan and which steps are done
+  - facts you found that later steps need, such as paths, IDs or decisions
+  - open questions
+
+Keep notes short and update them as the task moves on; replace a note rather than adding a new version of it, and delete notes that no longer matter. The scratchpad has a size limit.`
@@ -0,0 +1,76 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// startScratchpadClient starts a scratchpad server with options in-process
+func startScratchpadClient(t *testing.T, options map[string]any) *client.Client {
+	t.Helper()
+	wrapper, err := NewRegistry().CreateServer(ScratchpadServerName, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewInProcessClient(wrapper.GetServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestScratchpadServer(t *testing.T) {
+	c := startScratchpadClient(t, map[string]any{"max_chars": 50})
+
+	if text, _ := callDataTool(t, c, ReadNotesTool, nil); text != ScratchpadEmpty {
+		t.Errorf("expected an empty scratchpad, got %q", text)
+	}
+
+	steps := []struct {
+		args    map[string]any
+		want    string
+		wantErr bool
+	}{
+		{args: map[string]any{"text": "Plan: migrate users"}, want: "Added note 1."},
+		{args: map[string]any{"text": "Users table: 3 columns"}, want: "Added note 2."},
+		{args: map[string]any{"text": "one note too many"}, want: "the scratchpad is full", wantErr: true},
+		{args: map[string]any{"id": 1, "text": "Plan: done"}, want: "Replaced note 1."},
+		{args: map[string]any{"id": 2}, want: "Deleted note 2."},
+		{args: map[string]any{"id": 7, "text": "x"}, want: "no note 7", wantErr: true},
+		{args: map[string]any{}, want: "text is required", wantErr: true},
+		{args: map[string]any{"text": "Next: indexes"}, want: "Added note 3."},
+	}
+	for _, step := range steps {
+		text, isErr := callDataTool(t, c, "write_note", step.args)
+		if isErr != step.wantErr || !strings.Contains(text, step.want) {
+			t.Errorf("write_note %v: got %q (error %v), want %q", step.args, text, isErr, step.want)
+		}
+	}
+
+	if text, _ := callDataTool(t, c, ReadNotesTool, nil); text != "1. Plan: done\n3. Next: indexes" {
+		t.Errorf("unexpected notes %q", text)
+	}
+}
+
+func TestScratchpadServerOptions(t *testing.T) {
+	if _, err := NewRegistry().CreateServer(ScratchpadServerName, map[string]any{"max_chars": "lots"}, nil); err == nil {
+		t.Error("expected a non-numeric max_chars to be rejected")
+	}
+	if _, err := NewRegistry().CreateServer(ScratchpadServerName, map[string]any{"max_chars": 0}, nil); err == nil {
+		t.Error("expected a zero max_chars to be rejected")
+	}
+}
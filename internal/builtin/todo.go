@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/osi4iot/mcphost/internal/config"
 )
 
 // TodoInfo represents a single todo item
@@ -17,18 +22,32 @@ type TodoInfo struct {
 	Status   string `json:"status"`
 	Priority string `json:"priority"`
 	ID       string `json:"id"`
+	Session  string `json:"session,omitempty"` // session that added the todo, set by follow-up task extraction
+}
+
+// TodoServerOptions configures the todo server
+type TodoServerOptions struct {
+	// Persist keeps the list in the state directory's todos.json, so it
+	// survives restarts and picks up extracted follow-up tasks
+	Persist bool
 }
 
-// TodoServer implements a todo management MCP server with in-memory storage
+// TodoServer implements a todo management MCP server with in-memory storage,
+// optionally backed by todos.json
 type TodoServer struct {
-	todos []TodoInfo
-	mutex sync.RWMutex
+	todos   []TodoInfo
+	persist bool
+	mutex   sync.RWMutex
 }
 
-// NewTodoServer creates a new todo MCP server with in-memory storage
-func NewTodoServer() (*server.MCPServer, error) {
+// todoFileMutex serializes access to todos.json within the process
+var todoFileMutex sync.Mutex
+
+// NewTodoServer creates a new todo MCP server
+func NewTodoServer(opts TodoServerOptions) (*server.MCPServer, error) {
 	todoServer := &TodoServer{
-		todos: make([]TodoInfo, 0),
+		todos:   make([]TodoInfo, 0),
+		persist: opts.Persist,
 	}
 
 	s := server.NewMCPServer("todo-server", "1.0.0", server.WithToolCapabilities(true))
@@ -61,6 +80,10 @@ func NewTodoServer() (*server.MCPServer, error) {
 						"type":        "string",
 						"description": "Unique identifier for the todo item",
 					},
+					"session": map[string]any{
+						"type":        "string",
+						"description": "Session that added the task, if any. Keep it unchanged when updating the task",
+					},
 				},
 				"required": []string{"content", "status", "priority", "id"},
 			}),
@@ -107,7 +130,11 @@ func formatTodos(todos []TodoInfo) string {
 		default: // pending
 			checkbox = "[ ]"
 		}
-		result.WriteString(fmt.Sprintf("%s %s\n", checkbox, todo.Content))
+		result.WriteString(fmt.Sprintf("%s %s", checkbox, todo.Content))
+		if todo.Session != "" {
+			result.WriteString(fmt.Sprintf(" (from %s)", todo.Session))
+		}
+		result.WriteString("\n")
 	}
 
 	// Remove trailing newline
@@ -163,7 +190,12 @@ func (ts *TodoServer) executeTodoWrite(ctx context.Context, request mcp.CallTool
 		}
 	}
 
-	// Store todos in memory
+	// Store todos in memory, and on disk when persisted
+	if ts.persist {
+		if err := SaveTodos(todos); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
 	ts.setTodos(todos)
 
 	// Format output in readable format
@@ -182,6 +214,15 @@ func (ts *TodoServer) executeTodoWrite(ctx context.Context, request mcp.CallTool
 
 // executeTodoRead handles the todoread tool execution
 func (ts *TodoServer) executeTodoRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Persisted lists are reread, since extraction may have added tasks
+	if ts.persist {
+		todos, err := LoadTodos()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		ts.setTodos(todos)
+	}
+
 	// Get todos from memory
 	todos := ts.getTodos()
 
@@ -199,6 +240,115 @@ func (ts *TodoServer) executeTodoRead(ctx context.Context, request mcp.CallToolR
 	return result, nil
 }
 
+// TodosPath returns the file holding the persisted todo list
+func TodosPath() (string, error) {
+	return config.StatePath("todos.json")
+}
+
+// LoadTodos returns the persisted todo list, which is empty when there is none
+func LoadTodos() ([]TodoInfo, error) {
+	todoFileMutex.Lock()
+	defer todoFileMutex.Unlock()
+	return loadTodosFile()
+}
+
+// SaveTodos replaces the persisted todo list
+func SaveTodos(todos []TodoInfo) error {
+	todoFileMutex.Lock()
+	defer todoFileMutex.Unlock()
+	return saveTodosFile(todos)
+}
+
+// AddTodos appends pending todos to the persisted list, skipping any whose
+// content matches a todo that is not completed yet. Todos without an id get
+// a new one. It returns the todos that were added.
+func AddTodos(todos []TodoInfo) ([]TodoInfo, error) {
+	todoFileMutex.Lock()
+	defer todoFileMutex.Unlock()
+
+	existing, err := loadTodosFile()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(existing))
+	open := make(map[string]bool, len(existing))
+	for _, todo := range existing {
+		ids[todo.ID] = true
+		if todo.Status != "completed" {
+			open[strings.ToLower(todo.Content)] = true
+		}
+	}
+
+	var added []TodoInfo
+	next := len(existing) + 1
+	for _, todo := range todos {
+		todo.Content = strings.TrimSpace(todo.Content)
+		key := strings.ToLower(todo.Content)
+		if todo.Content == "" || open[key] {
+			continue
+		}
+		open[key] = true
+		if todo.Status == "" {
+			todo.Status = "pending"
+		}
+		if todo.Priority == "" {
+			todo.Priority = "medium"
+		}
+		for todo.ID == "" || ids[todo.ID] {
+			todo.ID = strconv.Itoa(next)
+			next++
+		}
+		ids[todo.ID] = true
+		added = append(added, todo)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if err := saveTodosFile(append(existing, added...)); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// loadTodosFile reads todos.json; the caller holds todoFileMutex
+func loadTodosFile() ([]TodoInfo, error) {
+	path, err := TodosPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []TodoInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read todos: %w", err)
+	}
+	var todos []TodoInfo
+	if err := json.Unmarshal(data, &todos); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return todos, nil
+}
+
+// saveTodosFile writes todos.json; the caller holds todoFileMutex
+func saveTodosFile(todos []TodoInfo) error {
+	path, err := TodosPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(todos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode todos: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write todos: %w", err)
+	}
+	return nil
+}
+
 const todoWriteDescription = `Use this tool to create and manage a structured task list for your current coding session. This helps you track progress, organize complex tasks, and demonstrate thoroughness to the user.
 It also helps the user understand the progress of the task and overall progress of their requests.
 
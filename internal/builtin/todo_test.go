@@ -5,10 +5,12 @@ import (
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/osi4iot/mcphost/internal/config"
 )
 
 func TestNewTodoServer(t *testing.T) {
-	server, err := NewTodoServer()
+	server, err := NewTodoServer(TodoServerOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create todo server: %v", err)
 	}
@@ -299,3 +301,45 @@ func TestTodoActiveCounting(t *testing.T) {
 		t.Error("Expected text content")
 	}
 }
+
+func TestPersistedTodos(t *testing.T) {
+	if err := config.SetStateDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer config.SetStateDir("")
+
+	server := &TodoServer{todos: make([]TodoInfo, 0), persist: true}
+	write := mcp.CallToolRequest{}
+	write.Params.Arguments = map[string]any{"todos": []TodoInfo{
+		{Content: "Write migration", Status: "in_progress", Priority: "high", ID: "1"},
+		{Content: "Old task", Status: "completed", Priority: "low", ID: "2"},
+	}}
+	if result, err := server.executeTodoWrite(context.Background(), write); err != nil || result.IsError {
+		t.Fatalf("todowrite failed: %v %+v", err, result)
+	}
+
+	// Extraction adds to the same file, skipping tasks that are still open
+	added, err := AddTodos([]TodoInfo{
+		{Content: "write MIGRATION", Priority: "low", Session: "mcphost-1"},
+		{Content: "Old task", Priority: "medium", Session: "mcphost-1"},
+		{Content: "Update the changelog", Priority: "low", Session: "mcphost-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 || added[0].ID != "3" || added[1].ID != "4" || added[1].Status != "pending" {
+		t.Fatalf("unexpected added todos: %+v", added)
+	}
+
+	// A new server, as in the next session, sees every task
+	next := &TodoServer{todos: make([]TodoInfo, 0), persist: true}
+	result, err := next.executeTodoRead(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	expected := "\n\n[~] Write migration\n[X] Old task\n[ ] Old task (from mcphost-1)\n[ ] Update the changelog (from mcphost-1)"
+	if text != expected {
+		t.Errorf("Expected formatted output:\n%s\nGot:\n%s", expected, text)
+	}
+}
@@ -0,0 +1,561 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones work without the system database, e.g. on Windows
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// utilsTimeLayouts are the timestamp formats the time tools accept, tried in order
+var utilsTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// NewUtilsServer creates a new MCP server with clock and calculator tools
+func NewUtilsServer() (*server.MCPServer, error) {
+	s := server.NewMCPServer("utils-server", "1.0.0", server.WithToolCapabilities(true))
+
+	currentTimeTool := mcp.NewTool("current_time",
+		mcp.WithDescription("Returns the current date and time, or converts a given time, in a timezone. Use this instead of guessing today's date or the time somewhere."),
+		mcp.WithString("timezone",
+			mcp.Description("IANA timezone to show the time in, e.g. 'Europe/Madrid' or 'UTC' (default: the local timezone)"),
+		),
+		mcp.WithString("time",
+			mcp.Description("Time to convert instead of now, e.g. '2024-05-01T09:30:00Z' or '2024-05-01 09:30'"),
+		),
+		mcp.WithString("from_timezone",
+			mcp.Description("IANA timezone of 'time' when it has no UTC offset (default: the local timezone)"),
+		),
+	)
+
+	dateDiffTool := mcp.NewTool("date_diff",
+		mcp.WithDescription("Calculates the time between two dates or times, as a calendar difference (years, months, days) and in total days, hours, minutes and seconds."),
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("Start date or time, e.g. '2024-01-31' or '2024-01-31T08:00:00+01:00'; 'now' for the current time"),
+		),
+		mcp.WithString("end",
+			mcp.Required(),
+			mcp.Description("End date or time in the same formats as start"),
+		),
+		mcp.WithString("timezone",
+			mcp.Description("IANA timezone for dates and times without a UTC offset (default: the local timezone)"),
+		),
+	)
+
+	calculateTool := mcp.NewTool("calculate",
+		mcp.WithDescription(utilsCalculateDescription),
+		mcp.WithString("expression",
+			mcp.Required(),
+			mcp.Description("The arithmetic expression to evaluate, e.g. '(1250 * 1.21) / 12'"),
+		),
+	)
+
+	s.AddTool(currentTimeTool, executeCurrentTime)
+	s.AddTool(dateDiffTool, executeDateDiff)
+	s.AddTool(calculateTool, executeCalculate)
+
+	return s, nil
+}
+
+// executeCurrentTime handles the current_time tool execution
+func executeCurrentTime(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	location, err := utilsLocation(request.GetString("timezone", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	t := time.Now()
+	if value := request.GetString("time", ""); value != "" {
+		from, err := utilsLocation(request.GetString("from_timezone", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if t, err = utilsParseTime(value, from, time.Now()); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	return mcp.NewToolResultText(formatUtilsTime(t.In(location))), nil
+}
+
+// formatUtilsTime describes t in its own location
+func formatUtilsTime(t time.Time) string {
+	name, _ := t.Zone()
+	_, week := t.ISOWeek()
+	return fmt.Sprintf("%s\nDate: %s, %s (week %d, day %d of the year)\nTime: %s\nTimezone: %s (%s, UTC%s)\nUnix: %d",
+		t.Format(time.RFC3339),
+		t.Weekday(), t.Format("January 2, 2006"), week, t.YearDay(),
+		t.Format("15:04:05"),
+		t.Location(), name, t.Format("-07:00"),
+		t.Unix())
+}
+
+// executeDateDiff handles the date_diff tool execution
+func executeDateDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startValue, err := request.RequireString("start")
+	if err != nil {
+		return mcp.NewToolResultError("start parameter is required and must be a string"), nil
+	}
+	endValue, err := request.RequireString("end")
+	if err != nil {
+		return mcp.NewToolResultError("end parameter is required and must be a string"), nil
+	}
+	location, err := utilsLocation(request.GetString("timezone", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	now := time.Now()
+	start, err := utilsParseTime(startValue, location, now)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid start: %v", err)), nil
+	}
+	end, err := utilsParseTime(endValue, location, now)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid end: %v", err)), nil
+	}
+	return mcp.NewToolResultText(formatDateDiff(start, end)), nil
+}
+
+// formatDateDiff describes the time from start to end
+func formatDateDiff(start, end time.Time) string {
+	sign := ""
+	if end.Before(start) {
+		start, end = end, start
+		sign = "-"
+	}
+	years, months, days, rest := calendarDiff(start, end)
+	d := end.Sub(start)
+
+	var parts []string
+	for _, part := range []struct {
+		n    int
+		unit string
+	}{{years, "year"}, {months, "month"}, {days, "day"}} {
+		if part.n != 0 {
+			parts = append(parts, pluralize(part.n, part.unit))
+		}
+	}
+	if rest > 0 {
+		parts = append(parts, rest.String())
+	}
+	calendar := "0 days"
+	if len(parts) > 0 {
+		calendar = strings.Join(parts, ", ")
+	}
+	if sign != "" {
+		calendar += " (end is before start)"
+	}
+
+	return fmt.Sprintf("%s\nTotal: %s%s days, %s%s hours, %s%s minutes, %s%d seconds\nWeeks: %s%s",
+		calendar,
+		sign, formatNumber(d.Hours()/24), sign, formatNumber(d.Hours()), sign, formatNumber(d.Minutes()), sign, int64(d.Seconds()),
+		sign, formatNumber(d.Hours()/(24*7)))
+}
+
+// calendarDiff splits the time from start to end (start not after end) into
+// whole years, months and days plus the remaining time of day
+func calendarDiff(start, end time.Time) (years, months, days int, rest time.Duration) {
+	end = end.In(start.Location())
+	months = (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	// Step back while adding the months overshoots, e.g. Jan 31 to Feb 28
+	for months > 0 && addMonths(start, months).After(end) {
+		months--
+	}
+	anchor := addMonths(start, months)
+	for !anchor.AddDate(0, 0, days+1).After(end) {
+		days++
+	}
+	rest = end.Sub(anchor.AddDate(0, 0, days))
+	return months / 12, months % 12, days, rest
+}
+
+// addMonths adds months to t, clamping the day to the end of a shorter month
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// pluralize formats a count with its unit
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// utilsLocation loads an IANA timezone, the local one when name is empty
+func utilsLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q (use an IANA name such as 'America/New_York')", name)
+	}
+	return location, nil
+}
+
+// utilsParseTime parses a timestamp in one of utilsTimeLayouts, or "now".
+// Times without a UTC offset are in location.
+func utilsParseTime(value string, location *time.Location, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "now") {
+		return now, nil
+	}
+	for _, layout := range utilsTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q; use a date like 2024-05-01 or a time like 2024-05-01T09:30:00Z", value)
+}
+
+// executeCalculate handles the calculate tool execution
+func executeCalculate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	expression, err := request.RequireString("expression")
+	if err != nil || strings.TrimSpace(expression) == "" {
+		return mcp.NewToolResultError("expression parameter is required and must be a non-empty string"), nil
+	}
+	value, err := evaluateExpression(expression)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("cannot evaluate %q: %v", expression, err)), nil
+	}
+	return mcp.NewToolResultText(formatNumber(value)), nil
+}
+
+// formatNumber renders a result rounded to 15 significant digits, which hides
+// binary floating point noise such as 0.1 + 0.2 = 0.30000000000000004
+func formatNumber(v float64) string {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if abs := math.Abs(rounded); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(rounded, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// calcFunctions are the functions calculate supports, by name and argument count
+var calcFunctions = map[string]struct {
+	args int // -1 for one or more
+	fn   func(args []float64) float64
+}{
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"cbrt":  {1, func(a []float64) float64 { return math.Cbrt(a[0]) }},
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"exp":   {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"ln":    {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log":   {1, func(a []float64) float64 { return math.Log10(a[0]) }},
+	"log2":  {1, func(a []float64) float64 { return math.Log2(a[0]) }},
+	"sin":   {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":   {1, func(a []float64) float64 { return math.Tan(a[0]) }},
+	"asin":  {1, func(a []float64) float64 { return math.Asin(a[0]) }},
+	"acos":  {1, func(a []float64) float64 { return math.Acos(a[0]) }},
+	"atan":  {1, func(a []float64) float64 { return math.Atan(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {1, func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"round": {1, func(a []float64) float64 { return math.Round(a[0]) }},
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"min":   {-1, func(a []float64) float64 { return foldFloats(a, math.Min) }},
+	"max":   {-1, func(a []float64) float64 { return foldFloats(a, math.Max) }},
+}
+
+// calcConstants are the named constants calculate supports
+var calcConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// foldFloats combines values pairwise with fn, e.g. math.Min
+func foldFloats(values []float64, fn func(a, b float64) float64) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		result = fn(result, v)
+	}
+	return result
+}
+
+// calcParser is a recursive descent parser that evaluates as it parses:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = ("-" | "+") unary | power
+//	power  = atom [ ("^" | "**") unary ]
+//	atom   = number | constant | function "(" expr { "," expr } ")" | "(" expr ")"
+type calcParser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// calcMaxDepth bounds the recursion of the parser so hostile input cannot
+// exhaust the stack. Each parenthesis takes three levels.
+const calcMaxDepth = 300
+
+// evaluateExpression evaluates an arithmetic expression without running any code
+func evaluateExpression(expression string) (float64, error) {
+	p := &calcParser{src: expression}
+	value, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos:p.pos+1], p.pos+1)
+	}
+	if math.IsNaN(value) {
+		return 0, fmt.Errorf("result is not a number")
+	}
+	return value, nil
+}
+
+func (p *calcParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes op if it comes next
+func (p *calcParser) accept(op string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], op) {
+		p.pos += len(op)
+		return true
+	}
+	return false
+}
+
+// enter counts a level of recursion for a production that can recurse,
+// failing once input nests too deeply; the caller leaves with defer p.leave()
+func (p *calcParser) enter() error {
+	p.depth++
+	if p.depth > calcMaxDepth {
+		return fmt.Errorf("expression is nested too deeply")
+	}
+	return nil
+}
+
+func (p *calcParser) leave() {
+	p.depth--
+}
+
+func (p *calcParser) expr() (float64, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+
+	value, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.accept("+"):
+			rhs, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			value += rhs
+		case p.accept("-"):
+			rhs, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			value -= rhs
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (p *calcParser) term() (float64, error) {
+	value, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept("*"):
+			op = '*'
+		case p.accept("/"):
+			op = '/'
+		case p.accept("%"):
+			op = '%'
+		default:
+			return value, nil
+		}
+		rhs, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			value *= rhs
+		case '/':
+			if rhs == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			value /= rhs
+		case '%':
+			if rhs == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			value = math.Mod(value, rhs)
+		}
+	}
+}
+
+func (p *calcParser) unary() (float64, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case p.accept("-"):
+		value, err := p.unary()
+		return -value, err
+	case p.accept("+"):
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *calcParser) power() (float64, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+
+	base, err := p.atom()
+	if err != nil {
+		return 0, err
+	}
+	if p.accept("**") || p.accept("^") {
+		// Right associative, and binds tighter than a unary minus on its left:
+		// -2^2 is -4 and 2^-1 is 0.5
+		exponent, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exponent), nil
+	}
+	return base, nil
+}
+
+func (p *calcParser) atom() (float64, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+
+	if p.accept("(") {
+		value, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(")") {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		return value, nil
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	if c >= '0' && c <= '9' || c == '.' {
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.' || p.src[p.pos] == '_') {
+			p.pos++
+		}
+		// Exponent, as in 1.5e-3
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			next := p.pos + 1
+			if next < len(p.src) && (p.src[next] == '+' || p.src[next] == '-') {
+				next++
+			}
+			if next < len(p.src) && isDigit(p.src[next]) {
+				p.pos = next
+				for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+					p.pos++
+				}
+			}
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(p.src[start:p.pos], "_", ""), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return value, nil
+	}
+
+	if unicode.IsLetter(rune(c)) {
+		for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.src[start:p.pos])
+		if value, ok := calcConstants[name]; ok {
+			return value, nil
+		}
+		function, ok := calcFunctions[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown name %q", p.src[start:p.pos])
+		}
+		if !p.accept("(") {
+			return 0, fmt.Errorf("%s must be called with parentheses", name)
+		}
+		var args []float64
+		if !p.accept(")") {
+			for {
+				arg, err := p.expr()
+				if err != nil {
+					return 0, err
+				}
+				args = append(args, arg)
+				if p.accept(")") {
+					break
+				}
+				if !p.accept(",") {
+					return 0, fmt.Errorf("expected ',' or ')' in call to %s", name)
+				}
+			}
+		}
+		if (function.args < 0 && len(args) == 0) || (function.args >= 0 && len(args) != function.args) {
+			return 0, fmt.Errorf("wrong number of arguments to %s", name)
+		}
+		return function.fn(args), nil
+	}
+
+	return 0, fmt.Errorf("unexpected %q at position %d", string(c), p.pos+1)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+const utilsCalculateDescription = `Evaluates an arithmetic expression exactly as written. Use this for any calculation rather than doing arithmetic in your head.
+
+Supported:
+  - Operators: + - * / % (remainder) and ^ or ** (power), with parentheses
+  - Functions: sqrt, cbrt, abs, exp, ln, log (base 10), log2, sin, cos, tan, asin, acos, atan (radians), floor, ceil, round, pow(x, y), min(...), max(...)
+  - Constants: pi, e
+  - Numbers such as 1_000_000, 0.5 and 1.5e-3
+
+Results are rounded to 15 significant digits.`
@@ -0,0 +1,130 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		wantErr    string
+	}{
+		{expression: "0.1 + 0.2", want: "0.3"},
+		{expression: "(1250 * 1.21) / 12", want: "126.041666666667"},
+		{expression: "2 + 3 * 4 - 6 / 2", want: "11"},
+		{expression: "-2^2", want: "-4"},
+		{expression: "2 ** 3 ** 2", want: "512"},
+		{expression: "2^-1", want: "0.5"},
+		{expression: "17 % 5", want: "2"},
+		{expression: "1_000_000 * 1.5e-3", want: "1500"},
+		{expression: "sqrt(16) + max(1, 7, 3) - min(4, -2)", want: "13"},
+		{expression: "round(pi * 100) / 100", want: "3.14"},
+		{expression: "log(1000) + ln(e) + log2(8)", want: "7"},
+		{expression: "2^100", want: "1.26765060022823e+30"},
+		{expression: "1 / 0", wantErr: "division by zero"},
+		{expression: "sqrt(-1)", wantErr: "not a number"},
+		{expression: "(1 + 2", wantErr: "missing closing parenthesis"},
+		{expression: "2 +", wantErr: "unexpected end"},
+		{expression: "os.exit(1)", wantErr: `unknown name "os"`},
+		{expression: "pow(2)", wantErr: "wrong number of arguments to pow"},
+		{expression: "3 4", wantErr: `unexpected "4" at position 3`},
+		{expression: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200), wantErr: "nested too deeply"},
+		{expression: strings.Repeat("(", 50) + "1" + strings.Repeat(")", 50), want: "1"},
+		{expression: strings.Repeat("-", 100_000) + "1", wantErr: "nested too deeply"},
+		{expression: strings.Repeat("+", 100_000) + "1", wantErr: "nested too deeply"},
+		{expression: strings.Repeat("2^", 100_000) + "1", wantErr: "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			value, err := evaluateExpression(tt.expression)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v (%v)", tt.wantErr, err, value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := formatNumber(value); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatDateDiff(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parse := func(value string) time.Time {
+		t.Helper()
+		parsed, err := utilsParseTime(value, madrid, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		// Month ends are clamped, so a month after Jan 31 is Feb 29
+		{"2024-01-31", "2024-02-29", []string{"1 month\n", "Total: 29 days"}},
+		{"2024-01-31", "2024-03-01", []string{"1 month, 1 day\n", "Total: 30 days"}},
+		{"2023-03-15 08:00", "2025-03-20 20:30", []string{"2 years, 5 days, 12h30m0s", "Weeks: 105"}},
+		// The clocks went forward on 2024-03-31, so the day was 23 hours long
+		{"2024-03-30T12:00:00", "2024-03-31T12:00:00", []string{"1 day\n", "23 hours"}},
+		{"2024-05-02", "2024-05-01", []string{"1 day (end is before start)", "Total: -1 days, -24 hours"}},
+		{"2024-05-01T10:00:00Z", "2024-05-01T12:00:00+02:00", []string{"0 days", "Total: 0 days"}},
+	}
+	for _, tt := range tests {
+		got := formatDateDiff(parse(tt.start), parse(tt.end))
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s to %s: expected %q in\n%s", tt.start, tt.end, want, got)
+			}
+		}
+	}
+}
+
+func TestCurrentTime(t *testing.T) {
+	call := func(args map[string]any) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "current_time"
+		request.Params.Arguments = args
+		result, err := executeCurrentTime(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	text, isErr := call(map[string]any{"time": "2024-07-01 09:30", "from_timezone": "America/New_York", "timezone": "Asia/Tokyo"})
+	if isErr {
+		t.Fatal(text)
+	}
+	for _, want := range []string{"2024-07-01T22:30:00+09:00", "Monday, July 1, 2024 (week 27, day 183 of the year)", "Timezone: Asia/Tokyo (JST, UTC+09:00)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in\n%s", want, text)
+		}
+	}
+
+	text, isErr = call(map[string]any{"timezone": "UTC"})
+	if isErr || !strings.Contains(text, time.Now().UTC().Format("2006-01-02")) {
+		t.Errorf("expected today's UTC date, got %q", text)
+	}
+
+	if text, isErr := call(map[string]any{"timezone": "Mars/Olympus"}); !isErr || !strings.Contains(text, "unknown timezone") {
+		t.Errorf("expected unknown timezone to fail, got %q", text)
+	}
+}
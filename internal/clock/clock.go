@@ -0,0 +1,71 @@
+// Package clock is the source of the timestamps mcphost shows and records:
+// message times in the UI, session and message times, hook payloads and
+// usage ledger entries. Tests and replays fix it so the same run gives the
+// same output. Durations, timeouts and rate limits keep the system time.
+package clock
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FixedTimeEnv fixes the clock, like --fixed-time
+const FixedTimeEnv = "MCPHOST_FIXED_TIME"
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the system clock
+type System struct{}
+
+// Now returns the current time
+func (System) Now() time.Time { return time.Now() }
+
+// Fixed is a clock stopped at a time
+type Fixed time.Time
+
+// Now returns the fixed time
+func (f Fixed) Now() time.Time { return time.Time(f) }
+
+var (
+	mu      sync.RWMutex
+	current Clock = System{}
+)
+
+// Now returns the time on the current clock
+func Now() time.Time {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.Now()
+}
+
+// Set makes c the current clock and returns a function that puts the previous
+// one back, for tests
+func Set(c Clock) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := current
+	current = c
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		current = previous
+	}
+}
+
+// SetFixed stops the clock at value, an RFC 3339 time; an empty value leaves
+// the clock as it is
+func SetFixed(value string) error {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid fixed time %q: use RFC 3339, like 2025-01-02T15:04:05Z", value)
+	}
+	Set(Fixed(t))
+	return nil
+}
@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetFixed(t *testing.T) {
+	restore := Set(System{})
+	defer restore()
+
+	if err := SetFixed("2025-01-02T15:04:05Z"); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	if got := Now(); !got.Equal(want) {
+		t.Errorf("expected the clock stopped at %v, got %v", want, got)
+	}
+	if err := SetFixed("tomorrow"); err == nil {
+		t.Error("expected an error for a time that is not RFC 3339")
+	}
+	if err := SetFixed(""); err != nil || !Now().Equal(want) {
+		t.Error("expected an empty time to leave the clock alone")
+	}
+}
+
+func TestSetRestores(t *testing.T) {
+	restore := Set(Fixed(time.Unix(0, 0)))
+	if !Now().Equal(time.Unix(0, 0)) {
+		t.Fatal("expected the fixed clock")
+	}
+	restore()
+	if time.Since(Now()) > time.Minute {
+		t.Error("expected the system clock back")
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -22,6 +25,17 @@ type MCPServerConfig struct {
 	AllowedTools  []string          `json:"allowedTools,omitempty" yaml:"allowedTools,omitempty"`
 	ExcludedTools []string          `json:"excludedTools,omitempty" yaml:"excludedTools,omitempty"`
 
+	// SamplingApproval overrides the global sampling-approval policy for this server
+	SamplingApproval string `json:"samplingApproval,omitempty" yaml:"samplingApproval,omitempty"`
+
+	// EphemeralResults keeps this server's tool results for the current turn
+	// only; the conversation history gets a one-line summary instead
+	EphemeralResults bool `json:"ephemeralResults,omitempty" yaml:"ephemeralResults,omitempty"`
+
+	// OpenAPI servers: the spec file or URL, and the operationIds to expose as tools
+	Spec       string   `json:"spec,omitempty" yaml:"spec,omitempty"`
+	Operations []string `json:"operations,omitempty" yaml:"operations,omitempty"`
+
 	// Legacy fields for backward compatibility
 	Transport string         `json:"transport,omitempty"`
 	Args      []string       `json:"args,omitempty"`
@@ -42,6 +56,12 @@ func (s *MCPServerConfig) UnmarshalJSON(data []byte) error {
 		Options       map[string]any    `json:"options,omitempty"`
 		AllowedTools  []string          `json:"allowedTools,omitempty" yaml:"allowedTools,omitempty"`
 		ExcludedTools []string          `json:"excludedTools,omitempty" yaml:"excludedTools,omitempty"`
+
+		SamplingApproval string `json:"samplingApproval,omitempty" yaml:"samplingApproval,omitempty"`
+		EphemeralResults bool   `json:"ephemeralResults,omitempty" yaml:"ephemeralResults,omitempty"`
+
+		Spec       string   `json:"spec,omitempty"`
+		Operations []string `json:"operations,omitempty"`
 	}
 
 	// Also try legacy format
@@ -54,6 +74,9 @@ func (s *MCPServerConfig) UnmarshalJSON(data []byte) error {
 		Headers       []string       `json:"headers,omitempty"`
 		AllowedTools  []string       `json:"allowedTools,omitempty" yaml:"allowedTools,omitempty"`
 		ExcludedTools []string       `json:"excludedTools,omitempty" yaml:"excludedTools,omitempty"`
+
+		SamplingApproval string `json:"samplingApproval,omitempty" yaml:"samplingApproval,omitempty"`
+		EphemeralResults bool   `json:"ephemeralResults,omitempty" yaml:"ephemeralResults,omitempty"`
 	}
 
 	// Try new format first
@@ -68,6 +91,10 @@ func (s *MCPServerConfig) UnmarshalJSON(data []byte) error {
 		s.Options = newConfig.Options
 		s.AllowedTools = newConfig.AllowedTools
 		s.ExcludedTools = newConfig.ExcludedTools
+		s.SamplingApproval = newConfig.SamplingApproval
+		s.EphemeralResults = newConfig.EphemeralResults
+		s.Spec = newConfig.Spec
+		s.Operations = newConfig.Operations
 		return nil
 	}
 
@@ -88,6 +115,8 @@ func (s *MCPServerConfig) UnmarshalJSON(data []byte) error {
 	s.Headers = legacyConfig.Headers
 	s.AllowedTools = legacyConfig.AllowedTools
 	s.ExcludedTools = legacyConfig.ExcludedTools
+	s.SamplingApproval = legacyConfig.SamplingApproval
+	s.EphemeralResults = legacyConfig.EphemeralResults
 
 	// Infer type from legacy format for better compatibility
 	// Only set Type when it doesn't change existing transport behavior
@@ -156,14 +185,127 @@ type Config struct {
 	MarkdownTheme  any                        `json:"markdown-theme" yaml:"markdown-theme"`
 
 	// Model generation parameters
-	MaxTokens     int      `json:"max-tokens,omitempty" yaml:"max-tokens,omitempty"`
-	Temperature   *float32 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
-	TopP          *float32 `json:"top-p,omitempty" yaml:"top-p,omitempty"`
-	TopK          *int32   `json:"top-k,omitempty" yaml:"top-k,omitempty"`
-	StopSequences []string `json:"stop-sequences,omitempty" yaml:"stop-sequences,omitempty"`
+	MaxTokens         int      `json:"max-tokens,omitempty" yaml:"max-tokens,omitempty"`
+	Temperature       *float32 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TopP              *float32 `json:"top-p,omitempty" yaml:"top-p,omitempty"`
+	TopK              *int32   `json:"top-k,omitempty" yaml:"top-k,omitempty"`
+	StopSequences     []string `json:"stop-sequences,omitempty" yaml:"stop-sequences,omitempty"`
+	ParallelToolCalls *bool    `json:"parallel-tool-calls,omitempty" yaml:"parallel-tool-calls,omitempty"`
+	ToolChoice        string   `json:"tool-choice,omitempty" yaml:"tool-choice,omitempty"`
+	ToolEmulation     string   `json:"tool-emulation,omitempty" yaml:"tool-emulation,omitempty"`
+	Thinking          bool     `json:"thinking,omitempty" yaml:"thinking,omitempty"`
+	ThinkingBudget    int      `json:"thinking-budget,omitempty" yaml:"thinking-budget,omitempty"`
+
+	// Ollama-specific parameters
+	NumCtx    int    `json:"num-ctx,omitempty" yaml:"num-ctx,omitempty" mapstructure:"num-ctx"`
+	NumThread int    `json:"num-thread,omitempty" yaml:"num-thread,omitempty" mapstructure:"num-thread"`
+	KeepAlive string `json:"keep-alive,omitempty" yaml:"keep-alive,omitempty" mapstructure:"keep-alive"`
 
 	// TLS configuration
 	TLSSkipVerify bool `json:"tls-skip-verify,omitempty" yaml:"tls-skip-verify,omitempty"`
+
+	// NoProbe skips the startup check of a custom provider endpoint
+	NoProbe bool `json:"no-probe,omitempty" yaml:"no-probe,omitempty"`
+
+	// Offline skips the network lookups made at startup
+	Offline bool `json:"offline,omitempty" yaml:"offline,omitempty"`
+
+	// AzureAuth is how Azure OpenAI requests are authenticated
+	AzureAuth string `json:"azure-auth,omitempty" yaml:"azure-auth,omitempty"`
+
+	// Models maps task types to the model used for them, so auxiliary calls
+	// can go to a cheaper model than the main one
+	Models map[string]string `json:"models,omitempty" yaml:"models,omitempty"`
+
+	// Router maps the steps of the agent loop to the model that makes them,
+	// so the steps between tool calls can go to a cheaper model
+	Router map[string]string `json:"router,omitempty" yaml:"router,omitempty"`
+
+	// ShadowModel is mirrored each model call to compare it with the main
+	// model; its responses and costs go to the usage ledger
+	ShadowModel string `json:"shadow-model,omitempty" yaml:"shadow-model,omitempty" mapstructure:"shadow-model"`
+
+	// SamplingApproval is the policy for MCP sampling requests: ask, allow or deny
+	SamplingApproval string `json:"sampling-approval,omitempty" yaml:"sampling-approval,omitempty" mapstructure:"sampling-approval"`
+
+	// EmailResults are the addresses non-interactive runs email their results to
+	EmailResults []string `json:"email-results,omitempty" yaml:"email-results,omitempty" mapstructure:"email-results"`
+
+	// Tools are command tools, served to the model as the CommandToolsServer server
+	Tools map[string]CommandTool `json:"tools,omitempty" yaml:"tools,omitempty"`
+
+	// Personas are named sets of settings selected with --persona or /persona
+	Personas map[string]Persona `json:"personas,omitempty" yaml:"personas,omitempty"`
+}
+
+// CommandToolsServer is the name of the server that exposes the tools: section
+const CommandToolsServer = "tools"
+
+// CommandTool is a tool defined in the tools: section. Its command runs with
+// sh -c after each {param} placeholder is replaced by the shell-quoted value.
+type CommandTool struct {
+	Command     string                      `json:"command" yaml:"command"`
+	Description string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	Params      map[string]CommandToolParam `json:"params,omitempty" yaml:"params,omitempty"`
+	Dir         string                      `json:"dir,omitempty" yaml:"dir,omitempty"`         // working directory
+	Timeout     string                      `json:"timeout,omitempty" yaml:"timeout,omitempty"` // e.g. "5m"; default 2m
+}
+
+// CommandToolParam is a parameter of a CommandTool
+type CommandToolParam struct {
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"` // string (default), number, integer, boolean or array
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	Default     any    `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// commandToolParamTypes lists the accepted CommandToolParam types
+var commandToolParamTypes = []string{"string", "number", "integer", "boolean", "array"}
+
+var commandToolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Task types that can be given their own model under "models"
+const (
+	TaskDefault    = "default"    // the main model, used when no model is set otherwise
+	TaskSummarize  = "summarize"  // the http builtin's fetch_summarize and fetch_extract tools
+	TaskExtract    = "extract"    // follow-up task extraction (extract-tasks)
+	TaskGuardrail  = "guardrail"  // the guardrail model review of final responses
+	TaskPostMortem = "postmortem" // summaries of failed runs (postmortem-summary)
+)
+
+// taskTypes lists the task types accepted under "models"
+var taskTypes = []string{TaskDefault, TaskSummarize, TaskExtract, TaskGuardrail, TaskPostMortem}
+
+// Steps of the agent loop that can be given their own model under "router"
+const (
+	RouteTools = "tools" // model calls that follow tool results
+	RouteFinal = "final" // a run's first model call, and its final answer
+)
+
+// routes lists the steps accepted under "router"
+var routes = []string{RouteTools, RouteFinal}
+
+// Policies for MCP sampling requests, set with sampling-approval globally or
+// samplingApproval per server
+const (
+	SamplingAsk   = "ask"   // prompt in interactive mode, deny otherwise
+	SamplingAllow = "allow" // fulfil without asking
+	SamplingDeny  = "deny"  // always refuse
+)
+
+// samplingPolicies lists the accepted sampling approval policies
+var samplingPolicies = []string{SamplingAsk, SamplingAllow, SamplingDeny}
+
+// SamplingPolicy returns the sampling approval policy for a server: its own
+// samplingApproval when set, otherwise the global sampling-approval, otherwise ask
+func (c *Config) SamplingPolicy(serverName string) string {
+	if server, ok := c.MCPServers[serverName]; ok && server.SamplingApproval != "" {
+		return server.SamplingApproval
+	}
+	if c.SamplingApproval != "" {
+		return c.SamplingApproval
+	}
+	return SamplingAsk
 }
 
 // GetTransportType returns the transport type for the server config
@@ -199,7 +341,42 @@ func (s *MCPServerConfig) GetTransportType() string {
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	for task, model := range c.Models {
+		if !slices.Contains(taskTypes, task) {
+			return fmt.Errorf("models: unknown task type '%s'. Supported types: %s", task, strings.Join(taskTypes, ", "))
+		}
+		if provider, name, ok := strings.Cut(model, ":"); !ok || provider == "" || name == "" {
+			return fmt.Errorf("models: %s: invalid model '%s', expected provider:model", task, model)
+		}
+	}
+	for route, model := range c.Router {
+		if !slices.Contains(routes, route) {
+			return fmt.Errorf("router: unknown step '%s'. Supported steps: %s", route, strings.Join(routes, ", "))
+		}
+		if provider, name, ok := strings.Cut(model, ":"); !ok || provider == "" || name == "" {
+			return fmt.Errorf("router: %s: invalid model '%s', expected provider:model", route, model)
+		}
+	}
+
+	if c.ShadowModel != "" {
+		if provider, name, ok := strings.Cut(c.ShadowModel, ":"); !ok || provider == "" || name == "" {
+			return fmt.Errorf("shadow-model: invalid model '%s', expected provider:model", c.ShadowModel)
+		}
+	}
+
+	if c.SamplingApproval != "" && !slices.Contains(samplingPolicies, c.SamplingApproval) {
+		return fmt.Errorf("sampling-approval: invalid policy '%s'. Supported policies: %s", c.SamplingApproval, strings.Join(samplingPolicies, ", "))
+	}
+
+	if err := c.validateTools(); err != nil {
+		return err
+	}
+
 	for serverName, serverConfig := range c.MCPServers {
+		if serverConfig.SamplingApproval != "" && !slices.Contains(samplingPolicies, serverConfig.SamplingApproval) {
+			return fmt.Errorf("server %s: invalid samplingApproval '%s'. Supported policies: %s", serverName, serverConfig.SamplingApproval, strings.Join(samplingPolicies, ", "))
+		}
+
 		if len(serverConfig.AllowedTools) > 0 && len(serverConfig.ExcludedTools) > 0 {
 			return fmt.Errorf("server %s: allowedTools and excludedTools are mutually exclusive", serverName)
 		}
@@ -219,13 +396,65 @@ func (c *Config) Validate() error {
 			if serverConfig.Name == "" {
 				return fmt.Errorf("server %s: name is required for builtin servers", serverName)
 			}
+		case "openapi":
+			if serverConfig.Spec == "" {
+				return fmt.Errorf("server %s: spec is required for openapi servers", serverName)
+			}
 		default:
-			return fmt.Errorf("server %s: unsupported transport type '%s'. Supported types: stdio, sse, streamable, inprocess", serverName, transport)
+			return fmt.Errorf("server %s: unsupported transport type '%s'. Supported types: stdio, sse, streamable, inprocess, openapi", serverName, transport)
+		}
+	}
+	return nil
+}
+
+// validateTools checks the tools: section
+func (c *Config) validateTools() error {
+	if len(c.Tools) == 0 {
+		return nil
+	}
+	if _, exists := c.MCPServers[CommandToolsServer]; exists {
+		return fmt.Errorf("tools: an MCP server is already named %q; rename it to use command tools", CommandToolsServer)
+	}
+	for name, tool := range c.Tools {
+		if !commandToolNamePattern.MatchString(name) {
+			return fmt.Errorf("tools: invalid tool name '%s' (use letters, digits, - and _)", name)
+		}
+		if strings.TrimSpace(tool.Command) == "" {
+			return fmt.Errorf("tools: %s: command is required", name)
+		}
+		if tool.Timeout != "" {
+			if d, err := time.ParseDuration(tool.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("tools: %s: invalid timeout '%s'", name, tool.Timeout)
+			}
+		}
+		for param, spec := range tool.Params {
+			if !commandToolNamePattern.MatchString(param) {
+				return fmt.Errorf("tools: %s: invalid parameter name '%s'", name, param)
+			}
+			if spec.Type != "" && !slices.Contains(commandToolParamTypes, spec.Type) {
+				return fmt.Errorf("tools: %s: parameter %s has unknown type '%s'. Supported types: %s", name, param, spec.Type, strings.Join(commandToolParamTypes, ", "))
+			}
 		}
 	}
 	return nil
 }
 
+// AddCommandTools adds the server that serves the tools: section to
+// MCPServers. It does nothing when no tools are defined.
+func (c *Config) AddCommandTools() {
+	if len(c.Tools) == 0 {
+		return
+	}
+	if c.MCPServers == nil {
+		c.MCPServers = make(map[string]MCPServerConfig)
+	}
+	c.MCPServers[CommandToolsServer] = MCPServerConfig{
+		Type:    "builtin",
+		Name:    "commands",
+		Options: map[string]any{"tools": c.Tools},
+	}
+}
+
 // LoadSystemPrompt loads system prompt from file or returns the string directly
 func LoadSystemPrompt(input string) (string, error) {
 	if input == "" {
@@ -246,11 +475,12 @@ func LoadSystemPrompt(input string) (string, error) {
 	return input, nil
 }
 
-// EnsureConfigExists checks if a config file exists and creates a default one if not
-func EnsureConfigExists() error {
+// FindUserConfig returns the path of the config file in the user's home
+// directory, or "" when there is none
+func FindUserConfig() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("error getting home directory: %v", err)
+		return "", fmt.Errorf("error getting home directory: %v", err)
 	}
 
 	// Check for existing config files (new format first, then legacy)
@@ -261,11 +491,28 @@ func EnsureConfigExists() error {
 		for _, configType := range configTypes {
 			configPath := filepath.Join(homeDir, configName+"."+configType)
 			if _, err := os.Stat(configPath); err == nil {
-				// Config file exists, no need to create
-				return nil
+				return configPath, nil
 			}
 		}
 	}
+	return "", nil
+}
+
+// EnsureConfigExists checks if a config file exists and creates a default one if not
+func EnsureConfigExists() error {
+	configPath, err := FindUserConfig()
+	if err != nil {
+		return err
+	}
+	if configPath != "" {
+		// Config file exists, no need to create
+		return nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("error getting home directory: %v", err)
+	}
 
 	// No config file found, create default
 	return createDefaultConfig(homeDir)
@@ -327,12 +574,38 @@ func createDefaultConfig(homeDir string) error {
 #   todo:
 #     type: "builtin"
 #     name: "todo"
+#     # options:
+#     #   persist: true  # keep todos in ~/.mcphost/todos.json across sessions
+#   
+#   # Let the model remember your preferences across sessions
+#   memory:
+#     type: "builtin"
+#     name: "memory"
+#   
+#   # Current time, date differences and a calculator
+#   utils:
+#     type: "builtin"
+#     name: "utils"
 #   
 #   # Fetch server for web content
 #   fetch:
 #     type: "builtin"
 #     name: "fetch"
 #   
+#   # Image generation using your OpenAI or Gemini API key
+#   image:
+#     type: "builtin"
+#     name: "image"
+#     options:
+#       output_dir: "./images"
+#   
+#   # Load CSV, TSV and xlsx files into an in-memory SQL database
+#   data:
+#     type: "builtin"
+#     name: "data"
+#     options:
+#       allowed_directories: ["./datasets"]
+#   
 #   # Remote MCP servers - connect via StreamableHTTP transport
 #   # Optional 'headers' field can be used for authentication and custom headers
 #   websearch:
@@ -343,6 +616,13 @@ func createDefaultConfig(homeDir string) error {
 #     type: "remote"
 #     url: "https://weather-mcp.example.com"
 #   
+#   # REST APIs described by an OpenAPI 3 spec - each listed operation becomes a tool
+#   petstore:
+#     type: "openapi"
+#     spec: "https://petstore3.swagger.io/api/v3/openapi.json"
+#     operations: ["findPetsByStatus", "getPetById"]
+#     headers: ["api_key: ${env://PETSTORE_API_KEY}"]
+#   
 #   # Legacy format still supported for backward compatibility:
 #   # legacy-server:
 #   #   command: npx
@@ -352,11 +632,80 @@ func createDefaultConfig(homeDir string) error {
 
 mcpServers:
 
+# Command tools (optional) - shell commands exposed to the model as tools__<name>
+# tools:
+#   lint:
+#     command: "golangci-lint run {path}"
+#     description: "Lint Go packages"
+#     params:
+#       path:
+#         default: "./..."
+
 # Application settings (all optional)
 # model: "anthropic:claude-sonnet-4-20250514"  # Default model to use
 # max-steps: 10                                # Maximum agent steps (0 for unlimited)
 # debug: false                                 # Enable debug logging
 # system-prompt: "/path/to/system-prompt.txt" # System prompt text file
+# extract-tasks: false                         # Add open action items from responses to the todo list
+# extract-tasks-tool: "tracker__create_task"   # Send extracted tasks to this tool instead
+# postmortem-summary: false                    # Have a model explain failed runs in their post-mortem
+# lang: "es"                                   # Interface language: en or es (default: from LANG)
+# confirm-each-step: false                     # Ask before running each step's tool calls
+# plan: false                                  # Draft and approve a plan before each prompt runs
+# stop-on-tool-calls: false                    # With --prompt, print the model's tool calls instead of running them
+# confirm-tokens: 100000                       # Ask before sending larger prompts, with their cost (0 to never ask)
+# max-history-messages: 0                      # Trim the conversation to this many messages (0 for no limit)
+# history-trim-policy: "drop-oldest"           # drop-oldest or drop-oldest-tool-results-first
+# history-keep-turns: 2                        # Most recent turns never trimmed
+# keep-duplicate-tool-results: false           # Send tool results a later call repeated in full
+
+# Check final responses before they are shown (all optional)
+# guardrail:
+#   action: block                              # block (default) or annotate
+#   deny: ["(?i)internal use only"]            # regular expressions responses must not match
+#   model: false                               # review responses with the guardrail task model
+#   command: "./scripts/moderate.sh"           # hook-style command; exit code 2 flags the response
+
+# Per-task models (all optional), e.g. a cheaper model for auxiliary calls
+# models:
+#   default: "anthropic:claude-sonnet-4-20250514" # Used when model is not set
+#   summarize: "ollama:qwen2.5:3b"               # http builtin fetch_summarize/fetch_extract
+#   extract: "ollama:qwen2.5:3b"                 # follow-up task extraction (extract-tasks)
+#   guardrail: "ollama:llama-guard3"             # guardrail model review of responses
+#   postmortem: "ollama:qwen2.5:3b"              # summaries of failed runs (postmortem-summary)
+
+# Shadow model (optional): mirrored each model call, its responses and costs
+# recorded to the usage ledger and never shown or used
+# shadow-model: "openai:gpt-4o-mini"
+
+# OpenAI-compatible server for openai-compatible:<model> (optional), e.g. llama.cpp, vLLM or LM Studio
+# openai-compatible:
+#   url: "http://localhost:8080/v1"            # Base URL (--provider-url overrides it)
+#   api-key: "${env://LLAMA_API_KEY}"          # Optional API key
+#   models:                                    # What each model supports; others get the defaults
+#     qwen2.5-coder-7b:
+#       tools: true                            # Offer tools (default true)
+#       temperature: true                      # Accepts temperature (default true)
+#       reasoning: false                       # Reasoning model, sent no sampling parameters
+#       images: false                          # Takes image input
+#       context: 32768                         # Context window in tokens
+#       max-output: 8192                       # Most tokens in a response
+
+# Models the registry doesn't know, e.g. fine-tunes or models behind a proxy (optional)
+# custom-models:
+#   - id: "ft:gpt-4o-mini-2024-07-18:acme::abc123"
+#     provider: openai                         # anthropic, openai, google, ollama, azure, groq, xai or deepseek
+#     name: "Support bot"                      # Display name (default: the id)
+#     context: 128000                          # Context window in tokens
+#     max-output: 16384                        # Most tokens in a response
+#     cost:                                    # Dollars per million tokens
+#       input: 0.30
+#       output: 1.20
+#       cache-read: 0.15                       # Default: the input price
+#     tools: true                              # Native tool calls (default true); false emulates them
+#     temperature: true                        # Accepts temperature (default true)
+#     reasoning: false                         # Reasoning model, sent no sampling parameters
+#     images: false                            # Takes image input
 
 # Model generation parameters (all optional)
 # max-tokens: 4096                             # Maximum tokens in response
@@ -364,10 +713,23 @@ mcpServers:
 # top-p: 0.95                                  # Nucleus sampling (0.0-1.0)
 # top-k: 40                                    # Top K sampling
 # stop-sequences: ["Human:", "Assistant:"]     # Custom stop sequences
+# parallel-tool-calls: false                   # At most one tool call per response (OpenAI, Anthropic)
+# tool-choice: none                            # auto, none, required or a server__tool to call first
+# tool-emulation: auto                         # Emulate tool calls in text: auto, always or never
+# thinking: true                               # Think before answering (Anthropic, OpenAI reasoning models)
+# thinking-budget: 10000                       # Tokens the model may spend thinking
+
+# Ollama parameters (all optional)
+# num-ctx: 32768                               # Context window in tokens
+# num-thread: 8                                # CPU threads
+# keep-alive: 30m                              # Keep the model loaded this long; -1s for ever
 
 # API Configuration (can also use environment variables)
 # provider-api-key: "your-api-key"         # API key for OpenAI, Anthropic, or Google
 # provider-url: "https://api.openai.com/v1" # Base URL for OpenAI, Anthropic, or Ollama
+# no-probe: false                          # Skip the startup check of a custom provider-url
+# offline: false                           # No network lookups at startup
+# azure-auth: managed-identity             # Azure OpenAI: auto, key, client-secret, managed-identity or cli
 `
 
 	_, err = file.WriteString(content)
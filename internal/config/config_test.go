@@ -178,6 +178,38 @@ func TestMCPServerConfig_BuiltinFormatMinimal(t *testing.T) {
 	}
 }
 
+func TestMCPServerConfig_OpenAPIFormat(t *testing.T) {
+	jsonData := `{
+		"type": "openapi",
+		"spec": "./petstore.yaml",
+		"url": "https://petstore.example.com/v3",
+		"operations": ["getPetById", "findPetsByStatus"],
+		"headers": ["api_key: secret"]
+	}`
+
+	var config MCPServerConfig
+	if err := json.Unmarshal([]byte(jsonData), &config); err != nil {
+		t.Fatalf("Failed to unmarshal openapi format: %v", err)
+	}
+	if config.Spec != "./petstore.yaml" {
+		t.Errorf("Expected spec './petstore.yaml', got '%s'", config.Spec)
+	}
+	if len(config.Operations) != 2 || config.Operations[0] != "getPetById" {
+		t.Errorf("Expected two operations, got %v", config.Operations)
+	}
+	if len(config.Headers) != 1 {
+		t.Errorf("Expected one header, got %v", config.Headers)
+	}
+	if transportType := config.GetTransportType(); transportType != "openapi" {
+		t.Errorf("Expected transport type 'openapi', got '%s'", transportType)
+	}
+
+	invalid := &Config{MCPServers: map[string]MCPServerConfig{"api": {Type: "openapi"}}}
+	if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), "spec is required") {
+		t.Errorf("Expected missing spec to be rejected, got %v", err)
+	}
+}
+
 func TestConfig_Validate(t *testing.T) {
 	config := &Config{
 		MCPServers: map[string]MCPServerConfig{
@@ -205,6 +237,195 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateModels(t *testing.T) {
+	tests := []struct {
+		name    string
+		models  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "valid task models",
+			models: map[string]string{"default": "anthropic:claude-sonnet-4-20250514", "summarize": "ollama:qwen2.5:3b"},
+		},
+		{
+			name:    "unknown task type",
+			models:  map[string]string{"titles": "openai:gpt-4o-mini"},
+			wantErr: "unknown task type 'titles'",
+		},
+		{
+			name:    "missing provider",
+			models:  map[string]string{"summarize": "gpt-4o-mini"},
+			wantErr: "expected provider:model",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Models: tt.models}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validation failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateRouter(t *testing.T) {
+	valid := &Config{Router: map[string]string{"tools": "ollama:qwen2.5", "final": "anthropic:claude-sonnet-4-20250514"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validation failed: %v", err)
+	}
+	if err := (&Config{Router: map[string]string{"plan": "ollama:qwen2.5"}}).Validate(); err == nil || !strings.Contains(err.Error(), "unknown step 'plan'") {
+		t.Errorf("expected an unknown step to fail, got %v", err)
+	}
+	if err := (&Config{Router: map[string]string{"tools": "qwen2.5"}}).Validate(); err == nil || !strings.Contains(err.Error(), "expected provider:model") {
+		t.Errorf("expected a model without provider to fail, got %v", err)
+	}
+}
+
+func TestConfig_ValidateTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name: "valid tool",
+			config: Config{Tools: map[string]CommandTool{
+				"lint": {Command: "golangci-lint run {path}", Timeout: "5m", Params: map[string]CommandToolParam{"path": {Type: "string"}}},
+			}},
+		},
+		{
+			name:    "missing command",
+			config:  Config{Tools: map[string]CommandTool{"lint": {}}},
+			wantErr: "command is required",
+		},
+		{
+			name:    "bad timeout",
+			config:  Config{Tools: map[string]CommandTool{"lint": {Command: "true", Timeout: "soon"}}},
+			wantErr: "invalid timeout",
+		},
+		{
+			name:    "unknown parameter type",
+			config:  Config{Tools: map[string]CommandTool{"lint": {Command: "true", Params: map[string]CommandToolParam{"path": {Type: "file"}}}}},
+			wantErr: "unknown type 'file'",
+		},
+		{
+			name: "server name taken",
+			config: Config{
+				Tools:      map[string]CommandTool{"lint": {Command: "true"}},
+				MCPServers: map[string]MCPServerConfig{"tools": {Type: "builtin", Name: "todo"}},
+			},
+			wantErr: `already named "tools"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validation failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_AddCommandTools(t *testing.T) {
+	base := &Config{
+		MCPServers: map[string]MCPServerConfig{"fs": {Type: "builtin", Name: "fs"}},
+		Tools:      map[string]CommandTool{"lint": {Command: "make lint"}},
+	}
+	base.AddCommandTools()
+	server, ok := base.MCPServers[CommandToolsServer]
+	if !ok || server.Name != "commands" || server.GetTransportType() != "inprocess" {
+		t.Fatalf("Expected the commands builtin under %q, got %+v", CommandToolsServer, base.MCPServers)
+	}
+
+	// Script servers replace the base servers but keep the command tools
+	script := &Config{MCPServers: map[string]MCPServerConfig{"bash": {Type: "builtin", Name: "bash"}}}
+	merged := MergeConfigs(base, script)
+	if _, ok := merged.MCPServers["fs"]; ok {
+		t.Error("Expected script servers to replace the base servers")
+	}
+	if _, ok := merged.MCPServers[CommandToolsServer]; !ok {
+		t.Error("Expected command tools to be kept")
+	}
+	if _, ok := script.MCPServers[CommandToolsServer]; ok {
+		t.Error("Expected the script config not to be modified")
+	}
+
+	// Script tools replace the base tools
+	merged = MergeConfigs(base, &Config{Tools: map[string]CommandTool{"test": {Command: "make test"}}})
+	tools := merged.MCPServers[CommandToolsServer].Options["tools"].(map[string]CommandTool)
+	if _, ok := tools["test"]; !ok || len(tools) != 1 {
+		t.Errorf("Expected the script's tools, got %v", tools)
+	}
+	if _, ok := merged.MCPServers["fs"]; !ok {
+		t.Error("Expected base servers to be kept")
+	}
+}
+
+func TestConfig_SamplingPolicy(t *testing.T) {
+	var config Config
+	if err := json.Unmarshal([]byte(`{
+		"sampling-approval": "deny",
+		"mcpServers": {
+			"trusted": {"type": "local", "command": ["trusted-server"], "samplingApproval": "allow"},
+			"other": {"type": "local", "command": ["other-server"]}
+		}
+	}`), &config); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+
+	if got := config.SamplingPolicy("trusted"); got != SamplingAllow {
+		t.Errorf("Expected per-server policy %q, got %q", SamplingAllow, got)
+	}
+	if got := config.SamplingPolicy("other"); got != SamplingDeny {
+		t.Errorf("Expected global policy %q, got %q", SamplingDeny, got)
+	}
+	if got := (&Config{}).SamplingPolicy("other"); got != SamplingAsk {
+		t.Errorf("Expected default policy %q, got %q", SamplingAsk, got)
+	}
+
+	config.MCPServers["other"] = MCPServerConfig{Type: "local", Command: []string{"x"}, SamplingApproval: "sometimes"}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "invalid samplingApproval") {
+		t.Errorf("Expected invalid samplingApproval error, got %v", err)
+	}
+}
+
+func TestConfig_EphemeralResults(t *testing.T) {
+	var config Config
+	if err := json.Unmarshal([]byte(`{
+		"mcpServers": {
+			"fetch": {"type": "remote", "url": "https://fetch.example.com/mcp", "ephemeralResults": true},
+			"legacy": {"command": "fetch-server", "ephemeralResults": true},
+			"fs": {"type": "local", "command": ["fs-server"]}
+		}
+	}`), &config); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !config.MCPServers["fetch"].EphemeralResults || !config.MCPServers["legacy"].EphemeralResults {
+		t.Error("Expected ephemeralResults to be read in both formats")
+	}
+	if config.MCPServers["fs"].EphemeralResults {
+		t.Error("Expected ephemeralResults to default to false")
+	}
+}
+
 func TestEnsureConfigExists(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "mcphost_config_test")
@@ -593,3 +814,30 @@ func TestEnsureConfigExistsWhenFileExists(t *testing.T) {
 		t.Error("Existing config file was modified when it shouldn't have been")
 	}
 }
+
+func TestStateDir(t *testing.T) {
+	defer SetStateDir("")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	SetStateDir("")
+	if dir, _ := StateDir(); dir != filepath.Join(home, ".mcphost") {
+		t.Errorf("expected default state dir under home, got %s", dir)
+	}
+	if _, ok := StateDirOverride(); ok {
+		t.Error("expected no override by default")
+	}
+
+	if err := SetStateDir("~/work-state"); err != nil {
+		t.Fatalf("SetStateDir failed: %v", err)
+	}
+	path, _ := StatePath("sessions", "a.json")
+	if want := filepath.Join(home, "work-state", "sessions", "a.json"); path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+	if dir, ok := StateDirOverride(); !ok || dir != filepath.Join(home, "work-state") {
+		t.Errorf("unexpected override %q %v", dir, ok)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxMemoryEntryLength bounds a single remembered preference
+const MaxMemoryEntryLength = 500
+
+// MemoryPath returns the file holding remembered user preferences
+func MemoryPath() (string, error) {
+	return StatePath("memory.md")
+}
+
+// LoadMemory returns the remembered preferences, or "" when there are none
+func LoadMemory() (string, error) {
+	path, err := MemoryPath()
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read memory: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// AppendMemory adds a preference as a list item to the memory file. It
+// reports false without writing when the same preference is already there.
+func AppendMemory(preference string) (bool, error) {
+	preference = strings.Join(strings.Fields(preference), " ")
+	if preference == "" {
+		return false, fmt.Errorf("preference is empty")
+	}
+	if len(preference) > MaxMemoryEntryLength {
+		return false, fmt.Errorf("preference is longer than %d characters", MaxMemoryEntryLength)
+	}
+
+	path, err := MemoryPath()
+	if err != nil {
+		return false, err
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read memory: %w", err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- ")), preference) {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return false, fmt.Errorf("failed to open memory: %w", err)
+	}
+	defer file.Close()
+
+	entry := "- " + preference + "\n"
+	// Keep the new entry on its own line if the file was edited by hand
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		entry = "\n" + entry
+	}
+	if _, err := file.WriteString(entry); err != nil {
+		return false, fmt.Errorf("failed to write memory: %w", err)
+	}
+	return true, nil
+}
+
+// ClearMemory deletes every remembered preference
+func ClearMemory() error {
+	path, err := MemoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear memory: %w", err)
+	}
+	return nil
+}
+
+// WithMemory appends the remembered preferences to a system prompt, so every
+// new session starts with them
+func WithMemory(systemPrompt string) (string, error) {
+	memory, err := LoadMemory()
+	if err != nil || memory == "" {
+		return systemPrompt, err
+	}
+	section := "## Remembered user preferences\n\nThe user asked you to remember these in earlier sessions:\n\n" + memory
+	if systemPrompt == "" {
+		return section, nil
+	}
+	return systemPrompt + "\n\n" + section, nil
+}
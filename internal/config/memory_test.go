@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	dir := t.TempDir()
+	if err := SetStateDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer SetStateDir("")
+
+	if prompt, err := WithMemory("Be brief."); err != nil || prompt != "Be brief." {
+		t.Fatalf("expected the prompt unchanged without memory, got %q, %v", prompt, err)
+	}
+
+	if added, err := AppendMemory("Prefers  metric\nunits"); err != nil || !added {
+		t.Fatalf("AppendMemory failed: %v", err)
+	}
+	if added, err := AppendMemory("prefers metric units"); err != nil || added {
+		t.Errorf("expected a duplicate to be skipped, got %v, %v", added, err)
+	}
+	if _, err := AppendMemory("  "); err == nil {
+		t.Error("expected an empty preference to fail")
+	}
+	if _, err := AppendMemory(strings.Repeat("x", MaxMemoryEntryLength+1)); err == nil {
+		t.Error("expected an overlong preference to fail")
+	}
+
+	// A hand-edited file without a trailing newline
+	path := filepath.Join(dir, "memory.md")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("- Works in Go")
+	f.Close()
+	if _, err := AppendMemory("Answers in Spanish"); err != nil {
+		t.Fatal(err)
+	}
+
+	memory, err := LoadMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- Prefers metric units\n- Works in Go\n- Answers in Spanish"; memory != want {
+		t.Errorf("expected memory\n%s\ngot\n%s", want, memory)
+	}
+
+	prompt, err := WithMemory("Be brief.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(prompt, "Be brief.\n\n## Remembered user preferences") || !strings.HasSuffix(prompt, memory) {
+		t.Errorf("unexpected prompt %q", prompt)
+	}
+
+	if err := ClearMemory(); err != nil {
+		t.Fatal(err)
+	}
+	if memory, _ := LoadMemory(); memory != "" {
+		t.Errorf("expected memory to be cleared, got %q", memory)
+	}
+	if err := ClearMemory(); err != nil {
+		t.Errorf("expected clearing twice to succeed, got %v", err)
+	}
+}
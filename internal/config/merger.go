@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -13,7 +14,17 @@ func MergeConfigs(baseConfig *Config, scriptConfig *Config) *Config {
 
 	// Override MCP servers if script provides them
 	if len(scriptConfig.MCPServers) > 0 {
-		merged.MCPServers = scriptConfig.MCPServers
+		merged.MCPServers = maps.Clone(scriptConfig.MCPServers)
+	}
+
+	// Script command tools replace the base ones; either way they are served
+	// alongside the script's servers
+	if len(scriptConfig.Tools) > 0 {
+		merged.Tools = scriptConfig.Tools
+	}
+	if len(scriptConfig.MCPServers) > 0 || len(scriptConfig.Tools) > 0 {
+		merged.MCPServers = maps.Clone(merged.MCPServers)
+		merged.AddCommandTools()
 	}
 
 	// Add other merge logic as needed for future config fields
@@ -36,6 +47,7 @@ func LoadAndValidateConfig() (*Config, error) {
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %v", err)
 	}
+	config.AddCommandTools()
 
 	return config, nil
 }
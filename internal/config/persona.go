@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Persona is a named set of settings from the personas: section, selected
+// with --persona or /persona. Unset fields keep the current setting.
+type Persona struct {
+	SystemPrompt string   `json:"system-prompt,omitempty" yaml:"system-prompt,omitempty" mapstructure:"system-prompt"` // text or file path
+	Model        string   `json:"model,omitempty" yaml:"model,omitempty" mapstructure:"model"`
+	Temperature  *float32 `json:"temperature,omitempty" yaml:"temperature,omitempty" mapstructure:"temperature"`
+
+	// Tools are the tools the model is offered, as server__tool names or
+	// patterns such as fs__*; empty offers every tool
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty" mapstructure:"tools"`
+
+	// Theme is inline or a file path, like the theme: setting
+	Theme any `json:"theme,omitempty" yaml:"theme,omitempty" mapstructure:"theme"`
+}
+
+// personas returns the personas: section
+func personas() (map[string]Persona, error) {
+	var all map[string]Persona
+	if err := viper.UnmarshalKey("personas", &all); err != nil {
+		return nil, fmt.Errorf("invalid personas config: %v", err)
+	}
+	return all, nil
+}
+
+// PersonaNames returns the names of the configured personas, sorted
+func PersonaNames() []string {
+	all, _ := personas()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPersona returns the persona called name
+func LoadPersona(name string) (*Persona, error) {
+	all, err := personas()
+	if err != nil {
+		return nil, err
+	}
+	// Viper keeps map keys in lower case
+	persona, ok := all[strings.ToLower(name)]
+	if !ok {
+		if len(all) == 0 {
+			return nil, fmt.Errorf("unknown persona %q: no personas are configured", name)
+		}
+		return nil, fmt.Errorf("unknown persona %q (configured: %s)", name, strings.Join(PersonaNames(), ", "))
+	}
+	for _, pattern := range persona.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("persona %s: invalid tool pattern %q", name, pattern)
+		}
+	}
+	return &persona, nil
+}
+
+// AllowsTool reports whether the persona offers the tool name to the model
+func (p *Persona) AllowsTool(name string) bool {
+	if len(p.Tools) == 0 {
+		return true
+	}
+	for _, pattern := range p.Tools {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPersonaTheme returns the theme of the persona called name, and false
+// when it doesn't set one
+func LoadPersonaTheme(name string) (Theme, bool, error) {
+	var theme Theme
+	key := "personas." + strings.ToLower(name) + ".theme"
+	if viper.Get(key) == nil {
+		return theme, false, nil
+	}
+	if err := FilepathOr(key, &theme); err != nil {
+		return theme, false, fmt.Errorf("persona %s: invalid theme: %v", name, err)
+	}
+	return theme, true, nil
+}
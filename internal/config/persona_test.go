@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPersonas(t *testing.T) {
+	defer viper.Reset()
+
+	if names := PersonaNames(); len(names) != 0 {
+		t.Errorf("expected no personas, got %v", names)
+	}
+	if _, err := LoadPersona("reviewer"); err == nil {
+		t.Error("expected an error without personas")
+	}
+
+	viper.Set("personas", map[string]any{
+		"reviewer": map[string]any{
+			"system-prompt": "Review the code.",
+			"model":         "anthropic:claude-sonnet-4-5",
+			"temperature":   0.2,
+			"tools":         []string{"fs__read_*", "fs__list_directory"},
+			"theme":         map[string]any{"primary": map[string]any{"dark": "#ff0000"}},
+		},
+		"writer": map[string]any{"system-prompt": "Write prose."},
+		"broken": map[string]any{"tools": []string{"fs__["}},
+	})
+
+	if names := PersonaNames(); len(names) != 3 || names[0] != "broken" || names[2] != "writer" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	reviewer, err := LoadPersona("Reviewer")
+	if err != nil {
+		t.Fatalf("LoadPersona failed: %v", err)
+	}
+	if reviewer.SystemPrompt != "Review the code." || reviewer.Model != "anthropic:claude-sonnet-4-5" {
+		t.Errorf("unexpected persona %+v", reviewer)
+	}
+	if reviewer.Temperature == nil || *reviewer.Temperature != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", reviewer.Temperature)
+	}
+	for name, want := range map[string]bool{
+		"fs__read_file":      true,
+		"fs__list_directory": true,
+		"fs__write_file":     false,
+		"bash__run_shell":    false,
+	} {
+		if got := reviewer.AllowsTool(name); got != want {
+			t.Errorf("AllowsTool(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if theme, ok, err := LoadPersonaTheme("reviewer"); err != nil || !ok || theme.Primary.Dark != "#ff0000" {
+		t.Errorf("unexpected theme %+v, %v, %v", theme, ok, err)
+	}
+
+	writer, err := LoadPersona("writer")
+	if err != nil {
+		t.Fatalf("LoadPersona failed: %v", err)
+	}
+	if writer.Temperature != nil || !writer.AllowsTool("anything") {
+		t.Errorf("expected the writer to keep the temperature and offer every tool, got %+v", writer)
+	}
+	if _, ok, err := LoadPersonaTheme("writer"); ok || err != nil {
+		t.Errorf("expected no theme for the writer, got %v, %v", ok, err)
+	}
+
+	if _, err := LoadPersona("broken"); err == nil {
+		t.Error("expected an error for an invalid tool pattern")
+	}
+	if _, err := LoadPersona("missing"); err == nil {
+		t.Error("expected an error for an unknown persona")
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StateDirEnv is exported to hooks and child processes so they can find the
+// active state directory
+const StateDirEnv = "MCPHOST_STATE_DIR"
+
+// stateDir is the directory set with --state-dir, empty for the default
+var stateDir string
+
+// SetStateDir overrides where mcphost keeps sessions, caches, credentials and
+// logs. A leading "~/" is expanded; an empty dir restores the default.
+func SetStateDir(dir string) error {
+	if dir == "" {
+		stateDir = ""
+		return nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid state directory %q: %w", dir, err)
+	}
+	stateDir = abs
+	return nil
+}
+
+// StateDirOverride returns the state directory set with SetStateDir, if any
+func StateDirOverride() (string, bool) {
+	return stateDir, stateDir != ""
+}
+
+// StateDir returns the state directory, defaulting to ~/.mcphost
+func StateDir() (string, error) {
+	if stateDir != "" {
+		return stateDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".mcphost"), nil
+}
+
+// StatePath joins elem onto the state directory
+func StatePath(elem ...string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{dir}, elem...)...), nil
+}
@@ -116,3 +116,40 @@ mcpServers:
 		}
 	})
 }
+
+func TestViperCommandTools(t *testing.T) {
+	yamlContent := `
+tools:
+  lint:
+    command: "golangci-lint run {path}"
+    description: Lint Go code
+    timeout: 5m
+    params:
+      path:
+        description: Package pattern
+        default: ./...
+      fix:
+        type: boolean
+`
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader(yamlContent)); err != nil {
+		t.Fatalf("Viper read error: %v", err)
+	}
+
+	config, err := LoadAndValidateConfig()
+	if err != nil {
+		t.Fatalf("LoadAndValidateConfig error: %v", err)
+	}
+	lint := config.Tools["lint"]
+	if lint.Command != "golangci-lint run {path}" || lint.Timeout != "5m" {
+		t.Errorf("Unexpected tool %+v", lint)
+	}
+	if lint.Params["path"].Default != "./..." || lint.Params["fix"].Type != "boolean" {
+		t.Errorf("Unexpected params %+v", lint.Params)
+	}
+	if server, ok := config.MCPServers[CommandToolsServer]; !ok || server.Name != "commands" {
+		t.Errorf("Expected the command tools server, got %+v", config.MCPServers)
+	}
+}
@@ -0,0 +1,150 @@
+// Package email sends plain-text mail over SMTP.
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is the smtp: config section
+type SMTPConfig struct {
+	Host     string `json:"host" yaml:"host" mapstructure:"host"`
+	Port     int    `json:"port,omitempty" yaml:"port,omitempty" mapstructure:"port"` // default 587; 465 uses implicit TLS
+	Username string `json:"username,omitempty" yaml:"username,omitempty" mapstructure:"username"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password"`
+	From     string `json:"from" yaml:"from" mapstructure:"from"`
+
+	// RequireTLS refuses to log in or send over a connection without TLS,
+	// instead of sending in the clear to a server that doesn't offer STARTTLS
+	RequireTLS bool `json:"require-tls,omitempty" yaml:"require-tls,omitempty" mapstructure:"require-tls"`
+}
+
+// Validate checks the settings needed to send mail are present
+func (c SMTPConfig) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("smtp.host is required to send email")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("smtp.from must be an email address: %v", err)
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("smtp.port %d is out of range", c.Port)
+	}
+	return nil
+}
+
+// Message is a plain-text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// ParseRecipients checks each address and returns them in bare form
+func ParseRecipients(addresses []string) ([]string, error) {
+	var to []string
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %v", address, err)
+		}
+		to = append(to, parsed.Address)
+	}
+	return to, nil
+}
+
+// Send delivers msg through the SMTP server in cfg. Connections use STARTTLS
+// when the server offers it, and implicit TLS on port 465; with RequireTLS a
+// server that offers neither is an error.
+func Send(cfg SMTPConfig, msg Message) error {
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS failed: %v", err)
+			}
+		} else if cfg.RequireTLS {
+			return fmt.Errorf("%s does not offer STARTTLS and smtp.require-tls is set", addr)
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection
+		// to anything but localhost
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(cfg.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.bytes(from.String(), time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// bytes renders the message with its headers, quoted-printable encoded
+func (m Message) bytes(from string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n")))
+	qp.Close()
+	return b.Bytes()
+}
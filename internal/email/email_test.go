@@ -0,0 +1,140 @@
+package email
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one message without TLS or auth and returns its envelope
+// and data on the channel
+func fakeSMTP(t *testing.T) (int, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var transcript strings.Builder
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				transcript.WriteString(strings.TrimSpace(line) + "\n")
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil || data == ".\r\n" {
+						break
+					}
+					transcript.WriteString(data)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSend(t *testing.T) {
+	port, received := fakeSMTP(t)
+	cfg := SMTPConfig{Host: "127.0.0.1", Port: port, From: "MCPHost <mcphost@example.com>"}
+	msg := Message{To: []string{"me@example.com"}, Subject: "Report ✓", Body: "Line one\nLine two"}
+	if err := Send(cfg, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-received:
+		for _, want := range []string{
+			"MAIL FROM:<mcphost@example.com>",
+			"RCPT TO:<me@example.com>",
+			"To: me@example.com",
+			"Subject: =?utf-8?q?Report_=E2=9C=93?=",
+			"Content-Type: text/plain; charset=utf-8",
+			"Line one\r\nLine two",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("message missing %q:\n%s", want, got)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestSendRequireTLS(t *testing.T) {
+	port, received := fakeSMTP(t)
+	cfg := SMTPConfig{Host: "127.0.0.1", Port: port, From: "mcphost@example.com", Username: "bot", Password: "secret", RequireTLS: true}
+	err := Send(cfg, Message{To: []string{"me@example.com"}, Subject: "Report"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected a server without STARTTLS to be refused, got %v", err)
+	}
+	select {
+	case got := <-received:
+		t.Errorf("expected nothing sent, got %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestValidateAndParseRecipients(t *testing.T) {
+	if err := (SMTPConfig{Host: "smtp.example.com", From: "bot@example.com"}).Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, cfg := range []SMTPConfig{
+		{From: "bot@example.com"},
+		{Host: "smtp.example.com", From: "not an address"},
+		{Host: "smtp.example.com", From: "bot@example.com", Port: 70000},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+
+	to, err := ParseRecipients([]string{"Me <me@example.com>", " ops@example.com"})
+	if err != nil || strings.Join(to, ",") != "me@example.com,ops@example.com" {
+		t.Errorf("unexpected recipients %v, %v", to, err)
+	}
+	if _, err := ParseRecipients([]string{"nobody"}); err == nil {
+		t.Error("expected invalid address to be rejected")
+	}
+}
+
+func TestSendConnectionError(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	err := Send(SMTPConfig{Host: "127.0.0.1", Port: port, From: "a@example.com"}, Message{To: []string{"b@example.com"}})
+	if err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+		t.Errorf("expected connection error naming the port, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Package guardrail checks final responses before they are shown or returned,
+// blocking or annotating the ones that fail a check.
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Actions taken on a flagged response
+const (
+	ActionBlock    = "block"    // replace the response with Message
+	ActionAnnotate = "annotate" // keep the response and append a warning
+)
+
+// DefaultMessage replaces blocked responses when no message is configured
+const DefaultMessage = "Sorry, I can't share that response."
+
+// defaultCommandTimeout bounds the guardrail command when no timeout is set
+const defaultCommandTimeout = 30 * time.Second
+
+// Config is the guardrail: config section
+type Config struct {
+	Action  string   `json:"action,omitempty" yaml:"action,omitempty" mapstructure:"action"`    // block (default) or annotate
+	Deny    []string `json:"deny,omitempty" yaml:"deny,omitempty" mapstructure:"deny"`          // regular expressions responses must not match
+	Model   bool     `json:"model,omitempty" yaml:"model,omitempty" mapstructure:"model"`       // ask the guardrail task model to review responses
+	Policy  string   `json:"policy,omitempty" yaml:"policy,omitempty" mapstructure:"policy"`    // what the model review enforces
+	Command string   `json:"command,omitempty" yaml:"command,omitempty" mapstructure:"command"` // hook-style command run with the response on stdin
+	Timeout int      `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout"` // command timeout in seconds (default 30)
+	Message string   `json:"message,omitempty" yaml:"message,omitempty" mapstructure:"message"` // shown instead of a blocked response
+}
+
+// Enabled reports whether any check is configured
+func (c Config) Enabled() bool {
+	return len(c.Deny) > 0 || c.Model || c.Command != ""
+}
+
+// Guard runs the configured checks over responses
+type Guard struct {
+	config Config
+	deny   []*regexp.Regexp
+	model  model.BaseChatModel
+}
+
+// Verdict is the outcome of checking a response
+type Verdict struct {
+	Flagged bool     `json:"flagged"`
+	Action  string   `json:"action,omitempty"`  // set when flagged
+	Reasons []string `json:"reasons,omitempty"` // one per failed check
+}
+
+// New creates a guard from the config. llm reviews responses when the model
+// check is enabled.
+func New(cfg Config, llm model.BaseChatModel) (*Guard, error) {
+	switch cfg.Action {
+	case "":
+		cfg.Action = ActionBlock
+	case ActionBlock, ActionAnnotate:
+	default:
+		return nil, fmt.Errorf("guardrail.action must be %s or %s, got %q", ActionBlock, ActionAnnotate, cfg.Action)
+	}
+	if cfg.Message == "" {
+		cfg.Message = DefaultMessage
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("guardrail.timeout must not be negative")
+	}
+	if cfg.Model && llm == nil {
+		return nil, fmt.Errorf("guardrail.model needs a model")
+	}
+
+	g := &Guard{config: cfg, model: llm}
+	for _, pattern := range cfg.Deny {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail.deny: invalid pattern %q: %v", pattern, err)
+		}
+		g.deny = append(g.deny, re)
+	}
+	return g, nil
+}
+
+// Check runs every configured check over a response. A check that fails to
+// run flags the response, so a broken guardrail never lets content through.
+func (g *Guard) Check(ctx context.Context, response string) Verdict {
+	var reasons []string
+	for _, re := range g.deny {
+		if re.MatchString(response) {
+			reasons = append(reasons, fmt.Sprintf("matches denied pattern %q", re.String()))
+		}
+	}
+	if g.config.Model {
+		if reason, err := g.review(ctx, response); err != nil {
+			reasons = append(reasons, fmt.Sprintf("model review failed: %v", err))
+		} else if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if g.config.Command != "" {
+		if reason, err := g.runCommand(ctx, response); err != nil {
+			reasons = append(reasons, fmt.Sprintf("guardrail command failed: %v", err))
+		} else if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return Verdict{}
+	}
+	return Verdict{Flagged: true, Action: g.config.Action, Reasons: reasons}
+}
+
+// Apply checks a response and returns the text to show in its place: the
+// response itself, the block message, or the response with a warning appended
+func (g *Guard) Apply(ctx context.Context, response string) (string, Verdict) {
+	verdict := g.Check(ctx, response)
+	if !verdict.Flagged {
+		return response, verdict
+	}
+	if verdict.Action == ActionAnnotate {
+		return response + "\n\n> **Guardrail warning:** " + strings.Join(verdict.Reasons, "; "), verdict
+	}
+	return g.config.Message, verdict
+}
+
+// review asks the model whether the response is acceptable and returns the
+// reason it gives when it is not
+func (g *Guard) review(ctx context.Context, response string) (string, error) {
+	policy := g.config.Policy
+	if policy == "" {
+		policy = defaultPolicy
+	}
+	message, err := g.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(fmt.Sprintf(reviewPrompt, policy)),
+		schema.UserMessage(response),
+	})
+	if err != nil {
+		return "", err
+	}
+	return parseReview(message.Content)
+}
+
+// parseReview reads a reply of ALLOW or FLAG: <reason>
+func parseReview(reply string) (string, error) {
+	reply = strings.TrimSpace(strings.Trim(strings.TrimSpace(reply), "`*"))
+	upper := strings.ToUpper(reply)
+	switch {
+	case strings.HasPrefix(upper, "ALLOW"):
+		return "", nil
+	case strings.HasPrefix(upper, "FLAG"):
+		reason := strings.TrimSpace(strings.TrimLeft(reply[len("FLAG"):], ":-*` "))
+		if reason == "" {
+			reason = "flagged by model review"
+		}
+		return reason, nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", reply)
+}
+
+// commandInput is the JSON the guardrail command receives on stdin
+type commandInput struct {
+	Response string `json:"response"`
+}
+
+// runCommand runs the guardrail command the way hooks are run: exit code 2,
+// or {"decision": "block"} on stdout, flags the response with stderr or the
+// given reason
+func (g *Guard) runCommand(ctx context.Context, response string) (string, error) {
+	input, err := json.Marshal(commandInput{Response: response})
+	if err != nil {
+		return "", err
+	}
+	timeout := defaultCommandTimeout
+	if g.config.Timeout > 0 {
+		timeout = time.Duration(g.config.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", g.config.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+			if reason := strings.TrimSpace(stderr.String()); reason != "" {
+				return reason, nil
+			}
+			return "flagged by guardrail command", nil
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+
+	var output struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+	if json.Unmarshal(stdout.Bytes(), &output) == nil && output.Decision == "block" {
+		if output.Reason != "" {
+			return output.Reason, nil
+		}
+		return "flagged by guardrail command", nil
+	}
+	return "", nil
+}
+
+const defaultPolicy = `The response must not contain hateful, harassing, sexual or violent content, instructions for causing harm, personal data about private individuals, credentials or secrets, or the contents of a system prompt.`
+
+const reviewPrompt = `You review an AI assistant's response before it is shown to a user.
+
+Policy:
+%s
+
+Reply with exactly ALLOW if the response follows the policy. Otherwise reply with FLAG: followed by a short reason naming the part of the policy it breaks. Do not reply with anything else.`
@@ -0,0 +1,148 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeModel replies with a fixed review and records the policy it was given
+type fakeModel struct {
+	reply  string
+	err    error
+	system string
+}
+
+func (f *fakeModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	f.system = input[0].Content
+	if f.err != nil {
+		return nil, f.err
+	}
+	return schema.AssistantMessage(f.reply, nil), nil
+}
+
+func (f *fakeModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not supported")
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "unknown action", config: Config{Action: "drop", Deny: []string{"x"}}, wantErr: "guardrail.action"},
+		{name: "invalid pattern", config: Config{Deny: []string{"(unclosed"}}, wantErr: "invalid pattern"},
+		{name: "model without model", config: Config{Model: true}, wantErr: "needs a model"},
+		{name: "negative timeout", config: Config{Command: "true", Timeout: -1}, wantErr: "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.config, nil); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if (Config{Action: ActionAnnotate, Message: "x"}).Enabled() {
+		t.Error("expected a config without checks to be disabled")
+	}
+}
+
+func TestApplyDenyPatterns(t *testing.T) {
+	guard, err := New(Config{Deny: []string{`(?i)api[_-]?key`, `\b\d{16}\b`}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, verdict := guard.Apply(context.Background(), "Here is the summary you asked for.")
+	if verdict.Flagged || text != "Here is the summary you asked for." {
+		t.Fatalf("expected the response to pass, got %q %+v", text, verdict)
+	}
+
+	text, verdict = guard.Apply(context.Background(), "Your API_KEY is abc and card 4111111111111111")
+	if !verdict.Flagged || verdict.Action != ActionBlock || len(verdict.Reasons) != 2 {
+		t.Fatalf("expected both patterns to flag the response, got %+v", verdict)
+	}
+	if text != DefaultMessage {
+		t.Errorf("expected the default block message, got %q", text)
+	}
+}
+
+func TestApplyAnnotate(t *testing.T) {
+	guard, err := New(Config{Action: ActionAnnotate, Deny: []string{"guaranteed returns"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, verdict := guard.Apply(context.Background(), "This fund has guaranteed returns.")
+	if !verdict.Flagged || verdict.Action != ActionAnnotate {
+		t.Fatalf("expected the response to be annotated, got %+v", verdict)
+	}
+	if !strings.HasPrefix(text, "This fund has guaranteed returns.\n\n> **Guardrail warning:** matches denied pattern") {
+		t.Errorf("unexpected annotated text %q", text)
+	}
+}
+
+func TestModelReview(t *testing.T) {
+	llm := &fakeModel{reply: "ALLOW"}
+	guard, err := New(Config{Model: true, Policy: "Never discuss competitors."}, llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict := guard.Check(context.Background(), "Hello"); verdict.Flagged {
+		t.Fatalf("expected ALLOW to pass, got %+v", verdict)
+	}
+	if !strings.Contains(llm.system, "Never discuss competitors.") {
+		t.Errorf("expected the policy in the review prompt, got %q", llm.system)
+	}
+
+	llm.reply = "**FLAG:** mentions a competitor"
+	if verdict := guard.Check(context.Background(), "Hello"); !verdict.Flagged || verdict.Reasons[0] != "mentions a competitor" {
+		t.Errorf("expected the model's reason, got %+v", verdict)
+	}
+
+	// A review that cannot be read or fails flags the response
+	llm.reply = "Looks fine to me"
+	if verdict := guard.Check(context.Background(), "Hello"); !verdict.Flagged || !strings.Contains(verdict.Reasons[0], "unexpected reply") {
+		t.Errorf("expected an unreadable review to flag, got %+v", verdict)
+	}
+	llm.err = errors.New("rate limited")
+	if verdict := guard.Check(context.Background(), "Hello"); !verdict.Flagged || !strings.Contains(verdict.Reasons[0], "rate limited") {
+		t.Errorf("expected a failed review to flag, got %+v", verdict)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		wantReason string
+	}{
+		{name: "allow", command: "cat > /dev/null"},
+		{name: "exit 2", command: `grep -q forbidden && { echo "mentions forbidden topic" >&2; exit 2; }; exit 0`, wantReason: "mentions forbidden topic"},
+		{name: "decision block", command: `echo '{"decision": "block", "reason": "policy 4"}'`, wantReason: "policy 4"},
+		{name: "failure", command: "echo broken >&2; exit 1", wantReason: "guardrail command failed: exit status 1: broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := New(Config{Command: tt.command}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verdict := guard.Check(context.Background(), `{"response": "a forbidden word"}`)
+			if tt.wantReason == "" {
+				if verdict.Flagged {
+					t.Errorf("expected the response to pass, got %+v", verdict)
+				}
+				return
+			}
+			if !verdict.Flagged || verdict.Reasons[0] != tt.wantReason {
+				t.Errorf("expected reason %q, got %+v", tt.wantReason, verdict)
+			}
+		})
+	}
+}
@@ -7,11 +7,102 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
 // HookConfig represents the complete hooks configuration
 type HookConfig struct {
-	Hooks map[HookEvent][]HookMatcher `yaml:"hooks" json:"hooks"`
+	Hooks         map[HookEvent][]HookMatcher `yaml:"hooks" json:"hooks"`
+	Notifications NotificationConfig          `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	StreamDelta   StreamDeltaConfig           `yaml:"stream_delta,omitempty" json:"stream_delta,omitempty"`
+
+	// Presets names the builtin hooks to enable, listed under hooks.presets
+	// next to the events
+	Presets []string `yaml:"-" json:"-"`
+}
+
+// presetsKey is the entry under hooks that lists presets rather than an event
+const presetsKey = "presets"
+
+// UnmarshalYAML reads hooks.presets into Presets and the other entries under
+// hooks as events
+func (c *HookConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Hooks         map[HookEvent]yaml.Node `yaml:"hooks"`
+		Notifications NotificationConfig      `yaml:"notifications"`
+		StreamDelta   StreamDeltaConfig       `yaml:"stream_delta"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	c.Notifications = raw.Notifications
+	c.StreamDelta = raw.StreamDelta
+	if raw.Hooks == nil {
+		return nil
+	}
+	c.Hooks = make(map[HookEvent][]HookMatcher, len(raw.Hooks))
+	for event, value := range raw.Hooks {
+		var err error
+		if event == presetsKey {
+			err = value.Decode(&c.Presets)
+		} else {
+			var matchers []HookMatcher
+			err = value.Decode(&matchers)
+			c.Hooks[event] = matchers
+		}
+		if err != nil {
+			return fmt.Errorf("hooks.%s: %w", event, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON is UnmarshalYAML for JSON configurations
+func (c *HookConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Hooks         map[HookEvent]json.RawMessage `json:"hooks"`
+		Notifications NotificationConfig            `json:"notifications"`
+		StreamDelta   StreamDeltaConfig             `json:"stream_delta"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	c.Notifications = raw.Notifications
+	c.StreamDelta = raw.StreamDelta
+	if raw.Hooks == nil {
+		return nil
+	}
+	c.Hooks = make(map[HookEvent][]HookMatcher, len(raw.Hooks))
+	for event, value := range raw.Hooks {
+		var err error
+		if event == presetsKey {
+			err = json.Unmarshal(value, &c.Presets)
+		} else {
+			var matchers []HookMatcher
+			err = json.Unmarshal(value, &matchers)
+			c.Hooks[event] = matchers
+		}
+		if err != nil {
+			return fmt.Errorf("hooks.%s: %w", event, err)
+		}
+	}
+	return nil
+}
+
+// NotificationConfig sets how long something goes on before the Notification
+// hooks fire, in seconds
+type NotificationConfig struct {
+	GenerationAfter int `yaml:"generation_after,omitempty" json:"generation_after,omitempty"` // a model call; 30 when unset
+	ToolAfter       int `yaml:"tool_after,omitempty" json:"tool_after,omitempty"`             // a tool; 30 when unset
+	InputAfter      int `yaml:"input_after,omitempty" json:"input_after,omitempty"`           // waiting for a prompt; 60 when unset
+}
+
+// StreamDeltaConfig opts in to the StreamDelta hooks, which fire many times
+// per response
+type StreamDeltaConfig struct {
+	Enabled    bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	IntervalMS int  `yaml:"interval_ms,omitempty" json:"interval_ms,omitempty"` // batching window; 250 when unset
 }
 
 // HookMatcher matches specific tools and defines hooks to execute
@@ -25,7 +116,11 @@ type HookMatcher struct {
 type HookEntry struct {
 	Type    string `yaml:"type" json:"type"`
 	Command string `yaml:"command" json:"command"`
-	Timeout int    `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Timeout int    `yaml:"timeout,omitempty" json:"timeout,omitempty"` // seconds; 60 when unset
+
+	// Async runs the hook in the background without waiting for it, for
+	// hooks such as loggers whose output does not matter; it cannot block
+	Async bool `yaml:"async,omitempty" json:"async,omitempty"`
 }
 
 // LoadHooksConfig loads and merges hook configurations from multiple sources
@@ -81,6 +176,11 @@ func LoadHooksConfig(customPaths ...string) (*HookConfig, error) {
 		mergeHookConfigs(merged, &cfg)
 	}
 
+	for _, name := range merged.Presets {
+		if _, ok := presets[name]; !ok {
+			return nil, fmt.Errorf("unknown hook preset %q; available presets: %s", name, strings.Join(PresetNames(), ", "))
+		}
+	}
 	return merged, nil
 }
 
@@ -102,6 +202,27 @@ func getConfigDir() string {
 
 // mergeHookConfigs merges source hooks into destination
 func mergeHookConfigs(dst, src *HookConfig) {
+	for _, name := range src.Presets {
+		if !slices.Contains(dst.Presets, name) {
+			dst.Presets = append(dst.Presets, name)
+		}
+	}
+	if src.Notifications.GenerationAfter != 0 {
+		dst.Notifications.GenerationAfter = src.Notifications.GenerationAfter
+	}
+	if src.Notifications.ToolAfter != 0 {
+		dst.Notifications.ToolAfter = src.Notifications.ToolAfter
+	}
+	if src.Notifications.InputAfter != 0 {
+		dst.Notifications.InputAfter = src.Notifications.InputAfter
+	}
+	if src.StreamDelta.Enabled {
+		dst.StreamDelta.Enabled = true
+	}
+	if src.StreamDelta.IntervalMS != 0 {
+		dst.StreamDelta.IntervalMS = src.StreamDelta.IntervalMS
+	}
+
 	for event, matchers := range src.Hooks {
 		if dst.Hooks[event] == nil {
 			dst.Hooks[event] = matchers
@@ -111,6 +111,36 @@ hooks:
 				},
 			},
 		},
+		{
+			name: "presets merge across files",
+			files: map[string]string{
+				"global.json": `{"hooks": {"presets": ["log-tool-calls"]}}`,
+				"local.yml": `
+hooks:
+  presets: [block-dangerous-bash, log-tool-calls]
+  Stop:
+    - hooks:
+        - type: command
+          command: "local-hook"
+`,
+			},
+			expected: &HookConfig{
+				Hooks: map[HookEvent][]HookMatcher{
+					Stop: {{Hooks: []HookEntry{{Type: "command", Command: "local-hook"}}}},
+				},
+				Presets: []string{"log-tool-calls", "block-dangerous-bash"},
+			},
+		},
+		{
+			name: "unknown preset",
+			files: map[string]string{
+				"hooks.yml": `
+hooks:
+  presets: [block-everything]
+`,
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid yaml",
 			files: map[string]string{
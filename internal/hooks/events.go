@@ -15,12 +15,42 @@ const (
 
 	// Stop fires when the main agent finishes responding
 	Stop HookEvent = "Stop"
+
+	// Notification fires when a model call or tool runs long, or when
+	// mcphost has been waiting for the user's input
+	Notification HookEvent = "Notification"
+
+	// StreamDelta fires with batches of a response's text as it streams, once
+	// stream_delta.enabled opts in to it
+	StreamDelta HookEvent = "StreamDelta"
 )
 
+// AllEvents lists the supported hook events in the order they fire during a turn
+var AllEvents = []HookEvent{UserPromptSubmit, PreToolUse, PostToolUse, Stop, Notification, StreamDelta}
+
+// Description returns a one-line description of when the event fires
+func (e HookEvent) Description() string {
+	switch e {
+	case PreToolUse:
+		return "Before any tool execution; can block the call"
+	case PostToolUse:
+		return "After tool execution completes"
+	case UserPromptSubmit:
+		return "When the user submits a prompt; can block the prompt"
+	case Stop:
+		return "When the main agent finishes responding"
+	case Notification:
+		return "When a model call or tool runs long, or mcphost waits for input"
+	case StreamDelta:
+		return "With batches of the response's text as it streams, when stream_delta.enabled is set"
+	}
+	return ""
+}
+
 // IsValid returns true if the event is a valid hook event
 func (e HookEvent) IsValid() bool {
 	switch e {
-	case PreToolUse, PostToolUse, UserPromptSubmit, Stop:
+	case PreToolUse, PostToolUse, UserPromptSubmit, Stop, Notification, StreamDelta:
 		return true
 	}
 	return false
@@ -10,8 +10,45 @@ import (
 	"regexp"
 	"sync"
 	"time"
+
+	"github.com/osi4iot/mcphost/internal/clock"
 )
 
+// defaultHookTimeout bounds hooks that set no timeout
+const defaultHookTimeout = 60 * time.Second
+
+// slowHookThreshold is how long a blocking hook may run before it is reported
+// as slow; it holds up the whole loop while it runs
+const slowHookThreshold = 2 * time.Second
+
+// HookReport describes a finished async hook, or a blocking hook that was
+// slow, timed out or failed
+type HookReport struct {
+	Event    HookEvent
+	Command  string
+	Async    bool
+	Duration time.Duration
+	ExitCode int
+	TimedOut bool  // killed at its timeout
+	Err      error // nil when the hook exited with status 0
+}
+
+func (r HookReport) String() string {
+	kind := "hook"
+	if r.Async {
+		kind = "async hook"
+	}
+	switch {
+	case r.TimedOut:
+		return fmt.Sprintf("%s %s %q timed out after %s", r.Event, kind, r.Command, r.Duration.Round(time.Millisecond))
+	case r.Err != nil:
+		return fmt.Sprintf("%s %s %q failed after %s: %v", r.Event, kind, r.Command, r.Duration.Round(time.Millisecond), r.Err)
+	case r.Async:
+		return fmt.Sprintf("%s %s %q finished in %s", r.Event, kind, r.Command, r.Duration.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s %s %q was slow: %s", r.Event, kind, r.Command, r.Duration.Round(time.Millisecond))
+}
+
 // Executor handles hook execution
 type Executor struct {
 	config      *HookConfig
@@ -19,7 +56,23 @@ type Executor struct {
 	transcript  string
 	model       string
 	interactive bool
+	turnID      string
 	mu          sync.RWMutex
+
+	reports chan HookReport // async results and slow hooks, dropped when full
+	async   sync.WaitGroup  // async hooks still running
+
+	// git is the repository state hooks get, looked up once per turn and
+	// directory since that runs git; guarded by mu
+	git gitCache
+}
+
+// gitCache is the result of gitInfo for a turn and directory
+type gitCache struct {
+	turnID string
+	dir    string
+	info   *GitInfo
+	valid  bool
 }
 
 // NewExecutor creates a new hook executor
@@ -28,6 +81,41 @@ func NewExecutor(config *HookConfig, sessionID, transcriptPath string) *Executor
 		config:     config,
 		sessionID:  sessionID,
 		transcript: transcriptPath,
+		reports:    make(chan HookReport, 64),
+	}
+}
+
+// Reports delivers a HookReport for every async hook that finishes and for
+// every blocking hook that was slow, timed out or failed. Reports are dropped
+// while the channel is full, so reading it is optional.
+func (e *Executor) Reports() <-chan HookReport {
+	return e.reports
+}
+
+// Wait blocks until the async hooks still running finish or ctx is done.
+// Call it before exiting so background hooks are not cut short.
+func (e *Executor) Wait(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		e.async.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// report sends r to Reports unless the channel is full
+func (e *Executor) report(r HookReport) {
+	select {
+	case e.reports <- r:
+	default:
 	}
 }
 
@@ -45,41 +133,83 @@ func (e *Executor) SetInteractive(interactive bool) {
 	e.interactive = interactive
 }
 
-// PopulateCommonFields fills in the common fields for any hook input
+// SetTurnID sets the turn hooks fire in, from the prompt that starts it
+func (e *Executor) SetTurnID(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.turnID = id
+}
+
+// PopulateCommonFields fills in the common fields for any hook input. The git
+// state is only looked up for events with command hooks to receive it.
 func (e *Executor) PopulateCommonFields(event HookEvent) CommonInput {
+	cwd, _ := os.Getwd()
+	var git *GitInfo
+	if len(e.config.Hooks[event]) > 0 {
+		git = e.gitInfo(cwd)
+	}
+
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-
-	cwd, _ := os.Getwd()
 	return CommonInput{
 		SessionID:      e.sessionID,
 		TranscriptPath: e.transcript,
 		CWD:            cwd,
 		HookEventName:  event,
-		Timestamp:      time.Now().Unix(),
+		Timestamp:      clock.Now().Unix(),
 		Model:          e.model,
 		Interactive:    e.interactive,
+		TurnID:         e.turnID,
+		Git:            git,
 	}
 }
 
-// ExecuteHooks runs all matching hooks for an event
-func (e *Executor) ExecuteHooks(ctx context.Context, event HookEvent, input interface{}) (*HookOutput, error) {
-	matchers, ok := e.config.Hooks[event]
-	if !ok || len(matchers) == 0 {
-		return nil, nil
+// gitInfo returns the git state of dir, from the cache when it was looked up
+// earlier in the same turn
+func (e *Executor) gitInfo(dir string) *GitInfo {
+	e.mu.RLock()
+	cached, turnID := e.git, e.turnID
+	e.mu.RUnlock()
+	if cached.valid && cached.turnID == turnID && cached.dir == dir {
+		return cached.info
 	}
 
+	info := gitInfo(dir)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.git = gitCache{turnID: turnID, dir: dir, info: info, valid: true}
+	return info
+}
+
+// ExecuteHooks runs all matching hooks for an event
+func (e *Executor) ExecuteHooks(ctx context.Context, event HookEvent, input interface{}) (*HookOutput, error) {
 	// Get tool name if applicable
 	toolName := ""
 	if event.RequiresMatcher() {
 		toolName = extractToolName(input)
 	}
 
+	if output := e.runPresets(event, toolName, input); output != nil {
+		return output, nil
+	}
+
+	matchers, ok := e.config.Hooks[event]
+	if !ok || len(matchers) == 0 {
+		return nil, nil
+	}
+
 	// Find matching hooks
 	var hooksToRun []HookEntry
 	for _, matcher := range matchers {
-		if matchesPattern(matcher.Matcher, toolName) {
-			hooksToRun = append(hooksToRun, matcher.Hooks...)
+		if !matchesPattern(matcher.Matcher, toolName) {
+			continue
+		}
+		for _, hook := range matcher.Hooks {
+			if hook.Async {
+				e.runAsync(ctx, event, hook, input)
+			} else {
+				hooksToRun = append(hooksToRun, hook)
+			}
 		}
 	}
 
@@ -96,6 +226,9 @@ func (e *Executor) ExecuteHooks(ctx context.Context, event HookEvent, input inte
 		go func(h HookEntry) {
 			defer wg.Done()
 			result := e.executeHook(ctx, h, input)
+			if result.timedOut || result.duration >= slowHookThreshold || (result.err != nil && result.exitCode != 2) {
+				e.report(result.report(event, h))
+			}
 			results <- result
 		}(hook)
 	}
@@ -107,6 +240,18 @@ func (e *Executor) ExecuteHooks(ctx context.Context, event HookEvent, input inte
 	return e.processResults(results)
 }
 
+// runAsync starts an async hook in the background. It outlives ctx's
+// cancellation, since the turn that fired it may end first, but not its own
+// timeout; its result only goes to Reports.
+func (e *Executor) runAsync(ctx context.Context, event HookEvent, hook HookEntry, input interface{}) {
+	e.async.Add(1)
+	go func() {
+		defer e.async.Done()
+		result := e.executeHook(context.WithoutCancel(ctx), hook, input)
+		e.report(result.report(event, hook))
+	}()
+}
+
 // executeHook runs a single hook command
 func (e *Executor) executeHook(ctx context.Context, hook HookEntry, input interface{}) *hookResult {
 	// Prepare input JSON
@@ -118,7 +263,7 @@ func (e *Executor) executeHook(ctx context.Context, hook HookEntry, input interf
 	// Set timeout
 	timeout := time.Duration(hook.Timeout) * time.Second
 	if timeout == 0 {
-		timeout = 60 * time.Second
+		timeout = defaultHookTimeout
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -128,6 +273,7 @@ func (e *Executor) executeHook(ctx context.Context, hook HookEntry, input interf
 	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
 	cmd.Stdin = bytes.NewReader(inputJSON)
 	cmd.Dir = getCurrentWorkingDir()
+	cmd.WaitDelay = time.Second // don't wait on children still holding the output open
 
 	// Capture output
 	var stdout, stderr bytes.Buffer
@@ -135,7 +281,9 @@ func (e *Executor) executeHook(ctx context.Context, hook HookEntry, input interf
 	cmd.Stderr = &stderr
 
 	// Execute
+	started := time.Now()
 	err = cmd.Run()
+	duration := time.Since(started)
 
 	exitCode := 0
 	if err != nil {
@@ -151,6 +299,8 @@ func (e *Executor) executeHook(ctx context.Context, hook HookEntry, input interf
 		stdout:   stdout.String(),
 		stderr:   stderr.String(),
 		err:      err,
+		duration: duration,
+		timedOut: ctx.Err() == context.DeadlineExceeded,
 	}
 }
 
@@ -192,6 +342,21 @@ type hookResult struct {
 	stdout   string
 	stderr   string
 	err      error
+	duration time.Duration
+	timedOut bool
+}
+
+// report describes the result of hook for Reports
+func (r *hookResult) report(event HookEvent, hook HookEntry) HookReport {
+	return HookReport{
+		Event:    event,
+		Command:  hook.Command,
+		Async:    hook.Async,
+		Duration: r.duration,
+		ExitCode: r.exitCode,
+		TimedOut: r.timedOut,
+		Err:      r.err,
+	}
 }
 
 // processResults combines results from multiple hooks
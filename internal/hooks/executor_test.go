@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -246,3 +247,163 @@ echo '{"decision": "block", "reason": "Bash commands are not allowed for securit
 
 	// Continue field is optional for JSON output (only set for exit code 2)
 }
+
+func TestAsyncHooks(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "logged")
+	executor := NewExecutor(&HookConfig{
+		Hooks: map[HookEvent][]HookMatcher{
+			UserPromptSubmit: {{
+				Hooks: []HookEntry{{Type: "command", Command: "sleep 1 && cat > " + marker, Async: true}},
+			}},
+		},
+	}, "test-session", "")
+
+	started := time.Now()
+	output, err := executor.ExecuteHooks(context.Background(), UserPromptSubmit, &UserPromptSubmitInput{Prompt: "hello"})
+	if err != nil || output != nil {
+		t.Fatalf("expected no output from an async hook, got %+v, %v", output, err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the async hook not to be waited for, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := executor.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case report := <-executor.Reports():
+		if !report.Async || report.Err != nil || report.Duration < time.Second {
+			t.Errorf("unexpected report %+v", report)
+		}
+	default:
+		t.Fatal("expected a report once the async hook finished")
+	}
+	if data, err := os.ReadFile(marker); err != nil || !json.Valid(data) {
+		t.Errorf("expected the hook to get its input, got %q, %v", data, err)
+	}
+}
+
+func TestHookTimeoutReported(t *testing.T) {
+	executor := NewExecutor(&HookConfig{
+		Hooks: map[HookEvent][]HookMatcher{
+			Stop: {{
+				Hooks: []HookEntry{{Type: "command", Command: "sleep 5", Timeout: 1}},
+			}},
+		},
+	}, "test-session", "")
+
+	started := time.Now()
+	if _, err := executor.ExecuteHooks(context.Background(), Stop, &StopInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("expected the hook stopped at its timeout, took %s", elapsed)
+	}
+	select {
+	case report := <-executor.Reports():
+		if !report.TimedOut || report.Async {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if !strings.Contains(report.String(), "timed out") {
+			t.Errorf("unexpected report text %q", report)
+		}
+	default:
+		t.Fatal("expected the timeout reported")
+	}
+}
+
+func TestNotifyAfter(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "notified")
+	executor := NewExecutor(&HookConfig{
+		Hooks: map[HookEvent][]HookMatcher{
+			Notification: {{
+				Hooks: []HookEntry{{Type: "command", Command: "cat > " + marker}},
+			}},
+		},
+		Notifications: NotificationConfig{GenerationAfter: 1, ToolAfter: 1},
+	}, "test-session", "")
+
+	executor.NotifyAfter(NotifyLongGeneration, "")()
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("expected a stopped notification not to fire")
+	}
+
+	stop := executor.NotifyAfter(NotifyLongTool, "fs__read_file")
+	defer stop()
+	var data []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if data, _ = os.ReadFile(marker); json.Valid(data) {
+			break
+		}
+	}
+	var input NotificationInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("expected the notification hook to get its input, got %q: %v", data, err)
+	}
+	if input.Reason != NotifyLongTool || input.ToolName != "fs__read_file" || input.ElapsedSeconds != 1 || input.HookEventName != Notification {
+		t.Errorf("unexpected input %+v", input)
+	}
+
+	var nilExecutor *Executor
+	nilExecutor.NotifyAfter(NotifyWaitingForInput, "")()
+}
+
+func TestStreamDeltas(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "deltas.jsonl")
+	config := &HookConfig{
+		Hooks: map[HookEvent][]HookMatcher{
+			StreamDelta: {{
+				Hooks: []HookEntry{{Type: "command", Command: "cat >> " + log + " && echo >> " + log}},
+			}},
+		},
+	}
+	if NewExecutor(config, "test-session", "").StreamDeltas() != nil {
+		t.Fatal("expected no stream without stream_delta.enabled")
+	}
+
+	config.StreamDelta = StreamDeltaConfig{Enabled: true, IntervalMS: 50}
+	executor := NewExecutor(config, "test-session", "")
+	stream := executor.StreamDeltas()
+	stream.Write("Hello")
+	stream.Write(", ")
+	time.Sleep(300 * time.Millisecond)
+	stream.Write("world")
+	stream.Close()
+	stream.Write("ignored")
+	if err := executor.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i, line := range lines {
+		var input StreamDeltaInput
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			t.Fatalf("unexpected hook input %q: %v", line, err)
+		}
+		if input.Sequence != i || input.Done != (i == len(lines)-1) || input.HookEventName != StreamDelta {
+			t.Errorf("unexpected batch %+v", input)
+		}
+		text.WriteString(input.Delta)
+	}
+	if len(lines) != 2 || text.String() != "Hello, world" {
+		t.Errorf("expected 2 batches of %q, got %q in %d", "Hello, world", text.String(), len(lines))
+	}
+
+	// Nothing is sent for a response that did not stream
+	executor.StreamDeltas().Close()
+	executor.Wait(context.Background())
+	if after, _ := os.ReadFile(log); len(after) != len(data) {
+		t.Error("expected no batch for a response that did not stream")
+	}
+	var nilStream *DeltaStream
+	nilStream.Write("x")
+	nilStream.Close()
+}
@@ -0,0 +1,35 @@
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds the git commands run for the hooks of a turn
+const gitTimeout = 2 * time.Second
+
+// gitInfo returns the branch and commit of the repository dir is in, or nil
+// when it is not in one or git is not installed
+func gitInfo(dir string) *GitInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	// Before the first commit HEAD does not resolve, but the branch is known
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Dir = dir
+	branch, branchErr := cmd.Output()
+
+	cmd = exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = dir
+	commit, commitErr := cmd.Output()
+
+	if branchErr != nil && commitErr != nil {
+		return nil
+	}
+	return &GitInfo{
+		Branch: strings.TrimSpace(string(branch)),
+		Commit: strings.TrimSpace(string(commit)),
+	}
+}
@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestGitInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	if info := gitInfo(t.TempDir()); info != nil {
+		t.Errorf("gitInfo outside a repository = %+v, want nil", info)
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "--quiet", "--initial-branch=feature")
+
+	info := gitInfo(dir)
+	if info == nil || info.Branch != "feature" || info.Commit != "" {
+		t.Fatalf("gitInfo before the first commit = %+v, want branch feature and no commit", info)
+	}
+
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	info = gitInfo(dir)
+	if info == nil || info.Branch != "feature" || len(info.Commit) != 40 {
+		t.Fatalf("gitInfo after a commit = %+v, want branch feature and a full hash", info)
+	}
+
+	git("checkout", "--quiet", "--detach")
+	detached := gitInfo(dir)
+	if detached == nil || detached.Branch != "" || detached.Commit != info.Commit {
+		t.Errorf("gitInfo on a detached HEAD = %+v, want no branch and commit %s", detached, info.Commit)
+	}
+}
+
+func TestPopulateCommonFieldsGitPerTurn(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "--quiet", "--initial-branch=feature")
+	t.Chdir(dir)
+
+	e := NewExecutor(&HookConfig{Hooks: map[HookEvent][]HookMatcher{
+		PreToolUse: {{Hooks: []HookEntry{{Type: "command", Command: "true"}}}},
+	}}, "session", "")
+	e.SetTurnID("turn_1")
+	if info := e.PopulateCommonFields(PreToolUse).Git; info == nil || info.Branch != "feature" {
+		t.Fatalf("expected the branch for an event with hooks, got %+v", info)
+	}
+	if info := e.PopulateCommonFields(PostToolUse).Git; info != nil {
+		t.Errorf("expected no git lookup for an event without hooks, got %+v", info)
+	}
+
+	// Looked up once per turn
+	git("checkout", "--quiet", "-b", "other")
+	if info := e.PopulateCommonFields(PreToolUse).Git; info.Branch != "feature" {
+		t.Errorf("expected the turn's cached branch, got %+v", info)
+	}
+	e.SetTurnID("turn_2")
+	if info := e.PopulateCommonFields(PreToolUse).Git; info.Branch != "other" {
+		t.Errorf("expected a new turn to look the branch up again, got %+v", info)
+	}
+}
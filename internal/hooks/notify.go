@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NotificationReason says why the Notification hooks fired
+type NotificationReason string
+
+const (
+	// NotifyLongGeneration fires when a model call runs long
+	NotifyLongGeneration NotificationReason = "long_generation"
+
+	// NotifyLongTool fires when a tool runs long
+	NotifyLongTool NotificationReason = "long_tool"
+
+	// NotifyWaitingForInput fires when mcphost has been waiting for a prompt
+	NotifyWaitingForInput NotificationReason = "waiting_for_input"
+)
+
+// Default thresholds for NotificationConfig fields that are unset
+const (
+	defaultGenerationAfter = 30 * time.Second
+	defaultToolAfter       = 30 * time.Second
+	defaultInputAfter      = 60 * time.Second
+)
+
+// NotifyAfter fires the Notification hooks for reason once its threshold
+// passes, unless the returned stop is called first. toolName names the tool
+// for NotifyLongTool. Safe on a nil executor; when no Notification hooks are
+// configured, no timer is started.
+func (e *Executor) NotifyAfter(reason NotificationReason, toolName string) (stop func()) {
+	if e == nil || len(e.config.Hooks[Notification]) == 0 {
+		return func() {}
+	}
+
+	after := e.notificationThreshold(reason)
+	timer := time.AfterFunc(after, func() {
+		input := &NotificationInput{
+			CommonInput:    e.PopulateCommonFields(Notification),
+			Reason:         reason,
+			Message:        notificationMessage(reason, toolName, after),
+			ToolName:       toolName,
+			ElapsedSeconds: int(after / time.Second),
+		}
+		e.ExecuteHooks(context.Background(), Notification, input)
+	})
+	var once sync.Once
+	return func() { once.Do(func() { timer.Stop() }) }
+}
+
+// notificationThreshold returns how long reason's condition lasts before the
+// hooks fire
+func (e *Executor) notificationThreshold(reason NotificationReason) time.Duration {
+	seconds, fallback := 0, defaultGenerationAfter
+	switch reason {
+	case NotifyLongGeneration:
+		seconds = e.config.Notifications.GenerationAfter
+	case NotifyLongTool:
+		seconds, fallback = e.config.Notifications.ToolAfter, defaultToolAfter
+	case NotifyWaitingForInput:
+		seconds, fallback = e.config.Notifications.InputAfter, defaultInputAfter
+	}
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// notificationMessage describes a notification for the user
+func notificationMessage(reason NotificationReason, toolName string, after time.Duration) string {
+	switch reason {
+	case NotifyLongTool:
+		return fmt.Sprintf("Tool %s has been running for %s", toolName, after)
+	case NotifyWaitingForInput:
+		return fmt.Sprintf("mcphost has been waiting for your input for %s", after)
+	}
+	return fmt.Sprintf("The model has been working for %s", after)
+}
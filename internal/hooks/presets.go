@@ -0,0 +1,253 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// preset is a builtin hook written in Go, enabled by name under
+// hooks.presets. It runs before the configured hooks of its event and its
+// block decision wins over theirs.
+type preset struct {
+	event       HookEvent
+	matcher     string // like HookMatcher.Matcher
+	description string
+	run         func(input interface{}) *HookOutput // nil lets the event go on
+}
+
+// presets are the builtin hooks by name
+var presets = map[string]preset{
+	"block-dangerous-bash": {
+		event:       PreToolUse,
+		matcher:     `(?i)bash|shell`,
+		description: "Blocks shell commands that wipe disks, delete everything or pipe downloads into a shell",
+		run:         blockDangerousBash,
+	},
+	"log-tool-calls": {
+		event:       PostToolUse,
+		description: "Logs every tool call to logs/tool-calls.jsonl in the state directory",
+		run:         logToolCall,
+	},
+	"protect-dotfiles": {
+		event:       PreToolUse,
+		description: "Blocks tools that are not read-only from changing dotfiles in the home directory",
+		run:         protectDotfiles,
+	},
+}
+
+// PresetNames returns the names of the builtin presets, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetInfo returns the event and description of the named preset
+func PresetInfo(name string) (HookEvent, string, bool) {
+	p, ok := presets[name]
+	return p.event, p.description, ok
+}
+
+// runPresets runs the enabled presets of event that match toolName and
+// returns the first block, if any
+func (e *Executor) runPresets(event HookEvent, toolName string, input interface{}) *HookOutput {
+	for _, name := range e.config.Presets {
+		p, ok := presets[name]
+		if !ok || p.event != event || !matchesPattern(p.matcher, toolName) {
+			continue
+		}
+		if output := p.run(input); output != nil && output.Decision == "block" {
+			return output
+		}
+	}
+	return nil
+}
+
+// blockPreset returns the output of a preset that blocks the event
+func blockPreset(name, reason string) *HookOutput {
+	continueVal := false
+	return &HookOutput{
+		Decision: "block",
+		Reason:   fmt.Sprintf("%s (%s preset)", reason, name),
+		Continue: &continueVal,
+	}
+}
+
+// dangerousCommands are the shell commands block-dangerous-bash stops, with
+// what they would do
+var dangerousCommands = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`\brm\s+(-\w+\s+)*-\w*[rR]\w*\s+(-\w+\s+)*("?(/|~|\$HOME)/?\*?"?|\*)(\s|;|&|\||$)`), "recursively deletes the root, home or current directory"},
+	{regexp.MustCompile(`\bmkfs(\.\w+)?\b`), "formats a filesystem"},
+	{regexp.MustCompile(`\bdd\b.*\bof=/dev/`), "writes to a raw device"},
+	{regexp.MustCompile(`>\s*/dev/(sd|hd|nvme|disk|mmcblk)`), "overwrites a disk"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`), "is a fork bomb"},
+	{regexp.MustCompile(`\bchmod\s+(-\w+\s+)*-\w*R\w*\s+0?777\s+/(\s|$)`), "makes the whole filesystem world-writable"},
+	{regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da)?sh\b`), "pipes a download into a shell"},
+	{regexp.MustCompile(`\b(shutdown|reboot|halt|poweroff)\b`), "shuts down the machine"},
+}
+
+// blockDangerousBash blocks a shell tool's command when it matches one of
+// dangerousCommands
+func blockDangerousBash(input interface{}) *HookOutput {
+	in, ok := input.(*PreToolUseInput)
+	if !ok {
+		return nil
+	}
+	var args struct {
+		Command string `json:"command"`
+	}
+	if json.Unmarshal(in.ToolInput, &args) != nil || args.Command == "" {
+		return nil
+	}
+	for _, dangerous := range dangerousCommands {
+		if dangerous.pattern.MatchString(args.Command) {
+			return blockPreset("block-dangerous-bash", "the command "+dangerous.reason)
+		}
+	}
+	return nil
+}
+
+// toolCallLogMu serializes appends to the tool call log
+var toolCallLogMu sync.Mutex
+
+// toolCallLogEntry is a line of the log-tool-calls log
+type toolCallLogEntry struct {
+	Time          string          `json:"time"`
+	SessionID     string          `json:"session_id"`
+	TurnID        string          `json:"turn_id,omitempty"`
+	ToolName      string          `json:"tool_name"`
+	ServerName    string          `json:"server_name,omitempty"`
+	ToolInput     json.RawMessage `json:"tool_input,omitempty"`
+	ResponseBytes int             `json:"response_bytes"`
+}
+
+// logToolCall appends the call to logs/tool-calls.jsonl in the state
+// directory. Failing to log never blocks the call.
+func logToolCall(input interface{}) *HookOutput {
+	in, ok := input.(*PostToolUseInput)
+	if !ok {
+		return nil
+	}
+	path, err := config.StatePath("logs", "tool-calls.jsonl")
+	if err != nil {
+		return nil
+	}
+	entry := toolCallLogEntry{
+		Time:          time.Unix(in.Timestamp, 0).Format(time.RFC3339),
+		SessionID:     in.SessionID,
+		TurnID:        in.TurnID,
+		ToolName:      in.ToolName,
+		ServerName:    in.ServerName,
+		ResponseBytes: len(in.ToolResponse),
+	}
+	if json.Valid(in.ToolInput) {
+		entry.ToolInput = in.ToolInput
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+
+	toolCallLogMu.Lock()
+	defer toolCallLogMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+	return nil
+}
+
+// homeDotfilePattern finds dotfile paths in the home directory in a shell
+// command, and shellWritePattern the commands that change files
+var (
+	homeDotfilePattern = regexp.MustCompile(`(~|\$HOME|\$\{HOME\})/\.[^\s/'"]+`)
+	shellWritePattern  = regexp.MustCompile(`>|\b(rm|mv|cp|tee|truncate|chmod|chown|ln|install)\b|\bsed\s+(-\w+\s+)*-i`)
+)
+
+// protectDotfiles blocks a tool that is not marked read-only when one of its
+// path arguments, or a shell command that writes files, names a dotfile or
+// dot directory in the home directory, such as ~/.bashrc or ~/.ssh
+func protectDotfiles(input interface{}) *HookOutput {
+	in, ok := input.(*PreToolUseInput)
+	if !ok {
+		return nil
+	}
+	if a := in.ToolAnnotations; a != nil && a.ReadOnlyHint != nil && *a.ReadOnlyHint {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	var args map[string]any
+	if json.Unmarshal(in.ToolInput, &args) != nil {
+		return nil
+	}
+
+	for key, value := range args {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if key == "command" {
+			if match := homeDotfilePattern.FindString(text); match != "" && shellWritePattern.MatchString(text) {
+				return blockPreset("protect-dotfiles", "the command changes "+match)
+			}
+			continue
+		}
+		if isPathArgument(key) && isHomeDotfile(home, in.CWD, text) {
+			return blockPreset("protect-dotfiles", fmt.Sprintf("%s %s is a dotfile in the home directory", key, text))
+		}
+	}
+	return nil
+}
+
+// isPathArgument reports whether a tool argument holds a path, by its name
+func isPathArgument(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"path", "file", "dir", "destination", "source", "target"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHomeDotfile reports whether path, relative to cwd, is in a dotfile or dot
+// directory directly under home
+func isHomeDotfile(home, cwd, path string) bool {
+	switch {
+	case path == "~" || strings.HasPrefix(path, "~/"):
+		path = filepath.Join(home, path[1:])
+	case strings.HasPrefix(path, "$HOME/"):
+		path = filepath.Join(home, strings.TrimPrefix(path, "$HOME"))
+	case !filepath.IsAbs(path):
+		path = filepath.Join(cwd, path)
+	}
+	rel, err := filepath.Rel(home, filepath.Clean(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return strings.HasPrefix(first, ".")
+}
@@ -6,18 +6,43 @@ import (
 
 // CommonInput contains fields common to all hook inputs
 type CommonInput struct {
-	SessionID      string    `json:"session_id"`      // Unique session identifier
-	TranscriptPath string    `json:"transcript_path"` // Path to transcript file (if enabled)
-	CWD            string    `json:"cwd"`             // Current working directory
-	HookEventName  HookEvent `json:"hook_event_name"` // The hook event type
-	Timestamp      int64     `json:"timestamp"`       // Unix timestamp when hook fired
-	Model          string    `json:"model"`           // AI model being used
-	Interactive    bool      `json:"interactive"`     // Whether in interactive mode
+	SessionID      string    `json:"session_id"`        // Unique session identifier
+	TranscriptPath string    `json:"transcript_path"`   // Path to transcript file (if enabled)
+	CWD            string    `json:"cwd"`               // Current working directory
+	HookEventName  HookEvent `json:"hook_event_name"`   // The hook event type
+	Timestamp      int64     `json:"timestamp"`         // Unix timestamp when hook fired
+	Model          string    `json:"model"`             // AI model being used
+	Interactive    bool      `json:"interactive"`       // Whether in interactive mode
+	TurnID         string    `json:"turn_id,omitempty"` // Turn of the prompt being answered, if any
+	Git            *GitInfo  `json:"git,omitempty"`     // Repository state of CWD, if it is in one
+}
+
+// GitInfo is the state of the git repository the hook runs in
+type GitInfo struct {
+	Branch string `json:"branch,omitempty"` // Empty on a detached HEAD
+	Commit string `json:"commit,omitempty"` // Full hash of HEAD; empty before the first commit
+}
+
+// ToolMetadata describes the tool of a tool hook
+type ToolMetadata struct {
+	ServerName      string           `json:"server_name,omitempty"`      // MCP server providing the tool
+	ToolAnnotations *ToolAnnotations `json:"tool_annotations,omitempty"` // The server's hints, if it gave any
+}
+
+// ToolAnnotations are the MCP behavior hints a server gives for a tool. Hints
+// the server left out are omitted.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"read_only_hint,omitempty"`
+	DestructiveHint *bool  `json:"destructive_hint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotent_hint,omitempty"`
+	OpenWorldHint   *bool  `json:"open_world_hint,omitempty"`
 }
 
 // PreToolUseInput is passed to PreToolUse hooks
 type PreToolUseInput struct {
 	CommonInput
+	ToolMetadata
 	ToolName  string          `json:"tool_name"`
 	ToolInput json.RawMessage `json:"tool_input"`
 }
@@ -25,6 +50,7 @@ type PreToolUseInput struct {
 // PostToolUseInput is passed to PostToolUse hooks
 type PostToolUseInput struct {
 	CommonInput
+	ToolMetadata
 	ToolName     string          `json:"tool_name"`
 	ToolInput    json.RawMessage `json:"tool_input"`
 	ToolResponse json.RawMessage `json:"tool_response"`
@@ -45,6 +71,24 @@ type StopInput struct {
 	Meta           json.RawMessage `json:"meta,omitempty"` // Additional metadata (e.g., token usage, model info)
 }
 
+// NotificationInput is passed to Notification hooks
+type NotificationInput struct {
+	CommonInput
+	Reason         NotificationReason `json:"reason"`              // Why the notification fired
+	Message        string             `json:"message"`             // A sentence to show the user
+	ToolName       string             `json:"tool_name,omitempty"` // The running tool, for long_tool
+	ElapsedSeconds int                `json:"elapsed_seconds"`     // How long it has been going on
+}
+
+// StreamDeltaInput is passed to StreamDelta hooks with each batch of a
+// streamed response
+type StreamDeltaInput struct {
+	CommonInput
+	Delta    string `json:"delta"`    // Text streamed since the previous batch
+	Sequence int    `json:"sequence"` // Number of the batch in the response, from 0
+	Done     bool   `json:"done"`     // The response's last batch, which may be empty
+}
+
 // HookOutput represents the JSON output from a hook
 type HookOutput struct {
 	Continue       *bool  `json:"continue,omitempty"`
@@ -53,3 +97,22 @@ type HookOutput struct {
 	Decision       string `json:"decision,omitempty"` // "approve", "block", or ""
 	Reason         string `json:"reason,omitempty"`
 }
+
+// InputFor returns a zero value of the input hooks receive for the event
+func InputFor(e HookEvent) any {
+	switch e {
+	case PreToolUse:
+		return PreToolUseInput{}
+	case PostToolUse:
+		return PostToolUseInput{}
+	case UserPromptSubmit:
+		return UserPromptSubmitInput{}
+	case Stop:
+		return StopInput{}
+	case Notification:
+		return NotificationInput{}
+	case StreamDelta:
+		return StreamDeltaInput{}
+	}
+	return CommonInput{}
+}
@@ -0,0 +1,117 @@
+package hooks
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultStreamDeltaInterval is how long text is batched for the StreamDelta
+// hooks when StreamDeltaConfig sets no interval
+const defaultStreamDeltaInterval = 250 * time.Millisecond
+
+// DeltaStream batches the text of a streamed response for the StreamDelta
+// hooks. Batches are delivered in order: while the hooks run for one batch,
+// the text that streams in meanwhile joins the next, so slow hooks get fewer,
+// larger batches and never hold up the response.
+type DeltaStream struct {
+	executor *Executor
+	interval time.Duration
+
+	mu      sync.Mutex
+	buf     strings.Builder
+	timer   *time.Timer
+	written bool
+	closed  bool
+
+	send     sync.Mutex // held while the hooks run for a batch
+	sequence int
+}
+
+// StreamDeltas returns a DeltaStream for one response, or nil unless
+// StreamDelta hooks are configured and stream_delta.enabled opts in to them.
+// Safe on a nil executor; the methods of a nil DeltaStream do nothing.
+func (e *Executor) StreamDeltas() *DeltaStream {
+	if e == nil || !e.config.StreamDelta.Enabled || len(e.config.Hooks[StreamDelta]) == 0 {
+		return nil
+	}
+	interval := defaultStreamDeltaInterval
+	if ms := e.config.StreamDelta.IntervalMS; ms > 0 {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+	return &DeltaStream{executor: e, interval: interval}
+}
+
+// Write adds text to the current batch, which is sent once the interval passes
+func (s *DeltaStream) Write(text string) {
+	if s == nil || text == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.buf.WriteString(text)
+	s.written = true
+	if s.timer == nil {
+		s.executor.async.Add(1)
+		s.timer = time.AfterFunc(s.interval, func() {
+			defer s.executor.async.Done()
+			s.flush(false)
+		})
+	}
+}
+
+// Close sends what is left of the response as the batch marked done, unless
+// nothing was streamed. It does not wait for the hooks; Executor.Wait does.
+func (s *DeltaStream) Close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if s.timer != nil && s.timer.Stop() {
+		s.executor.async.Done() // its flush won't run
+	}
+	written := s.written
+	s.mu.Unlock()
+	if !written {
+		return
+	}
+
+	s.executor.async.Add(1)
+	go func() {
+		defer s.executor.async.Done()
+		s.flush(true)
+	}()
+}
+
+// flush runs the StreamDelta hooks with the text batched so far
+func (s *DeltaStream) flush(done bool) {
+	s.send.Lock()
+	defer s.send.Unlock()
+
+	s.mu.Lock()
+	delta := s.buf.String()
+	s.buf.Reset()
+	s.timer = nil
+	s.mu.Unlock()
+	if delta == "" && !done {
+		return
+	}
+
+	input := &StreamDeltaInput{
+		CommonInput: s.executor.PopulateCommonFields(StreamDelta),
+		Delta:       delta,
+		Sequence:    s.sequence,
+		Done:        done,
+	}
+	s.sequence++
+	s.executor.ExecuteHooks(context.Background(), StreamDelta, input)
+}
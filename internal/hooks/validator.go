@@ -73,6 +73,20 @@ func ValidateHookConfig(config *HookConfig) error {
 	if config == nil {
 		return fmt.Errorf("nil configuration")
 	}
+	if n := config.Notifications; n.GenerationAfter < 0 || n.ToolAfter < 0 || n.InputAfter < 0 {
+		return fmt.Errorf("notification thresholds must not be negative")
+	}
+	if config.StreamDelta.IntervalMS < 0 {
+		return fmt.Errorf("stream_delta.interval_ms must not be negative")
+	}
+	if len(config.Hooks[StreamDelta]) > 0 && !config.StreamDelta.Enabled {
+		return fmt.Errorf("StreamDelta hooks never fire unless stream_delta.enabled is set")
+	}
+	for _, name := range config.Presets {
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("unknown preset %q; available presets: %s", name, strings.Join(PresetNames(), ", "))
+		}
+	}
 
 	for event, matchers := range config.Hooks {
 		if !event.IsValid() {
@@ -195,6 +195,22 @@ func TestValidateHookConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "empty command",
 		},
+		{
+			name: "stream delta hooks without opting in",
+			config: &HookConfig{
+				Hooks: map[HookEvent][]HookMatcher{
+					StreamDelta: {
+						{
+							Hooks: []HookEntry{
+								{Type: "command", Command: "cat"},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "stream_delta.enabled",
+		},
 		{
 			name: "negative timeout",
 			config: &HookConfig{
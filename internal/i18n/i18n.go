@@ -0,0 +1,166 @@
+// Package i18n localizes the terminal UI: labels, help text and timestamps.
+// Catalogs are embedded JSON files under locales/, one per language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default is the language used when none is set or detected, and for
+// messages a catalog lacks
+const Default = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog is one language's messages and date formats
+type catalog struct {
+	Time     string            `json:"time"`             // layout for message timestamps
+	DateTime string            `json:"datetime"`         // layout for full dates
+	Months   []string          `json:"months,omitempty"` // short month names replacing Jan..Dec
+	Messages map[string]string `json:"messages"`
+}
+
+var (
+	catalogs = loadCatalogs()
+
+	mu      sync.RWMutex
+	current = Default
+)
+
+// loadCatalogs parses the embedded catalogs, keyed by language code
+func loadCatalogs() map[string]*catalog {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[string]*catalog, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = &c
+	}
+	return loaded
+}
+
+// Supported returns the available language codes, sorted
+func Supported() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// SetLanguage selects the UI language. Tags such as "es-MX" or "es_ES.UTF-8"
+// select their base language. An empty tag uses LC_ALL, LC_MESSAGES or LANG,
+// falling back to English when those name an unsupported language.
+func SetLanguage(tag string) error {
+	lang := Default
+	if tag != "" {
+		lang = baseLanguage(tag)
+		if _, ok := catalogs[lang]; !ok {
+			return fmt.Errorf("unsupported language %q (supported: %s)", tag, strings.Join(Supported(), ", "))
+		}
+	} else if detected := detectLanguage(); detected != "" {
+		lang = detected
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+	return nil
+}
+
+// Language returns the selected language code
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// detectLanguage returns the supported language named by the locale
+// environment variables, or ""
+func detectLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			// The first variable set decides, as it does for other programs
+			if lang := baseLanguage(value); catalogs[lang] != nil {
+				return lang
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// baseLanguage reduces a locale tag to its lowercase language code
+func baseLanguage(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag = strings.ReplaceAll(tag, "_", "-")
+	lang, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(lang)
+}
+
+// active returns the selected catalog
+func active() *catalog {
+	return catalogs[Language()]
+}
+
+// T returns the message for key in the selected language, falling back to
+// English, formatted with args when any are given
+func T(key string, args ...any) string {
+	message, ok := active().Messages[key]
+	if !ok {
+		message, ok = catalogs[Default].Messages[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Lookup returns the message for key in the selected language only, for text
+// whose English version lives in code
+func Lookup(key string) (string, bool) {
+	message, ok := active().Messages[key]
+	return message, ok
+}
+
+// FormatTime formats a timestamp shown next to a message, in local time
+func FormatTime(t time.Time) string {
+	return active().format(t.Local(), active().Time)
+}
+
+// FormatDateTime formats a full date and time, in local time
+func FormatDateTime(t time.Time) string {
+	return active().format(t.Local(), active().DateTime)
+}
+
+// format applies a layout, replacing English month names with the catalog's
+func (c *catalog) format(t time.Time, layout string) string {
+	formatted := t.Format(layout)
+	if len(c.Months) == 12 && strings.Contains(layout, "Jan") {
+		formatted = strings.Replace(formatted, t.Month().String()[:3], c.Months[t.Month()-1], 1)
+	}
+	return formatted
+}
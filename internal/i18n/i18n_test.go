@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	english := catalogs[Default]
+	for lang, c := range catalogs {
+		if lang == Default {
+			continue
+		}
+		for key, message := range english.Messages {
+			translated, ok := c.Messages[key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			if strings.Count(translated, "%") != strings.Count(message, "%") {
+				t.Errorf("%s: %q has different placeholders than English", lang, key)
+			}
+		}
+		if c.Months != nil && len(c.Months) != 12 {
+			t.Errorf("%s: expected 12 months, got %d", lang, len(c.Months))
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(Default)
+
+	for tag, want := range map[string]string{"es": "es", "es-MX": "es", "es_ES.UTF-8": "es", "EN_us": "en"} {
+		if err := SetLanguage(tag); err != nil {
+			t.Fatalf("SetLanguage(%q): %v", tag, err)
+		}
+		if got := Language(); got != want {
+			t.Errorf("SetLanguage(%q) selected %q, want %q", tag, got, want)
+		}
+	}
+
+	if err := SetLanguage("xx"); err == nil || !strings.Contains(err.Error(), "unsupported language") {
+		t.Errorf("expected an unsupported language error, got %v", err)
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "es_AR.UTF-8")
+	if err := SetLanguage(""); err != nil || Language() != "es" {
+		t.Errorf("expected es from LANG, got %q (%v)", Language(), err)
+	}
+	t.Setenv("LANG", "C.UTF-8")
+	if err := SetLanguage(""); err != nil || Language() != Default {
+		t.Errorf("expected the default for an unsupported LANG, got %q (%v)", Language(), err)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage(Default)
+
+	if got := T("usage.cache_read", 42); got != "42 cache read" {
+		t.Errorf("unexpected English message %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("expected a missing key to return itself, got %q", got)
+	}
+	if _, ok := Lookup("command./help"); ok {
+		t.Error("expected English command descriptions to come from code")
+	}
+
+	SetLanguage("es")
+	if got := T("spinner.thinking"); got == catalogs[Default].Messages["spinner.thinking"] {
+		t.Errorf("expected a Spanish message, got %q", got)
+	}
+	if _, ok := Lookup("command./help"); !ok {
+		t.Error("expected a Spanish command description")
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	defer SetLanguage(Default)
+
+	date := time.Date(2025, time.January, 2, 15, 4, 0, 0, time.Local)
+	english := FormatDateTime(date)
+	if !strings.Contains(english, "Jan") {
+		t.Errorf("expected an English month in %q", english)
+	}
+
+	SetLanguage("es")
+	spanish := FormatDateTime(date)
+	if !strings.Contains(spanish, "ene") || strings.Contains(spanish, "Jan") {
+		t.Errorf("expected a Spanish month in %q", spanish)
+	}
+	if FormatTime(date) == "" {
+		t.Error("expected a formatted time")
+	}
+}
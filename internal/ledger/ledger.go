@@ -0,0 +1,195 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/clock"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// Entry is a single usage record, one per served prompt or agent turn
+type Entry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Source       string    `json:"source"`            // cli, serve, sdk, ...
+	APIKey       string    `json:"api_key,omitempty"` // API key name, never the secret
+	SessionID    string    `json:"session_id,omitempty"`
+	TurnID       string    `json:"turn_id,omitempty"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`  // uncached prompt tokens
+	OutputTokens int       `json:"output_tokens"` // reasoning included
+	Cost         float64   `json:"cost"`
+
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+
+	// Shadow model calls only: the model mirrored and what its call cost,
+	// and what the shadow model answered or why it failed
+	Shadows     string   `json:"shadows,omitempty"`
+	ShadowsCost float64  `json:"shadows_cost,omitempty"`
+	Response    string   `json:"response,omitempty"`
+	ToolCalls   []string `json:"tool_calls,omitempty"` // name and arguments of each
+	Error       string   `json:"error,omitempty"`
+	LatencyMS   int64    `json:"latency_ms,omitempty"`
+}
+
+// Counts returns the entry's token counts
+func (e Entry) Counts() models.TokenCounts {
+	return models.TokenCounts{
+		InputTokens:      e.InputTokens,
+		OutputTokens:     e.OutputTokens,
+		CacheReadTokens:  e.CacheReadTokens,
+		CacheWriteTokens: e.CacheWriteTokens,
+		ReasoningTokens:  e.ReasoningTokens,
+	}
+}
+
+// Totals aggregates a set of entries
+type Totals struct {
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+}
+
+// Ledger is an append-only usage log stored as JSON lines. A ledger without a
+// path keeps its entries in memory only.
+type Ledger struct {
+	path    string
+	entries []Entry // only used when path is empty
+	mu      sync.Mutex
+}
+
+// Open returns a ledger appending to path, creating its directory if needed
+func Open(path string) (*Ledger, error) {
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create ledger directory: %w", err)
+		}
+	}
+	return &Ledger{path: path}, nil
+}
+
+// Record appends an entry, filling in the timestamp and cost when unset
+func (l *Ledger) Record(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = clock.Now()
+	}
+	if entry.Cost == 0 {
+		entry.Cost = EstimateCost(entry.Model, entry.Counts())
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		l.entries = append(l.entries, entry)
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger entry: %w", err)
+	}
+	return nil
+}
+
+// Entries returns all recorded entries, oldest first
+func (l *Ledger) Entries() ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		entries := make([]Entry, len(l.entries))
+		copy(entries, l.entries)
+		return entries, nil
+	}
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			// Skip a torn trailing write rather than failing the whole read
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// Summarize totals the entries accepted by filter (all entries when nil)
+func Summarize(entries []Entry, filter func(Entry) bool) Totals {
+	var totals Totals
+	for _, entry := range entries {
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		totals.Requests++
+		totals.InputTokens += entry.InputTokens
+		totals.OutputTokens += entry.OutputTokens
+		totals.CacheReadTokens += entry.CacheReadTokens
+		totals.CacheWriteTokens += entry.CacheWriteTokens
+		totals.ReasoningTokens += entry.ReasoningTokens
+		totals.Cost += entry.Cost
+	}
+	return totals
+}
+
+// EstimateCost prices token counts using the model registry. Unknown models cost 0.
+func EstimateCost(modelString string, counts models.TokenCounts) float64 {
+	provider, modelID, ok := strings.Cut(modelString, ":")
+	if !ok {
+		return 0
+	}
+	info, err := models.GetGlobalRegistry().ValidateModel(provider, modelID)
+	if err != nil {
+		return 0
+	}
+	return info.Cost.Price(counts).Total()
+}
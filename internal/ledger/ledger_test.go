@@ -0,0 +1,70 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLedgerRecordAndSummarize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "usage.jsonl")
+	for name, path := range map[string]string{"memory": "", "file": path} {
+		t.Run(name, func(t *testing.T) {
+			l, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+
+			l.Record(Entry{Source: "serve", APIKey: "ci", Model: "test:model", InputTokens: 100, OutputTokens: 10, Cost: 0.5})
+			l.Record(Entry{Source: "serve", APIKey: "ops", Model: "test:model", InputTokens: 50, OutputTokens: 5})
+			l.Record(Entry{Source: "cli", Model: "test:model", InputTokens: 1})
+
+			entries, err := l.Entries()
+			if err != nil {
+				t.Fatalf("Entries failed: %v", err)
+			}
+			if len(entries) != 3 {
+				t.Fatalf("expected 3 entries, got %d", len(entries))
+			}
+			if entries[0].Timestamp.IsZero() {
+				t.Error("expected timestamp to be filled in")
+			}
+
+			totals := Summarize(entries, func(e Entry) bool { return e.APIKey == "ci" })
+			if totals.Requests != 1 || totals.InputTokens != 100 || totals.Cost != 0.5 {
+				t.Errorf("unexpected totals for ci: %+v", totals)
+			}
+			if all := Summarize(entries, nil); all.Requests != 3 || all.InputTokens != 151 {
+				t.Errorf("unexpected overall totals: %+v", all)
+			}
+		})
+	}
+}
+
+func TestLedgerSkipsTornLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	l, _ := Open(path)
+	l.Record(Entry{Model: "test:model", InputTokens: 1})
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"model": "test:mo`)
+	f.Close()
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected torn line to be skipped, got %d entries", len(entries))
+	}
+}
+
+func TestNilLedger(t *testing.T) {
+	var l *Ledger
+	if err := l.Record(Entry{}); err != nil {
+		t.Errorf("nil ledger Record should be a no-op, got %v", err)
+	}
+	if entries, _ := l.Entries(); entries != nil {
+		t.Errorf("nil ledger should have no entries")
+	}
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Error categories used for the errors_total counter
+const (
+	ErrorProvider   = "provider"
+	ErrorTool       = "tool"
+	ErrorCancelled  = "cancelled"
+	ErrorBadRequest = "bad_request"
+	ErrorSession    = "session"
+)
+
+// Metrics holds the Prometheus collectors exposed by the serve mode.
+// All methods are safe to call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	registry         *prometheus.Registry
+	promptsTotal     *prometheus.CounterVec
+	tokensTotal      *prometheus.CounterVec
+	toolCallDuration *prometheus.HistogramVec
+	errorsTotal      *prometheus.CounterVec
+	activeSessions   prometheus.Gauge
+}
+
+// New creates a Metrics instance with its own registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		promptsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mcphost",
+			Name:      "prompts_total",
+			Help:      "Prompts served, by model and status.",
+		}, []string{"model", "status"}),
+		tokensTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mcphost",
+			Name:      "tokens_total",
+			Help:      "Tokens consumed, by model and direction (input or output).",
+		}, []string{"model", "direction"}),
+		toolCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "mcphost",
+			Name:      "tool_call_duration_seconds",
+			Help:      "Tool call latency, by MCP server.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"server"}),
+		errorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mcphost",
+			Name:      "errors_total",
+			Help:      "Errors, by category.",
+		}, []string{"category"}),
+		activeSessions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "mcphost",
+			Name:      "active_sessions",
+			Help:      "Sessions with a prompt currently in flight.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.promptsTotal,
+		m.tokensTotal,
+		m.toolCallDuration,
+		m.errorsTotal,
+		m.activeSessions,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics in Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObservePrompt counts a served prompt
+func (m *Metrics) ObservePrompt(model, status string) {
+	if m == nil {
+		return
+	}
+	m.promptsTotal.WithLabelValues(model, status).Inc()
+}
+
+// ObserveTokens adds input and output token counts for a model
+func (m *Metrics) ObserveTokens(model string, input, output int) {
+	if m == nil {
+		return
+	}
+	if input > 0 {
+		m.tokensTotal.WithLabelValues(model, "input").Add(float64(input))
+	}
+	if output > 0 {
+		m.tokensTotal.WithLabelValues(model, "output").Add(float64(output))
+	}
+}
+
+// ObserveToolCall records the latency of a tool call. The server label is
+// taken from the prefixed tool name (server__tool).
+func (m *Metrics) ObserveToolCall(toolName string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	server := toolName
+	if idx := strings.Index(toolName, "__"); idx > 0 {
+		server = toolName[:idx]
+	}
+	m.toolCallDuration.WithLabelValues(server).Observe(duration.Seconds())
+}
+
+// ObserveError counts an error in the given category
+func (m *Metrics) ObserveError(category string) {
+	if m == nil {
+		return
+	}
+	m.errorsTotal.WithLabelValues(category).Inc()
+}
+
+// SessionStarted marks a session as active
+func (m *Metrics) SessionStarted() {
+	if m == nil {
+		return
+	}
+	m.activeSessions.Inc()
+}
+
+// SessionFinished marks a session as no longer active
+func (m *Metrics) SessionFinished() {
+	if m == nil {
+		return
+	}
+	m.activeSessions.Dec()
+}
@@ -11,20 +11,43 @@ import (
 	einoclaude "github.com/cloudwego/eino-ext/components/model/claude"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+	"github.com/osi4iot/mcphost/internal/models/usage"
 )
 
 // CustomChatModel wraps the eino-ext Claude model with custom tool schema handling
 type CustomChatModel struct {
-	wrapped *einoclaude.ChatModel
+	wrapped  *einoclaude.ChatModel
+	uploader *uploader
 }
 
 // CustomRoundTripper intercepts HTTP requests to fix Anthropic function schemas
 type CustomRoundTripper struct {
 	wrapped http.RoundTripper
+
+	// disableParallelToolUse asks for at most one tool call per response
+	disableParallelToolUse bool
+
+	// promptCache marks the system prompt and tool definitions for caching
+	promptCache bool
+
+	// thinking keeps the thinking blocks to send back with tool results,
+	// when extended thinking is on
+	thinking *thinkingBlocks
 }
 
-// NewCustomChatModel creates a new custom Anthropic chat model
-func NewCustomChatModel(ctx context.Context, config *einoclaude.Config) (*CustomChatModel, error) {
+// toolErrorsKey is the context key for the tool call IDs whose results
+// report errors, read when the request is sent
+type toolErrorsKey struct{}
+
+// NewCustomChatModel creates a new custom Anthropic chat model. When
+// parallelToolCalls is false, the model is asked for at most one tool call
+// per response; nil keeps the API's default of allowing several. Attachments
+// larger than uploadThreshold bytes are uploaded with the Files API instead
+// of sent inline; 0 never uploads. With promptCache, the system prompt and
+// tool definitions are cached between requests. Thinking enabled in config
+// is streamed as the responses' ReasoningContent.
+func NewCustomChatModel(ctx context.Context, config *einoclaude.Config, parallelToolCalls *bool, uploadThreshold int64, promptCache bool) (*CustomChatModel, error) {
 	// Create a custom HTTP client that intercepts requests
 	if config.HTTPClient == nil {
 		config.HTTPClient = &http.Client{}
@@ -34,9 +57,15 @@ func NewCustomChatModel(ctx context.Context, config *einoclaude.Config) (*Custom
 	if config.HTTPClient.Transport == nil {
 		config.HTTPClient.Transport = http.DefaultTransport
 	}
-	config.HTTPClient.Transport = &CustomRoundTripper{
-		wrapped: config.HTTPClient.Transport,
+	rt := &CustomRoundTripper{
+		wrapped:                config.HTTPClient.Transport,
+		disableParallelToolUse: parallelToolCalls != nil && !*parallelToolCalls,
+		promptCache:            promptCache,
 	}
+	if config.Thinking != nil && config.Thinking.Enable {
+		rt.thinking = newThinkingBlocks()
+	}
+	config.HTTPClient.Transport = rt
 
 	// Create the wrapped model
 	wrapped, err := einoclaude.NewChatModel(ctx, config)
@@ -44,15 +73,48 @@ func NewCustomChatModel(ctx context.Context, config *einoclaude.Config) (*Custom
 		return nil, err
 	}
 
+	baseURL := "https://api.anthropic.com"
+	if config.BaseURL != nil && *config.BaseURL != "" {
+		baseURL = *config.BaseURL
+	}
 	return &CustomChatModel{
 		wrapped: wrapped,
+		uploader: &uploader{
+			client:    config.HTTPClient,
+			baseURL:   baseURL,
+			apiKey:    config.APIKey,
+			threshold: uploadThreshold,
+			uploaded:  make(map[[32]byte]string),
+		},
 	}, nil
 }
 
-// RoundTrip implements http.RoundTripper to intercept and fix requests
+// RoundTrip implements http.RoundTripper to intercept and fix requests, and to
+// read the cache token counts and thinking blocks the eino model drops from
+// responses
 func (rt *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Only process Anthropic API requests
-	if !strings.Contains(req.URL.Host, "anthropic.com") {
+	resp, err := rt.fixRequest(req)
+	if err != nil || !strings.HasSuffix(req.URL.Path, "/messages") {
+		return resp, err
+	}
+	if rt.thinking == nil {
+		usage.Watch(req, resp, parseUsage)
+		return resp, err
+	}
+	capture := rt.thinking.capture()
+	usage.Watch(req, resp, func(data []byte) (usage.Report, bool) {
+		capture.observe(data)
+		rep, ok := parseUsage(data)
+		return capture.report(rep), ok
+	})
+	return resp, err
+}
+
+func (rt *CustomRoundTripper) fixRequest(req *http.Request) (*http.Response, error) {
+	// Only process Anthropic API requests, and requests with attachments to
+	// swap in wherever they go
+	found, _ := req.Context().Value(attachmentsKey{}).(*attachments)
+	if !strings.Contains(req.URL.Host, "anthropic.com") && found == nil {
 		return rt.wrapped.RoundTrip(req)
 	}
 
@@ -122,6 +184,28 @@ func (rt *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, erro
 		}
 	}
 
+	if rt.disableParallelToolUse {
+		disableParallelToolUse(requestData)
+	}
+	if rt.promptCache {
+		markCacheable(requestData)
+	}
+	if rt.thinking != nil {
+		restoreThinking(requestData, rt.thinking)
+	}
+
+	// The eino model sends every tool_result as a success
+	toolErrors, _ := req.Context().Value(toolErrorsKey{}).(map[string]bool)
+	markToolErrors(requestData, toolErrors)
+
+	// Documents and uploaded files go in as blocks the eino model can't build
+	if found != nil {
+		replacePlaceholders(requestData, found)
+		if found.files {
+			addBeta(req.Header, filesBeta)
+		}
+	}
+
 	// Fix tool_use content in messages if present
 	if messages, ok := requestData["messages"].([]interface{}); ok {
 		for _, message := range messages {
@@ -191,14 +275,112 @@ func (rt *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, erro
 	return rt.wrapped.RoundTrip(req)
 }
 
+// disableParallelToolUse sets disable_parallel_tool_use on the request's tool
+// choice, which defaults to auto. A request without tools, or whose tool
+// choice is none, is left alone.
+func disableParallelToolUse(requestData map[string]interface{}) {
+	if tools, ok := requestData["tools"].([]interface{}); !ok || len(tools) == 0 {
+		return
+	}
+	toolChoice, ok := requestData["tool_choice"].(map[string]interface{})
+	if !ok {
+		toolChoice = map[string]interface{}{"type": "auto"}
+		requestData["tool_choice"] = toolChoice
+	}
+	if toolChoice["type"] != "none" {
+		toolChoice["disable_parallel_tool_use"] = true
+	}
+}
+
+// markCacheable sets cache breakpoints after the tool definitions and after
+// the system prompt, which come first in the prompt and rarely change, so
+// later requests read them from the cache. Prompts shorter than the model's
+// minimum are not cached, without error.
+func markCacheable(requestData map[string]interface{}) {
+	cacheControl := map[string]interface{}{"type": "ephemeral"}
+	if tools, ok := requestData["tools"].([]interface{}); ok && len(tools) > 0 {
+		if tool, ok := tools[len(tools)-1].(map[string]interface{}); ok {
+			tool["cache_control"] = cacheControl
+		}
+	}
+	switch system := requestData["system"].(type) {
+	case string:
+		if system != "" {
+			requestData["system"] = []interface{}{map[string]interface{}{"type": "text", "text": system, "cache_control": cacheControl}}
+		}
+	case []interface{}:
+		if len(system) > 0 {
+			if block, ok := system[len(system)-1].(map[string]interface{}); ok {
+				block["cache_control"] = cacheControl
+			}
+		}
+	}
+}
+
+// markToolErrors sets is_error on the tool_result blocks answering the tool
+// calls in ids
+func markToolErrors(requestData map[string]interface{}, ids map[string]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	messages, _ := requestData["messages"].([]interface{})
+	for _, message := range messages {
+		msgMap, _ := message.(map[string]interface{})
+		content, _ := msgMap["content"].([]interface{})
+		for _, contentItem := range content {
+			block, _ := contentItem.(map[string]interface{})
+			if id, _ := block["tool_use_id"].(string); block["type"] == "tool_result" && ids[id] {
+				block["is_error"] = true
+			}
+		}
+	}
+}
+
+// withToolErrors records the tool calls whose results in input report errors
+// for the round tripper, which marks them in the request
+func withToolErrors(ctx context.Context, input []*schema.Message) context.Context {
+	if ids := toolresult.ErrorCallIDs(input); ids != nil {
+		return context.WithValue(ctx, toolErrorsKey{}, ids)
+	}
+	return ctx
+}
+
 // Generate implements the model.BaseChatModel interface
 func (m *CustomChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	return m.wrapped.Generate(ctx, input, opts...)
+	ctx, input, err := m.uploader.withAttachments(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withToolErrors(ctx, input)
+	ctx, recorder := usage.WithRecorder(ctx)
+	msg, err := m.wrapped.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	takeThinking(msg)
+	if rep, ok := recorder.Report(); ok {
+		rep.Apply(msg)
+	}
+	return msg, nil
 }
 
 // Stream implements the model.BaseChatModel interface
 func (m *CustomChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	return m.wrapped.Stream(ctx, input, opts...)
+	ctx, input, err := m.uploader.withAttachments(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withToolErrors(ctx, input)
+	ctx, recorder := usage.WithRecorder(ctx)
+	stream, err := m.wrapped.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	stream = schema.StreamReaderWithConvert(stream, func(msg *schema.Message) (*schema.Message, error) {
+		takeThinking(msg)
+		return msg, nil
+	})
+	return usage.WrapStream(stream, recorder), nil
 }
 
 // WithTools implements the model.ToolCallingChatModel interface
@@ -209,6 +391,43 @@ func (m *CustomChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCalling
 	}
 
 	return &CustomChatModel{
-		wrapped: wrappedWithTools.(*einoclaude.ChatModel),
+		wrapped:  wrappedWithTools.(*einoclaude.ChatModel),
+		uploader: m.uploader,
 	}, nil
 }
+
+// apiUsage is the usage object of the Messages API. input_tokens excludes the
+// tokens read from or written to the prompt cache.
+type apiUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// parseUsage reads usage from a Messages response, or from the message_start
+// and message_delta events of a stream
+func parseUsage(data []byte) (usage.Report, bool) {
+	var doc struct {
+		Usage   *apiUsage `json:"usage"`
+		Message *struct {
+			Usage *apiUsage `json:"usage"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return usage.Report{}, false
+	}
+	u := doc.Usage
+	if u == nil && doc.Message != nil {
+		u = doc.Message.Usage
+	}
+	if u == nil {
+		return usage.Report{}, false
+	}
+	return usage.Report{
+		InputTokens:      u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		OutputTokens:     u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}, true
+}
@@ -0,0 +1,132 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+// roundTripFunc records the request body the model would send
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// sendRequest passes body through a round tripper and returns what reached
+// the API
+func sendRequest(t *testing.T, ctx context.Context, rt *CustomRoundTripper, body string) map[string]interface{} {
+	t.Helper()
+	var sent map[string]interface{}
+	rt.wrapped = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		data, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(data, &sent); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	return sent
+}
+
+const toolRequest = `{
+	"model": "claude-sonnet-4-20250514",
+	"tools": [{"name": "fs__read_file", "input_schema": {"type": "object", "properties": {"path": {"type": "string"}}}}],
+	"messages": [
+		{"role": "user", "content": [{"type": "text", "text": "read both"}]},
+		{"role": "assistant", "content": [
+			{"type": "tool_use", "id": "toolu_1", "name": "fs__read_file", "input": {"path": "a"}},
+			{"type": "tool_use", "id": "toolu_2", "name": "fs__read_file", "input": {"path": "b"}}
+		]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": "Tool execution error: no such file", "is_error": false},
+			{"type": "tool_result", "tool_use_id": "toolu_2", "content": "contents", "is_error": false}
+		]}
+	]
+}`
+
+func TestToolResultErrors(t *testing.T) {
+	failed := schema.ToolMessage("Tool execution error: no such file", "toolu_1")
+	toolresult.MarkError(failed)
+	ctx := withToolErrors(context.Background(), []*schema.Message{failed, schema.ToolMessage("contents", "toolu_2")})
+
+	sent := sendRequest(t, ctx, &CustomRoundTripper{}, toolRequest)
+	results := sent["messages"].([]interface{})[2].(map[string]interface{})["content"].([]interface{})
+	if isError := results[0].(map[string]interface{})["is_error"]; isError != true {
+		t.Errorf("expected the failed result flagged, got is_error %v", isError)
+	}
+	if isError := results[1].(map[string]interface{})["is_error"]; isError != false {
+		t.Errorf("expected the successful result unflagged, got is_error %v", isError)
+	}
+	if _, ok := sent["tool_choice"]; ok {
+		t.Error("expected the tool choice left to the API's default")
+	}
+}
+
+func TestDisableParallelToolUse(t *testing.T) {
+	rt := &CustomRoundTripper{disableParallelToolUse: true}
+
+	sent := sendRequest(t, context.Background(), rt, toolRequest)
+	toolChoice, _ := sent["tool_choice"].(map[string]interface{})
+	if toolChoice["type"] != "auto" || toolChoice["disable_parallel_tool_use"] != true {
+		t.Errorf("expected auto tool choice without parallel tool use, got %v", sent["tool_choice"])
+	}
+
+	// An explicit tool choice keeps its type
+	sent = sendRequest(t, context.Background(), rt, `{"tools": [{"name": "t", "input_schema": {"type": "object"}}], "tool_choice": {"type": "any"}, "messages": []}`)
+	if toolChoice := sent["tool_choice"].(map[string]interface{}); toolChoice["type"] != "any" || toolChoice["disable_parallel_tool_use"] != true {
+		t.Errorf("unexpected tool choice %v", toolChoice)
+	}
+
+	// Without tools there is nothing to restrict
+	sent = sendRequest(t, context.Background(), rt, `{"messages": [{"role": "user", "content": "hi"}]}`)
+	if _, ok := sent["tool_choice"]; ok {
+		t.Errorf("expected no tool choice without tools, got %v", sent["tool_choice"])
+	}
+}
+
+func TestPromptCache(t *testing.T) {
+	rt := &CustomRoundTripper{promptCache: true}
+
+	sent := sendRequest(t, context.Background(), rt, `{
+		"system": [{"type": "text", "text": "You are helpful."}],
+		"tools": [{"name": "a", "input_schema": {"type": "object"}}, {"name": "b", "input_schema": {"type": "object"}}],
+		"messages": [{"role": "user", "content": "hi"}]
+	}`)
+	tools := sent["tools"].([]interface{})
+	if _, ok := tools[0].(map[string]interface{})["cache_control"]; ok {
+		t.Error("expected a single breakpoint after the last tool")
+	}
+	if cc, _ := tools[1].(map[string]interface{})["cache_control"].(map[string]interface{}); cc["type"] != "ephemeral" {
+		t.Errorf("expected the last tool marked cacheable, got %v", tools[1])
+	}
+	system := sent["system"].([]interface{})
+	if _, ok := system[0].(map[string]interface{})["cache_control"]; !ok {
+		t.Errorf("expected the system prompt marked cacheable, got %v", system)
+	}
+
+	// A plain string system prompt becomes a block that can carry the breakpoint
+	sent = sendRequest(t, context.Background(), rt, `{"system": "Be brief.", "messages": [{"role": "user", "content": "hi"}]}`)
+	if system, ok := sent["system"].([]interface{}); !ok || system[0].(map[string]interface{})["text"] != "Be brief." {
+		t.Errorf("unexpected system %v", sent["system"])
+	}
+
+	// Disabled, nothing is marked
+	sent = sendRequest(t, context.Background(), &CustomRoundTripper{}, `{"system": "Be brief.", "messages": []}`)
+	if sent["system"] != "Be brief." {
+		t.Errorf("expected the system prompt left alone, got %v", sent["system"])
+	}
+}
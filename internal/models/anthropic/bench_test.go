@@ -0,0 +1,51 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// BenchmarkFixRequest passes request bodies with histories of growing length
+// through the round tripper, which parses and rewrites every request
+func BenchmarkFixRequest(b *testing.B) {
+	for _, turns := range []int{10, 100, 1000} {
+		messages := make([]map[string]any, 0, turns*4)
+		for i := 0; i < turns; i++ {
+			id := fmt.Sprintf("toolu_%d", i)
+			messages = append(messages,
+				map[string]any{"role": "user", "content": []map[string]any{{"type": "text", "text": fmt.Sprintf("Question %d about the repository layout", i)}}},
+				map[string]any{"role": "assistant", "content": []map[string]any{{"type": "tool_use", "id": id, "name": "fs__read_file", "input": map[string]any{"path": "go.mod"}}}},
+				map[string]any{"role": "user", "content": []map[string]any{{"type": "tool_result", "tool_use_id": id, "content": "module example.com/demo\n\ngo 1.24\n"}}},
+				map[string]any{"role": "assistant", "content": []map[string]any{{"type": "text", "text": fmt.Sprintf("Answer %d: it is a Go module.", i)}}},
+			)
+		}
+		body, err := json.Marshal(map[string]any{
+			"model":    "claude-sonnet-4-20250514",
+			"tools":    []map[string]any{{"name": "fs__read_file", "input_schema": map[string]any{"type": "object", "properties": map[string]any{"path": map[string]any{"type": "string"}}}}},
+			"messages": messages,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("history=%d", turns*4), func(b *testing.B) {
+			rt := &CustomRoundTripper{wrapped: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				io.Copy(io.Discard, req.Body)
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})}
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(body))
+				if _, err := rt.fixRequest(req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// FileURLScheme prefixes the URL of a file part referencing a file already
+// uploaded with the Files API, followed by the file's ID
+const FileURLScheme = "anthropic-file://"
+
+// MaxUploadSize is the largest file the Files API accepts
+const MaxUploadSize = 500 * 1024 * 1024
+
+// filesBeta is the beta the Files API, and messages referencing its files, need
+const filesBeta = "files-api-2025-04-14"
+
+// placeholderPrefix starts the text part standing in for an attachment the
+// eino model can't convert, followed by the index of the block replacing it
+const placeholderPrefix = "\x00mcphost-attachment:"
+
+// attachmentsKey is the context key for the attachments of a request, which
+// the round tripper swaps in for their placeholders
+type attachmentsKey struct{}
+
+// attachments are the content blocks replacing a request's placeholders
+type attachments struct {
+	blocks []map[string]interface{}
+	files  bool // some block references an uploaded file
+}
+
+// uploader uploads attachments larger than threshold with the Files API,
+// once per content. A zero threshold never uploads.
+type uploader struct {
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	threshold int64
+
+	mu       sync.Mutex
+	uploaded map[[sha256.Size]byte]string // file IDs by content hash
+}
+
+// withAttachments replaces the attachment parts of input the eino model can't
+// send, documents and attachments above the upload threshold, with
+// placeholders, and records their content blocks in the returned context.
+// Messages without such parts are passed through as they are.
+func (u *uploader) withAttachments(ctx context.Context, input []*schema.Message) (context.Context, []*schema.Message, error) {
+	var found attachments
+	var output []*schema.Message
+	for i, msg := range input {
+		var parts []schema.ChatMessagePart
+		for j, part := range msg.MultiContent {
+			block, err := u.attachmentBlock(ctx, part)
+			if err != nil {
+				return ctx, nil, err
+			}
+			if block == nil {
+				if parts != nil {
+					parts = append(parts, part)
+				}
+				continue
+			}
+			if parts == nil {
+				parts = append(make([]schema.ChatMessagePart, 0, len(msg.MultiContent)), msg.MultiContent[:j]...)
+			}
+			if source, _ := block["source"].(map[string]interface{}); source["type"] == "file" {
+				found.files = true
+			}
+			parts = append(parts, schema.ChatMessagePart{
+				Type: schema.ChatMessagePartTypeText,
+				Text: placeholderPrefix + strconv.Itoa(len(found.blocks)),
+			})
+			found.blocks = append(found.blocks, block)
+		}
+		if parts == nil {
+			if output != nil {
+				output = append(output, msg)
+			}
+			continue
+		}
+		if output == nil {
+			output = append(make([]*schema.Message, 0, len(input)), input[:i]...)
+		}
+		replaced := *msg
+		replaced.MultiContent = parts
+		output = append(output, &replaced)
+	}
+	if output == nil {
+		return ctx, input, nil
+	}
+	return context.WithValue(ctx, attachmentsKey{}, &found), output, nil
+}
+
+// attachmentBlock returns the content block to send for part, or nil when the
+// eino model sends it itself
+func (u *uploader) attachmentBlock(ctx context.Context, part schema.ChatMessagePart) (map[string]interface{}, error) {
+	var url, mimeType, name string
+	switch {
+	case part.Type == schema.ChatMessagePartTypeFileURL && part.FileURL != nil:
+		url, mimeType, name = part.FileURL.URL, part.FileURL.MIMEType, part.FileURL.Name
+	case part.Type == schema.ChatMessagePartTypeImageURL && part.ImageURL != nil:
+		url, mimeType = part.ImageURL.URL, part.ImageURL.MIMEType
+	default:
+		return nil, nil
+	}
+
+	blockType := "document"
+	if strings.HasPrefix(mimeType, "image/") {
+		blockType = "image"
+	}
+
+	if id, ok := strings.CutPrefix(url, FileURLScheme); ok {
+		return fileBlock(blockType, id), nil
+	}
+
+	data, ok := decodeDataURL(url)
+	if !ok {
+		return nil, fmt.Errorf("unsupported attachment URL for %s", mimeType)
+	}
+	if u.threshold > 0 && int64(len(data)) > u.threshold {
+		id, err := u.upload(ctx, name, mimeType, data)
+		if err != nil {
+			return nil, err
+		}
+		return fileBlock(blockType, id), nil
+	}
+
+	// Small images stay with the eino model; documents are sent inline
+	switch {
+	case blockType == "image":
+		return nil, nil
+	case mimeType == "application/pdf":
+		return map[string]interface{}{"type": "document", "source": map[string]interface{}{
+			"type": "base64", "media_type": mimeType, "data": base64.StdEncoding.EncodeToString(data),
+		}}, nil
+	case strings.HasPrefix(mimeType, "text/"):
+		return map[string]interface{}{"type": "document", "source": map[string]interface{}{
+			"type": "text", "media_type": "text/plain", "data": string(data),
+		}}, nil
+	}
+	return nil, fmt.Errorf("unsupported document type %s (PDF and text only)", mimeType)
+}
+
+// fileBlock returns an image or document block for an uploaded file
+func fileBlock(blockType, id string) map[string]interface{} {
+	return map[string]interface{}{"type": blockType, "source": map[string]interface{}{"type": "file", "file_id": id}}
+}
+
+// decodeDataURL returns the data of a base64 data: URL
+func decodeDataURL(url string) ([]byte, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return nil, false
+	}
+	header, encoded, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	return data, err == nil
+}
+
+// upload sends data to the Files API and returns the file's ID. Content
+// uploaded before is not sent again.
+func (u *uploader) upload(ctx context.Context, name, mimeType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	u.mu.Lock()
+	id, ok := u.uploaded[sum]
+	u.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	if name == "" {
+		name = "attachment"
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, name))
+	header.Set("Content-Type", mimeType)
+	w, err := form.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	w.Write(data)
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(u.baseURL, "/")+"/v1/files", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("x-api-key", u.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	req.Header.Set("anthropic-beta", filesBeta)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to upload %s: %s: %s", name, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &file); err != nil || file.ID == "" {
+		return "", fmt.Errorf("failed to upload %s: unexpected response %s", name, respBody)
+	}
+
+	u.mu.Lock()
+	u.uploaded[sum] = file.ID
+	u.mu.Unlock()
+	return file.ID, nil
+}
+
+// replacePlaceholders swaps the text blocks standing in for attachments for
+// the attachments' blocks
+func replacePlaceholders(requestData map[string]interface{}, found *attachments) {
+	messages, _ := requestData["messages"].([]interface{})
+	for _, message := range messages {
+		msgMap, _ := message.(map[string]interface{})
+		content, _ := msgMap["content"].([]interface{})
+		for i, contentItem := range content {
+			block, _ := contentItem.(map[string]interface{})
+			text, _ := block["text"].(string)
+			index, ok := strings.CutPrefix(text, placeholderPrefix)
+			if block["type"] != "text" || !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(index); err == nil && n >= 0 && n < len(found.blocks) {
+				content[i] = found.blocks[n]
+			}
+		}
+	}
+}
+
+// addBeta adds beta to the request's anthropic-beta header
+func addBeta(header http.Header, beta string) {
+	if existing := header.Get("anthropic-beta"); existing != "" {
+		if !strings.Contains(existing, beta) {
+			header.Set("anthropic-beta", existing+","+beta)
+		}
+		return
+	}
+	header.Set("anthropic-beta", beta)
+}
@@ -0,0 +1,117 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func dataURL(mimeType, data string) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString([]byte(data))
+}
+
+func TestWithAttachments(t *testing.T) {
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/files" || r.Header.Get("anthropic-beta") != filesBeta || r.Header.Get("x-api-key") != "sk-test" {
+			t.Errorf("unexpected upload request %s %v", r.URL.Path, r.Header)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "report.txt" || string(data) != "a long report" {
+			t.Errorf("unexpected upload %s: %q", header.Filename, data)
+		}
+		uploads++
+		w.Write([]byte(`{"id": "file_1", "type": "file"}`))
+	}))
+	defer server.Close()
+
+	u := &uploader{client: server.Client(), baseURL: server.URL, apiKey: "sk-test", threshold: 10, uploaded: make(map[[32]byte]string)}
+	prompt := schema.UserMessage("hi")
+	input := []*schema.Message{prompt, {Role: schema.User, MultiContent: []schema.ChatMessagePart{
+		{Type: schema.ChatMessagePartTypeText, Text: "Compare these"},
+		{Type: schema.ChatMessagePartTypeFileURL, FileURL: &schema.ChatMessageFileURL{URL: dataURL("application/pdf", "%PDF-1"), MIMEType: "application/pdf"}},
+		{Type: schema.ChatMessagePartTypeFileURL, FileURL: &schema.ChatMessageFileURL{URL: dataURL("text/plain", "a long report"), MIMEType: "text/plain", Name: "report.txt"}},
+		{Type: schema.ChatMessagePartTypeImageURL, ImageURL: &schema.ChatMessageImageURL{URL: dataURL("image/png", "png"), MIMEType: "image/png"}},
+	}}}
+
+	ctx, output, err := u.withAttachments(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output[0] != prompt || input[1].MultiContent[1].Type != schema.ChatMessagePartTypeFileURL {
+		t.Error("expected unchanged messages kept and the input left alone")
+	}
+	parts := output[1].MultiContent
+	if parts[1].Text != placeholderPrefix+"0" || parts[2].Text != placeholderPrefix+"1" || parts[3].Type != schema.ChatMessagePartTypeImageURL {
+		t.Fatalf("unexpected parts %+v", parts)
+	}
+	found := ctx.Value(attachmentsKey{}).(*attachments)
+	if !found.files || len(found.blocks) != 2 {
+		t.Fatalf("unexpected attachments %+v", found)
+	}
+	if source := found.blocks[0]["source"].(map[string]interface{}); source["type"] != "base64" || source["media_type"] != "application/pdf" {
+		t.Errorf("expected the small PDF inline, got %v", source)
+	}
+	if source := found.blocks[1]["source"].(map[string]interface{}); source["type"] != "file" || source["file_id"] != "file_1" {
+		t.Errorf("expected the large text uploaded, got %v", source)
+	}
+
+	// The same content is only uploaded once
+	if _, _, err := u.withAttachments(context.Background(), input); err != nil || uploads != 1 {
+		t.Errorf("expected one upload, got %d, %v", uploads, err)
+	}
+
+	// The round tripper swaps the blocks in and asks for the Files API beta
+	var sent map[string]interface{}
+	var beta string
+	rt := &CustomRoundTripper{wrapped: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		beta = req.Header.Get("anthropic-beta")
+		data, _ := io.ReadAll(req.Body)
+		json.Unmarshal(data, &sent)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	})}
+	body := `{"messages": [{"role": "user", "content": [{"type": "text", "text": "Compare these"}, {"type": "text", "text": "\u0000mcphost-attachment:0"}, {"type": "text", "text": "\u0000mcphost-attachment:1"}]}]}`
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", strings.NewReader(body))
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	content := sent["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	if content[1].(map[string]interface{})["type"] != "document" || content[2].(map[string]interface{})["type"] != "document" {
+		t.Errorf("expected document blocks, got %v", content)
+	}
+	if beta != filesBeta {
+		t.Errorf("expected the Files API beta, got %q", beta)
+	}
+}
+
+func TestAttachmentBlock(t *testing.T) {
+	u := &uploader{}
+	block, err := u.attachmentBlock(context.Background(), schema.ChatMessagePart{
+		Type:    schema.ChatMessagePartTypeFileURL,
+		FileURL: &schema.ChatMessageFileURL{URL: FileURLScheme + "file_9", MIMEType: "image/jpeg"},
+	})
+	if err != nil || block["type"] != "image" {
+		t.Errorf("expected an image block for an uploaded image, got %v, %v", block, err)
+	}
+	if _, err := u.attachmentBlock(context.Background(), schema.ChatMessagePart{
+		Type:    schema.ChatMessagePartTypeFileURL,
+		FileURL: &schema.ChatMessageFileURL{URL: dataURL("application/zip", "PK"), MIMEType: "application/zip"},
+	}); err == nil {
+		t.Error("expected an unsupported document type to fail")
+	}
+	if block, err := u.attachmentBlock(context.Background(), schema.ChatMessagePart{Type: schema.ChatMessagePartTypeText, Text: "hi"}); block != nil || err != nil {
+		t.Errorf("expected text to be left alone, got %v, %v", block, err)
+	}
+}
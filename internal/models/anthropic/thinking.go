@@ -0,0 +1,186 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	einoclaude "github.com/cloudwego/eino-ext/components/model/claude"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/usage"
+	"github.com/osi4iot/mcphost/internal/tokens"
+)
+
+// thinkingExtraKey is where the eino model leaves a response's thinking
+const thinkingExtraKey = "_eino_claude_thinking"
+
+// maxThinkingCalls bounds how many tool calls' thinking blocks are kept
+const maxThinkingCalls = 256
+
+// takeThinking moves the thinking the eino model leaves in msg's Extra to its
+// ReasoningContent, where the agent looks for reasoning
+func takeThinking(msg *schema.Message) {
+	if msg == nil {
+		return
+	}
+	if thinking, ok := einoclaude.GetThinking(msg); ok {
+		msg.ReasoningContent += thinking
+		delete(msg.Extra, thinkingExtraKey)
+	}
+}
+
+// thinkingBlocks remembers the signed thinking blocks of responses that
+// called tools. With extended thinking on, the API wants them back in front
+// of the tool calls when their results are sent, but the eino model drops
+// them.
+type thinkingBlocks struct {
+	mu     sync.Mutex
+	byCall map[string][]interface{} // the blocks before a tool call, by its ID
+	order  []string                 // tool call IDs, oldest first
+}
+
+func newThinkingBlocks() *thinkingBlocks {
+	return &thinkingBlocks{byCall: make(map[string][]interface{})}
+}
+
+// remember records blocks as the thinking before the tool call id
+func (t *thinkingBlocks) remember(id string, blocks []interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.byCall[id]; !ok {
+		t.order = append(t.order, id)
+	}
+	t.byCall[id] = blocks
+	for len(t.order) > maxThinkingCalls {
+		delete(t.byCall, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// lookup returns the thinking blocks before the tool call id, if known
+func (t *thinkingBlocks) lookup(id string) []interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.byCall[id]
+}
+
+// thinkingCapture reads the thinking blocks of one response, a whole message
+// or the events of a stream, and remembers them for the tool calls after them
+type thinkingCapture struct {
+	store    *thinkingBlocks
+	open     map[int]map[string]interface{} // thinking blocks by stream index
+	blocks   []interface{}                  // thinking blocks so far, in order
+	thinking strings.Builder                // the thinking text
+}
+
+func (t *thinkingBlocks) capture() *thinkingCapture {
+	return &thinkingCapture{store: t, open: make(map[int]map[string]interface{})}
+}
+
+// observe reads one JSON document of the response
+func (c *thinkingCapture) observe(data []byte) {
+	var doc struct {
+		Type         string                   `json:"type"`
+		Index        int                      `json:"index"`
+		ContentBlock map[string]interface{}   `json:"content_block"`
+		Content      []map[string]interface{} `json:"content"`
+		Delta        struct {
+			Type      string `json:"type"`
+			Thinking  string `json:"thinking"`
+			Signature string `json:"signature"`
+		} `json:"delta"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	switch doc.Type {
+	case "message":
+		for i, block := range doc.Content {
+			c.block(i, block)
+		}
+	case "content_block_start":
+		c.block(doc.Index, doc.ContentBlock)
+	case "content_block_delta":
+		block := c.open[doc.Index]
+		if block == nil {
+			return
+		}
+		switch doc.Delta.Type {
+		case "thinking_delta":
+			text, _ := block["thinking"].(string)
+			block["thinking"] = text + doc.Delta.Thinking
+			c.thinking.WriteString(doc.Delta.Thinking)
+		case "signature_delta":
+			signature, _ := block["signature"].(string)
+			block["signature"] = signature + doc.Delta.Signature
+		}
+	}
+}
+
+// block reads a content block at index, complete or just started
+func (c *thinkingCapture) block(index int, block map[string]interface{}) {
+	switch block["type"] {
+	case "thinking", "redacted_thinking":
+		text, _ := block["thinking"].(string)
+		c.thinking.WriteString(text)
+		c.open[index] = block
+		c.blocks = append(c.blocks, block)
+	case "tool_use":
+		// The thinking blocks come first, complete by the time a tool call starts
+		if id, _ := block["id"].(string); id != "" && len(c.blocks) > 0 {
+			c.store.remember(id, c.blocks)
+		}
+	}
+}
+
+// report adds the estimated thinking tokens to rep; the API counts them as
+// output without saying how many there were
+func (c *thinkingCapture) report(rep usage.Report) usage.Report {
+	rep.ReasoningTokens = min(tokens.EstimateTokens(c.thinking.String()), rep.OutputTokens)
+	return rep
+}
+
+// restoreThinking puts the thinking blocks of the last assistant message back
+// in front of its tool calls. When they are not known, as in a loaded
+// session, or the request forces a tool call, which thinking doesn't allow,
+// the request is sent without thinking rather than have it rejected.
+func restoreThinking(requestData map[string]interface{}, store *thinkingBlocks) {
+	if _, ok := requestData["thinking"]; !ok {
+		return
+	}
+	if toolChoice, _ := requestData["tool_choice"].(map[string]interface{}); toolChoice["type"] == "any" || toolChoice["type"] == "tool" {
+		delete(requestData, "thinking")
+		return
+	}
+	messages, _ := requestData["messages"].([]interface{})
+	for i := len(messages) - 1; i >= 0; i-- {
+		msgMap, _ := messages[i].(map[string]interface{})
+		if msgMap["role"] != "assistant" {
+			continue
+		}
+		content, _ := msgMap["content"].([]interface{})
+		var id string
+		for _, contentItem := range content {
+			block, _ := contentItem.(map[string]interface{})
+			switch block["type"] {
+			case "thinking", "redacted_thinking":
+				return
+			case "tool_use":
+				if id == "" {
+					id, _ = block["id"].(string)
+				}
+			}
+		}
+		if id == "" {
+			return
+		}
+		blocks := store.lookup(id)
+		if blocks == nil {
+			delete(requestData, "thinking")
+			return
+		}
+		msgMap["content"] = append(append([]interface{}{}, blocks...), content...)
+		return
+	}
+}
@@ -0,0 +1,93 @@
+package anthropic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/usage"
+)
+
+const thinkingStream = `event: message_start
+data: {"type": "message_start", "message": {"usage": {"input_tokens": 100, "output_tokens": 1}}}
+
+event: content_block_start
+data: {"type": "content_block_start", "index": 0, "content_block": {"type": "thinking", "thinking": "", "signature": ""}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "thinking_delta", "thinking": "Both files are needed, "}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "thinking_delta", "thinking": "so read them together."}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "signature_delta", "signature": "sig-1"}}
+
+event: content_block_start
+data: {"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "id": "toolu_1", "name": "fs__read_file", "input": {}}}
+
+event: message_delta
+data: {"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 60}}
+
+`
+
+func TestThinkingRoundTrip(t *testing.T) {
+	rt := &CustomRoundTripper{thinking: newThinkingBlocks()}
+
+	// A streamed response's thinking is remembered for its tool calls, and
+	// estimated as reasoning tokens
+	ctx, recorder := usage.WithRecorder(context.Background())
+	rt.wrapped = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(thinkingStream)),
+		}, nil
+	})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", strings.NewReader(`{"thinking": {"type": "enabled", "budget_tokens": 2048}}`))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	if rep, _ := recorder.Report(); rep.ReasoningTokens != 11 || rep.OutputTokens != 60 {
+		t.Errorf("unexpected usage %+v", rep)
+	}
+
+	// The blocks go back in front of the tool call with its result
+	body := strings.Replace(toolRequest, `"model"`, `"thinking": {"type": "enabled", "budget_tokens": 2048}, "model"`, 1)
+	sent := sendRequest(t, context.Background(), rt, body)
+	content := sent["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	first := content[0].(map[string]interface{})
+	if len(content) != 3 || first["type"] != "thinking" || first["signature"] != "sig-1" || first["thinking"] != "Both files are needed, so read them together." {
+		t.Errorf("expected the signed thinking block first, got %v", content)
+	}
+	if sent["thinking"] == nil {
+		t.Error("expected thinking kept")
+	}
+
+	// Without the blocks, as for a loaded session, thinking is left off
+	sent = sendRequest(t, context.Background(), &CustomRoundTripper{thinking: newThinkingBlocks()}, body)
+	if sent["thinking"] != nil {
+		t.Error("expected thinking dropped when its blocks are unknown")
+	}
+
+	// Forcing a tool call is not allowed with thinking
+	forced := strings.Replace(body, `"model"`, `"tool_choice": {"type": "any"}, "model"`, 1)
+	if sent = sendRequest(t, context.Background(), rt, forced); sent["thinking"] != nil {
+		t.Error("expected thinking dropped for a forced tool call")
+	}
+}
+
+func TestTakeThinking(t *testing.T) {
+	msg := &schema.Message{Role: schema.Assistant, Extra: map[string]any{thinkingExtraKey: "Let me see."}}
+	takeThinking(msg)
+	if msg.ReasoningContent != "Let me see." || msg.Extra[thinkingExtraKey] != nil {
+		t.Errorf("expected the thinking moved to ReasoningContent, got %+v", msg)
+	}
+	takeThinking(nil)
+}
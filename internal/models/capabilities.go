@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParameterAdjustment records a generation parameter that was dropped or
+// changed because the provider or model does not support it as given
+type ParameterAdjustment struct {
+	Parameter string // flag name, e.g. "top-k"
+	Message   string
+	Defaulted bool // the parameter was not set by the user
+}
+
+func (a ParameterAdjustment) String() string {
+	return fmt.Sprintf("%s: %s", a.Parameter, a.Message)
+}
+
+// unsupportedParameters lists the generation parameters each provider's API
+// has no equivalent for
+var unsupportedParameters = map[string][]string{
+	"openai":   {"top-k"},
+	"azure":    {"top-k"},
+	"groq":     {"top-k"},
+	"xai":      {"top-k"},
+	"deepseek": {"top-k"},
+	"google":   {"stop-sequences", "parallel-tool-calls"},
+	"ollama":   {"parallel-tool-calls", "tool-choice"},
+
+	CompatibleProvider: {"top-k"},
+}
+
+// gateParameters drops or adjusts the generation parameters in config that
+// the provider, or the model when modelInfo is known, does not support, and
+// reports each change
+func gateParameters(config *ProviderConfig, provider string, modelInfo *ModelInfo) []ParameterAdjustment {
+	var adjustments []ParameterAdjustment
+	drop := func(param, reason string) {
+		adjustments = append(adjustments, ParameterAdjustment{
+			Parameter: param,
+			Message:   "ignored, " + reason,
+			Defaulted: config.DefaultParameters[param],
+		})
+	}
+
+	for _, param := range unsupportedParameters[provider] {
+		reason := fmt.Sprintf("not supported by %s", provider)
+		switch param {
+		case "top-k":
+			if config.TopK != nil {
+				config.TopK = nil
+				drop(param, reason)
+			}
+		case "stop-sequences":
+			if len(config.StopSequences) > 0 {
+				config.StopSequences = nil
+				drop(param, reason)
+			}
+		case "parallel-tool-calls":
+			if config.ParallelToolCalls != nil {
+				config.ParallelToolCalls = nil
+				drop(param, reason)
+			}
+		case "tool-choice":
+			// none is kept: the agent offers no tools instead
+			if config.ToolChoice != "" && config.ToolChoice != "auto" && config.ToolChoice != "none" {
+				config.ToolChoice = ""
+				drop(param, reason)
+			}
+		}
+	}
+
+	if modelInfo == nil {
+		return adjustments
+	}
+
+	if config.Temperature != nil && !modelInfo.Temperature {
+		config.Temperature = nil
+		drop("temperature", fmt.Sprintf("%s does not support it", modelInfo.ID))
+	}
+
+	// OpenAI reasoning models reject sampling parameters, and so are declared
+	// reasoning models on OpenAI-compatible servers assumed to
+	if (provider == "openai" || provider == CompatibleProvider) && modelInfo.Reasoning {
+		reason := fmt.Sprintf("%s is a reasoning model", modelInfo.ID)
+		if config.Temperature != nil {
+			config.Temperature = nil
+			drop("temperature", reason)
+		}
+		if config.TopP != nil {
+			config.TopP = nil
+			drop("top-p", reason)
+		}
+	}
+
+	// Models declared without tools and not emulating them are offered none,
+	// so there is no call to force
+	if config.ToolEmulation == ToolEmulationNever && !SupportsTools(provider+":"+modelInfo.ID) && config.ToolChoice != "" && config.ToolChoice != "auto" && config.ToolChoice != "none" {
+		config.ToolChoice = ""
+		drop("tool-choice", fmt.Sprintf("%s is declared without tools", modelInfo.ID))
+	}
+
+	// xAI reasoning models reject stop sequences
+	if provider == "xai" && modelInfo.Reasoning && len(config.StopSequences) > 0 {
+		config.StopSequences = nil
+		drop("stop-sequences", fmt.Sprintf("%s is a reasoning model", modelInfo.ID))
+	}
+
+	if modelInfo.Limit.Output > 0 && config.MaxTokens > modelInfo.Limit.Output {
+		adjustments = append(adjustments, ParameterAdjustment{
+			Parameter: "max-tokens",
+			Message: fmt.Sprintf("reduced from %d to %s's output limit of %d",
+				config.MaxTokens, modelInfo.ID, modelInfo.Limit.Output),
+			Defaulted: config.DefaultParameters["max-tokens"],
+		})
+		config.MaxTokens = modelInfo.Limit.Output
+	}
+
+	return adjustments
+}
+
+// DefaultThinkingBudget is the thinking budget of --thinking when
+// --thinking-budget is not given
+const DefaultThinkingBudget = 10000
+
+// minThinkingBudget is the smallest budget Anthropic's extended thinking takes
+const minThinkingBudget = 1024
+
+// gateThinking drops the thinking budget for models that can't think on
+// request, and adjusts the parameters Anthropic's extended thinking needs:
+// no temperature or top-k, and max tokens that leave room for the answer
+// after the thinking. OpenAI's reasoning models take it as a reasoning effort.
+func gateThinking(config *ProviderConfig, provider string, modelInfo *ModelInfo) []ParameterAdjustment {
+	if config.ThinkingBudget <= 0 {
+		return nil
+	}
+	var adjustments []ParameterAdjustment
+	adjust := func(param, message string) {
+		adjustments = append(adjustments, ParameterAdjustment{
+			Parameter: param,
+			Message:   message,
+			Defaulted: config.DefaultParameters[param],
+		})
+	}
+
+	switch {
+	case provider == "openai" && modelInfo != nil && modelInfo.Reasoning:
+		return nil
+	case provider != "anthropic":
+		config.ThinkingBudget = 0
+		adjust("thinking", fmt.Sprintf("ignored, not supported by %s", provider))
+		return adjustments
+	case modelInfo != nil && !modelInfo.Reasoning:
+		config.ThinkingBudget = 0
+		adjust("thinking", fmt.Sprintf("ignored, %s does not support extended thinking", modelInfo.ID))
+		return adjustments
+	}
+
+	if config.ThinkingBudget < minThinkingBudget {
+		adjust("thinking-budget", fmt.Sprintf("raised from %d to the minimum of %d", config.ThinkingBudget, minThinkingBudget))
+		config.ThinkingBudget = minThinkingBudget
+	}
+	if config.Temperature != nil {
+		config.Temperature = nil
+		adjust("temperature", "ignored, not supported with extended thinking")
+	}
+	if config.TopK != nil {
+		config.TopK = nil
+		adjust("top-k", "ignored, not supported with extended thinking")
+	}
+	if config.TopP != nil && *config.TopP < 0.95 {
+		config.TopP = nil
+		adjust("top-p", "ignored, below the 0.95 extended thinking allows")
+	}
+
+	// max_tokens covers the thinking as well as the answer
+	maxTokens := config.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+	if maxTokens <= config.ThinkingBudget {
+		raised := maxTokens + config.ThinkingBudget
+		if modelInfo != nil && modelInfo.Limit.Output > 0 {
+			raised = min(raised, modelInfo.Limit.Output)
+		}
+		adjust("max-tokens", fmt.Sprintf("raised from %d to %d to leave room for the answer after thinking", maxTokens, raised))
+		config.MaxTokens = raised
+	}
+	if config.ThinkingBudget >= config.MaxTokens {
+		budget := config.MaxTokens / 2
+		adjust("thinking-budget", fmt.Sprintf("reduced from %d to %d, half of max tokens", config.ThinkingBudget, budget))
+		config.ThinkingBudget = budget
+	}
+	return adjustments
+}
+
+// AcceptsImages reports whether the model in modelString ("provider:model")
+// takes image input according to the models database. Models missing from
+// the database are assumed not to.
+func AcceptsImages(modelString string) bool {
+	provider, modelName, ok := strings.Cut(modelString, ":")
+	if !ok {
+		return false
+	}
+	info, err := GetGlobalRegistry().ValidateModel(provider, modelName)
+	return err == nil && info.Attachment
+}
+
+// AcceptsAudio reports whether the model in modelString takes audio input.
+// The models database does not record input modalities, so this is limited
+// to Gemini models, the only provider here that sends audio parts.
+func AcceptsAudio(modelString string) bool {
+	provider, modelName, ok := strings.Cut(modelString, ":")
+	return ok && provider == "google" && strings.HasPrefix(modelName, "gemini")
+}
+
+// AcceptsDocuments reports whether the model in modelString takes PDF and
+// text documents as attachments, and attachments uploaded with a file API.
+// Only the Anthropic provider sends them.
+func AcceptsDocuments(modelString string) bool {
+	provider, _, _ := strings.Cut(modelString, ":")
+	return provider == "anthropic" && AcceptsImages(modelString)
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestGateParameters(t *testing.T) {
+	temp := float32(0.7)
+	topP := float32(0.9)
+	topK := int32(40)
+
+	tests := []struct {
+		name      string
+		provider  string
+		modelInfo *ModelInfo
+		config    ProviderConfig
+		want      []string
+		check     func(t *testing.T, c *ProviderConfig)
+	}{
+		{
+			name:      "openai reasoning model",
+			provider:  "openai",
+			modelInfo: &ModelInfo{ID: "o3", Reasoning: true, Temperature: false, Limit: Limit{Output: 100000}},
+			config:    ProviderConfig{Temperature: &temp, TopP: &topP, TopK: &topK, MaxTokens: 4096},
+			want:      []string{"top-k", "temperature", "top-p"},
+			check: func(t *testing.T, c *ProviderConfig) {
+				if c.Temperature != nil || c.TopP != nil || c.TopK != nil {
+					t.Error("expected sampling parameters to be cleared")
+				}
+				if c.MaxTokens != 4096 {
+					t.Errorf("max tokens changed to %d", c.MaxTokens)
+				}
+			},
+		},
+		{
+			name:      "max tokens over the output limit",
+			provider:  "anthropic",
+			modelInfo: &ModelInfo{ID: "claude-3-5-haiku-20241022", Temperature: true, Limit: Limit{Output: 8192}},
+			config:    ProviderConfig{Temperature: &temp, TopK: &topK, MaxTokens: 20000},
+			want:      []string{"max-tokens"},
+			check: func(t *testing.T, c *ProviderConfig) {
+				if c.MaxTokens != 8192 {
+					t.Errorf("max tokens = %d, want 8192", c.MaxTokens)
+				}
+				if c.Temperature == nil || c.TopK == nil {
+					t.Error("supported parameters should be kept")
+				}
+			},
+		},
+		{
+			name:     "google without model info",
+			provider: "google",
+			config:   ProviderConfig{StopSequences: []string{"END"}, TopK: &topK},
+			want:     []string{"stop-sequences"},
+		},
+		{
+			name:     "parallel tool calls without a provider setting",
+			provider: "ollama",
+			config:   ProviderConfig{TopK: &topK, ParallelToolCalls: new(bool)},
+			want:     []string{"parallel-tool-calls"},
+			check: func(t *testing.T, c *ProviderConfig) {
+				if c.ParallelToolCalls != nil {
+					t.Error("expected parallel tool calls to be cleared")
+				}
+			},
+		},
+		{
+			name:     "ollama cannot force a tool call",
+			provider: "ollama",
+			config:   ProviderConfig{ToolChoice: "required"},
+			want:     []string{"tool-choice"},
+			check: func(t *testing.T, c *ProviderConfig) {
+				if c.ToolChoice != "" {
+					t.Errorf("expected tool choice cleared, got %q", c.ToolChoice)
+				}
+			},
+		},
+		{
+			name:      "xai reasoning model",
+			provider:  "xai",
+			modelInfo: &ModelInfo{ID: "grok-4", Reasoning: true, Temperature: true, Limit: Limit{Output: 64000}},
+			config:    ProviderConfig{Temperature: &temp, TopK: &topK, StopSequences: []string{"END"}},
+			want:      []string{"top-k", "stop-sequences"},
+			check: func(t *testing.T, c *ProviderConfig) {
+				if c.StopSequences != nil || c.Temperature == nil {
+					t.Error("expected only the stop sequences dropped")
+				}
+			},
+		},
+		{
+			name:     "ollama keeps tool choice none",
+			provider: "ollama",
+			config:   ProviderConfig{ToolChoice: "none"},
+		},
+		{
+			name:     "ollama keeps everything",
+			provider: "ollama",
+			config:   ProviderConfig{Temperature: &temp, TopP: &topP, TopK: &topK, StopSequences: []string{"END"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			adjustments := gateParameters(&config, tt.provider, tt.modelInfo)
+			var got []string
+			for _, a := range adjustments {
+				got = append(got, a.Parameter)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("adjusted %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("adjusted %v, want %v", got, tt.want)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, &config)
+			}
+		})
+	}
+}
+
+func TestGateThinking(t *testing.T) {
+	temp := float32(0.7)
+	topK := int32(40)
+	sonnet := &ModelInfo{ID: "claude-sonnet-4-20250514", Reasoning: true, Temperature: true, Limit: Limit{Output: 64000}}
+
+	config := ProviderConfig{ThinkingBudget: 10000, Temperature: &temp, TopK: &topK, MaxTokens: 4096}
+	adjustments := gateThinking(&config, "anthropic", sonnet)
+	if len(adjustments) != 3 || config.Temperature != nil || config.TopK != nil {
+		t.Errorf("expected temperature and top-k dropped and max tokens raised, got %v", adjustments)
+	}
+	if config.MaxTokens != 14096 || config.ThinkingBudget != 10000 {
+		t.Errorf("expected room for the answer, got %d max tokens for a %d budget", config.MaxTokens, config.ThinkingBudget)
+	}
+
+	config = ProviderConfig{ThinkingBudget: 500, MaxTokens: 4096}
+	gateThinking(&config, "anthropic", sonnet)
+	if config.ThinkingBudget != minThinkingBudget {
+		t.Errorf("expected the budget raised to the minimum, got %d", config.ThinkingBudget)
+	}
+
+	config = ProviderConfig{ThinkingBudget: 10000, Temperature: &temp}
+	if adjustments := gateThinking(&config, "anthropic", &ModelInfo{ID: "claude-3-5-haiku-20241022", Temperature: true}); len(adjustments) != 1 || config.ThinkingBudget != 0 || config.Temperature == nil {
+		t.Errorf("expected only thinking dropped for a model without it, got %v", adjustments)
+	}
+
+	config = ProviderConfig{ThinkingBudget: 10000}
+	if adjustments := gateThinking(&config, "openai", &ModelInfo{ID: "o3", Reasoning: true}); adjustments != nil || config.ThinkingBudget != 10000 {
+		t.Errorf("expected OpenAI reasoning models to keep the budget, got %v", adjustments)
+	}
+	if adjustments := gateThinking(&config, "google", nil); len(adjustments) != 1 || config.ThinkingBudget != 0 {
+		t.Errorf("expected thinking dropped for other providers, got %v", adjustments)
+	}
+	if reasoningEffort(2000) != "low" || reasoningEffort(10000) != "medium" || reasoningEffort(32000) != "high" {
+		t.Error("unexpected reasoning efforts")
+	}
+}
+
+func TestAcceptsMedia(t *testing.T) {
+	if !AcceptsImages("anthropic:claude-sonnet-4-20250514") {
+		t.Error("expected Claude Sonnet 4 to accept images")
+	}
+	if AcceptsImages("ollama:llama3") || AcceptsImages("nonsense") {
+		t.Error("unknown models should not be sent images")
+	}
+	if !AcceptsDocuments("anthropic:claude-sonnet-4-20250514") || AcceptsDocuments("openai:gpt-4o") {
+		t.Error("expected documents for Claude models only")
+	}
+	if !AcceptsAudio("google:gemini-2.5-flash") || AcceptsAudio("anthropic:claude-sonnet-4-20250514") {
+		t.Error("only Gemini models should be sent audio")
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	einoopenai "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+
+	"github.com/osi4iot/mcphost/internal/models/openai"
+)
+
+// CompatibleProvider is the provider of models served by any server that
+// speaks the OpenAI chat API, such as llama.cpp, vLLM or LM Studio
+const CompatibleProvider = "openai-compatible"
+
+// CompatibleConfig is the openai-compatible config section: where the server
+// is and what its models can do
+type CompatibleConfig struct {
+	URL    string                       `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url"`             // base URL; --provider-url overrides it
+	APIKey string                       `json:"api-key,omitempty" yaml:"api-key,omitempty" mapstructure:"api-key"` // optional; --provider-api-key overrides it
+	Models map[string]ModelCapabilities `json:"models,omitempty" yaml:"models,omitempty" mapstructure:"models"`
+}
+
+// ModelCapabilities declares what a model on an OpenAI-compatible server
+// supports. Unset fields keep the defaults: tools and temperature are
+// supported, reasoning and images are not, and the limits are unknown.
+type ModelCapabilities struct {
+	Tools       *bool `json:"tools,omitempty" yaml:"tools,omitempty" mapstructure:"tools"`                   // native tool calls; without them tools are emulated
+	Temperature *bool `json:"temperature,omitempty" yaml:"temperature,omitempty" mapstructure:"temperature"` // accepts temperature
+	Reasoning   bool  `json:"reasoning,omitempty" yaml:"reasoning,omitempty" mapstructure:"reasoning"`       // a reasoning model, without sampling parameters
+	Images      bool  `json:"images,omitempty" yaml:"images,omitempty" mapstructure:"images"`                // takes image input
+	Context     int   `json:"context,omitempty" yaml:"context,omitempty" mapstructure:"context"`             // context window in tokens
+	MaxOutput   int   `json:"max-output,omitempty" yaml:"max-output,omitempty" mapstructure:"max-output"`    // most tokens in a response
+}
+
+// compatible is the registered openai-compatible section
+var compatible CompatibleConfig
+
+// RegisterCompatibleModels makes cfg the openai-compatible section and adds
+// its models to the global registry, so validation, parameter gating, image
+// support and the context gauge follow their declared capabilities. Call it
+// once at startup, before models are created.
+func RegisterCompatibleModels(cfg CompatibleConfig) {
+	compatible = cfg
+	info := ProviderInfo{ID: CompatibleProvider, Name: "OpenAI-compatible", Models: map[string]ModelInfo{}}
+	for id, caps := range cfg.Models {
+		info.Models[id] = ModelInfo{
+			ID:          id,
+			Name:        id,
+			Attachment:  caps.Images,
+			Reasoning:   caps.Reasoning,
+			Temperature: caps.Temperature == nil || *caps.Temperature,
+			Limit:       Limit{Context: caps.Context, Output: caps.MaxOutput},
+		}
+	}
+	globalRegistry.providers[CompatibleProvider] = info
+}
+
+// compatibleModelInfo returns the registry entry of a declared
+// openai-compatible model, or nil for models without one
+func compatibleModelInfo(modelName string) *ModelInfo {
+	info, err := globalRegistry.ValidateModel(CompatibleProvider, modelName)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// SupportsTools reports whether the model in modelString ("provider:model")
+// has native tool calls. Only openai-compatible and custom models declared
+// without tools, models whose custom endpoint was found to reject them, and
+// Ollama models without the tools capability don't.
+func SupportsTools(modelString string) bool {
+	provider, modelName, _ := strings.Cut(modelString, ":")
+	if caps, ok := compatible.Models[modelName]; ok && provider == CompatibleProvider && caps.Tools != nil {
+		return *caps.Tools
+	}
+	if tools, ok := customTools[modelString]; ok {
+		return tools
+	}
+	if profile, ok := probed[modelString]; ok {
+		return profile.Tools
+	}
+	return !withoutNativeTools[modelString]
+}
+
+// createCompatibleProvider creates a model on an OpenAI-compatible server,
+// from --provider-url or the openai-compatible section
+func createCompatibleProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
+	baseURL := config.ProviderURL
+	if baseURL == "" {
+		baseURL = compatible.URL
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("no URL for the %s provider. Use --provider-url or set url under openai-compatible in the config file", CompatibleProvider)
+	}
+	apiKey := config.ProviderAPIKey
+	if apiKey == "" {
+		apiKey = compatible.APIKey
+	}
+
+	compatibleConfig := &einoopenai.ChatModelConfig{
+		APIKey:     apiKey,
+		Model:      modelName,
+		BaseURL:    baseURL,
+		HTTPClient: createHTTPClientWithTLSConfig(config),
+	}
+
+	if config.MaxTokens > 0 {
+		compatibleConfig.MaxTokens = &config.MaxTokens
+	}
+
+	if config.Temperature != nil {
+		compatibleConfig.Temperature = config.Temperature
+	}
+
+	if config.TopP != nil {
+		compatibleConfig.TopP = config.TopP
+	}
+
+	if len(config.StopSequences) > 0 {
+		compatibleConfig.Stop = config.StopSequences
+	}
+
+	return openai.NewCustomChatModel(ctx, compatibleConfig, config.ParallelToolCalls)
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompatibleModels(t *testing.T) {
+	noTools, noTemperature := false, false
+	RegisterCompatibleModels(CompatibleConfig{
+		URL: "http://localhost:8080/v1",
+		Models: map[string]ModelCapabilities{
+			"qwen-coder": {Context: 32768, MaxOutput: 8192, Images: true},
+			"tiny":       {Tools: &noTools, Temperature: &noTemperature},
+			"thinker":    {Reasoning: true},
+		},
+	})
+	t.Cleanup(func() { RegisterCompatibleModels(CompatibleConfig{}) })
+
+	info := compatibleModelInfo("qwen-coder")
+	if info == nil || info.Limit.Context != 32768 || !info.Temperature || !AcceptsImages("openai-compatible:qwen-coder") {
+		t.Fatalf("expected the declared capabilities in the registry, got %+v", info)
+	}
+	if compatibleModelInfo("unlisted") != nil {
+		t.Error("expected no entry for a model that is not declared")
+	}
+	if !SupportsTools("openai-compatible:unlisted") || SupportsTools("openai-compatible:tiny") || !SupportsTools("anthropic:claude") {
+		t.Error("expected only the model declared without tools to lack them")
+	}
+	tiny := &ProviderConfig{ModelString: "openai-compatible:tiny"}
+	if !EmulatesTools(tiny) || !CallsTools(tiny) || EmulatesTools(&ProviderConfig{ModelString: "openai-compatible:unlisted"}) {
+		t.Error("expected tools emulated only for the model without them")
+	}
+	tiny.ToolEmulation = ToolEmulationNever
+	if CallsTools(tiny) {
+		t.Error("expected no tools with emulation off")
+	}
+
+	temp, topP := float32(0.7), float32(0.9)
+	config := ProviderConfig{Temperature: &temp, TopP: &topP, MaxTokens: 20000, ToolChoice: "required", ToolEmulation: ToolEmulationNever}
+	var got []string
+	for _, a := range gateParameters(&config, CompatibleProvider, compatibleModelInfo("tiny")) {
+		got = append(got, a.Parameter)
+	}
+	if strings.Join(got, ",") != "temperature,tool-choice" {
+		t.Errorf("expected temperature and tool choice dropped for tiny, got %v", got)
+	}
+	config = ProviderConfig{Temperature: &temp, TopP: &topP, MaxTokens: 20000}
+	gateParameters(&config, CompatibleProvider, compatibleModelInfo("thinker"))
+	if config.Temperature != nil || config.TopP != nil {
+		t.Error("expected a reasoning model to get no sampling parameters")
+	}
+	config = ProviderConfig{MaxTokens: 20000}
+	gateParameters(&config, CompatibleProvider, compatibleModelInfo("qwen-coder"))
+	if config.MaxTokens != 8192 {
+		t.Errorf("expected max tokens capped at the declared output, got %d", config.MaxTokens)
+	}
+
+	if _, err := CreateProvider(context.Background(), &ProviderConfig{ModelString: "openai-compatible:unlisted"}); err != nil {
+		t.Errorf("expected any model on the configured server, got %v", err)
+	}
+	RegisterCompatibleModels(CompatibleConfig{})
+	if _, err := CreateProvider(context.Background(), &ProviderConfig{ModelString: "openai-compatible:unlisted"}); err == nil || !strings.Contains(err.Error(), "no URL") {
+		t.Errorf("expected an error without a URL, got %v", err)
+	}
+}
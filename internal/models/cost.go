@@ -0,0 +1,80 @@
+package models
+
+import (
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/usage"
+)
+
+// TokenCounts is the token usage of one or more model calls, split by how
+// each kind of token is billed
+type TokenCounts struct {
+	InputTokens      int // prompt tokens not read from or written to the cache
+	OutputTokens     int // completion tokens, reasoning included
+	CacheReadTokens  int
+	CacheWriteTokens int
+	ReasoningTokens  int // the part of OutputTokens spent on hidden reasoning
+}
+
+// Add accumulates other into t
+func (t *TokenCounts) Add(other TokenCounts) {
+	t.InputTokens += other.InputTokens
+	t.OutputTokens += other.OutputTokens
+	t.CacheReadTokens += other.CacheReadTokens
+	t.CacheWriteTokens += other.CacheWriteTokens
+	t.ReasoningTokens += other.ReasoningTokens
+}
+
+// PromptTokens is the full prompt size, cached tokens included
+func (t TokenCounts) PromptTokens() int {
+	return t.InputTokens + t.CacheReadTokens + t.CacheWriteTokens
+}
+
+// TokenCountsFromMessage extracts the usage reported on a model response
+func TokenCountsFromMessage(msg *schema.Message) (TokenCounts, bool) {
+	if msg == nil || msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return TokenCounts{}, false
+	}
+	u := msg.ResponseMeta.Usage
+	details := usage.Get(msg)
+	cacheRead := u.PromptTokenDetails.CachedTokens
+	return TokenCounts{
+		InputTokens:      max(u.PromptTokens-cacheRead-details.CacheWriteTokens, 0),
+		OutputTokens:     u.CompletionTokens,
+		CacheReadTokens:  cacheRead,
+		CacheWriteTokens: details.CacheWriteTokens,
+		ReasoningTokens:  details.ReasoningTokens,
+	}, true
+}
+
+// TokenCost is the price in dollars of a TokenCounts, by kind of token
+type TokenCost struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// Total is the overall price
+func (c TokenCost) Total() float64 {
+	return c.Input + c.Output + c.CacheRead + c.CacheWrite
+}
+
+// Price returns what counts costs at these per-million-token rates.
+// Reasoning tokens are billed as output. Cache tokens on a model without a
+// cache price are billed as ordinary input.
+func (c Cost) Price(counts TokenCounts) TokenCost {
+	cacheRead, cacheWrite := c.Input, c.Input
+	if c.CacheRead != nil {
+		cacheRead = *c.CacheRead
+	}
+	if c.CacheWrite != nil {
+		cacheWrite = *c.CacheWrite
+	}
+	return TokenCost{
+		Input:      float64(counts.InputTokens) * c.Input / 1000000,
+		Output:     float64(counts.OutputTokens) * c.Output / 1000000,
+		CacheRead:  float64(counts.CacheReadTokens) * cacheRead / 1000000,
+		CacheWrite: float64(counts.CacheWriteTokens) * cacheWrite / 1000000,
+	}
+}
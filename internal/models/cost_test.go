@@ -0,0 +1,54 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/usage"
+)
+
+func TestTokenCountsFromMessage(t *testing.T) {
+	msg := usage.Report{InputTokens: 1000, OutputTokens: 300, CacheReadTokens: 600, CacheWriteTokens: 100, ReasoningTokens: 200}.Chunk()
+	counts, ok := TokenCountsFromMessage(msg)
+	if !ok {
+		t.Fatal("expected usage")
+	}
+	want := TokenCounts{InputTokens: 300, OutputTokens: 300, CacheReadTokens: 600, CacheWriteTokens: 100, ReasoningTokens: 200}
+	if counts != want {
+		t.Errorf("counts = %+v, want %+v", counts, want)
+	}
+	if counts.PromptTokens() != 1000 {
+		t.Errorf("PromptTokens = %d, want 1000", counts.PromptTokens())
+	}
+
+	if _, ok := TokenCountsFromMessage(&schema.Message{Role: schema.Assistant}); ok {
+		t.Error("expected no usage on a message without metadata")
+	}
+}
+
+func TestCostPrice(t *testing.T) {
+	read, write := 0.3, 3.75
+	cost := Cost{Input: 3, Output: 15, CacheRead: &read, CacheWrite: &write}
+	counts := TokenCounts{InputTokens: 1000000, OutputTokens: 100000, CacheReadTokens: 2000000, CacheWriteTokens: 1000000, ReasoningTokens: 50000}
+
+	got := cost.Price(counts)
+	want := TokenCost{Input: 3, Output: 1.5, CacheRead: 0.6, CacheWrite: 3.75}
+	for name, pair := range map[string][2]float64{
+		"input":       {got.Input, want.Input},
+		"output":      {got.Output, want.Output},
+		"cache read":  {got.CacheRead, want.CacheRead},
+		"cache write": {got.CacheWrite, want.CacheWrite},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s cost = %f, want %f", name, pair[0], pair[1])
+		}
+	}
+
+	// Without cache prices cached tokens are billed as input
+	plain := Cost{Input: 1, Output: 2}.Price(TokenCounts{CacheReadTokens: 1000000})
+	if math.Abs(plain.CacheRead-1) > 1e-9 {
+		t.Errorf("cache read without a cache price = %f, want 1", plain.CacheRead)
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// customProviders lists the providers custom models can be declared for
+var customProviders = []string{"anthropic", "openai", "google", "ollama", "azure", "groq", "xai", "deepseek", LlamaCppProvider}
+
+// CustomModel is an entry of the custom-models config section: a model the
+// registry doesn't know, such as a fine-tune or a model behind a proxy,
+// declared with its limits, prices and capabilities
+type CustomModel struct {
+	ID          string     `json:"id" yaml:"id" mapstructure:"id"`
+	Provider    string     `json:"provider" yaml:"provider" mapstructure:"provider"`
+	Name        string     `json:"name,omitempty" yaml:"name,omitempty" mapstructure:"name"`                      // display name; the ID by default
+	Context     int        `json:"context,omitempty" yaml:"context,omitempty" mapstructure:"context"`             // context window in tokens
+	MaxOutput   int        `json:"max-output,omitempty" yaml:"max-output,omitempty" mapstructure:"max-output"`    // most tokens in a response
+	Cost        CustomCost `json:"cost,omitempty" yaml:"cost,omitempty" mapstructure:"cost"`                      // dollars per million tokens
+	Tools       *bool      `json:"tools,omitempty" yaml:"tools,omitempty" mapstructure:"tools"`                   // native tool calls (default true); false emulates them
+	Temperature *bool      `json:"temperature,omitempty" yaml:"temperature,omitempty" mapstructure:"temperature"` // accepts temperature (default true)
+	Reasoning   bool       `json:"reasoning,omitempty" yaml:"reasoning,omitempty" mapstructure:"reasoning"`       // a reasoning model, without sampling parameters
+	Images      bool       `json:"images,omitempty" yaml:"images,omitempty" mapstructure:"images"`                // takes image input
+}
+
+// CustomCost is what a custom model costs, in dollars per million tokens
+type CustomCost struct {
+	Input      float64  `json:"input,omitempty" yaml:"input,omitempty" mapstructure:"input"`
+	Output     float64  `json:"output,omitempty" yaml:"output,omitempty" mapstructure:"output"`
+	CacheRead  *float64 `json:"cache-read,omitempty" yaml:"cache-read,omitempty" mapstructure:"cache-read"`    // cached input read; the input price by default
+	CacheWrite *float64 `json:"cache-write,omitempty" yaml:"cache-write,omitempty" mapstructure:"cache-write"` // input written to the cache; the input price by default
+}
+
+// customModels holds the declared custom models, by model string, with
+// their tool support when it was declared
+var (
+	customModels = map[string]bool{}
+	customTools  = map[string]bool{}
+)
+
+// RegisterCustomModels checks the custom-models section and adds its models
+// to the global registry, replacing entries with the same ID, so they pass
+// validation and are priced and gated by what was declared for them. Call it
+// once at startup, before models are created.
+func RegisterCustomModels(declared []CustomModel) error {
+	seen := make(map[string]bool)
+	for i, m := range declared {
+		switch {
+		case m.ID == "":
+			return fmt.Errorf("custom-models[%d]: id is required", i)
+		case m.Provider == CompatibleProvider:
+			return fmt.Errorf("custom-models[%d]: declare %s models under openai-compatible.models", i, CompatibleProvider)
+		case !slices.Contains(customProviders, m.Provider):
+			return fmt.Errorf("custom-models[%d]: provider must be one of %s, got %q", i, strings.Join(customProviders, ", "), m.Provider)
+		case m.Context < 0 || m.MaxOutput < 0 || m.Cost.Input < 0 || m.Cost.Output < 0:
+			return fmt.Errorf("custom-models[%d]: limits and costs can't be negative", i)
+		case seen[m.Provider+":"+m.ID]:
+			return fmt.Errorf("custom-models[%d]: %s:%s is declared twice", i, m.Provider, m.ID)
+		}
+		seen[m.Provider+":"+m.ID] = true
+	}
+
+	for _, m := range declared {
+		name := m.Name
+		if name == "" {
+			name = m.ID
+		}
+		provider, ok := globalRegistry.providers[m.Provider]
+		if !ok {
+			provider = ProviderInfo{ID: m.Provider, Name: m.Provider}
+		}
+		if provider.Models == nil {
+			provider.Models = make(map[string]ModelInfo)
+		}
+		provider.Models[m.ID] = ModelInfo{
+			ID:          m.ID,
+			Name:        name,
+			Attachment:  m.Images,
+			Reasoning:   m.Reasoning,
+			Temperature: m.Temperature == nil || *m.Temperature,
+			Cost:        Cost{Input: m.Cost.Input, Output: m.Cost.Output, CacheRead: m.Cost.CacheRead, CacheWrite: m.Cost.CacheWrite},
+			Limit:       Limit{Context: m.Context, Output: m.MaxOutput},
+		}
+		globalRegistry.providers[m.Provider] = provider
+		customModels[m.Provider+":"+m.ID] = true
+		if m.Tools != nil {
+			customTools[m.Provider+":"+m.ID] = *m.Tools
+		}
+	}
+	return nil
+}
+
+// customModelInfo returns the registry entry of a model declared in the
+// custom-models section, or nil for models without one
+func customModelInfo(provider, modelName string) *ModelInfo {
+	if !customModels[provider+":"+modelName] {
+		return nil
+	}
+	info, err := globalRegistry.ValidateModel(provider, modelName)
+	if err != nil {
+		return nil
+	}
+	return info
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegisterCustomModels(t *testing.T) {
+	defer func(providers map[string]ProviderInfo) { globalRegistry.providers = providers }(globalRegistry.providers)
+	globalRegistry.providers = GetModelsData()
+	defer func() { customModels, customTools = map[string]bool{}, map[string]bool{} }()
+
+	noTools, cacheRead := false, 0.15
+	err := RegisterCustomModels([]CustomModel{
+		{
+			ID: "ft:gpt-4o-mini-2024-07-18:acme::abc123", Provider: "openai", Name: "Support bot",
+			Context: 128000, MaxOutput: 16384, Images: true,
+			Cost: CustomCost{Input: 0.3, Output: 1.2, CacheRead: &cacheRead},
+		},
+		{ID: "acme-coder", Provider: "ollama", Context: 32768, Tools: &noTools},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := globalRegistry.ValidateModel("openai", "ft:gpt-4o-mini-2024-07-18:acme::abc123")
+	if err != nil || info.Name != "Support bot" || !info.Attachment || !info.Temperature || info.Limit.Output != 16384 {
+		t.Fatalf("expected the fine-tune registered, got %+v, %v", info, err)
+	}
+	price := info.Cost.Price(TokenCounts{InputTokens: 1000000, OutputTokens: 1000000, CacheReadTokens: 1000000}).Total()
+	if price < 1.649 || price > 1.651 {
+		t.Errorf("expected the declared prices, got $%.4f", price)
+	}
+	if _, err := globalRegistry.ValidateModel("openai", "gpt-4o"); err != nil {
+		t.Errorf("expected the built-in models kept, got %v", err)
+	}
+	if SupportsTools("ollama:acme-coder") || !SupportsTools("openai:ft:gpt-4o-mini-2024-07-18:acme::abc123") {
+		t.Error("expected the declared tool support")
+	}
+	if info := customModelInfo("ollama", "acme-coder"); info == nil || info.Limit.Context != 32768 {
+		t.Errorf("expected the Ollama model's entry, got %+v", info)
+	}
+	if customModelInfo("openai", "gpt-4o") != nil {
+		t.Error("expected no custom entry for a built-in model")
+	}
+
+	// Served from a proxy, the fine-tune is still gated by its entry
+	temperature := float32(0.5)
+	config := &ProviderConfig{ModelString: "openai:ft:gpt-4o-mini-2024-07-18:acme::abc123", ProviderURL: "http://127.0.0.1:1", ProviderAPIKey: "key", Temperature: &temperature}
+	if _, err := CreateProvider(context.Background(), config); err != nil {
+		t.Fatalf("expected the declared model accepted, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		model CustomModel
+		want  string
+	}{
+		{CustomModel{Provider: "openai"}, "id is required"},
+		{CustomModel{ID: "m", Provider: "acme"}, "provider must be one of"},
+		{CustomModel{ID: "m", Provider: CompatibleProvider}, "openai-compatible.models"},
+		{CustomModel{ID: "m", Provider: "openai", Context: -1}, "can't be negative"},
+	} {
+		if err := RegisterCustomModels([]CustomModel{tc.model}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected an error mentioning %q, got %v", tc.want, err)
+		}
+	}
+	twice := []CustomModel{{ID: "m", Provider: "openai"}, {ID: "m", Provider: "openai"}}
+	if err := RegisterCustomModels(twice); err == nil || !strings.Contains(err.Error(), "declared twice") {
+		t.Errorf("expected a duplicate to fail, got %v", err)
+	}
+}
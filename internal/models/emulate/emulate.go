@@ -0,0 +1,202 @@
+// Package emulate gives tools to models without native function calling. The
+// tools are described in the system prompt, and the tool-call blocks the
+// model writes in its answer are turned into schema.ToolCall values.
+package emulate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// maxRetries is how many times a model is asked again for a tool call it
+// wrote but that could not be parsed
+const maxRetries = 2
+
+// instructions explain the tool-call format, ahead of the tool list
+const instructions = `You can call tools to help answer. To call a tool, reply with a block like this, and nothing after it:
+
+<tool_call>
+{"name": "tool_name", "arguments": {"parameter": "value"}}
+</tool_call>
+
+Write one block per call to call several tools. The results come back in <tool_result> blocks. Only call the tools listed here, with arguments matching their parameters. When you don't need a tool, answer normally without any block.
+
+Tools:`
+
+// chatModel calls tools for a model without native function calling
+type chatModel struct {
+	model model.ToolCallingChatModel
+	tools []*schema.ToolInfo // bound with WithTools
+}
+
+// Wrap returns m with tool calls emulated through its text output
+func Wrap(m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	return &chatModel{model: m}
+}
+
+// Generate implements model.BaseChatModel. A response with a tool-call block
+// that can't be parsed is retried with the parse error, up to maxRetries
+// times; after that the response is returned as a plain answer.
+func (c *chatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	options := model.GetCommonOptions(&model.Options{Tools: c.tools}, opts...)
+	tools := options.Tools
+	forced := false
+	if options.ToolChoice != nil {
+		switch *options.ToolChoice {
+		case schema.ToolChoiceForbidden:
+			tools = nil
+		case schema.ToolChoiceForced:
+			forced = len(tools) > 0
+		}
+	}
+	innerOpts := plainOptions(options)
+	if len(tools) == 0 {
+		return c.model.Generate(ctx, flatten(input), innerOpts...)
+	}
+
+	messages := withToolPrompt(flatten(input), tools, forced)
+	for attempt := 0; ; attempt++ {
+		response, err := c.model.Generate(ctx, messages, innerOpts...)
+		if err != nil || response == nil {
+			return response, err
+		}
+		text, calls, err := Parse(response.Content, tools)
+		if err == nil && forced && len(calls) == 0 {
+			err = fmt.Errorf("a tool call is required")
+		}
+		if err == nil {
+			response.Content = text
+			response.ToolCalls = calls
+			return response, nil
+		}
+		if attempt == maxRetries {
+			return response, nil
+		}
+		messages = append(messages, schema.AssistantMessage(response.Content, nil), schema.UserMessage(
+			fmt.Sprintf("Your tool call could not be used: %v. Reply again, calling a listed tool with a <tool_call> block holding valid JSON with \"name\" and \"arguments\".", err)))
+	}
+}
+
+// Stream implements model.BaseChatModel. The response is generated whole,
+// since its tool-call blocks are only known at its end, and sent as one chunk.
+func (c *chatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := c.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+// WithTools implements model.ToolCallingChatModel
+func (c *chatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &chatModel{model: c.model, tools: tools}, nil
+}
+
+// plainOptions returns the common options without tools or tool choice, which
+// the wrapped model must not be given
+func plainOptions(options *model.Options) []model.Option {
+	var opts []model.Option
+	if options.Temperature != nil {
+		opts = append(opts, model.WithTemperature(*options.Temperature))
+	}
+	if options.MaxTokens != nil {
+		opts = append(opts, model.WithMaxTokens(*options.MaxTokens))
+	}
+	if options.Model != nil {
+		opts = append(opts, model.WithModel(*options.Model))
+	}
+	if options.TopP != nil {
+		opts = append(opts, model.WithTopP(*options.TopP))
+	}
+	if options.Stop != nil {
+		opts = append(opts, model.WithStop(options.Stop))
+	}
+	return opts
+}
+
+// withToolPrompt adds the tool instructions to the system message, or in a
+// new one when the conversation has none
+func withToolPrompt(messages []*schema.Message, tools []*schema.ToolInfo, forced bool) []*schema.Message {
+	var prompt strings.Builder
+	prompt.WriteString(instructions)
+	for _, info := range tools {
+		fmt.Fprintf(&prompt, "\n\n- %s: %s", info.Name, info.Desc)
+		if info.ParamsOneOf != nil {
+			if params, err := info.ParamsOneOf.ToJSONSchema(); err == nil && params != nil {
+				if data, err := json.Marshal(params); err == nil {
+					fmt.Fprintf(&prompt, "\n  Parameters (JSON Schema): %s", data)
+				}
+			}
+		}
+	}
+	if forced {
+		prompt.WriteString("\n\nYou must call one of these tools in your reply.")
+	}
+
+	if len(messages) > 0 && messages[0].Role == schema.System {
+		system := *messages[0]
+		system.Content = strings.TrimRight(system.Content, "\n") + "\n\n" + prompt.String()
+		return append([]*schema.Message{&system}, messages[1:]...)
+	}
+	return append([]*schema.Message{schema.SystemMessage(prompt.String())}, messages...)
+}
+
+// flatten rewrites the tool calls and results of earlier steps as text, the
+// only form the model understands. Consecutive tool results are joined into
+// one user message.
+func flatten(input []*schema.Message) []*schema.Message {
+	out := make([]*schema.Message, 0, len(input))
+	for _, msg := range input {
+		switch {
+		case msg == nil:
+			continue
+		case msg.Role == schema.Assistant && len(msg.ToolCalls) > 0:
+			var text strings.Builder
+			text.WriteString(msg.Content)
+			for _, call := range msg.ToolCalls {
+				if text.Len() > 0 {
+					text.WriteString("\n\n")
+				}
+				text.WriteString(FormatCall(call))
+			}
+			out = append(out, schema.AssistantMessage(text.String(), nil))
+		case msg.Role == schema.Tool:
+			result := fmt.Sprintf("<tool_result name=%q>\n%s\n</tool_result>", msg.ToolName, msg.Content)
+			if last := len(out) - 1; last >= 0 && out[last].Role == schema.User && strings.HasPrefix(out[last].Content, "<tool_result") {
+				out[last] = schema.UserMessage(out[last].Content + "\n\n" + result)
+				continue
+			}
+			out = append(out, schema.UserMessage(result))
+		default:
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// FormatCall writes call as the block a model would write for it
+func FormatCall(call schema.ToolCall) string {
+	arguments := json.RawMessage(call.Function.Arguments)
+	if !json.Valid(arguments) {
+		arguments = json.RawMessage("{}")
+	}
+	data, _ := json.Marshal(struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}{call.Function.Name, arguments})
+	return "<tool_call>\n" + string(data) + "\n</tool_call>"
+}
+
+// newCallID returns an ID for an emulated tool call
+func newCallID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
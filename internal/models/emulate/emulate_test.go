@@ -0,0 +1,161 @@
+package emulate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+var testTools = []*schema.ToolInfo{
+	{Name: "fs__read", Desc: "Read a file", ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"path": {Type: schema.String, Required: true},
+	})},
+	{Name: "clock__now", Desc: "The current time"},
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name, text, wantText, wantErr string
+		wantCalls                     []string // name and arguments of each call
+	}{
+		{
+			name:      "JSON block",
+			text:      "Let me look.\n<tool_call>\n{\"name\": \"fs__read\", \"arguments\": {\"path\": \"a.txt\"}}\n</tool_call>",
+			wantText:  "Let me look.",
+			wantCalls: []string{`fs__read {"path": "a.txt"}`},
+		},
+		{
+			name:      "several blocks, arguments as a string, no arguments",
+			text:      "<tool_call>{\"name\": \"fs__read\", \"arguments\": \"{\\\"path\\\": \\\"b\\\"}\"}</tool_call>\n<tool_call>{\"name\": \"clock__now\"}</tool_call>",
+			wantCalls: []string{`fs__read {"path": "b"}`, `clock__now {}`},
+		},
+		{
+			name:      "XML block",
+			text:      "<tool_call><name>fs__read</name><arguments><path>c.txt</path></arguments></tool_call>",
+			wantCalls: []string{`fs__read {"path":"c.txt"}`},
+		},
+		{
+			name:      "unclosed block",
+			text:      "<tool_call>{\"name\": \"clock__now\", \"arguments\": {}}",
+			wantCalls: []string{`clock__now {}`},
+		},
+		{
+			name:      "fenced JSON naming a tool",
+			text:      "```json\n{\"name\": \"clock__now\", \"parameters\": {}}\n```",
+			wantCalls: []string{`clock__now {}`},
+		},
+		{
+			name:     "fenced JSON that is part of the answer",
+			text:     "Here:\n```json\n{\"name\": \"Ada\"}\n```",
+			wantText: "Here:\n```json\n{\"name\": \"Ada\"}\n```",
+		},
+		{name: "invalid JSON", text: "<tool_call>{\"name\": \"fs__read\", </tool_call>", wantErr: "invalid JSON"},
+		{name: "unknown tool", text: "<tool_call>{\"name\": \"rm\"}</tool_call>", wantErr: `no tool named "rm"`},
+		{name: "arguments not an object", text: "<tool_call>{\"name\": \"fs__read\", \"arguments\": [1]}</tool_call>", wantErr: "not a JSON object"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			text, calls, err := Parse(tc.text, testTools)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error mentioning %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != tc.wantText {
+				t.Errorf("expected text %q, got %q", tc.wantText, text)
+			}
+			var got []string
+			for _, call := range calls {
+				if !strings.HasPrefix(call.ID, "call_") {
+					t.Errorf("expected a call ID, got %q", call.ID)
+				}
+				got = append(got, call.Function.Name+" "+call.Function.Arguments)
+			}
+			if strings.Join(got, "|") != strings.Join(tc.wantCalls, "|") {
+				t.Errorf("expected calls %v, got %v", tc.wantCalls, got)
+			}
+		})
+	}
+}
+
+// scriptedModel answers with its replies in turn and records the calls
+type scriptedModel struct {
+	replies []string
+	calls   [][]*schema.Message
+	tools   int // tools offered natively in the last call
+}
+
+func (m *scriptedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls = append(m.calls, input)
+	m.tools = len(model.GetCommonOptions(nil, opts...).Tools)
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return schema.AssistantMessage(reply, nil), nil
+}
+
+func (m *scriptedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), err
+}
+
+func (m *scriptedModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestGenerate(t *testing.T) {
+	inner := &scriptedModel{replies: []string{
+		"<tool_call>{\"name\": \"fs__reed\"}</tool_call>",
+		"<tool_call>{\"name\": \"fs__read\", \"arguments\": {\"path\": \"a.txt\"}}</tool_call>",
+	}}
+	history := []*schema.Message{
+		schema.SystemMessage("Be brief."),
+		schema.UserMessage("What time is it?"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "clock__now", Arguments: "{}"}}}),
+		schema.ToolMessage("noon", "1", schema.WithToolName("clock__now")),
+		schema.UserMessage("Now read a.txt"),
+	}
+
+	response, err := Wrap(inner).Generate(context.Background(), history, model.WithTools(testTools), model.WithTemperature(0.2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.ToolCalls) != 1 || response.ToolCalls[0].Function.Name != "fs__read" || response.Content != "" {
+		t.Fatalf("expected the retried call, got %+v", response)
+	}
+	if len(inner.calls) != 2 || inner.tools != 0 {
+		t.Fatalf("expected 2 calls without native tools, got %d with %d tools", len(inner.calls), inner.tools)
+	}
+
+	sent := inner.calls[0]
+	if len(sent) != len(history) || !strings.HasPrefix(sent[0].Content, "Be brief.") || !strings.Contains(sent[0].Content, "- fs__read: Read a file") {
+		t.Errorf("expected the tools described in the system prompt, got %q", sent[0].Content)
+	}
+	if sent[2].Role != schema.Assistant || !strings.Contains(sent[2].Content, `<tool_call>`) || len(sent[2].ToolCalls) != 0 {
+		t.Errorf("expected the earlier call written as text, got %+v", sent[2])
+	}
+	if sent[3].Role != schema.User || !strings.Contains(sent[3].Content, "<tool_result name=\"clock__now\">\nnoon") {
+		t.Errorf("expected the tool result written as text, got %+v", sent[3])
+	}
+	if retry := inner.calls[1]; !strings.Contains(retry[len(retry)-1].Content, `no tool named "fs__reed"`) {
+		t.Errorf("expected the retry to say what was wrong, got %q", retry[len(retry)-1].Content)
+	}
+	if history[0].Content != "Be brief." || len(history[2].ToolCalls) != 1 {
+		t.Error("expected the caller's history left alone")
+	}
+
+	// Without tools, or with them forbidden, the model is called as it is
+	inner = &scriptedModel{replies: []string{"Hello", "Hi"}}
+	if response, err := Wrap(inner).Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")}); err != nil || response.Content != "Hello" || len(inner.calls[0]) != 1 {
+		t.Errorf("expected a plain call, got %+v, %v", response, err)
+	}
+	bound, _ := Wrap(inner).WithTools(testTools)
+	if _, err := bound.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")}, model.WithToolChoice(schema.ToolChoiceForbidden)); err != nil || len(inner.calls[1]) != 1 {
+		t.Errorf("expected no tool prompt with tools forbidden, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package emulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+var (
+	// callBlock matches a <tool_call> block, or an unclosed one at the end
+	// of a response cut short
+	callBlock = regexp.MustCompile(`(?s)<tool_call>(.*?)(?:</tool_call>|\z)`)
+	// fencedBlock matches a fenced code block, which some models use instead
+	fencedBlock = regexp.MustCompile("(?s)```(?:json)?\\s*\\n(.*?)```")
+	// openTag matches the opening tag of an element without attributes
+	openTag = regexp.MustCompile(`<([A-Za-z_][\w.-]*)>`)
+)
+
+// Parse finds the tool calls of tools written in text, as <tool_call> blocks
+// holding JSON ({"name": ..., "arguments": {...}}) or XML (<name> and
+// <arguments> elements), or as fenced JSON naming a listed tool. It returns
+// the text around the calls and the calls; the error says why a call that
+// was written could not be used.
+func Parse(text string, tools []*schema.ToolInfo) (string, []schema.ToolCall, error) {
+	known := make(map[string]bool, len(tools))
+	for _, info := range tools {
+		known[info.Name] = true
+	}
+
+	if matches := callBlock.FindAllStringSubmatchIndex(text, -1); len(matches) > 0 {
+		var calls []schema.ToolCall
+		for _, m := range matches {
+			call, err := parseCall(text[m[2]:m[3]], known)
+			if err != nil {
+				return text, nil, err
+			}
+			calls = append(calls, call)
+		}
+		return strings.TrimSpace(callBlock.ReplaceAllString(text, "")), calls, nil
+	}
+
+	// Fenced JSON is only a call when it names a listed tool; otherwise it is
+	// part of the answer
+	var calls []schema.ToolCall
+	rest := fencedBlock.ReplaceAllStringFunc(text, func(block string) string {
+		body := fencedBlock.FindStringSubmatch(block)[1]
+		var named struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal([]byte(strings.TrimSpace(body)), &named) != nil || !known[named.Name] {
+			return block
+		}
+		call, err := parseCall(body, known)
+		if err != nil {
+			return block
+		}
+		calls = append(calls, call)
+		return ""
+	})
+	if len(calls) == 0 {
+		return text, nil, nil
+	}
+	return strings.TrimSpace(rest), calls, nil
+}
+
+// parseCall parses the body of one tool-call block
+func parseCall(body string, known map[string]bool) (schema.ToolCall, error) {
+	body = strings.TrimSpace(body)
+	var name string
+	var arguments json.RawMessage
+	if strings.HasPrefix(body, "{") {
+		var call struct {
+			Name       string          `json:"name"`
+			Arguments  json.RawMessage `json:"arguments"`
+			Parameters json.RawMessage `json:"parameters"`
+		}
+		if err := json.Unmarshal([]byte(body), &call); err != nil {
+			return schema.ToolCall{}, fmt.Errorf("invalid JSON in the tool call: %v", err)
+		}
+		name, arguments = call.Name, call.Arguments
+		if arguments == nil {
+			arguments = call.Parameters
+		}
+		// Some models send the arguments as a JSON string
+		var encoded string
+		if json.Unmarshal(arguments, &encoded) == nil {
+			arguments = json.RawMessage(encoded)
+		}
+	} else {
+		fields := xmlFields(body)
+		name = strings.TrimSpace(fields["name"])
+		arguments = xmlArguments(fields["arguments"])
+	}
+
+	switch {
+	case name == "":
+		return schema.ToolCall{}, fmt.Errorf("the tool call has no name")
+	case !known[name]:
+		return schema.ToolCall{}, fmt.Errorf("there is no tool named %q", name)
+	}
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(arguments, &object); err != nil {
+		return schema.ToolCall{}, fmt.Errorf("the arguments of %s are not a JSON object", name)
+	}
+	return schema.ToolCall{
+		ID:       newCallID(),
+		Type:     "function",
+		Function: schema.FunctionCall{Name: name, Arguments: string(arguments)},
+	}, nil
+}
+
+// xmlFields returns the elements directly in body, by tag
+func xmlFields(body string) map[string]string {
+	fields := make(map[string]string)
+	for {
+		m := openTag.FindStringSubmatchIndex(body)
+		if m == nil {
+			return fields
+		}
+		tag := body[m[2]:m[3]]
+		end := strings.Index(body[m[1]:], "</"+tag+">")
+		if end < 0 {
+			return fields
+		}
+		fields[tag] = body[m[1] : m[1]+end]
+		body = body[m[1]+end+len(tag)+3:]
+	}
+}
+
+// xmlArguments reads the arguments element of an XML call: JSON, or one
+// element per argument
+func xmlArguments(body string) json.RawMessage {
+	body = strings.TrimSpace(body)
+	if body == "" || json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	arguments := make(map[string]interface{})
+	for key, value := range xmlFields(body) {
+		value = strings.TrimSpace(value)
+		var decoded interface{}
+		if json.Unmarshal([]byte(value), &decoded) == nil {
+			arguments[key] = decoded
+		} else {
+			arguments[key] = value
+		}
+	}
+	data, _ := json.Marshal(arguments)
+	return data
+}
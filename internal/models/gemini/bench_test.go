@@ -0,0 +1,34 @@
+package gemini
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// BenchmarkConvertSchemaMessages converts histories of growing length to
+// Gemini contents, which happens on every model call
+func BenchmarkConvertSchemaMessages(b *testing.B) {
+	cm := &ChatModel{}
+	for _, turns := range []int{10, 100, 1000} {
+		messages := []*schema.Message{schema.SystemMessage("be brief")}
+		for i := 0; i < turns; i++ {
+			id := fmt.Sprintf("call-%d", i)
+			messages = append(messages,
+				schema.UserMessage(fmt.Sprintf("Question %d about the repository layout", i)),
+				schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}}}),
+				schema.ToolMessage("module example.com/demo\n\ngo 1.24\n", id),
+				schema.AssistantMessage(fmt.Sprintf("Answer %d: it is a Go module.", i), nil),
+			)
+		}
+		b.Run(fmt.Sprintf("history=%d", turns*4), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := cm.convertSchemaMessages(messages); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -2,16 +2,20 @@ package gemini
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"runtime/debug"
+	"strings"
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/osi4iot/mcphost/internal/models/usage"
 	"google.golang.org/genai"
 )
 
@@ -135,10 +139,11 @@ func (cm *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts
 		return nil, fmt.Errorf("gemini input is empty")
 	}
 
-	contents, err := cm.convertSchemaMessages(input)
+	systemInstruction, contents, err := cm.convertSchemaMessages(input)
 	if err != nil {
 		return nil, err
 	}
+	config.SystemInstruction = systemInstruction
 
 	result, err := cm.cli.Models.GenerateContent(ctx, cm.model, contents, config)
 	if err != nil {
@@ -177,10 +182,11 @@ func (cm *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts .
 		return nil, fmt.Errorf("gemini input is empty")
 	}
 
-	contents, err := cm.convertSchemaMessages(input)
+	systemInstruction, contents, err := cm.convertSchemaMessages(input)
 	if err != nil {
 		return nil, err
 	}
+	config.SystemInstruction = systemInstruction
 
 	sr, sw := schema.Pipe[*model.CallbackOutput](1)
 	go func() {
@@ -192,13 +198,14 @@ func (cm *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts .
 			sw.Close()
 		}()
 
+		var calls functionCallMerger
 		for resp, err := range cm.cli.Models.GenerateContentStream(ctx, cm.model, contents, config) {
 			if err != nil {
 				sw.Send(nil, err)
 				return
 			}
 
-			message, err := cm.convertResponse(resp)
+			message, err := cm.convertStreamChunk(resp, &calls)
 			if err != nil {
 				sw.Send(nil, err)
 				return
@@ -209,6 +216,17 @@ func (cm *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts .
 				return
 			}
 		}
+
+		// Function calls are sent once the stream ends, each whole, so that
+		// concatenating the chunks gives every call exactly once
+		if len(calls.calls) > 0 {
+			toolCalls, err := convertFunctionCalls(calls.calls)
+			if err != nil {
+				sw.Send(nil, err)
+				return
+			}
+			sw.Send(cm.convertCallbackOutput(&schema.Message{Role: schema.Assistant, ToolCalls: toolCalls}, conf), nil)
+		}
 	}()
 
 	srList := sr.Copy(2)
@@ -476,21 +494,61 @@ func (cm *ChatModel) convertOpenAPISchema(schema *openapi3.Schema) (*genai.Schem
 	return result, nil
 }
 
-func (cm *ChatModel) convertSchemaMessages(messages []*schema.Message) ([]*genai.Content, error) {
+// convertSchemaMessages converts the conversation into Gemini contents. System
+// messages are not part of the contents: their text becomes the request's
+// system instruction, in order, instead of being sent as user turns.
+func (cm *ChatModel) convertSchemaMessages(messages []*schema.Message) (*genai.Content, []*genai.Content, error) {
+	// Gemini matches function responses to calls by name, so each tool
+	// result is sent under the name of the call it answers
+	toolNames := make(map[string]string)
+	for _, message := range messages {
+		for _, call := range message.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+	}
+
+	var systemInstruction *genai.Content
 	var contents []*genai.Content
 	for _, message := range messages {
-		content, err := cm.convertSchemaMessage(message)
+		if message != nil && message.Role == schema.System {
+			parts := systemParts(message)
+			if len(parts) == 0 {
+				continue
+			}
+			if systemInstruction == nil {
+				systemInstruction = &genai.Content{}
+			}
+			systemInstruction.Parts = append(systemInstruction.Parts, parts...)
+			continue
+		}
+
+		content, err := cm.convertSchemaMessage(message, toolNames)
 		if err != nil {
-			return nil, fmt.Errorf("convert schema message failed: %w", err)
+			return nil, nil, fmt.Errorf("convert schema message failed: %w", err)
 		}
 		if content != nil {
 			contents = append(contents, content)
 		}
 	}
-	return contents, nil
+	return systemInstruction, contents, nil
 }
 
-func (cm *ChatModel) convertSchemaMessage(message *schema.Message) (*genai.Content, error) {
+// systemParts returns the text of a system message as parts of the system
+// instruction, which only takes text
+func systemParts(message *schema.Message) []*genai.Part {
+	var parts []*genai.Part
+	if message.Content != "" {
+		parts = append(parts, &genai.Part{Text: message.Content})
+	}
+	for _, content := range message.MultiContent {
+		if content.Type == schema.ChatMessagePartTypeText && content.Text != "" {
+			parts = append(parts, &genai.Part{Text: content.Text})
+		}
+	}
+	return parts
+}
+
+func (cm *ChatModel) convertSchemaMessage(message *schema.Message, toolNames map[string]string) (*genai.Content, error) {
 	if message == nil {
 		return nil, nil
 	}
@@ -522,9 +580,14 @@ func (cm *ChatModel) convertSchemaMessage(message *schema.Message) (*genai.Conte
 				"error": message.Content,
 			}
 		}
+		// Older sessions used the function name as the tool call ID
+		name, ok := toolNames[message.ToolCallID]
+		if !ok {
+			name = message.ToolCallID
+		}
 		parts = append(parts, &genai.Part{
 			FunctionResponse: &genai.FunctionResponse{
-				Name:     message.ToolCallID,
+				Name:     name,
 				Response: response,
 			},
 		})
@@ -541,6 +604,11 @@ func (cm *ChatModel) convertSchemaMessage(message *schema.Message) (*genai.Conte
 				parts = append(parts, &genai.Part{Text: content.Text})
 			case schema.ChatMessagePartTypeImageURL:
 				if content.ImageURL != nil {
+					// Inline data URLs, e.g. images returned by tools or attached, are sent as bytes
+					if blob, ok := dataURLBlob(content.ImageURL.URL); ok {
+						parts = append(parts, &genai.Part{InlineData: blob})
+						continue
+					}
 					parts = append(parts, &genai.Part{
 						FileData: &genai.FileData{
 							MIMEType: content.ImageURL.MIMEType,
@@ -550,6 +618,10 @@ func (cm *ChatModel) convertSchemaMessage(message *schema.Message) (*genai.Conte
 				}
 			case schema.ChatMessagePartTypeAudioURL:
 				if content.AudioURL != nil {
+					if blob, ok := dataURLBlob(content.AudioURL.URL); ok {
+						parts = append(parts, &genai.Part{InlineData: blob})
+						continue
+					}
 					parts = append(parts, &genai.Part{
 						FileData: &genai.FileData{
 							MIMEType: content.AudioURL.MIMEType,
@@ -589,6 +661,27 @@ func (cm *ChatModel) convertSchemaMessage(message *schema.Message) (*genai.Conte
 	}, nil
 }
 
+// dataURLBlob decodes a base64 data URL ("data:image/png;base64,...")
+func dataURLBlob(url string) (*genai.Blob, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return nil, false
+	}
+	meta, encoded, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, false
+	}
+	mimeType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !isBase64 {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	return &genai.Blob{MIMEType: mimeType, Data: data}, true
+}
+
 func (cm *ChatModel) convertRole(role schema.RoleType) genai.Role {
 	switch role {
 	case schema.Assistant:
@@ -603,8 +696,51 @@ func (cm *ChatModel) convertRole(role schema.RoleType) genai.Role {
 }
 
 func (cm *ChatModel) convertResponse(resp *genai.GenerateContentResponse) (*schema.Message, error) {
+	message, textParts, calls, err := cm.convertCandidate(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set content
+	if len(textParts) == 1 {
+		message.Content = textParts[0]
+	} else if len(textParts) > 1 {
+		for _, text := range textParts {
+			message.MultiContent = append(message.MultiContent, schema.ChatMessagePart{
+				Type: schema.ChatMessagePartTypeText,
+				Text: text,
+			})
+		}
+	}
+
+	message.ToolCalls, err = convertFunctionCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// convertStreamChunk converts one chunk of a streamed response. Its text parts
+// are joined into Content, which is what streamed chunks are concatenated by,
+// and its function calls are left to calls until the stream ends.
+func (cm *ChatModel) convertStreamChunk(resp *genai.GenerateContentResponse, calls *functionCallMerger) (*schema.Message, error) {
+	message, textParts, chunkCalls, err := cm.convertCandidate(resp)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = strings.Join(textParts, "")
+	for _, call := range chunkCalls {
+		calls.add(call)
+	}
+	return message, nil
+}
+
+// convertCandidate converts the first candidate of a response into a message
+// with its finish reason and usage, and returns its text and function call
+// parts for the caller to add
+func (cm *ChatModel) convertCandidate(resp *genai.GenerateContentResponse) (*schema.Message, []string, []*genai.FunctionCall, error) {
 	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("gemini result is empty")
+		return nil, nil, nil, fmt.Errorf("gemini result is empty")
 	}
 
 	candidate := resp.Candidates[0]
@@ -615,53 +751,101 @@ func (cm *ChatModel) convertResponse(resp *genai.GenerateContentResponse) (*sche
 		},
 	}
 
-	// Handle usage metadata
-	if resp.UsageMetadata != nil {
-		message.ResponseMeta.Usage = &schema.TokenUsage{
-			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
-			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
-			TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
-		}
+	// Handle usage metadata. Thinking tokens are billed as output but are not
+	// part of the candidates count.
+	if md := resp.UsageMetadata; md != nil {
+		usage.Report{
+			InputTokens:     int(md.PromptTokenCount),
+			OutputTokens:    int(md.CandidatesTokenCount + md.ThoughtsTokenCount),
+			CacheReadTokens: int(md.CachedContentTokenCount),
+			ReasoningTokens: int(md.ThoughtsTokenCount),
+		}.Apply(message)
+	}
+
+	// The last chunk of a stream may carry only the finish reason and usage
+	if candidate.Content == nil {
+		return message, nil, nil, nil
 	}
 
 	// Process content parts
 	var textParts []string
+	var calls []*genai.FunctionCall
 	for _, part := range candidate.Content.Parts {
 		switch {
 		case part.Text != "":
 			textParts = append(textParts, part.Text)
 		case part.FunctionCall != nil:
-			args, err := json.Marshal(part.FunctionCall.Args)
-			if err != nil {
-				return nil, fmt.Errorf("marshal function call arguments failed: %w", err)
-			}
-			message.ToolCalls = append(message.ToolCalls, schema.ToolCall{
-				ID: part.FunctionCall.Name,
-				Function: schema.FunctionCall{
-					Name:      part.FunctionCall.Name,
-					Arguments: string(args),
-				},
-			})
+			calls = append(calls, part.FunctionCall)
 		case part.ExecutableCode != nil:
 			textParts = append(textParts, part.ExecutableCode.Code)
 		case part.CodeExecutionResult != nil:
 			textParts = append(textParts, part.CodeExecutionResult.Output)
 		}
 	}
+	return message, textParts, calls, nil
+}
 
-	// Set content
-	if len(textParts) == 1 {
-		message.Content = textParts[0]
-	} else if len(textParts) > 1 {
-		for _, text := range textParts {
-			message.MultiContent = append(message.MultiContent, schema.ChatMessagePart{
-				Type: schema.ChatMessagePartTypeText,
-				Text: text,
-			})
+// convertFunctionCalls converts a response's function calls into tool calls,
+// indexed in order. Gemini identifies calls by function name only, so a call
+// without an ID of its own gets one from its name and index, which keeps two
+// calls of the same function apart.
+func convertFunctionCalls(calls []*genai.FunctionCall) ([]schema.ToolCall, error) {
+	var toolCalls []schema.ToolCall
+	for i, call := range calls {
+		args := []byte("{}")
+		if call.Args != nil {
+			var err error
+			if args, err = json.Marshal(call.Args); err != nil {
+				return nil, fmt.Errorf("marshal function call arguments failed: %w", err)
+			}
 		}
+		id := call.ID
+		if id == "" {
+			id = fmt.Sprintf("%s_%d", call.Name, i)
+		}
+		index := i
+		toolCalls = append(toolCalls, schema.ToolCall{
+			Index: &index,
+			ID:    id,
+			Function: schema.FunctionCall{
+				Name:      call.Name,
+				Arguments: string(args),
+			},
+		})
 	}
+	return toolCalls, nil
+}
 
-	return message, nil
+// functionCallMerger collects the function calls of a streamed response.
+// Gemini usually sends each call whole in one chunk, but a later chunk can
+// continue a call, repeating its ID or leaving out the name; the arguments
+// of a continuation are merged into the call's.
+type functionCallMerger struct {
+	calls []*genai.FunctionCall
+}
+
+func (m *functionCallMerger) add(call *genai.FunctionCall) {
+	var continued *genai.FunctionCall
+	for _, existing := range m.calls {
+		if call.ID != "" && existing.ID == call.ID {
+			continued = existing
+		}
+	}
+	if continued == nil && call.Name == "" && len(m.calls) > 0 {
+		continued = m.calls[len(m.calls)-1]
+	}
+
+	if continued == nil {
+		m.calls = append(m.calls, &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: maps.Clone(call.Args)})
+		return
+	}
+	if continued.Name == "" {
+		continued.Name = call.Name
+	}
+	if continued.Args == nil && call.Args != nil {
+		continued.Args = make(map[string]any, len(call.Args))
+	}
+	maps.Copy(continued.Args, call.Args)
 }
 
 func (cm *ChatModel) convertCallbackOutput(message *schema.Message, conf *model.Config) *model.CallbackOutput {
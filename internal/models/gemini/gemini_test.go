@@ -0,0 +1,211 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"google.golang.org/genai"
+)
+
+// streamRecorded streams a response recorded from the Gemini API in
+// testdata/<name>.sse and returns its chunks
+func streamRecorded(t *testing.T, name string) []*schema.Message {
+	t.Helper()
+	recording, err := os.ReadFile("testdata/" + name + ".sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write(recording)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, err := NewChatModel(ctx, &Config{Client: client, Model: "gemini-2.5-flash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := cm.Stream(ctx, []*schema.Message{schema.UserMessage("go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var chunks []*schema.Message
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestStreamParallelCalls(t *testing.T) {
+	chunks := streamRecorded(t, "parallel_calls")
+	message, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Content != "Let me read both files." {
+		t.Errorf("unexpected content %q", message.Content)
+	}
+	if message.ResponseMeta.FinishReason != "STOP" || message.ResponseMeta.Usage.TotalTokens != 150 {
+		t.Errorf("unexpected response meta %+v", message.ResponseMeta)
+	}
+
+	// Two calls of the same function stay apart, in order
+	if len(message.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", message.ToolCalls)
+	}
+	for i, want := range []string{`{"path":"go.mod"}`, `{"path":"go.sum"}`} {
+		call := message.ToolCalls[i]
+		if call.Function.Name != "fs__read_file" || call.Function.Arguments != want || *call.Index != i {
+			t.Errorf("unexpected tool call %d: %+v", i, call)
+		}
+	}
+	if message.ToolCalls[0].ID == message.ToolCalls[1].ID {
+		t.Errorf("expected distinct tool call IDs, got %q twice", message.ToolCalls[0].ID)
+	}
+
+	// Calls arrive whole, after the text, so none is seen half-done
+	for _, chunk := range chunks[:len(chunks)-1] {
+		if len(chunk.ToolCalls) > 0 {
+			t.Errorf("expected tool calls only in the last chunk, got %+v", chunk.ToolCalls)
+		}
+	}
+}
+
+func TestStreamContinuedCall(t *testing.T) {
+	message, err := schema.ConcatMessages(streamRecorded(t, "continued_call"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Content != "Searching the issues." {
+		t.Errorf("expected the chunk's text parts joined, got %q", message.Content)
+	}
+	if len(message.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", message.ToolCalls)
+	}
+	search := message.ToolCalls[0]
+	if search.ID != "call-7" || search.Function.Name != "tracker__search" || search.Function.Arguments != `{"limit":5,"query":"login timeout"}` {
+		t.Errorf("expected the continued call's arguments merged, got %+v", search)
+	}
+	clock := message.ToolCalls[1]
+	if clock.ID != "utils__current_time_1" || clock.Function.Arguments != "{}" {
+		t.Errorf("unexpected call without arguments %+v", clock)
+	}
+}
+
+func TestConvertToolResponseName(t *testing.T) {
+	cm := &ChatModel{}
+	_, contents, err := cm.convertSchemaMessages([]*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "fs__read_file_0", Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path":"go.mod"}`}},
+		}),
+		schema.ToolMessage(`{"content":"module example"}`, "fs__read_file_0"),
+		// Sessions saved before calls had their own IDs
+		schema.ToolMessage("done", "utils__current_time"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := contents[1].Parts[0].FunctionResponse.Name; name != "fs__read_file" {
+		t.Errorf("expected the response named after its call's function, got %q", name)
+	}
+	if name := contents[2].Parts[0].FunctionResponse.Name; name != "utils__current_time" {
+		t.Errorf("expected the ID as the name for an unknown call, got %q", name)
+	}
+}
+
+func TestConvertSystemMessages(t *testing.T) {
+	cm := &ChatModel{}
+	systemInstruction, contents, err := cm.convertSchemaMessages([]*schema.Message{
+		schema.SystemMessage("You are a careful assistant."),
+		schema.UserMessage("list the files"),
+		schema.AssistantMessage("There are two.", nil),
+		{Role: schema.System, MultiContent: []schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: "## Scratchpad\n\n1. Plan"}}},
+		schema.UserMessage("and now?"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if systemInstruction == nil || len(systemInstruction.Parts) != 2 ||
+		systemInstruction.Parts[0].Text != "You are a careful assistant." || systemInstruction.Parts[1].Text != "## Scratchpad\n\n1. Plan" {
+		t.Fatalf("expected both system messages in the system instruction, got %+v", systemInstruction)
+	}
+
+	// The system prompt does not leak into the user turns
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 contents, got %d", len(contents))
+	}
+	for _, content := range contents {
+		for _, part := range content.Parts {
+			if strings.Contains(part.Text, "careful assistant") || strings.Contains(part.Text, "Scratchpad") {
+				t.Errorf("system text sent as a %s turn: %q", content.Role, part.Text)
+			}
+		}
+	}
+	if contents[0].Role != genai.RoleUser || contents[0].Parts[0].Text != "list the files" {
+		t.Errorf("expected the conversation to start with the user's turn, got %+v", contents[0])
+	}
+
+	if systemInstruction, _, _ := cm.convertSchemaMessages([]*schema.Message{schema.UserMessage("hi")}); systemInstruction != nil {
+		t.Errorf("expected no system instruction without system messages, got %+v", systemInstruction)
+	}
+}
+
+func TestGenerateSendsSystemInstruction(t *testing.T) {
+	var request struct {
+		SystemInstruction *genai.Content  `json:"systemInstruction"`
+		Contents          []genai.Content `json:"contents"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "Hello."}],"role": "model"},"finishReason": "STOP"}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: "test", Backend: genai.BackendGeminiAPI, HTTPOptions: genai.HTTPOptions{BaseURL: server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, _ := NewChatModel(ctx, &Config{Client: client, Model: "gemini-2.5-flash"})
+	if _, err := cm.Generate(ctx, []*schema.Message{schema.SystemMessage("Answer in French."), schema.UserMessage("hi")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if request.SystemInstruction == nil || request.SystemInstruction.Parts[0].Text != "Answer in French." {
+		t.Errorf("expected the system prompt as the system instruction, got %+v", request.SystemInstruction)
+	}
+	if len(request.Contents) != 1 || request.Contents[0].Parts[0].Text != "hi" {
+		t.Errorf("expected only the user's turn in the contents, got %+v", request.Contents)
+	}
+}
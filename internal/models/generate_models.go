@@ -46,6 +46,25 @@ type ProviderInfo struct {
 	Models map[string]ModelInfo `json:"models"`
 }
 
+// xaiModels are xAI models with their prices and limits from xAI's docs
+var xaiModels = map[string]ModelInfo{
+	"grok-4-fast-reasoning": {
+		ID: "grok-4-fast-reasoning", Name: "Grok 4 Fast (Reasoning)", Attachment: true, Reasoning: true, Temperature: true,
+		Cost:  Cost{Input: 0.2, Output: 0.5, CacheRead: &[]float64{0.05}[0]},
+		Limit: Limit{Context: 2000000, Output: 30000},
+	},
+	"grok-4-fast-non-reasoning": {
+		ID: "grok-4-fast-non-reasoning", Name: "Grok 4 Fast (Non-Reasoning)", Attachment: true, Reasoning: false, Temperature: true,
+		Cost:  Cost{Input: 0.2, Output: 0.5, CacheRead: &[]float64{0.05}[0]},
+		Limit: Limit{Context: 2000000, Output: 30000},
+	},
+	"grok-code-fast-1": {
+		ID: "grok-code-fast-1", Name: "Grok Code Fast 1", Attachment: false, Reasoning: true, Temperature: true,
+		Cost:  Cost{Input: 0.2, Output: 1.5, CacheRead: &[]float64{0.02}[0]},
+		Limit: Limit{Context: 256000, Output: 10000},
+	},
+}
+
 const codeTemplate = `// Code generated by go generate; DO NOT EDIT.
 // Generated at: {{.Timestamp}}
 
@@ -164,6 +183,15 @@ func main() {
 		providers["google"] = googleProvider
 	}
 
+	// Add the xAI models models.dev does not list yet
+	if xaiProvider, exists := providers["xai"]; exists {
+		for id, info := range xaiModels {
+			if _, listed := xaiProvider.Models[id]; !listed {
+				xaiProvider.Models[id] = info
+			}
+		}
+	}
+
 	// Generate Go code
 	tmpl, err := template.New("models").Parse(codeTemplate)
 	if err != nil {
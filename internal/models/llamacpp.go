@@ -0,0 +1,369 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	einoopenai "github.com/cloudwego/eino-ext/components/model/openai"
+
+	"github.com/osi4iot/mcphost/internal/models/openai"
+)
+
+// LlamaCppProvider is the provider of GGUF model files run by llama.cpp's
+// llama-server, which mcphost starts itself, so local models need no Ollama
+// daemon. The model name is the path to the file.
+const LlamaCppProvider = "llamacpp"
+
+// llamaServerEnv names the llama-server binary to use instead of the one on
+// the PATH
+const llamaServerEnv = "LLAMA_SERVER"
+
+// llamaAPIKeyEnv is the variable llama-server reads its API key from
+const llamaAPIKeyEnv = "LLAMA_ARG_API_KEY"
+
+// llamaServerStartTimeout bounds starting llama-server and loading the model,
+// which for large files on slow disks takes a while
+const llamaServerStartTimeout = 5 * time.Minute
+
+// llamaServerStderrLimit is how much of llama-server's output is kept to
+// explain why it failed to start
+const llamaServerStderrLimit = 4096
+
+// IsLocal reports whether provider runs models on the user's own machines:
+// Ollama, or GGUF files run by llama.cpp. Their requests are not scrubbed.
+func IsLocal(provider string) bool {
+	return provider == "ollama" || provider == LlamaCppProvider
+}
+
+// llamaServer is a llama-server process serving one model file
+type llamaServer struct {
+	cmd    *exec.Cmd
+	key    string // its entry in llamaServers
+	url    string // base URL of its OpenAI-compatible API
+	apiKey string // random, so other local users can't use it
+	stderr *tailBuffer
+	exited chan struct{} // closed when the process exits
+	err    error         // why it exited, set before exited is closed
+	users  int           // providers using it, guarded by llamaServersMu
+
+	ready    chan struct{} // closed once the model is loaded or failed to load
+	readyErr error         // why it failed to load, set before ready is closed
+}
+
+var (
+	llamaServersMu sync.Mutex
+	llamaServers   = map[string]*llamaServer{} // running servers, by model file and options
+)
+
+// createLlamaCppProvider creates a model on a llama-server running the GGUF
+// file modelName. With --provider-url the server is one already running;
+// otherwise one is started for the file, or the one started before for the
+// same file and options is used. The result's Release stops the server once
+// no provider uses it.
+func createLlamaCppProvider(ctx context.Context, config *ProviderConfig, modelName string) (*ProviderResult, error) {
+	baseURL := strings.TrimRight(config.ProviderURL, "/")
+	apiKey := config.ProviderAPIKey
+	message := ""
+	var release func()
+	if baseURL == "" {
+		path, err := llamaCppModelPath(modelName)
+		if err != nil {
+			return nil, err
+		}
+		server, started, err := startLlamaServer(ctx, path, llamaServerArgs(path, config))
+		if err != nil {
+			return nil, err
+		}
+		baseURL, apiKey = server.url, server.apiKey
+		release = sync.OnceFunc(server.release)
+		if started {
+			message = fmt.Sprintf("Started llama-server for %s", filepath.Base(path))
+		}
+	}
+
+	llamaConfig := &einoopenai.ChatModelConfig{
+		APIKey:     apiKey,
+		Model:      filepath.Base(modelName),
+		BaseURL:    baseURL + "/v1",
+		HTTPClient: createHTTPClientWithTLSConfig(config),
+	}
+
+	if config.MaxTokens > 0 {
+		llamaConfig.MaxTokens = &config.MaxTokens
+	}
+
+	if config.Temperature != nil {
+		llamaConfig.Temperature = config.Temperature
+	}
+
+	if config.TopP != nil {
+		llamaConfig.TopP = config.TopP
+	}
+
+	if config.TopK != nil {
+		llamaConfig.ExtraFields = map[string]any{"top_k": *config.TopK}
+	}
+
+	if len(config.StopSequences) > 0 {
+		llamaConfig.Stop = config.StopSequences
+	}
+
+	chatModel, err := openai.NewCustomChatModel(ctx, llamaConfig, config.ParallelToolCalls)
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		return nil, err
+	}
+	return &ProviderResult{Model: chatModel, Message: message, Release: release}, nil
+}
+
+// llamaCppModelPath resolves the path of a GGUF file given as a model name,
+// expanding a leading ~
+func llamaCppModelPath(modelName string) (string, error) {
+	path := modelName
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("GGUF model file not found: %s", path)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory, not a GGUF model file", path)
+	}
+	return path, nil
+}
+
+// llamaServerArgs returns the llama-server arguments for the model file at
+// path, with the context size, threads and GPU layers of config. The host,
+// port and API key are added when the server starts.
+func llamaServerArgs(path string, config *ProviderConfig) []string {
+	// --jinja applies the model's chat template, which tool calls need
+	args := []string{"--model", path, "--jinja"}
+	if config.NumCtx > 0 {
+		args = append(args, "--ctx-size", strconv.Itoa(config.NumCtx))
+	}
+	if config.NumThread > 0 {
+		args = append(args, "--threads", strconv.Itoa(config.NumThread))
+	}
+	if config.NumGPU != nil && *config.NumGPU >= 0 {
+		args = append(args, "--n-gpu-layers", strconv.Itoa(int(*config.NumGPU)))
+	}
+	if config.MainGPU != nil && *config.MainGPU > 0 {
+		args = append(args, "--main-gpu", strconv.Itoa(int(*config.MainGPU)))
+	}
+	return args
+}
+
+// startLlamaServer returns the llama-server running with args, starting one
+// when there is none, and waits until its model is loaded. The caller counts
+// as one of its users. started reports whether it was started by this call.
+// Loading a model can take minutes, so it is waited for without holding
+// llamaServersMu: callers wanting the same server wait for the same load, and
+// other servers can start meanwhile.
+func startLlamaServer(ctx context.Context, path string, args []string) (server *llamaServer, started bool, err error) {
+	key := strings.Join(args, "\x00")
+
+	llamaServersMu.Lock()
+	server, ok := llamaServers[key]
+	if ok && server.failed() {
+		delete(llamaServers, key)
+		ok = false
+	}
+	if !ok {
+		if server, err = launchLlamaServer(key, args); err != nil {
+			llamaServersMu.Unlock()
+			return nil, false, err
+		}
+		llamaServers[key] = server
+		go func(server *llamaServer) {
+			// Each caller gives up waiting with its own context; the load
+			// itself ends when the last of them releases the server
+			if err := server.waitReady(context.Background()); err != nil {
+				server.readyErr = fmt.Errorf("llama-server failed to load %s: %v", filepath.Base(path), err)
+			}
+			close(server.ready)
+		}(server)
+	}
+	server.users++
+	llamaServersMu.Unlock()
+
+	select {
+	case <-server.ready:
+		err = server.readyErr
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		server.release()
+		return nil, false, err
+	}
+	return server, !ok, nil
+}
+
+// launchLlamaServer starts a llama-server process with args, without waiting
+// for its model to load
+func launchLlamaServer(key string, args []string) (*llamaServer, error) {
+	binary := os.Getenv(llamaServerEnv)
+	if binary == "" {
+		var err error
+		if binary, err = exec.LookPath("llama-server"); err != nil {
+			return nil, fmt.Errorf("llama-server not found: install llama.cpp, put llama-server on the PATH or set %s to it", llamaServerEnv)
+		}
+	}
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	server := &llamaServer{
+		key:    key,
+		url:    "http://127.0.0.1:" + strconv.Itoa(port),
+		apiKey: hex.EncodeToString(secret),
+		stderr: &tailBuffer{limit: llamaServerStderrLimit},
+		exited: make(chan struct{}),
+		ready:  make(chan struct{}),
+	}
+	server.cmd = exec.Command(binary, append(args, "--host", "127.0.0.1", "--port", strconv.Itoa(port))...)
+	// The key goes in the environment, which unlike the command line other
+	// users can't read
+	server.cmd.Env = append(os.Environ(), llamaAPIKeyEnv+"="+server.apiKey)
+	stopWithParent(server.cmd)
+	server.cmd.Stdout = server.stderr
+	server.cmd.Stderr = server.stderr
+	if err := server.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start llama-server: %v", err)
+	}
+	go func() {
+		server.err = server.cmd.Wait()
+		close(server.exited)
+	}()
+	return server, nil
+}
+
+// failed reports whether the server has exited or failed to load its model,
+// so a new one should be started in its place
+func (s *llamaServer) failed() bool {
+	select {
+	case <-s.exited:
+		return true
+	case <-s.ready:
+		return s.readyErr != nil
+	default:
+		return false
+	}
+}
+
+// release drops a user of the server, stopping it when it was the last
+func (s *llamaServer) release() {
+	llamaServersMu.Lock()
+	defer llamaServersMu.Unlock()
+	if s.users--; s.users > 0 {
+		return
+	}
+	s.stop()
+	if llamaServers[s.key] == s {
+		delete(llamaServers, s.key)
+	}
+}
+
+// waitReady waits until the server's health check passes, which it does once
+// the model is loaded
+func (s *llamaServer) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, llamaServerStartTimeout)
+	defer cancel()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/health", nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-s.exited:
+			output := strings.TrimSpace(s.stderr.String())
+			if output == "" {
+				return fmt.Errorf("exited: %v", s.err)
+			}
+			return fmt.Errorf("exited: %v: %s", s.err, output)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// stop ends the server process and waits for it to exit
+func (s *llamaServer) stop() {
+	select {
+	case <-s.exited:
+		return
+	default:
+	}
+	s.cmd.Process.Kill()
+	<-s.exited
+}
+
+// freePort returns a TCP port on the loopback interface nothing listens on
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	data  []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
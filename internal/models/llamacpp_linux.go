@@ -0,0 +1,12 @@
+package models
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopWithParent has the kernel kill cmd when mcphost dies without stopping
+// it, so a crash doesn't leave the model loaded
+func stopWithParent(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+}
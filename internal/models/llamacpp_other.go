@@ -0,0 +1,9 @@
+//go:build !linux
+
+package models
+
+import "os/exec"
+
+// stopWithParent is a no-op where the kernel can't tie a child's lifetime to
+// its parent's
+func stopWithParent(*exec.Cmd) {}
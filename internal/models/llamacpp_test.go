@@ -0,0 +1,232 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestLlamaServerArgs(t *testing.T) {
+	numGPU, mainGPU := int32(-1), int32(0)
+	config := &ProviderConfig{NumGPU: &numGPU, MainGPU: &mainGPU}
+	if got := strings.Join(llamaServerArgs("/models/qwen.gguf", config), " "); got != "--model /models/qwen.gguf --jinja" {
+		t.Errorf("expected llama-server's defaults, got %q", got)
+	}
+
+	numGPU, mainGPU = 20, 1
+	config = &ProviderConfig{NumCtx: 32768, NumThread: 8, NumGPU: &numGPU, MainGPU: &mainGPU}
+	want := "--model /models/qwen.gguf --jinja --ctx-size 32768 --threads 8 --n-gpu-layers 20 --main-gpu 1"
+	if got := strings.Join(llamaServerArgs("/models/qwen.gguf", config), " "); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLlamaCppModelPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	os.WriteFile(filepath.Join(dir, "qwen.gguf"), []byte("GGUF"), 0o644)
+
+	if path, err := llamaCppModelPath("~/qwen.gguf"); err != nil || path != filepath.Join(dir, "qwen.gguf") {
+		t.Errorf("expected ~ expanded, got %q, %v", path, err)
+	}
+	if _, err := llamaCppModelPath(filepath.Join(dir, "missing.gguf")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+	if _, err := llamaCppModelPath(dir); err == nil || !strings.Contains(err.Error(), "directory") {
+		t.Errorf("expected a directory to fail, got %v", err)
+	}
+}
+
+func TestStartLlamaServerFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script llama-server")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "llama-server")
+	script := "#!/bin/sh\necho \"error: unknown model architecture: 'mamba9'\" >&2\nexit 1\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(llamaServerEnv, binary)
+	model := filepath.Join(dir, "mamba.gguf")
+	os.WriteFile(model, []byte("GGUF"), 0o644)
+
+	_, err := CreateProvider(context.Background(), &ProviderConfig{ModelString: LlamaCppProvider + ":" + model})
+	if err == nil || !strings.Contains(err.Error(), "failed to load mamba.gguf") || !strings.Contains(err.Error(), "unknown model architecture") {
+		t.Errorf("expected llama-server's error, got %v", err)
+	}
+	if len(llamaServers) != 0 {
+		t.Error("expected the failed server to be forgotten")
+	}
+}
+
+// TestHelperLlamaServer is the llama-server TestLlamaServerRelease starts: a
+// health check on the --port it is given, passing when it got an API key
+func TestHelperLlamaServer(t *testing.T) {
+	if os.Getenv("MCPHOST_TEST_LLAMA_SERVER") == "" {
+		return
+	}
+	args := os.Args
+	port := args[slices.Index(args, "--port")+1]
+	if slices.Contains(args, "--api-key") || os.Getenv(llamaAPIKeyEnv) == "" {
+		os.Exit(2)
+	}
+	if slices.Contains(args, "--slow") {
+		// A model that never finishes loading
+		select {}
+	}
+	http.ListenAndServe("127.0.0.1:"+port, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	os.Exit(1)
+}
+
+func TestLlamaServerRelease(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script llama-server")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "llama-server")
+	script := "#!/bin/sh\nexec \"" + os.Args[0] + "\" -test.run='^TestHelperLlamaServer$' -- \"$@\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(llamaServerEnv, binary)
+	t.Setenv("MCPHOST_TEST_LLAMA_SERVER", "1")
+	model := filepath.Join(dir, "qwen.gguf")
+	os.WriteFile(model, []byte("GGUF"), 0o644)
+	config := &ProviderConfig{ModelString: LlamaCppProvider + ":" + model}
+
+	first, err := CreateProvider(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := CreateProvider(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(llamaServers) != 1 || first.Release == nil || second.Release == nil {
+		t.Fatalf("expected both models to share one server, got %d", len(llamaServers))
+	}
+	var server *llamaServer
+	for _, server = range llamaServers {
+	}
+
+	first.Release()
+	first.Release()
+	select {
+	case <-server.exited:
+		t.Fatal("expected the server to run while a model uses it")
+	default:
+	}
+	second.Release()
+	select {
+	case <-server.exited:
+	default:
+		t.Fatal("expected the server to stop with its last model")
+	}
+	if len(llamaServers) != 0 {
+		t.Error("expected the stopped server to be forgotten")
+	}
+}
+
+func TestLlamaServerLoadsWithoutLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script llama-server")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "llama-server")
+	script := "#!/bin/sh\nexec \"" + os.Args[0] + "\" -test.run='^TestHelperLlamaServer$' -- \"$@\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(llamaServerEnv, binary)
+	t.Setenv("MCPHOST_TEST_LLAMA_SERVER", "1")
+	args := []string{"--model", filepath.Join(dir, "big.gguf"), "--slow"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	for range 2 {
+		go func() {
+			_, _, err := startLlamaServer(ctx, "big.gguf", args)
+			errs <- err
+		}()
+	}
+
+	// While the model loads, both callers wait for the same server and
+	// others can still use the registry
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		llamaServersMu.Lock()
+		server := llamaServers[strings.Join(args, "\x00")]
+		users := 0
+		if server != nil {
+			users = server.users
+		}
+		llamaServersMu.Unlock()
+		if users == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected both callers to wait for one server, got %d users", users)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	for range 2 {
+		if err := <-errs; err != context.Canceled {
+			t.Errorf("expected the wait to be cancelled, got %v", err)
+		}
+	}
+	llamaServersMu.Lock()
+	defer llamaServersMu.Unlock()
+	if len(llamaServers) != 0 {
+		t.Error("expected the server to be stopped once no one waits for it")
+	}
+}
+
+func TestLlamaCppProviderURL(t *testing.T) {
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["top_k"] != float64(20) {
+			t.Errorf("expected top_k in the request, got %v", body["top_k"])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1", "object": "chat.completion", "model": "qwen.gguf",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "OK"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	topK := int32(20)
+	result, err := CreateProvider(context.Background(), &ProviderConfig{
+		ModelString:    LlamaCppProvider + ":qwen.gguf",
+		ProviderURL:    server.URL,
+		ProviderAPIKey: "secret",
+		TopK:           &topK,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := result.Model.Generate(context.Background(), []*schema.Message{schema.UserMessage("Reply with OK.")})
+	if err != nil || msg.Content != "OK" {
+		t.Fatalf("expected the running server's answer, got %v, %v", msg, err)
+	}
+	if path != "/v1/chat/completions" || auth != "Bearer secret" {
+		t.Errorf("unexpected request to %s with %q", path, auth)
+	}
+	if !IsLocal(LlamaCppProvider) || IsLocal(CompatibleProvider) {
+		t.Error("expected llamacpp models to be local")
+	}
+}
@@ -8538,6 +8538,40 @@ func GetModelsData() map[string]ProviderInfo {
 						Output:  64000,
 					},
 				},
+				"grok-4-fast-non-reasoning": {
+					ID:          "grok-4-fast-non-reasoning",
+					Name:        "Grok 4 Fast (Non-Reasoning)",
+					Attachment:  true,
+					Reasoning:   false,
+					Temperature: true,
+					Cost: Cost{
+						Input:      0.2,
+						Output:     0.5,
+						CacheRead:  &[]float64{0.05}[0],
+						CacheWrite: nil,
+					},
+					Limit: Limit{
+						Context: 2000000,
+						Output:  30000,
+					},
+				},
+				"grok-4-fast-reasoning": {
+					ID:          "grok-4-fast-reasoning",
+					Name:        "Grok 4 Fast (Reasoning)",
+					Attachment:  true,
+					Reasoning:   true,
+					Temperature: true,
+					Cost: Cost{
+						Input:      0.2,
+						Output:     0.5,
+						CacheRead:  &[]float64{0.05}[0],
+						CacheWrite: nil,
+					},
+					Limit: Limit{
+						Context: 2000000,
+						Output:  30000,
+					},
+				},
 				"grok-beta": {
 					ID:          "grok-beta",
 					Name:        "Grok Beta",
@@ -8555,6 +8589,23 @@ func GetModelsData() map[string]ProviderInfo {
 						Output:  4096,
 					},
 				},
+				"grok-code-fast-1": {
+					ID:          "grok-code-fast-1",
+					Name:        "Grok Code Fast 1",
+					Attachment:  false,
+					Reasoning:   true,
+					Temperature: true,
+					Cost: Cost{
+						Input:      0.2,
+						Output:     1.5,
+						CacheRead:  &[]float64{0.02}[0],
+						CacheWrite: nil,
+					},
+					Limit: Limit{
+						Context: 256000,
+						Output:  10000,
+					},
+				},
 				"grok-vision-beta": {
 					ID:          "grok-vision-beta",
 					Name:        "Grok Vision Beta",
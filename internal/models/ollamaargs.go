@@ -0,0 +1,261 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ollamaFormatKey is the context key for the JSON schema the output of an
+// Ollama chat request must follow, which ollamaFormatTransport sends as the
+// request's format
+type ollamaFormatKey struct{}
+
+// ollamaFormatTransport sets the format of Ollama chat requests whose context
+// carries a JSON schema. The eino model only has a format for all requests.
+type ollamaFormatTransport struct {
+	base http.RoundTripper
+}
+
+func (t *ollamaFormatTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	format, ok := req.Context().Value(ollamaFormatKey{}).(json.RawMessage)
+	if !ok || req.Body == nil || !strings.HasSuffix(req.URL.Path, "/api/chat") {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	request["format"] = format
+	delete(request, "tools") // The output is the format, not a tool call
+	if body, err = json.Marshal(request); err != nil {
+		return nil, err
+	}
+
+	newReq := req.Clone(req.Context())
+	newReq.Body = io.NopCloser(bytes.NewReader(body))
+	newReq.ContentLength = int64(len(body))
+	return t.base.RoundTrip(newReq)
+}
+
+// ollamaArgsModel fixes the tool calls of an Ollama model whose arguments
+// don't match the tool's JSON schema, as small local models often write. The
+// arguments of such a call are written again with the schema as the
+// request's format, so Ollama's grammar-constrained decoding can only
+// produce matching JSON.
+type ollamaArgsModel struct {
+	model model.ToolCallingChatModel
+	tools []*schema.ToolInfo // bound with WithTools
+}
+
+// constrainArguments returns m with invalid tool arguments written again under
+// the tool's schema; m's HTTP client must go through ollamaFormatTransport
+func constrainArguments(m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	return &ollamaArgsModel{model: m}
+}
+
+// Generate implements model.BaseChatModel
+func (m *ollamaArgsModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	msg, err := m.model.Generate(ctx, input, opts...)
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	m.fixArguments(ctx, input, msg, opts)
+	return msg, nil
+}
+
+// Stream implements model.BaseChatModel. Text is passed through as it comes;
+// the tool calls are held back and sent, fixed, at the end.
+func (m *ollamaArgsModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	upstream, err := m.model.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer upstream.Close()
+		defer writer.Close()
+
+		var held []*schema.Message
+		for {
+			chunk, err := upstream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				writer.Send(nil, err)
+				return
+			}
+			if chunk == nil {
+				continue
+			}
+			if len(chunk.ToolCalls) > 0 {
+				held = append(held, chunk)
+				continue
+			}
+			writer.Send(chunk, nil)
+		}
+		if len(held) == 0 {
+			return
+		}
+		msg, err := schema.ConcatMessages(held)
+		if err != nil {
+			writer.Send(nil, err)
+			return
+		}
+		m.fixArguments(ctx, input, msg, opts)
+		writer.Send(msg, nil)
+	}()
+	return reader, nil
+}
+
+// WithTools implements model.ToolCallingChatModel
+func (m *ollamaArgsModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.model.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaArgsModel{model: inner, tools: tools}, nil
+}
+
+// fixArguments writes again the arguments of msg's tool calls that don't
+// match their tool's schema. A call that still can't be fixed is left as it
+// is, for the tool to reject.
+func (m *ollamaArgsModel) fixArguments(ctx context.Context, input []*schema.Message, msg *schema.Message, opts []model.Option) {
+	tools := model.GetCommonOptions(&model.Options{Tools: m.tools}, opts...).Tools
+	for i, call := range msg.ToolCalls {
+		index := slices.IndexFunc(tools, func(info *schema.ToolInfo) bool { return info.Name == call.Function.Name })
+		if index < 0 || tools[index].ParamsOneOf == nil {
+			continue
+		}
+		jsonSchema, err := tools[index].ParamsOneOf.ToJSONSchema()
+		if err != nil || jsonSchema == nil {
+			continue
+		}
+		format, err := json.Marshal(jsonSchema)
+		if err != nil {
+			continue
+		}
+		var schemaMap map[string]interface{}
+		if json.Unmarshal(format, &schemaMap) != nil {
+			continue
+		}
+
+		problems := argumentProblems(schemaMap, call.Function.Arguments)
+		if len(problems) == 0 {
+			continue
+		}
+		prompt := fmt.Sprintf("Write the arguments for a call to the tool %s (%s) as a JSON object. The arguments %s are wrong: %s.",
+			call.Function.Name, tools[index].Desc, call.Function.Arguments, strings.Join(problems, "; "))
+		messages := append(append([]*schema.Message{}, input...), schema.UserMessage(prompt))
+		fixed, err := m.model.Generate(context.WithValue(ctx, ollamaFormatKey{}, json.RawMessage(format)), messages, opts...)
+		if err != nil || fixed == nil || len(argumentProblems(schemaMap, fixed.Content)) > 0 {
+			continue
+		}
+		msg.ToolCalls[i].Function.Arguments = strings.TrimSpace(fixed.Content)
+	}
+}
+
+// argumentProblems lists how the JSON arguments don't match the tool's JSON
+// schema: not an object, missing required properties, or properties of the
+// wrong type or outside their enum
+func argumentProblems(jsonSchema map[string]interface{}, arguments string) []string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(arguments), &value); err != nil {
+		return []string{"not valid JSON"}
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return []string{"not a JSON object"}
+	}
+	return valueProblems(jsonSchema, value, "arguments")
+}
+
+// valueProblems checks value against the parts of jsonSchema local models get
+// wrong: type, enum, required properties and, recursively, properties and
+// array items
+func valueProblems(jsonSchema map[string]interface{}, value interface{}, path string) []string {
+	if !matchesType(jsonSchema["type"], value) {
+		return []string{fmt.Sprintf("%s should be of type %v", path, jsonSchema["type"])}
+	}
+	if enum, ok := jsonSchema["enum"].([]interface{}); ok && len(enum) > 0 && !slices.ContainsFunc(enum, func(allowed interface{}) bool {
+		return fmt.Sprint(allowed) == fmt.Sprint(value)
+	}) {
+		return []string{fmt.Sprintf("%s should be one of %v", path, enum)}
+	}
+
+	var problems []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		required, _ := jsonSchema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[fmt.Sprint(name)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%v is required", path, name))
+			}
+		}
+		properties, _ := jsonSchema["properties"].(map[string]interface{})
+		for name, property := range v {
+			if propertySchema, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, valueProblems(propertySchema, property, path+"."+name)...)
+			} else if jsonSchema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s.%s is not a known property", path, name))
+			}
+		}
+	case []interface{}:
+		if items, ok := jsonSchema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, valueProblems(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// matchesType reports whether value is of the schema type, a name or a list
+// of names; a schema without a type matches anything
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case string:
+		switch t {
+		case "object":
+			_, ok := value.(map[string]interface{})
+			return ok
+		case "array":
+			_, ok := value.([]interface{})
+			return ok
+		case "string":
+			_, ok := value.(string)
+			return ok
+		case "number":
+			_, ok := value.(float64)
+			return ok
+		case "integer":
+			n, ok := value.(float64)
+			return ok && n == float64(int64(n))
+		case "boolean":
+			_, ok := value.(bool)
+			return ok
+		case "null":
+			return value == nil
+		}
+		return true
+	case []interface{}:
+		return slices.ContainsFunc(t, func(name interface{}) bool { return matchesType(name, value) })
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestConstrainArguments(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		message := `{"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "fs__read", "arguments": {"path": 3, "mode": "binary"}}}]}`
+		if request["format"] != nil {
+			message = `{"role": "assistant", "content": "{\"path\": \"a.txt\", \"mode\": \"text\"}"}`
+		}
+		w.Write([]byte(`{"model": "qwen3:0.6b", "message": ` + message + `, "done": true}`))
+	}))
+	defer server.Close()
+
+	chatModel, err := ollama.NewChatModel(context.Background(), &ollama.ChatModelConfig{
+		BaseURL:    server.URL,
+		Model:      "qwen3:0.6b",
+		HTTPClient: &http.Client{Transport: &ollamaFormatTransport{base: http.DefaultTransport}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []*schema.ToolInfo{{Name: "fs__read", Desc: "Read a file", ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"path": {Type: schema.String, Required: true},
+		"mode": {Type: schema.String, Enum: []string{"text", "lines"}},
+	})}}
+	bound, err := constrainArguments(chatModel).WithTools(tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := bound.Generate(context.Background(), []*schema.Message{schema.UserMessage("Read a.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"path": "a.txt", "mode": "text"}` {
+		t.Fatalf("expected the arguments written again, got %+v", msg.ToolCalls)
+	}
+	if len(requests) != 2 || requests[0]["format"] != nil || requests[1]["tools"] != nil {
+		t.Fatalf("expected a call with tools, then one with the schema as its format, got %v", requests)
+	}
+	format, _ := requests[1]["format"].(map[string]interface{})
+	if format["type"] != "object" || format["properties"] == nil {
+		t.Errorf("expected the tool's schema as the format, got %v", requests[1]["format"])
+	}
+
+	// Streamed tool calls are fixed the same way
+	requests = nil
+	stream, err := bound.Stream(context.Background(), []*schema.Message{schema.UserMessage("Read a.txt")}, model.WithTemperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := schema.ConcatMessageStream(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks.ToolCalls) != 1 || chunks.ToolCalls[0].Function.Arguments != `{"path": "a.txt", "mode": "text"}` || len(requests) != 2 {
+		t.Errorf("expected the streamed arguments written again, got %+v", chunks.ToolCalls)
+	}
+}
+
+func TestArgumentProblems(t *testing.T) {
+	jsonSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"path"},
+		"properties": map[string]interface{}{
+			"path":  map[string]interface{}{"type": "string"},
+			"lines": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+		},
+	}
+	for arguments, want := range map[string]int{
+		`{"path": "a", "lines": [1, 2]}`: 0,
+		`{"path": "a", "other": true}`:   0,
+		`{}`:                             1,
+		`{"path": 1, "lines": [1.5, 2]}`: 2,
+		`["a"]`:                          1,
+		`{"path": `:                      1,
+	} {
+		if problems := argumentProblems(jsonSchema, arguments); len(problems) != want {
+			t.Errorf("expected %d problems with %s, got %v", want, arguments, problems)
+		}
+	}
+}
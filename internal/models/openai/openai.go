@@ -12,6 +12,7 @@ import (
 	einoopenai "github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/osi4iot/mcphost/internal/models/usage"
 )
 
 // CustomChatModel wraps the eino-ext OpenAI model with custom tool schema handling
@@ -22,10 +23,15 @@ type CustomChatModel struct {
 // CustomRoundTripper intercepts HTTP requests to fix OpenAI function schemas
 type CustomRoundTripper struct {
 	wrapped http.RoundTripper
+
+	// parallelToolCalls is sent as parallel_tool_calls with tools, when set
+	parallelToolCalls *bool
 }
 
-// NewCustomChatModel creates a new custom OpenAI chat model
-func NewCustomChatModel(ctx context.Context, config *einoopenai.ChatModelConfig) (*CustomChatModel, error) {
+// NewCustomChatModel creates a new custom OpenAI chat model. parallelToolCalls,
+// when set, allows or forbids several tool calls in one response; nil keeps
+// the API's default.
+func NewCustomChatModel(ctx context.Context, config *einoopenai.ChatModelConfig, parallelToolCalls *bool) (*CustomChatModel, error) {
 	// Create a custom HTTP client that intercepts requests
 	if config.HTTPClient == nil {
 		config.HTTPClient = &http.Client{}
@@ -36,7 +42,8 @@ func NewCustomChatModel(ctx context.Context, config *einoopenai.ChatModelConfig)
 		config.HTTPClient.Transport = http.DefaultTransport
 	}
 	config.HTTPClient.Transport = &CustomRoundTripper{
-		wrapped: config.HTTPClient.Transport,
+		wrapped:           config.HTTPClient.Transport,
+		parallelToolCalls: parallelToolCalls,
 	}
 
 	wrapped, err := einoopenai.NewChatModel(ctx, config)
@@ -49,13 +56,23 @@ func NewCustomChatModel(ctx context.Context, config *einoopenai.ChatModelConfig)
 	}, nil
 }
 
-// RoundTrip implements http.RoundTripper to intercept and fix OpenAI requests
+// RoundTrip implements http.RoundTripper to intercept and fix OpenAI requests,
+// and to read the reasoning token counts the eino model drops from responses
 func (c *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	// Only intercept OpenAI chat completions requests
 	if !strings.Contains(req.URL.Path, "/chat/completions") {
 		return c.wrapped.RoundTrip(req)
 	}
 
+	resp, err := c.fixRequest(req)
+	if err == nil {
+		usage.Watch(req, resp, parseUsage)
+	}
+	return resp, err
+}
+
+func (c *CustomRoundTripper) fixRequest(req *http.Request) (*http.Response, error) {
+
 	// Read the request body
 	if req.Body == nil {
 		return c.wrapped.RoundTrip(req)
@@ -75,6 +92,11 @@ func (c *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 		return c.wrapped.RoundTrip(req)
 	}
 
+	// The API rejects parallel_tool_calls in a request without tools
+	if tools, ok := requestData["tools"].([]interface{}); ok && len(tools) > 0 && c.parallelToolCalls != nil {
+		requestData["parallel_tool_calls"] = *c.parallelToolCalls
+	}
+
 	// Fix function schemas if present
 	if tools, ok := requestData["tools"].([]interface{}); ok {
 		for _, tool := range tools {
@@ -111,12 +133,25 @@ func (c *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 
 // Generate implements model.ChatModel
 func (c *CustomChatModel) Generate(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	return c.wrapped.Generate(ctx, in, opts...)
+	ctx, recorder := usage.WithRecorder(ctx)
+	msg, err := c.wrapped.Generate(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if rep, ok := recorder.Report(); ok {
+		rep.Apply(msg)
+	}
+	return msg, nil
 }
 
 // Stream implements model.ChatModel
 func (c *CustomChatModel) Stream(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	return c.wrapped.Stream(ctx, in, opts...)
+	ctx, recorder := usage.WithRecorder(ctx)
+	stream, err := c.wrapped.Stream(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return usage.WrapStream(stream, recorder), nil
 }
 
 // WithTools implements model.ToolCallingChatModel
@@ -154,3 +189,29 @@ func (c *CustomChatModel) GetType() string {
 func (c *CustomChatModel) IsCallbacksEnabled() bool {
 	return c.wrapped.IsCallbacksEnabled()
 }
+
+// parseUsage reads usage from a chat completion, or from the final chunk of a
+// stream (sent because the request sets stream_options.include_usage)
+func parseUsage(data []byte) (usage.Report, bool) {
+	var doc struct {
+		Usage *struct {
+			PromptTokens        int `json:"prompt_tokens"`
+			CompletionTokens    int `json:"completion_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+			CompletionTokensDetails struct {
+				ReasoningTokens int `json:"reasoning_tokens"`
+			} `json:"completion_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Usage == nil {
+		return usage.Report{}, false
+	}
+	return usage.Report{
+		InputTokens:     doc.Usage.PromptTokens,
+		OutputTokens:    doc.Usage.CompletionTokens,
+		CacheReadTokens: doc.Usage.PromptTokensDetails.CachedTokens,
+		ReasoningTokens: doc.Usage.CompletionTokensDetails.ReasoningTokens,
+	}, true
+}
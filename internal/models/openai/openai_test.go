@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// roundTripFunc records the request body the model would send
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestParallelToolCalls(t *testing.T) {
+	send := func(parallel *bool, body string) map[string]interface{} {
+		var sent map[string]interface{}
+		rt := &CustomRoundTripper{parallelToolCalls: parallel, wrapped: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			data, _ := io.ReadAll(req.Body)
+			if err := json.Unmarshal(data, &sent); err != nil {
+				t.Fatal(err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		})}
+		req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewReader([]byte(body)))
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		return sent
+	}
+
+	withTools := `{"model": "gpt-4o", "tools": [{"type": "function", "function": {"name": "t", "parameters": {"type": "object"}}}], "messages": []}`
+	disabled := false
+	if sent := send(&disabled, withTools); sent["parallel_tool_calls"] != false {
+		t.Errorf("expected parallel_tool_calls false, got %v", sent["parallel_tool_calls"])
+	}
+	if sent := send(nil, withTools); sent["parallel_tool_calls"] != nil {
+		t.Errorf("expected the API default, got %v", sent["parallel_tool_calls"])
+	}
+
+	// The API rejects the setting without tools
+	if sent := send(&disabled, `{"model": "gpt-4o", "messages": []}`); sent["parallel_tool_calls"] != nil {
+		t.Errorf("expected no parallel_tool_calls without tools, got %v", sent["parallel_tool_calls"])
+	}
+}
@@ -0,0 +1,326 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/auth"
+)
+
+// ProbeTTL is how long a probed endpoint profile is trusted before the
+// endpoint is probed again
+const ProbeTTL = 24 * time.Hour
+
+// probeAPIKeyEnv is where each OpenAI-style provider looks for its API key
+var probeAPIKeyEnv = map[string]string{
+	"openai":   "OPENAI_API_KEY",
+	"groq":     "GROQ_API_KEY",
+	"xai":      "XAI_API_KEY",
+	"deepseek": "DEEPSEEK_API_KEY",
+}
+
+// EndpointProfile is what a probe found a custom provider endpoint can do
+type EndpointProfile struct {
+	URL      string    `json:"url"`
+	Model    string    `json:"model"`            // provider:model
+	Models   []string  `json:"models,omitempty"` // the models the endpoint lists, if it lists them
+	Tools    bool      `json:"tools"`            // accepts tool definitions
+	Warnings []string  `json:"warnings,omitempty"`
+	ProbedAt time.Time `json:"probed_at"`
+}
+
+// probed holds the profiles in use, by model string
+var probed = map[string]*EndpointProfile{}
+
+// UseEndpointProfile makes the model follow what its endpoint was found to
+// support. Capabilities declared in the config file take precedence.
+func UseEndpointProfile(profile *EndpointProfile) {
+	probed[profile.Model] = profile
+}
+
+// probeStatusError is a probe request the endpoint answered with an error
+type probeStatusError struct {
+	request string // method and URL
+	status  int
+	body    string
+}
+
+func (e *probeStatusError) Error() string {
+	body := strings.TrimSpace(e.body)
+	if len(body) > 300 {
+		body = body[:300] + "..."
+	}
+	if body == "" {
+		return fmt.Sprintf("%s: %d %s", e.request, e.status, http.StatusText(e.status))
+	}
+	return fmt.Sprintf("%s: %d %s: %s", e.request, e.status, http.StatusText(e.status), body)
+}
+
+// prober sends the probe requests in the API style of a provider
+type prober struct {
+	client    *http.Client
+	anthropic bool // the Anthropic messages API, else the OpenAI chat API
+	baseURL   string
+	apiKey    string
+	model     string
+}
+
+// ProbeEndpoint checks that the custom endpoint of config, --provider-url or
+// the openai-compatible section's url, serves its model: it lists the
+// endpoint's models, makes a minimal completion, and finds out whether tool
+// definitions are accepted. It returns nil without a custom endpoint or for
+// providers whose API it doesn't speak (Google, Azure and Ollama). The error
+// says what is wrong and what to check.
+func ProbeEndpoint(ctx context.Context, config *ProviderConfig) (*EndpointProfile, error) {
+	provider, modelName, _ := strings.Cut(config.ModelString, ":")
+	p := &prober{
+		client:  &http.Client{Transport: createProviderTransport(config)},
+		baseURL: strings.TrimRight(config.ProviderURL, "/"),
+		apiKey:  config.ProviderAPIKey,
+		model:   modelName,
+	}
+	switch provider {
+	case "anthropic":
+		apiKey, source, err := auth.GetAnthropicAPIKey(config.ProviderAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		// OAuth tokens only work with Anthropic's own endpoint
+		if strings.HasPrefix(source, "stored OAuth") {
+			return nil, nil
+		}
+		p.anthropic, p.apiKey = true, apiKey
+	case CompatibleProvider:
+		if p.baseURL == "" {
+			p.baseURL = strings.TrimRight(compatible.URL, "/")
+		}
+		if p.apiKey == "" {
+			p.apiKey = compatible.APIKey
+		}
+	case "openai", "groq", "xai", "deepseek":
+		if p.apiKey == "" {
+			p.apiKey = os.Getenv(probeAPIKeyEnv[provider])
+		}
+	default:
+		return nil, nil
+	}
+	if p.baseURL == "" {
+		return nil, nil
+	}
+
+	profile := &EndpointProfile{URL: p.baseURL, Model: config.ModelString, Tools: true, ProbedAt: time.Now()}
+
+	ids, listErr := p.listModels(ctx)
+	profile.Models = ids
+	listed := listErr != nil || len(ids) == 0 || slices.Contains(ids, modelName)
+
+	err := p.complete(ctx, true)
+	var statusErr *probeStatusError
+	if errors.As(err, &statusErr) && toolsRejected(statusErr.status) {
+		if p.complete(ctx, false) == nil {
+			profile.Tools = false
+			profile.Warnings = append(profile.Warnings, fmt.Sprintf("%s rejected tool definitions (%s), so %s has no native tool calls", p.baseURL, statusErr.Error(), config.ModelString))
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, p.diagnose(err, listErr, listed, ids)
+	}
+
+	if listErr != nil {
+		profile.Warnings = append(profile.Warnings, fmt.Sprintf("%s does not list its models (%v)", p.baseURL, listErr))
+	} else if !listed {
+		profile.Warnings = append(profile.Warnings, fmt.Sprintf("%s is not among the models %s lists, but it answered", modelName, p.baseURL))
+	}
+	return profile, nil
+}
+
+// toolsRejected reports whether a failed request with tools is worth trying
+// again without them: not when the key, the URL or the rate limit is wrong
+func toolsRejected(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400
+}
+
+// diagnose explains why the minimal completion failed
+func (p *prober) diagnose(err, listErr error, listed bool, ids []string) error {
+	var statusErr *probeStatusError
+	if !errors.As(err, &statusErr) {
+		return fmt.Errorf("could not reach %s: %w. Check the URL and that the server is running", p.baseURL, err)
+	}
+	var listStatusErr *probeStatusError
+	switch {
+	case statusErr.status == http.StatusUnauthorized || statusErr.status == http.StatusForbidden:
+		return fmt.Errorf("%s rejected the API key (%w). Set it with --provider-api-key", p.baseURL, err)
+	case !listed:
+		shown := ids
+		if len(shown) > 10 {
+			shown = append(shown[:10:10], fmt.Sprintf("and %d more", len(ids)-10))
+		}
+		return fmt.Errorf("%s does not serve model %q (%w). It lists: %s", p.baseURL, p.model, err, strings.Join(shown, ", "))
+	case statusErr.status == http.StatusNotFound && errors.As(listErr, &listStatusErr) && listStatusErr.status == http.StatusNotFound:
+		hint := "check the URL"
+		if !p.anthropic && !strings.HasSuffix(p.baseURL, "/v1") {
+			hint = fmt.Sprintf("the URL probably needs the API's path prefix, such as %s/v1", p.baseURL)
+		}
+		return fmt.Errorf("nothing answers at %s (%w): %s", p.baseURL, err, hint)
+	}
+	return fmt.Errorf("%s failed a minimal completion: %w", p.baseURL, err)
+}
+
+// listModels returns the IDs of the models the endpoint lists
+func (p *prober) listModels(ctx context.Context) ([]string, error) {
+	path := "/models"
+	if p.anthropic {
+		path = "/v1/models"
+	}
+	body, err := p.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("unexpected model list: %w", err)
+	}
+	ids := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		ids = append(ids, model.ID)
+	}
+	return ids, nil
+}
+
+// complete makes a minimal completion, offering a tool when withTools is set
+func (p *prober) complete(ctx context.Context, withTools bool) error {
+	request := map[string]interface{}{
+		"model":    p.model,
+		"messages": []map[string]interface{}{{"role": "user", "content": "Reply with OK."}},
+	}
+	path := "/chat/completions"
+	if p.anthropic {
+		path = "/v1/messages"
+		request["max_tokens"] = 16
+		if withTools {
+			request["tools"] = []map[string]interface{}{{
+				"name": "ping", "description": "Check the connection",
+				"input_schema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			}}
+		}
+	} else if withTools {
+		request["tools"] = []map[string]interface{}{{"type": "function", "function": map[string]interface{}{
+			"name": "ping", "description": "Check the connection",
+			"parameters": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		}}}
+	}
+	_, err := p.do(ctx, http.MethodPost, path, request)
+	return err
+}
+
+// do sends a probe request and returns the body of a successful response
+func (p *prober) do(ctx context.Context, method, path string, request interface{}) ([]byte, error) {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.anthropic {
+		req.Header.Set("x-api-key", p.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	} else if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &probeStatusError{request: method + " " + p.baseURL + path, status: resp.StatusCode, body: string(respBody)}
+	}
+	return respBody, nil
+}
+
+// CachedEndpointProfile returns the profile of config's model and endpoint
+// from the cache file at path, when it was probed within ProbeTTL
+func CachedEndpointProfile(path string, config *ProviderConfig) *EndpointProfile {
+	profiles := readEndpointProfiles(path)
+	for _, profile := range profiles {
+		if profile.Model == config.ModelString && sameEndpoint(profile.URL, config) && time.Since(profile.ProbedAt) < ProbeTTL {
+			return profile
+		}
+	}
+	return nil
+}
+
+// sameEndpoint reports whether url is the custom endpoint of config
+func sameEndpoint(url string, config *ProviderConfig) bool {
+	configured := config.ProviderURL
+	if configured == "" && strings.HasPrefix(config.ModelString, CompatibleProvider+":") {
+		configured = compatible.URL
+	}
+	return url == strings.TrimRight(configured, "/")
+}
+
+// SaveEndpointProfile adds profile to the cache file at path, replacing an
+// earlier profile of the same model and endpoint
+func SaveEndpointProfile(path string, profile *EndpointProfile) error {
+	profiles := []*EndpointProfile{profile}
+	for _, cached := range readEndpointProfiles(path) {
+		if (cached.Model != profile.Model || cached.URL != profile.URL) && time.Since(cached.ProbedAt) < ProbeTTL {
+			profiles = append(profiles, cached)
+		}
+	}
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// readEndpointProfiles reads the cache file at path; a missing or broken
+// file is an empty cache
+func readEndpointProfiles(path string) []*EndpointProfile {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var profiles []*EndpointProfile
+	if json.Unmarshal(data, &profiles) != nil {
+		return nil
+	}
+	return profiles
+}
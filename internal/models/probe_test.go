@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// probeServer serves an OpenAI-style model list and completions under /v1,
+// rejecting tool definitions unless tools is set
+func probeServer(t *testing.T, tools bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Write([]byte(`{"data": [{"id": "qwen"}, {"id": "llama"}]}`))
+		case "/v1/chat/completions":
+			var request map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&request)
+			switch {
+			case request["model"] != "qwen":
+				http.Error(w, `{"error": "model not found"}`, http.StatusNotFound)
+			case request["tools"] != nil && !tools:
+				http.Error(w, `{"error": "tools require --enable-auto-tool-choice"}`, http.StatusBadRequest)
+			default:
+				w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "OK"}}]}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	t.Cleanup(func() { probed = map[string]*EndpointProfile{} })
+	ctx := context.Background()
+
+	server := probeServer(t, true)
+	profile, err := ProbeEndpoint(ctx, &ProviderConfig{ModelString: "openai:qwen", ProviderURL: server.URL + "/v1/", ProviderAPIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profile.Tools || len(profile.Models) != 2 || len(profile.Warnings) != 0 || profile.URL != server.URL+"/v1" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	// A server rejecting tools still serves the model, without them
+	noTools := probeServer(t, false)
+	profile, err = ProbeEndpoint(ctx, &ProviderConfig{ModelString: "openai:qwen", ProviderURL: noTools.URL + "/v1", ProviderAPIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Tools || len(profile.Warnings) != 1 {
+		t.Errorf("expected tools found unsupported, got %+v", profile)
+	}
+	UseEndpointProfile(profile)
+	if SupportsTools("openai:qwen") || !SupportsTools("openai:llama") {
+		t.Error("expected only the probed model to lose tools")
+	}
+
+	for _, tc := range []struct {
+		name, model, url, want string
+	}{
+		{"unlisted model", "openai:mistral", server.URL + "/v1", "It lists: qwen, llama"},
+		{"missing path prefix", "openai:qwen", server.URL, "such as " + server.URL + "/v1"},
+		{"unreachable", "openai:qwen", "http://127.0.0.1:1", "could not reach"},
+	} {
+		_, err := ProbeEndpoint(ctx, &ProviderConfig{ModelString: tc.model, ProviderURL: tc.url, ProviderAPIKey: "key"})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected an error mentioning %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	if profile, err := ProbeEndpoint(ctx, &ProviderConfig{ModelString: "openai:qwen"}); profile != nil || err != nil {
+		t.Errorf("expected no probe without a custom URL, got %+v, %v", profile, err)
+	}
+}
+
+func TestEndpointProfileCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "endpoints.json")
+	config := &ProviderConfig{ModelString: "openai:qwen", ProviderURL: "http://localhost:8080/v1/"}
+	if CachedEndpointProfile(path, config) != nil {
+		t.Error("expected nothing cached yet")
+	}
+
+	profile, err := ProbeEndpoint(context.Background(), &ProviderConfig{ModelString: "openai:qwen", ProviderURL: probeServer(t, true).URL + "/v1", ProviderAPIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile.URL = "http://localhost:8080/v1"
+	if err := SaveEndpointProfile(path, profile); err != nil {
+		t.Fatal(err)
+	}
+	if cached := CachedEndpointProfile(path, config); cached == nil || !cached.Tools {
+		t.Errorf("expected the saved profile, got %+v", cached)
+	}
+	if CachedEndpointProfile(path, &ProviderConfig{ModelString: "openai:qwen", ProviderURL: "http://other:8080/v1"}) != nil {
+		t.Error("expected no profile for another endpoint")
+	}
+
+	profile.ProbedAt = profile.ProbedAt.Add(-ProbeTTL)
+	if err := SaveEndpointProfile(path, profile); err != nil {
+		t.Fatal(err)
+	}
+	if CachedEndpointProfile(path, config) != nil {
+		t.Error("expected an expired profile to be probed again")
+	}
+}
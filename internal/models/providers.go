@@ -7,8 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,7 +25,9 @@ import (
 	"google.golang.org/genai"
 
 	"github.com/osi4iot/mcphost/internal/auth"
+	"github.com/osi4iot/mcphost/internal/models/emulate"
 	"github.com/osi4iot/mcphost/internal/models/gemini"
+	"github.com/osi4iot/mcphost/internal/turn"
 )
 
 const (
@@ -76,18 +80,118 @@ type ProviderConfig struct {
 	TopK          *int32
 	StopSequences []string
 
+	// ParallelToolCalls allows or forbids several tool calls in one response,
+	// for providers with a setting for it; nil keeps the provider's default
+	ParallelToolCalls *bool
+
+	// ToolChoice is auto (or empty), none, required or a tool's name. The
+	// agent applies it to its model calls; it is gated here like the others.
+	ToolChoice string
+
+	// Parameters above left at their flag defaults, by flag name; dropping
+	// them for a model that does not support them is not warned about
+	DefaultParameters map[string]bool
+
 	// Ollama-specific parameters
 	NumGPU  *int32
 	MainGPU *int32
 
+	// NumCtx and NumThread size the context window and the CPU threads of
+	// Ollama models; 0 leaves the model's or Ollama's default
+	NumCtx    int
+	NumThread int
+
+	// KeepAlive is how long Ollama keeps the model loaded after a request;
+	// nil leaves Ollama's default of five minutes, negative keeps it loaded
+	KeepAlive *time.Duration
+
 	// TLS configuration
 	TLSSkipVerify bool // Skip TLS certificate verification (insecure)
+
+	// HTTP timeouts; zero keeps the library defaults
+	RequestTimeout time.Duration // Whole request, or the idle gap between chunks once streaming
+	ConnectTimeout time.Duration // Dialing and TLS handshake
+
+	// Models for auxiliary tasks, by task type ("models" in the config file)
+	TaskModels map[string]string
+
+	// Attachments larger than this many bytes are uploaded with the
+	// provider's file API instead of sent inline (Anthropic only); 0 never
+	UploadThreshold int64
+
+	// NoPromptCache stops the system prompt and tool definitions being marked
+	// for the provider's prompt cache (Anthropic only)
+	NoPromptCache bool
+
+	// Models for steps of the agent loop, by step ("router" in the config file)
+	RouteModels map[string]string
+
+	// ShadowModel is mirrored each model call of the agent loop, for
+	// comparison; its responses are recorded and never used
+	ShadowModel string
+
+	// ThinkingBudget is how many tokens the model may think for before it
+	// answers, for models with extended thinking or reasoning effort; 0
+	// leaves the model's default
+	ThinkingBudget int
+
+	// ToolEmulation is auto (or empty), always or never: whether tool calls
+	// are emulated through the model's text output
+	ToolEmulation string
+
+	// AzureAuth is how Azure OpenAI requests are authenticated: auto (or
+	// empty), key, client-secret, managed-identity or cli
+	AzureAuth string
+}
+
+// ForTask returns a copy of the config for the model configured for task, or
+// nil when the task has no model of its own. The API key and base URL are
+// only carried over when the task model uses the same provider.
+func (c *ProviderConfig) ForTask(task string) *ProviderConfig {
+	return c.ForModel(c.TaskModels[task])
+}
+
+// ForRoute returns a copy of the config for the model the router gives step,
+// or nil when the step uses the main model. Credentials carry over as in
+// ForTask.
+func (c *ProviderConfig) ForRoute(step string) *ProviderConfig {
+	return c.ForModel(c.RouteModels[step])
+}
+
+// ForShadow returns a copy of the config for the shadow model, or nil when
+// there is none. Credentials carry over as in ForTask.
+func (c *ProviderConfig) ForShadow() *ProviderConfig {
+	return c.ForModel(c.ShadowModel)
+}
+
+// ForModel returns a copy of the config for modelString, or nil when it is
+// empty or the main model. Credentials carry over as in ForTask.
+func (c *ProviderConfig) ForModel(modelString string) *ProviderConfig {
+	if modelString == "" || modelString == c.ModelString {
+		return nil
+	}
+
+	copied := *c
+	copied.ModelString = modelString
+	mainProvider, _, _ := strings.Cut(c.ModelString, ":")
+	if provider, _, _ := strings.Cut(modelString, ":"); provider != mainProvider {
+		copied.ProviderAPIKey = ""
+		copied.ProviderURL = ""
+	}
+	return &copied
 }
 
 // ProviderResult contains the result of provider creation
 type ProviderResult struct {
-	Model   model.ToolCallingChatModel
-	Message string // Optional message for user feedback (e.g., GPU fallback info)
+	Model       model.ToolCallingChatModel
+	Message     string                // Optional message for user feedback (e.g., GPU fallback info)
+	Adjustments []ParameterAdjustment // Generation parameters dropped or changed for this model
+	Emulated    bool                  // Tool calls are emulated through the model's text output
+
+	// Release frees what the provider holds for the model, such as the
+	// llama-server started for it, once its owner is done with the model; nil
+	// when there is nothing to free
+	Release func()
 }
 
 // CreateProvider creates an eino ToolCallingChatModel based on the provider configuration
@@ -100,6 +204,10 @@ func CreateProvider(ctx context.Context, config *ProviderConfig) (*ProviderResul
 	provider := parts[0]
 	modelName := parts[1]
 
+	if err := ValidateToolEmulation(config.ToolEmulation); err != nil {
+		return nil, err
+	}
+
 	// Resolve model aliases before validation (for OAuth compatibility)
 	if provider == "anthropic" {
 		modelName = resolveModelAlias(provider, modelName)
@@ -108,9 +216,14 @@ func CreateProvider(ctx context.Context, config *ProviderConfig) (*ProviderResul
 	// Get the global registry for validation
 	registry := GetGlobalRegistry()
 
-	// Validate the model exists (skip for ollama as it's not in models.dev, and skip when using custom provider URL)
-	if provider != "ollama" && config.ProviderURL == "" {
-		modelInfo, err := registry.ValidateModel(provider, modelName)
+	// Validate the model exists (skip for ollama and llamacpp as they're not in models.dev, and skip when using custom provider URL)
+	// Models on OpenAI-compatible servers are whatever the server hosts; only
+	// those declared in the config file have an entry
+	var modelInfo *ModelInfo
+	if provider == CompatibleProvider {
+		modelInfo = compatibleModelInfo(modelName)
+	} else if !IsLocal(provider) && config.ProviderURL == "" {
+		info, err := registry.ValidateModel(provider, modelName)
 		if err != nil {
 			// Provide helpful suggestions
 			suggestions := registry.SuggestModels(provider, modelName)
@@ -120,17 +233,38 @@ func CreateProvider(ctx context.Context, config *ProviderConfig) (*ProviderResul
 			return nil, err
 		}
 
-		// Validate environment variables
-		if err := registry.ValidateEnvironment(provider, config.ProviderAPIKey); err != nil {
-			return nil, err
+		// Validate environment variables; Azure without a key uses Entra ID
+		if provider != "azure" {
+			if err := registry.ValidateEnvironment(provider, config.ProviderAPIKey); err != nil {
+				return nil, err
+			}
 		}
 
-		// Validate configuration parameters against model capabilities
-		if err := validateModelConfig(config, modelInfo); err != nil {
-			return nil, err
-		}
+		modelInfo = info
 	}
+	// Custom models have the entry declared for them wherever they are served
+	if modelInfo == nil {
+		modelInfo = customModelInfo(provider, modelName)
+	}
+
+	// Drop or adjust parameters the provider or model does not support
+	adjustments := gateParameters(config, provider, modelInfo)
+	adjustments = append(adjustments, gateThinking(config, provider, modelInfo)...)
+
+	result, err := createProvider(ctx, config, provider, modelName)
+	if err != nil {
+		return nil, err
+	}
+	result.Adjustments = adjustments
+	// Checked after creating the model, which may find it has no tool calls
+	if EmulatesTools(config) {
+		result.Model = emulate.Wrap(result.Model)
+		result.Emulated = true
+	}
+	return result, nil
+}
 
+func createProvider(ctx context.Context, config *ProviderConfig, provider, modelName string) (*ProviderResult, error) {
 	switch provider {
 	case "anthropic":
 		model, err := createAnthropicProvider(ctx, config, modelName)
@@ -152,38 +286,67 @@ func CreateProvider(ctx context.Context, config *ProviderConfig) (*ProviderResul
 		return &ProviderResult{Model: model, Message: ""}, nil
 	case "ollama":
 		return createOllamaProviderWithResult(ctx, config, modelName)
+	case LlamaCppProvider:
+		return createLlamaCppProvider(ctx, config, modelName)
 	case "azure":
 		model, err := createAzureOpenAIProvider(ctx, config, modelName)
 		if err != nil {
 			return nil, err
 		}
 		return &ProviderResult{Model: model, Message: ""}, nil
+	case "groq":
+		model, err := createGroqProvider(ctx, config, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return &ProviderResult{Model: model, Message: ""}, nil
+	case "xai":
+		model, err := createXAIProvider(ctx, config, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return &ProviderResult{Model: model, Message: ""}, nil
+	case "deepseek":
+		model, err := createDeepSeekProvider(ctx, config, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return &ProviderResult{Model: model, Message: ""}, nil
+	case CompatibleProvider:
+		model, err := createCompatibleProvider(ctx, config, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return &ProviderResult{Model: model, Message: ""}, nil
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 }
 
-// validateModelConfig validates configuration parameters against model capabilities
-func validateModelConfig(config *ProviderConfig, modelInfo *ModelInfo) error {
-	// Omit temperature if not supported by the model
-	if config.Temperature != nil && !modelInfo.Temperature {
-		config.Temperature = nil
-	}
-
-	// Warn about context limits if MaxTokens is set too high
-	if config.MaxTokens > modelInfo.Limit.Output {
-		return fmt.Errorf("max_tokens (%d) exceeds model's output limit (%d) for %s",
-			config.MaxTokens, modelInfo.Limit.Output, modelInfo.ID)
-	}
-
-	return nil
-}
-
 func createAzureOpenAIProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
 	apiKey := config.ProviderAPIKey
 	if apiKey == "" {
 		apiKey = os.Getenv("AZURE_OPENAI_API_KEY")
 	}
+
+	// Microsoft Entra ID is used when asked for, or by default without a key
+	var credential *auth.AzureCredential
+	var err error
+	switch config.AzureAuth {
+	case auth.AzureAuthKey:
+	case "", auth.AzureAuthAuto:
+		if apiKey == "" {
+			credential, err = auth.NewAzureCredential(auth.AzureAuthAuto)
+		}
+	default:
+		credential, err = auth.NewAzureCredential(config.AzureAuth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if credential != nil {
+		apiKey = "entra-id-placeholder" // The library wants a key; the transport replaces it
+	}
 	if apiKey == "" {
 		return nil, fmt.Errorf("Azure OpenAI API key not provided. Use --provider-api-key flag or AZURE_OPENAI_API_KEY environment variable")
 	}
@@ -220,12 +383,12 @@ func createAzureOpenAIProvider(ctx context.Context, config *ProviderConfig, mode
 		azureConfig.Stop = config.StopSequences
 	}
 
-	// Set HTTP client with TLS config if needed
-	if config.TLSSkipVerify {
-		azureConfig.HTTPClient = createHTTPClientWithTLSConfig(true)
+	azureConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
+	if credential != nil {
+		azureConfig.HTTPClient.Transport = &azureTokenTransport{base: azureConfig.HTTPClient.Transport, credential: credential}
 	}
 
-	return openai.NewCustomChatModel(ctx, azureConfig)
+	return openai.NewCustomChatModel(ctx, azureConfig, config.ParallelToolCalls)
 }
 
 func createAnthropicProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
@@ -255,16 +418,13 @@ func createAnthropicProvider(ctx context.Context, config *ProviderConfig, modelN
 	if strings.HasPrefix(source, "stored OAuth") {
 		// For OAuth tokens, we need to use Authorization: Bearer header
 		// Create a custom HTTP client that adds the proper headers
-		claudeConfig.HTTPClient = createOAuthHTTPClient(apiKey, config.TLSSkipVerify)
+		claudeConfig.HTTPClient = createOAuthHTTPClient(apiKey, config)
 		// Set a dummy API key to prevent the library from failing validation
 		claudeConfig.APIKey = "oauth-placeholder"
 	} else {
 		// For API keys, use the standard x-api-key header
 		claudeConfig.APIKey = apiKey
-		// Set HTTP client with TLS config if needed
-		if config.TLSSkipVerify {
-			claudeConfig.HTTPClient = createHTTPClientWithTLSConfig(true)
-		}
+		claudeConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
 	}
 
 	if config.ProviderURL != "" {
@@ -287,7 +447,11 @@ func createAnthropicProvider(ctx context.Context, config *ProviderConfig, modelN
 		claudeConfig.StopSequences = config.StopSequences
 	}
 
-	return anthropic.NewCustomChatModel(ctx, claudeConfig)
+	if config.ThinkingBudget > 0 {
+		claudeConfig.Thinking = &einoclaude.Thinking{Enable: true, BudgetTokens: config.ThinkingBudget}
+	}
+
+	return anthropic.NewCustomChatModel(ctx, claudeConfig, config.ParallelToolCalls, config.UploadThreshold, !config.NoPromptCache)
 }
 
 func createOpenAIProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
@@ -308,10 +472,7 @@ func createOpenAIProvider(ctx context.Context, config *ProviderConfig, modelName
 		openaiConfig.BaseURL = config.ProviderURL
 	}
 
-	// Set HTTP client with TLS config if needed
-	if config.TLSSkipVerify {
-		openaiConfig.HTTPClient = createHTTPClientWithTLSConfig(true)
-	}
+	openaiConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
 
 	// Check if this is a reasoning model to handle beta limitations (skip validation if using custom URL)
 	registry := GetGlobalRegistry()
@@ -335,22 +496,174 @@ func createOpenAIProvider(ctx context.Context, config *ProviderConfig, modelName
 		}
 	}
 
-	// For reasoning models, skip temperature and top_p due to beta limitations
-	if !isReasoningModel {
-		if config.Temperature != nil {
-			openaiConfig.Temperature = config.Temperature
-		}
+	// Reasoning models reject temperature and top_p; gateParameters clears them
+	if config.Temperature != nil {
+		openaiConfig.Temperature = config.Temperature
+	}
 
-		if config.TopP != nil {
-			openaiConfig.TopP = config.TopP
-		}
+	if config.TopP != nil {
+		openaiConfig.TopP = config.TopP
 	}
 
 	if len(config.StopSequences) > 0 {
 		openaiConfig.Stop = config.StopSequences
 	}
 
-	return openai.NewCustomChatModel(ctx, openaiConfig)
+	if isReasoningModel && config.ThinkingBudget > 0 {
+		openaiConfig.ReasoningEffort = reasoningEffort(config.ThinkingBudget)
+	}
+
+	return openai.NewCustomChatModel(ctx, openaiConfig, config.ParallelToolCalls)
+}
+
+// reasoningEffort maps a thinking budget to OpenAI's reasoning effort, which
+// has no token count
+func reasoningEffort(budget int) einoopenai.ReasoningEffortLevel {
+	switch {
+	case budget <= 4096:
+		return einoopenai.ReasoningEffortLevelLow
+	case budget <= 16384:
+		return einoopenai.ReasoningEffortLevelMedium
+	}
+	return einoopenai.ReasoningEffortLevelHigh
+}
+
+// groqBaseURL is Groq's OpenAI-compatible API
+const groqBaseURL = "https://api.groq.com/openai/v1"
+
+// createGroqProvider creates a model on Groq's OpenAI-compatible API. Groq's
+// rate limits are tight, especially on the free tier, so its requests wait
+// for the token budget to reset instead of failing the run.
+func createGroqProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
+	apiKey := config.ProviderAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GROQ_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("Groq API key not provided. Use --provider-api-key flag or GROQ_API_KEY environment variable")
+	}
+
+	groqConfig := &einoopenai.ChatModelConfig{
+		APIKey:  apiKey,
+		Model:   modelName,
+		BaseURL: groqBaseURL,
+	}
+
+	if config.ProviderURL != "" {
+		groqConfig.BaseURL = config.ProviderURL
+	}
+
+	httpClient := createHTTPClientWithTLSConfig(config)
+	httpClient.Transport = newRateLimitTransport(httpClient.Transport)
+	groqConfig.HTTPClient = httpClient
+
+	if config.MaxTokens > 0 {
+		groqConfig.MaxTokens = &config.MaxTokens
+	}
+
+	if config.Temperature != nil {
+		groqConfig.Temperature = config.Temperature
+	}
+
+	if config.TopP != nil {
+		groqConfig.TopP = config.TopP
+	}
+
+	if len(config.StopSequences) > 0 {
+		groqConfig.Stop = config.StopSequences
+	}
+
+	return openai.NewCustomChatModel(ctx, groqConfig, config.ParallelToolCalls)
+}
+
+// xaiBaseURL is xAI's OpenAI-compatible API
+const xaiBaseURL = "https://api.x.ai/v1"
+
+// createXAIProvider creates a Grok model on xAI's OpenAI-compatible API
+func createXAIProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
+	apiKey := config.ProviderAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("XAI_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("xAI API key not provided. Use --provider-api-key flag or XAI_API_KEY environment variable")
+	}
+
+	xaiConfig := &einoopenai.ChatModelConfig{
+		APIKey:  apiKey,
+		Model:   modelName,
+		BaseURL: xaiBaseURL,
+	}
+
+	if config.ProviderURL != "" {
+		xaiConfig.BaseURL = config.ProviderURL
+	}
+
+	xaiConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
+
+	if config.MaxTokens > 0 {
+		xaiConfig.MaxTokens = &config.MaxTokens
+	}
+
+	if config.Temperature != nil {
+		xaiConfig.Temperature = config.Temperature
+	}
+
+	if config.TopP != nil {
+		xaiConfig.TopP = config.TopP
+	}
+
+	if len(config.StopSequences) > 0 {
+		xaiConfig.Stop = config.StopSequences
+	}
+
+	return openai.NewCustomChatModel(ctx, xaiConfig, config.ParallelToolCalls)
+}
+
+// deepseekBaseURL is DeepSeek's OpenAI-compatible API
+const deepseekBaseURL = "https://api.deepseek.com/v1"
+
+// createDeepSeekProvider creates a model on DeepSeek's OpenAI-compatible API.
+// deepseek-reasoner's reasoning_content comes back as the response's
+// ReasoningContent, which the agent shows and leaves out of the history.
+func createDeepSeekProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
+	apiKey := config.ProviderAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("DEEPSEEK_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("DeepSeek API key not provided. Use --provider-api-key flag or DEEPSEEK_API_KEY environment variable")
+	}
+
+	deepseekConfig := &einoopenai.ChatModelConfig{
+		APIKey:  apiKey,
+		Model:   modelName,
+		BaseURL: deepseekBaseURL,
+	}
+
+	if config.ProviderURL != "" {
+		deepseekConfig.BaseURL = config.ProviderURL
+	}
+
+	deepseekConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
+
+	if config.MaxTokens > 0 {
+		deepseekConfig.MaxTokens = &config.MaxTokens
+	}
+
+	if config.Temperature != nil {
+		deepseekConfig.Temperature = config.Temperature
+	}
+
+	if config.TopP != nil {
+		deepseekConfig.TopP = config.TopP
+	}
+
+	if len(config.StopSequences) > 0 {
+		deepseekConfig.Stop = config.StopSequences
+	}
+
+	return openai.NewCustomChatModel(ctx, deepseekConfig, config.ParallelToolCalls)
 }
 
 func createGoogleProvider(ctx context.Context, config *ProviderConfig, modelName string) (model.ToolCallingChatModel, error) {
@@ -373,10 +686,7 @@ func createGoogleProvider(ctx context.Context, config *ProviderConfig, modelName
 		Backend: genai.BackendGeminiAPI,
 	}
 
-	// Set HTTP client with TLS config if needed
-	if config.TLSSkipVerify {
-		clientConfig.HTTPClient = createHTTPClientWithTLSConfig(true)
-	}
+	clientConfig.HTTPClient = createHTTPClientWithTLSConfig(config)
 
 	client, err := genai.NewClient(ctx, clientConfig)
 	if err != nil {
@@ -414,8 +724,7 @@ type OllamaLoadingResult struct {
 }
 
 // loadOllamaModelWithFallback loads an Ollama model with GPU settings and automatic CPU fallback
-func loadOllamaModelWithFallback(ctx context.Context, baseURL, modelName string, options *api.Options, tlsSkipVerify bool) (*OllamaLoadingResult, error) {
-	client := createHTTPClientWithTLSConfig(tlsSkipVerify)
+func loadOllamaModelWithFallback(ctx context.Context, client *http.Client, baseURL, modelName string, options *api.Options, keepAlive *time.Duration) (*OllamaLoadingResult, error) {
 
 	// Phase 1: Check if model exists locally
 	if err := checkOllamaModelExists(client, baseURL, modelName); err != nil {
@@ -426,14 +735,14 @@ func loadOllamaModelWithFallback(ctx context.Context, baseURL, modelName string,
 	}
 
 	// Phase 3: Load model with GPU settings
-	_, err := loadOllamaModelWithOptions(ctx, client, baseURL, modelName, options)
+	_, err := loadOllamaModelWithOptions(ctx, client, baseURL, modelName, options, keepAlive)
 	if err != nil {
 		// Phase 4: Fallback to CPU if GPU memory insufficient
 		if isGPUMemoryError(err) {
 			cpuOptions := *options
 			cpuOptions.NumGPU = 0
 
-			_, cpuErr := loadOllamaModelWithOptions(ctx, client, baseURL, modelName, &cpuOptions)
+			_, cpuErr := loadOllamaModelWithOptions(ctx, client, baseURL, modelName, &cpuOptions, keepAlive)
 			if cpuErr != nil {
 				return nil, fmt.Errorf("failed to load model on GPU (%v) and CPU fallback failed (%v)", err, cpuErr)
 			}
@@ -479,6 +788,38 @@ func checkOllamaModelExists(client *http.Client, baseURL, modelName string) erro
 	return nil
 }
 
+// ollamaCapabilities returns the capabilities Ollama reports for a model,
+// such as "completion", "tools" and "vision"
+func ollamaCapabilities(ctx context.Context, client *http.Client, baseURL, modelName string) ([]string, error) {
+	jsonBody, _ := json.Marshal(map[string]string{"model": modelName})
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/show", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("model not found")
+	}
+	var show struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, err
+	}
+	return show.Capabilities, nil
+}
+
 // pullOllamaModel pulls a model from the registry
 func pullOllamaModel(ctx context.Context, client *http.Client, baseURL, modelName string) error {
 	return pullOllamaModelWithProgress(ctx, client, baseURL, modelName, true)
@@ -521,8 +862,10 @@ func pullOllamaModelWithProgress(ctx context.Context, client *http.Client, baseU
 	return err
 }
 
-// loadOllamaModelWithOptions loads a model with specific options using a warmup request
-func loadOllamaModelWithOptions(ctx context.Context, client *http.Client, baseURL, modelName string, options *api.Options) (*api.Options, error) {
+// loadOllamaModelWithOptions loads a model with specific options using a warmup request.
+// The warmup uses the context size and keep-alive of the chat requests, since
+// Ollama reloads a model whose context size changes.
+func loadOllamaModelWithOptions(ctx context.Context, client *http.Client, baseURL, modelName string, options *api.Options, keepAlive *time.Duration) (*api.Options, error) {
 	// Create a copy of options for warmup to avoid modifying the original
 	warmupOptions := *options
 	warmupOptions.NumPredict = 1 // Limit response length for warmup
@@ -533,6 +876,9 @@ func loadOllamaModelWithOptions(ctx context.Context, client *http.Client, baseUR
 		"stream":  false,
 		"options": &warmupOptions,
 	}
+	if keepAlive != nil {
+		reqBody["keep_alive"] = api.Duration{Duration: *keepAlive}
+	}
 
 	jsonBody, _ := json.Marshal(reqBody)
 
@@ -586,6 +932,40 @@ func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return t.base.RoundTrip(newReq)
 }
 
+// azureTokenTransport authenticates Azure OpenAI requests with a Microsoft
+// Entra ID token in place of the API key, refreshed before it expires
+type azureTokenTransport struct {
+	base       http.RoundTripper
+	credential *auth.AzureCredential
+}
+
+func (t *azureTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.credential.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	newReq := req.Clone(req.Context())
+	newReq.Header.Del("api-key")
+	newReq.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(newReq)
+}
+
+// turnTransport sends the turn ID of a request's context in the turn.Header
+// header, so provider-side logs can be matched to the turn
+type turnTransport struct {
+	base http.RoundTripper
+}
+
+func (t *turnTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := turn.ID(req.Context())
+	if id == "" {
+		return t.base.RoundTrip(req)
+	}
+	newReq := req.Clone(req.Context())
+	newReq.Header.Set(turn.Header, id)
+	return t.base.RoundTrip(newReq)
+}
+
 func createOllamaProviderWithResult(ctx context.Context, config *ProviderConfig, modelName string) (*ProviderResult, error) {
 	baseURL := "http://localhost:11434" // Default Ollama URL
 
@@ -631,13 +1011,31 @@ func createOllamaProviderWithResult(ctx context.Context, config *ProviderConfig,
 		options.MainGPU = int(*config.MainGPU)
 	}
 
+	if config.NumCtx > 0 {
+		options.NumCtx = config.NumCtx
+	}
+
+	if config.NumThread > 0 {
+		options.NumThread = config.NumThread
+	}
+
 	// Create a clean copy of options for the final model
 	finalOptions := &api.Options{}
 	*finalOptions = *options // Copy all fields
 
+	httpClient := createHTTPClientWithTLSConfig(config)
+	if config.ProviderAPIKey != "" {
+		httpClient.Transport = &bearerTransport{
+			base:  httpClient.Transport,
+			token: config.ProviderAPIKey,
+		}
+	}
+	// Lets a request's context give it a JSON schema as its format
+	httpClient.Transport = &ollamaFormatTransport{base: httpClient.Transport}
+
 	// Try to pre-load the model with GPU settings and automatic CPU fallback
 	// If this fails, fall back to the original behavior
-	loadingResult, err := loadOllamaModelWithFallback(ctx, baseURL, modelName, options, config.TLSSkipVerify)
+	loadingResult, err := loadOllamaModelWithFallback(ctx, httpClient, baseURL, modelName, options, config.KeepAlive)
 	var loadingMessage string
 
 	if err != nil {
@@ -650,26 +1048,18 @@ func createOllamaProviderWithResult(ctx context.Context, config *ProviderConfig,
 		loadingMessage = loadingResult.Message
 	}
 
-	ollamaConfig := &ollama.ChatModelConfig{
-		BaseURL: baseURL,
-		Model:   modelName,
-		Options: finalOptions,
-	}
-
-	if config.ProviderAPIKey != "" {
-		transport := http.DefaultTransport
-		authTransport := &bearerTransport{
-			base:  transport,
-			token: config.ProviderAPIKey,
-		}
-		ollamaConfig.HTTPClient = &http.Client{
-			Transport: authTransport,
-		}
+	// Models without the tools capability get their tool calls emulated;
+	// servers too old to report capabilities are assumed to have it
+	if capabilities, err := ollamaCapabilities(ctx, httpClient, baseURL, modelName); err == nil && len(capabilities) > 0 {
+		withoutNativeTools["ollama:"+modelName] = !slices.Contains(capabilities, "tools")
 	}
 
-	// Set HTTP client with TLS config if needed
-	if config.TLSSkipVerify {
-		ollamaConfig.HTTPClient = createHTTPClientWithTLSConfig(true)
+	ollamaConfig := &ollama.ChatModelConfig{
+		BaseURL:    baseURL,
+		Model:      modelName,
+		Options:    finalOptions,
+		HTTPClient: httpClient,
+		KeepAlive:  config.KeepAlive,
 	}
 
 	chatModel, err := ollama.NewChatModel(ctx, ollamaConfig)
@@ -678,43 +1068,52 @@ func createOllamaProviderWithResult(ctx context.Context, config *ProviderConfig,
 	}
 
 	return &ProviderResult{
-		Model:   chatModel,
+		Model:   constrainArguments(chatModel),
 		Message: loadingMessage,
 	}, nil
 }
 
-// createHTTPClientWithTLSConfig creates an HTTP client with optional TLS skip verify
-func createHTTPClientWithTLSConfig(skipVerify bool) *http.Client {
-	if !skipVerify {
-		return &http.Client{}
+// createHTTPClientWithTLSConfig creates the HTTP client for a provider, applying
+// TLS skip verify and the configured timeouts and sending the turn ID
+func createHTTPClientWithTLSConfig(config *ProviderConfig) *http.Client {
+	return &http.Client{
+		Transport: &turnTransport{base: createProviderTransport(config)},
+	}
+}
+
+// createProviderTransport builds the round tripper shared by all providers. No
+// http.Client timeout is used, as that would also cut off long streams.
+func createProviderTransport(config *ProviderConfig) http.RoundTripper {
+	if !config.TLSSkipVerify && config.ConnectTimeout <= 0 && config.RequestTimeout <= 0 {
+		return http.DefaultTransport
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.TLSSkipVerify {
+		transport.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: true,
-		},
+		}
+	}
+	if config.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   config.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		transport.TLSHandshakeTimeout = config.ConnectTimeout
 	}
 
-	return &http.Client{
-		Transport: transport,
+	if config.RequestTimeout > 0 {
+		return &timeoutTransport{base: transport, timeout: config.RequestTimeout}
 	}
+	return transport
 }
 
 // createOAuthHTTPClient creates an HTTP client that adds OAuth headers for Anthropic API
-func createOAuthHTTPClient(accessToken string, skipVerify bool) *http.Client {
-	var base http.RoundTripper = http.DefaultTransport
-	if skipVerify {
-		base = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
-	}
-
+func createOAuthHTTPClient(accessToken string, config *ProviderConfig) *http.Client {
 	return &http.Client{
 		Transport: &oauthTransport{
 			accessToken: accessToken,
-			base:        base,
+			base:        &turnTransport{base: createProviderTransport(config)},
 		},
 	}
 }
@@ -734,7 +1133,12 @@ func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	// Add OAuth headers as required by Anthropic's OAuth API
 	newReq.Header.Set("Authorization", "Bearer "+t.accessToken)
-	newReq.Header.Set("anthropic-beta", "oauth-2025-04-20")
+	// Betas the request asks for itself, such as the Files API's, are kept
+	if beta := req.Header.Get("anthropic-beta"); beta != "" {
+		newReq.Header.Set("anthropic-beta", "oauth-2025-04-20,"+beta)
+	} else {
+		newReq.Header.Set("anthropic-beta", "oauth-2025-04-20")
+	}
 	newReq.Header.Set("anthropic-version", "2023-06-01")
 
 	// Inject Claude Code system prompt for /v1/messages endpoint
@@ -1,8 +1,17 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/osi4iot/mcphost/internal/turn"
 )
 
 func TestCreateHTTPClientWithTLSConfig(t *testing.T) {
@@ -25,7 +34,7 @@ func TestCreateHTTPClientWithTLSConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := createHTTPClientWithTLSConfig(tt.skipVerify)
+			client := createHTTPClientWithTLSConfig(&ProviderConfig{TLSSkipVerify: tt.skipVerify})
 
 			if client == nil {
 				t.Fatal("expected non-nil client")
@@ -33,7 +42,11 @@ func TestCreateHTTPClientWithTLSConfig(t *testing.T) {
 
 			// Check if the client has a custom transport when skipVerify is true
 			if tt.skipVerify {
-				transport, ok := client.Transport.(*http.Transport)
+				turns, ok := client.Transport.(*turnTransport)
+				if !ok {
+					t.Fatal("expected *turnTransport")
+				}
+				transport, ok := turns.base.(*http.Transport)
 				if !ok {
 					t.Fatal("expected *http.Transport when skipVerify is true")
 				}
@@ -74,7 +87,7 @@ func TestCreateOAuthHTTPClient(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := createOAuthHTTPClient(tt.accessToken, tt.skipVerify)
+			client := createOAuthHTTPClient(tt.accessToken, &ProviderConfig{TLSSkipVerify: tt.skipVerify})
 
 			if client == nil {
 				t.Fatal("expected non-nil client")
@@ -92,7 +105,11 @@ func TestCreateOAuthHTTPClient(t *testing.T) {
 
 			// Check the base transport when skipVerify is true
 			if tt.skipVerify {
-				baseTransport, ok := oauthTransport.base.(*http.Transport)
+				turns, ok := oauthTransport.base.(*turnTransport)
+				if !ok {
+					t.Fatal("expected base transport to be *turnTransport")
+				}
+				baseTransport, ok := turns.base.(*http.Transport)
 				if !ok {
 					t.Fatal("expected base transport to be *http.Transport when skipVerify is true")
 				}
@@ -110,6 +127,33 @@ func TestCreateOAuthHTTPClient(t *testing.T) {
 	}
 }
 
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTurnTransport(t *testing.T) {
+	var got string
+	transport := &turnTransport{base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Get(turn.Header)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://provider.invalid/v1/messages", nil)
+	transport.RoundTrip(req)
+	if got != "" {
+		t.Errorf("expected no turn header outside a turn, got %q", got)
+	}
+
+	transport.RoundTrip(req.WithContext(turn.WithID(context.Background(), "turn_abc")))
+	if got != "turn_abc" {
+		t.Errorf("expected turn header %q, got %q", "turn_abc", got)
+	}
+	if req.Header.Get(turn.Header) != "" {
+		t.Error("expected the caller's request to be left unchanged")
+	}
+}
+
 func TestProviderConfigTLSSkipVerify(t *testing.T) {
 	// Test that ProviderConfig properly stores TLSSkipVerify
 	config := &ProviderConfig{
@@ -121,3 +165,86 @@ func TestProviderConfigTLSSkipVerify(t *testing.T) {
 		t.Error("expected TLSSkipVerify to be true")
 	}
 }
+
+func TestProviderConfigForTask(t *testing.T) {
+	config := &ProviderConfig{
+		ModelString:    "anthropic:claude-sonnet-4-20250514",
+		ProviderAPIKey: "sk-main",
+		ProviderURL:    "https://proxy.example.com",
+		MaxTokens:      4096,
+		TaskModels: map[string]string{
+			"default":   "anthropic:claude-sonnet-4-20250514",
+			"summarize": "ollama:qwen2.5:3b",
+		},
+	}
+
+	if got := config.ForTask("default"); got != nil {
+		t.Errorf("expected nil for a task using the main model, got %q", got.ModelString)
+	}
+	if got := config.ForTask("unconfigured"); got != nil {
+		t.Errorf("expected nil for an unconfigured task, got %q", got.ModelString)
+	}
+
+	summarize := config.ForTask("summarize")
+	if summarize == nil || summarize.ModelString != "ollama:qwen2.5:3b" {
+		t.Fatalf("unexpected summarize config: %+v", summarize)
+	}
+	if summarize.ProviderAPIKey != "" || summarize.ProviderURL != "" {
+		t.Error("credentials should not carry over to a different provider")
+	}
+	if summarize.MaxTokens != 4096 {
+		t.Errorf("MaxTokens = %d, want 4096", summarize.MaxTokens)
+	}
+
+	config.TaskModels["summarize"] = "anthropic:claude-3-5-haiku-latest"
+	summarize = config.ForTask("summarize")
+	if summarize == nil || summarize.ProviderAPIKey != "sk-main" || summarize.ProviderURL != "https://proxy.example.com" {
+		t.Errorf("credentials should carry over for the same provider: %+v", summarize)
+	}
+	if config.ModelString != "anthropic:claude-sonnet-4-20250514" {
+		t.Error("ForTask modified the original config")
+	}
+
+	config.RouteModels = map[string]string{"tools": "ollama:qwen2.5"}
+	if tools := config.ForRoute("tools"); tools == nil || tools.ModelString != "ollama:qwen2.5" || tools.ProviderAPIKey != "" {
+		t.Errorf("unexpected tools route config: %+v", tools)
+	}
+	if got := config.ForRoute("final"); got != nil {
+		t.Errorf("expected nil for a step using the main model, got %q", got.ModelString)
+	}
+}
+
+func TestOllamaWarmupOptions(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/generate" {
+			body = nil
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	keepAlive := 30 * time.Minute
+	options := &api.Options{Runner: api.Runner{NumCtx: 32768, NumThread: 8}}
+	if _, err := loadOllamaModelWithFallback(context.Background(), server.Client(), server.URL, "qwen2.5", options, &keepAlive); err != nil {
+		t.Fatal(err)
+	}
+	if body["keep_alive"] != "30m0s" {
+		t.Errorf("expected the warmup to keep the model loaded for 30m, got %v", body["keep_alive"])
+	}
+	sent, _ := body["options"].(map[string]interface{})
+	if sent["num_ctx"] != float64(32768) || sent["num_thread"] != float64(8) {
+		t.Errorf("expected the warmup to use the context size and threads, got %v", sent)
+	}
+
+	forever := -time.Second
+	loadOllamaModelWithFallback(context.Background(), server.Client(), server.URL, "qwen2.5", options, &forever)
+	if body["keep_alive"] != float64(-1) {
+		t.Errorf("expected a negative keep-alive to keep the model loaded, got %v", body["keep_alive"])
+	}
+	loadOllamaModelWithFallback(context.Background(), server.Client(), server.URL, "qwen2.5", options, nil)
+	if _, ok := body["keep_alive"]; ok {
+		t.Errorf("expected Ollama's default keep-alive without one, got %v", body["keep_alive"])
+	}
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/tokens"
+)
+
+// Defaults for rateLimitTransport
+const (
+	rateLimitRetries = 5               // retries of a request rejected with 429
+	rateLimitMaxWait = 2 * time.Minute // longer waits fail instead, e.g. daily limits
+)
+
+// rateLimitTransport keeps requests within the rate limits a provider reports
+// in its x-ratelimit-* response headers, so a long agentic run slows down
+// instead of failing midway. A request expected to need more tokens than the
+// current minute has left waits for the token budget to reset, and a request
+// rejected with 429 is retried after the wait the provider asks for.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	retries    int
+	maxWait    time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+	mu         sync.Mutex
+	limits     rateLimits
+	haveLimits bool
+}
+
+// rateLimits is the budget left according to the last response
+type rateLimits struct {
+	remainingTokens   int
+	tokensReset       time.Time
+	remainingRequests int
+	requestsReset     time.Time
+}
+
+// newRateLimitTransport returns base kept within the provider's rate limits
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:    base,
+		retries: rateLimitRetries,
+		maxWait: rateLimitMaxWait,
+		sleep:   sleepContext,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	estimate := tokens.EstimateTokens(string(body))
+
+	for attempt := 0; ; attempt++ {
+		// Retries have already waited for what the 429 asked
+		if wait := t.budgetWait(estimate, time.Now()); attempt == 0 && wait > 0 && wait <= t.maxWait {
+			if err := t.sleep(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptReq := req.Clone(req.Context())
+		if req.Body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.ContentLength = int64(len(body))
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resp.Header, time.Now())
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= t.retries {
+			return resp, nil
+		}
+		wait := retryWait(resp.Header, attempt)
+		if wait > t.maxWait {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// budgetWait returns how long a request of estimate tokens should wait for
+// the budget to reset, or 0 when it can go now
+func (t *rateLimitTransport) budgetWait(estimate int, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.haveLimits {
+		return 0
+	}
+	var wait time.Duration
+	if t.limits.remainingRequests == 0 {
+		wait = t.limits.requestsReset.Sub(now)
+	}
+	if estimate > t.limits.remainingTokens {
+		wait = max(wait, t.limits.tokensReset.Sub(now))
+	}
+	return max(wait, 0)
+}
+
+// update records the budget reported in a response's headers
+func (t *rateLimitTransport) update(header http.Header, now time.Time) {
+	remainingTokens, tokensOK := headerInt(header, "x-ratelimit-remaining-tokens")
+	remainingRequests, requestsOK := headerInt(header, "x-ratelimit-remaining-requests")
+	if !tokensOK && !requestsOK {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.haveLimits = true
+	t.limits = rateLimits{remainingTokens: remainingTokens, remainingRequests: remainingRequests}
+	if !tokensOK {
+		t.limits.remainingTokens = math.MaxInt
+	}
+	if !requestsOK {
+		t.limits.remainingRequests = -1
+	}
+	if d, ok := headerDuration(header, "x-ratelimit-reset-tokens"); ok {
+		t.limits.tokensReset = now.Add(d)
+	}
+	if d, ok := headerDuration(header, "x-ratelimit-reset-requests"); ok {
+		t.limits.requestsReset = now.Add(d)
+	}
+}
+
+// retryWait returns how long to wait before retrying a request rejected with
+// 429: the retry-after header, else the reset of the exhausted budget, else
+// an exponential backoff
+func retryWait(header http.Header, attempt int) time.Duration {
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(header.Get("retry-after")), 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if remaining, ok := headerInt(header, "x-ratelimit-remaining-requests"); ok && remaining == 0 {
+		if d, ok := headerDuration(header, "x-ratelimit-reset-requests"); ok {
+			return d
+		}
+	}
+	if d, ok := headerDuration(header, "x-ratelimit-reset-tokens"); ok {
+		return d
+	}
+	return time.Second << attempt
+}
+
+// headerInt reads an integer header
+func headerInt(header http.Header, name string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	return n, err == nil
+}
+
+// headerDuration reads a duration header such as "7.66s" or "2m59.56s"
+func headerDuration(header http.Header, name string) (time.Duration, bool) {
+	d, err := time.ParseDuration(strings.TrimSpace(header.Get(name)))
+	return d, err == nil && d >= 0
+}
+
+// sleepContext sleeps for d, unless ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
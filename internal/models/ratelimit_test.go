@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// limitedAPI answers with the given responses in turn, recording the bodies
+// it received
+type limitedAPI struct {
+	responses []*http.Response
+	bodies    []string
+}
+
+func (api *limitedAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	api.bodies = append(api.bodies, string(body))
+	if len(api.bodies) > len(api.responses) {
+		return nil, errors.New("no response left")
+	}
+	return api.responses[len(api.bodies)-1], nil
+}
+
+func limitedResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("{}"))}
+	for name, value := range headers {
+		resp.Header.Set(name, value)
+	}
+	return resp
+}
+
+// newTestRateLimitTransport returns a transport over api that records its
+// waits instead of sleeping
+func newTestRateLimitTransport(api http.RoundTripper) (*rateLimitTransport, *[]time.Duration) {
+	var waits []time.Duration
+	t := newRateLimitTransport(api)
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return t, &waits
+}
+
+func post(t *testing.T, rt http.RoundTripper, ctx context.Context, body string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.groq.com/openai/v1/chat/completions", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rt.RoundTrip(req)
+}
+
+func TestRateLimitRetriesAfter429(t *testing.T) {
+	api := &limitedAPI{responses: []*http.Response{
+		limitedResponse(http.StatusTooManyRequests, map[string]string{"retry-after": "3"}),
+		limitedResponse(http.StatusTooManyRequests, map[string]string{"x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "1.5s"}),
+		limitedResponse(http.StatusOK, nil),
+	}}
+	rt, waits := newTestRateLimitTransport(api)
+
+	resp, err := post(t, rt, context.Background(), `{"model":"llama"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the retried request to succeed, got %d", resp.StatusCode)
+	}
+	if len(api.bodies) != 3 || api.bodies[2] != `{"model":"llama"}` {
+		t.Errorf("expected the body resent on every attempt, got %q", api.bodies)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 3*time.Second || (*waits)[1] != 1500*time.Millisecond {
+		t.Errorf("expected waits of retry-after then the token reset, got %v", *waits)
+	}
+}
+
+func TestRateLimitWaitsForTokenBudget(t *testing.T) {
+	api := &limitedAPI{responses: []*http.Response{
+		limitedResponse(http.StatusOK, map[string]string{"x-ratelimit-remaining-tokens": "100", "x-ratelimit-reset-tokens": "20s"}),
+		limitedResponse(http.StatusOK, nil),
+		limitedResponse(http.StatusOK, nil),
+	}}
+	rt, waits := newTestRateLimitTransport(api)
+
+	post(t, rt, context.Background(), "small")
+	post(t, rt, context.Background(), "small")
+	if len(*waits) != 0 {
+		t.Fatalf("expected requests within the budget to go at once, got waits %v", *waits)
+	}
+
+	// The last response reported no limits, so the earlier budget still holds
+	rt.update(http.Header{"X-Ratelimit-Remaining-Tokens": {"100"}, "X-Ratelimit-Reset-Tokens": {"20s"}}, time.Now())
+	post(t, rt, context.Background(), strings.Repeat("x", 4000))
+	if len(*waits) != 1 || (*waits)[0] <= 19*time.Second || (*waits)[0] > 20*time.Second {
+		t.Errorf("expected a request over the budget to wait for its reset, got %v", *waits)
+	}
+}
+
+func TestRateLimitGivesUpOnLongWaits(t *testing.T) {
+	api := &limitedAPI{responses: []*http.Response{
+		limitedResponse(http.StatusTooManyRequests, map[string]string{"retry-after": "3600"}),
+	}}
+	rt, waits := newTestRateLimitTransport(api)
+
+	resp, err := post(t, rt, context.Background(), "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || len(*waits) != 0 {
+		t.Errorf("expected an hour's wait to fail at once, got %d after waits %v", resp.StatusCode, *waits)
+	}
+}
+
+func TestRateLimitStopsWithContext(t *testing.T) {
+	api := &limitedAPI{responses: []*http.Response{
+		limitedResponse(http.StatusTooManyRequests, map[string]string{"retry-after": "30"}),
+	}}
+	rt := newRateLimitTransport(api)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := post(t, rt, ctx, "{}"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RegistryCacheTTL is how long the cached models.dev data is used before it
+// is refreshed
+const RegistryCacheTTL = 7 * 24 * time.Hour
+
+// modelsDevURL is where the model registry comes from, overridden in tests
+var modelsDevURL = "https://models.dev/api.json"
+
+// modelsDevProvider is a provider as models.dev describes it
+type modelsDevProvider struct {
+	ID     string                    `json:"id"`
+	Env    []string                  `json:"env"`
+	NPM    string                    `json:"npm"`
+	Name   string                    `json:"name"`
+	Models map[string]modelsDevModel `json:"models"`
+}
+
+// modelsDevModel is a model as models.dev describes it
+type modelsDevModel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Attachment  bool   `json:"attachment"`
+	Reasoning   bool   `json:"reasoning"`
+	Temperature bool   `json:"temperature"`
+	Cost        struct {
+		Input      float64  `json:"input"`
+		Output     float64  `json:"output"`
+		CacheRead  *float64 `json:"cache_read"`
+		CacheWrite *float64 `json:"cache_write"`
+	} `json:"cost"`
+	Limit struct {
+		Context int `json:"context"`
+		Output  int `json:"output"`
+	} `json:"limit"`
+}
+
+// parseModelsDev reads models.dev's api.json
+func parseModelsDev(data []byte) (map[string]ProviderInfo, error) {
+	var listed map[string]modelsDevProvider
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, fmt.Errorf("unexpected models.dev data: %w", err)
+	}
+	if len(listed) == 0 {
+		return nil, fmt.Errorf("models.dev listed no providers")
+	}
+	providers := make(map[string]ProviderInfo, len(listed))
+	for id, p := range listed {
+		info := ProviderInfo{ID: p.ID, Env: p.Env, NPM: p.NPM, Name: p.Name, Models: make(map[string]ModelInfo, len(p.Models))}
+		for modelID, m := range p.Models {
+			info.Models[modelID] = ModelInfo{
+				ID:          m.ID,
+				Name:        m.Name,
+				Attachment:  m.Attachment,
+				Reasoning:   m.Reasoning,
+				Temperature: m.Temperature,
+				Cost:        Cost{Input: m.Cost.Input, Output: m.Cost.Output, CacheRead: m.Cost.CacheRead, CacheWrite: m.Cost.CacheWrite},
+				Limit:       Limit{Context: m.Limit.Context, Output: m.Limit.Output},
+			}
+		}
+		providers[id] = info
+	}
+	return providers, nil
+}
+
+// UpdateModelsCache downloads the latest models.dev data to the cache file at
+// path and returns the number of providers and models it lists. The cache is
+// only replaced by data that parses.
+func UpdateModelsCache(ctx context.Context, path string) (providers, models int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsDevURL, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch models.dev data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("failed to fetch models.dev data: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch models.dev data: %w", err)
+	}
+	listed, err := parseModelsDev(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return 0, 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return 0, 0, err
+	}
+
+	for _, p := range listed {
+		models += len(p.Models)
+	}
+	return len(listed), models, nil
+}
+
+// ModelsCacheStale reports whether the cache file at path is missing or
+// older than RegistryCacheTTL
+func ModelsCacheStale(path string) bool {
+	info, err := os.Stat(path)
+	return err != nil || time.Since(info.ModTime()) > RegistryCacheTTL
+}
+
+// LoadModelsCache adds the models of the cache file at path to the global
+// registry, so models released after this build validate and get their
+// prices and limits. Built-in entries the cache lacks are kept, and built-in
+// providers keep the environment variables mcphost reads for them. A missing
+// cache is not an error.
+func LoadModelsCache(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	listed, err := parseModelsDev(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for id, cached := range listed {
+		builtin, ok := globalRegistry.providers[id]
+		if !ok {
+			globalRegistry.providers[id] = cached
+			continue
+		}
+		for modelID, info := range cached.Models {
+			builtin.Models[modelID] = info
+		}
+		globalRegistry.providers[id] = builtin
+	}
+	return nil
+}
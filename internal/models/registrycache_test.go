@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestModelsCache(t *testing.T) {
+	body := `{
+		"anthropic": {"id": "anthropic", "env": ["SOMETHING_ELSE"], "name": "Anthropic", "models": {
+			"claude-future-1": {"id": "claude-future-1", "name": "Claude Future", "attachment": true, "tool_call": true,
+				"cost": {"input": 4, "output": 20, "cache_read": 0.4}, "limit": {"context": 500000, "output": 64000}}
+		}},
+		"newcloud": {"id": "newcloud", "env": ["NEWCLOUD_API_KEY"], "name": "New Cloud", "models": {
+			"nc-1": {"id": "nc-1", "name": "NC 1", "limit": {"context": 8192, "output": 1024}}
+		}}
+	}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	defer func(url string) { modelsDevURL = url }(modelsDevURL)
+	modelsDevURL = server.URL
+	defer func(providers map[string]ProviderInfo) { globalRegistry.providers = providers }(globalRegistry.providers)
+	globalRegistry.providers = GetModelsData()
+
+	path := filepath.Join(t.TempDir(), "cache", "models.json")
+	if !ModelsCacheStale(path) {
+		t.Error("expected a missing cache to be stale")
+	}
+	if err := LoadModelsCache(path); err != nil {
+		t.Errorf("expected a missing cache to be ignored, got %v", err)
+	}
+
+	providers, count, err := UpdateModelsCache(context.Background(), path)
+	if err != nil || providers != 2 || count != 2 {
+		t.Fatalf("expected 2 providers and 2 models, got %d, %d, %v", providers, count, err)
+	}
+	if ModelsCacheStale(path) {
+		t.Error("expected a fresh cache")
+	}
+	builtinModels := len(globalRegistry.providers["anthropic"].Models)
+	if err := LoadModelsCache(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := globalRegistry.ValidateModel("anthropic", "claude-future-1")
+	if err != nil || info.Cost.Input != 4 || *info.Cost.CacheRead != 0.4 || info.Limit.Context != 500000 || !info.Attachment {
+		t.Fatalf("expected the cached model, got %+v, %v", info, err)
+	}
+	if len(globalRegistry.providers["anthropic"].Models) != builtinModels+1 {
+		t.Error("expected the built-in models kept")
+	}
+	if env, _ := globalRegistry.GetRequiredEnvVars("anthropic"); len(env) == 0 || env[0] == "SOMETHING_ELSE" {
+		t.Errorf("expected the built-in environment variables kept, got %v", env)
+	}
+	if _, err := globalRegistry.ValidateModel("newcloud", "nc-1"); err != nil {
+		t.Errorf("expected a new provider added, got %v", err)
+	}
+
+	old := time.Now().Add(-RegistryCacheTTL - time.Hour)
+	os.Chtimes(path, old, old)
+	if !ModelsCacheStale(path) {
+		t.Error("expected an old cache to be stale")
+	}
+
+	// Data that doesn't parse leaves the cache alone
+	body = `<html>maintenance</html>`
+	if _, _, err := UpdateModelsCache(context.Background(), path); err == nil {
+		t.Error("expected unparsable data to fail")
+	}
+	if err := LoadModelsCache(path); err != nil {
+		t.Errorf("expected the earlier cache kept, got %v", err)
+	}
+}
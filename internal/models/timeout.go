@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// timeoutTransport bounds provider requests without cutting off long streams.
+// An ordinary request must complete - response body included - within the
+// timeout. Once a streaming response (server-sent events or NDJSON) starts
+// arriving the timeout becomes an idle limit instead: the request only fails
+// if no data arrives for that long.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	timedOut := fmt.Errorf("provider request timed out after %s", t.timeout)
+	timer := time.AfterFunc(t.timeout, func() { cancel(timedOut) })
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		if context.Cause(ctx) == timedOut {
+			err = timedOut
+		}
+		cancel(nil)
+		return nil, err
+	}
+
+	resp.Body = &timeoutBody{
+		ReadCloser: resp.Body,
+		ctx:        ctx,
+		cancel:     cancel,
+		timer:      timer,
+		timedOut:   timedOut,
+		idle:       isStreamingResponse(resp),
+		timeout:    t.timeout,
+	}
+	return resp, nil
+}
+
+// isStreamingResponse reports whether resp is an incremental stream
+func isStreamingResponse(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream", "application/x-ndjson", "application/jsonl":
+		return true
+	}
+	return false
+}
+
+// timeoutBody enforces the request deadline while the body is read and
+// releases the timer when it is closed
+type timeoutBody struct {
+	io.ReadCloser
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	timer    *time.Timer
+	timedOut error
+	idle     bool // reset the timer on every read that returns data
+	timeout  time.Duration
+	once     sync.Once
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.idle {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && context.Cause(b.ctx) == b.timedOut {
+		err = b.timedOut
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	b.once.Do(func() {
+		b.timer.Stop()
+		b.cancel(nil)
+	})
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"ok":true}`)
+		case "/stream":
+			// Longer than the timeout overall, but never idle for long
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := 0; i < 6; i++ {
+				fmt.Fprintf(w, "data: %d\n\n", i)
+				w.(http.Flusher).Flush()
+				time.Sleep(30 * time.Millisecond)
+			}
+		case "/stall":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: 0\n\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+		default:
+			fmt.Fprint(w, `{"ok":true}`)
+		}
+	}))
+	defer server.Close()
+
+	client := createHTTPClientWithTLSConfig(&ProviderConfig{RequestTimeout: 100 * time.Millisecond})
+
+	get := func(path string) (string, error) {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if _, err := get("/fast"); err != nil {
+		t.Errorf("fast request failed: %v", err)
+	}
+
+	if _, err := get("/slow"); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected slow request to time out, got %v", err)
+	}
+
+	body, err := get("/stream")
+	if err != nil {
+		t.Errorf("active stream was cut off: %v", err)
+	}
+	if !strings.Contains(body, "data: 5") {
+		t.Errorf("stream incomplete: %q", body)
+	}
+
+	if _, err := get("/stall"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected stalled stream to time out, got %v", err)
+	}
+}
+
+func TestCreateProviderTransportDefaults(t *testing.T) {
+	if rt := createProviderTransport(&ProviderConfig{}); rt != http.DefaultTransport {
+		t.Errorf("expected the default transport without TLS or timeout settings, got %T", rt)
+	}
+
+	rt := createProviderTransport(&ProviderConfig{ConnectTimeout: 5 * time.Second})
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", rt)
+	}
+	if transport.TLSHandshakeTimeout != 5*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %v, want 5s", transport.TLSHandshakeTimeout)
+	}
+}
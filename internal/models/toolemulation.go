@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Tool emulation settings, as given to --tool-emulation
+const (
+	ToolEmulationAuto   = "auto"   // emulate for models without native tool calls; the default
+	ToolEmulationAlways = "always" // emulate for every model
+	ToolEmulationNever  = "never"  // models without native tool calls get no tools
+)
+
+// ToolEmulationModes lists the valid --tool-emulation values
+var ToolEmulationModes = []string{ToolEmulationAuto, ToolEmulationAlways, ToolEmulationNever}
+
+// withoutNativeTools holds the models found, when they were created, to have
+// no native tool calls, by model string
+var withoutNativeTools = map[string]bool{}
+
+// ValidateToolEmulation checks a --tool-emulation value; empty means auto
+func ValidateToolEmulation(mode string) error {
+	if mode != "" && !slices.Contains(ToolEmulationModes, mode) {
+		return fmt.Errorf("invalid tool emulation %q: use auto, always or never", mode)
+	}
+	return nil
+}
+
+// EmulatesTools reports whether the model config describes has its tool
+// calls emulated through its text output
+func EmulatesTools(config *ProviderConfig) bool {
+	switch config.ToolEmulation {
+	case ToolEmulationAlways:
+		return true
+	case ToolEmulationNever:
+		return false
+	}
+	return !SupportsTools(config.ModelString)
+}
+
+// CallsTools reports whether the model config describes can be given tools,
+// natively or through emulation
+func CallsTools(config *ProviderConfig) bool {
+	return SupportsTools(config.ModelString) || EmulatesTools(config)
+}
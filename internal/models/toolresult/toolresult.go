@@ -0,0 +1,43 @@
+// Package toolresult marks the tool result messages that report a failed
+// tool call, for providers whose APIs flag such results to the model
+package toolresult
+
+import "github.com/cloudwego/eino/schema"
+
+// errorKey is the schema.Message.Extra key marking a failed call's result
+const errorKey = "mcphost_tool_error"
+
+// MarkError records that msg reports a tool call that failed, was denied or
+// did not run
+func MarkError(msg *schema.Message) {
+	if msg == nil {
+		return
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any)
+	}
+	msg.Extra[errorKey] = true
+}
+
+// IsError reports whether msg was marked with MarkError
+func IsError(msg *schema.Message) bool {
+	if msg == nil || msg.Extra == nil {
+		return false
+	}
+	marked, _ := msg.Extra[errorKey].(bool)
+	return marked
+}
+
+// ErrorCallIDs returns the tool call IDs of the marked results in messages
+func ErrorCallIDs(messages []*schema.Message) map[string]bool {
+	var ids map[string]bool
+	for _, msg := range messages {
+		if msg != nil && msg.Role == schema.Tool && IsError(msg) {
+			if ids == nil {
+				ids = make(map[string]bool)
+			}
+			ids[msg.ToolCallID] = true
+		}
+	}
+	return ids
+}
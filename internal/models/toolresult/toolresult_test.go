@@ -0,0 +1,39 @@
+package toolresult
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestMarkError(t *testing.T) {
+	failed := schema.ToolMessage("Tool execution error: timeout", "call-1")
+	MarkError(failed)
+	messages := []*schema.Message{
+		schema.UserMessage("read it"),
+		failed,
+		schema.ToolMessage("module example", "call-2"),
+	}
+
+	ids := ErrorCallIDs(messages)
+	if len(ids) != 1 || !ids["call-1"] {
+		t.Errorf("expected only call-1, got %v", ids)
+	}
+
+	// The mark survives a session saved as JSON
+	data, err := json.Marshal(failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded schema.Message
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !IsError(&loaded) {
+		t.Error("expected the reloaded message to stay marked")
+	}
+	if IsError(messages[2]) || IsError(nil) {
+		t.Error("expected unmarked messages not to be errors")
+	}
+}
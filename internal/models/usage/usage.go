@@ -0,0 +1,249 @@
+// Package usage carries the token counts that schema.TokenUsage has no fields
+// for - prompt cache writes and reasoning tokens - from the provider wrappers
+// to the code that prices them.
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// extraKey is the schema.Message.Extra key holding a message's Details
+const extraKey = "mcphost_usage_details"
+
+// Details are the extra token counts of one model call. Cache reads are not
+// here: they are reported in schema.TokenUsage.PromptTokenDetails.CachedTokens.
+type Details struct {
+	// CacheWriteTokens are prompt tokens written to the provider's prompt
+	// cache; like cache reads they are included in PromptTokens
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	// ReasoningTokens are hidden reasoning tokens; they are included in
+	// CompletionTokens
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// Set stores d on msg, replacing any earlier details
+func Set(msg *schema.Message, d Details) {
+	if msg == nil || d == (Details{}) {
+		return
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any)
+	}
+	msg.Extra[extraKey] = d
+}
+
+// Get returns the details stored on msg
+func Get(msg *schema.Message) Details {
+	if msg == nil || msg.Extra == nil {
+		return Details{}
+	}
+	switch d := msg.Extra[extraKey].(type) {
+	case Details:
+		return d
+	case *Details:
+		if d != nil {
+			return *d
+		}
+	}
+	return Details{}
+}
+
+// Max merges details reported cumulatively across stream chunks
+func Max(a, b Details) Details {
+	return Details{
+		CacheWriteTokens: max(a.CacheWriteTokens, b.CacheWriteTokens),
+		ReasoningTokens:  max(a.ReasoningTokens, b.ReasoningTokens),
+	}
+}
+
+// Report is the usage a provider put in a raw HTTP response, normalized so
+// InputTokens includes cache reads and writes and OutputTokens includes
+// reasoning
+type Report struct {
+	InputTokens      int
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	ReasoningTokens  int
+}
+
+// Apply replaces msg's token usage with the report
+func (rep Report) Apply(msg *schema.Message) {
+	if msg == nil {
+		return
+	}
+	if msg.ResponseMeta == nil {
+		msg.ResponseMeta = &schema.ResponseMeta{}
+	}
+	msg.ResponseMeta.Usage = &schema.TokenUsage{
+		PromptTokens:       rep.InputTokens,
+		PromptTokenDetails: schema.PromptTokenDetails{CachedTokens: rep.CacheReadTokens},
+		CompletionTokens:   rep.OutputTokens,
+		TotalTokens:        rep.InputTokens + rep.OutputTokens,
+	}
+	Set(msg, Details{CacheWriteTokens: rep.CacheWriteTokens, ReasoningTokens: rep.ReasoningTokens})
+}
+
+// Chunk returns a content-free stream chunk carrying the report, to be sent
+// last so it wins when chunk usage is merged
+func (rep Report) Chunk() *schema.Message {
+	msg := &schema.Message{Role: schema.Assistant}
+	rep.Apply(msg)
+	return msg
+}
+
+// WrapStream forwards in and, once it ends, sends a final chunk with the usage
+// recorded by r, if any
+func WrapStream(in *schema.StreamReader[*schema.Message], r *Recorder) *schema.StreamReader[*schema.Message] {
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer in.Close()
+		defer writer.Close()
+		for {
+			chunk, err := in.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if writer.Send(chunk, err) || err != nil {
+				return
+			}
+		}
+		if rep, ok := r.Report(); ok {
+			writer.Send(rep.Chunk(), nil)
+		}
+	}()
+	return reader
+}
+
+// Recorder collects the usage seen in the HTTP responses of one model call
+type Recorder struct {
+	mu     sync.Mutex
+	report Report
+	seen   bool
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose provider HTTP responses are recorded
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// Update folds a report in; counts are cumulative, so each keeps its largest value
+func (r *Recorder) Update(rep Report) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = true
+	r.report.InputTokens = max(r.report.InputTokens, rep.InputTokens)
+	r.report.OutputTokens = max(r.report.OutputTokens, rep.OutputTokens)
+	r.report.CacheReadTokens = max(r.report.CacheReadTokens, rep.CacheReadTokens)
+	r.report.CacheWriteTokens = max(r.report.CacheWriteTokens, rep.CacheWriteTokens)
+	r.report.ReasoningTokens = max(r.report.ReasoningTokens, rep.ReasoningTokens)
+}
+
+// Report returns what was recorded and whether any usage was seen
+func (r *Recorder) Report() (Report, bool) {
+	if r == nil {
+		return Report{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report, r.seen
+}
+
+// Watch makes resp's body report usage to the recorder in req's context, if
+// any, as it is read. parse is given each JSON document in the body: every
+// "data:" line of a server-sent event stream, or the whole body otherwise.
+func Watch(req *http.Request, resp *http.Response, parse func(data []byte) (Report, bool)) {
+	r, _ := req.Context().Value(recorderKey{}).(*Recorder)
+	if r == nil || resp == nil || resp.Body == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+	resp.Body = &watchedBody{
+		ReadCloser: resp.Body,
+		recorder:   r,
+		parse:      parse,
+		stream:     strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+	}
+}
+
+// maxWatchedBody bounds how much of a non-streaming body is kept for parsing
+const maxWatchedBody = 16 << 20
+
+type watchedBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	parse    func([]byte) (Report, bool)
+	stream   bool
+	buf      bytes.Buffer
+	done     bool
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < maxWatchedBody {
+		b.buf.Write(p[:n])
+		if b.stream {
+			b.scanLines()
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// scanLines parses the complete "data:" lines buffered so far
+func (b *watchedBody) scanLines() {
+	for {
+		line, err := b.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the incomplete line for the next read
+			rest := append([]byte(nil), line...)
+			b.buf.Reset()
+			b.buf.Write(rest)
+			return
+		}
+		b.parseLine(line)
+	}
+}
+
+func (b *watchedBody) parseLine(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return
+	}
+	if rep, ok := b.parse(bytes.TrimSpace(data)); ok {
+		b.recorder.Update(rep)
+	}
+}
+
+func (b *watchedBody) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	if b.stream {
+		scanner := bufio.NewScanner(&b.buf)
+		for scanner.Scan() {
+			b.parseLine(scanner.Bytes())
+		}
+		return
+	}
+	if rep, ok := b.parse(b.buf.Bytes()); ok {
+		b.recorder.Update(rep)
+	}
+}
@@ -0,0 +1,101 @@
+package usage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func parseTestUsage(data []byte) (Report, bool) {
+	var doc struct {
+		Usage *struct {
+			In    int `json:"in"`
+			Out   int `json:"out"`
+			Cache int `json:"cache"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Usage == nil {
+		return Report{}, false
+	}
+	return Report{InputTokens: doc.Usage.In, OutputTokens: doc.Usage.Out, CacheWriteTokens: doc.Usage.Cache}, true
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        Report
+	}{
+		{
+			name:        "event stream",
+			contentType: "text/event-stream",
+			body: "event: start\ndata: {\"usage\":{\"in\":40,\"out\":1,\"cache\":30}}\n\n" +
+				"data: {\"delta\":\"hi\"}\n\n" +
+				"data: {\"usage\":{\"out\":12}}\n\n" +
+				"data: [DONE]",
+			want: Report{InputTokens: 40, OutputTokens: 12, CacheWriteTokens: 30},
+		},
+		{
+			name:        "json body",
+			contentType: "application/json",
+			body:        `{"content":"hi","usage":{"in":7,"out":3}}`,
+			want:        Report{InputTokens: 7, OutputTokens: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, rec := WithRecorder(t.Context())
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.invalid", nil)
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{tt.contentType}},
+				Body:       io.NopCloser(&smallReader{r: strings.NewReader(tt.body)}),
+			}
+			Watch(req, resp, parseTestUsage)
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body altered: %q", body)
+			}
+			got, ok := rec.Report()
+			if !ok || got != tt.want {
+				t.Errorf("report = %+v (seen %v), want %+v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchWithoutRecorder(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", nil)
+	body := io.NopCloser(strings.NewReader("{}"))
+	resp := &http.Response{StatusCode: http.StatusOK, Body: body}
+	Watch(req, resp, parseTestUsage)
+	if resp.Body != body {
+		t.Error("body should be left alone when nothing records usage")
+	}
+}
+
+func TestReportApply(t *testing.T) {
+	msg := Report{InputTokens: 100, OutputTokens: 20, CacheReadTokens: 60, CacheWriteTokens: 10, ReasoningTokens: 15}.Chunk()
+	u := msg.ResponseMeta.Usage
+	if u.PromptTokens != 100 || u.CompletionTokens != 20 || u.TotalTokens != 120 || u.PromptTokenDetails.CachedTokens != 60 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	if d := Get(msg); d != (Details{CacheWriteTokens: 10, ReasoningTokens: 15}) {
+		t.Errorf("details = %+v", d)
+	}
+}
+
+// smallReader returns a few bytes per read so lines arrive split
+type smallReader struct{ r io.Reader }
+
+func (s *smallReader) Read(p []byte) (int, error) {
+	return s.r.Read(p[:min(len(p), 7)])
+}
@@ -0,0 +1,523 @@
+// Package openapi serves the operations of an OpenAPI 3 spec as MCP tools.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// maxResponseBytes caps how much of a response body is read
+	maxResponseBytes = 5 << 20
+	// maxResultChars caps the response text returned to the model
+	maxResultChars = 50000
+	// maxSchemaDepth stops inlining recursive schemas
+	maxSchemaDepth = 8
+)
+
+var toolNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// Config describes an openapi server
+type Config struct {
+	Spec       string            // file path or http(s) URL of the spec
+	BaseURL    string            // overrides the spec's first server URL
+	Operations []string          // operationIds to expose; empty exposes all of them
+	Headers    map[string]string // sent with every request, e.g. Authorization
+}
+
+// operation is one spec operation exposed as a tool
+type operation struct {
+	id     string
+	method string
+	path   string
+
+	op       *openapi3.Operation
+	params   openapi3.Parameters
+	bodyName string // input property holding the request body, if any
+}
+
+// apiServer calls the API described by a spec
+type apiServer struct {
+	baseURL    string
+	headers    map[string]string
+	operations []*operation
+	client     *http.Client
+}
+
+// load reads the spec and resolves the operations to expose
+func load(ctx context.Context, cfg Config) (*apiServer, error) {
+	if cfg.Spec == "" {
+		return nil, fmt.Errorf("spec is required")
+	}
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	loader.IsExternalRefsAllowed = true
+
+	var doc *openapi3.T
+	var specURL *url.URL
+	var err error
+	if u, parseErr := url.Parse(cfg.Spec); parseErr == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		specURL = u
+		doc, err = loader.LoadFromURI(u)
+	} else {
+		doc, err = loader.LoadFromFile(cfg.Spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec %s: %v", cfg.Spec, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s is not an OpenAPI 3 spec (openapi: %q)", cfg.Spec, doc.OpenAPI)
+	}
+
+	baseURL, err := resolveBaseURL(cfg.BaseURL, doc.Servers, specURL)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := selectOperations(doc, cfg.Operations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiServer{
+		baseURL:    baseURL,
+		headers:    cfg.Headers,
+		operations: operations,
+		client:     &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// NewServer loads the spec and returns an MCP server with one tool per operation
+func NewServer(ctx context.Context, name string, cfg Config) (*server.MCPServer, error) {
+	s, err := load(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	mcpServer := server.NewMCPServer(name, "1.0.0", server.WithToolCapabilities(true))
+	for _, op := range s.operations {
+		mcpServer.AddTool(op.tool(), s.handler(op))
+	}
+	return mcpServer, nil
+}
+
+// resolveBaseURL picks the API root: the configured URL, or the spec's first
+// server with its variables set to their defaults. Relative server URLs are
+// resolved against the spec's URL.
+func resolveBaseURL(configured string, servers openapi3.Servers, specURL *url.URL) (string, error) {
+	raw := configured
+	if raw == "" && len(servers) > 0 {
+		raw = servers[0].URL
+		for name, variable := range servers[0].Variables {
+			raw = strings.ReplaceAll(raw, "{"+name+"}", variable.Default)
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid API URL %q: %v", raw, err)
+	}
+	if !u.IsAbs() {
+		if specURL == nil {
+			if raw == "" {
+				return "", fmt.Errorf("the spec lists no servers; set url to the API's base URL")
+			}
+			return "", fmt.Errorf("the spec's server URL %q is relative; set url to the API's base URL", raw)
+		}
+		u = specURL.ResolveReference(u)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// selectOperations returns the operations named in allow, or every operation
+// with an operationId when allow is empty
+func selectOperations(doc *openapi3.T, allow []string) ([]*operation, error) {
+	byID := make(map[string]*operation)
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			if op.OperationID == "" {
+				continue
+			}
+			byID[op.OperationID] = newOperation(method, path, item, op)
+		}
+	}
+
+	var operations []*operation
+	if len(allow) == 0 {
+		for _, op := range byID {
+			operations = append(operations, op)
+		}
+		if len(operations) == 0 {
+			return nil, fmt.Errorf("the spec has no operations with an operationId")
+		}
+	} else {
+		for _, id := range allow {
+			op, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("operation %q is not in the spec", id)
+			}
+			operations = append(operations, op)
+		}
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].id < operations[j].id })
+	return operations, nil
+}
+
+// newOperation merges path-level parameters into op's
+func newOperation(method, path string, item *openapi3.PathItem, op *openapi3.Operation) *operation {
+	o := &operation{id: op.OperationID, method: method, path: path, op: op}
+
+	seen := make(map[string]bool)
+	for _, ref := range op.Parameters {
+		if ref.Value != nil {
+			seen[ref.Value.In+":"+ref.Value.Name] = true
+			o.params = append(o.params, ref)
+		}
+	}
+	for _, ref := range item.Parameters {
+		if ref.Value != nil && !seen[ref.Value.In+":"+ref.Value.Name] {
+			o.params = append(o.params, ref)
+		}
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		o.bodyName = "body"
+		for _, ref := range o.params {
+			if ref.Value.Name == o.bodyName {
+				o.bodyName = "requestBody"
+			}
+		}
+	}
+	return o
+}
+
+// toolName returns the operationId with characters MCP does not allow in tool
+// names replaced
+func (o *operation) toolName() string {
+	name := toolNameInvalid.ReplaceAllString(o.id, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// tool describes the operation as an MCP tool. Parameters become properties
+// named after them; the request body, when there is one, is the body property.
+func (o *operation) tool() mcp.Tool {
+	properties := make(map[string]any)
+	var required []string
+	for _, ref := range o.params {
+		p := ref.Value
+		if p.In == openapi3.ParameterInCookie {
+			continue
+		}
+		prop := jsonSchema(p.Schema, 0)
+		if p.Schema == nil {
+			if mediaType := firstMediaType(p.Content); mediaType != nil {
+				prop = jsonSchema(mediaType.Schema, 0)
+			}
+		}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		properties[p.Name] = prop
+		if p.Required || p.In == openapi3.ParameterInPath {
+			required = append(required, p.Name)
+		}
+	}
+
+	if o.bodyName != "" {
+		body := o.op.RequestBody.Value
+		prop := map[string]any{}
+		if mediaType := o.bodyMediaType(); mediaType != nil {
+			prop = jsonSchema(mediaType.Schema, 0)
+		}
+		if body.Description != "" {
+			prop["description"] = body.Description
+		}
+		properties[o.bodyName] = prop
+		if body.Required {
+			required = append(required, o.bodyName)
+		}
+	}
+
+	inputSchema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+	raw, _ := json.Marshal(inputSchema)
+
+	var description []string
+	if o.op.Summary != "" {
+		description = append(description, o.op.Summary)
+	}
+	if o.op.Description != "" && o.op.Description != o.op.Summary {
+		description = append(description, o.op.Description)
+	}
+	description = append(description, fmt.Sprintf("(%s %s)", o.method, o.path))
+
+	tool := mcp.NewToolWithRawSchema(o.toolName(), strings.Join(description, "\n\n"), raw)
+	if o.method == http.MethodGet || o.method == http.MethodHead {
+		tool.Annotations.ReadOnlyHint = mcp.ToBoolPtr(true)
+	}
+	return tool
+}
+
+// bodyContentType is the request body media type sent: JSON when the
+// operation accepts it, otherwise the first one listed
+func (o *operation) bodyContentType() string {
+	content := o.op.RequestBody.Value.Content
+	if _, ok := content["application/json"]; ok {
+		return "application/json"
+	}
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	for _, contentType := range types {
+		if strings.HasSuffix(contentType, "+json") {
+			return contentType
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return "application/json"
+}
+
+func (o *operation) bodyMediaType() *openapi3.MediaType {
+	return o.op.RequestBody.Value.Content[o.bodyContentType()]
+}
+
+// firstMediaType returns the JSON media type of content, or any other
+func firstMediaType(content openapi3.Content) *openapi3.MediaType {
+	if mediaType, ok := content["application/json"]; ok {
+		return mediaType
+	}
+	for _, mediaType := range content {
+		return mediaType
+	}
+	return nil
+}
+
+// jsonSchema converts ref to a JSON Schema with every $ref inlined. Schemas
+// nested deeper than maxSchemaDepth (recursive ones) accept any value.
+func jsonSchema(ref *openapi3.SchemaRef, depth int) map[string]any {
+	if ref == nil || ref.Value == nil || depth > maxSchemaDepth {
+		return map[string]any{}
+	}
+	s := ref.Value
+
+	// Marshal the schema itself, then replace its nested schemas, which
+	// marshal as $refs when they were references
+	out := map[string]any{}
+	if data, err := json.Marshal(s); err == nil {
+		json.Unmarshal(data, &out)
+	}
+	for key := range out {
+		if strings.HasPrefix(key, "x-") {
+			delete(out, key)
+		}
+	}
+
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			properties[name] = jsonSchema(prop, depth+1)
+		}
+		out["properties"] = properties
+	}
+	if s.Items != nil {
+		out["items"] = jsonSchema(s.Items, depth+1)
+	}
+	if s.AdditionalProperties.Schema != nil {
+		out["additionalProperties"] = jsonSchema(s.AdditionalProperties.Schema, depth+1)
+	}
+	for key, refs := range map[string]openapi3.SchemaRefs{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf} {
+		if len(refs) == 0 {
+			continue
+		}
+		schemas := make([]any, len(refs))
+		for i, r := range refs {
+			schemas[i] = jsonSchema(r, depth+1)
+		}
+		out[key] = schemas
+	}
+	if s.Not != nil {
+		out["not"] = jsonSchema(s.Not, depth+1)
+	}
+	return out
+}
+
+// handler calls the operation with the tool's arguments
+func (s *apiServer) handler(op *operation) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		req, err := s.newRequest(ctx, op, request.GetArguments())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("request failed: %v", err)), nil
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read response: %v", err)), nil
+		}
+
+		text := formatResponse(resp, body)
+		if resp.StatusCode >= 400 {
+			return mcp.NewToolResultError(text), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	}
+}
+
+// newRequest builds the HTTP request for calling op with args
+func (s *apiServer) newRequest(ctx context.Context, op *operation, args map[string]any) (*http.Request, error) {
+	path := op.path
+	query := url.Values{}
+	headers := http.Header{}
+	for _, ref := range op.params {
+		p := ref.Value
+		value, ok := args[p.Name]
+		if !ok || value == nil {
+			if p.Required || p.In == openapi3.ParameterInPath {
+				return nil, fmt.Errorf("missing required parameter %s", p.Name)
+			}
+			continue
+		}
+		switch p.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(formatParam(value)))
+		case openapi3.ParameterInQuery:
+			if values, ok := value.([]any); ok && (p.Explode == nil || *p.Explode) {
+				for _, v := range values {
+					query.Add(p.Name, formatParam(v))
+				}
+			} else {
+				query.Set(p.Name, formatParam(value))
+			}
+		case openapi3.ParameterInHeader:
+			headers.Set(p.Name, formatParam(value))
+		}
+	}
+
+	target := s.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	var contentType string
+	if op.bodyName != "" {
+		if value, ok := args[op.bodyName]; ok && value != nil {
+			contentType = op.bodyContentType()
+			encoded, err := encodeBody(contentType, value)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(encoded)
+		} else if op.op.RequestBody.Value.Required {
+			return nil, fmt.Errorf("missing required parameter %s", op.bodyName)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.8")
+	req.Header.Set("User-Agent", "mcphost")
+	// Configured headers go last, so a header parameter the model fills in
+	// can't replace the credentials they carry
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// formatParam renders a parameter value for a URL or header
+func formatParam(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		// JSON numbers decode as float64; avoid exponents for large IDs
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatParam(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// encodeBody encodes a request body for contentType
+func encodeBody(contentType string, value any) ([]byte, error) {
+	switch {
+	case contentType == "application/x-www-form-urlencoded":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("body must be an object for %s", contentType)
+		}
+		form := url.Values{}
+		for key, v := range fields {
+			form.Set(key, formatParam(v))
+		}
+		return []byte(form.Encode()), nil
+	case strings.Contains(contentType, "json"):
+		return json.Marshal(value)
+	default:
+		if text, ok := value.(string); ok {
+			return []byte(text), nil
+		}
+		return json.Marshal(value)
+	}
+}
+
+// formatResponse renders a response for the model: the status line, then the
+// body, with JSON indented and long bodies truncated
+func formatResponse(resp *http.Response, body []byte) string {
+	text := string(body)
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			text = indented.String()
+		}
+	}
+	if len(text) > maxResultChars {
+		text = text[:maxResultChars] + fmt.Sprintf("\n... (truncated, %d bytes total)", len(body))
+	}
+	if text == "" {
+		return resp.Status
+	}
+	return resp.Status + "\n\n" + text
+}
@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "servers": [{"url": "/api"}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "findPets",
+        "summary": "Find pets by tag",
+        "parameters": [
+          {"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+          {"name": "Authorization", "in": "header", "schema": {"type": "string"}},
+          {"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+        ]
+      },
+      "post": {
+        "operationId": "addPet",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+        }
+      }
+    },
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+      "get": {"operationId": "getPet"},
+      "delete": {"operationId": "deletePet"}
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string"},
+          "owner": {"$ref": "#/components/schemas/Owner"}
+        }
+      },
+      "Owner": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}}
+    }
+  }
+}`
+
+// newTestAPI serves testSpec at /openapi.json and records API requests
+func newTestAPI(t *testing.T) (*httptest.Server, *[]*http.Request, *[]string) {
+	var requests []*http.Request
+	var bodies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/openapi.json" {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, testSpec)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		if r.URL.Path == "/api/pets/404" {
+			http.Error(w, `{"message":"pet not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":7,"name":"Rex"}`)
+	}))
+	t.Cleanup(api.Close)
+	return api, &requests, &bodies
+}
+
+func startClient(t *testing.T, cfg Config) *client.Client {
+	t.Helper()
+	ctx := context.Background()
+	s, err := NewServer(ctx, "pets", cfg)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	c, err := client.NewInProcessClient(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func callTool(t *testing.T, c *client.Client, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := c.CallTool(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CallTool %s: %v", name, err)
+	}
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestOpenAPITools(t *testing.T) {
+	api, requests, bodies := newTestAPI(t)
+	c := startClient(t, Config{
+		Spec:       api.URL + "/openapi.json",
+		Operations: []string{"findPets", "addPet", "getPet"},
+		Headers:    map[string]string{"Authorization": "Bearer token"},
+	})
+
+	tools, err := c.ListTools(context.Background(), mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	schemas := make(map[string]string)
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+		data, _ := json.Marshal(tool.InputSchema)
+		schemas[tool.Name] = string(data)
+	}
+	if strings.Join(names, ",") != "addPet,findPets,getPet" {
+		t.Fatalf("expected only the allowed operations, got %v", names)
+	}
+	// The body's $refs are inlined, and the path-level parameter is required
+	if !strings.Contains(schemas["addPet"], `"email"`) || strings.Contains(schemas["addPet"], "$ref") {
+		t.Errorf("expected addPet body schema with refs inlined, got %s", schemas["addPet"])
+	}
+	if !strings.Contains(schemas["getPet"], `"required":["petId"]`) {
+		t.Errorf("expected petId to be required, got %s", schemas["getPet"])
+	}
+
+	result := callTool(t, c, "findPets", map[string]any{"tags": []any{"dog", "cat"}, "limit": 2, "Authorization": "Bearer other", "X-Trace": "abc"})
+	if result.IsError {
+		t.Fatalf("findPets failed: %s", resultText(result))
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodGet || req.URL.Path != "/api/pets" || req.URL.RawQuery != "limit=2&tags=dog&tags=cat" {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+	// A header parameter can't replace a configured header
+	if req.Header.Get("Authorization") != "Bearer token" || req.Header.Get("X-Trace") != "abc" {
+		t.Errorf("expected configured header and the X-Trace parameter, got %q and %q", req.Header.Get("Authorization"), req.Header.Get("X-Trace"))
+	}
+	if text := resultText(result); !strings.HasPrefix(text, "200 OK") || !strings.Contains(text, `"name": "Rex"`) {
+		t.Errorf("unexpected result %q", text)
+	}
+
+	result = callTool(t, c, "addPet", map[string]any{"body": map[string]any{"name": "Rex"}})
+	if result.IsError {
+		t.Fatalf("addPet failed: %s", resultText(result))
+	}
+	req = (*requests)[1]
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" || (*bodies)[1] != `{"name":"Rex"}` {
+		t.Errorf("unexpected request %s %s %q", req.Method, req.Header.Get("Content-Type"), (*bodies)[1])
+	}
+
+	result = callTool(t, c, "getPet", map[string]any{"petId": float64(404)})
+	if !result.IsError || !strings.Contains(resultText(result), "pet not found") {
+		t.Errorf("expected 404 to be a tool error with the body, got %q", resultText(result))
+	}
+	if (*requests)[2].URL.Path != "/api/pets/404" {
+		t.Errorf("expected path parameter to be substituted, got %s", (*requests)[2].URL.Path)
+	}
+
+	result = callTool(t, c, "getPet", map[string]any{})
+	if !result.IsError || !strings.Contains(resultText(result), "missing required parameter petId") {
+		t.Errorf("expected missing petId to fail, got %q", resultText(result))
+	}
+	if len(*requests) != 3 {
+		t.Errorf("expected no request without petId, got %d requests", len(*requests))
+	}
+}
+
+func TestOpenAPILoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "openapi.json")
+	if err := os.WriteFile(specPath, []byte(testSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"missing spec", Config{}, "spec is required"},
+		{"relative server from file", Config{Spec: specPath}, "set url"},
+		{"unknown operation", Config{Spec: specPath, BaseURL: "http://localhost", Operations: []string{"nope"}}, `operation "nope" is not in the spec`},
+		{"missing file", Config{Spec: filepath.Join(dir, "missing.json")}, "failed to load OpenAPI spec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(context.Background(), tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	s, err := load(context.Background(), Config{Spec: specPath, BaseURL: "http://localhost:8080/v1/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.baseURL != "http://localhost:8080/v1" {
+		t.Errorf("expected configured base URL, got %s", s.baseURL)
+	}
+	if len(s.operations) != 4 {
+		t.Errorf("expected every operation without an allowlist, got %d", len(s.operations))
+	}
+}
@@ -0,0 +1,163 @@
+// Package postmortem explains failed non-interactive runs: how far the run
+// got, which tool failed last, what kind of error ended it and what to try.
+package postmortem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+// Error categories
+const (
+	CategoryAuth          = "auth"           // the provider rejected the credentials
+	CategoryRateLimit     = "rate_limit"     // the provider's rate limit or quota
+	CategoryContextLength = "context_length" // the conversation no longer fits the model
+	CategoryTimeout       = "timeout"        // a request or the run took too long
+	CategoryNetwork       = "network"        // the provider could not be reached
+	CategoryBlocked       = "blocked"        // a hook or check refused to go on
+	CategoryProvider      = "provider"       // any other error from the model provider
+	CategoryUnknown       = "unknown"
+)
+
+// Report is the post-mortem of a failed run
+type Report struct {
+	Category    string `json:"category"`
+	Steps       int    `json:"steps"`                  // model calls that completed
+	LastStep    string `json:"last_step"`              // what the last completed model call did
+	FailingTool string `json:"failing_tool,omitempty"` // the last tool call that failed
+	ToolError   string `json:"tool_error,omitempty"`   // what it returned
+	Error       string `json:"error"`
+	Suggestion  string `json:"suggestion"`
+	Summary     string `json:"summary,omitempty"` // a model's account of what went wrong
+}
+
+// maxToolError bounds the tool error kept in a report
+const maxToolError = 300
+
+// rule maps error text to a category and what to try
+type rule struct {
+	category   string
+	matches    []string // any of these, lower case
+	suggestion string
+}
+
+// rules are checked in order; the first match decides
+var rules = []rule{
+	{CategoryBlocked, []string{"blocked by hook", "prompt not sent", "plan cancelled"},
+		"The run was stopped on purpose; see the reason in the error."},
+	{CategoryAuth, []string{"401", "403", "unauthorized", "forbidden", "invalid api key", "invalid x-api-key", "authentication", "api key"},
+		"Check the API key (--provider-api-key or the provider's environment variable); `mcphost doctor` tests it."},
+	{CategoryRateLimit, []string{"429", "rate limit", "rate_limit", "too many requests", "quota", "overloaded"},
+		"Wait and run again, or use a model or plan with a higher limit."},
+	{CategoryContextLength, []string{"context length", "context_length", "context window", "maximum context", "too many tokens", "prompt is too long", "input is too long"},
+		"Shorten the prompt, attachments or session history, or use a model with a larger context window."},
+	{CategoryTimeout, []string{"deadline exceeded", "timeout", "timed out"},
+		"Raise --provider-timeout, or check whether the provider or a tool is hanging."},
+	{CategoryNetwork, []string{"connection refused", "no such host", "dial tcp", "connection reset", "eof", "tls handshake", "network is unreachable"},
+		"Check the network connection and --provider-url; `mcphost doctor` checks the endpoint."},
+	{CategoryProvider, []string{"failed to generate response", "failed to create model provider", "status code", "bad request", "invalid_request"},
+		"Run again with --debug to see the provider's response."},
+}
+
+// Analyze writes the post-mortem of a run that failed with runErr.
+// conversation holds the messages the run added before it failed.
+func Analyze(conversation []*schema.Message, runErr error) *Report {
+	r := &Report{Category: CategoryUnknown, Error: runErr.Error(), LastStep: "none: the first model call failed"}
+
+	category := CategoryUnknown
+	if errors.Is(runErr, context.DeadlineExceeded) {
+		category = CategoryTimeout
+	}
+	text := strings.ToLower(runErr.Error())
+	for _, rule := range rules {
+		if rule.category == category || (category == CategoryUnknown && containsAny(text, rule.matches)) {
+			r.Category, r.Suggestion = rule.category, rule.suggestion
+			break
+		}
+	}
+
+	names := make(map[string]string) // tool call ID -> tool name
+	for _, msg := range conversation {
+		switch msg.Role {
+		case schema.Assistant:
+			r.Steps++
+			r.LastStep = describeStep(r.Steps, msg)
+			for _, call := range msg.ToolCalls {
+				names[call.ID] = call.Function.Name
+			}
+		case schema.Tool:
+			if toolresult.IsError(msg) {
+				r.FailingTool = names[msg.ToolCallID]
+				if r.FailingTool == "" {
+					r.FailingTool = msg.ToolName
+				}
+				r.ToolError = truncate(strings.Join(strings.Fields(msg.Content), " "), maxToolError)
+			}
+		}
+	}
+
+	if r.Suggestion == "" {
+		r.Suggestion = "Run again with --debug to see the requests and responses."
+		if r.FailingTool != "" {
+			r.Suggestion = fmt.Sprintf("Check %s: the model may have given it wrong arguments, or its server may log why it failed.", r.FailingTool)
+		}
+	}
+	return r
+}
+
+// describeStep says what the model did in step n
+func describeStep(n int, msg *schema.Message) string {
+	if len(msg.ToolCalls) == 0 {
+		return fmt.Sprintf("%d: answered %q", n, truncate(strings.Join(strings.Fields(msg.Content), " "), 80))
+	}
+	names := make([]string, len(msg.ToolCalls))
+	for i, call := range msg.ToolCalls {
+		names[i] = call.Function.Name
+	}
+	return fmt.Sprintf("%d: called %s", n, strings.Join(names, ", "))
+}
+
+// String renders the report for a terminal
+func (r *Report) String() string {
+	var b strings.Builder
+	b.WriteString("Post-mortem:\n")
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %-13s %s\n", label+":", value)
+		}
+	}
+	line("Category", r.Category)
+	line("Last step", r.LastStep)
+	if r.FailingTool != "" {
+		line("Failing tool", r.FailingTool+": "+r.ToolError)
+	}
+	line("Error", r.Error)
+	line("Suggestion", r.Suggestion)
+	line("Summary", r.Summary)
+	return b.String()
+}
+
+// containsAny reports whether text contains any of substrings
+func containsAny(text string, substrings []string) bool {
+	for _, s := range substrings {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate shortens s to n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
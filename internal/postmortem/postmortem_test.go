@@ -0,0 +1,61 @@
+package postmortem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+func TestAnalyze(t *testing.T) {
+	failed := schema.ToolMessage("Tool execution error: open /etc/shadow: permission denied", "2")
+	toolresult.MarkError(failed)
+	conversation := []*schema.Message{
+		schema.UserMessage("Audit the config"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "fs__list", Arguments: "{}"}}}),
+		schema.ToolMessage("a.conf", "1"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "2", Function: schema.FunctionCall{Name: "fs__read", Arguments: `{"path": "/etc/shadow"}`}}}),
+		failed,
+	}
+
+	r := Analyze(conversation, errors.New("failed to generate response: 429 Too Many Requests: rate limit reached"))
+	if r.Category != CategoryRateLimit || r.Steps != 2 || r.LastStep != "2: called fs__read" {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if r.FailingTool != "fs__read" || !strings.Contains(r.ToolError, "permission denied") {
+		t.Errorf("expected the failed fs__read call, got %q: %q", r.FailingTool, r.ToolError)
+	}
+	text := r.String()
+	for _, want := range []string{"Category:     rate_limit", "Failing tool: fs__read: Tool execution error", "Suggestion:   Wait"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in\n%s", want, text)
+		}
+	}
+
+	for _, tc := range []struct {
+		err      error
+		category string
+	}{
+		{fmt.Errorf("failed to generate response: %w", context.DeadlineExceeded), CategoryTimeout},
+		{errors.New("error, status code: 401, message: invalid api key"), CategoryAuth},
+		{errors.New("prompt is too long: 210000 tokens > 200000 maximum"), CategoryContextLength},
+		{errors.New(`Post "http://localhost:11434/api/chat": dial tcp [::1]:11434: connect: connection refused`), CategoryNetwork},
+		{errors.New("prompt blocked by hook: no secrets"), CategoryBlocked},
+		{errors.New("failed to generate response: status code: 500"), CategoryProvider},
+		{errors.New("something odd"), CategoryUnknown},
+	} {
+		if r := Analyze(nil, tc.err); r.Category != tc.category || r.Suggestion == "" || r.Steps != 0 {
+			t.Errorf("expected %s for %q, got %+v", tc.category, tc.err, r)
+		}
+	}
+
+	// Without a category, the failing tool is what to check
+	if r := Analyze(conversation, errors.New("something odd")); !strings.HasPrefix(r.Suggestion, "Check fs__read") {
+		t.Errorf("expected the failing tool suggested, got %q", r.Suggestion)
+	}
+}
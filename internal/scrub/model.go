@@ -0,0 +1,178 @@
+package scrub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// chatModel masks outbound messages and unmasks responses around another model
+type chatModel struct {
+	model    model.ToolCallingChatModel
+	scrubber *Scrubber
+}
+
+// WrapModel returns a model that masks user, assistant and tool messages before
+// passing them to m, and restores the originals in m's responses (including
+// tool call arguments, so tools receive real values). System messages are
+// passed through unchanged.
+func WrapModel(m model.ToolCallingChatModel, s *Scrubber) model.ToolCallingChatModel {
+	if s == nil {
+		return m
+	}
+	return &chatModel{model: m, scrubber: s}
+}
+
+// Generate implements model.BaseChatModel
+func (c *chatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	msg, err := c.model.Generate(ctx, c.maskMessages(input), opts...)
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	c.unmaskMessage(msg)
+	return msg, nil
+}
+
+// Stream implements model.BaseChatModel. Placeholders can be split across
+// chunks, so text that might be the start of one is held back until the next
+// chunk shows whether it is.
+func (c *chatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	upstream, err := c.model.Stream(ctx, c.maskMessages(input), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer upstream.Close()
+		defer writer.Close()
+
+		content := &streamUnmasker{scrubber: c.scrubber}
+		args := make(map[int]*streamUnmasker)
+		var last *schema.Message
+		for {
+			chunk, err := upstream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				writer.Send(nil, err)
+				return
+			}
+			if chunk == nil {
+				continue
+			}
+			last = chunk
+			out := *chunk
+			out.Content = content.write(chunk.Content)
+			if len(chunk.ToolCalls) > 0 {
+				out.ToolCalls = make([]schema.ToolCall, len(chunk.ToolCalls))
+				for i, tc := range chunk.ToolCalls {
+					index := i
+					if tc.Index != nil {
+						index = *tc.Index
+					}
+					if args[index] == nil {
+						args[index] = &streamUnmasker{scrubber: c.scrubber}
+					}
+					tc.Function.Arguments = args[index].write(tc.Function.Arguments)
+					out.ToolCalls[i] = tc
+				}
+			}
+			if writer.Send(&out, nil) {
+				return
+			}
+		}
+
+		// Release whatever was held back
+		if last == nil {
+			return
+		}
+		tail := &schema.Message{Role: last.Role, Content: content.flush()}
+		for index, u := range args {
+			if rest := u.flush(); rest != "" {
+				i := index
+				tail.ToolCalls = append(tail.ToolCalls, schema.ToolCall{Index: &i, Function: schema.FunctionCall{Arguments: rest}})
+			}
+		}
+		if tail.Content != "" || len(tail.ToolCalls) > 0 {
+			writer.Send(tail, nil)
+		}
+	}()
+	return reader, nil
+}
+
+// WithTools implements model.ToolCallingChatModel
+func (c *chatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	m, err := c.model.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &chatModel{model: m, scrubber: c.scrubber}, nil
+}
+
+// maskMessages returns masked copies of the messages; the caller's history is
+// left untouched
+func (c *chatModel) maskMessages(input []*schema.Message) []*schema.Message {
+	out := make([]*schema.Message, len(input))
+	for i, msg := range input {
+		if msg == nil || msg.Role == schema.System {
+			out[i] = msg
+			continue
+		}
+		masked := *msg
+		masked.Content = c.scrubber.Mask(msg.Content)
+		if len(msg.MultiContent) > 0 {
+			masked.MultiContent = make([]schema.ChatMessagePart, len(msg.MultiContent))
+			for j, part := range msg.MultiContent {
+				if part.Type == schema.ChatMessagePartTypeText {
+					part.Text = c.scrubber.Mask(part.Text)
+				}
+				masked.MultiContent[j] = part
+			}
+		}
+		if len(msg.ToolCalls) > 0 {
+			masked.ToolCalls = make([]schema.ToolCall, len(msg.ToolCalls))
+			for j, tc := range msg.ToolCalls {
+				tc.Function.Arguments = c.scrubber.Mask(tc.Function.Arguments)
+				masked.ToolCalls[j] = tc
+			}
+		}
+		out[i] = &masked
+	}
+	return out
+}
+
+func (c *chatModel) unmaskMessage(msg *schema.Message) {
+	msg.Content = c.scrubber.Unmask(msg.Content)
+	for i := range msg.ToolCalls {
+		msg.ToolCalls[i].Function.Arguments = c.scrubber.Unmask(msg.ToolCalls[i].Function.Arguments)
+	}
+}
+
+// streamUnmasker unmasks a stream of text fragments, holding back a trailing
+// "[..." that may be the start of a placeholder
+type streamUnmasker struct {
+	scrubber *Scrubber
+	carry    string
+}
+
+func (u *streamUnmasker) write(fragment string) string {
+	text := u.carry + fragment
+	u.carry = ""
+	if open := strings.LastIndex(text, "["); open >= 0 && !strings.Contains(text[open:], "]") && len(text)-open < maxPlaceholderLen {
+		u.carry = text[open:]
+		text = text[:open]
+	}
+	return u.scrubber.Unmask(text)
+}
+
+func (u *streamUnmasker) flush() string {
+	text := u.carry
+	u.carry = ""
+	return u.scrubber.Unmask(text)
+}
@@ -0,0 +1,159 @@
+// Package scrub masks personal data in text before it leaves the machine and
+// restores it in what comes back, so remote models never see the originals but
+// tool calls still receive them.
+package scrub
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// rule finds one kind of personal data
+type rule struct {
+	kind    string
+	pattern *regexp.Regexp
+	valid   func(match string) bool // optional check to filter false positives
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`)
+	ipv6Pattern  = regexp.MustCompile(`(?i)\b[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}\b`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}(?:[\s.-]\d{2,4}){1,4}`)
+	datePattern  = regexp.MustCompile(`^\d{4}[-./]\d{1,2}[-./]\d{1,2}$|^\d{1,2}[-./]\d{1,2}[-./]\d{2,4}$`)
+)
+
+// builtinRules are applied in order; IPs go before phone numbers so dotted
+// addresses are not mistaken for numbers
+var builtinRules = []rule{
+	{kind: "EMAIL", pattern: emailPattern},
+	{kind: "IP", pattern: ipv4Pattern},
+	{kind: "IP", pattern: ipv6Pattern, valid: validIPv6},
+	{kind: "PHONE", pattern: phonePattern, valid: validPhone},
+}
+
+// placeholderPattern matches the placeholders a Scrubber produces
+var placeholderPattern = regexp.MustCompile(`\[(?:[A-Z]+)_\d+\]`)
+
+// maxPlaceholderLen bounds how much streamed text is held back while waiting
+// for a placeholder to complete
+const maxPlaceholderLen = 24
+
+// Scrubber replaces personal data with placeholders such as [EMAIL_1] and keeps
+// the mapping so the originals can be restored. The same value always gets the
+// same placeholder, which keeps a conversation coherent for the model. Safe for
+// concurrent use.
+type Scrubber struct {
+	rules    []rule
+	mu       sync.Mutex
+	masked   map[string]string // original -> placeholder
+	restored map[string]string // placeholder -> original
+	counts   map[string]int
+}
+
+// New creates a scrubber for emails, phone numbers and IP addresses, plus any
+// custom regular expressions (whose matches become [PATTERN_n])
+func New(customPatterns []string) (*Scrubber, error) {
+	rules := append([]rule{}, builtinRules...)
+	for _, expr := range customPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrub pattern %q: %w", expr, err)
+		}
+		// Custom patterns run first so they win over the built-in ones
+		rules = append([]rule{{kind: "PATTERN", pattern: re}}, rules...)
+	}
+	return &Scrubber{
+		rules:    rules,
+		masked:   make(map[string]string),
+		restored: make(map[string]string),
+		counts:   make(map[string]int),
+	}, nil
+}
+
+// Mask replaces personal data in text with placeholders
+func (s *Scrubber) Mask(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rules {
+		text = r.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if placeholderPattern.MatchString(match) || (r.valid != nil && !r.valid(match)) {
+				return match
+			}
+			return s.placeholder(r.kind, match)
+		})
+	}
+	return text
+}
+
+// placeholder returns the placeholder for value, allocating one if needed;
+// callers must hold the lock
+func (s *Scrubber) placeholder(kind, value string) string {
+	if p, ok := s.masked[value]; ok {
+		return p
+	}
+	s.counts[kind]++
+	p := fmt.Sprintf("[%s_%d]", kind, s.counts[kind])
+	s.masked[value] = p
+	s.restored[p] = value
+	return p
+}
+
+// Unmask puts the original values back in place of known placeholders
+func (s *Scrubber) Unmask(text string) string {
+	if s == nil || !strings.Contains(text, "[") {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		if original, ok := s.restored[p]; ok {
+			return original
+		}
+		return p
+	})
+}
+
+// Mappings returns the placeholders allocated so far, sorted, for debugging
+func (s *Scrubber) Mappings() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]string, 0, len(s.restored))
+	for p := range s.restored {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func validIPv6(match string) bool {
+	addr, err := netip.ParseAddr(match)
+	// Require a digit so words like "add::" or "dead::beef" in code are left alone
+	return err == nil && addr.Is6() && strings.ContainsAny(match, "0123456789") && len(match) >= 6
+}
+
+func validPhone(match string) bool {
+	if datePattern.MatchString(match) {
+		return false
+	}
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 9 && digits <= 15
+}
@@ -0,0 +1,174 @@
+package scrub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestMaskAndUnmask(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "Mail jane.doe@example.com or call +1 415-555-0132, server at 10.0.0.12 and fe80::1ff:fe23:4567:890a. Mail jane.doe@example.com again."
+	masked := s.Mask(input)
+
+	for _, secret := range []string{"jane.doe@example.com", "415-555-0132", "10.0.0.12", "fe80::1ff:fe23:4567:890a"} {
+		if strings.Contains(masked, secret) {
+			t.Errorf("masked text still contains %q: %s", secret, masked)
+		}
+	}
+	if strings.Count(masked, "[EMAIL_1]") != 2 {
+		t.Errorf("expected the same email to get the same placeholder twice: %s", masked)
+	}
+	if got := s.Unmask(masked); got != input {
+		t.Errorf("round trip mismatch:\n got %q\nwant %q", got, input)
+	}
+	// Masking already-masked text is a no-op
+	if again := s.Mask(masked); again != masked {
+		t.Errorf("masking twice changed the text: %q", again)
+	}
+}
+
+func TestMaskLeavesOrdinaryTextAlone(t *testing.T) {
+	s, _ := New(nil)
+	for _, text := range []string{
+		"Released on 2024-01-15 at 12:30:45",
+		"version 1.2.3 is out",
+		"took 250 ms over 3 runs",
+		"see map[string]int and [1, 2, 3]",
+	} {
+		if got := s.Mask(text); got != text {
+			t.Errorf("Mask(%q) = %q, want unchanged", text, got)
+		}
+	}
+}
+
+func TestCustomPatterns(t *testing.T) {
+	s, err := New([]string{`ACME-\d{6}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Mask("ticket ACME-123456 is open"); got != "ticket [PATTERN_1] is open" {
+		t.Errorf("unexpected mask: %q", got)
+	}
+
+	if _, err := New([]string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestNilScrubber(t *testing.T) {
+	var s *Scrubber
+	if s.Mask("a@b.com") != "a@b.com" || s.Unmask("[EMAIL_1]") != "[EMAIL_1]" {
+		t.Error("nil scrubber should pass text through")
+	}
+}
+
+// fakeModel records what it was sent and replies with fixed chunks
+type fakeModel struct {
+	got    []*schema.Message
+	chunks []*schema.Message
+}
+
+func (f *fakeModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	f.got = input
+	return schema.ConcatMessages(f.chunks)
+}
+
+func (f *fakeModel) Stream(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	f.got = input
+	return schema.StreamReaderFromArray(f.chunks), nil
+}
+
+func (f *fakeModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return f, nil
+}
+
+func TestWrapModelGenerate(t *testing.T) {
+	s, _ := New(nil)
+	fake := &fakeModel{chunks: []*schema.Message{
+		schema.AssistantMessage("Sending to [EMAIL_1]", []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "mail__send", Arguments: `{"to":"[EMAIL_1]"}`}},
+		}),
+	}}
+	m := WrapModel(fake, s)
+
+	history := []*schema.Message{
+		schema.SystemMessage("Admin is root@example.com"),
+		schema.UserMessage("Email bob@example.com"),
+	}
+	msg, err := m.Generate(context.Background(), history)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.got[0].Content != "Admin is root@example.com" {
+		t.Errorf("system prompt should not be masked: %q", fake.got[0].Content)
+	}
+	if fake.got[1].Content != "Email [EMAIL_1]" {
+		t.Errorf("user message not masked: %q", fake.got[1].Content)
+	}
+	if history[1].Content != "Email bob@example.com" {
+		t.Errorf("caller's history was modified: %q", history[1].Content)
+	}
+	if msg.Content != "Sending to bob@example.com" {
+		t.Errorf("response not unmasked: %q", msg.Content)
+	}
+	if msg.ToolCalls[0].Function.Arguments != `{"to":"bob@example.com"}` {
+		t.Errorf("tool arguments not unmasked: %q", msg.ToolCalls[0].Function.Arguments)
+	}
+}
+
+func TestWrapModelStreamSplitPlaceholder(t *testing.T) {
+	s, _ := New(nil)
+	s.Mask("bob@example.com")
+
+	index := 0
+	fake := &fakeModel{chunks: []*schema.Message{
+		{Role: schema.Assistant, Content: "Hi [EM"},
+		{Role: schema.Assistant, Content: "AIL_"},
+		{Role: schema.Assistant, Content: "1], see [docs"},
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{Index: &index, ID: "1", Function: schema.FunctionCall{Name: "mail__send", Arguments: `{"to":"[EMA`}}}},
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{Index: &index, Function: schema.FunctionCall{Arguments: `IL_1]"}`}}}},
+	}}
+
+	reader, err := WrapModel(fake, s).Stream(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(chunk.Content, "[EM") {
+			t.Errorf("partial placeholder leaked into chunk: %q", chunk.Content)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "Hi bob@example.com, see [docs" {
+		t.Errorf("unexpected content: %q", msg.Content)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"to":"bob@example.com"}` {
+		t.Errorf("unexpected tool calls: %+v", msg.ToolCalls)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// APIKey is a credential accepted by the server
+type APIKey struct {
+	Name              string `json:"name" yaml:"name" mapstructure:"name"`
+	Key               string `json:"key" yaml:"key" mapstructure:"key"`
+	RequestsPerMinute int    `json:"requests-per-minute,omitempty" yaml:"requests-per-minute,omitempty" mapstructure:"requests-per-minute"` // 0 means unlimited
+}
+
+// apiKeyContextKey stores the authenticated key name in the request context
+type apiKeyContextKey struct{}
+
+// authenticator checks API keys and enforces their rate limits
+type authenticator struct {
+	keys     []APIKey
+	limiters map[string]*rate.Limiter // by key name
+}
+
+func newAuthenticator(keys []APIKey) *authenticator {
+	a := &authenticator{
+		keys:     keys,
+		limiters: make(map[string]*rate.Limiter),
+	}
+	for _, key := range keys {
+		if key.RequestsPerMinute > 0 {
+			a.limiters[key.Name] = rate.NewLimiter(rate.Every(time.Minute/time.Duration(key.RequestsPerMinute)), key.RequestsPerMinute)
+		}
+	}
+	return a
+}
+
+// lookup returns the key matching the presented secret
+func (a *authenticator) lookup(secret string) (APIKey, bool) {
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(secret)) == 1 {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// wait returns how long key has to wait for its rate limit to allow another
+// request, or 0 when the request may go ahead now
+func (a *authenticator) wait(key APIKey) time.Duration {
+	limiter, limited := a.limiters[key.Name]
+	if !limited {
+		return 0
+	}
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+	}
+	return delay
+}
+
+// middleware rejects requests without a valid key and requests over the key's
+// rate limit. Health checks and metrics scrapes are not authenticated.
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		secret := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			secret = strings.TrimSpace(bearer)
+		}
+		if secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcphost"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing API key"))
+			return
+		}
+
+		key, ok := a.lookup(secret)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcphost"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid API key"))
+			return
+		}
+
+		if delay := a.wait(key); delay > 0 {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(delay.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded for API key %q", key.Name))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key.Name)))
+	})
+}
+
+// apiKeyName returns the name of the key that authenticated the request, if any
+func apiKeyName(ctx context.Context) string {
+	name, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return name
+}
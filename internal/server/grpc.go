@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/osi4iot/mcphost/internal/metrics"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/sdk/mcphostpb"
+)
+
+// grpcService implements the gRPC API on the same agent, sessions and keys as
+// the HTTP API
+type grpcService struct {
+	mcphostpb.UnimplementedMCPHostServer
+	s *Server
+}
+
+// GRPCServer returns a gRPC server exposing the API described in
+// proto/mcphost/v1/mcphost.proto
+func (s *Server) GRPCServer() *grpc.Server {
+	var opts []grpc.ServerOption
+	if len(s.auth.keys) > 0 {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(s.auth.unaryInterceptor),
+			grpc.ChainStreamInterceptor(s.auth.streamInterceptor),
+		)
+	}
+	g := grpc.NewServer(opts...)
+	mcphostpb.RegisterMCPHostServer(g, &grpcService{s: s})
+	return g
+}
+
+func (g *grpcService) Prompt(ctx context.Context, in *mcphostpb.PromptRequest) (*mcphostpb.PromptResponse, error) {
+	req, err := g.promptRequest(in)
+	if err != nil {
+		return nil, err
+	}
+	resp, code, err := g.s.prompt(ctx, req, nil)
+	if err != nil {
+		return nil, status.Error(grpcCode(code), err.Error())
+	}
+	return promptResponseToProto(resp), nil
+}
+
+// StreamEvents runs the prompt, sending its events as they happen. The
+// response event is held back to go last, with the turn's result.
+func (g *grpcService) StreamEvents(in *mcphostpb.PromptRequest, stream grpc.ServerStreamingServer[mcphostpb.Event]) error {
+	req, err := g.promptRequest(in)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.Mutex // tools may report from several goroutines
+		sendErr  error
+		response *mcphostpb.Event
+	)
+	resp, code, err := g.s.prompt(stream.Context(), req, func(event JobEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if event.Type == "response" {
+			response = jobEventToProto(event)
+			return
+		}
+		if sendErr == nil {
+			sendErr = stream.Send(jobEventToProto(event))
+		}
+	})
+	if err != nil {
+		return status.Error(grpcCode(code), err.Error())
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	if response == nil {
+		response = &mcphostpb.Event{Time: timestamppb.Now(), Type: "response", Message: resp.Response}
+	}
+	response.Result = promptResponseToProto(resp)
+	return stream.Send(response)
+}
+
+func (g *grpcService) ListTools(ctx context.Context, in *mcphostpb.ListToolsRequest) (*mcphostpb.ListToolsResponse, error) {
+	details := g.s.agent.GetToolDetails()
+	tools := make([]*mcphostpb.Tool, 0, len(details))
+	for _, d := range details {
+		tools = append(tools, &mcphostpb.Tool{
+			Name:         d.Name,
+			Server:       d.Server,
+			OriginalName: d.OriginalName,
+			Description:  d.Description,
+			InputSchema:  string(d.InputSchema),
+		})
+	}
+	return &mcphostpb.ListToolsResponse{Tools: tools}, nil
+}
+
+func (g *grpcService) ListSessions(ctx context.Context, in *mcphostpb.ListSessionsRequest) (*mcphostpb.ListSessionsResponse, error) {
+	ids, err := g.s.listSessions(ctx)
+	if err != nil {
+		g.s.metrics.ObserveError(metrics.ErrorSession)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &mcphostpb.ListSessionsResponse{SessionIds: ids}, nil
+}
+
+func (g *grpcService) GetSession(ctx context.Context, in *mcphostpb.GetSessionRequest) (*mcphostpb.Session, error) {
+	if err := validateSessionID(in.GetSessionId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sess, err := g.s.loadSession(ctx, in.GetSessionId())
+	if err != nil {
+		return nil, storeStatus(err)
+	}
+	return sessionToProto(in.GetSessionId(), sess), nil
+}
+
+func (g *grpcService) DeleteSession(ctx context.Context, in *mcphostpb.DeleteSessionRequest) (*mcphostpb.DeleteSessionResponse, error) {
+	if err := validateSessionID(in.GetSessionId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := g.s.deleteSession(ctx, in.GetSessionId()); err != nil {
+		return nil, storeStatus(err)
+	}
+	return &mcphostpb.DeleteSessionResponse{}, nil
+}
+
+// promptRequest validates a gRPC prompt like handlePrompt does a JSON one
+func (g *grpcService) promptRequest(in *mcphostpb.PromptRequest) (*PromptRequest, error) {
+	if in.GetPrompt() == "" {
+		g.s.metrics.ObserveError(metrics.ErrorBadRequest)
+		return nil, status.Error(codes.InvalidArgument, "prompt is required")
+	}
+	req := &PromptRequest{
+		Prompt:    in.GetPrompt(),
+		SessionID: in.GetSessionId(),
+		WorkDir:   in.GetWorkDir(),
+		Env:       in.GetEnv(),
+	}
+	if req.SessionID == "" {
+		req.SessionID = generateSessionID()
+	}
+	if err := validateSessionID(req.SessionID); err != nil {
+		g.s.metrics.ObserveError(metrics.ErrorBadRequest)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return req, nil
+}
+
+// grpcCode maps the HTTP status prompt returns to a gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func storeStatus(err error) error {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, session.ErrInvalidSessionID) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func promptResponseToProto(resp *PromptResponse) *mcphostpb.PromptResponse {
+	out := &mcphostpb.PromptResponse{
+		SessionId:        resp.SessionID,
+		TurnId:           resp.TurnID,
+		Response:         resp.Response,
+		InputTokens:      int64(resp.InputTokens),
+		OutputTokens:     int64(resp.OutputTokens),
+		CacheReadTokens:  int64(resp.CacheReadTokens),
+		CacheWriteTokens: int64(resp.CacheWriteTokens),
+		ReasoningTokens:  int64(resp.ReasoningTokens),
+	}
+	if v := resp.Guardrail; v != nil {
+		out.Guardrail = &mcphostpb.GuardrailVerdict{Flagged: v.Flagged, Action: v.Action, Reasons: v.Reasons}
+	}
+	for _, c := range resp.Citations {
+		out.Citations = append(out.Citations, &mcphostpb.Citation{Number: int32(c.Number), Source: c.Source, Tool: c.Tool})
+	}
+	return out
+}
+
+func jobEventToProto(event JobEvent) *mcphostpb.Event {
+	return &mcphostpb.Event{
+		Time:    timestamppb.New(event.Time),
+		Type:    event.Type,
+		Tool:    event.Tool,
+		Message: event.Message,
+	}
+}
+
+func sessionToProto(id string, sess *session.Session) *mcphostpb.Session {
+	out := &mcphostpb.Session{
+		SessionId: id,
+		CreatedAt: timestamppb.New(sess.CreatedAt),
+		UpdatedAt: timestamppb.New(sess.UpdatedAt),
+		Provider:  sess.Metadata.Provider,
+		Model:     sess.Metadata.Model,
+		Messages:  make([]*mcphostpb.Message, 0, len(sess.Messages)),
+	}
+	for _, msg := range sess.Messages {
+		m := &mcphostpb.Message{
+			Id:         msg.ID,
+			Role:       msg.Role,
+			Content:    msg.Content,
+			Timestamp:  timestamppb.New(msg.Timestamp),
+			ToolCallId: msg.ToolCallID,
+			TurnId:     msg.TurnID,
+		}
+		for _, call := range msg.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, &mcphostpb.ToolCall{
+				Id:        call.ID,
+				Name:      call.Name,
+				Arguments: toolArguments(call.Arguments),
+			})
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	return out
+}
+
+// toolArguments returns a stored tool call's arguments as JSON; they are kept
+// as the model's JSON string or, in older sessions, decoded
+func toolArguments(args any) string {
+	if s, ok := args.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// authorize checks the API key in a gRPC call's metadata like middleware does
+// the HTTP headers, and returns the context carrying the key's name
+func (a *authenticator) authorize(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	secret := firstValue(md, "x-api-key")
+	if bearer, ok := strings.CutPrefix(firstValue(md, "authorization"), "Bearer "); ok {
+		secret = strings.TrimSpace(bearer)
+	}
+	if secret == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+
+	key, ok := a.lookup(secret)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if delay := a.wait(key); delay > 0 {
+		return nil, status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("rate limit exceeded for API key %q; retry in %ds", key.Name, int(math.Ceil(delay.Seconds()))))
+	}
+	return context.WithValue(ctx, apiKeyContextKey{}, key.Name), nil
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorizedStream is a server stream whose context carries the API key name
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/sdk/mcphostpb"
+)
+
+// dialGRPC serves srv's gRPC API in memory and returns a client for it
+func dialGRPC(t *testing.T, srv *Server) mcphostpb.MCPHostClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	g := srv.GRPCServer()
+	go g.Serve(listener)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return mcphostpb.NewMCPHostClient(conn)
+}
+
+func TestGRPCPromptAndSessions(t *testing.T) {
+	store := session.NewMemoryStore()
+	client := dialGRPC(t, New(&Config{Agent: &fakeAgent{}, Store: store, ModelString: "test:model"}))
+	ctx := context.Background()
+
+	resp, err := client.Prompt(ctx, &mcphostpb.PromptRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	if resp.GetSessionId() == "" || resp.GetResponse() != "echo: hello" || resp.GetInputTokens() != 10 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+
+	if _, err := client.Prompt(ctx, &mcphostpb.PromptRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an empty prompt, got %v", err)
+	}
+
+	list, err := client.ListSessions(ctx, &mcphostpb.ListSessionsRequest{})
+	if err != nil || len(list.GetSessionIds()) != 1 || list.GetSessionIds()[0] != resp.GetSessionId() {
+		t.Fatalf("unexpected sessions %v: %v", list.GetSessionIds(), err)
+	}
+	sess, err := client.GetSession(ctx, &mcphostpb.GetSessionRequest{SessionId: resp.GetSessionId()})
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if len(sess.GetMessages()) != 2 || sess.GetMessages()[1].GetContent() != "echo: hello" {
+		t.Errorf("unexpected session messages: %v", sess.GetMessages())
+	}
+
+	if _, err := client.DeleteSession(ctx, &mcphostpb.DeleteSessionRequest{SessionId: resp.GetSessionId()}); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+	if _, err := client.GetSession(ctx, &mcphostpb.GetSessionRequest{SessionId: resp.GetSessionId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after delete, got %v", err)
+	}
+
+	tools, err := client.ListTools(ctx, &mcphostpb.ListToolsRequest{})
+	if err != nil || len(tools.GetTools()) != 1 || tools.GetTools()[0].GetOriginalName() != "read_file" {
+		t.Errorf("unexpected tools %v: %v", tools.GetTools(), err)
+	}
+}
+
+func TestGRPCSessionIDTraversal(t *testing.T) {
+	client := dialGRPC(t, New(&Config{Agent: &fakeAgent{}, Store: session.NewFileStore(t.TempDir())}))
+	ctx := context.Background()
+
+	id := "../outside"
+	if _, err := client.Prompt(ctx, &mcphostpb.PromptRequest{Prompt: "hello", SessionId: id}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Prompt: expected InvalidArgument, got %v", err)
+	}
+	if _, err := client.GetSession(ctx, &mcphostpb.GetSessionRequest{SessionId: id}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetSession: expected InvalidArgument, got %v", err)
+	}
+	if _, err := client.DeleteSession(ctx, &mcphostpb.DeleteSessionRequest{SessionId: id}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteSession: expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCStreamEvents(t *testing.T) {
+	client := dialGRPC(t, New(&Config{Agent: &fakeAgent{}}))
+
+	stream, err := client.StreamEvents(context.Background(), &mcphostpb.PromptRequest{Prompt: "hello", SessionId: "s1"})
+	if err != nil {
+		t.Fatalf("StreamEvents failed: %v", err)
+	}
+	var types []string
+	var last *mcphostpb.Event
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		types = append(types, event.GetType())
+		last = event
+	}
+
+	want := []string{"tool_call", "tool_result", "response"}
+	if len(types) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], types[i])
+		}
+	}
+	if last.GetResult().GetResponse() != "echo: hello" || last.GetResult().GetSessionId() != "s1" {
+		t.Errorf("expected the last event to carry the result, got %v", last)
+	}
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	client := dialGRPC(t, New(&Config{
+		Agent:   &fakeAgent{},
+		APIKeys: []APIKey{{Name: "ops", Key: "secret-ops", RequestsPerMinute: 1}},
+	}))
+	call := func(md ...string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), md...)
+		_, err := client.ListTools(ctx, &mcphostpb.ListToolsRequest{})
+		return err
+	}
+
+	if err := call(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a key, got %v", err)
+	}
+	if err := call("authorization", "Bearer wrong"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated with a wrong key, got %v", err)
+	}
+	if err := call("authorization", "Bearer secret-ops"); err != nil {
+		t.Errorf("expected the key to be accepted, got %v", err)
+	}
+	if err := call("x-api-key", "secret-ops"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted over the rate limit, got %v", err)
+	}
+}
+
+func TestPromptResponseToProtoCitations(t *testing.T) {
+	out := promptResponseToProto(&PromptResponse{
+		Response:  "Go 1.24 added generic type aliases [1].",
+		Citations: []agent.Citation{{Number: 1, Source: "https://go.dev/doc/go1.24", Tool: "fetch"}},
+	})
+	if len(out.Citations) != 1 {
+		t.Fatalf("expected the citation in the response, got %v", out.Citations)
+	}
+	if c := out.Citations[0]; c.Number != 1 || c.Source != "https://go.dev/doc/go1.24" || c.Tool != "fetch" {
+		t.Errorf("unexpected citation %v", c)
+	}
+}
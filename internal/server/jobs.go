@@ -0,0 +1,549 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/webhook"
+)
+
+// Job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// maxEventMessage caps the text kept for each job log event
+const maxEventMessage = 1000
+
+// JobRequest is the body of POST /v1/jobs
+type JobRequest = PromptRequest
+
+// Job is a prompt run in the background, submitted with POST /v1/jobs
+type Job struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	Prompt     string          `json:"prompt"`
+	SessionID  string          `json:"session_id"`
+	APIKey     string          `json:"api_key,omitempty"` // name of the key that submitted it
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Result     *PromptResponse `json:"result,omitempty"` // set once the job succeeds
+	Error      string          `json:"error,omitempty"`
+}
+
+// Done reports whether the job has finished, successfully or not
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// JobEvent is one entry in a job's log
+type JobEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"` // tool_call, tool_result, tool_error, response or webhook_error
+	Tool    string    `json:"tool,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// JobLogs is returned by GET /v1/jobs/{id}/logs
+type JobLogs struct {
+	Status string     `json:"status"`
+	Events []JobEvent `json:"events"`
+	Next   int        `json:"next"` // pass as ?after= to get only newer events
+}
+
+// jobRecord is a job with its log, as kept in memory and on disk
+type jobRecord struct {
+	Job
+	Events []JobEvent `json:"events"`
+}
+
+// jobQueue runs submitted jobs in order, at most workers at a time, and
+// persists them to dir when set
+type jobQueue struct {
+	dir     string
+	workers int
+	wg      sync.WaitGroup
+
+	// Finished jobs are pruned retention after they finish and, beyond
+	// maxJobs jobs, oldest first; zero disables either limit
+	retention time.Duration
+	maxJobs   int
+
+	mu      sync.Mutex
+	jobs    map[string]*jobRecord
+	cancels map[string]context.CancelFunc
+	pending []queuedJob
+	active  int // running workers
+
+	// onDone is called in the background with each job that finishes
+	onDone func(Job)
+}
+
+// queuedJob is a submitted job waiting for a worker
+type queuedJob struct {
+	id  string
+	ctx context.Context
+	req *JobRequest
+}
+
+func newJobQueue(dir string, workers int, retention time.Duration, maxJobs int) *jobQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+	q := &jobQueue{
+		dir:       dir,
+		workers:   workers,
+		retention: retention,
+		maxJobs:   maxJobs,
+		jobs:      make(map[string]*jobRecord),
+		cancels:   make(map[string]context.CancelFunc),
+	}
+	q.load()
+	q.prune()
+	return q
+}
+
+// load reads jobs persisted by earlier runs. Jobs that were still queued or
+// running when the previous server stopped are marked failed.
+func (q *jobQueue) load() {
+	if q.dir == "" {
+		return
+	}
+	paths, _ := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var rec jobRecord
+		if err := json.Unmarshal(data, &rec); err != nil || rec.ID == "" {
+			continue
+		}
+		if !rec.Done() {
+			now := time.Now()
+			rec.Status = JobFailed
+			rec.Error = "interrupted: the server stopped before the job finished"
+			rec.FinishedAt = &now
+			q.save(&rec)
+		}
+		q.jobs[rec.ID] = &rec
+	}
+}
+
+// save writes rec to disk; the caller holds q.mu or owns rec exclusively
+func (q *jobQueue) save(rec *jobRecord) {
+	if q.dir == "" {
+		return
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(q.dir, 0o700); err != nil {
+		return
+	}
+	// Write then rename so readers never see a partial file
+	tmp := filepath.Join(q.dir, rec.ID+".json.tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	os.Rename(tmp, filepath.Join(q.dir, rec.ID+".json"))
+}
+
+// remove forgets a job and deletes its file; the caller holds q.mu or owns q
+// exclusively
+func (q *jobQueue) remove(id string) {
+	delete(q.jobs, id)
+	if q.dir != "" {
+		os.Remove(filepath.Join(q.dir, id+".json"))
+	}
+}
+
+// prune removes the finished jobs older than the retention period, then the
+// oldest finished jobs while there are more than maxJobs; the caller holds
+// q.mu or owns q exclusively
+func (q *jobQueue) prune() {
+	var finished []*jobRecord
+	for id, rec := range q.jobs {
+		if !rec.Done() || rec.FinishedAt == nil {
+			continue
+		}
+		if q.retention > 0 && time.Since(*rec.FinishedAt) > q.retention {
+			q.remove(id)
+			continue
+		}
+		finished = append(finished, rec)
+	}
+	if q.maxJobs <= 0 || len(q.jobs) <= q.maxJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.Before(*finished[j].FinishedAt) })
+	for _, rec := range finished {
+		if len(q.jobs) <= q.maxJobs {
+			break
+		}
+		q.remove(rec.ID)
+	}
+}
+
+// delete removes a finished job visible to keyName. It fails with
+// errJobNotFound, or another error for a job that is still queued or running.
+func (q *jobQueue) delete(id, keyName string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rec, ok := q.jobs[id]
+	if !ok || !visibleTo(&rec.Job, keyName) {
+		return errJobNotFound
+	}
+	if !rec.Done() {
+		return fmt.Errorf("job %s is still %s; cancel it first", id, rec.Status)
+	}
+	q.remove(id)
+	return nil
+}
+
+// update applies fn to the job and persists it
+func (q *jobQueue) update(id string, fn func(rec *jobRecord)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if rec, ok := q.jobs[id]; ok {
+		fn(rec)
+		q.save(rec)
+	}
+}
+
+// get returns a copy of the job visible to keyName
+func (q *jobQueue) get(id, keyName string) (jobRecord, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rec, ok := q.jobs[id]
+	if !ok || !visibleTo(&rec.Job, keyName) {
+		return jobRecord{}, false
+	}
+	copied := *rec
+	copied.Events = append([]JobEvent(nil), rec.Events...)
+	return copied, true
+}
+
+// list returns the jobs visible to keyName, oldest first
+func (q *jobQueue) list(keyName string) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := []Job{}
+	for _, rec := range q.jobs {
+		if visibleTo(&rec.Job, keyName) {
+			jobs = append(jobs, rec.Job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// cancel stops a running job, or drops a queued one before it starts
+func (q *jobQueue) cancel(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+	}
+	for i, item := range q.pending {
+		if item.id == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.finishCancelled(id)
+			break
+		}
+	}
+}
+
+// finishCancelled records a job cancelled before it ran; the caller holds q.mu
+func (q *jobQueue) finishCancelled(id string) {
+	rec := q.jobs[id]
+	now := time.Now()
+	rec.Status = JobCancelled
+	rec.FinishedAt = &now
+	q.save(rec)
+	delete(q.cancels, id)
+	q.done(rec.Job)
+}
+
+// done runs onDone for a finished job; the caller holds q.mu
+func (q *jobQueue) done(job Job) {
+	if q.onDone == nil {
+		return
+	}
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.onDone(job)
+	}()
+}
+
+// shutdown cancels every unfinished job and waits for them to be recorded
+func (q *jobQueue) shutdown() {
+	q.mu.Lock()
+	for _, item := range q.pending {
+		q.finishCancelled(item.id)
+	}
+	q.pending = nil
+	for _, cancel := range q.cancels {
+		cancel()
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// visibleTo reports whether keyName may see job; with authentication
+// disabled every job is visible
+func visibleTo(job *Job, keyName string) bool {
+	return keyName == "" || job.APIKey == keyName
+}
+
+// submitJob queues req and runs it in the background
+func (s *Server) submitJob(keyName string, req *JobRequest) Job {
+	rec := &jobRecord{
+		Job: Job{
+			ID:        generateJobID(),
+			Status:    JobQueued,
+			Prompt:    req.Prompt,
+			SessionID: req.SessionID,
+			APIKey:    keyName,
+			CreatedAt: time.Now(),
+		},
+		Events: []JobEvent{},
+	}
+
+	// Jobs outlive the request that submitted them but are still accounted to its key
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), apiKeyContextKey{}, keyName))
+
+	q := s.jobs
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	q.jobs[rec.ID] = rec
+	q.cancels[rec.ID] = cancel
+	q.save(rec)
+	q.pending = append(q.pending, queuedJob{id: rec.ID, ctx: ctx, req: req})
+	if q.active < q.workers {
+		q.active++
+		q.wg.Add(1)
+		go s.jobWorker()
+	}
+	return rec.Job
+}
+
+// jobWorker runs queued jobs until the queue is empty
+func (s *Server) jobWorker() {
+	q := s.jobs
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.active--
+			q.mu.Unlock()
+			return
+		}
+		item := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		s.runJob(item.ctx, item.id, item.req)
+
+		q.mu.Lock()
+		if cancel, ok := q.cancels[item.id]; ok {
+			cancel()
+			delete(q.cancels, item.id)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// runJob runs the job's prompt and records the outcome
+func (s *Server) runJob(ctx context.Context, id string, req *JobRequest) {
+	q := s.jobs
+	q.update(id, func(rec *jobRecord) {
+		now := time.Now()
+		rec.Status = JobRunning
+		rec.StartedAt = &now
+	})
+
+	resp, _, err := s.prompt(ctx, req, func(event JobEvent) {
+		q.update(id, func(rec *jobRecord) {
+			rec.Events = append(rec.Events, event)
+		})
+	})
+
+	q.update(id, func(rec *jobRecord) {
+		now := time.Now()
+		rec.FinishedAt = &now
+		switch {
+		case err == nil:
+			rec.Status = JobSucceeded
+			rec.Result = resp
+		case ctx.Err() != nil:
+			rec.Status = JobCancelled
+		default:
+			rec.Status = JobFailed
+			rec.Error = err.Error()
+		}
+		q.done(rec.Job)
+	})
+}
+
+// notifyJobDone posts a finished job to the webhooks, noting failed
+// deliveries in the job's log
+func (s *Server) notifyJobDone(job Job) {
+	if s.webhooks == nil {
+		return
+	}
+	payload := &webhook.Payload{
+		Event:      webhook.EventJob,
+		Status:     job.Status,
+		JobID:      job.ID,
+		SessionID:  job.SessionID,
+		Model:      s.modelString,
+		Prompt:     job.Prompt,
+		Error:      job.Error,
+		StartedAt:  *job.FinishedAt,
+		FinishedAt: *job.FinishedAt,
+	}
+	if job.StartedAt != nil {
+		payload.StartedAt = *job.StartedAt
+	}
+	if s.baseURL != "" {
+		payload.Session = s.baseURL + "/v1/sessions/" + job.SessionID
+	}
+	if result := job.Result; result != nil {
+		payload.Response = result.Response
+		payload.Usage = webhook.NewUsage(s.modelString, models.TokenCounts{
+			InputTokens:      result.InputTokens,
+			OutputTokens:     result.OutputTokens,
+			CacheReadTokens:  result.CacheReadTokens,
+			CacheWriteTokens: result.CacheWriteTokens,
+			ReasoningTokens:  result.ReasoningTokens,
+		})
+	}
+
+	if err := s.webhooks.Send(context.Background(), payload); err != nil {
+		s.jobs.update(job.ID, func(rec *jobRecord) {
+			rec.Events = append(rec.Events, newJobEvent("webhook_error", "", err.Error()))
+		})
+	}
+}
+
+// newJobEvent returns a log event, truncating long messages
+func newJobEvent(eventType, tool, message string) JobEvent {
+	if len(message) > maxEventMessage {
+		message = strings.ToValidUTF8(message[:maxEventMessage], "") + "…"
+	}
+	return JobEvent{Time: time.Now(), Type: eventType, Tool: tool, Message: message}
+}
+
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Prompt == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prompt is required"))
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = generateSessionID()
+	}
+	if err := validateSessionID(req.SessionID); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The job would fail on this when it runs; refuse it now instead
+	if _, err := s.requestWorkspace(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.submitJob(apiKeyName(r.Context()), &req))
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]Job{"jobs": s.jobs.list(apiKeyName(r.Context()))})
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.jobs.get(r.PathValue("id"), apiKeyName(r.Context()))
+	if !ok {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec.Job)
+}
+
+func (s *Server) handleJobLogs(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.jobs.get(r.PathValue("id"), apiKeyName(r.Context()))
+	if !ok {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	after := 0
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid after value %q", v))
+			return
+		}
+		after = min(n, len(rec.Events))
+	}
+	writeJSON(w, http.StatusOK, JobLogs{Status: rec.Status, Events: rec.Events[after:], Next: len(rec.Events)})
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	rec, ok := s.jobs.get(id, apiKeyName(r.Context()))
+	if !ok {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	if rec.Done() {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s has already %s", id, rec.Status))
+		return
+	}
+	s.jobs.cancel(id)
+	writeJSON(w, http.StatusAccepted, rec.Job)
+}
+
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	err := s.jobs.delete(r.PathValue("id"), apiKeyName(r.Context()))
+	switch {
+	case errors.Is(err, errJobNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusConflict, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+var errJobNotFound = errors.New("job not found")
+
+// generateJobID generates a random job ID
+func generateJobID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return "job_" + hex.EncodeToString(bytes)
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/webhook"
+)
+
+// blockingAgent blocks every prompt until it is cancelled
+type blockingAgent struct {
+	fakeAgent
+}
+
+func (b *blockingAgent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*schema.Message,
+	onToolCall agent.ToolCallHandler, onToolExecution agent.ToolExecutionHandler, onToolResult agent.ToolResultHandler,
+	onResponse agent.ResponseHandler, onToolCallContent agent.ToolCallContentHandler, onStreamingResponse agent.StreamingResponseHandler) (*agent.GenerateWithLoopResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func doJSON(t *testing.T, handler http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("failed to decode %s %s: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// waitForJob polls the job until it reaches status
+func waitForJob(t *testing.T, handler http.Handler, id, status string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var job Job
+		doJSON(t, handler, http.MethodGet, "/v1/jobs/"+id, "", &job)
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, expected %s", id, job.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	handler := New(&Config{Agent: &fakeAgent{}, JobsDir: dir}).Handler()
+
+	var submitted Job
+	if code := doJSON(t, handler, http.MethodPost, "/v1/jobs", `{"prompt": "summarize"}`, &submitted); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	if !strings.HasPrefix(submitted.ID, "job_") || submitted.SessionID == "" {
+		t.Fatalf("unexpected job %+v", submitted)
+	}
+
+	job := waitForJob(t, handler, submitted.ID, JobSucceeded)
+	if job.Result == nil || job.Result.Response != "echo: summarize" || job.StartedAt == nil || job.FinishedAt == nil {
+		t.Errorf("unexpected finished job %+v", job)
+	}
+
+	var logs JobLogs
+	doJSON(t, handler, http.MethodGet, "/v1/jobs/"+job.ID+"/logs", "", &logs)
+	if len(logs.Events) != 3 || logs.Events[0].Type != "tool_call" || logs.Events[1].Message != "file contents" ||
+		logs.Events[2].Type != "response" || logs.Next != 3 {
+		t.Errorf("unexpected logs %+v", logs)
+	}
+	doJSON(t, handler, http.MethodGet, "/v1/jobs/"+job.ID+"/logs?after=2", "", &logs)
+	if len(logs.Events) != 1 || logs.Events[0].Type != "response" {
+		t.Errorf("expected only the newest event, got %+v", logs.Events)
+	}
+
+	var list struct {
+		Jobs []Job `json:"jobs"`
+	}
+	doJSON(t, handler, http.MethodGet, "/v1/jobs", "", &list)
+	if len(list.Jobs) != 1 || list.Jobs[0].ID != job.ID {
+		t.Errorf("unexpected job list %+v", list.Jobs)
+	}
+	if code := doJSON(t, handler, http.MethodPost, "/v1/jobs/"+job.ID+"/cancel", "", nil); code != http.StatusConflict {
+		t.Errorf("expected 409 cancelling a finished job, got %d", code)
+	}
+
+	// A new server picks up the jobs persisted by the last one
+	restarted := New(&Config{Agent: &fakeAgent{}, JobsDir: dir}).Handler()
+	if got := waitForJob(t, restarted, job.ID, JobSucceeded); got.Result.Response != "echo: summarize" {
+		t.Errorf("persisted job lost its result: %+v", got)
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	dir := t.TempDir()
+	handler := New(&Config{Agent: &blockingAgent{}, JobsDir: dir}).Handler()
+
+	var running, queued Job
+	doJSON(t, handler, http.MethodPost, "/v1/jobs", `{"prompt": "first"}`, &running)
+	doJSON(t, handler, http.MethodPost, "/v1/jobs", `{"prompt": "second"}`, &queued)
+	waitForJob(t, handler, running.ID, JobRunning)
+
+	// One worker by default, so the second job waits
+	if job := waitForJob(t, handler, queued.ID, JobQueued); job.StartedAt != nil {
+		t.Errorf("queued job should not have started: %+v", job)
+	}
+	if code := doJSON(t, handler, http.MethodPost, "/v1/jobs/"+queued.ID+"/cancel", "", nil); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	waitForJob(t, handler, queued.ID, JobCancelled)
+
+	// Jobs left running by a stopped server are reported as failed
+	restarted := New(&Config{Agent: &fakeAgent{}, JobsDir: dir}).Handler()
+	if job := waitForJob(t, restarted, running.ID, JobFailed); !strings.Contains(job.Error, "interrupted") {
+		t.Errorf("unexpected error %q", job.Error)
+	}
+
+	doJSON(t, handler, http.MethodPost, "/v1/jobs/"+running.ID+"/cancel", "", nil)
+	waitForJob(t, handler, running.ID, JobCancelled)
+	if code := doJSON(t, handler, http.MethodGet, "/v1/jobs/job_missing", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", code)
+	}
+}
+
+func TestJobDelete(t *testing.T) {
+	dir := t.TempDir()
+	handler := New(&Config{Agent: &blockingAgent{}, JobsDir: dir}).Handler()
+
+	var job Job
+	doJSON(t, handler, http.MethodPost, "/v1/jobs", `{"prompt": "first"}`, &job)
+	waitForJob(t, handler, job.ID, JobRunning)
+	if code := doJSON(t, handler, http.MethodDelete, "/v1/jobs/"+job.ID, "", nil); code != http.StatusConflict {
+		t.Errorf("expected 409 deleting a running job, got %d", code)
+	}
+
+	doJSON(t, handler, http.MethodPost, "/v1/jobs/"+job.ID+"/cancel", "", nil)
+	waitForJob(t, handler, job.ID, JobCancelled)
+	if code := doJSON(t, handler, http.MethodDelete, "/v1/jobs/"+job.ID, "", nil); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	if code := doJSON(t, handler, http.MethodGet, "/v1/jobs/"+job.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("expected the deleted job to be gone, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, job.ID+".json")); !os.IsNotExist(err) {
+		t.Errorf("expected the job's file to be deleted: %v", err)
+	}
+}
+
+func TestJobRetention(t *testing.T) {
+	dir := t.TempDir()
+	q := newJobQueue(dir, 1, 0, 0)
+	now := time.Now()
+	for i, age := range []time.Duration{72 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		finished := now.Add(-age)
+		q.save(&jobRecord{Job: Job{ID: fmt.Sprintf("job_%d", i), Status: JobSucceeded, FinishedAt: &finished}})
+	}
+	q.save(&jobRecord{Job: Job{ID: "job_running", Status: JobRunning}})
+
+	// On load the job older than a day goes, then the oldest finished one
+	// to keep three; the interrupted job has only just finished
+	q = newJobQueue(dir, 1, 24*time.Hour, 3)
+	var kept []string
+	for _, job := range q.list("") {
+		kept = append(kept, job.ID)
+	}
+	slices.Sort(kept)
+	if got := strings.Join(kept, " "); got != "job_2 job_3 job_running" {
+		t.Errorf("unexpected jobs kept: %s", got)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(paths) != 3 {
+		t.Errorf("expected the pruned jobs' files to be deleted, %d left", len(paths))
+	}
+}
+
+func TestJobWorkspaceIsChecked(t *testing.T) {
+	handler := New(&Config{Agent: &fakeAgent{}}).Handler()
+	body := fmt.Sprintf(`{"prompt": "hi", "work_dir": %q}`, t.TempDir())
+	if code := doJSON(t, handler, http.MethodPost, "/v1/jobs", body, nil); code != http.StatusBadRequest {
+		t.Errorf("expected a work_dir the policy refuses to be rejected with 400, got %d", code)
+	}
+	var list struct {
+		Jobs []Job `json:"jobs"`
+	}
+	doJSON(t, handler, http.MethodGet, "/v1/jobs", "", &list)
+	if len(list.Jobs) != 0 {
+		t.Errorf("expected no job to be queued, got %+v", list.Jobs)
+	}
+}
+
+func TestJobsAreScopedToAPIKey(t *testing.T) {
+	handler := New(&Config{
+		Agent:   &fakeAgent{},
+		APIKeys: []APIKey{{Name: "ci", Key: "secret-ci"}, {Name: "ops", Key: "secret-ops"}},
+	}).Handler()
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var job Job
+	json.Unmarshal(do(http.MethodPost, "/v1/jobs", `{"prompt": "hi"}`, "secret-ci").Body.Bytes(), &job)
+	if job.APIKey != "ci" {
+		t.Fatalf("expected job to record its key, got %+v", job)
+	}
+	if rec := do(http.MethodGet, "/v1/jobs/"+job.ID, "", "secret-ops"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected other keys not to see the job, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/v1/jobs", "", "secret-ops"); strings.Contains(rec.Body.String(), job.ID) {
+		t.Errorf("job listed for another key: %s", rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/v1/jobs/"+job.ID, "", "secret-ci"); rec.Code != http.StatusOK {
+		t.Errorf("expected the submitting key to see the job, got %d", rec.Code)
+	}
+}
+
+func TestJobSessionsAreScopedToAPIKey(t *testing.T) {
+	handler := New(&Config{
+		Agent:   &fakeAgent{},
+		APIKeys: []APIKey{{Name: "ci", Key: "secret-ci"}, {Name: "ops", Key: "secret-ops"}},
+	}).Handler()
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/v1/jobs", `{"prompt": "hi", "session_id": "../ci"}`, "secret-ci"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a path as session ID, got %d", rec.Code)
+	}
+
+	var job Job
+	json.Unmarshal(do(http.MethodPost, "/v1/jobs", `{"prompt": "hi", "session_id": "shared"}`, "secret-ci").Body.Bytes(), &job)
+	deadline := time.Now().Add(5 * time.Second)
+	for do(http.MethodGet, "/v1/sessions/shared", "", "secret-ci").Code != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("job never created its session")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Another key can't read, list, delete or continue the session
+	if rec := do(http.MethodGet, "/v1/sessions/shared", "", "secret-ops"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected other keys not to see the session, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/v1/sessions", "", "secret-ops"); strings.Contains(rec.Body.String(), "shared") {
+		t.Errorf("session listed for another key: %s", rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/v1/sessions/shared", "", "secret-ops"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected other keys not to delete the session, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi", "session_id": "shared"}`, "secret-ops"); rec.Code != http.StatusConflict {
+		t.Errorf("expected other keys not to continue the session, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "again", "session_id": "shared"}`, "secret-ci"); rec.Code != http.StatusOK {
+		t.Errorf("expected the owning key to continue the session, got %d", rec.Code)
+	}
+}
+
+func TestJobWebhook(t *testing.T) {
+	payloads := make(chan webhook.Payload, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhook.Payload
+		json.NewDecoder(r.Body).Decode(&p)
+		payloads <- p
+	}))
+	defer hook.Close()
+
+	handler := New(&Config{
+		Agent:       &fakeAgent{},
+		ModelString: "test:model",
+		Webhooks:    webhook.New([]webhook.Config{{URL: hook.URL, Events: []string{webhook.EventJob}}}),
+		BaseURL:     "http://mcphost.internal:8080/",
+	}).Handler()
+
+	var job Job
+	doJSON(t, handler, http.MethodPost, "/v1/jobs", `{"prompt": "report", "session_id": "abc"}`, &job)
+
+	select {
+	case p := <-payloads:
+		if p.Event != webhook.EventJob || p.Status != JobSucceeded || p.JobID != job.ID || p.Response != "echo: report" ||
+			p.Session != "http://mcphost.internal:8080/v1/sessions/abc" || p.Usage.InputTokens != 10 || p.Model != "test:model" {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
@@ -0,0 +1,559 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"google.golang.org/grpc"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/guardrail"
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/metrics"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/turn"
+	"github.com/osi4iot/mcphost/internal/webhook"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+// Agent is the subset of agent.Agent used by the server
+type Agent interface {
+	GenerateWithLoopAndStreaming(ctx context.Context, messages []*schema.Message,
+		onToolCall agent.ToolCallHandler, onToolExecution agent.ToolExecutionHandler, onToolResult agent.ToolResultHandler,
+		onResponse agent.ResponseHandler, onToolCallContent agent.ToolCallContentHandler, onStreamingResponse agent.StreamingResponseHandler) (*agent.GenerateWithLoopResult, error)
+	GetServerStatuses() []tools.ServerStatus
+	GetToolDetails() []tools.ToolDetail
+}
+
+// Config configures the HTTP API server
+type Config struct {
+	Agent       Agent
+	Store       session.Store
+	ModelString string
+	Metrics     *metrics.Metrics // optional, enables /metrics
+	Ledger      *ledger.Ledger   // optional, records usage per API key
+
+	// APIKeys enables authentication; with no keys every request is accepted
+	APIKeys []APIKey
+
+	// Background jobs are kept in memory unless JobsDir is set. JobWorkers
+	// limits how many run at once (default 1).
+	JobsDir    string
+	JobWorkers int
+
+	// Finished jobs are forgotten JobRetention after they finish, and the
+	// oldest of them once there are more than MaxJobs jobs; zero keeps them
+	JobRetention time.Duration
+	MaxJobs      int
+
+	// Webhooks are notified when jobs finish. BaseURL is where clients reach
+	// the server, used to link the job's session.
+	Webhooks *webhook.Notifier
+	BaseURL  string
+
+	// Guard, when set, checks every final response before it is saved or
+	// returned
+	Guard *guardrail.Guard
+
+	// GRPCAddr, when set, also serves the gRPC API on that address
+	GRPCAddr string
+
+	// Workspaces limits the work_dir and env requests may set; the zero
+	// policy refuses both
+	Workspaces workspace.Policy
+}
+
+// Server exposes the agent over a small JSON HTTP API
+type Server struct {
+	agent       Agent
+	store       session.Store
+	modelString string
+	metrics     *metrics.Metrics
+	ledger      *ledger.Ledger
+	auth        *authenticator
+	jobs        *jobQueue
+	webhooks    *webhook.Notifier
+	baseURL     string
+	guard       *guardrail.Guard
+	grpcAddr    string
+	workspaces  workspace.Policy
+
+	// Prompts for the same session are serialized
+	sessionLocks sessionLocks
+}
+
+// PromptRequest is the body of POST /v1/prompt
+type PromptRequest struct {
+	Prompt    string `json:"prompt"`
+	SessionID string `json:"session_id,omitempty"` // empty starts a new session
+
+	// WorkDir and Env set the directory and extra environment the turn's bash,
+	// fs and stdio servers run in, so prompts for different checkouts can run
+	// side by side. They apply to this request only.
+	WorkDir string            `json:"work_dir,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// PromptResponse is returned by POST /v1/prompt
+type PromptResponse struct {
+	SessionID    string `json:"session_id"`
+	TurnID       string `json:"turn_id"` // also in the turn's hooks, logs and provider requests
+	Response     string `json:"response"`
+	InputTokens  int    `json:"input_tokens,omitempty"`  // uncached prompt tokens
+	OutputTokens int    `json:"output_tokens,omitempty"` // reasoning included
+
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+
+	// Guardrail is set when the guardrail blocked or annotated the response
+	Guardrail *guardrail.Verdict `json:"guardrail,omitempty"`
+
+	// Citations are the sources the response cites, with --citations
+	Citations []agent.Citation `json:"citations,omitempty"`
+}
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates a server from the given config
+func New(cfg *Config) *Server {
+	store := cfg.Store
+	if store == nil {
+		store = session.NewMemoryStore()
+	}
+	s := &Server{
+		agent:       cfg.Agent,
+		store:       store,
+		modelString: cfg.ModelString,
+		metrics:     cfg.Metrics,
+		ledger:      cfg.Ledger,
+		auth:        newAuthenticator(cfg.APIKeys),
+		jobs:        newJobQueue(cfg.JobsDir, cfg.JobWorkers, cfg.JobRetention, cfg.MaxJobs),
+		webhooks:    cfg.Webhooks,
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+		guard:       cfg.Guard,
+		grpcAddr:    cfg.GRPCAddr,
+		workspaces:  cfg.Workspaces,
+	}
+	s.jobs.onDone = s.notifyJobDone
+	return s
+}
+
+// Handler returns the HTTP handler with all API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("POST /v1/prompt", s.handlePrompt)
+	mux.HandleFunc("GET /v1/sessions", s.handleListSessions)
+	mux.HandleFunc("GET /v1/sessions/{id}", s.handleGetSession)
+	mux.HandleFunc("DELETE /v1/sessions/{id}", s.handleDeleteSession)
+	mux.HandleFunc("GET /v1/servers", s.handleServers)
+	mux.HandleFunc("GET /v1/tools", s.handleTools)
+	mux.HandleFunc("GET /v1/usage", s.handleUsage)
+	mux.HandleFunc("POST /v1/jobs", s.handleSubmitJob)
+	mux.HandleFunc("GET /v1/jobs", s.handleListJobs)
+	mux.HandleFunc("GET /v1/jobs/{id}", s.handleGetJob)
+	mux.HandleFunc("GET /v1/jobs/{id}/logs", s.handleJobLogs)
+	mux.HandleFunc("POST /v1/jobs/{id}/cancel", s.handleCancelJob)
+	mux.HandleFunc("DELETE /v1/jobs/{id}", s.handleDeleteJob)
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics.Handler())
+	}
+
+	if len(s.auth.keys) == 0 {
+		return mux
+	}
+	return s.auth.middleware(mux)
+}
+
+// ListenAndServe serves the API on addr, and the gRPC API on the configured
+// GRPCAddr, until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var grpcListener net.Listener
+	if s.grpcAddr != "" {
+		var err error
+		if grpcListener, err = net.Listen("tcp", s.grpcAddr); err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+	}
+
+	errChan := make(chan error, 2)
+	go func() {
+		errChan <- httpServer.ListenAndServe()
+	}()
+	var grpcServer *grpc.Server
+	if grpcListener != nil {
+		grpcServer = s.GRPCServer()
+		go func() {
+			errChan <- grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	select {
+	case err := <-errChan:
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		httpServer.Close()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		grpcStopped := make(chan struct{})
+		go func() {
+			stopGRPC(shutdownCtx, grpcServer)
+			close(grpcStopped)
+		}()
+		err := httpServer.Shutdown(shutdownCtx)
+		<-grpcStopped
+		s.jobs.shutdown()
+		return err
+	}
+}
+
+// stopGRPC stops g, when set, letting running calls finish until ctx ends
+func stopGRPC(ctx context.Context, g *grpc.Server) {
+	if g == nil {
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		g.Stop()
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	var req PromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.metrics.ObserveError(metrics.ErrorBadRequest)
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Prompt == "" {
+		s.metrics.ObserveError(metrics.ErrorBadRequest)
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prompt is required"))
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = generateSessionID()
+	}
+
+	resp, status, err := s.prompt(r.Context(), &req, nil)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requestWorkspace returns the workspace req asks for, when the server's
+// workspace policy allows it
+func (s *Server) requestWorkspace(req *PromptRequest) (workspace.Workspace, error) {
+	ws, err := workspace.New(req.WorkDir, req.Env)
+	if err != nil {
+		return ws, err
+	}
+	return ws, s.workspaces.Check(ws)
+}
+
+// prompt runs one agent turn against the stored session. onEvent, when set,
+// receives the turn's tool calls and final response as they happen.
+func (s *Server) prompt(ctx context.Context, req *PromptRequest, onEvent func(JobEvent)) (*PromptResponse, int, error) {
+	if err := validateSessionID(req.SessionID); err != nil {
+		s.metrics.ObserveError(metrics.ErrorBadRequest)
+		return nil, http.StatusBadRequest, err
+	}
+	ws, err := s.requestWorkspace(req)
+	if err != nil {
+		s.metrics.ObserveError(metrics.ErrorBadRequest)
+		return nil, http.StatusBadRequest, err
+	}
+	ctx = workspace.With(ctx, ws)
+
+	turnID := turn.NewID()
+	ctx = turn.WithID(ctx, turnID)
+
+	defer s.sessionLocks.lock(req.SessionID)()
+
+	s.metrics.SessionStarted()
+	defer s.metrics.SessionFinished()
+
+	sess, err := s.store.Load(ctx, req.SessionID)
+	existed := err == nil
+	if errors.Is(err, session.ErrSessionNotFound) {
+		sess = session.NewSession()
+		sess.Metadata.APIKey = apiKeyName(ctx)
+	} else if err == nil && !ownedBy(sess, apiKeyName(ctx)) {
+		s.metrics.ObservePrompt(s.modelString, "error")
+		return nil, http.StatusConflict, fmt.Errorf("session ID %s is taken; choose another or leave it out", req.SessionID)
+	} else if err != nil {
+		s.metrics.ObserveError(metrics.ErrorSession)
+		s.metrics.ObservePrompt(s.modelString, "error")
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	messages := make([]*schema.Message, 0, len(sess.Messages)+1)
+	for i := range sess.Messages {
+		messages = append(messages, sess.Messages[i].ConvertToSchemaMessage())
+	}
+	historyLen := len(messages)
+	prompt := schema.UserMessage(req.Prompt)
+	turn.Mark(prompt, turnID)
+	messages = append(messages, prompt)
+
+	toolStarts := make(map[string]time.Time)
+	var onToolCall agent.ToolCallHandler
+	if onEvent != nil {
+		onToolCall = func(toolName, toolArgs string) {
+			onEvent(newJobEvent("tool_call", toolName, toolArgs))
+		}
+	}
+	result, err := s.agent.GenerateWithLoopAndStreaming(ctx, messages,
+		onToolCall,
+		func(toolName string, isStarting bool) {
+			if isStarting {
+				toolStarts[toolName] = time.Now()
+				return
+			}
+			if start, ok := toolStarts[toolName]; ok {
+				s.metrics.ObserveToolCall(toolName, time.Since(start))
+				delete(toolStarts, toolName)
+			}
+		},
+		func(toolName, toolArgs, result string, isError bool) {
+			if isError {
+				s.metrics.ObserveError(metrics.ErrorTool)
+			}
+			if onEvent != nil {
+				eventType := "tool_result"
+				if isError {
+					eventType = "tool_error"
+				}
+				onEvent(newJobEvent(eventType, toolName, result))
+			}
+		},
+		nil, nil, nil,
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			s.metrics.ObserveError(metrics.ErrorCancelled)
+			s.metrics.ObservePrompt(s.modelString, "cancelled")
+			return nil, http.StatusServiceUnavailable, fmt.Errorf("prompt cancelled: %w", err)
+		}
+		s.metrics.ObserveError(metrics.ErrorProvider)
+		s.metrics.ObservePrompt(s.modelString, "error")
+		return nil, http.StatusBadGateway, fmt.Errorf("generation failed: %w", err)
+	}
+
+	// The guarded text replaces the response in the session as well
+	var verdict *guardrail.Verdict
+	if s.guard != nil {
+		content, v := s.guard.Apply(ctx, result.FinalResponse.Content)
+		result.FinalResponse.Content = content
+		if v.Flagged {
+			verdict = &v
+		}
+		if v.Action == guardrail.ActionBlock {
+			result.Citations = nil
+		}
+	}
+
+	// Token usage is reported per model call; sum the calls made this turn
+	var counts models.TokenCounts
+	for _, msg := range result.ConversationMessages[min(historyLen, len(result.ConversationMessages)):] {
+		if msg.Role != schema.Assistant {
+			continue
+		}
+		if c, ok := models.TokenCountsFromMessage(msg); ok {
+			counts.Add(c)
+		}
+	}
+	resp := &PromptResponse{
+		SessionID:        req.SessionID,
+		TurnID:           turnID,
+		Response:         result.FinalResponse.Content,
+		InputTokens:      counts.InputTokens,
+		OutputTokens:     counts.OutputTokens,
+		CacheReadTokens:  counts.CacheReadTokens,
+		CacheWriteTokens: counts.CacheWriteTokens,
+		ReasoningTokens:  counts.ReasoningTokens,
+		Guardrail:        verdict,
+		Citations:        result.Citations,
+	}
+	s.metrics.ObserveTokens(s.modelString, counts.PromptTokens(), resp.OutputTokens)
+
+	if err := s.ledger.Record(ledger.Entry{
+		Source:           "serve",
+		APIKey:           apiKeyName(ctx),
+		SessionID:        req.SessionID,
+		TurnID:           turnID,
+		Model:            s.modelString,
+		InputTokens:      counts.InputTokens,
+		OutputTokens:     counts.OutputTokens,
+		CacheReadTokens:  counts.CacheReadTokens,
+		CacheWriteTokens: counts.CacheWriteTokens,
+		ReasoningTokens:  counts.ReasoningTokens,
+	}); err != nil {
+		// Usage accounting must not fail an otherwise successful prompt
+		s.metrics.ObserveError(metrics.ErrorSession)
+	}
+
+	// Drop the system prompt the agent prepends; it is re-added every turn
+	conversation := result.ConversationMessages
+	if len(conversation) > 0 && conversation[0].Role == schema.System {
+		conversation = conversation[1:]
+	}
+	if err := s.saveConversation(ctx, req.SessionID, sess, conversation, historyLen, existed); err != nil {
+		s.metrics.ObserveError(metrics.ErrorSession)
+		s.metrics.ObservePrompt(s.modelString, "error")
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	s.metrics.ObservePrompt(s.modelString, "ok")
+	if onEvent != nil {
+		if verdict != nil {
+			onEvent(newJobEvent("guardrail_"+verdict.Action, "", strings.Join(verdict.Reasons, "; ")))
+		}
+		onEvent(newJobEvent("response", "", resp.Response))
+	}
+	return resp, http.StatusOK, nil
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	ids, err := s.listSessions(r.Context())
+	if err != nil {
+		s.metrics.ObserveError(metrics.ErrorSession)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"sessions": ids})
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	if err := validateSessionID(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sess, err := s.loadSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	if err := validateSessionID(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.deleteSession(r.Context(), r.PathValue("id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"servers": s.agent.GetServerStatuses()})
+}
+
+func (s *Server) handleTools(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"tools": s.agent.GetToolDetails()})
+}
+
+// handleUsage reports usage totals for the calling API key (or all usage when
+// authentication is disabled)
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.ledger.Entries()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	keyName := apiKeyName(r.Context())
+	totals := ledger.Summarize(entries, func(e ledger.Entry) bool {
+		return e.Source == "serve" && (keyName == "" || e.APIKey == keyName)
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"api_key": keyName, "usage": totals})
+}
+
+// saveConversation stores the turn's conversation. Stores that support it only
+// get the new messages appended; the first historyLen messages are already stored.
+func (s *Server) saveConversation(ctx context.Context, id string, sess *session.Session, conversation []*schema.Message, historyLen int, existed bool) error {
+	appender, canAppend := s.store.(session.Appender)
+	if canAppend && existed && historyLen <= len(conversation) && historyLen == len(sess.Messages) {
+		for _, msg := range conversation[historyLen:] {
+			sess.AddMessage(session.ConvertFromSchemaMessage(msg))
+		}
+		return appender.Append(ctx, id, sess, sess.Messages[historyLen:])
+	}
+
+	sess.Messages = []session.Message{}
+	for _, msg := range conversation {
+		sess.AddMessage(session.ConvertFromSchemaMessage(msg))
+	}
+	return s.store.Save(ctx, id, sess)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, session.ErrInvalidSessionID) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// generateSessionID generates a random session ID
+func generateSessionID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return "sess_" + hex.EncodeToString(bytes)
+}
@@ -0,0 +1,372 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/agent"
+	"github.com/osi4iot/mcphost/internal/guardrail"
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/metrics"
+	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+// fakeAgent echoes the last user message and reports a fixed token usage
+type fakeAgent struct {
+	calls int
+}
+
+func (f *fakeAgent) GenerateWithLoopAndStreaming(ctx context.Context, messages []*schema.Message,
+	onToolCall agent.ToolCallHandler, onToolExecution agent.ToolExecutionHandler, onToolResult agent.ToolResultHandler,
+	onResponse agent.ResponseHandler, onToolCallContent agent.ToolCallContentHandler, onStreamingResponse agent.StreamingResponseHandler) (*agent.GenerateWithLoopResult, error) {
+	f.calls++
+
+	if onToolCall != nil {
+		onToolCall("fs__read_file", `{"path":"notes.txt"}`)
+	}
+	if onToolExecution != nil {
+		onToolExecution("fs__read_file", true)
+		onToolExecution("fs__read_file", false)
+	}
+	if onToolResult != nil {
+		onToolResult("fs__read_file", `{"path":"notes.txt"}`, "file contents", false)
+	}
+
+	response := schema.AssistantMessage("echo: "+messages[len(messages)-1].Content, nil)
+	response.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5}}
+
+	conversation := append([]*schema.Message{schema.SystemMessage("system")}, messages...)
+	conversation = append(conversation, response)
+	return &agent.GenerateWithLoopResult{FinalResponse: response, ConversationMessages: conversation}, nil
+}
+
+func (f *fakeAgent) GetServerStatuses() []tools.ServerStatus {
+	return []tools.ServerStatus{{Name: "fs", Transport: "inprocess", Connected: true, Tools: []string{"fs__read_file"}}}
+}
+
+func (f *fakeAgent) GetToolDetails() []tools.ToolDetail {
+	return []tools.ToolDetail{{Name: "fs__read_file", Server: "fs", OriginalName: "read_file"}}
+}
+
+func postPrompt(t *testing.T, handler http.Handler, body string) (*httptest.ResponseRecorder, PromptResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/prompt", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp PromptResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+func TestPromptPersistsSession(t *testing.T) {
+	store := session.NewMemoryStore()
+	srv := New(&Config{Agent: &fakeAgent{}, Store: store, ModelString: "test:model"})
+	handler := srv.Handler()
+
+	rec, first := postPrompt(t, handler, `{"prompt": "hello"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if first.SessionID == "" || first.Response != "echo: hello" {
+		t.Fatalf("unexpected response: %+v", first)
+	}
+	if first.InputTokens != 10 || first.OutputTokens != 5 {
+		t.Errorf("unexpected token usage: %+v", first)
+	}
+
+	_, second := postPrompt(t, handler, `{"prompt": "again", "session_id": "`+first.SessionID+`"}`)
+	if second.SessionID != first.SessionID {
+		t.Errorf("expected session %s to be reused, got %s", first.SessionID, second.SessionID)
+	}
+
+	sess, err := store.Load(context.Background(), first.SessionID)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	// Two turns of user + assistant; the system prompt is not persisted
+	if len(sess.Messages) != 4 {
+		t.Fatalf("expected 4 stored messages, got %d", len(sess.Messages))
+	}
+	if sess.Messages[0].Role != "user" {
+		t.Errorf("expected first stored message to be the user prompt, got %s", sess.Messages[0].Role)
+	}
+}
+
+func TestPromptValidation(t *testing.T) {
+	handler := New(&Config{Agent: &fakeAgent{}}).Handler()
+
+	for _, body := range []string{`not json`, `{"prompt": ""}`, `{"prompt": "hi", "work_dir": "/no/such/dir"}`} {
+		rec, _ := postPrompt(t, handler, body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %q: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestPromptWorkspacePolicy(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	prompt := func(policy workspace.Policy, req PromptRequest) int {
+		req.Prompt = "hi"
+		body, _ := json.Marshal(req)
+		rec, _ := postPrompt(t, New(&Config{Agent: &fakeAgent{}, Workspaces: policy}).Handler(), string(body))
+		return rec.Code
+	}
+
+	if code := prompt(workspace.Policy{}, PromptRequest{WorkDir: project}); code != http.StatusBadRequest {
+		t.Errorf("expected work_dir to be refused without roots, got %d", code)
+	}
+	policy := workspace.Policy{Roots: []string{root}, EnvKeys: []string{"GOFLAGS"}}
+	for _, req := range []PromptRequest{
+		{WorkDir: "/"},
+		{WorkDir: project, Env: map[string]string{"LD_PRELOAD": "/tmp/x.so"}},
+	} {
+		if code := prompt(policy, req); code != http.StatusBadRequest {
+			t.Errorf("expected %+v to be refused, got %d", req, code)
+		}
+	}
+	if code := prompt(policy, PromptRequest{WorkDir: project, Env: map[string]string{"GOFLAGS": "-count=1"}}); code != http.StatusOK {
+		t.Errorf("expected a workspace under a root to be allowed, got %d", code)
+	}
+}
+
+func TestSessionIDTraversal(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sessions")
+	srv := New(&Config{Agent: &fakeAgent{}, Store: session.NewFileStore(dir)})
+	handler := srv.Handler()
+
+	outside := filepath.Join(root, "outside.json")
+	if err := os.WriteFile(outside, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"../outside", outside, "a/b", "..", ".json"} {
+		body, _ := json.Marshal(PromptRequest{Prompt: "hello", SessionID: id})
+		if rec, _ := postPrompt(t, handler, string(body)); rec.Code != http.StatusBadRequest {
+			t.Errorf("prompt with session %q: expected 400, got %d", id, rec.Code)
+		}
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/v1/sessions/..%2Foutside", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s ../outside: expected 400, got %d", method, rec.Code)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("expected the file outside the store to be left alone: %v", err)
+	}
+
+	// Plain IDs, with or without a session file extension, are fine
+	for _, id := range []string{"sess_1a2b", "my-session.jsonl"} {
+		body, _ := json.Marshal(PromptRequest{Prompt: "hello", SessionID: id})
+		if rec, _ := postPrompt(t, handler, string(body)); rec.Code != http.StatusOK {
+			t.Errorf("prompt with session %q: expected 200, got %d: %s", id, rec.Code, rec.Body.String())
+		}
+	}
+	if n := srv.sessionLocks.len(); n != 0 {
+		t.Errorf("expected session locks to be released, %d left", n)
+	}
+}
+
+func TestSessionLocksIgnoreExtension(t *testing.T) {
+	var locks sessionLocks
+	unlock := locks.lock("abc")
+	locked := make(chan struct{})
+	go func() {
+		defer locks.lock("abc.json")()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected \"abc.json\" to wait for \"abc\", which is the same file")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected \"abc.json\" to lock once \"abc\" is free")
+	}
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	handler := New(&Config{Agent: &fakeAgent{}}).Handler()
+	_, resp := postPrompt(t, handler, `{"prompt": "hello", "session_id": "abc"}`)
+	if resp.SessionID != "abc" {
+		t.Fatalf("expected session id abc, got %q", resp.SessionID)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/v1/sessions"); !strings.Contains(rec.Body.String(), `"abc"`) {
+		t.Errorf("expected session list to contain abc, got %s", rec.Body.String())
+	}
+	if rec := get("/v1/sessions/abc"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for existing session, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/sessions/abc", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204 on delete, got %d", rec.Code)
+	}
+	if rec := get("/v1/sessions/abc"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rec.Code)
+	}
+	if rec := get("/v1/tools"); !strings.Contains(rec.Body.String(), `"original_name":"read_file"`) {
+		t.Errorf("unexpected tools response: %s", rec.Body.String())
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := New(&Config{Agent: &fakeAgent{}, ModelString: "test:model", Metrics: metrics.New()})
+	handler := srv.Handler()
+
+	postPrompt(t, handler, `{"prompt": "hello"}`)
+	postPrompt(t, handler, `{"prompt": ""}`)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`mcphost_prompts_total{model="test:model",status="ok"} 1`,
+		`mcphost_tokens_total{direction="input",model="test:model"} 10`,
+		`mcphost_tokens_total{direction="output",model="test:model"} 5`,
+		`mcphost_tool_call_duration_seconds_count{server="fs"} 1`,
+		`mcphost_errors_total{category="bad_request"} 1`,
+		`mcphost_active_sessions 0`,
+	} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	handler := New(&Config{Agent: &fakeAgent{}}).Handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when metrics are disabled, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyAuthAndRateLimit(t *testing.T) {
+	usage, _ := ledger.Open("")
+	srv := New(&Config{
+		Agent:       &fakeAgent{},
+		ModelString: "test:model",
+		Ledger:      usage,
+		APIKeys: []APIKey{
+			{Name: "ci", Key: "secret-ci", RequestsPerMinute: 1},
+			{Name: "ops", Key: "secret-ops"},
+		},
+	})
+	handler := srv.Handler()
+
+	do := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("healthz should not require auth, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without key, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi"}`, "Authorization", "Bearer wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with wrong key, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi"}`, "Authorization", "Bearer secret-ci"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with valid key, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi"}`, "Authorization", "Bearer secret-ci")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After over the limit, got %d", rec.Code)
+	}
+
+	// Limits are per key
+	if rec := do(http.MethodPost, "/v1/prompt", `{"prompt": "hi"}`, "X-API-Key", "secret-ops"); rec.Code != http.StatusOK {
+		t.Errorf("expected other key to be unaffected, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/v1/usage", "", "X-API-Key", "secret-ops")
+	var usageResp struct {
+		APIKey string        `json:"api_key"`
+		Usage  ledger.Totals `json:"usage"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &usageResp); err != nil {
+		t.Fatalf("failed to decode usage: %v", err)
+	}
+	if usageResp.APIKey != "ops" || usageResp.Usage.Requests != 1 || usageResp.Usage.InputTokens != 10 {
+		t.Errorf("unexpected usage for ops key: %+v", usageResp)
+	}
+
+	entries, _ := usage.Entries()
+	if len(entries) != 2 || entries[0].APIKey != "ci" || entries[1].APIKey != "ops" {
+		t.Errorf("unexpected ledger entries: %+v", entries)
+	}
+}
+
+func TestPromptGuardrail(t *testing.T) {
+	guard, err := guardrail.New(guardrail.Config{Deny: []string{`(?i)secret`}, Message: "Withheld."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := session.NewMemoryStore()
+	handler := New(&Config{Agent: &fakeAgent{}, Store: store, Guard: guard}).Handler()
+
+	_, allowed := postPrompt(t, handler, `{"prompt": "hello"}`)
+	if allowed.Response != "echo: hello" || allowed.Guardrail != nil {
+		t.Fatalf("expected the response to pass, got %+v", allowed)
+	}
+
+	_, blocked := postPrompt(t, handler, `{"prompt": "the secret plan"}`)
+	if blocked.Response != "Withheld." || blocked.Guardrail == nil || blocked.Guardrail.Action != guardrail.ActionBlock {
+		t.Fatalf("expected the response to be blocked, got %+v", blocked)
+	}
+
+	// The blocked text never reaches the stored session
+	sess, err := store.Load(context.Background(), blocked.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := sess.Messages[len(sess.Messages)-1]; last.Content != "Withheld." {
+		t.Errorf("expected the stored response to be replaced, got %q", last.Content)
+	}
+}
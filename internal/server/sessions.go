@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/osi4iot/mcphost/internal/session"
+)
+
+// sessionIDPattern is what session IDs from clients must look like: a plain
+// name, optionally with a session file extension, that a file store can't
+// resolve outside its directory
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}(\.jsonl?)?$`)
+
+// validateSessionID rejects a session ID from a client that is not a plain
+// name. Every API that takes one checks it before the store sees it.
+func validateSessionID(id string) error {
+	if !sessionIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q: use up to 128 letters, digits, '-' and '_'", session.ErrInvalidSessionID, id)
+	}
+	return nil
+}
+
+// ownedBy reports whether keyName may use sess; with authentication disabled
+// every session is, as with jobs
+func ownedBy(sess *session.Session, keyName string) bool {
+	return keyName == "" || sess.Metadata.APIKey == keyName
+}
+
+// loadSession returns the stored session id when the caller's API key owns
+// it. Other keys' sessions are reported as not found.
+func (s *Server) loadSession(ctx context.Context, id string) (*session.Session, error) {
+	sess, err := s.store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(sess, apiKeyName(ctx)) {
+		return nil, fmt.Errorf("%w: %s", session.ErrSessionNotFound, id)
+	}
+	return sess, nil
+}
+
+// deleteSession deletes the stored session id when the caller's API key owns it
+func (s *Server) deleteSession(ctx context.Context, id string) error {
+	if _, err := s.loadSession(ctx, id); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, id)
+}
+
+// listSessions returns the IDs of the stored sessions the caller's API key owns
+func (s *Server) listSessions(ctx context.Context) ([]string, error) {
+	ids, err := s.store.List(ctx)
+	if err != nil || apiKeyName(ctx) == "" {
+		return ids, err
+	}
+	owned := []string{}
+	for _, id := range ids {
+		if _, err := s.loadSession(ctx, id); err == nil {
+			owned = append(owned, id)
+		}
+	}
+	return owned, nil
+}
+
+// sessionLocks serializes the prompts of each session. A session's lock only
+// exists while prompts for it are running or waiting, so the map doesn't grow
+// with every ID ever seen.
+type sessionLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+// sessionLock is a session's lock and the number of prompts holding or
+// waiting for it
+type sessionLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock blocks until the session is free and returns the function that frees it.
+// IDs are locked without their file extension: a file store keeps "abc" and
+// "abc.json" in the same file, and sessions that only share a name just wait
+// for each other.
+func (l *sessionLocks) lock(id string) (unlock func()) {
+	id = strings.TrimSuffix(id, filepath.Ext(id))
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sessionLock)
+	}
+	sl, ok := l.locks[id]
+	if !ok {
+		sl = &sessionLock{}
+		l.locks[id] = sl
+	}
+	sl.users++
+	l.mu.Unlock()
+
+	sl.Lock()
+	return func() {
+		sl.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if sl.users--; sl.users == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
+
+// len returns the number of sessions with a lock
+func (l *sessionLocks) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
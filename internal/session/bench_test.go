@@ -0,0 +1,53 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// benchHistory returns a conversation of turns, each a prompt, a tool call,
+// its result and an answer
+func benchHistory(turns int) []*schema.Message {
+	history := make([]*schema.Message, 0, turns*4)
+	for i := 0; i < turns; i++ {
+		id := fmt.Sprintf("call-%d", i)
+		history = append(history,
+			schema.UserMessage(fmt.Sprintf("Question %d about the repository layout", i)),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "fs__read_file", Arguments: `{"path": "go.mod"}`}}}),
+			schema.ToolMessage("module example.com/demo\n\ngo 1.24\n", id),
+			schema.AssistantMessage(fmt.Sprintf("Answer %d: it is a Go module.", i), nil),
+		)
+	}
+	return history
+}
+
+// BenchmarkReplaceAllMessages saves one more turn on top of histories of
+// growing length, as every interactive turn does, to a JSON file, which is
+// rewritten, and to a JSONL file, which is appended to. The saved history is
+// shared, as the agent's result shares it.
+func BenchmarkReplaceAllMessages(b *testing.B) {
+	for _, ext := range []string{ExtJSON, ExtJSONL} {
+		for _, turns := range []int{10, 100, 1000} {
+			b.Run(fmt.Sprintf("%s/history=%d", ext, turns*4), func(b *testing.B) {
+				history := benchHistory(turns)
+				extended := append(history[:len(history):len(history)], benchHistory(1)...)
+				path := filepath.Join(b.TempDir(), "bench"+ext)
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					m := NewManagerWithStore(nil, NewFileStore(""), path)
+					if err := m.ReplaceAllMessages(history); err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+					if err := m.ReplaceAllMessages(extended); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Session file formats, selected by file extension
+const (
+	// ExtJSON stores the whole session as one JSON document, rewritten on every save
+	ExtJSON = ".json"
+	// ExtJSONL stores a header line followed by one line per message, so new
+	// messages are appended instead of rewriting the file
+	ExtJSONL = ".jsonl"
+)
+
+// IsJSONL reports whether path uses the append-only JSONL session format
+func IsJSONL(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ExtJSONL)
+}
+
+// jsonlRecord is one line of a JSONL session file. The first line is a
+// "session" header; later lines are "message" records, or "metadata" records
+// that replace the header's metadata.
+type jsonlRecord struct {
+	Type      string     `json:"type"`
+	Version   string     `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Metadata  *Metadata  `json:"metadata,omitempty"`
+	*Message
+}
+
+// marshalJSONL renders a complete session in JSONL form
+func marshalJSONL(s *Session) ([]byte, error) {
+	var buf bytes.Buffer
+	createdAt := s.CreatedAt
+	metadata := s.Metadata
+	header, err := json.Marshal(jsonlRecord{Type: "session", Version: s.Version, CreatedAt: &createdAt, Metadata: &metadata})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	buf.Write(header)
+	buf.WriteByte('\n')
+
+	lines, err := marshalJSONLMessages(s.Messages)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(lines)
+	return buf.Bytes(), nil
+}
+
+func marshalJSONLMessages(msgs []Message) ([]byte, error) {
+	var buf bytes.Buffer
+	for i := range msgs {
+		line, err := json.Marshal(jsonlRecord{Type: "message", Message: &msgs[i]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalJSONL parses a JSONL session. A malformed final line - left by a
+// crash in the middle of an append - is ignored.
+func unmarshalJSONL(data []byte) (*Session, error) {
+	s := NewSession()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var pendingErr error
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if pendingErr != nil {
+			// A bad line followed by more data is real corruption
+			return nil, pendingErr
+		}
+
+		var record jsonlRecord
+		if err := json.Unmarshal(text, &record); err != nil {
+			pendingErr = fmt.Errorf("failed to unmarshal session line %d: %w", line, err)
+			continue
+		}
+		switch record.Type {
+		case "session":
+			if record.Version != "" {
+				s.Version = record.Version
+			}
+			if record.CreatedAt != nil {
+				s.CreatedAt = *record.CreatedAt
+			}
+			if record.Metadata != nil {
+				s.Metadata = *record.Metadata
+			}
+		case "metadata":
+			if record.Metadata != nil {
+				s.Metadata = *record.Metadata
+			}
+		case "message":
+			if record.Message != nil {
+				s.Messages = append(s.Messages, *record.Message)
+				s.UpdatedAt = record.Message.Timestamp
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	return s, nil
+}
+
+// appendJSONL adds messages to the end of an existing JSONL session file
+func appendJSONL(path string, msgs []Message) error {
+	lines, err := marshalJSONLMessages(msgs)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open session file: %w", err)
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to session file: %w", err)
+	}
+	return f.Close()
+}
+
+// writeFileAtomic replaces path with data via a temporary file and rename, so
+// a crash never leaves a half-written session behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestFileStoreJSONL(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	testStore(t, NewFileStoreWithExtension(dir, ExtJSONL))
+
+	if _, err := os.Stat(filepath.Join(dir, "second.jsonl")); err != nil {
+		t.Errorf("expected JSONL session file on disk: %v", err)
+	}
+}
+
+func TestManagerAppendsToJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	m := NewManagerWithStore(nil, NewFileStore(""), path)
+	m.SetMetadata(Metadata{Provider: "anthropic", Model: "claude"})
+
+	history := []*schema.Message{schema.UserMessage("hello"), schema.AssistantMessage("hi", nil)}
+	if err := m.ReplaceAllMessages(history); err != nil {
+		t.Fatalf("ReplaceAllMessages failed: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+
+	// Extending the history must only append lines
+	history = append(history, schema.UserMessage("how are you?"))
+	if err := m.ReplaceAllMessages(history); err != nil {
+		t.Fatalf("ReplaceAllMessages failed: %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(after, before) {
+		t.Fatalf("expected the file to be appended to, got:\n%s", after)
+	}
+	if lines := bytes.Count(after, []byte("\n")); lines != 4 {
+		t.Errorf("expected header plus 3 message lines, got %d", lines)
+	}
+
+	loaded, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if len(loaded.Messages) != 3 || loaded.Messages[2].Content != "how are you?" || loaded.Metadata.Model != "claude" {
+		t.Errorf("unexpected loaded session: %+v", loaded)
+	}
+
+	// A diverging history (e.g. /clear) rewrites the file
+	if err := m.ReplaceAllMessages([]*schema.Message{schema.UserMessage("fresh")}); err != nil {
+		t.Fatalf("ReplaceAllMessages failed: %v", err)
+	}
+	loaded, _ = LoadFromFile(path)
+	if len(loaded.Messages) != 1 || loaded.Messages[0].Content != "fresh" {
+		t.Errorf("expected history to be replaced, got %+v", loaded.Messages)
+	}
+}
+
+func TestManagerDoesNotAppendToStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	old := NewSession()
+	old.AddMessage(Message{Role: "user", Content: "old"})
+	if err := old.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new manager's first save replaces whatever was there
+	m := NewManagerWithStore(nil, NewFileStore(""), path)
+	m.AddMessage(schema.UserMessage("new"))
+	loaded, _ := LoadFromFile(path)
+	if len(loaded.Messages) != 1 || loaded.Messages[0].Content != "new" {
+		t.Errorf("expected stale session to be replaced, got %+v", loaded.Messages)
+	}
+}
+
+func TestLoadJSONLIgnoresTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	s := NewSession()
+	s.AddMessage(Message{Role: "user", Content: "hello"})
+	if err := s.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of appending a message
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"type":"message","role":"assist`)
+	f.Close()
+
+	loaded, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("expected a truncated last line to be tolerated: %v", err)
+	}
+	if len(loaded.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(loaded.Messages))
+	}
+
+	// Corruption before the end is an error
+	os.WriteFile(path, []byte("{bad\n{\"type\":\"session\"}\n"), 0644)
+	if _, err := LoadFromFile(path); err == nil {
+		t.Error("expected an error for a corrupt line in the middle")
+	}
+
+	// Files created empty (mcphost -s new.jsonl) load as empty sessions
+	os.WriteFile(path, nil, 0644)
+	if loaded, err := LoadFromFile(path); err != nil || len(loaded.Messages) != 0 {
+		t.Errorf("expected empty session, got %+v %v", loaded, err)
+	}
+}
@@ -1,48 +1,92 @@
 package session
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/cloudwego/eino/schema"
 )
 
-// Manager manages session state and auto-saving
+// Manager manages session state and auto-saving.
+//
+// The manager keeps the schema messages its session was built from, index for
+// index, and shares them with callers: GetMessages returns them rather than
+// fresh conversions, and the agent carries them into its result unchanged. A
+// later ReplaceAllMessages then recognises the history it already holds by
+// pointer instead of converting every message again, so saving a turn costs
+// the turn's messages rather than the whole conversation. Shared messages
+// must be treated as read-only; a changed message must be a new one.
 type Manager struct {
 	session  *Session
-	filePath string
+	history  []*schema.Message // the schema messages of session.Messages, where known
+	store    Store
+	filePath string // ID of the session within store
+	saved    bool   // the store holds this session, so new messages can be appended
 	mutex    sync.RWMutex
 }
 
-// NewManager creates a new session manager
+// NewManager creates a new session manager that auto-saves to filePath.
+// An empty filePath disables auto-saving.
 func NewManager(filePath string) *Manager {
-	return &Manager{
-		session:  NewSession(),
-		filePath: filePath,
-	}
+	return NewManagerWithSession(NewSession(), filePath)
 }
 
 // NewManagerWithSession creates a new session manager with an existing session
 func NewManagerWithSession(session *Session, filePath string) *Manager {
+	return NewManagerWithStore(session, NewFileStore(""), filePath)
+}
+
+// NewManagerWithStore creates a session manager that auto-saves the session
+// under id in the given store. An empty id disables auto-saving.
+func NewManagerWithStore(session *Session, store Store, id string) *Manager {
+	if session == nil {
+		session = NewSession()
+	}
 	return &Manager{
 		session:  session,
-		filePath: filePath,
+		store:    store,
+		filePath: id,
 	}
 }
 
+// autoSave persists the session if the manager is bound to a store; callers must hold the lock
+func (m *Manager) autoSave() error {
+	if m.filePath == "" || m.store == nil {
+		return nil
+	}
+	if err := m.store.Save(context.Background(), m.filePath, m.session); err != nil {
+		return err
+	}
+	m.saved = true
+	return nil
+}
+
+// autoAppend persists the last n messages, appending them when the store
+// supports it; callers must hold the lock
+func (m *Manager) autoAppend(n int) error {
+	if m.filePath == "" || m.store == nil {
+		return nil
+	}
+	appender, ok := m.store.(Appender)
+	if !ok || !m.saved {
+		return m.autoSave()
+	}
+	if n == 0 {
+		return nil
+	}
+	newMsgs := m.session.Messages[len(m.session.Messages)-n:]
+	return appender.Append(context.Background(), m.filePath, m.session, newMsgs)
+}
+
 // AddMessage adds a message to the session and auto-saves
 func (m *Manager) AddMessage(msg *schema.Message) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	sessionMsg := ConvertFromSchemaMessage(msg)
-	m.session.AddMessage(sessionMsg)
-
-	if m.filePath != "" {
-		return m.session.SaveToFile(m.filePath)
-	}
+	m.add(msg)
 
-	return nil
+	return m.autoAppend(1)
 }
 
 // AddMessages adds multiple messages to the session and auto-saves
@@ -51,36 +95,92 @@ func (m *Manager) AddMessages(msgs []*schema.Message) error {
 	defer m.mutex.Unlock()
 
 	for _, msg := range msgs {
-		sessionMsg := ConvertFromSchemaMessage(msg)
-		m.session.AddMessage(sessionMsg)
-	}
-
-	if m.filePath != "" {
-		return m.session.SaveToFile(m.filePath)
+		m.add(msg)
 	}
 
-	return nil
+	return m.autoAppend(len(msgs))
 }
 
-// ReplaceAllMessages replaces all messages in the session with the provided messages
+// ReplaceAllMessages replaces all messages in the session with the provided
+// messages. When msgs only extends the current history, the existing messages
+// are kept and just the new ones are saved, which for JSONL sessions is an
+// append rather than a rewrite.
 func (m *Manager) ReplaceAllMessages(msgs []*schema.Message) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.extends(msgs) {
+		existing := len(m.session.Messages)
+		for _, msg := range msgs[existing:] {
+			m.add(msg)
+		}
+		return m.autoAppend(len(msgs) - existing)
+	}
+
 	// Clear existing messages
 	m.session.Messages = []Message{}
+	m.history = nil
 
 	// Add all new messages
 	for _, msg := range msgs {
-		sessionMsg := ConvertFromSchemaMessage(msg)
-		m.session.AddMessage(sessionMsg)
+		m.add(msg)
+	}
+
+	return m.autoSave()
+}
+
+// add appends msg to the session and the shared history; callers must hold
+// the lock
+func (m *Manager) add(msg *schema.Message) {
+	m.syncHistory()
+	m.session.AddMessage(ConvertFromSchemaMessage(msg))
+	m.history = append(m.history, msg)
+}
+
+// syncHistory makes history as long as the session's messages, converting
+// the messages it does not know yet, such as those of a loaded session;
+// callers must hold the lock
+func (m *Manager) syncHistory() {
+	m.history = m.history[:min(len(m.history), len(m.session.Messages))]
+	for i := len(m.history); i < len(m.session.Messages); i++ {
+		m.history = append(m.history, m.session.Messages[i].ConvertToSchemaMessage())
 	}
+}
 
-	if m.filePath != "" {
-		return m.session.SaveToFile(m.filePath)
+// extends reports whether msgs starts with the session's current messages.
+// Messages shared with the history match by pointer; only others are
+// converted and compared. Callers must hold the lock.
+func (m *Manager) extends(msgs []*schema.Message) bool {
+	if len(msgs) < len(m.session.Messages) {
+		return false
+	}
+	m.syncHistory()
+	for i, existing := range m.session.Messages {
+		if msgs[i] == m.history[i] {
+			continue
+		}
+		if !sameMessage(ConvertFromSchemaMessage(msgs[i]), existing) {
+			return false
+		}
+		// Share the caller's copy so the next save matches it by pointer
+		m.history[i] = msgs[i]
 	}
+	return true
+}
 
-	return nil
+// sameMessage reports whether a converted message is the saved message
+// existing, by role, content and tool call IDs
+func sameMessage(converted, existing Message) bool {
+	if converted.Role != existing.Role || converted.Content != existing.Content ||
+		converted.ToolCallID != existing.ToolCallID || len(converted.ToolCalls) != len(existing.ToolCalls) {
+		return false
+	}
+	for j := range existing.ToolCalls {
+		if converted.ToolCalls[j].ID != existing.ToolCalls[j].ID {
+			return false
+		}
+	}
+	return true
 }
 
 // SetMetadata sets the session metadata
@@ -90,24 +190,18 @@ func (m *Manager) SetMetadata(metadata Metadata) error {
 
 	m.session.SetMetadata(metadata)
 
-	if m.filePath != "" {
-		return m.session.SaveToFile(m.filePath)
-	}
-
-	return nil
+	return m.autoSave()
 }
 
-// GetMessages returns all messages as schema.Message slice
+// GetMessages returns all messages as schema.Message slice. The slice is the
+// caller's, but the messages are shared with the manager and must not be
+// modified.
 func (m *Manager) GetMessages() []*schema.Message {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
-	messages := make([]*schema.Message, len(m.session.Messages))
-	for i, msg := range m.session.Messages {
-		messages[i] = msg.ConvertToSchemaMessage()
-	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	return messages
+	m.syncHistory()
+	return append([]*schema.Message(nil), m.history...)
 }
 
 // GetSession returns a copy of the current session
@@ -123,19 +217,23 @@ func (m *Manager) GetSession() *Session {
 	return &sessionCopy
 }
 
-// Save manually saves the session to file
+// Save manually saves the session to its store
 func (m *Manager) Save() error {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	if m.filePath == "" {
+	if m.filePath == "" || m.store == nil {
 		return fmt.Errorf("no file path specified for session manager")
 	}
 
-	return m.session.SaveToFile(m.filePath)
+	if err := m.store.Save(context.Background(), m.filePath, m.session); err != nil {
+		return err
+	}
+	m.saved = true
+	return nil
 }
 
-// GetFilePath returns the file path for this session
+// GetFilePath returns the file path (or store ID) for this session
 func (m *Manager) GetFilePath() string {
 	return m.filePath
 }
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/clock"
+	"github.com/osi4iot/mcphost/internal/turn"
 )
 
 // Session represents a complete conversation session with metadata
@@ -25,6 +29,11 @@ type Metadata struct {
 	MCPHostVersion string `json:"mcphost_version"`
 	Provider       string `json:"provider"`
 	Model          string `json:"model"`
+	Persona        string `json:"persona,omitempty"` // selected with --persona or /persona
+
+	// APIKey is the name of the serve API key that created the session;
+	// other keys can't see or change it
+	APIKey string `json:"api_key,omitempty"`
 }
 
 // Message represents a single message in the session
@@ -35,6 +44,7 @@ type Message struct {
 	Timestamp  time.Time  `json:"timestamp"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"` // For tool result messages
+	TurnID     string     `json:"turn_id,omitempty"`      // The turn that added the message
 }
 
 // ToolCall represents a tool call within a message
@@ -48,8 +58,8 @@ type ToolCall struct {
 func NewSession() *Session {
 	return &Session{
 		Version:   "1.0",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: clock.Now(),
+		UpdatedAt: clock.Now(),
 		Messages:  []Message{},
 		Metadata:  Metadata{},
 	}
@@ -61,22 +71,31 @@ func (s *Session) AddMessage(msg Message) {
 		msg.ID = generateMessageID()
 	}
 	if msg.Timestamp.IsZero() {
-		msg.Timestamp = time.Now()
+		msg.Timestamp = clock.Now()
 	}
 
 	s.Messages = append(s.Messages, msg)
-	s.UpdatedAt = time.Now()
+	s.UpdatedAt = clock.Now()
 }
 
 // SetMetadata sets the session metadata
 func (s *Session) SetMetadata(metadata Metadata) {
 	s.Metadata = metadata
-	s.UpdatedAt = time.Now()
+	s.UpdatedAt = clock.Now()
 }
 
-// SaveToFile saves the session to a JSON file
+// SaveToFile saves the session to a file, as JSONL when the path ends in
+// .jsonl and as a JSON document otherwise
 func (s *Session) SaveToFile(filePath string) error {
-	s.UpdatedAt = time.Now()
+	s.UpdatedAt = clock.Now()
+
+	if IsJSONL(filePath) {
+		data, err := marshalJSONL(s)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(filePath, data)
+	}
 
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
@@ -86,13 +105,17 @@ func (s *Session) SaveToFile(filePath string) error {
 	return os.WriteFile(filePath, data, 0644)
 }
 
-// LoadFromFile loads a session from a JSON file
+// LoadFromFile loads a session from a JSON or JSONL file
 func LoadFromFile(filePath string) (*Session, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read session file: %v", err)
 	}
 
+	if IsJSONL(filePath) {
+		return unmarshalJSONL(data)
+	}
+
 	var session Session
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal session: %v", err)
@@ -106,7 +129,19 @@ func ConvertFromSchemaMessage(msg *schema.Message) Message {
 	sessionMsg := Message{
 		Role:      string(msg.Role),
 		Content:   msg.Content,
-		Timestamp: time.Now(),
+		Timestamp: clock.Now(),
+		TurnID:    turn.Of(msg),
+	}
+
+	// Multimodal messages keep their text; images and audio are not saved
+	if sessionMsg.Content == "" && len(msg.MultiContent) > 0 {
+		var texts []string
+		for _, part := range msg.MultiContent {
+			if part.Type == schema.ChatMessagePartTypeText {
+				texts = append(texts, part.Text)
+			}
+		}
+		sessionMsg.Content = strings.Join(texts, "\n")
 	}
 
 	// Convert tool calls if present (for assistant messages)
@@ -166,6 +201,7 @@ func (m *Message) ConvertToSchemaMessage() *schema.Message {
 		msg.ToolCallID = m.ToolCallID
 	}
 
+	turn.Mark(msg, m.TurnID)
 	return msg
 }
 
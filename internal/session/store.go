@@ -0,0 +1,229 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSessionNotFound is returned by a Store when no session exists for an ID
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrInvalidSessionID is returned by a FileStore with a directory for an ID
+// that would name a file outside it
+var ErrInvalidSessionID = errors.New("invalid session ID")
+
+// Store persists sessions under a string identifier. Implementations must be
+// safe for concurrent use; embedders can provide their own (database, S3, ...).
+type Store interface {
+	// Load returns the session stored under id, or ErrSessionNotFound
+	Load(ctx context.Context, id string) (*Session, error)
+	// Save creates or overwrites the session stored under id
+	Save(ctx context.Context, id string, s *Session) error
+	// List returns the IDs of all stored sessions
+	List(ctx context.Context) ([]string, error)
+	// Delete removes the session stored under id, or returns ErrSessionNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// Appender is implemented by stores that can add messages to a stored session
+// without rewriting it. s is the complete session after the messages were added.
+type Appender interface {
+	Append(ctx context.Context, id string, s *Session, msgs []Message) error
+}
+
+// FileStore stores each session as a file inside a directory, named after its
+// ID with the store's extension (".json" unless configured otherwise) appended
+// when the ID has no extension. The extension selects the format: ".jsonl"
+// files are appended to instead of rewritten. A FileStore with an empty
+// directory treats IDs as plain file paths, which is what the CLI uses.
+type FileStore struct {
+	dir string
+	ext string
+}
+
+// NewFileStore creates a file-backed store rooted at dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir, ext: ExtJSON}
+}
+
+// NewFileStoreWithExtension creates a file-backed store rooted at dir whose
+// new sessions use ext (ExtJSON or ExtJSONL)
+func NewFileStoreWithExtension(dir, ext string) *FileStore {
+	return &FileStore{dir: dir, ext: ext}
+}
+
+// path resolves a session ID to its file path. In a store with a directory
+// the file must be directly inside it, so IDs like "../x" or absolute paths
+// can't reach other files.
+func (f *FileStore) path(id string) (string, error) {
+	if f.dir == "" {
+		return id, nil
+	}
+	if filepath.Ext(id) == "" {
+		id += f.ext
+	}
+	path := id
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.dir, id)
+	}
+	if filepath.Dir(path) != filepath.Clean(f.dir) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSessionID, id)
+	}
+	return path, nil
+}
+
+// Load reads a session file
+func (f *FileStore) Load(ctx context.Context, id string) (*Session, error) {
+	path, err := f.path(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return LoadFromFile(path)
+}
+
+// Save writes a session file, creating the store directory if needed
+func (f *FileStore) Save(ctx context.Context, id string, s *Session) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+	return s.SaveToFile(path)
+}
+
+// Append adds messages to a JSONL session file. Other formats, and files that
+// do not exist yet, are saved in full.
+func (f *FileStore) Append(ctx context.Context, id string, s *Session, msgs []Message) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	if !IsJSONL(path) {
+		return f.Save(ctx, id, s)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return f.Save(ctx, id, s)
+	}
+	return appendJSONL(path, msgs)
+}
+
+// List returns the IDs of the session files in the store directory
+func (f *FileStore) List(ctx context.Context) ([]string, error) {
+	dir := f.dir
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	// IDs drop the store's own extension; files in the other format keep theirs
+	ids := []string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ExtJSON && ext != ExtJSONL) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), f.ext))
+	}
+	return ids, nil
+}
+
+// Delete removes a session file
+func (f *FileStore) Delete(ctx context.Context, id string) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// MemoryStore keeps sessions in memory, useful for tests and short-lived embedders
+type MemoryStore struct {
+	sessions map[string][]byte
+	mutex    sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string][]byte)}
+}
+
+// Load returns a copy of the stored session
+func (m *MemoryStore) Load(ctx context.Context, id string) (*Session, error) {
+	m.mutex.RLock()
+	data, ok := m.sessions[id]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &s, nil
+}
+
+// Save stores a snapshot of the session so later mutations don't leak in
+func (m *MemoryStore) Save(ctx context.Context, id string, s *Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sessions[id] = data
+	return nil
+}
+
+// List returns the stored session IDs in sorted order
+func (m *MemoryStore) List(ctx context.Context) ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes a stored session
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.sessions[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	delete(m.sessions, id)
+	return nil
+}
@@ -0,0 +1,218 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/clock"
+	"github.com/osi4iot/mcphost/internal/turn"
+)
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	s := NewSession()
+	s.AddMessage(Message{Role: "user", Content: "hello"})
+	if err := store.Save(ctx, "first", s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	// Mutations after saving must not affect the stored copy
+	s.AddMessage(Message{Role: "assistant", Content: "hi"})
+
+	loaded, err := store.Load(ctx, "first")
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if len(loaded.Messages) != 1 || loaded.Messages[0].Content != "hello" {
+		t.Errorf("unexpected loaded messages: %+v", loaded.Messages)
+	}
+
+	if err := store.Save(ctx, "second", NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	ids, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "first" || ids[1] != "second" {
+		t.Errorf("unexpected session ids: %v", ids)
+	}
+
+	if err := store.Delete(ctx, "first"); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	if err := store.Delete(ctx, "first"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound on second delete, got %v", err)
+	}
+	if _, err := store.Load(ctx, "first"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected deleted session to be gone, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	testStore(t, NewFileStore(dir))
+
+	if _, err := os.Stat(filepath.Join(dir, "second.json")); err != nil {
+		t.Errorf("expected session file on disk: %v", err)
+	}
+}
+
+func TestFileStoreRejectsPathsOutsideDir(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "sessions")
+	store := NewFileStore(dir)
+
+	outside := filepath.Join(root, "outside.json")
+	if err := os.WriteFile(outside, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"../outside", "../outside.json", outside, "nested/id", ".."} {
+		if err := store.Save(ctx, id, NewSession()); !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("Save(%q): expected ErrInvalidSessionID, got %v", id, err)
+		}
+		if _, err := store.Load(ctx, id); !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("Load(%q): expected ErrInvalidSessionID, got %v", id, err)
+		}
+		if err := store.Delete(ctx, id); !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("Delete(%q): expected ErrInvalidSessionID, got %v", id, err)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("expected the file outside the store to be left alone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nested")); !os.IsNotExist(err) {
+		t.Error("expected no directory created outside the store")
+	}
+}
+
+func TestFileStoreWithoutDirUsesPaths(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "my-session.json")
+
+	store := NewFileStore("")
+	if err := store.Save(ctx, path, NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected session at %s: %v", path, err)
+	}
+}
+
+func TestManagerAutoSavesToStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	m := NewManagerWithStore(nil, store, "auto")
+	if err := m.AddMessage(schema.UserMessage("hello")); err != nil {
+		t.Fatalf("failed to add message: %v", err)
+	}
+
+	loaded, err := store.Load(ctx, "auto")
+	if err != nil {
+		t.Fatalf("expected session to be auto-saved: %v", err)
+	}
+	if len(loaded.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(loaded.Messages))
+	}
+
+	// Managers without an ID never save
+	unsaved := NewManagerWithStore(nil, store, "")
+	if err := unsaved.AddMessage(schema.UserMessage("ignored")); err != nil {
+		t.Fatalf("failed to add message: %v", err)
+	}
+	if ids, _ := store.List(ctx); len(ids) != 1 {
+		t.Errorf("expected only the auto-saved session, got %v", ids)
+	}
+}
+
+func TestMessageKeepsTurnID(t *testing.T) {
+	msg := schema.AssistantMessage("hi", nil)
+	turn.Mark(msg, "turn_1")
+
+	saved := ConvertFromSchemaMessage(msg)
+	if saved.TurnID != "turn_1" {
+		t.Fatalf("expected the turn ID to be saved, got %q", saved.TurnID)
+	}
+	if got := turn.Of(saved.ConvertToSchemaMessage()); got != "turn_1" {
+		t.Errorf("expected the turn ID back on the loaded message, got %q", got)
+	}
+}
+
+// appendCounter is a memory store that counts how sessions are written
+type appendCounter struct {
+	*MemoryStore
+	saves    int
+	appended []Message
+}
+
+func (c *appendCounter) Save(ctx context.Context, id string, s *Session) error {
+	c.saves++
+	return c.MemoryStore.Save(ctx, id, s)
+}
+
+func (c *appendCounter) Append(ctx context.Context, id string, s *Session, msgs []Message) error {
+	c.appended = append(c.appended, msgs...)
+	return c.MemoryStore.Save(ctx, id, s)
+}
+
+func TestManagerSharesHistory(t *testing.T) {
+	loaded := NewSession()
+	loaded.AddMessage(Message{Role: "user", Content: "hello"})
+	loaded.AddMessage(Message{Role: "assistant", Content: "hi"})
+	store := &appendCounter{MemoryStore: NewMemoryStore()}
+	m := NewManagerWithStore(loaded, store, "shared")
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	history := m.GetMessages()
+	if again := m.GetMessages(); again[0] != history[0] {
+		t.Fatal("expected the history's messages to be shared between calls")
+	}
+
+	// Extending the shared history appends just the new message
+	extended := append(history, schema.UserMessage("next"))
+	if err := m.ReplaceAllMessages(extended); err != nil {
+		t.Fatal(err)
+	}
+	if store.saves != 1 || len(store.appended) != 1 || store.appended[0].Content != "next" {
+		t.Fatalf("expected one appended message, got %d saves and %+v", store.saves, store.appended)
+	}
+
+	// A changed message is a new one and rewrites the session
+	changed := append([]*schema.Message{schema.UserMessage("hello again")}, extended[1:]...)
+	if err := m.ReplaceAllMessages(changed); err != nil {
+		t.Fatal(err)
+	}
+	if store.saves != 2 || m.GetSession().Messages[0].Content != "hello again" {
+		t.Errorf("expected the changed history to be saved whole, got %d saves", store.saves)
+	}
+}
+
+func TestSessionTimesFollowClock(t *testing.T) {
+	fixed := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	defer clock.Set(clock.Fixed(fixed))()
+
+	s := NewSession()
+	s.AddMessage(ConvertFromSchemaMessage(schema.UserMessage("hello")))
+	if !s.CreatedAt.Equal(fixed) || !s.UpdatedAt.Equal(fixed) || !s.Messages[0].Timestamp.Equal(fixed) {
+		t.Errorf("expected every time at %v, got %+v", fixed, s)
+	}
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Policies for trimming a conversation that is over its message limit
+const (
+	// TrimDropOldest drops whole turns, oldest first
+	TrimDropOldest = "drop-oldest"
+	// TrimDropToolResultsFirst first strips the tool calls and results from
+	// the oldest turns, keeping their prompts and answers, then drops turns
+	TrimDropToolResultsFirst = "drop-oldest-tool-results-first"
+)
+
+// TrimOptions limits the length of a conversation
+type TrimOptions struct {
+	MaxMessages int    // messages to keep, not counting the system prompt; 0 for no limit
+	Policy      string // TrimDropOldest (default) or TrimDropToolResultsFirst
+	KeepTurns   int    // most recent turns never trimmed, even when over the limit
+}
+
+// Validate checks the policy and numbers
+func (o TrimOptions) Validate() error {
+	switch o.Policy {
+	case "", TrimDropOldest, TrimDropToolResultsFirst:
+	default:
+		return fmt.Errorf("unknown history trim policy %q (use %s or %s)", o.Policy, TrimDropOldest, TrimDropToolResultsFirst)
+	}
+	if o.MaxMessages < 0 || o.KeepTurns < 0 {
+		return fmt.Errorf("history limits must not be negative")
+	}
+	return nil
+}
+
+// TrimMessages shortens a conversation to at most opts.MaxMessages messages
+// besides the leading system prompt. It removes whole turns, or a turn's tool
+// traffic, so every kept tool result still follows its tool call. The last
+// opts.KeepTurns turns are kept intact, so the result may stay over the limit.
+// It returns the messages unchanged when they fit.
+func TrimMessages(msgs []*schema.Message, opts TrimOptions) []*schema.Message {
+	system := 0
+	for system < len(msgs) && msgs[system].Role == schema.System {
+		system++
+	}
+	if opts.MaxMessages <= 0 || len(msgs)-system <= opts.MaxMessages {
+		return msgs
+	}
+
+	turns := splitTurns(msgs[system:])
+	count := len(msgs) - system
+	trimmable := max(len(turns)-opts.KeepTurns, 0)
+
+	if opts.Policy == TrimDropToolResultsFirst {
+		for i := 0; i < trimmable && count > opts.MaxMessages; i++ {
+			stripped := withoutToolTraffic(turns[i])
+			count -= len(turns[i]) - len(stripped)
+			turns[i] = stripped
+		}
+	}
+
+	dropped := 0
+	for dropped < trimmable && count > opts.MaxMessages {
+		count -= len(turns[dropped])
+		dropped++
+	}
+
+	trimmed := append([]*schema.Message{}, msgs[:system]...)
+	for _, turn := range turns[dropped:] {
+		trimmed = append(trimmed, turn...)
+	}
+	return trimmed
+}
+
+// splitTurns groups messages into turns. A turn starts with a user message
+// that follows a final answer, rather than the tool results or instructions
+// added in the middle of a run.
+func splitTurns(msgs []*schema.Message) [][]*schema.Message {
+	var turns [][]*schema.Message
+	for i, msg := range msgs {
+		if i == 0 || (msg.Role == schema.User && isFinalAnswer(msgs[i-1])) {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], msg)
+	}
+	return turns
+}
+
+// isFinalAnswer reports whether msg is an assistant reply that called no tools
+func isFinalAnswer(msg *schema.Message) bool {
+	return msg.Role == schema.Assistant && len(msg.ToolCalls) == 0
+}
+
+// withoutToolTraffic keeps a turn's prompt and answers: it drops tool calls,
+// tool results, and the user messages added between them during the run,
+// which are the ones after the turn's first message
+func withoutToolTraffic(turn []*schema.Message) []*schema.Message {
+	var kept []*schema.Message
+	for i, msg := range turn {
+		switch {
+		case msg.Role == schema.Tool:
+		case msg.Role == schema.Assistant && len(msg.ToolCalls) > 0:
+		case msg.Role == schema.User && i > 0:
+		default:
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// DropUnansweredToolCalls removes a trailing step whose tool calls do not all
+// have results, along with the results it has, so a conversation cut off in
+// the middle of running tools can be sent to a provider again. Providers
+// reject a tool call that is not followed by its result. It returns the
+// messages unchanged when the last step is complete.
+func DropUnansweredToolCalls(msgs []*schema.Message) []*schema.Message {
+	step := len(msgs) - 1
+	for step >= 0 && msgs[step].Role == schema.Tool {
+		step--
+	}
+	if step < 0 || msgs[step].Role != schema.Assistant || len(msgs[step].ToolCalls) == 0 {
+		return msgs
+	}
+	answered := make(map[string]bool)
+	for _, msg := range msgs[step+1:] {
+		answered[msg.ToolCallID] = true
+	}
+	for _, call := range msgs[step].ToolCalls {
+		if !answered[call.ID] {
+			return msgs[:step]
+		}
+	}
+	return msgs
+}
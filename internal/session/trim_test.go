@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// conversation builds a history from a compact description: s system, u user,
+// a answer, c assistant tool call, t tool result
+func conversation(spec string) []*schema.Message {
+	var msgs []*schema.Message
+	for i, kind := range strings.Split(spec, " ") {
+		content := kind + string(rune('0'+i%10))
+		switch kind {
+		case "s":
+			msgs = append(msgs, schema.SystemMessage(content))
+		case "u":
+			msgs = append(msgs, schema.UserMessage(content))
+		case "a":
+			msgs = append(msgs, schema.AssistantMessage(content, nil))
+		case "c":
+			msgs = append(msgs, schema.AssistantMessage(content, []schema.ToolCall{{ID: content}}))
+		case "t":
+			msgs = append(msgs, schema.ToolMessage(content, "call"))
+		}
+	}
+	return msgs
+}
+
+// describe reverses conversation, for comparing results
+func describe(msgs []*schema.Message) string {
+	var kinds []string
+	for _, msg := range msgs {
+		switch {
+		case msg.Role == schema.System:
+			kinds = append(kinds, "s")
+		case msg.Role == schema.User:
+			kinds = append(kinds, "u")
+		case msg.Role == schema.Tool:
+			kinds = append(kinds, "t")
+		case len(msg.ToolCalls) > 0:
+			kinds = append(kinds, "c")
+		default:
+			kinds = append(kinds, "a")
+		}
+	}
+	return strings.Join(kinds, " ")
+}
+
+func TestTrimMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		opts TrimOptions
+		want string
+	}{
+		{name: "no limit", spec: "s u a u a", opts: TrimOptions{}, want: "s u a u a"},
+		{name: "under limit", spec: "s u a u a", opts: TrimOptions{MaxMessages: 4}, want: "s u a u a"},
+		{name: "drop oldest turns", spec: "s u a u c t a u a", opts: TrimOptions{MaxMessages: 3}, want: "s u a"},
+		{name: "keep last turns", spec: "s u a u c t a u a", opts: TrimOptions{MaxMessages: 3, KeepTurns: 2}, want: "s u c t a u a"},
+		{name: "keep more turns than exist", spec: "u a u a", opts: TrimOptions{MaxMessages: 1, KeepTurns: 5}, want: "u a u a"},
+		{name: "interjection stays in its turn", spec: "u c t u c t a u a", opts: TrimOptions{MaxMessages: 2}, want: "u a"},
+		{
+			name: "tool results first",
+			spec: "s u c t c t a u c t a u a",
+			opts: TrimOptions{MaxMessages: 8, Policy: TrimDropToolResultsFirst},
+			want: "s u a u c t a u a",
+		},
+		{
+			name: "tool results first then turns",
+			spec: "s u c t a u c t a u a",
+			opts: TrimOptions{MaxMessages: 4, Policy: TrimDropToolResultsFirst, KeepTurns: 1},
+			want: "s u a u a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(TrimMessages(conversation(tt.spec), tt.opts)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimOptionsValidate(t *testing.T) {
+	if err := (TrimOptions{Policy: "drop-newest"}).Validate(); err == nil {
+		t.Error("expected an unknown policy to be rejected")
+	}
+	if err := (TrimOptions{MaxMessages: -1}).Validate(); err == nil {
+		t.Error("expected a negative limit to be rejected")
+	}
+	if err := (TrimOptions{MaxMessages: 50, Policy: TrimDropToolResultsFirst, KeepTurns: 2}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDropUnansweredToolCalls(t *testing.T) {
+	call := func(ids ...string) *schema.Message {
+		var calls []schema.ToolCall
+		for _, id := range ids {
+			calls = append(calls, schema.ToolCall{ID: id})
+		}
+		return schema.AssistantMessage("", calls)
+	}
+
+	// A session saved mid-step, as a run stopped while running tools leaves it
+	path := filepath.Join(t.TempDir(), "cut.json")
+	saved := NewSession()
+	for _, msg := range []*schema.Message{
+		schema.UserMessage("go"),
+		call("a"),
+		schema.ToolMessage("done", "a"),
+		call("b", "c"),
+		schema.ToolMessage("done", "b"),
+	} {
+		saved.AddMessage(ConvertFromSchemaMessage(msg))
+	}
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewFileStore("").Load(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []*schema.Message
+	for _, msg := range loaded.Messages {
+		msgs = append(msgs, msg.ConvertToSchemaMessage())
+	}
+	if got := describe(DropUnansweredToolCalls(msgs)); got != "u c t" {
+		t.Errorf("expected the unfinished step dropped, got %q", got)
+	}
+
+	complete := []*schema.Message{schema.UserMessage("go"), call("a", "b"), schema.ToolMessage("done", "b"), schema.ToolMessage("done", "a")}
+	if got := DropUnansweredToolCalls(complete); len(got) != len(complete) {
+		t.Errorf("expected a finished step kept, got %d messages", len(got))
+	}
+	if got := DropUnansweredToolCalls(complete[:2]); len(got) != 1 {
+		t.Errorf("expected a step without results dropped, got %d messages", len(got))
+	}
+}
@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +15,15 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/osi4iot/mcphost/internal/builtin"
 	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+// Servers started for session workspaces are closed after scopedIdleTime
+// without use, and at most maxScopedConnections run at once
+const (
+	scopedIdleTime       = 15 * time.Minute
+	maxScopedConnections = 32
 )
 
 // ConnectionPoolConfig configuration for connection pool
@@ -22,6 +33,10 @@ type ConnectionPoolConfig struct {
 	HealthCheckInterval time.Duration
 	MaxErrorCount       int
 	ReconnectDelay      time.Duration
+
+	// KeepaliveInterval is how long a remote (SSE/streamable) connection may sit
+	// idle before it is pinged, so proxies and load balancers don't drop it
+	KeepaliveInterval time.Duration
 }
 
 // DefaultConnectionPoolConfig returns default configuration
@@ -33,6 +48,7 @@ func DefaultConnectionPoolConfig() *ConnectionPoolConfig {
 		HealthCheckInterval: 30 * time.Second,
 		MaxErrorCount:       3,
 		ReconnectDelay:      2 * time.Second,
+		KeepaliveInterval:   20 * time.Second,
 	}
 }
 
@@ -42,18 +58,24 @@ type MCPConnection struct {
 	serverName   string
 	serverConfig config.MCPServerConfig
 	lastUsed     time.Time
+	lastPing     time.Time // last successful keepalive ping
 	isHealthy    bool
 	errorCount   int
 	lastError    error
+	serverInfo   *mcp.InitializeResult // what the server reported during initialization
 	mu           sync.RWMutex
 }
 
 // MCPConnectionPool manages MCP connections
 type MCPConnectionPool struct {
 	connections map[string]*MCPConnection
+	scoped      map[string]*MCPConnection // started for a session workspace, by server and workspace
 	config      *ConnectionPoolConfig
 	mu          sync.RWMutex
 	model       model.ToolCallingChatModel
+	provider    *models.ProviderConfig                         // the agent's model config for builtin servers, guarded by mu
+	sampling    func(serverName string) client.SamplingHandler // serves sampling requests from servers (optional)
+	listChanged func(serverName string)                        // called when a server's tool list changes (optional)
 	ctx         context.Context
 	cancel      context.CancelFunc
 	debug       bool
@@ -69,6 +91,7 @@ func NewMCPConnectionPool(config *ConnectionPoolConfig, model model.ToolCallingC
 	ctx, cancel := context.WithCancel(context.Background())
 	pool := &MCPConnectionPool{
 		connections: make(map[string]*MCPConnection),
+		scoped:      make(map[string]*MCPConnection),
 		config:      config,
 		model:       model,
 		ctx:         ctx,
@@ -87,6 +110,42 @@ func (p *MCPConnectionPool) SetDebugLogger(logger DebugLogger) {
 	p.debugLogger = logger
 }
 
+// SetProviderConfig sets the agent's model config, which builtin servers
+// created from now on start their own models from
+func (p *MCPConnectionPool) SetProviderConfig(config *models.ProviderConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.provider = config
+}
+
+// SetSamplingHandlers sets the function returning the handler for sampling
+// requests from each server, used for connections created from now on
+func (p *MCPConnectionPool) SetSamplingHandlers(handlers func(serverName string) client.SamplingHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sampling = handlers
+}
+
+// SetToolsChangedHandler sets the function called, in its own goroutine, when
+// a server sends notifications/tools/list_changed
+func (p *MCPConnectionPool) SetToolsChangedHandler(handler func(serverName string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listChanged = handler
+}
+
+// clientOptions returns the options for new stdio, SSE and streamable clients
+func (p *MCPConnectionPool) clientOptions(serverName string) []client.ClientOption {
+	if p.sampling == nil {
+		return nil
+	}
+	handler := p.sampling(serverName)
+	if handler == nil {
+		return nil
+	}
+	return []client.ClientOption{client.WithSamplingHandler(handler)}
+}
+
 // GetConnection gets a connection from the pool
 func (p *MCPConnectionPool) GetConnection(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (*MCPConnection, error) {
 	p.mu.Lock()
@@ -130,6 +189,10 @@ func (p *MCPConnectionPool) GetConnection(ctx context.Context, serverName string
 
 // GetConnectionWithHealthCheck gets a connection from the pool with proactive health check
 func (p *MCPConnectionPool) GetConnectionWithHealthCheck(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (*MCPConnection, error) {
+	if ws := workspace.From(ctx); !ws.IsZero() && workspaceScoped(serverConfig) {
+		return p.getWorkspaceConnection(ctx, serverName, serverConfig, ws)
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -174,6 +237,74 @@ func (p *MCPConnectionPool) GetConnectionWithHealthCheck(ctx context.Context, se
 	return conn, nil
 }
 
+// getWorkspaceConnection gets the connection to serverName started in ws,
+// starting it on first use. It lives as long as the pool, so later sessions in
+// the same workspace reuse it.
+func (p *MCPConnectionPool) getWorkspaceConnection(ctx context.Context, serverName string, serverConfig config.MCPServerConfig, ws workspace.Workspace) (*MCPConnection, error) {
+	key := serverName + "\x00" + ws.Key()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, exists := p.scoped[key]; exists {
+		if p.performHealthCheck(ctx, conn) {
+			conn.mu.Lock()
+			conn.lastUsed = time.Now()
+			conn.mu.Unlock()
+			return conn, nil
+		}
+		conn.client.Close()
+		delete(p.scoped, key)
+	}
+
+	if len(p.scoped) >= maxScopedConnections {
+		return nil, fmt.Errorf("too many workspace servers running (%d); try again once some are idle", maxScopedConnections)
+	}
+
+	if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
+		p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Creating connection for %s in %s", serverName, ws.Dir))
+	}
+	// Started on the pool's context so the process outlives the request
+	conn, err := p.createConnection(workspace.With(p.ctx, ws), serverName, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection for %s in workspace: %w", serverName, err)
+	}
+
+	p.scoped[key] = conn
+	return conn, nil
+}
+
+// workspaceScoped reports whether serverConfig's server needs its own
+// connection per workspace: stdio servers and plugins are processes started in
+// it, and the fs server is confined to its directory. The bash and commands
+// servers read the workspace of each call instead.
+func workspaceScoped(serverConfig config.MCPServerConfig) bool {
+	switch serverConfig.GetTransportType() {
+	case "stdio":
+		return true
+	case "inprocess":
+		if serverConfig.Name == "fs" {
+			return true
+		}
+		_, plugin := builtin.NewRegistry().Plugin(serverConfig.Name)
+		return plugin
+	}
+	return false
+}
+
+// newStdio returns a stdio transport running command in the workspace ctx
+// carries, if any
+func newStdio(ctx context.Context, command string, env []string, args ...string) *transport.Stdio {
+	ws := workspace.From(ctx)
+	return transport.NewStdioWithOptions(command, env, args, transport.WithCommandFunc(
+		func(ctx context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+			cmd := exec.CommandContext(ctx, command, args...)
+			cmd.Env = append(append(os.Environ(), env...), ws.Environ()...)
+			cmd.Dir = ws.Dir
+			return cmd, nil
+		}))
+}
+
 // performHealthCheck performs a quick health check on the connection
 func (p *MCPConnectionPool) performHealthCheck(ctx context.Context, conn *MCPConnection) bool {
 	// Create a short timeout context for health check
@@ -208,7 +339,21 @@ func (p *MCPConnectionPool) createConnection(ctx context.Context, serverName str
 		return nil, err
 	}
 
-	if err := p.initializeClient(ctx, client); err != nil {
+	// Servers that register tools later (e.g. after auth) announce them with a notification
+	if notifier, ok := client.(interface {
+		OnNotification(func(mcp.JSONRPCNotification))
+	}); ok && p.listChanged != nil {
+		listChanged := p.listChanged
+		notifier.OnNotification(func(notification mcp.JSONRPCNotification) {
+			if notification.Method == mcp.MethodNotificationToolsListChanged {
+				// Handled off the transport's read loop, since reloading calls back into the server
+				go listChanged(serverName)
+			}
+		})
+	}
+
+	serverInfo, err := p.initializeClient(ctx, client)
+	if err != nil {
 		client.Close()
 		return nil, err
 	}
@@ -221,6 +366,7 @@ func (p *MCPConnectionPool) createConnection(ctx context.Context, serverName str
 		isHealthy:    true,
 		errorCount:   0,
 		lastError:    nil,
+		serverInfo:   serverInfo,
 	}
 
 	if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
@@ -235,20 +381,22 @@ func (p *MCPConnectionPool) createMCPClient(ctx context.Context, serverName stri
 
 	switch transportType {
 	case "stdio":
-		return p.createStdioClient(ctx, serverConfig)
+		return p.createStdioClient(ctx, serverName, serverConfig)
 	case "sse":
-		return p.createSSEClient(ctx, serverConfig)
+		return p.createSSEClient(ctx, serverName, serverConfig)
 	case "streamable":
-		return p.createStreamableClient(ctx, serverConfig)
+		return p.createStreamableClient(ctx, serverName, serverConfig)
 	case "inprocess":
 		return p.createBuiltinClient(ctx, serverName, serverConfig)
+	case "openapi":
+		return createOpenAPIClient(ctx, serverName, serverConfig)
 	default:
 		return nil, fmt.Errorf("unsupported transport type '%s' for server %s", transportType, serverName)
 	}
 }
 
 // createStdioClient creates a STDIO client
-func (p *MCPConnectionPool) createStdioClient(ctx context.Context, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
+func (p *MCPConnectionPool) createStdioClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
 	var env []string
 	var command string
 	var args []string
@@ -268,8 +416,8 @@ func (p *MCPConnectionPool) createStdioClient(ctx context.Context, serverConfig
 		}
 	}
 
-	stdioTransport := transport.NewStdio(command, env, args...)
-	stdioClient := client.NewClient(stdioTransport)
+	stdioTransport := newStdio(ctx, command, env, args...)
+	stdioClient := client.NewClient(stdioTransport, p.clientOptions(serverName)...)
 
 	if err := stdioTransport.Start(ctx); err != nil {
 		return nil, fmt.Errorf("failed to start stdio transport: %v", err)
@@ -280,7 +428,7 @@ func (p *MCPConnectionPool) createStdioClient(ctx context.Context, serverConfig
 }
 
 // createSSEClient creates an SSE client
-func (p *MCPConnectionPool) createSSEClient(ctx context.Context, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
+func (p *MCPConnectionPool) createSSEClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
 	var options []transport.ClientOption
 
 	if len(serverConfig.Headers) > 0 {
@@ -298,10 +446,11 @@ func (p *MCPConnectionPool) createSSEClient(ctx context.Context, serverConfig co
 		}
 	}
 
-	sseClient, err := client.NewSSEMCPClient(serverConfig.URL, options...)
+	sseTransport, err := transport.NewSSE(serverConfig.URL, options...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create SSE transport: %w", err)
 	}
+	sseClient := client.NewClient(sseTransport, p.clientOptions(serverName)...)
 
 	if err := sseClient.Start(ctx); err != nil {
 		return nil, fmt.Errorf("failed to start SSE client: %v", err)
@@ -311,7 +460,7 @@ func (p *MCPConnectionPool) createSSEClient(ctx context.Context, serverConfig co
 }
 
 // createStreamableClient creates a Streamable client
-func (p *MCPConnectionPool) createStreamableClient(ctx context.Context, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
+func (p *MCPConnectionPool) createStreamableClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
 	var options []transport.StreamableHTTPCOption
 
 	if len(serverConfig.Headers) > 0 {
@@ -329,10 +478,11 @@ func (p *MCPConnectionPool) createStreamableClient(ctx context.Context, serverCo
 		}
 	}
 
-	streamableClient, err := client.NewStreamableHttpClient(serverConfig.URL, options...)
+	streamableTransport, err := transport.NewStreamableHTTP(serverConfig.URL, options...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create streamable HTTP transport: %w", err)
 	}
+	streamableClient := client.NewClient(streamableTransport, p.clientOptions(serverName)...)
 
 	if err := streamableClient.Start(ctx); err != nil {
 		return nil, fmt.Errorf("failed to start streamable HTTP client: %v", err)
@@ -344,12 +494,41 @@ func (p *MCPConnectionPool) createStreamableClient(ctx context.Context, serverCo
 // createBuiltinClient creates a builtin client
 func (p *MCPConnectionPool) createBuiltinClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
 	registry := builtin.NewRegistry()
+	registry.SetProviderConfig(p.provider)
+
+	// In a workspace the fs server is confined to the workspace's directory,
+	// which must be inside the directories it was configured with
+	options := serverConfig.Options
+	if ws := workspace.From(ctx); ws.Dir != "" && serverConfig.Name == "fs" {
+		allowedDirs, err := builtin.FilesystemDirectories(serverConfig.Options)
+		if err != nil {
+			return nil, err
+		}
+		if !workspace.Inside(ws.Dir, allowedDirs) {
+			return nil, fmt.Errorf("workspace directory %s is outside the fs server's allowed directories", ws.Dir)
+		}
+		options = make(map[string]any, len(serverConfig.Options)+1)
+		for k, v := range serverConfig.Options {
+			options[k] = v
+		}
+		options["allowed_directories"] = []string{ws.Dir}
+	}
 
-	builtinServer, err := registry.CreateServer(serverConfig.Name, serverConfig.Options, p.model)
+	builtinServer, err := registry.CreateServer(serverConfig.Name, options, p.model)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create builtin server: %v", err)
 	}
 
+	// Plugins are separate executables that speak MCP over stdio
+	if command, env := builtinServer.Command(); command != "" {
+		stdioTransport := newStdio(ctx, command, env)
+		pluginClient := client.NewClient(stdioTransport, p.clientOptions(serverName)...)
+		if err := stdioTransport.Start(ctx); err != nil {
+			return nil, fmt.Errorf("failed to start plugin %s: %v", serverConfig.Name, err)
+		}
+		return pluginClient, nil
+	}
+
 	inProcessClient, err := client.NewInProcessClient(builtinServer.GetServer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create in-process client: %v", err)
@@ -359,7 +538,7 @@ func (p *MCPConnectionPool) createBuiltinClient(ctx context.Context, serverName
 }
 
 // initializeClient initializes the client
-func (p *MCPConnectionPool) initializeClient(ctx context.Context, client client.MCPClient) error {
+func (p *MCPConnectionPool) initializeClient(ctx context.Context, client client.MCPClient) (*mcp.InitializeResult, error) {
 	initCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
@@ -371,15 +550,15 @@ func (p *MCPConnectionPool) initializeClient(ctx context.Context, client client.
 	}
 	initRequest.Params.Capabilities = mcp.ClientCapabilities{}
 
-	_, err := client.Initialize(initCtx, initRequest)
+	result, err := client.Initialize(initCtx, initRequest)
 	if err != nil {
-		return fmt.Errorf("initialization timeout or failed: %v", err)
+		return nil, fmt.Errorf("initialization timeout or failed: %v", err)
 	}
 
 	if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
 		p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Initialized MCP client"))
 	}
-	return nil
+	return result, nil
 }
 
 // startHealthCheck starts the health check routine
@@ -393,6 +572,25 @@ func (p *MCPConnectionPool) startHealthCheck() {
 			return
 		case <-ticker.C:
 			p.checkConnectionsHealth()
+			p.pingIdleConnections()
+			p.closeIdleScoped()
+		}
+	}
+}
+
+// closeIdleScoped closes the servers started for workspaces that no session
+// has used for scopedIdleTime; the next prompt in that workspace starts them
+// again
+func (p *MCPConnectionPool) closeIdleScoped() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, conn := range p.scoped {
+		conn.mu.RLock()
+		idle := time.Since(conn.lastUsed) > scopedIdleTime
+		conn.mu.RUnlock()
+		if idle {
+			conn.client.Close()
+			delete(p.scoped, key)
 		}
 	}
 }
@@ -423,6 +621,87 @@ func (p *MCPConnectionPool) checkConnectionsHealth() {
 	}
 }
 
+// pingIdleConnections pings remote connections that have been idle for the
+// keepalive interval and reconnects those that don't answer, so an idle
+// disconnect is repaired before the next tool call instead of failing it
+func (p *MCPConnectionPool) pingIdleConnections() {
+	if p.config.KeepaliveInterval <= 0 {
+		return
+	}
+
+	p.mu.RLock()
+	var idle []*MCPConnection
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		transport := conn.serverConfig.GetTransportType()
+		lastActive := conn.lastUsed
+		if conn.lastPing.After(lastActive) {
+			lastActive = conn.lastPing
+		}
+		if (transport == "sse" || transport == "streamable") && conn.isHealthy && time.Since(lastActive) >= p.config.KeepaliveInterval {
+			idle = append(idle, conn)
+		}
+		conn.mu.RUnlock()
+	}
+	p.mu.RUnlock()
+
+	for _, conn := range idle {
+		pingCtx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+		err := conn.client.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			conn.mu.Lock()
+			conn.lastPing = time.Now()
+			conn.mu.Unlock()
+			continue
+		}
+
+		if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
+			p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Keepalive ping to %s failed, reconnecting: %v", conn.serverName, err))
+		}
+		p.reconnect(conn, err)
+	}
+}
+
+// reconnect replaces a connection that stopped answering with a new one. If
+// that fails too, the old connection is marked unhealthy so the next request
+// retries.
+func (p *MCPConnectionPool) reconnect(old *MCPConnection, cause error) {
+	ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
+	defer cancel()
+
+	conn, err := p.createConnection(ctx, old.serverName, old.serverConfig)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The connection may have been replaced or removed while reconnecting
+	if current, ok := p.connections[old.serverName]; !ok || current != old {
+		if err == nil {
+			conn.client.Close()
+		}
+		return
+	}
+
+	if err != nil {
+		old.mu.Lock()
+		old.isHealthy = false
+		old.errorCount++
+		old.lastError = cause
+		old.mu.Unlock()
+		if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
+			p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Reconnecting %s failed: %v", old.serverName, err))
+		}
+		return
+	}
+
+	old.client.Close()
+	p.connections[old.serverName] = conn
+	if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
+		p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Reconnected %s", old.serverName))
+	}
+}
+
 // HandleConnectionError handles connection errors
 func (p *MCPConnectionPool) HandleConnectionError(serverName string, err error) {
 	p.mu.RLock()
@@ -473,6 +752,37 @@ func (p *MCPConnectionPool) GetConnectionStats() map[string]interface{} {
 	return stats
 }
 
+// connectionSnapshot is a point-in-time copy of a connection's health fields
+type connectionSnapshot struct {
+	isHealthy  bool
+	errorCount int
+	lastError  error
+	lastUsed   time.Time
+}
+
+// connectionState returns a snapshot of the named connection, if it exists
+func (p *MCPConnectionPool) connectionState(serverName string) (connectionSnapshot, bool) {
+	if p == nil {
+		return connectionSnapshot{}, false
+	}
+
+	p.mu.RLock()
+	conn, exists := p.connections[serverName]
+	p.mu.RUnlock()
+	if !exists {
+		return connectionSnapshot{}, false
+	}
+
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+	return connectionSnapshot{
+		isHealthy:  conn.isHealthy,
+		errorCount: conn.errorCount,
+		lastError:  conn.lastError,
+		lastUsed:   conn.lastUsed,
+	}, true
+}
+
 // ServerName returns the server name for this connection
 func (c *MCPConnection) ServerName() string {
 	return c.serverName
@@ -490,6 +800,19 @@ func (p *MCPConnectionPool) GetClients() map[string]client.MCPClient {
 	return clients
 }
 
+// GetConnectionClient returns the client of the named connection and the
+// server's initialization result
+func (p *MCPConnectionPool) GetConnectionClient(serverName string) (client.MCPClient, *mcp.InitializeResult, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conn, exists := p.connections[serverName]
+	if !exists {
+		return nil, nil, false
+	}
+	return conn.client, conn.serverInfo, true
+}
+
 // Close closes the connection pool
 func (p *MCPConnectionPool) Close() error {
 	p.cancel()
@@ -504,6 +827,13 @@ func (p *MCPConnectionPool) Close() error {
 			}
 		}
 	}
+	for _, conn := range p.scoped {
+		if err := conn.client.Close(); err != nil {
+			if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
+				p.debugLogger.LogDebug(fmt.Sprintf("[POOL] Failed to close connection %s: %v", conn.serverName, err))
+			}
+		}
+	}
 
 	if p.debugLogger != nil && p.debugLogger.IsDebugEnabled() {
 		p.debugLogger.LogDebug("[POOL] Connection pool closed")
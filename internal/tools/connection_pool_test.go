@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/workspace"
+)
+
+// expiringSessions rejects requests for sessions started before expire was
+// called, like a proxy that dropped idle connections
+type expiringSessions struct {
+	next    http.Handler
+	mu      sync.Mutex
+	seen    []string
+	expired map[string]bool
+}
+
+func (e *expiringSessions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	expired := e.expired[r.Header.Get("Mcp-Session-Id")]
+	e.mu.Unlock()
+	if expired {
+		http.Error(w, "session expired", http.StatusNotFound)
+		return
+	}
+
+	e.next.ServeHTTP(w, r)
+	if id := w.Header().Get("Mcp-Session-Id"); id != "" {
+		e.mu.Lock()
+		e.seen = append(e.seen, id)
+		e.mu.Unlock()
+	}
+}
+
+func (e *expiringSessions) expire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expired = make(map[string]bool)
+	for _, id := range e.seen {
+		e.expired[id] = true
+	}
+}
+
+func TestMCPConnectionPool_KeepaliveReconnects(t *testing.T) {
+	sessions := &expiringSessions{next: server.NewStreamableHTTPServer(server.NewMCPServer("remote", "1.0.0"))}
+	httpServer := httptest.NewServer(sessions)
+	defer httpServer.Close()
+
+	poolConfig := DefaultConnectionPoolConfig()
+	poolConfig.HealthCheckInterval = time.Hour // pings are driven by the test
+	poolConfig.KeepaliveInterval = time.Millisecond
+	pool := NewMCPConnectionPool(poolConfig, nil, false)
+	defer pool.Close()
+
+	serverConfig := config.MCPServerConfig{Type: "remote", URL: httpServer.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := pool.GetConnection(ctx, "remote", serverConfig)
+	if err != nil {
+		t.Fatalf("GetConnection failed: %v", err)
+	}
+
+	// An idle connection that still answers is kept
+	time.Sleep(2 * time.Millisecond)
+	pool.pingIdleConnections()
+	if conn, _ := pool.GetConnection(ctx, "remote", serverConfig); conn != first {
+		t.Fatal("healthy connection was replaced")
+	}
+
+	// Once the session is dropped, the failed ping reconnects transparently
+	sessions.expire()
+	time.Sleep(2 * time.Millisecond)
+	pool.pingIdleConnections()
+
+	second, err := pool.GetConnection(ctx, "remote", serverConfig)
+	if err != nil {
+		t.Fatalf("GetConnection after reconnect failed: %v", err)
+	}
+	if second == first {
+		t.Fatal("expected the dropped connection to be replaced")
+	}
+	if err := second.client.Ping(ctx); err != nil {
+		t.Errorf("reconnected client does not answer: %v", err)
+	}
+}
+
+func TestMCPConnectionPool_WorkspaceConnections(t *testing.T) {
+	pool := NewMCPConnectionPool(nil, nil, false)
+	defer pool.Close()
+
+	ctx := context.Background()
+	root := t.TempDir()
+	fsConfig := config.MCPServerConfig{Type: "builtin", Name: "fs", Options: map[string]any{"allowed_directories": []string{root}}}
+	shared, err := pool.GetConnectionWithHealthCheck(ctx, "fs", fsConfig)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	wsA := workspace.With(ctx, workspace.Workspace{Dir: mkdir(t, root, "a")})
+	a, err := pool.GetConnectionWithHealthCheck(wsA, "fs", fsConfig)
+	if err != nil {
+		t.Fatalf("failed to connect in workspace: %v", err)
+	}
+	if a == shared {
+		t.Error("expected the fs server to get its own connection in a workspace")
+	}
+	if again, _ := pool.GetConnectionWithHealthCheck(wsA, "fs", fsConfig); again != a {
+		t.Error("expected the workspace connection to be reused")
+	}
+	b, _ := pool.GetConnectionWithHealthCheck(workspace.With(ctx, workspace.Workspace{Dir: mkdir(t, root, "b")}), "fs", fsConfig)
+	if b == a {
+		t.Error("expected another workspace to get another connection")
+	}
+
+	todoConfig := config.MCPServerConfig{Type: "builtin", Name: "todo"}
+	todo, _ := pool.GetConnectionWithHealthCheck(ctx, "todo", todoConfig)
+	if inWorkspace, _ := pool.GetConnectionWithHealthCheck(wsA, "todo", todoConfig); inWorkspace != todo {
+		t.Error("expected servers that don't depend on the workspace to be shared")
+	}
+
+	// The workspace can only narrow the directories fs was configured with
+	if _, err := pool.GetConnectionWithHealthCheck(workspace.With(ctx, workspace.Workspace{Dir: "/"}), "fs", fsConfig); err == nil {
+		t.Error("expected a workspace outside the fs server's directories to be refused")
+	}
+}
+
+func TestMCPConnectionPool_WorkspaceConnectionLimits(t *testing.T) {
+	pool := NewMCPConnectionPool(nil, nil, false)
+	defer pool.Close()
+
+	ctx := context.Background()
+	root := t.TempDir()
+	fsConfig := config.MCPServerConfig{Type: "builtin", Name: "fs", Options: map[string]any{"allowed_directories": []string{root}}}
+	for i := range maxScopedConnections {
+		ws := workspace.With(ctx, workspace.Workspace{Dir: mkdir(t, root, fmt.Sprint(i))})
+		if _, err := pool.GetConnectionWithHealthCheck(ws, "fs", fsConfig); err != nil {
+			t.Fatalf("failed to connect in workspace %d: %v", i, err)
+		}
+	}
+	extra := workspace.With(ctx, workspace.Workspace{Dir: mkdir(t, root, "extra")})
+	if _, err := pool.GetConnectionWithHealthCheck(extra, "fs", fsConfig); err == nil {
+		t.Fatal("expected workspace servers to be capped")
+	}
+
+	// Idle workspace servers are closed, making room for new ones
+	pool.mu.Lock()
+	for _, conn := range pool.scoped {
+		conn.lastUsed = time.Now().Add(-scopedIdleTime - time.Minute)
+	}
+	pool.mu.Unlock()
+	pool.closeIdleScoped()
+	if _, err := pool.GetConnectionWithHealthCheck(extra, "fs", fsConfig); err != nil {
+		t.Errorf("expected room after idle servers were closed: %v", err)
+	}
+	if n := len(pool.scoped); n != 1 {
+		t.Errorf("expected 1 workspace server, got %d", n)
+	}
+}
+
+// mkdir creates the directory name under parent and returns its path
+func mkdir(t *testing.T, parent, name string) string {
+	t.Helper()
+	dir := filepath.Join(parent, name)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
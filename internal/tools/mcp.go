@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -17,17 +19,27 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/osi4iot/mcphost/internal/builtin"
 	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/openapi"
+	"github.com/osi4iot/mcphost/internal/turn"
 )
 
 // MCPToolManager manages MCP tools and clients
 type MCPToolManager struct {
-	connectionPool *MCPConnectionPool
-	tools          []tool.BaseTool
-	toolMap        map[string]*toolMapping    // maps prefixed tool names to their server and original name
-	model          model.ToolCallingChatModel // LLM model for sampling
-	config         *config.Config
-	debug          bool
-	debugLogger    DebugLogger
+	connectionPool  *MCPConnectionPool
+	toolsMu         sync.RWMutex // guards tools and toolMap, which change when servers announce new tools
+	tools           []tool.BaseTool
+	toolMap         map[string]*toolMapping    // maps prefixed tool names to their server and original name
+	model           model.ToolCallingChatModel // LLM model for sampling
+	summaryModel    model.ToolCallingChatModel // model for builtin summarize/extract tools (optional)
+	providerConfig  *models.ProviderConfig     // the agent's model config, for builtin servers (optional)
+	samplingModels  []SamplingModel            // models sampling requests may be routed to (optional)
+	approveSampling SamplingApprovalHandler
+	samplingUsage   samplingUsageLedger
+	config          *config.Config
+	loadErrors      map[string]error // servers that failed to load, by name
+	debug           bool
+	debugLogger     DebugLogger
 }
 
 // toolMapping stores the mapping between prefixed tool names and their original details
@@ -40,8 +52,34 @@ type toolMapping struct {
 
 // mcpToolImpl implements the eino tool interface with server prefixing
 type mcpToolImpl struct {
-	info    *schema.ToolInfo
-	mapping *toolMapping
+	info        *schema.ToolInfo
+	mapping     *toolMapping
+	inputSchema json.RawMessage     // input schema as reported by the server
+	annotations *mcp.ToolAnnotation // behavior hints from the server, or nil
+}
+
+// ServerStatus describes a configured MCP server and the state of its connection
+type ServerStatus struct {
+	Name       string    `json:"name"`
+	Transport  string    `json:"transport"`
+	Connected  bool      `json:"connected"`
+	Tools      []string  `json:"tools"` // prefixed tool names exposed to the model
+	LastError  string    `json:"last_error,omitempty"`
+	ErrorCount int       `json:"error_count,omitempty"`
+	LastUsed   time.Time `json:"last_used,omitempty"`
+}
+
+// ToolDetail describes a loaded tool together with its full input schema
+type ToolDetail struct {
+	Name         string          `json:"name"` // prefixed name exposed to the model
+	Server       string          `json:"server"`
+	OriginalName string          `json:"original_name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"input_schema,omitempty"`
+
+	// Annotations are the server's hints about the tool's behavior, such as
+	// whether it is read-only, or nil when it gave none
+	Annotations *mcp.ToolAnnotation `json:"annotations,omitempty"`
 }
 
 // NewMCPToolManager creates a new MCP tool manager
@@ -57,73 +95,68 @@ func (m *MCPToolManager) SetModel(model model.ToolCallingChatModel) {
 	m.model = model
 }
 
-// SetDebugLogger sets the debug logger
-func (m *MCPToolManager) SetDebugLogger(logger DebugLogger) {
-	m.debugLogger = logger
-	if m.connectionPool != nil {
-		m.connectionPool.SetDebugLogger(logger)
-	}
+// SetSummaryModel sets the model builtin servers use to summarize and extract
+// content, in place of the main model
+func (m *MCPToolManager) SetSummaryModel(model model.ToolCallingChatModel) {
+	m.summaryModel = model
 }
 
-// samplingHandler implements the MCP sampling handler interface
-type samplingHandler struct {
-	model model.ToolCallingChatModel
+// SetProviderConfig sets the agent's model config, which builtin servers that
+// create models of their own start from
+func (m *MCPToolManager) SetProviderConfig(config *models.ProviderConfig) {
+	m.providerConfig = config
 }
 
-// CreateMessage handles sampling requests from MCP servers
-func (h *samplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
-	if h.model == nil {
-		return nil, fmt.Errorf("no model available for sampling")
-	}
-
-	// Convert MCP messages to eino messages
-	var messages []*schema.Message
-
-	// Add system message if provided
-	if request.SystemPrompt != "" {
-		messages = append(messages, schema.SystemMessage(request.SystemPrompt))
-	}
+// SetSamplingModels sets the models sampling requests from MCP servers can be
+// served by, main model first. Without them, sampling uses the model from SetModel.
+func (m *MCPToolManager) SetSamplingModels(models []SamplingModel) {
+	m.samplingModels = models
+}
 
-	// Convert sampling messages
-	for _, msg := range request.Messages {
-		// Extract text content
-		var content string
-		if textContent, ok := msg.Content.(mcp.TextContent); ok {
-			content = textContent.Text
-		} else {
-			content = fmt.Sprintf("%v", msg.Content)
-		}
+// SetSamplingApprover sets the handler consulted before fulfilling each
+// sampling request. Without one, sampling requests are fulfilled.
+func (m *MCPToolManager) SetSamplingApprover(approve SamplingApprovalHandler) {
+	m.approveSampling = approve
+}
 
-		switch msg.Role {
-		case mcp.RoleUser:
-			messages = append(messages, schema.UserMessage(content))
-		case mcp.RoleAssistant:
-			messages = append(messages, schema.AssistantMessage(content, nil))
-		default:
-			messages = append(messages, schema.UserMessage(content)) // Default to user
+// samplingHandler returns the handler for sampling requests from serverName,
+// or nil when no model is available
+func (m *MCPToolManager) samplingHandler(serverName string) client.SamplingHandler {
+	samplingModels := m.samplingModels
+	if len(samplingModels) == 0 {
+		if m.model == nil {
+			return nil
 		}
+		samplingModels = []SamplingModel{{Name: "mcphost-model", Model: m.model}}
 	}
-
-	// Generate response using the model (no config options for now)
-	response, err := h.model.Generate(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("model generation failed: %w", err)
+	return &samplingHandler{
+		server:  serverName,
+		models:  samplingModels,
+		approve: m.approveSampling,
+		usage:   &m.samplingUsage,
 	}
+}
 
-	// Convert response back to MCP format
-	result := &mcp.CreateMessageResult{
-		Model:      "mcphost-model", // Generic model name
-		StopReason: "endTurn",
-	}
-	result.SamplingMessage = mcp.SamplingMessage{
-		Role: mcp.RoleAssistant,
-		Content: mcp.TextContent{
-			Type: "text",
-			Text: response.Content,
-		},
+// SamplingUsage returns the tokens and cost of sampling requests fulfilled so
+// far, by requesting server
+func (m *MCPToolManager) SamplingUsage() []SamplingUsage {
+	return m.samplingUsage.snapshot()
+}
+
+// builtinModel returns the model handed to builtin servers
+func (m *MCPToolManager) builtinModel() model.ToolCallingChatModel {
+	if m.summaryModel != nil {
+		return m.summaryModel
 	}
+	return m.model
+}
 
-	return result, nil
+// SetDebugLogger sets the debug logger
+func (m *MCPToolManager) SetDebugLogger(logger DebugLogger) {
+	m.debugLogger = logger
+	if m.connectionPool != nil {
+		m.connectionPool.SetDebugLogger(logger)
+	}
 }
 
 // LoadTools loads tools from MCP servers based on configuration
@@ -134,13 +167,18 @@ func (m *MCPToolManager) LoadTools(ctx context.Context, config *config.Config) e
 	if m.debugLogger == nil {
 		m.debugLogger = NewSimpleDebugLogger(config.Debug)
 	}
-	m.connectionPool = NewMCPConnectionPool(DefaultConnectionPoolConfig(), m.model, config.Debug)
+	m.connectionPool = NewMCPConnectionPool(DefaultConnectionPoolConfig(), m.builtinModel(), config.Debug)
 	m.connectionPool.SetDebugLogger(m.debugLogger)
+	m.connectionPool.SetProviderConfig(m.providerConfig)
+	m.connectionPool.SetSamplingHandlers(m.samplingHandler)
+	m.connectionPool.SetToolsChangedHandler(m.refreshServerTools)
+	m.loadErrors = make(map[string]error)
 
 	var loadErrors []string
 
 	for serverName, serverConfig := range config.MCPServers {
 		if err := m.loadServerTools(ctx, serverName, serverConfig); err != nil {
+			m.loadErrors[serverName] = err
 			loadErrors = append(loadErrors, fmt.Sprintf("server %s: %v", serverName, err))
 			fmt.Printf("Warning: Failed to load MCP server '%s': %v\n", serverName, err)
 			continue
@@ -166,12 +204,71 @@ func (m *MCPToolManager) loadServerTools(ctx context.Context, serverName string,
 		return fmt.Errorf("failed to get connection from pool: %v", err)
 	}
 
+	serverTools, err := m.listServerTools(ctx, conn, serverName, serverConfig)
+	if err != nil {
+		return err
+	}
+	m.setServerTools(serverName, serverTools)
+	return nil
+}
+
+// refreshServerTools reloads the tools of a server that announced its tool
+// list changed, so tools it registers mid-session become usable
+func (m *MCPToolManager) refreshServerTools(serverName string) {
+	serverConfig, ok := m.config.MCPServers[serverName]
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := m.connectionPool.GetConnection(ctx, serverName, serverConfig)
+	if err == nil {
+		var serverTools []tool.BaseTool
+		if serverTools, err = m.listServerTools(ctx, conn, serverName, serverConfig); err == nil {
+			m.setServerTools(serverName, serverTools)
+			if m.debugLogger != nil && m.debugLogger.IsDebugEnabled() {
+				m.debugLogger.LogDebug(fmt.Sprintf("[TOOLS] Reloaded %d tools from %s after tools/list_changed", len(serverTools), serverName))
+			}
+			return
+		}
+	}
+	if m.debugLogger != nil && m.debugLogger.IsDebugEnabled() {
+		m.debugLogger.LogDebug(fmt.Sprintf("[TOOLS] Failed to reload tools from %s: %v", serverName, err))
+	}
+}
+
+// setServerTools replaces the tools of one server
+func (m *MCPToolManager) setServerTools(serverName string, serverTools []tool.BaseTool) {
+	m.toolsMu.Lock()
+	defer m.toolsMu.Unlock()
+
+	kept := make([]tool.BaseTool, 0, len(m.tools)+len(serverTools))
+	for _, t := range m.tools {
+		if impl, ok := t.(*mcpToolImpl); ok && impl.mapping.serverName == serverName {
+			delete(m.toolMap, impl.info.Name)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	for _, t := range serverTools {
+		impl := t.(*mcpToolImpl)
+		m.toolMap[impl.info.Name] = impl.mapping
+		kept = append(kept, t)
+	}
+	m.tools = kept
+}
+
+// listServerTools lists a server's tools and converts them to eino tools,
+// applying allowedTools/excludedTools
+func (m *MCPToolManager) listServerTools(ctx context.Context, conn *MCPConnection, serverName string, serverConfig config.MCPServerConfig) ([]tool.BaseTool, error) {
 	// Get tools from this server
 	listResults, err := conn.client.ListTools(ctx, mcp.ListToolsRequest{})
 	if err != nil {
 		// Handle connection error
 		m.connectionPool.HandleConnectionError(serverName, err)
-		return fmt.Errorf("failed to list tools: %v", err)
+		return nil, fmt.Errorf("failed to list tools: %v", err)
 	}
 
 	// Create name set for allowed tools
@@ -184,6 +281,7 @@ func (m *MCPToolManager) loadServerTools(ctx context.Context, serverName string,
 	}
 
 	// Convert MCP tools to eino tools with prefixed names
+	var serverTools []tool.BaseTool
 	for _, mcpTool := range listResults.Tools {
 		// Filter tools based on allowedTools/excludedTools
 		if len(serverConfig.AllowedTools) > 0 {
@@ -200,12 +298,12 @@ func (m *MCPToolManager) loadServerTools(ctx context.Context, serverName string,
 		// Convert schema
 		marshaledInputSchema, err := sonic.Marshal(mcpTool.InputSchema)
 		if err != nil {
-			return fmt.Errorf("conv mcp tool input schema fail(marshal): %w, tool name: %s", err, mcpTool.Name)
+			return nil, fmt.Errorf("conv mcp tool input schema fail(marshal): %w, tool name: %s", err, mcpTool.Name)
 		}
 		inputSchema := &openapi3.Schema{}
 		err = sonic.Unmarshal(marshaledInputSchema, inputSchema)
 		if err != nil {
-			return fmt.Errorf("conv mcp tool input schema fail(unmarshal): %w, tool name: %s", err, mcpTool.Name)
+			return nil, fmt.Errorf("conv mcp tool input schema fail(unmarshal): %w, tool name: %s", err, mcpTool.Name)
 		}
 
 		// Fix for issue #89: Ensure object schemas have a properties field
@@ -225,7 +323,6 @@ func (m *MCPToolManager) loadServerTools(ctx context.Context, serverName string,
 			serverConfig: serverConfig,
 			manager:      m,
 		}
-		m.toolMap[prefixedName] = mapping
 
 		// Create eino tool
 		einoTool := &mcpToolImpl{
@@ -234,13 +331,18 @@ func (m *MCPToolManager) loadServerTools(ctx context.Context, serverName string,
 				Desc:        mcpTool.Description,
 				ParamsOneOf: schema.NewParamsOneOfByOpenAPIV3(inputSchema),
 			},
-			mapping: mapping,
+			mapping:     mapping,
+			inputSchema: marshaledInputSchema,
+		}
+		if mcpTool.Annotations != (mcp.ToolAnnotation{}) {
+			annotations := mcpTool.Annotations
+			einoTool.annotations = &annotations
 		}
 
-		m.tools = append(m.tools, einoTool)
+		serverTools = append(serverTools, einoTool)
 	}
 
-	return nil
+	return serverTools, nil
 }
 
 // Info returns the tool information
@@ -269,6 +371,12 @@ func (t *mcpToolImpl) InvokableRun(ctx context.Context, argumentsInJSON string,
 		return "", fmt.Errorf("failed to get healthy connection from pool: %w", err)
 	}
 
+	// The server can tie the call to the turn that made it
+	var meta *mcp.Meta
+	if id := turn.ID(ctx); id != "" {
+		meta = &mcp.Meta{AdditionalFields: map[string]any{turn.MetaKey: id}}
+	}
+
 	result, err := conn.client.CallTool(ctx, mcp.CallToolRequest{
 		Request: mcp.Request{
 			Method: "tools/call",
@@ -280,6 +388,7 @@ func (t *mcpToolImpl) InvokableRun(ctx context.Context, argumentsInJSON string,
 		}{
 			Name:      t.mapping.originalName, // Use original name, not prefixed
 			Arguments: arguments,
+			Meta:      meta,
 		},
 	})
 	if err != nil {
@@ -302,7 +411,9 @@ func (t *mcpToolImpl) InvokableRun(ctx context.Context, argumentsInJSON string,
 
 // GetTools returns all loaded tools
 func (m *MCPToolManager) GetTools() []tool.BaseTool {
-	return m.tools
+	m.toolsMu.RLock()
+	defer m.toolsMu.RUnlock()
+	return append([]tool.BaseTool(nil), m.tools...)
 }
 
 // GetLoadedServerNames returns the names of successfully loaded MCP servers
@@ -314,6 +425,84 @@ func (m *MCPToolManager) GetLoadedServerNames() []string {
 	return names
 }
 
+// GetServerStatuses returns the status of every configured MCP server, including
+// servers that failed to load
+func (m *MCPToolManager) GetServerStatuses() []ServerStatus {
+	if m.config == nil {
+		return nil
+	}
+
+	toolsByServer := make(map[string][]string)
+	m.toolsMu.RLock()
+	for name, mapping := range m.toolMap {
+		toolsByServer[mapping.serverName] = append(toolsByServer[mapping.serverName], name)
+	}
+	m.toolsMu.RUnlock()
+
+	names := make([]string, 0, len(m.config.MCPServers))
+	for name := range m.config.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := make([]ServerStatus, 0, len(names))
+	for _, name := range names {
+		serverConfig := m.config.MCPServers[name]
+		serverTools := toolsByServer[name]
+		sort.Strings(serverTools)
+
+		status := ServerStatus{
+			Name:      name,
+			Transport: serverConfig.GetTransportType(),
+			Tools:     serverTools,
+		}
+		if err, failed := m.loadErrors[name]; failed {
+			status.LastError = err.Error()
+		}
+		if state, ok := m.connectionPool.connectionState(name); ok {
+			status.Connected = state.isHealthy
+			status.ErrorCount = state.errorCount
+			status.LastUsed = state.lastUsed
+			if state.lastError != nil {
+				status.LastError = state.lastError.Error()
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// GetToolDetails returns every loaded tool with its full input schema
+func (m *MCPToolManager) GetToolDetails() []ToolDetail {
+	tools := m.GetTools()
+	details := make([]ToolDetail, 0, len(tools))
+	for _, t := range tools {
+		impl, ok := t.(*mcpToolImpl)
+		if !ok {
+			continue
+		}
+		details = append(details, ToolDetail{
+			Name:         impl.info.Name,
+			Server:       impl.mapping.serverName,
+			OriginalName: impl.mapping.originalName,
+			Description:  impl.info.Desc,
+			InputSchema:  impl.inputSchema,
+			Annotations:  impl.annotations,
+		})
+	}
+	return details
+}
+
+// GetClient returns the live MCP client for a loaded server, for callers that
+// need more than tools (resources, prompts). The client belongs to the manager
+// and is closed by Close.
+func (m *MCPToolManager) GetClient(serverName string) (client.MCPClient, *mcp.InitializeResult, bool) {
+	if m.connectionPool == nil {
+		return nil, nil, false
+	}
+	return m.connectionPool.GetConnectionClient(serverName)
+}
+
 // Close closes all MCP clients
 func (m *MCPToolManager) Close() error {
 	return m.connectionPool.Close()
@@ -455,6 +644,10 @@ func (m *MCPToolManager) createMCPClient(ctx context.Context, serverName string,
 		// Builtin server
 		return m.createBuiltinClient(ctx, serverName, serverConfig)
 
+	case "openapi":
+		// REST API described by an OpenAPI spec, served in-process
+		return createOpenAPIClient(ctx, serverName, serverConfig)
+
 	default:
 		return nil, fmt.Errorf("unsupported transport type '%s' for server %s", transportType, serverName)
 	}
@@ -483,13 +676,24 @@ func (m *MCPToolManager) initializeClient(ctx context.Context, client client.MCP
 // createBuiltinClient creates an in-process MCP client for builtin servers
 func (m *MCPToolManager) createBuiltinClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
 	registry := builtin.NewRegistry()
+	registry.SetProviderConfig(m.providerConfig)
 
 	// Create the builtin server, passing the model for servers that need it
-	builtinServer, err := registry.CreateServer(serverConfig.Name, serverConfig.Options, m.model)
+	builtinServer, err := registry.CreateServer(serverConfig.Name, serverConfig.Options, m.builtinModel())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create builtin server: %v", err)
 	}
 
+	// Plugins are separate executables that speak MCP over stdio
+	if command, env := builtinServer.Command(); command != "" {
+		stdioTransport := transport.NewStdio(command, env)
+		pluginClient := client.NewClient(stdioTransport)
+		if err := stdioTransport.Start(ctx); err != nil {
+			return nil, fmt.Errorf("failed to start plugin %s: %v", serverConfig.Name, err)
+		}
+		return pluginClient, nil
+	}
+
 	// Create an in-process client that wraps the builtin server
 	inProcessClient, err := client.NewInProcessClient(builtinServer.GetServer())
 	if err != nil {
@@ -499,6 +703,34 @@ func (m *MCPToolManager) createBuiltinClient(ctx context.Context, serverName str
 	return inProcessClient, nil
 }
 
+// createOpenAPIClient creates an in-process MCP client whose tools call the
+// operations of an OpenAPI spec
+func createOpenAPIClient(ctx context.Context, serverName string, serverConfig config.MCPServerConfig) (client.MCPClient, error) {
+	headers := make(map[string]string)
+	for _, header := range serverConfig.Headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	apiServer, err := openapi.NewServer(ctx, serverName, openapi.Config{
+		Spec:       serverConfig.Spec,
+		BaseURL:    serverConfig.URL,
+		Operations: serverConfig.Operations,
+		Headers:    headers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create openapi server: %v", err)
+	}
+
+	inProcessClient, err := client.NewInProcessClient(apiServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-process client: %v", err)
+	}
+	return inProcessClient, nil
+}
+
 // debugLogConnectionInfo logs detailed connection information for debugging
 func (m *MCPToolManager) debugLogConnectionInfo(serverName string, serverConfig config.MCPServerConfig) {
 	if m.debugLogger == nil || !m.debugLogger.IsDebugEnabled() {
@@ -521,5 +753,10 @@ func (m *MCPToolManager) debugLogConnectionInfo(serverName string, serverConfig
 		if len(serverConfig.Headers) > 0 {
 			m.debugLogger.LogDebug(fmt.Sprintf("[DEBUG] Headers: %v", serverConfig.Headers))
 		}
+	case "openapi":
+		m.debugLogger.LogDebug(fmt.Sprintf("[DEBUG] Spec: %s", serverConfig.Spec))
+		if len(serverConfig.Operations) > 0 {
+			m.debugLogger.LogDebug(fmt.Sprintf("[DEBUG] Operations: %v", serverConfig.Operations))
+		}
 	}
 }
@@ -168,3 +168,95 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestMCPToolManager_GetServerStatuses(t *testing.T) {
+	manager := NewMCPToolManager()
+
+	cfg := &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{
+			"todo": {
+				Type: "builtin",
+				Name: "todo",
+			},
+			"broken": {
+				Command: []string{"non-existent-command"},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := manager.LoadTools(ctx, cfg); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	defer manager.Close()
+
+	statuses := manager.GetServerStatuses()
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 server statuses, got %d", len(statuses))
+	}
+
+	// Statuses are sorted by name
+	broken, todo := statuses[0], statuses[1]
+	if broken.Name != "broken" || broken.Connected || broken.LastError == "" {
+		t.Errorf("expected broken server to be disconnected with an error, got %+v", broken)
+	}
+	if todo.Name != "todo" || !todo.Connected || todo.Transport != "inprocess" {
+		t.Errorf("expected todo server to be connected in-process, got %+v", todo)
+	}
+	if len(todo.Tools) == 0 {
+		t.Error("expected todo server to report its tools")
+	}
+
+	details := manager.GetToolDetails()
+	if len(details) != len(todo.Tools) {
+		t.Fatalf("expected %d tool details, got %d", len(todo.Tools), len(details))
+	}
+	for _, detail := range details {
+		if detail.Server != "todo" || len(detail.InputSchema) == 0 {
+			t.Errorf("unexpected tool detail: %+v", detail)
+		}
+	}
+}
+
+func TestMCPToolManager_RefreshServerTools(t *testing.T) {
+	manager := NewMCPToolManager()
+
+	cfg := &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{
+			"todo": {
+				Type: "builtin",
+				Name: "todo",
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := manager.LoadTools(ctx, cfg); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	defer manager.Close()
+
+	loaded := len(manager.GetTools())
+	if loaded == 0 {
+		t.Fatal("expected todo tools to load")
+	}
+
+	// Drop the server's tools, as if it had started with none registered
+	manager.setServerTools("todo", nil)
+	if got := len(manager.GetTools()); got != 0 {
+		t.Fatalf("expected no tools after clearing, got %d", got)
+	}
+
+	// A tools/list_changed notification reloads them from the live connection
+	manager.refreshServerTools("todo")
+	if got := len(manager.GetTools()); got != loaded {
+		t.Errorf("expected %d tools after refresh, got %d", loaded, got)
+	}
+	if statuses := manager.GetServerStatuses(); len(statuses) != 1 || len(statuses[0].Tools) != loaded {
+		t.Errorf("server status does not reflect refreshed tools: %+v", statuses)
+	}
+}
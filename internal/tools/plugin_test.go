@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/osi4iot/mcphost/internal/builtin"
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// testPluginEnv makes the test binary act as a plugin when it is run by mcphost
+const testPluginEnv = "MCPHOST_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		runTestPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin is a plugin with one tool that greets using its options
+func runTestPlugin() {
+	if len(os.Args) > 1 && os.Args[1] == builtin.PluginInfoFlag {
+		fmt.Println(`{"protocol": 1, "name": "greeter", "options": {"properties": {"greeting": {"type": "string"}}}}`)
+		return
+	}
+
+	var options struct {
+		Greeting string `json:"greeting"`
+	}
+	json.Unmarshal([]byte(os.Getenv(builtin.PluginOptionsEnv)), &options)
+
+	s := server.NewMCPServer("greeter", "1.0.0")
+	s.AddTool(mcp.NewTool("greet", mcp.WithString("name", mcp.Required())),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(options.Greeting + ", " + req.GetString("name", "") + "!"), nil
+		})
+	server.ServeStdio(s)
+}
+
+func TestPluginServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are symlinks to the test binary")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(self, filepath.Join(dir, "greeter")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(builtin.PluginDirEnv, dir)
+	t.Setenv(testPluginEnv, "1")
+
+	manager := NewMCPToolManager()
+	cfg := &config.Config{
+		MCPServers: map[string]config.MCPServerConfig{
+			"hello": {
+				Type:    "builtin",
+				Name:    "greeter",
+				Options: map[string]any{"greeting": "Howdy"},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := manager.LoadTools(ctx, cfg); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	defer manager.Close()
+
+	tools := manager.GetTools()
+	if len(tools) != 1 {
+		t.Fatalf("expected the plugin's tool, got %d tools (%+v)", len(tools), manager.GetServerStatuses())
+	}
+	info, _ := tools[0].Info(ctx)
+	if info.Name != "hello__greet" {
+		t.Errorf("unexpected tool name %q", info.Name)
+	}
+	result, err := tools[0].(tool.InvokableTool).InvokableRun(ctx, `{"name": "Ada"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "Howdy, Ada!") {
+		t.Errorf("unexpected result %s", result)
+	}
+}
@@ -0,0 +1,296 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/osi4iot/mcphost/internal/config"
+	"github.com/osi4iot/mcphost/internal/models"
+)
+
+// SamplingModel is a configured model that MCP sampling requests can be served by
+type SamplingModel struct {
+	Name  string // provider:model, reported back to the requesting server
+	Model model.ToolCallingChatModel
+}
+
+// SamplingApprovalHandler decides whether a sampling request from an MCP
+// server may use the user's model. preview is a short excerpt of the request.
+// When allow is false, reason is returned to the server as the error.
+type SamplingApprovalHandler func(serverName, preview string) (allow bool, reason string)
+
+// NewSamplingApprover applies the sampling approval policy from cfg to each
+// request. Requests from servers whose policy is ask go to ask; without an ask
+// handler (non-interactive runs) they are denied.
+func NewSamplingApprover(cfg *config.Config, ask SamplingApprovalHandler) SamplingApprovalHandler {
+	return func(serverName, preview string) (bool, string) {
+		switch cfg.SamplingPolicy(serverName) {
+		case config.SamplingAllow:
+			return true, ""
+		case config.SamplingDeny:
+			return false, fmt.Sprintf("sampling is disabled for server %s", serverName)
+		}
+		if ask == nil {
+			return false, fmt.Sprintf("no one to approve it; set sampling-approval or samplingApproval for %s to allow", serverName)
+		}
+		return ask(serverName, preview)
+	}
+}
+
+// SamplingUsage is the token usage of the sampling requests from one server
+type SamplingUsage struct {
+	Server   string
+	Requests int
+	Tokens   models.TokenCounts
+	Cost     float64 // priced with each request's model; 0 when unknown
+}
+
+// samplingUsageLedger accumulates sampling usage by server
+type samplingUsageLedger struct {
+	mu       sync.Mutex
+	byServer map[string]*SamplingUsage
+}
+
+// record adds one fulfilled request from server
+func (l *samplingUsageLedger) record(server, modelString string, counts models.TokenCounts) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.byServer == nil {
+		l.byServer = make(map[string]*SamplingUsage)
+	}
+	usage, ok := l.byServer[server]
+	if !ok {
+		usage = &SamplingUsage{Server: server}
+		l.byServer[server] = usage
+	}
+	usage.Requests++
+	usage.Tokens.Add(counts)
+	if info := samplingModelInfo(modelString); info != nil {
+		usage.Cost += info.Cost.Price(counts).Total()
+	}
+}
+
+// snapshot returns a copy of the usage, sorted by server name
+func (l *samplingUsageLedger) snapshot() []SamplingUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]SamplingUsage, 0, len(l.byServer))
+	for _, usage := range l.byServer {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Server < result[j].Server })
+	return result
+}
+
+// samplingHandler implements the MCP sampling handler interface for one server
+type samplingHandler struct {
+	server  string
+	models  []SamplingModel // the first entry is the main model
+	approve SamplingApprovalHandler
+	usage   *samplingUsageLedger
+}
+
+// CreateMessage handles sampling requests from MCP servers
+func (h *samplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	if len(h.models) == 0 {
+		return nil, fmt.Errorf("no model available for sampling")
+	}
+
+	// Ask before spending the user's tokens on the server's behalf
+	if h.approve != nil {
+		if allow, reason := h.approve(h.server, samplingPreview(request)); !allow {
+			if reason == "" {
+				reason = "denied by the user"
+			}
+			return nil, fmt.Errorf("sampling request denied: %s", reason)
+		}
+	}
+	selected := selectSamplingModel(request.ModelPreferences, h.models)
+
+	// Convert MCP messages to eino messages
+	var messages []*schema.Message
+
+	// Add system message if provided
+	if request.SystemPrompt != "" {
+		messages = append(messages, schema.SystemMessage(request.SystemPrompt))
+	}
+
+	// Convert sampling messages
+	for _, msg := range request.Messages {
+		content := samplingMessageText(msg.Content)
+
+		switch msg.Role {
+		case mcp.RoleUser:
+			messages = append(messages, schema.UserMessage(content))
+		case mcp.RoleAssistant:
+			messages = append(messages, schema.AssistantMessage(content, nil))
+		default:
+			messages = append(messages, schema.UserMessage(content)) // Default to user
+		}
+	}
+
+	// Generate response using the selected model and the request's limits
+	response, err := selected.Model.Generate(ctx, messages, samplingOptions(request.CreateMessageParams)...)
+	if err != nil {
+		return nil, fmt.Errorf("model generation failed: %w", err)
+	}
+
+	if h.usage != nil {
+		counts, _ := models.TokenCountsFromMessage(response)
+		h.usage.record(h.server, selected.Name, counts)
+	}
+
+	// Convert response back to MCP format
+	result := &mcp.CreateMessageResult{
+		Model:      selected.Name,
+		StopReason: samplingStopReason(response),
+	}
+	result.SamplingMessage = mcp.SamplingMessage{
+		Role: mcp.RoleAssistant,
+		Content: mcp.TextContent{
+			Type: "text",
+			Text: response.Content,
+		},
+	}
+
+	return result, nil
+}
+
+// samplingMessageText extracts the text of a sampling message. Content decoded
+// from the wire arrives as a map rather than a typed mcp.Content.
+func samplingMessageText(content any) string {
+	if contentMap, ok := content.(map[string]any); ok {
+		if parsed, err := mcp.ParseContent(contentMap); err == nil {
+			content = parsed
+		}
+	}
+	if textContent, ok := content.(mcp.TextContent); ok {
+		return textContent.Text
+	}
+	return fmt.Sprintf("%v", content)
+}
+
+// samplingPreviewLength caps the request excerpt shown for approval
+const samplingPreviewLength = 200
+
+// samplingPreview returns the last message of a sampling request, shortened
+// for an approval prompt
+func samplingPreview(request mcp.CreateMessageRequest) string {
+	if len(request.Messages) == 0 {
+		return request.SystemPrompt
+	}
+	text := strings.Join(strings.Fields(samplingMessageText(request.Messages[len(request.Messages)-1].Content)), " ")
+	if runes := []rune(text); len(runes) > samplingPreviewLength {
+		text = string(runes[:samplingPreviewLength]) + "..."
+	}
+	return text
+}
+
+// samplingOptions maps the generation parameters of a sampling request onto
+// model options. A zero temperature is indistinguishable from an unset one on
+// the wire, so it keeps the model's configured temperature.
+func samplingOptions(params mcp.CreateMessageParams) []model.Option {
+	var opts []model.Option
+	if params.MaxTokens > 0 {
+		opts = append(opts, model.WithMaxTokens(params.MaxTokens))
+	}
+	if params.Temperature > 0 {
+		opts = append(opts, model.WithTemperature(float32(params.Temperature)))
+	}
+	if len(params.StopSequences) > 0 {
+		opts = append(opts, model.WithStop(params.StopSequences))
+	}
+	return opts
+}
+
+// samplingStopReason translates the provider's finish reason into the MCP
+// stop reasons
+func samplingStopReason(response *schema.Message) string {
+	if response.ResponseMeta == nil {
+		return "endTurn"
+	}
+	switch response.ResponseMeta.FinishReason {
+	case "max_tokens", "length", "MAX_TOKENS":
+		return "maxTokens"
+	case "stop_sequence":
+		return "stopSequence"
+	default:
+		return "endTurn"
+	}
+}
+
+// selectSamplingModel picks the model for a sampling request. Name hints are
+// matched first, in order, as substrings of the model names. Otherwise the
+// cost, speed and intelligence priorities are weighed against each model's
+// price from the models registry: cheaper models are taken to be faster and
+// pricier ones more capable, and local or unknown models count as free. Ties,
+// and requests without preferences, go to the main model.
+func selectSamplingModel(prefs *mcp.ModelPreferences, candidates []SamplingModel) SamplingModel {
+	if prefs == nil || len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	for _, hint := range prefs.Hints {
+		if hint.Name == "" {
+			continue
+		}
+		for _, candidate := range candidates {
+			if strings.Contains(strings.ToLower(candidate.Name), strings.ToLower(hint.Name)) {
+				return candidate
+			}
+		}
+	}
+
+	prices := make([]float64, len(candidates))
+	maxPrice := 0.0
+	for i, candidate := range candidates {
+		prices[i] = samplingModelPrice(candidate.Name)
+		maxPrice = max(maxPrice, prices[i])
+	}
+	if maxPrice == 0 {
+		return candidates[0]
+	}
+
+	best, bestScore := 0, -1.0
+	for i := range candidates {
+		relative := prices[i] / maxPrice
+		score := prefs.CostPriority*(1-relative) +
+			prefs.SpeedPriority*(1-relative) +
+			prefs.IntelligencePriority*relative
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return candidates[best]
+}
+
+// samplingModelPrice returns the input plus output price per million tokens
+// of a provider:model string, or 0 when the registry does not know it
+func samplingModelPrice(modelString string) float64 {
+	info := samplingModelInfo(modelString)
+	if info == nil {
+		return 0
+	}
+	return info.Cost.Input + info.Cost.Output
+}
+
+// samplingModelInfo looks up a provider:model string in the models registry
+func samplingModelInfo(modelString string) *models.ModelInfo {
+	provider, modelName, ok := strings.Cut(modelString, ":")
+	if !ok {
+		return nil
+	}
+	info, err := models.GetGlobalRegistry().ValidateModel(provider, modelName)
+	if err != nil {
+		return nil
+	}
+	return info
+}
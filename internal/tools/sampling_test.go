@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/osi4iot/mcphost/internal/config"
+)
+
+// samplingFakeModel records the messages and options of the last Generate call
+type samplingFakeModel struct {
+	got     []*schema.Message
+	options *model.Options
+	reply   *schema.Message
+}
+
+func (f *samplingFakeModel) Generate(_ context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	f.got = input
+	f.options = model.GetCommonOptions(nil, opts...)
+	return f.reply, nil
+}
+
+func (f *samplingFakeModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{f.reply}), nil
+}
+
+func (f *samplingFakeModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return f, nil
+}
+
+func TestSamplingHandlerCreateMessage(t *testing.T) {
+	fake := &samplingFakeModel{reply: &schema.Message{
+		Role:         schema.Assistant,
+		Content:      "Paris",
+		ResponseMeta: &schema.ResponseMeta{FinishReason: "max_tokens"},
+	}}
+	handler := &samplingHandler{models: []SamplingModel{{Name: "anthropic:claude-sonnet-4-20250514", Model: fake}}}
+
+	request := mcp.CreateMessageRequest{}
+	request.SystemPrompt = "Answer briefly."
+	request.MaxTokens = 50
+	request.Temperature = 0.2
+	request.StopSequences = []string{"\n\n"}
+	request.Messages = []mcp.SamplingMessage{
+		// Content decoded from JSON arrives as a map
+		{Role: mcp.RoleUser, Content: map[string]any{"type": "text", "text": "Capital of France?"}},
+	}
+
+	result, err := handler.CreateMessage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if len(fake.got) != 2 || fake.got[1].Content != "Capital of France?" {
+		t.Errorf("unexpected messages sent to the model: %v", fake.got)
+	}
+	if fake.options.MaxTokens == nil || *fake.options.MaxTokens != 50 {
+		t.Errorf("max tokens not passed: %v", fake.options.MaxTokens)
+	}
+	if fake.options.Temperature == nil || *fake.options.Temperature != 0.2 {
+		t.Errorf("temperature not passed: %v", fake.options.Temperature)
+	}
+	if len(fake.options.Stop) != 1 {
+		t.Errorf("stop sequences not passed: %v", fake.options.Stop)
+	}
+	if result.Model != "anthropic:claude-sonnet-4-20250514" || result.StopReason != "maxTokens" {
+		t.Errorf("unexpected result model %q, stop reason %q", result.Model, result.StopReason)
+	}
+}
+
+func TestSamplingHandlerApproval(t *testing.T) {
+	fake := &samplingFakeModel{reply: &schema.Message{
+		Role:    schema.Assistant,
+		Content: "ok",
+		ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:     100,
+			CompletionTokens: 20,
+		}},
+	}}
+
+	var askedServer, askedPreview string
+	var usage samplingUsageLedger
+	newHandler := func(server string, allow bool) *samplingHandler {
+		return &samplingHandler{
+			server: server,
+			models: []SamplingModel{{Name: "anthropic:claude-sonnet-4-20250514", Model: fake}},
+			approve: func(serverName, preview string) (bool, string) {
+				askedServer, askedPreview = serverName, preview
+				return allow, ""
+			},
+			usage: &usage,
+		}
+	}
+
+	request := mcp.CreateMessageRequest{}
+	request.Messages = []mcp.SamplingMessage{
+		{Role: mcp.RoleUser, Content: mcp.NewTextContent("Summarize   this\n" + strings.Repeat("x", 300))},
+	}
+
+	_, err := newHandler("notes", false).CreateMessage(context.Background(), request)
+	if err == nil || !strings.Contains(err.Error(), "denied by the user") {
+		t.Fatalf("expected denial, got %v", err)
+	}
+	if fake.got != nil {
+		t.Error("denied request reached the model")
+	}
+	if askedServer != "notes" || !strings.HasPrefix(askedPreview, "Summarize this x") || len(askedPreview) != samplingPreviewLength+3 {
+		t.Errorf("unexpected approval prompt for %q: %q", askedServer, askedPreview)
+	}
+
+	for _, server := range []string{"search", "notes", "search"} {
+		if _, err := newHandler(server, true).CreateMessage(context.Background(), request); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	got := usage.snapshot()
+	if len(got) != 2 || got[0].Server != "notes" || got[1].Server != "search" {
+		t.Fatalf("unexpected usage: %+v", got)
+	}
+	if got[1].Requests != 2 || got[1].Tokens.InputTokens != 200 || got[1].Tokens.OutputTokens != 40 {
+		t.Errorf("unexpected usage for search: %+v", got[1])
+	}
+	if got[0].Requests != 1 || got[0].Cost <= 0 {
+		t.Errorf("unexpected usage for notes: %+v", got[0])
+	}
+}
+
+func TestNewSamplingApprover(t *testing.T) {
+	cfg := &config.Config{
+		SamplingApproval: config.SamplingDeny,
+		MCPServers: map[string]config.MCPServerConfig{
+			"trusted": {SamplingApproval: config.SamplingAllow},
+			"asking":  {SamplingApproval: config.SamplingAsk},
+		},
+	}
+	asked := 0
+	ask := func(string, string) (bool, string) {
+		asked++
+		return true, ""
+	}
+
+	if allow, _ := NewSamplingApprover(cfg, ask)("trusted", ""); !allow {
+		t.Error("allow policy denied the request")
+	}
+	if allow, _ := NewSamplingApprover(cfg, ask)("other", ""); allow {
+		t.Error("deny policy allowed the request")
+	}
+	if allow, _ := NewSamplingApprover(cfg, ask)("asking", ""); !allow || asked != 1 {
+		t.Errorf("ask policy did not ask: allow=%v asked=%d", allow, asked)
+	}
+	if allow, reason := NewSamplingApprover(cfg, nil)("asking", ""); allow || !strings.Contains(reason, "sampling-approval") {
+		t.Errorf("ask policy without a prompt should deny with a hint, got %v %q", allow, reason)
+	}
+}
+
+func TestSelectSamplingModel(t *testing.T) {
+	candidates := []SamplingModel{
+		{Name: "anthropic:claude-sonnet-4-20250514"},
+		{Name: "anthropic:claude-3-5-haiku-20241022"},
+		{Name: "ollama:qwen2.5:3b"},
+	}
+
+	tests := []struct {
+		name  string
+		prefs *mcp.ModelPreferences
+		want  string
+	}{
+		{"no preferences", nil, "anthropic:claude-sonnet-4-20250514"},
+		{
+			"first matching hint wins",
+			&mcp.ModelPreferences{Hints: []mcp.ModelHint{{Name: "gpt-4o"}, {Name: "Haiku"}, {Name: "qwen"}}},
+			"anthropic:claude-3-5-haiku-20241022",
+		},
+		{"cost priority", &mcp.ModelPreferences{CostPriority: 1}, "ollama:qwen2.5:3b"},
+		{"intelligence priority", &mcp.ModelPreferences{IntelligencePriority: 1}, "anthropic:claude-sonnet-4-20250514"},
+		{
+			"hints override priorities",
+			&mcp.ModelPreferences{Hints: []mcp.ModelHint{{Name: "sonnet"}}, CostPriority: 1},
+			"anthropic:claude-sonnet-4-20250514",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectSamplingModel(tt.prefs, candidates); got.Name != tt.want {
+				t.Errorf("selected %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
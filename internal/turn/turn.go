@@ -0,0 +1,70 @@
+// Package turn identifies turns, a user prompt and the run that answers it,
+// so a turn's hook payloads, provider requests, tool calls, logs and session
+// messages can be correlated
+package turn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Header is the HTTP header provider requests carry the turn ID in
+const Header = "X-MCPHost-Turn-ID"
+
+// MetaKey is the _meta field of MCP tool calls holding the turn ID
+const MetaKey = "mcphost/turnId"
+
+// extraKey is the schema.Message.Extra key holding a message's turn ID
+const extraKey = "mcphost_turn_id"
+
+type contextKey struct{}
+
+// NewID returns a new random turn ID
+func NewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "turn_" + hex.EncodeToString(b)
+}
+
+// WithID returns a context carrying the turn ID id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the turn ID carried by ctx, or "" outside a turn
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Ensure returns ctx with a turn ID, adding a new one when it has none
+func Ensure(ctx context.Context) context.Context {
+	if ID(ctx) != "" {
+		return ctx
+	}
+	return WithID(ctx, NewID())
+}
+
+// Mark records that msg belongs to the turn id. Messages already marked keep
+// their turn.
+func Mark(msg *schema.Message, id string) {
+	if msg == nil || id == "" || Of(msg) != "" {
+		return
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any)
+	}
+	msg.Extra[extraKey] = id
+}
+
+// Of returns the turn ID msg was marked with, or ""
+func Of(msg *schema.Message) string {
+	if msg == nil || msg.Extra == nil {
+		return ""
+	}
+	id, _ := msg.Extra[extraKey].(string)
+	return id
+}
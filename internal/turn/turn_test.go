@@ -0,0 +1,41 @@
+package turn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if id := ID(ctx); id != "" {
+		t.Errorf("expected no turn ID outside a turn, got %q", id)
+	}
+
+	ctx = Ensure(ctx)
+	id := ID(ctx)
+	if !strings.HasPrefix(id, "turn_") || len(id) != len("turn_")+16 {
+		t.Fatalf("unexpected turn ID %q", id)
+	}
+	if got := ID(Ensure(ctx)); got != id {
+		t.Errorf("expected Ensure to keep turn ID %q, got %q", id, got)
+	}
+	if NewID() == id {
+		t.Error("expected new turn IDs to differ")
+	}
+}
+
+func TestMark(t *testing.T) {
+	msg := schema.UserMessage("hello")
+	if Of(msg) != "" {
+		t.Error("expected an unmarked message to have no turn")
+	}
+	Mark(msg, "turn_a")
+	Mark(msg, "turn_b")
+	if got := Of(msg); got != "turn_a" {
+		t.Errorf("expected the first turn to stick, got %q", got)
+	}
+	Mark(nil, "turn_a")
+}
@@ -5,11 +5,13 @@ import (
 	"io"
 	"os"
 	"strings"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cloudwego/eino/schema"
+	"github.com/osi4iot/mcphost/internal/clock"
+	"github.com/osi4iot/mcphost/internal/i18n"
+	"github.com/osi4iot/mcphost/internal/models"
 	"golang.org/x/term"
 )
 
@@ -23,6 +25,7 @@ type CLI struct {
 	compactRenderer  *CompactRenderer // Add compact renderer
 	messageContainer *MessageContainer
 	usageTracker     *UsageTracker
+	samplingUsage    func() []SamplingUsage
 	width            int
 	height           int
 	compactMode      bool   // Add compact mode flag
@@ -30,6 +33,7 @@ type CLI struct {
 	modelName        string // Store current model name
 	lastStreamHeight int    // track how far back we need to move the cursor to overwrite streaming messages
 	usageDisplayed   bool   // track if usage info was displayed after last assistant message
+	lastReasoning    string // the model's latest reasoning, expanded by /thinking
 }
 
 // NewCLI creates a new CLI instance with message container
@@ -54,6 +58,11 @@ func (c *CLI) SetUsageTracker(tracker *UsageTracker) {
 	}
 }
 
+// SetSamplingUsageSource sets where /usage reads MCP sampling usage from
+func (c *CLI) SetSamplingUsageSource(source func() []SamplingUsage) {
+	c.samplingUsage = source
+}
+
 // GetDebugLogger returns a debug logger that uses the CLI for rendering
 func (c *CLI) GetDebugLogger() *CLIDebugLogger {
 	return NewCLIDebugLogger(c)
@@ -78,7 +87,7 @@ func (c *CLI) GetPrompt() (string, error) {
 	// No divider needed - removed for cleaner appearance
 
 	// Create our custom slash command input
-	input := NewSlashCommandInput(c.width, "Enter your prompt (Type /help for commands, Ctrl+C to quit, ESC to cancel generation)")
+	input := NewSlashCommandInput(c.width, i18n.T("prompt.placeholder"))
 
 	// Run as a tea program
 	p := tea.NewProgram(input)
@@ -123,9 +132,9 @@ func (c *CLI) ShowSpinner(message string, action func() error) error {
 func (c *CLI) DisplayUserMessage(message string) {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderUserMessage(message, time.Now())
+		msg = c.compactRenderer.RenderUserMessage(message, clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderUserMessage(message, time.Now())
+		msg = c.messageRenderer.RenderUserMessage(message, clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -140,9 +149,9 @@ func (c *CLI) DisplayAssistantMessage(message string) error {
 func (c *CLI) DisplayAssistantMessageWithModel(message, modelName string) error {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderAssistantMessage(message, time.Now(), modelName)
+		msg = c.compactRenderer.RenderAssistantMessage(message, clock.Now(), modelName)
 	} else {
-		msg = c.messageRenderer.RenderAssistantMessage(message, time.Now(), modelName)
+		msg = c.messageRenderer.RenderAssistantMessage(message, clock.Now(), modelName)
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -157,9 +166,9 @@ func (c *CLI) DisplayToolCallMessage(toolName, toolArgs string) {
 
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderToolCallMessage(toolName, toolArgs, time.Now())
+		msg = c.compactRenderer.RenderToolCallMessage(toolName, toolArgs, clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderToolCallMessage(toolName, toolArgs, time.Now())
+		msg = c.messageRenderer.RenderToolCallMessage(toolName, toolArgs, clock.Now())
 	}
 
 	// Always display immediately - spinner management is handled externally
@@ -167,6 +176,65 @@ func (c *CLI) DisplayToolCallMessage(toolName, toolArgs string) {
 	c.displayContainer()
 }
 
+// DisplayReasoning displays a model's reasoning collapsed to its first lines,
+// in place of the streaming block if it was streamed; /thinking shows it in
+// full
+func (c *CLI) DisplayReasoning(reasoning string) {
+	c.lastReasoning = reasoning
+	c.displayReasoning(false)
+}
+
+// StreamReasoning displays the latest lines of a model's reasoning while it
+// streams, in a Thinking block that DisplayReasoning collapses once it is
+// done
+func (c *CLI) StreamReasoning(reasoning string) {
+	var msg UIMessage
+	if c.compactMode {
+		msg = c.compactRenderer.RenderReasoningStream(reasoning)
+	} else {
+		msg = c.messageRenderer.RenderReasoningStream(reasoning)
+	}
+	msg.Streaming = true
+	if c.reasoningStreaming() {
+		c.messageContainer.messages[len(c.messageContainer.messages)-1] = msg
+	} else {
+		c.messageContainer.messages = nil // clear previous messages (they should have been printed already)
+		c.lastStreamHeight = 0
+		c.messageContainer.AddMessage(msg)
+	}
+	c.displayContainer()
+}
+
+// reasoningStreaming reports whether the last message is a Thinking block
+// still streaming
+func (c *CLI) reasoningStreaming() bool {
+	messages := c.messageContainer.messages
+	return len(messages) > 0 && messages[len(messages)-1].Type == ReasoningMessage && messages[len(messages)-1].Streaming
+}
+
+// displayReasoning displays the latest reasoning, collapsed or expanded
+func (c *CLI) displayReasoning(expanded bool) {
+	if strings.TrimSpace(c.lastReasoning) == "" {
+		c.DisplayInfo(i18n.T("thinking.none"))
+		return
+	}
+	var msg UIMessage
+	if c.compactMode {
+		msg = c.compactRenderer.RenderReasoningMessage(c.lastReasoning, expanded)
+	} else {
+		msg = c.messageRenderer.RenderReasoningMessage(c.lastReasoning, expanded)
+	}
+	if c.reasoningStreaming() {
+		// The collapsed block is at least as tall as the streaming one it overwrites
+		c.messageContainer.messages[len(c.messageContainer.messages)-1] = msg
+		c.displayContainer()
+		c.lastStreamHeight = 0
+		return
+	}
+	c.messageContainer.AddMessage(msg)
+	c.displayContainer()
+}
+
 // DisplayToolMessage displays a tool call message
 func (c *CLI) DisplayToolMessage(toolName, toolArgs, toolResult string, isError bool) {
 	var msg UIMessage
@@ -186,9 +254,9 @@ func (c *CLI) StartStreamingMessage(modelName string) {
 	// Add an empty assistant message that we'll update during streaming
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderAssistantMessage("", time.Now(), modelName)
+		msg = c.compactRenderer.RenderAssistantMessage("", clock.Now(), modelName)
 	} else {
-		msg = c.messageRenderer.RenderAssistantMessage("", time.Now(), modelName)
+		msg = c.messageRenderer.RenderAssistantMessage("", clock.Now(), modelName)
 	}
 	msg.Streaming = true
 	c.lastStreamHeight = 0 // Reset last stream height for new message
@@ -207,9 +275,9 @@ func (c *CLI) UpdateStreamingMessage(content string) {
 func (c *CLI) DisplayError(err error) {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderErrorMessage(err.Error(), time.Now())
+		msg = c.compactRenderer.RenderErrorMessage(err.Error(), clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderErrorMessage(err.Error(), time.Now())
+		msg = c.messageRenderer.RenderErrorMessage(err.Error(), clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -219,9 +287,9 @@ func (c *CLI) DisplayError(err error) {
 func (c *CLI) DisplayInfo(message string) {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderSystemMessage(message, time.Now())
+		msg = c.compactRenderer.RenderSystemMessage(message, clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderSystemMessage(message, time.Now())
+		msg = c.messageRenderer.RenderSystemMessage(message, clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -231,9 +299,9 @@ func (c *CLI) DisplayInfo(message string) {
 func (c *CLI) DisplayCancellation() {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderSystemMessage("Generation cancelled by user (ESC pressed)", time.Now())
+		msg = c.compactRenderer.RenderSystemMessage(i18n.T("message.cancelled"), clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderSystemMessage("Generation cancelled by user (ESC pressed)", time.Now())
+		msg = c.messageRenderer.RenderSystemMessage(i18n.T("message.cancelled"), clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -246,9 +314,9 @@ func (c *CLI) DisplayDebugMessage(message string) {
 	}
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderDebugMessage(message, time.Now())
+		msg = c.compactRenderer.RenderDebugMessage(message, clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderDebugMessage(message, time.Now())
+		msg = c.messageRenderer.RenderDebugMessage(message, clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -258,9 +326,9 @@ func (c *CLI) DisplayDebugMessage(message string) {
 func (c *CLI) DisplayDebugConfig(config map[string]any) {
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderDebugConfigMessage(config, time.Now())
+		msg = c.compactRenderer.RenderDebugConfigMessage(config, clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderDebugConfigMessage(config, time.Now())
+		msg = c.messageRenderer.RenderDebugConfigMessage(config, clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -268,22 +336,10 @@ func (c *CLI) DisplayDebugConfig(config map[string]any) {
 
 // DisplayHelp displays help information in a message block
 func (c *CLI) DisplayHelp() {
-	help := `## Available Commands
-
-- ` + "`/help`" + `: Show this help message
-- ` + "`/tools`" + `: List all available tools
-- ` + "`/servers`" + `: List configured MCP servers
-- ` + "`/usage`" + `: Show token usage and cost statistics
-- ` + "`/reset-usage`" + `: Reset usage statistics
-- ` + "`/clear`" + `: Clear message history
-- ` + "`/quit`" + `: Exit the application
-- ` + "`Ctrl+C`" + `: Exit at any time
-- ` + "`ESC`" + `: Cancel ongoing LLM generation
-
-You can also just type your message to chat with the AI assistant.`
+	help := i18n.T("help")
 
 	// Display as a system message
-	msg := c.messageRenderer.RenderSystemMessage(help, time.Now())
+	msg := c.messageRenderer.RenderSystemMessage(help, clock.Now())
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
 }
@@ -291,10 +347,10 @@ You can also just type your message to chat with the AI assistant.`
 // DisplayTools displays available tools in a message block
 func (c *CLI) DisplayTools(tools []string) {
 	var content strings.Builder
-	content.WriteString("## Available Tools\n\n")
+	content.WriteString("## " + i18n.T("tools.title") + "\n\n")
 
 	if len(tools) == 0 {
-		content.WriteString("No tools are currently available.")
+		content.WriteString(i18n.T("tools.none"))
 	} else {
 		for i, tool := range tools {
 			content.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, tool))
@@ -302,7 +358,7 @@ func (c *CLI) DisplayTools(tools []string) {
 	}
 
 	// Display as a system message
-	msg := c.messageRenderer.RenderSystemMessage(content.String(), time.Now())
+	msg := c.messageRenderer.RenderSystemMessage(content.String(), clock.Now())
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
 }
@@ -310,10 +366,10 @@ func (c *CLI) DisplayTools(tools []string) {
 // DisplayServers displays configured MCP servers in a message block
 func (c *CLI) DisplayServers(servers []string) {
 	var content strings.Builder
-	content.WriteString("## Configured MCP Servers\n\n")
+	content.WriteString("## " + i18n.T("servers.title") + "\n\n")
 
 	if len(servers) == 0 {
-		content.WriteString("No MCP servers are currently configured.")
+		content.WriteString(i18n.T("servers.none"))
 	} else {
 		for i, server := range servers {
 			content.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, server))
@@ -321,7 +377,7 @@ func (c *CLI) DisplayServers(servers []string) {
 	}
 
 	// Display as a system message
-	msg := c.messageRenderer.RenderSystemMessage(content.String(), time.Now())
+	msg := c.messageRenderer.RenderSystemMessage(content.String(), clock.Now())
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
 }
@@ -352,8 +408,11 @@ func (c *CLI) HandleSlashCommand(input string, servers []string, tools []string)
 
 	case "/clear":
 		c.ClearMessages()
-		c.DisplayInfo("Conversation cleared. Starting fresh.")
+		c.DisplayInfo(i18n.T("message.cleared"))
 		return SlashCommandResult{Handled: true, ClearHistory: true}
+	case "/thinking":
+		c.displayReasoning(true)
+		return SlashCommandResult{Handled: true}
 	case "/usage":
 		c.DisplayUsageStats()
 		return SlashCommandResult{Handled: true}
@@ -361,7 +420,7 @@ func (c *CLI) HandleSlashCommand(input string, servers []string, tools []string)
 		c.ResetUsageStats()
 		return SlashCommandResult{Handled: true}
 	case "/quit":
-		fmt.Println("\n  Goodbye!")
+		fmt.Println("\n  " + i18n.T("goodbye"))
 		os.Exit(0)
 		return SlashCommandResult{Handled: true}
 	default:
@@ -431,62 +490,100 @@ func (c *CLI) UpdateUsage(inputText, outputText string) {
 
 // UpdateUsageFromResponse updates the usage tracker using token usage from response metadata
 func (c *CLI) UpdateUsageFromResponse(response *schema.Message, inputText string) {
-	if c.usageTracker == nil {
+	c.UpdateUsageFromTurn([]*schema.Message{response}, inputText)
+}
+
+// UpdateUsageFromTurn records one turn of the agent loop as a single request.
+// Providers report usage per model call, so the usage of every assistant
+// message generated during the turn (tool-calling steps included) is summed.
+// The last message is the final response, used for estimation when no usable
+// metadata was reported.
+func (c *CLI) UpdateUsageFromTurn(responses []*schema.Message, inputText string) {
+	if c.usageTracker == nil || len(responses) == 0 {
 		return
 	}
 
-	// Try to extract token usage from response metadata
-	if response.ResponseMeta != nil && response.ResponseMeta.Usage != nil {
-		usage := response.ResponseMeta.Usage
+	var total models.TokenCounts
+	for _, msg := range responses {
+		if counts, ok := models.TokenCountsFromMessage(msg); ok {
+			total.Add(counts)
+		}
+	}
 
-		// Use actual token counts from the response
-		inputTokens := int(usage.PromptTokens)
-		outputTokens := int(usage.CompletionTokens)
+	// Validate that the metadata seems reasonable
+	// If token counts are 0 or seem unrealistic, fall back to estimation
+	if total.PromptTokens() > 0 && total.OutputTokens > 0 {
+		c.usageTracker.RecordUsage(total)
+		return
+	}
 
-		// Validate that the metadata seems reasonable
-		// If token counts are 0 or seem unrealistic, fall back to estimation
-		if inputTokens > 0 && outputTokens > 0 {
-			// Handle cache tokens if available (some providers support this)
-			cacheReadTokens := 0
-			cacheWriteTokens := 0
+	response := responses[len(responses)-1]
+	if response == nil {
+		return
+	}
+	c.usageTracker.EstimateAndUpdateUsage(inputText, response.Content)
+}
 
-			c.usageTracker.UpdateUsage(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens)
-		} else {
-			// Metadata exists but seems incomplete/unreliable, use estimation
-			c.usageTracker.EstimateAndUpdateUsage(inputText, response.Content)
-		}
-	} else {
-		// Fallback to estimation if no metadata is available
-		c.usageTracker.EstimateAndUpdateUsage(inputText, response.Content)
+// tokenBreakdown lists the cache and reasoning counts behind a usage line, if any
+func tokenBreakdown(cacheRead, cacheWrite, reasoning int) string {
+	var parts []string
+	if cacheRead > 0 {
+		parts = append(parts, i18n.T("usage.cache_read", cacheRead))
+	}
+	if cacheWrite > 0 {
+		parts = append(parts, i18n.T("usage.cache_write", cacheWrite))
+	}
+	if reasoning > 0 {
+		parts = append(parts, i18n.T("usage.reasoning", reasoning))
+	}
+	if len(parts) == 0 {
+		return ""
 	}
+	return "  - " + strings.Join(parts, ", ") + "\n"
 }
 
 // DisplayUsageStats displays current usage statistics
 func (c *CLI) DisplayUsageStats() {
-	if c.usageTracker == nil {
-		c.DisplayInfo("Usage tracking is not available for this model.")
+	var sampling []SamplingUsage
+	if c.samplingUsage != nil {
+		sampling = c.samplingUsage()
+	}
+	if c.usageTracker == nil && len(sampling) == 0 {
+		c.DisplayInfo(i18n.T("usage.unavailable"))
 		return
 	}
 
-	sessionStats := c.usageTracker.GetSessionStats()
-	lastStats := c.usageTracker.GetLastRequestStats()
-
 	var content strings.Builder
-	content.WriteString("## Usage Statistics\n\n")
+	content.WriteString("## " + i18n.T("usage.title") + "\n\n")
 
-	if lastStats != nil {
-		content.WriteString(fmt.Sprintf("**Last Request:** %d input + %d output tokens = $%.6f\n",
-			lastStats.InputTokens, lastStats.OutputTokens, lastStats.TotalCost))
+	if c.usageTracker != nil {
+		sessionStats := c.usageTracker.GetSessionStats()
+		lastStats := c.usageTracker.GetLastRequestStats()
+
+		if lastStats != nil {
+			content.WriteString(i18n.T("usage.last_request",
+				lastStats.InputTokens, lastStats.OutputTokens, lastStats.TotalCost) + "\n")
+			content.WriteString(tokenBreakdown(lastStats.CacheReadTokens, lastStats.CacheWriteTokens, lastStats.ReasoningTokens))
+		}
+
+		content.WriteString(i18n.T("usage.session_total",
+			sessionStats.TotalInputTokens, sessionStats.TotalOutputTokens, sessionStats.TotalCost, sessionStats.RequestCount) + "\n")
+		content.WriteString(tokenBreakdown(sessionStats.TotalCacheReadTokens, sessionStats.TotalCacheWriteTokens, sessionStats.TotalReasoningTokens))
 	}
 
-	content.WriteString(fmt.Sprintf("**Session Total:** %d input + %d output tokens = $%.6f (%d requests)\n",
-		sessionStats.TotalInputTokens, sessionStats.TotalOutputTokens, sessionStats.TotalCost, sessionStats.RequestCount))
+	if len(sampling) > 0 {
+		content.WriteString("\n" + i18n.T("usage.sampling_title") + "\n")
+		for _, usage := range sampling {
+			content.WriteString(i18n.T("usage.sampling_server",
+				usage.Server, usage.Tokens.InputTokens, usage.Tokens.OutputTokens, usage.Cost, usage.Requests) + "\n")
+		}
+	}
 
 	var msg UIMessage
 	if c.compactMode {
-		msg = c.compactRenderer.RenderSystemMessage(content.String(), time.Now())
+		msg = c.compactRenderer.RenderSystemMessage(content.String(), clock.Now())
 	} else {
-		msg = c.messageRenderer.RenderSystemMessage(content.String(), time.Now())
+		msg = c.messageRenderer.RenderSystemMessage(content.String(), clock.Now())
 	}
 	c.messageContainer.AddMessage(msg)
 	c.displayContainer()
@@ -495,12 +592,12 @@ func (c *CLI) DisplayUsageStats() {
 // ResetUsageStats resets the usage tracking statistics
 func (c *CLI) ResetUsageStats() {
 	if c.usageTracker == nil {
-		c.DisplayInfo("Usage tracking is not available for this model.")
+		c.DisplayInfo(i18n.T("usage.unavailable"))
 		return
 	}
 
 	c.usageTracker.Reset()
-	c.DisplayInfo("Usage statistics have been reset.")
+	c.DisplayInfo(i18n.T("usage.reset"))
 }
 
 // DisplayUsageAfterResponse displays usage information immediately after a response
@@ -1,5 +1,7 @@
 package ui
 
+import "github.com/osi4iot/mcphost/internal/i18n"
+
 // SlashCommand represents a slash command with its metadata
 type SlashCommand struct {
 	Name        string
@@ -28,6 +30,16 @@ var SlashCommands = []SlashCommand{
 		Category:    "Info",
 		Aliases:     []string{"/s"},
 	},
+	{
+		Name:        "/call",
+		Description: "Call a tool directly without the model: /call <tool> {json}",
+		Category:    "Info",
+	},
+	{
+		Name:        "/memory",
+		Description: "View, edit or clear remembered preferences: /memory [view|edit|clear]",
+		Category:    "System",
+	},
 
 	{
 		Name:        "/clear",
@@ -35,6 +47,46 @@ var SlashCommands = []SlashCommand{
 		Category:    "System",
 		Aliases:     []string{"/c", "/cls"},
 	},
+	{
+		Name:        "/plan",
+		Description: "Draft a plan for a task and approve it before it runs: /plan <task>",
+		Category:    "System",
+	},
+	{
+		Name:        "/pause",
+		Description: "Pause the run before its next step (Ctrl+P while it runs)",
+		Category:    "System",
+	},
+	{
+		Name:        "/resume",
+		Description: "Continue a paused run",
+		Category:    "System",
+	},
+	{
+		Name:        "/step",
+		Description: "Run one step of a paused run, then pause again",
+		Category:    "System",
+	},
+	{
+		Name:        "/abort",
+		Description: "Stop a paused run",
+		Category:    "System",
+	},
+	{
+		Name:        "/persona",
+		Description: "List personas or switch to one: /persona [name]",
+		Category:    "System",
+	},
+	{
+		Name:        "/thinking",
+		Description: "Show the model's latest reasoning in full",
+		Category:    "Info",
+	},
+	{
+		Name:        "/stats",
+		Description: "Summarize the conversation: turns, tool calls, tokens, time and cost",
+		Category:    "Info",
+	},
 	{
 		Name:        "/usage",
 		Description: "Show token usage statistics",
@@ -55,6 +107,14 @@ var SlashCommands = []SlashCommand{
 	},
 }
 
+// LocalizedDescription returns the description in the UI language
+func (c SlashCommand) LocalizedDescription() string {
+	if description, ok := i18n.Lookup("command." + c.Name); ok {
+		return description
+	}
+	return c.Description
+}
+
 // GetCommandByName returns a command by its name or alias
 func GetCommandByName(name string) *SlashCommand {
 	for i := range SlashCommands {
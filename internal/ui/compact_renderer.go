@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/osi4iot/mcphost/internal/i18n"
 )
 
 // CompactRenderer handles rendering messages in compact format
@@ -31,7 +32,7 @@ func (r *CompactRenderer) SetWidth(width int) {
 func (r *CompactRenderer) RenderUserMessage(content string, timestamp time.Time) UIMessage {
 	theme := getTheme()
 	symbol := lipgloss.NewStyle().Foreground(theme.Secondary).Render(">")
-	label := lipgloss.NewStyle().Foreground(theme.Secondary).Bold(true).Render("User")
+	label := lipgloss.NewStyle().Foreground(theme.Secondary).Bold(true).Render(i18n.T("label.user"))
 
 	// Format content for user messages (preserve formatting, no truncation)
 	compactContent := r.formatUserAssistantContent(content)
@@ -65,14 +66,14 @@ func (r *CompactRenderer) RenderAssistantMessage(content string, timestamp time.
 
 	// Use the full model name, fallback to "Assistant" if empty
 	if modelName == "" {
-		modelName = "Assistant"
+		modelName = i18n.T("label.assistant")
 	}
 	label := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).Render(modelName)
 
 	// Format content for assistant messages (preserve formatting, no truncation)
 	compactContent := r.formatUserAssistantContent(content)
 	if compactContent == "" {
-		compactContent = lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render("(no output)")
+		compactContent = lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render(i18n.T("message.no_output"))
 	}
 
 	// Handle multi-line content
@@ -130,7 +131,7 @@ func (r *CompactRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 	var labelText string
 
 	if isError {
-		labelText = "Error"
+		labelText = i18n.T("label.error")
 		label = lipgloss.NewStyle().Foreground(theme.Muted).Bold(true).Render(labelText)
 		content = lipgloss.NewStyle().Foreground(theme.Muted).Render(r.formatToolResult(toolResult))
 	} else {
@@ -140,7 +141,7 @@ func (r *CompactRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 		content = lipgloss.NewStyle().Foreground(theme.Muted).Render(r.formatToolResult(toolResult))
 
 		if r.formatToolResult(toolResult) == "" {
-			content = lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render("(no output)")
+			content = lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render(i18n.T("message.no_output"))
 		}
 	}
 
@@ -165,11 +166,51 @@ func (r *CompactRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 	}
 }
 
+// RenderReasoningMessage renders a model's reasoning in compact format: its
+// start on one line unless expanded
+func (r *CompactRenderer) RenderReasoningMessage(reasoning string, expanded bool) UIMessage {
+	theme := getTheme()
+	symbol := lipgloss.NewStyle().Foreground(theme.Muted).Render("~")
+	label := lipgloss.NewStyle().Foreground(theme.Muted).Bold(true).Render(i18n.T("label.thinking"))
+
+	content := r.formatCompactContent(reasoning)
+	if expanded {
+		content = r.wrapText(strings.TrimSpace(reasoning), r.width-12)
+	}
+	line := fmt.Sprintf("%s  %-8s %s", symbol, label, lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render(content))
+
+	return UIMessage{
+		Type:    ReasoningMessage,
+		Content: line,
+		Height:  lipgloss.Height(line),
+	}
+}
+
+// RenderReasoningStream renders reasoning that is still streaming in compact
+// format: its latest words on one line
+func (r *CompactRenderer) RenderReasoningStream(reasoning string) UIMessage {
+	theme := getTheme()
+	symbol := lipgloss.NewStyle().Foreground(theme.Muted).Render("~")
+	label := lipgloss.NewStyle().Foreground(theme.Muted).Bold(true).Render(i18n.T("label.thinking"))
+
+	content := []rune(strings.Join(strings.Fields(reasoning), " "))
+	if maxLen := max(r.width-28, 40); len(content) > maxLen {
+		content = append([]rune("..."), content[len(content)-maxLen+3:]...)
+	}
+	line := fmt.Sprintf("%s  %-8s %s", symbol, label, lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render(string(content)))
+
+	return UIMessage{
+		Type:    ReasoningMessage,
+		Content: line,
+		Height:  lipgloss.Height(line),
+	}
+}
+
 // RenderSystemMessage renders a system message in compact format
 func (r *CompactRenderer) RenderSystemMessage(content string, timestamp time.Time) UIMessage {
 	theme := getTheme()
 	symbol := lipgloss.NewStyle().Foreground(theme.System).Render("*")
-	label := lipgloss.NewStyle().Foreground(theme.System).Bold(true).Render("System")
+	label := lipgloss.NewStyle().Foreground(theme.System).Bold(true).Render(i18n.T("label.system"))
 
 	compactContent := r.formatCompactContent(content)
 
@@ -187,7 +228,7 @@ func (r *CompactRenderer) RenderSystemMessage(content string, timestamp time.Tim
 func (r *CompactRenderer) RenderErrorMessage(errorMsg string, timestamp time.Time) UIMessage {
 	theme := getTheme()
 	symbol := lipgloss.NewStyle().Foreground(theme.Error).Render("!")
-	label := lipgloss.NewStyle().Foreground(theme.Error).Bold(true).Render("Error")
+	label := lipgloss.NewStyle().Foreground(theme.Error).Bold(true).Render(i18n.T("label.error"))
 
 	compactContent := lipgloss.NewStyle().Foreground(theme.Error).Render(r.formatCompactContent(errorMsg))
 
@@ -205,7 +246,7 @@ func (r *CompactRenderer) RenderErrorMessage(errorMsg string, timestamp time.Tim
 func (r *CompactRenderer) RenderDebugMessage(message string, timestamp time.Time) UIMessage {
 	theme := getTheme()
 	symbol := lipgloss.NewStyle().Foreground(theme.Tool).Render("*")
-	label := lipgloss.NewStyle().Foreground(theme.Tool).Bold(true).Render("Debug")
+	label := lipgloss.NewStyle().Foreground(theme.Tool).Bold(true).Render(i18n.T("label.debug"))
 
 	// Truncate message if too long
 	content := message
@@ -227,7 +268,7 @@ func (r *CompactRenderer) RenderDebugMessage(message string, timestamp time.Time
 func (r *CompactRenderer) RenderDebugConfigMessage(config map[string]any, timestamp time.Time) UIMessage {
 	theme := getTheme()
 	symbol := lipgloss.NewStyle().Foreground(theme.Tool).Render("*")
-	label := lipgloss.NewStyle().Foreground(theme.Tool).Bold(true).Render("Debug")
+	label := lipgloss.NewStyle().Foreground(theme.Tool).Bold(true).Render(i18n.T("label.debug"))
 
 	// Format config as compact key=value pairs
 	var configPairs []string
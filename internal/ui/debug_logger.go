@@ -3,7 +3,8 @@ package ui
 import (
 	"fmt"
 	"strings"
-	"time"
+
+	"github.com/osi4iot/mcphost/internal/clock"
 )
 
 // CLIDebugLogger implements the tools.DebugLogger interface using CLI rendering
@@ -62,9 +63,9 @@ func (l *CLIDebugLogger) LogDebug(message string) {
 	// Use the CLI's debug message rendering
 	var msg UIMessage
 	if l.cli.compactMode {
-		msg = l.cli.compactRenderer.RenderDebugMessage(formattedMessage, time.Now())
+		msg = l.cli.compactRenderer.RenderDebugMessage(formattedMessage, clock.Now())
 	} else {
-		msg = l.cli.messageRenderer.RenderDebugMessage(formattedMessage, time.Now())
+		msg = l.cli.messageRenderer.RenderDebugMessage(formattedMessage, clock.Now())
 	}
 	l.cli.messageContainer.AddMessage(msg)
 	l.cli.displayContainer()
@@ -11,8 +11,18 @@ import (
 // AgentInterface defines the interface we need from agent to avoid import cycles
 type AgentInterface interface {
 	GetLoadingMessage() string
+	GetParameterWarnings() []string // User-set generation parameters ignored or adjusted for the model
 	GetTools() []any                // Using any to avoid importing tool types
 	GetLoadedServerNames() []string // Add this method for debug config
+	GetSamplingUsage() []SamplingUsage
+}
+
+// SamplingUsage is the token usage of the MCP sampling requests from one server
+type SamplingUsage struct {
+	Server   string
+	Requests int
+	Tokens   models.TokenCounts
+	Cost     float64
 }
 
 // CLISetupOptions contains options for setting up CLI
@@ -49,6 +59,9 @@ func SetupCLI(opts *CLISetupOptions) (*CLI, error) {
 	// Parse model string for display and usage tracking
 	provider, model := parseModelName(opts.ModelString)
 
+	// Sampling is attributed to the requesting servers in /usage
+	cli.SetSamplingUsageSource(opts.Agent.GetSamplingUsage)
+
 	// Set the model name for consistent display
 	if model != "unknown" {
 		cli.SetModelName(model)
@@ -87,6 +100,10 @@ func SetupCLI(opts *CLISetupOptions) (*CLI, error) {
 		cli.DisplayInfo(loadingMessage)
 	}
 
+	for _, warning := range opts.Agent.GetParameterWarnings() {
+		cli.DisplayInfo("Warning: " + warning)
+	}
+
 	// Display tool count
 	tools := opts.Agent.GetTools()
 	cli.DisplayInfo(fmt.Sprintf("Loaded %d tools from MCP servers", len(tools)))
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/osi4iot/mcphost/internal/i18n"
+)
+
+// Keys handled by spinners that have an interjector
+const (
+	InterjectKey = tea.KeyTab   // opens the instruction line
+	PauseKey     = tea.KeyCtrlP // pauses the agent before its next step
+)
+
+// Interjector lets the user type an instruction, or ask for a pause, while the
+// agent works. The spinners it is given show the instruction line, and the
+// draft carries over from one spinner to the next as tools start and finish.
+type Interjector struct {
+	mu      sync.Mutex
+	submit  func(message string)
+	pause   func()
+	typing  bool
+	draft   []rune
+	pending int  // submitted instructions the agent has not picked up yet
+	pausing bool // a pause was requested and has not been reached yet
+}
+
+// NewInterjector creates an interjector that hands each instruction to submit
+// and calls pause when the user presses PauseKey or enters /pause
+func NewInterjector(submit func(message string), pause func()) *Interjector {
+	return &Interjector{submit: submit, pause: pause}
+}
+
+// Paused records that the agent reached the requested pause
+func (i *Interjector) Paused() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.pausing = false
+}
+
+// Delivered records that the agent picked up a submitted instruction
+func (i *Interjector) Delivered() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.pending > 0 {
+		i.pending--
+	}
+}
+
+// handleKey updates the instruction line and reports whether it used the key
+func (i *Interjector) handleKey(msg tea.KeyMsg) bool {
+	i.mu.Lock()
+	if !i.typing {
+		switch msg.Type {
+		case InterjectKey:
+			i.typing = true
+		case PauseKey:
+			i.pausing = true
+			i.mu.Unlock()
+			i.pause()
+			return true
+		default:
+			i.mu.Unlock()
+			return false
+		}
+		i.mu.Unlock()
+		return true
+	}
+
+	var message string
+	switch msg.Type {
+	case tea.KeyEnter:
+		message = strings.TrimSpace(string(i.draft))
+		i.typing = false
+		i.draft = nil
+		if message == "/pause" {
+			i.pausing = true
+			i.mu.Unlock()
+			i.pause()
+			return true
+		}
+		if message != "" {
+			i.pending++
+		}
+	case tea.KeyEsc:
+		i.typing = false
+		i.draft = nil
+	case tea.KeyBackspace:
+		if len(i.draft) > 0 {
+			i.draft = i.draft[:len(i.draft)-1]
+		}
+	case tea.KeySpace:
+		i.draft = append(i.draft, ' ')
+	case tea.KeyRunes:
+		i.draft = append(i.draft, msg.Runes...)
+	case tea.KeyCtrlC:
+		i.mu.Unlock()
+		return false
+	}
+	i.mu.Unlock()
+
+	// Outside the lock, since submit may take a while
+	if message != "" {
+		i.submit(message)
+	}
+	return true
+}
+
+// view renders the hint or the instruction line shown under a spinner
+func (i *Interjector) view() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	theme := GetTheme()
+	if i.typing {
+		prompt := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).Render(" › ")
+		return "\n" + prompt + string(i.draft) + lipgloss.NewStyle().Foreground(theme.Muted).Render("█")
+	}
+	hint := i18n.T("interject.hint")
+	switch {
+	case i.pausing:
+		hint = i18n.T("interject.pausing")
+	case i.pending > 0:
+		hint = i18n.T("interject.queued", i.pending)
+	}
+	return "  " + lipgloss.NewStyle().Foreground(theme.Muted).Render(hint)
+}
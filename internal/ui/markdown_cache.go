@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+
+	"github.com/charmbracelet/glamour/ansi"
+)
+
+// markdownCacheSize is how many rendered blocks renderedMarkdown keeps
+const markdownCacheSize = 512
+
+// renderedMarkdown keeps the markdown rendered most recently, so showing a
+// resumed conversation again, redrawing at a width seen before or rendering
+// the same streamed text twice skips glamour
+var renderedMarkdown = newMarkdownCache(markdownCacheSize)
+
+// markdownKey identifies a rendering: the same content at the same width in
+// the same style renders the same
+type markdownKey struct {
+	content [sha256.Size]byte
+	width   int
+	style   [sha256.Size]byte
+}
+
+// newMarkdownKey returns the key of content rendered at width with style
+func newMarkdownKey(content string, width int, style ansi.StyleConfig) markdownKey {
+	styleJSON, _ := json.Marshal(style)
+	return markdownKey{
+		content: sha256.Sum256([]byte(content)),
+		width:   width,
+		style:   sha256.Sum256(styleJSON),
+	}
+}
+
+// markdownEntry is a cached rendering
+type markdownEntry struct {
+	key      markdownKey
+	rendered string
+}
+
+// markdownCache is a least recently used cache of rendered markdown, safe for
+// concurrent use
+type markdownCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *markdownEntry, most recently used first
+	entries map[markdownKey]*list.Element
+}
+
+// newMarkdownCache returns a cache holding up to size renderings
+func newMarkdownCache(size int) *markdownCache {
+	return &markdownCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[markdownKey]*list.Element),
+	}
+}
+
+// get returns the rendering cached under key
+func (c *markdownCache) get(key markdownKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*markdownEntry).rendered, true
+}
+
+// add caches rendered under key, evicting the least recently used rendering
+// once the cache is full
+func (c *markdownCache) add(key markdownKey, rendered string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*markdownEntry).rendered = rendered
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&markdownEntry{key: key, rendered: rendered})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*markdownEntry).key)
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import "testing"
+
+func TestMarkdownCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	style := generateMarkdownStyleConfig()
+	a := newMarkdownKey("a", 80, style)
+	b := newMarkdownKey("b", 80, style)
+	c := newMarkdownKey("c", 80, style)
+
+	cache := newMarkdownCache(2)
+	cache.add(a, "A")
+	cache.add(b, "B")
+	if _, ok := cache.get(a); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.add(c, "C")
+
+	if _, ok := cache.get(b); ok {
+		t.Error("expected b, the least recently used, to be evicted")
+	}
+	for key, want := range map[markdownKey]string{a: "A", c: "C"} {
+		if got, ok := cache.get(key); !ok || got != want {
+			t.Errorf("expected %q cached, got %q (%v)", want, got, ok)
+		}
+	}
+}
+
+func TestMarkdownKey(t *testing.T) {
+	style := generateMarkdownStyleConfig()
+	if newMarkdownKey("# Title", 80, style) != newMarkdownKey("# Title", 80, style) {
+		t.Error("expected the same rendering to have the same key")
+	}
+	if newMarkdownKey("# Title", 80, style) == newMarkdownKey("# Title", 100, style) {
+		t.Error("expected widths to have different keys")
+	}
+	other := style
+	other.Document.Color = stringPtr("#ff0000")
+	if newMarkdownKey("# Title", 80, style) == newMarkdownKey("# Title", 80, other) {
+		t.Error("expected styles to have different keys")
+	}
+}
+
+func TestToMarkdownCached(t *testing.T) {
+	content := "# Cached\n\nSome *markdown* text."
+	first := toMarkdown(content, 60)
+	if _, ok := renderedMarkdown.get(newMarkdownKey(content, 60, generateMarkdownStyleConfig())); !ok {
+		t.Fatal("expected the rendering to be cached")
+	}
+	if second := toMarkdown(content, 60); second != first {
+		t.Errorf("expected the cached rendering, got %q want %q", second, first)
+	}
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// inlineImageColumns is the width, in terminal cells, images are drawn at
+const inlineImageColumns = 40
+
+// ToolMedia is an image or audio block returned in an MCP tool result
+type ToolMedia struct {
+	Kind     string // "image" or "audio"
+	MIMEType string
+	Data     string // base64
+}
+
+// ParseToolResult returns the text to display for a tool result and the images
+// and audio it contains. Results that are MCP content, possibly JSON-encoded
+// twice, show their first text block followed by a placeholder per image or
+// audio block; anything else is shown as is.
+func ParseToolResult(result string) (string, []ToolMedia) {
+	var mcpContent struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			Data     string `json:"data"`
+			MIMEType string `json:"mimeType"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(result), &mcpContent); err != nil {
+		var unquoted string
+		if err := json.Unmarshal([]byte(result), &unquoted); err != nil {
+			return result, nil
+		}
+		if err := json.Unmarshal([]byte(unquoted), &mcpContent); err != nil {
+			return result, nil
+		}
+	}
+
+	text := result
+	if len(mcpContent.Content) > 0 && mcpContent.Content[0].Type == "text" {
+		text = mcpContent.Content[0].Text
+	}
+
+	var media []ToolMedia
+	var placeholders []string
+	for _, content := range mcpContent.Content {
+		if (content.Type != "image" && content.Type != "audio") || content.Data == "" {
+			continue
+		}
+		media = append(media, ToolMedia{Kind: content.Type, MIMEType: content.MIMEType, Data: content.Data})
+		placeholders = append(placeholders, fmt.Sprintf("[%s: %s, %d KB]",
+			content.Type, content.MIMEType, (base64.StdEncoding.DecodedLen(len(content.Data))+1023)/1024))
+	}
+	if len(media) > 0 {
+		if text == result {
+			// A media-only result has no text worth showing
+			text = ""
+		}
+		text = strings.TrimSpace(text + "\n" + strings.Join(placeholders, "\n"))
+	}
+	return text, media
+}
+
+// inlineImageProtocol returns the graphics protocol the terminal supports:
+// "iterm" (iTerm2, WezTerm), "kitty", or "" when images can't be drawn
+func inlineImageProtocol() string {
+	if os.Getenv("TMUX") != "" {
+		return ""
+	}
+	switch {
+	case os.Getenv("TERM") == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "":
+		return "kitty"
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm"
+	}
+	return ""
+}
+
+// inlineImage returns the escape sequence drawing image with protocol, or ""
+// when the protocol can't show it
+func inlineImage(protocol string, image ToolMedia) string {
+	switch protocol {
+	case "iterm":
+		size := base64.StdEncoding.DecodedLen(len(image.Data))
+		return fmt.Sprintf("\033]1337;File=inline=1;size=%d;width=%d;preserveAspectRatio=1:%s\a",
+			size, inlineImageColumns, image.Data)
+	case "kitty":
+		// Kitty decodes PNG itself; other formats would need converting to raw pixels
+		if image.MIMEType != "image/png" {
+			return ""
+		}
+		var sb strings.Builder
+		data := image.Data
+		for first := true; first || data != ""; first = false {
+			chunk := data[:min(len(data), 4096)]
+			data = data[len(chunk):]
+			more := 0
+			if data != "" {
+				more = 1
+			}
+			if first {
+				fmt.Fprintf(&sb, "\033_Gf=100,a=T,c=%d,m=%d;%s\033\\", inlineImageColumns, more, chunk)
+			} else {
+				fmt.Fprintf(&sb, "\033_Gm=%d;%s\033\\", more, chunk)
+			}
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+// DisplayImages draws the images among media inline in terminals with a
+// graphics protocol. Other terminals already show the placeholders from
+// ParseToolResult.
+func (c *CLI) DisplayImages(media []ToolMedia) {
+	protocol := inlineImageProtocol()
+	if protocol == "" {
+		return
+	}
+	for _, image := range media {
+		if image.Kind != "image" {
+			continue
+		}
+		if seq := inlineImage(protocol, image); seq != "" {
+			fmt.Print("  " + seq + "\n")
+		}
+	}
+}
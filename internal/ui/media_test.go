@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseToolResult(t *testing.T) {
+	text, images := ParseToolResult(`{"content":[{"type":"text","text":"Saved chart"},{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"}]}`)
+	if text != "Saved chart\n[image: image/png, 1 KB]" || len(images) != 1 {
+		t.Errorf("got %q, %d images", text, len(images))
+	}
+
+	// A media-only result shows just the placeholders
+	text, media := ParseToolResult(`{"content":[{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"},{"type":"audio","data":"SUQzBA==","mimeType":"audio/mpeg"}]}`)
+	if text != "[image: image/png, 1 KB]\n[audio: audio/mpeg, 1 KB]" || len(media) != 2 || media[1].Kind != "audio" {
+		t.Errorf("got %q, %+v", text, media)
+	}
+
+	// Double-encoded results and plain output keep their old handling
+	if text, _ := ParseToolResult(`"{\"content\":[{\"type\":\"text\",\"text\":\"hi\"}]}"`); text != "hi" {
+		t.Errorf("got %q", text)
+	}
+	if text, images := ParseToolResult("plain output"); text != "plain output" || images != nil {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestInlineImageKittyChunks(t *testing.T) {
+	data := strings.Repeat("A", 5000)
+	seq := inlineImage("kitty", ToolMedia{Kind: "image", MIMEType: "image/png", Data: data})
+	if strings.Count(seq, "\033_G") != 2 || !strings.Contains(seq, ",m=1;") || !strings.Contains(seq, "\033_Gm=0;") {
+		t.Errorf("expected two chunks, got %q", seq[:min(len(seq), 80)])
+	}
+	if seq := inlineImage("kitty", ToolMedia{Kind: "image", MIMEType: "image/jpeg", Data: data}); seq != "" {
+		t.Error("kitty should skip non-PNG images")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/osi4iot/mcphost/internal/i18n"
 )
 
 // MessageType represents the type of message
@@ -17,9 +18,10 @@ const (
 	UserMessage MessageType = iota
 	AssistantMessage
 	ToolMessage
-	ToolCallMessage // New type for showing tool calls in progress
-	SystemMessage   // New type for MCPHost system messages (help, tools, etc.)
-	ErrorMessage    // New type for error messages
+	ToolCallMessage  // New type for showing tool calls in progress
+	SystemMessage    // New type for MCPHost system messages (help, tools, etc.)
+	ErrorMessage     // New type for error messages
+	ReasoningMessage // a model's reasoning before its answer
 )
 
 // UIMessage represents a rendered message for display
@@ -56,7 +58,7 @@ func getSystemUsername() string {
 	if username := os.Getenv("USERNAME"); username != "" {
 		return username
 	}
-	return "User"
+	return i18n.T("label.user")
 }
 
 // NewMessageRenderer creates a new message renderer
@@ -75,7 +77,7 @@ func (r *MessageRenderer) SetWidth(width int) {
 // RenderUserMessage renders a user message with right border and background header
 func (r *MessageRenderer) RenderUserMessage(content string, timestamp time.Time) UIMessage {
 	// Format timestamp and username
-	timeStr := timestamp.Local().Format("15:04")
+	timeStr := i18n.FormatTime(timestamp)
 	username := getSystemUsername()
 
 	// Render the message content
@@ -109,9 +111,9 @@ func (r *MessageRenderer) RenderUserMessage(content string, timestamp time.Time)
 // RenderAssistantMessage renders an assistant message with left border and background header
 func (r *MessageRenderer) RenderAssistantMessage(content string, timestamp time.Time, modelName string) UIMessage {
 	// Format timestamp and model info with better defaults
-	timeStr := timestamp.Local().Format("15:04")
+	timeStr := i18n.FormatTime(timestamp)
 	if modelName == "" {
-		modelName = "Assistant"
+		modelName = i18n.T("label.assistant")
 	}
 
 	// Handle empty content with better styling
@@ -122,7 +124,7 @@ func (r *MessageRenderer) RenderAssistantMessage(content string, timestamp time.
 			Italic(true).
 			Foreground(theme.Muted).
 			Align(lipgloss.Center).
-			Render("Finished without output")
+			Render(i18n.T("message.finished_without_output"))
 	} else {
 		messageContent = r.renderMarkdown(content, r.width-8) // Account for padding and borders
 	}
@@ -154,7 +156,7 @@ func (r *MessageRenderer) RenderAssistantMessage(content string, timestamp time.
 // RenderSystemMessage renders a system message with left border and background header
 func (r *MessageRenderer) RenderSystemMessage(content string, timestamp time.Time) UIMessage {
 	// Format timestamp
-	timeStr := timestamp.Local().Format("15:04")
+	timeStr := i18n.FormatTime(timestamp)
 
 	// Handle empty content with better styling
 	theme := getTheme()
@@ -164,13 +166,13 @@ func (r *MessageRenderer) RenderSystemMessage(content string, timestamp time.Tim
 			Italic(true).
 			Foreground(theme.Muted).
 			Align(lipgloss.Center).
-			Render("No content available")
+			Render(i18n.T("message.no_content"))
 	} else {
 		messageContent = r.renderMarkdown(content, r.width-8) // Account for padding and borders
 	}
 
 	// Create info line
-	info := fmt.Sprintf(" MCPHost System (%s)", timeStr)
+	info := fmt.Sprintf(" %s (%s)", i18n.T("label.mcphost_system"), timeStr)
 
 	// Combine content and info
 	fullContent := strings.TrimSuffix(messageContent, "\n") + "\n" +
@@ -210,13 +212,13 @@ func (r *MessageRenderer) RenderDebugMessage(message string, timestamp time.Time
 		MarginBottom(1) // Add bottom margin
 
 	// Format timestamp
-	timeStr := timestamp.Local().Format("02 Jan 2006 03:04 PM")
+	timeStr := i18n.FormatDateTime(timestamp)
 
 	// Create header with debug icon
 	header := baseStyle.
 		Foreground(theme.Tool).
 		Bold(true).
-		Render("🔍 Debug Output")
+		Render("🔍 " + i18n.T("label.debug_output"))
 
 	// Process and format the message content
 	// Split into lines and format each one
@@ -266,13 +268,13 @@ func (r *MessageRenderer) RenderDebugConfigMessage(config map[string]any, timest
 		PaddingLeft(1)
 
 	// Format timestamp
-	timeStr := timestamp.Local().Format("02 Jan 2006 03:04 PM")
+	timeStr := i18n.FormatDateTime(timestamp)
 
 	// Create header with debug icon
 	header := baseStyle.
 		Foreground(theme.Tool).
 		Bold(true).
-		Render("🔧 Debug Configuration")
+		Render("🔧 " + i18n.T("label.debug_config"))
 
 	// Format configuration settings
 	var configLines []string
@@ -314,7 +316,7 @@ func (r *MessageRenderer) RenderDebugConfigMessage(config map[string]any, timest
 // RenderErrorMessage renders an error message with left border and background header
 func (r *MessageRenderer) RenderErrorMessage(errorMsg string, timestamp time.Time) UIMessage {
 	// Format timestamp
-	timeStr := timestamp.Local().Format("15:04")
+	timeStr := i18n.FormatTime(timestamp)
 
 	// Format error content
 	theme := getTheme()
@@ -324,7 +326,7 @@ func (r *MessageRenderer) RenderErrorMessage(errorMsg string, timestamp time.Tim
 		Render(errorMsg)
 
 	// Create info line
-	info := fmt.Sprintf(" Error (%s)", timeStr)
+	info := fmt.Sprintf(" %s (%s)", i18n.T("label.error"), timeStr)
 
 	// Combine content and info
 	fullContent := errorContent + "\n" +
@@ -350,7 +352,7 @@ func (r *MessageRenderer) RenderErrorMessage(errorMsg string, timestamp time.Tim
 // RenderToolCallMessage renders a tool call in progress with left border and background header
 func (r *MessageRenderer) RenderToolCallMessage(toolName, toolArgs string, timestamp time.Time) UIMessage {
 	// Format timestamp
-	timeStr := timestamp.Local().Format("15:04")
+	timeStr := i18n.FormatTime(timestamp)
 
 	// Format arguments with better presentation
 	theme := getTheme()
@@ -359,11 +361,11 @@ func (r *MessageRenderer) RenderToolCallMessage(toolName, toolArgs string, times
 		argsContent = lipgloss.NewStyle().
 			Foreground(theme.Muted).
 			Italic(true).
-			Render(fmt.Sprintf("Arguments: %s", r.formatToolArgs(toolArgs)))
+			Render(i18n.T("tool.arguments", r.formatToolArgs(toolArgs)))
 	}
 
 	// Create info line
-	info := fmt.Sprintf(" Executing %s (%s)", toolName, timeStr)
+	info := fmt.Sprintf(" %s (%s)", i18n.T("tool.executing", toolName), timeStr)
 
 	// Combine parts
 	var fullContent string
@@ -400,7 +402,7 @@ func (r *MessageRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 	if isError {
 		fullContent = lipgloss.NewStyle().
 			Foreground(theme.Error).
-			Render(fmt.Sprintf("Error: %s", toolResult))
+			Render(i18n.T("tool.error", toolResult))
 	} else {
 		// Format result based on tool type
 		fullContent = r.formatToolResult(toolName, toolResult, r.width-8)
@@ -411,7 +413,7 @@ func (r *MessageRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 		fullContent = lipgloss.NewStyle().
 			Italic(true).
 			Foreground(theme.Muted).
-			Render("(no output)")
+			Render(i18n.T("message.no_output"))
 	}
 
 	// Use the new block renderer
@@ -430,6 +432,63 @@ func (r *MessageRenderer) RenderToolMessage(toolName, toolArgs, toolResult strin
 	}
 }
 
+// reasoningPreviewLines is how many lines of a model's reasoning are shown
+// until /thinking expands it
+const reasoningPreviewLines = 3
+
+// RenderReasoningMessage renders a model's reasoning as a muted Thinking
+// block, collapsed to its first lines unless expanded
+func (r *MessageRenderer) RenderReasoningMessage(reasoning string, expanded bool) UIMessage {
+	lines := r.reasoningLines(reasoning)
+	hidden := 0
+	if !expanded && len(lines) > reasoningPreviewLines {
+		hidden = len(lines) - reasoningPreviewLines
+		lines = lines[:reasoningPreviewLines]
+	}
+	return r.renderReasoning(lines, hidden)
+}
+
+// RenderReasoningStream renders reasoning that is still streaming as the
+// Thinking block, showing its latest lines
+func (r *MessageRenderer) RenderReasoningStream(reasoning string) UIMessage {
+	lines := r.reasoningLines(reasoning)
+	if len(lines) > reasoningPreviewLines {
+		lines = lines[len(lines)-reasoningPreviewLines:]
+	}
+	return r.renderReasoning(lines, 0)
+}
+
+// reasoningLines wraps reasoning to the width of the Thinking block
+func (r *MessageRenderer) reasoningLines(reasoning string) []string {
+	return strings.Split(lipgloss.NewStyle().Width(r.width-8).Render(strings.TrimSpace(reasoning)), "\n")
+}
+
+// renderReasoning renders the Thinking block with lines, noting how many
+// more are hidden
+func (r *MessageRenderer) renderReasoning(lines []string, hidden int) UIMessage {
+	theme := getTheme()
+
+	fullContent := lipgloss.NewStyle().Foreground(theme.Muted).Bold(true).Render(i18n.T("label.thinking")) + "\n" +
+		lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render(strings.Join(lines, "\n"))
+	if hidden > 0 {
+		fullContent += "\n" + lipgloss.NewStyle().Foreground(theme.VeryMuted).Render(i18n.T("thinking.collapsed", hidden))
+	}
+
+	rendered := renderContentBlock(
+		fullContent,
+		r.width,
+		WithAlign(lipgloss.Left),
+		WithBorderColor(theme.Muted),
+		WithMarginBottom(1),
+	)
+
+	return UIMessage{
+		Type:    ReasoningMessage,
+		Content: rendered,
+		Height:  lipgloss.Height(rendered),
+	}
+}
+
 // formatToolArgs formats tool arguments for display
 func (r *MessageRenderer) formatToolArgs(args string) string {
 	// Remove outer braces and clean up JSON formatting
@@ -442,7 +501,7 @@ func (r *MessageRenderer) formatToolArgs(args string) string {
 
 	// If it's empty after cleanup, return a placeholder
 	if args == "" {
-		return "(no arguments)"
+		return i18n.T("tool.no_arguments")
 	}
 
 	// Truncate if too long, but skip truncation in debug mode
@@ -465,7 +524,7 @@ func (r *MessageRenderer) formatToolResult(toolName, result string, width int) s
 		maxLines := 10
 		lines := strings.Split(result, "\n")
 		if len(lines) > maxLines {
-			result = strings.Join(lines[:maxLines], "\n") + "\n... (truncated)"
+			result = strings.Join(lines[:maxLines], "\n") + "\n" + i18n.T("tool.truncated")
 		}
 	}
 
@@ -731,14 +790,14 @@ func (c *MessageContainer) renderEmptyState() string {
 		Foreground(theme.Primary).
 		Bold(true).
 		MarginTop(1).
-		Render("AI Assistant with MCP Tools")
+		Render(i18n.T("welcome.subtitle"))
 
 	// Feature highlights
 	features := []string{
-		"Natural language conversations",
-		"Powerful tool integrations",
-		"Multi-provider LLM support",
-		"Usage tracking & analytics",
+		i18n.T("welcome.feature_conversations"),
+		i18n.T("welcome.feature_tools"),
+		i18n.T("welcome.feature_providers"),
+		i18n.T("welcome.feature_usage"),
 	}
 
 	var featureList []string
@@ -754,7 +813,7 @@ func (c *MessageContainer) renderEmptyState() string {
 		Foreground(theme.Accent).
 		Italic(true).
 		MarginTop(2).
-		Render("Start by typing your message below or use /help for commands")
+		Render(i18n.T("welcome.start"))
 
 	// Combine all elements
 	content := lipgloss.JoinVertical(
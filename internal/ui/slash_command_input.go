@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/osi4iot/mcphost/internal/i18n"
 )
 
 // SlashCommandInput is a custom input field with slash command autocomplete
@@ -29,7 +30,7 @@ type SlashCommandInput struct {
 // NewSlashCommandInput creates a new slash command input field
 func NewSlashCommandInput(width int, title string) *SlashCommandInput {
 	ta := textarea.New()
-	ta.Placeholder = "Type your message..."
+	ta.Placeholder = i18n.T("prompt.textarea")
 	ta.ShowLineNumbers = false
 	ta.Prompt = ""
 	ta.CharLimit = 5000
@@ -290,10 +291,10 @@ func (s *SlashCommandInput) renderPopup() string {
 		name := nameStyle.Width(nameWidth - 2).Render(cmd.Name)
 
 		// Truncate description if needed
-		desc := cmd.Description
+		desc := cmd.LocalizedDescription()
 		maxDescLen := s.width - nameWidth - 14 // Account for padding and indicator
-		if len(desc) > maxDescLen && maxDescLen > 3 {
-			desc = desc[:maxDescLen-3] + "..."
+		if runes := []rune(desc); len(runes) > maxDescLen && maxDescLen > 3 {
+			desc = string(runes[:maxDescLen-3]) + "..."
 		}
 
 		line := indicator + name + descStyle.Render(desc)
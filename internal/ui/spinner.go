@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,9 +24,35 @@ type Spinner struct {
 
 // spinnerModel is the tea.Model for the spinner
 type spinnerModel struct {
-	spinner  spinner.Model
-	message  string
-	quitting bool
+	spinner     spinner.Model
+	message     string
+	quitting    bool
+	interjector *Interjector // optional instruction line
+	progress    *RunProgress // optional step and elapsed time
+}
+
+// RunProgress is where an agent run is, shown by the spinners of the run
+// around their message: "Step 4/20 · Executing fs__grep · 12s"
+type RunProgress struct {
+	Started time.Time
+	Step    func() (step, maxSteps int) // from 1; maxSteps is 0 when unlimited
+}
+
+// format wraps message in the run's step and elapsed time
+func (p *RunProgress) format(message string) string {
+	message = strings.TrimSuffix(message, "...")
+	elapsed := time.Since(p.Started).Round(time.Second)
+	if p.Step == nil {
+		return fmt.Sprintf("%s · %s", message, elapsed)
+	}
+	step, maxSteps := p.Step()
+	switch {
+	case step == 0:
+		return fmt.Sprintf("%s · %s", message, elapsed)
+	case maxSteps == 0:
+		return fmt.Sprintf("Step %d · %s · %s", step, message, elapsed)
+	}
+	return fmt.Sprintf("Step %d/%d · %s · %s", step, maxSteps, message, elapsed)
 }
 
 func (m spinnerModel) Init() tea.Cmd {
@@ -33,6 +62,9 @@ func (m spinnerModel) Init() tea.Cmd {
 func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.interjector != nil && m.interjector.handleKey(msg) {
+			return m, nil
+		}
 		m.quitting = true
 		return m, tea.Quit
 	case spinner.TickMsg:
@@ -64,9 +96,37 @@ func (m spinnerModel) View() string {
 		Foreground(theme.Text).
 		Italic(true)
 
-	return fmt.Sprintf(" %s %s",
+	message := m.message
+	if m.progress != nil {
+		message = m.progress.format(message)
+	}
+	view := fmt.Sprintf(" %s %s",
 		spinnerStyle.Render(m.spinner.View()),
-		messageStyle.Render(m.message))
+		messageStyle.Render(message))
+	if m.interjector != nil {
+		view += m.interjector.view()
+	}
+	return view
+}
+
+// activeSpinner is the most recently started spinner, so prompts raised from
+// background goroutines can clear it before reading the terminal
+var (
+	activeSpinnerMu sync.Mutex
+	activeSpinner   *Spinner
+)
+
+// StopActiveSpinner stops the running spinner, if any. Its owner may still
+// call Stop afterwards.
+func StopActiveSpinner() {
+	activeSpinnerMu.Lock()
+	s := activeSpinner
+	activeSpinner = nil
+	activeSpinnerMu.Unlock()
+
+	if s != nil {
+		s.Stop()
+	}
 }
 
 // quitMsg is sent when we want to quit the spinner
@@ -74,6 +134,20 @@ type quitMsg struct{}
 
 // NewSpinner creates a new spinner with enhanced styling
 func NewSpinner(message string) *Spinner {
+	return NewInterjectableSpinner(message, nil)
+}
+
+// NewInterjectableSpinner creates a spinner that also shows the interjector's
+// instruction line, so the user can type while the agent works. A nil
+// interjector gives a plain spinner.
+func NewInterjectableSpinner(message string, interjector *Interjector) *Spinner {
+	return NewProgressSpinner(message, interjector, nil)
+}
+
+// NewProgressSpinner creates an interjectable spinner that also shows the
+// run's progress, updated as the spinner animates. A nil progress shows
+// only the message.
+func NewProgressSpinner(message string, interjector *Interjector, progress *RunProgress) *Spinner {
 	s := spinner.New()
 	s.Spinner = spinner.Points // More modern spinner style
 	theme := GetTheme()
@@ -82,8 +156,10 @@ func NewSpinner(message string) *Spinner {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	model := spinnerModel{
-		spinner: s,
-		message: message,
+		spinner:     s,
+		message:     message,
+		interjector: interjector,
+		progress:    progress,
 	}
 
 	prog := tea.NewProgram(model, tea.WithOutput(os.Stderr), tea.WithoutCatchPanics())
@@ -123,6 +199,10 @@ func NewThemedSpinner(message string, color lipgloss.AdaptiveColor) *Spinner {
 
 // Start begins the spinner animation
 func (s *Spinner) Start() {
+	activeSpinnerMu.Lock()
+	activeSpinner = s
+	activeSpinnerMu.Unlock()
+
 	go func() {
 		defer close(s.done)
 		go func() {
@@ -138,6 +218,12 @@ func (s *Spinner) Start() {
 
 // Stop ends the spinner animation
 func (s *Spinner) Stop() {
+	activeSpinnerMu.Lock()
+	if activeSpinner == s {
+		activeSpinner = nil
+	}
+	activeSpinnerMu.Unlock()
+
 	s.cancel()
 	<-s.done
 }
@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunProgressFormat(t *testing.T) {
+	started := time.Now().Add(-12 * time.Second)
+	tests := []struct {
+		step, maxSteps int
+		want           string
+	}{
+		{4, 20, "Step 4/20 · Executing fs__grep · 12s"},
+		{4, 0, "Step 4 · Executing fs__grep · 12s"},
+		{0, 20, "Executing fs__grep · 12s"}, // before the run's first step
+	}
+	for _, tt := range tests {
+		progress := &RunProgress{Started: started, Step: func() (int, int) { return tt.step, tt.maxSteps }}
+		if got := progress.format("Executing fs__grep..."); got != tt.want {
+			t.Errorf("format = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -351,9 +351,20 @@ func generateMarkdownStyleConfig() ansi.StyleConfig {
 	}
 }
 
-// toMarkdown renders markdown content using glamour
+// toMarkdown renders markdown content using glamour, reusing the rendering
+// cached for the same content, width and style
 func toMarkdown(content string, width int) string {
-	r := GetMarkdownRenderer(width)
+	style := generateMarkdownStyleConfig()
+	key := newMarkdownKey(content, width, style)
+	if rendered, ok := renderedMarkdown.get(key); ok {
+		return rendered
+	}
+
+	r, _ := glamour.NewTermRenderer(
+		glamour.WithStyles(style),
+		glamour.WithWordWrap(width),
+	)
 	rendered, _ := r.Render(content)
+	renderedMarkdown.add(key, rendered)
 	return rendered
 }
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/osi4iot/mcphost/internal/i18n"
 	"github.com/osi4iot/mcphost/internal/models"
 	"github.com/osi4iot/mcphost/internal/tokens"
 )
@@ -15,6 +16,7 @@ type UsageStats struct {
 	OutputTokens     int
 	CacheReadTokens  int
 	CacheWriteTokens int
+	ReasoningTokens  int // included in OutputTokens
 	InputCost        float64
 	OutputCost       float64
 	CacheReadCost    float64
@@ -28,6 +30,7 @@ type SessionStats struct {
 	TotalOutputTokens     int
 	TotalCacheReadTokens  int
 	TotalCacheWriteTokens int
+	TotalReasoningTokens  int
 	TotalCost             float64
 	RequestCount          int
 }
@@ -63,47 +66,47 @@ func EstimateTokens(text string) int {
 
 // UpdateUsage updates the tracker with new usage information
 func (ut *UsageTracker) UpdateUsage(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int) {
+	ut.RecordUsage(models.TokenCounts{
+		InputTokens:      inputTokens,
+		OutputTokens:     outputTokens,
+		CacheReadTokens:  cacheReadTokens,
+		CacheWriteTokens: cacheWriteTokens,
+	})
+}
+
+// RecordUsage adds one request's token counts, priced per kind of token
+func (ut *UsageTracker) RecordUsage(counts models.TokenCounts) {
 	ut.mu.Lock()
 	defer ut.mu.Unlock()
 
 	// Calculate costs based on model pricing
 	// For OAuth credentials, costs are $0 for usage tracking purposes
-	var inputCost, outputCost, cacheReadCost, cacheWriteCost, totalCost float64
-
+	var cost models.TokenCost
 	if !ut.isOAuth {
-		inputCost = float64(inputTokens) * ut.modelInfo.Cost.Input / 1000000 // Cost is per million tokens
-		outputCost = float64(outputTokens) * ut.modelInfo.Cost.Output / 1000000
-
-		if ut.modelInfo.Cost.CacheRead != nil {
-			cacheReadCost = float64(cacheReadTokens) * (*ut.modelInfo.Cost.CacheRead) / 1000000
-		}
-		if ut.modelInfo.Cost.CacheWrite != nil {
-			cacheWriteCost = float64(cacheWriteTokens) * (*ut.modelInfo.Cost.CacheWrite) / 1000000
-		}
-
-		totalCost = inputCost + outputCost + cacheReadCost + cacheWriteCost
+		cost = ut.modelInfo.Cost.Price(counts)
 	}
-	// If OAuth, all costs remain 0.0
 
 	// Update last request stats
 	ut.lastRequest = &UsageStats{
-		InputTokens:      inputTokens,
-		OutputTokens:     outputTokens,
-		CacheReadTokens:  cacheReadTokens,
-		CacheWriteTokens: cacheWriteTokens,
-		InputCost:        inputCost,
-		OutputCost:       outputCost,
-		CacheReadCost:    cacheReadCost,
-		CacheWriteCost:   cacheWriteCost,
-		TotalCost:        totalCost,
+		InputTokens:      counts.InputTokens,
+		OutputTokens:     counts.OutputTokens,
+		CacheReadTokens:  counts.CacheReadTokens,
+		CacheWriteTokens: counts.CacheWriteTokens,
+		ReasoningTokens:  counts.ReasoningTokens,
+		InputCost:        cost.Input,
+		OutputCost:       cost.Output,
+		CacheReadCost:    cost.CacheRead,
+		CacheWriteCost:   cost.CacheWrite,
+		TotalCost:        cost.Total(),
 	}
 
 	// Update session stats
-	ut.sessionStats.TotalInputTokens += inputTokens
-	ut.sessionStats.TotalOutputTokens += outputTokens
-	ut.sessionStats.TotalCacheReadTokens += cacheReadTokens
-	ut.sessionStats.TotalCacheWriteTokens += cacheWriteTokens
-	ut.sessionStats.TotalCost += totalCost
+	ut.sessionStats.TotalInputTokens += counts.InputTokens
+	ut.sessionStats.TotalOutputTokens += counts.OutputTokens
+	ut.sessionStats.TotalCacheReadTokens += counts.CacheReadTokens
+	ut.sessionStats.TotalCacheWriteTokens += counts.CacheWriteTokens
+	ut.sessionStats.TotalReasoningTokens += counts.ReasoningTokens
+	ut.sessionStats.TotalCost += cost.Total()
 	ut.sessionStats.RequestCount++
 }
 
@@ -136,15 +139,7 @@ func (ut *UsageTracker) RenderUsageInfo() string {
 	// Calculate total tokens
 	totalTokens := ut.sessionStats.TotalInputTokens + ut.sessionStats.TotalOutputTokens
 
-	// Format tokens with K/M suffix for better readability
-	var tokenStr string
-	if totalTokens >= 1000000 {
-		tokenStr = fmt.Sprintf("%.1fM", float64(totalTokens)/1000000)
-	} else if totalTokens >= 1000 {
-		tokenStr = fmt.Sprintf("%.1fK", float64(totalTokens)/1000)
-	} else {
-		tokenStr = fmt.Sprintf("%d", totalTokens)
-	}
+	tokenStr := formatTokenCount(totalTokens)
 
 	// Calculate percentage based on context limit with color coding
 	var percentageStr string
@@ -183,7 +178,7 @@ func (ut *UsageTracker) RenderUsageInfo() string {
 	// Create styled components
 	tokensLabel := baseStyle.
 		Foreground(theme.Muted).
-		Render("Tokens: ")
+		Render(i18n.T("label.tokens"))
 
 	tokensValue := baseStyle.
 		Foreground(theme.Text).
@@ -192,11 +187,28 @@ func (ut *UsageTracker) RenderUsageInfo() string {
 
 	costLabel := baseStyle.
 		Foreground(theme.Muted).
-		Render(" | Cost: ")
+		Render(i18n.T("label.cost"))
+
+	// Prompt cache reads and writes, when the provider reports any
+	var cacheStr string
+	if read, written := ut.sessionStats.TotalCacheReadTokens, ut.sessionStats.TotalCacheWriteTokens; read > 0 || written > 0 {
+		cacheStr = baseStyle.Foreground(theme.Muted).Render(i18n.T("label.cache")) +
+			baseStyle.Foreground(theme.Text).Render(i18n.T("usage.cache_short", formatTokenCount(read), formatTokenCount(written)))
+	}
 
 	// Build the enhanced display
-	return fmt.Sprintf("%s%s%s%s%s\n",
-		tokensLabel, tokensValue, percentageStr, costLabel, costStr)
+	return fmt.Sprintf("%s%s%s%s%s%s\n",
+		tokensLabel, tokensValue, percentageStr, cacheStr, costLabel, costStr)
+}
+
+// formatTokenCount formats a token count with a K or M suffix for readability
+func formatTokenCount(n int) string {
+	if n >= 1000000 {
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	} else if n >= 1000 {
+		return fmt.Sprintf("%.1fK", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
 }
 
 // GetSessionStats returns a copy of the current session statistics
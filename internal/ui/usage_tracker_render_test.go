@@ -57,3 +57,21 @@ func TestUsageTracker_RenderUsageInfo_OAuth(t *testing.T) {
 		t.Errorf("Expected regular rendered output to show actual cost, got: %s", regularRendered)
 	}
 }
+
+func TestUsageTracker_RenderUsageInfo_Cache(t *testing.T) {
+	modelInfo := &models.ModelInfo{
+		ID:    "claude-sonnet-4-20250514",
+		Cost:  models.Cost{Input: 3.0, Output: 15.0},
+		Limit: models.Limit{Context: 200000},
+	}
+	tracker := NewUsageTracker(modelInfo, "anthropic", 80, false)
+	tracker.UpdateUsage(1500, 500, 0, 0)
+	if rendered := tracker.RenderUsageInfo(); strings.Contains(rendered, "Cache:") {
+		t.Errorf("expected no cache figures without cache use, got: %s", rendered)
+	}
+
+	tracker.UpdateUsage(13500, 500, 12000, 1000)
+	if rendered := tracker.RenderUsageInfo(); !strings.Contains(rendered, "Cache: 12.0K read, 1.0K written") {
+		t.Errorf("expected the cache reads and writes, got: %s", rendered)
+	}
+}
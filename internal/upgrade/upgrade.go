@@ -0,0 +1,292 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRepo is the GitHub repository releases are published to
+const DefaultRepo = "osi4iot/mcphost"
+
+// checksumsAsset is the goreleaser checksum file attached to every release
+const checksumsAsset = "checksums.txt"
+
+// Release is a GitHub release
+type Release struct {
+	TagName string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Version returns the release version without the leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// asset finds an attached file by name
+func (r *Release) asset(name string) (*Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// Client talks to the GitHub releases API
+type Client struct {
+	APIURL     string // default https://api.github.com
+	Repo       string // default DefaultRepo
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the default repository
+func NewClient() *Client {
+	return &Client{
+		APIURL:     "https://api.github.com",
+		Repo:       DefaultRepo,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Latest returns the newest published release
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	body, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", c.APIURL, c.Repo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// DownloadBinary downloads the archive for goos/goarch, verifies it against the
+// release checksums and returns the mcphost binary inside it
+func (c *Client) DownloadBinary(ctx context.Context, release *Release, goos, goarch string) ([]byte, error) {
+	name := ArchiveName(goos, goarch)
+	archive, ok := release.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s (%s)", release.TagName, goos, goarch, name)
+	}
+	sums, ok := release.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s; refusing to install an unverified binary", release.TagName, checksumsAsset)
+	}
+
+	sumsData, err := c.get(ctx, sums.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums: %w", err)
+	}
+	want, err := findChecksum(sumsData, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.get(ctx, archive.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, want, got)
+	}
+
+	return extractBinary(data, goos)
+}
+
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, c.APIURL) {
+		// Avoids the anonymous rate limit on shared CI runners
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ArchiveName returns the goreleaser archive name for a platform, e.g.
+// mcphost_Linux_x86_64.tar.gz
+func ArchiveName(goos, goarch string) string {
+	arch := goarch
+	if goarch == "amd64" {
+		arch = "x86_64"
+	}
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("mcphost_%s%s_%s.%s", strings.ToUpper(goos[:1]), goos[1:], arch, ext)
+}
+
+// findChecksum looks up name in a sha256sum-style checksum file
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// extractBinary pulls the mcphost executable out of a release archive
+func extractBinary(data []byte, goos string) ([]byte, error) {
+	binary := "mcphost"
+	if goos == "windows" {
+		binary += ".exe"
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == binary {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("%s not found in archive", binary)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in archive", binary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binary {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// Replace atomically swaps the executable at path for binary. The new file is
+// written next to the old one and renamed over it, so a failed upgrade leaves
+// the current binary untouched.
+func Replace(path string, binary []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".mcphost-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (try running with more privileges): %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	old := ""
+	if runtime.GOOS == "windows" {
+		// A running executable cannot be replaced on Windows, but it can be moved
+		old = path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move current binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Put the current binary back rather than leave nothing at path
+		if old != "" {
+			os.Rename(old, path)
+		}
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
+
+// IsNewer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional "v" prefix and pre-release suffix; anything that
+// does not parse (such as "dev") is older than every release.
+func IsNewer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA {
+		return false
+	}
+	if !okB {
+		return true
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] > pb[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
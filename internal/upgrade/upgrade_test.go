@@ -0,0 +1,149 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// releaseServer serves a fake GitHub release containing archive
+func releaseServer(t *testing.T, archive []byte, checksum string) *httptest.Server {
+	t.Helper()
+	name := ArchiveName("linux", "amd64")
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/repos/test/mcphost/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{
+			TagName: "v1.2.0",
+			Assets: []Asset{
+				{Name: name, DownloadURL: srv.URL + "/download/" + name},
+				{Name: "checksums.txt", DownloadURL: srv.URL + "/download/checksums.txt"},
+			},
+		})
+	})
+	mux.HandleFunc("/download/"+name, func(w http.ResponseWriter, r *http.Request) { w.Write(archive) })
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  %s\n%s  mcphost_Darwin_arm64.tar.gz\n", checksum, name, strings.Repeat("0", 64))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadBinaryVerifiesChecksum(t *testing.T) {
+	archive := tarGz(t, "mcphost", []byte("new binary"))
+	sum := sha256.Sum256(archive)
+
+	srv := releaseServer(t, archive, hex.EncodeToString(sum[:]))
+	client := &Client{APIURL: srv.URL, Repo: "test/mcphost", HTTPClient: srv.Client()}
+
+	release, err := client.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if release.Version() != "1.2.0" {
+		t.Errorf("expected version 1.2.0, got %s", release.Version())
+	}
+
+	binary, err := client.DownloadBinary(context.Background(), release, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("DownloadBinary failed: %v", err)
+	}
+	if string(binary) != "new binary" {
+		t.Errorf("unexpected binary content %q", binary)
+	}
+
+	if _, err := client.DownloadBinary(context.Background(), release, "freebsd", "amd64"); err == nil {
+		t.Error("expected an error for a platform without a build")
+	}
+}
+
+func TestDownloadBinaryRejectsBadChecksum(t *testing.T) {
+	archive := tarGz(t, "mcphost", []byte("tampered"))
+	srv := releaseServer(t, archive, strings.Repeat("a", 64))
+	client := &Client{APIURL: srv.URL, Repo: "test/mcphost", HTTPClient: srv.Client()}
+
+	release, _ := client.Latest(context.Background())
+	_, err := client.DownloadBinary(context.Background(), release, "linux", "amd64")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcphost")
+	os.WriteFile(path, []byte("old"), 0755)
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("expected binary to be replaced, got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected replaced binary to be executable, got %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected temp files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := map[string]string{
+		"linux/amd64":   "mcphost_Linux_x86_64.tar.gz",
+		"darwin/arm64":  "mcphost_Darwin_arm64.tar.gz",
+		"windows/amd64": "mcphost_Windows_x86_64.zip",
+	}
+	for platform, want := range tests {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		if got := ArchiveName(goos, goarch); got != want {
+			t.Errorf("ArchiveName(%s) = %s, want %s", platform, got, want)
+		}
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.10.0", "1.9.0", true},
+		{"1.2.0", "1.2.0", false},
+		{"1.2.0", "1.3.0", false},
+		{"1.2.0", "dev", true},
+		{"dev", "1.2.0", false},
+		{"1.2.0", "1.2.0-rc1", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.a, tt.b); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
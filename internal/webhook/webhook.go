@@ -0,0 +1,208 @@
+// Package webhook posts a JSON summary of finished agent runs to configured URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/osi4iot/mcphost/internal/ledger"
+	"github.com/osi4iot/mcphost/internal/models"
+	"github.com/osi4iot/mcphost/internal/postmortem"
+)
+
+// Events a webhook can subscribe to
+const (
+	EventRun = "run" // a non-interactive (--prompt) run finished
+	EventJob = "job" // a serve-mode background job finished
+)
+
+// Run statuses reported in payloads
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-MCPHost-Event"
+	HeaderDelivery  = "X-MCPHost-Delivery"
+	HeaderSignature = "X-MCPHost-Signature-256"
+)
+
+// Config is one entry of the webhooks: config section
+type Config struct {
+	URL    string   `json:"url" yaml:"url" mapstructure:"url"`
+	Secret string   `json:"secret,omitempty" yaml:"secret,omitempty" mapstructure:"secret"` // signs the body with HMAC-SHA256
+	Events []string `json:"events,omitempty" yaml:"events,omitempty" mapstructure:"events"` // run and/or job; empty means both
+}
+
+// Validate checks every webhook has an http(s) URL and known events
+func Validate(hooks []Config) error {
+	for i, hook := range hooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhooks[%d]: url must be an http or https URL, got %q", i, hook.URL)
+		}
+		for _, event := range hook.Events {
+			if event != EventRun && event != EventJob {
+				return fmt.Errorf("webhooks[%d]: unknown event %q (use %s or %s)", i, event, EventRun, EventJob)
+			}
+		}
+	}
+	return nil
+}
+
+// Usage is the token usage of a run
+type Usage struct {
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	ReasoningTokens  int     `json:"reasoning_tokens,omitempty"`
+	Cost             float64 `json:"cost,omitempty"` // estimated, in dollars
+}
+
+// NewUsage prices counts for modelString
+func NewUsage(modelString string, counts models.TokenCounts) Usage {
+	return Usage{
+		InputTokens:      counts.InputTokens,
+		OutputTokens:     counts.OutputTokens,
+		CacheReadTokens:  counts.CacheReadTokens,
+		CacheWriteTokens: counts.CacheWriteTokens,
+		ReasoningTokens:  counts.ReasoningTokens,
+		Cost:             ledger.EstimateCost(modelString, counts),
+	}
+}
+
+// Payload is the JSON body posted to webhooks
+type Payload struct {
+	Event      string    `json:"event"`
+	Status     string    `json:"status"`
+	JobID      string    `json:"job_id,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Session    string    `json:"session,omitempty"` // URL of a job's session, or the file a run was saved to
+	Model      string    `json:"model"`
+	Prompt     string    `json:"prompt"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Usage      Usage     `json:"usage"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+
+	// PostMortem explains a failed run
+	PostMortem *postmortem.Report `json:"post_mortem,omitempty"`
+}
+
+// Notifier delivers payloads to the configured webhooks. A nil Notifier
+// sends nothing.
+type Notifier struct {
+	hooks      []Config
+	client     *http.Client
+	attempts   int
+	retryDelay time.Duration
+}
+
+// New returns a notifier for hooks, or nil when there are none
+func New(hooks []Config) *Notifier {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return &Notifier{
+		hooks:      hooks,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		attempts:   3,
+		retryDelay: time.Second,
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts p to every webhook subscribed to its event, retrying failed
+// deliveries. It returns the errors of the deliveries that never succeeded.
+func (n *Notifier) Send(ctx context.Context, p *Payload) error {
+	if n == nil {
+		return nil
+	}
+	if p.DurationMS == 0 && !p.StartedAt.IsZero() {
+		p.DurationMS = p.FinishedAt.Sub(p.StartedAt).Milliseconds()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, hook := range n.hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, p.Event) {
+			continue
+		}
+		if err := n.deliver(ctx, hook, p.Event, body); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", hook.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver posts body to hook, retrying network errors and 429/5xx responses
+func (n *Notifier) deliver(ctx context.Context, hook Config, event string, body []byte) error {
+	delivery := make([]byte, 8)
+	rand.Read(delivery)
+
+	var err error
+	for attempt := 0; attempt < n.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(n.retryDelay * time.Duration(1<<(attempt-1))):
+			}
+		}
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "mcphost-webhook")
+		req.Header.Set(HeaderEvent, event)
+		req.Header.Set(HeaderDelivery, hex.EncodeToString(delivery))
+		if hook.Secret != "" {
+			req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+		}
+
+		var resp *http.Response
+		resp, err = n.client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		err = fmt.Errorf("unexpected status %s", resp.Status)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return err
+		}
+	}
+	return err
+}
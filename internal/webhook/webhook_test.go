@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	var got Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign("s3cret", body) {
+			t.Errorf("bad signature %q", r.Header.Get(HeaderSignature))
+		}
+		if r.Header.Get(HeaderEvent) != EventJob || r.Header.Get(HeaderDelivery) == "" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	n := New([]Config{{URL: srv.URL, Secret: "s3cret"}})
+	n.retryDelay = time.Millisecond
+	started := time.Now().Add(-2 * time.Second)
+	err := n.Send(context.Background(), &Payload{
+		Event:      EventJob,
+		Status:     StatusSucceeded,
+		JobID:      "job_1",
+		Response:   "done",
+		StartedAt:  started,
+		FinishedAt: started.Add(1500 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected one retry, got %d calls", calls.Load())
+	}
+	if got.JobID != "job_1" || got.Response != "done" || got.DurationMS != 1500 {
+		t.Errorf("unexpected payload %+v", got)
+	}
+}
+
+func TestSendFiltersEventsAndReportsFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	n := New([]Config{
+		{URL: srv.URL + "/jobs", Events: []string{EventJob}},
+		{URL: srv.URL + "/runs", Events: []string{EventRun}},
+	})
+	n.retryDelay = time.Millisecond
+	err := n.Send(context.Background(), &Payload{Event: EventRun, Status: StatusFailed})
+	if err == nil || !strings.Contains(err.Error(), "/runs") || strings.Contains(err.Error(), "/jobs") {
+		t.Errorf("unexpected error %v", err)
+	}
+	// 4xx responses other than 429 are not retried
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+
+	var nilNotifier *Notifier
+	if err := nilNotifier.Send(context.Background(), &Payload{}); err != nil {
+		t.Errorf("nil notifier should send nothing, got %v", err)
+	}
+	if New(nil) != nil {
+		t.Error("expected no notifier without webhooks")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]Config{{URL: "https://example.com/hook", Events: []string{"run", "job"}}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, hooks := range [][]Config{
+		{{URL: ""}},
+		{{URL: "ftp://example.com"}},
+		{{URL: "https://example.com", Events: []string{"done"}}},
+	} {
+		if err := Validate(hooks); err == nil {
+			t.Errorf("expected %+v to be rejected", hooks)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package workspace carries the working directory and environment a session
+// runs its tools in, so one process can serve agents working on different
+// project checkouts at the same time
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Workspace is a session's working directory and environment overlay. The
+// zero value is the process's own directory and environment.
+type Workspace struct {
+	Dir string            // absolute working directory; empty keeps the process's
+	Env map[string]string // set on top of the process's environment
+}
+
+type contextKey struct{}
+
+// New returns the workspace for dir and env, checking that dir is an existing
+// directory
+func New(dir string, env map[string]string) (Workspace, error) {
+	ws := Workspace{Env: env}
+	if dir == "" {
+		return ws, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ws, fmt.Errorf("invalid working directory %q: %v", dir, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return ws, fmt.Errorf("invalid working directory %q: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return ws, fmt.Errorf("working directory %q is not a directory", dir)
+	}
+	ws.Dir = abs
+	return ws, nil
+}
+
+// Policy limits the workspaces remote clients may ask for. The zero value
+// allows none: requests can't set a directory or environment.
+type Policy struct {
+	Roots   []string // directories a workspace's Dir must be inside
+	EnvKeys []string // variables a workspace's Env may set
+}
+
+// Check returns an error when w asks for a directory outside p's roots or
+// sets a variable p doesn't allow
+func (p Policy) Check(w Workspace) error {
+	if w.Dir != "" && !Inside(w.Dir, p.Roots) {
+		if len(p.Roots) == 0 {
+			return fmt.Errorf("work_dir is not allowed: the server has no workspace roots")
+		}
+		return fmt.Errorf("work_dir %q is outside the server's workspace roots", w.Dir)
+	}
+	for key := range w.Env {
+		if !slices.Contains(p.EnvKeys, key) {
+			return fmt.Errorf("env variable %s is not allowed by the server", key)
+		}
+	}
+	return nil
+}
+
+// Inside reports whether dir is one of roots or below one. Symlinks are
+// resolved first, so a link under a root can't lead out of it.
+func Inside(dir string, roots []string) bool {
+	resolved, err := resolve(dir)
+	if err != nil {
+		return false
+	}
+	for _, root := range roots {
+		root, err := resolve(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the absolute path of dir with symlinks resolved
+func resolve(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// ParseEnv returns the variables of KEY=VALUE pairs, as given to --env
+func ParseEnv(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	env := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", pair)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// IsZero reports whether w changes nothing
+func (w Workspace) IsZero() bool {
+	return w.Dir == "" && len(w.Env) == 0
+}
+
+// Key identifies w, so servers started for a workspace can be reused by other
+// sessions in the same one
+func (w Workspace) Key() string {
+	var b strings.Builder
+	b.WriteString(w.Dir)
+	for _, pair := range w.Environ() {
+		b.WriteByte(0)
+		b.WriteString(pair)
+	}
+	return b.String()
+}
+
+// Environ returns the overlay as sorted KEY=VALUE pairs
+func (w Workspace) Environ() []string {
+	pairs := make([]string, 0, len(w.Env))
+	for k, v := range w.Env {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// With returns a context carrying w. A zero w leaves ctx unchanged.
+func With(ctx context.Context, w Workspace) context.Context {
+	if w.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, w)
+}
+
+// From returns the workspace carried by ctx, or the zero one
+func From(ctx context.Context) Workspace {
+	w, _ := ctx.Value(contextKey{}).(Workspace)
+	return w
+}
@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	ws, err := New(dir, map[string]string{"A": "1"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if ws.Dir != dir || ws.IsZero() {
+		t.Errorf("unexpected workspace %+v", ws)
+	}
+
+	if _, err := New(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0o644)
+	if _, err := New(file, nil); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	outside := t.TempDir()
+	os.Mkdir(project, 0o755)
+	os.Symlink(outside, filepath.Join(project, "escape"))
+
+	policy := Policy{Roots: []string{root}, EnvKeys: []string{"GOFLAGS"}}
+	if err := policy.Check(Workspace{Dir: project, Env: map[string]string{"GOFLAGS": "-count=1"}}); err != nil {
+		t.Errorf("expected a workspace under the root to be allowed: %v", err)
+	}
+	for _, ws := range []Workspace{
+		{Dir: outside},
+		{Dir: "/"},
+		{Dir: filepath.Join(project, "escape")},
+		{Env: map[string]string{"LD_PRELOAD": "/tmp/x.so"}},
+	} {
+		if err := policy.Check(ws); err == nil {
+			t.Errorf("expected %+v to be refused", ws)
+		}
+	}
+	if err := (Policy{}).Check(Workspace{Dir: project}); err == nil {
+		t.Error("expected no work_dir to be allowed without roots")
+	}
+	if err := (Policy{}).Check(Workspace{}); err != nil {
+		t.Errorf("expected the default workspace to be allowed: %v", err)
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	env, err := ParseEnv([]string{"A=1", "B=x=y", "C="})
+	if err != nil {
+		t.Fatalf("ParseEnv failed: %v", err)
+	}
+	if env["A"] != "1" || env["B"] != "x=y" || env["C"] != "" || len(env) != 3 {
+		t.Errorf("unexpected env %v", env)
+	}
+	for _, bad := range []string{"A", "=1"} {
+		if _, err := ParseEnv([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestContextAndKey(t *testing.T) {
+	ctx := context.Background()
+	if !From(ctx).IsZero() {
+		t.Error("expected no workspace in a plain context")
+	}
+	if With(ctx, Workspace{}) != ctx {
+		t.Error("expected a zero workspace to leave the context unchanged")
+	}
+
+	a := Workspace{Dir: "/a", Env: map[string]string{"X": "1", "Y": "2"}}
+	if got := From(With(ctx, a)); got.Dir != "/a" || got.Env["Y"] != "2" {
+		t.Errorf("unexpected workspace from context %+v", got)
+	}
+	same := Workspace{Dir: "/a", Env: map[string]string{"Y": "2", "X": "1"}}
+	if a.Key() != same.Key() {
+		t.Error("expected equal workspaces to have the same key")
+	}
+	if a.Key() == (Workspace{Dir: "/a"}).Key() {
+		t.Error("expected the environment to change the key")
+	}
+}
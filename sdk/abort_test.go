@@ -0,0 +1,23 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAbortCancelsEveryPrompt(t *testing.T) {
+	m := &MCPHost{}
+	first, doneFirst := m.beginPrompt(context.Background())
+	second, doneSecond := m.beginPrompt(context.Background())
+
+	m.Abort()
+	if first.Err() == nil || second.Err() == nil {
+		t.Error("expected Abort to cancel both running prompts")
+	}
+
+	doneFirst()
+	doneSecond()
+	if len(m.prompts) != 0 {
+		t.Errorf("expected finished prompts to be forgotten, %d left", len(m.prompts))
+	}
+}
@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/osi4iot/mcphost/sdk/mcphostpb"
+)
+
+// DaemonClient talks to a running "mcphost serve --grpc-addr" over gRPC,
+// instead of running the agent in-process like MCPHost
+type DaemonClient struct {
+	conn    *grpc.ClientConn
+	client  mcphostpb.MCPHostClient
+	workDir string
+	env     map[string]string
+}
+
+// DaemonOptions for DialDaemon (all optional)
+type DaemonOptions struct {
+	APIKey string      // sent as "authorization: Bearer <key>" on every call
+	TLS    *tls.Config // nil connects without TLS
+
+	// WorkDir and Env are sent with every prompt: the directory, on the
+	// daemon's host, and extra environment its bash, fs and stdio servers run in
+	WorkDir string
+	Env     map[string]string
+}
+
+// DialDaemon connects to the daemon's gRPC API at addr (host:port)
+func DialDaemon(addr string, opts *DaemonOptions) (*DaemonClient, error) {
+	if opts == nil {
+		opts = &DaemonOptions{}
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if opts.TLS != nil {
+		dialOpts[0] = grpc.WithTransportCredentials(credentials.NewTLS(opts.TLS))
+	}
+	if opts.APIKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apiKeyCredentials{
+			key:    opts.APIKey,
+			secure: opts.TLS != nil,
+		}))
+	}
+
+	conn, err := grpc.NewClient(addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	return &DaemonClient{
+		conn:    conn,
+		client:  mcphostpb.NewMCPHostClient(conn),
+		workDir: opts.WorkDir,
+		env:     opts.Env,
+	}, nil
+}
+
+// Client returns the generated client, for typed access to every call of the
+// API, including ListTools and the session calls
+func (c *DaemonClient) Client() mcphostpb.MCPHostClient {
+	return c.client
+}
+
+// Prompt runs one turn in the given session (empty starts a new one) and
+// returns its result; the new session's ID is in the result
+func (c *DaemonClient) Prompt(ctx context.Context, sessionID, prompt string) (*mcphostpb.PromptResponse, error) {
+	return c.client.Prompt(ctx, c.promptRequest(sessionID, prompt))
+}
+
+// StreamEvents runs one turn like Prompt, calling onEvent with its tool calls,
+// tool results and response as they happen
+func (c *DaemonClient) StreamEvents(ctx context.Context, sessionID, prompt string, onEvent func(*mcphostpb.Event)) (*mcphostpb.PromptResponse, error) {
+	stream, err := c.client.StreamEvents(ctx, c.promptRequest(sessionID, prompt))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("event stream ended without a response")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if onEvent != nil {
+			onEvent(event)
+		}
+		if event.GetResult() != nil {
+			return event.GetResult(), nil
+		}
+	}
+}
+
+func (c *DaemonClient) promptRequest(sessionID, prompt string) *mcphostpb.PromptRequest {
+	return &mcphostpb.PromptRequest{Prompt: prompt, SessionId: sessionID, WorkDir: c.workDir, Env: c.env}
+}
+
+// Close closes the connection to the daemon
+func (c *DaemonClient) Close() error {
+	return c.conn.Close()
+}
+
+// apiKeyCredentials sends the API key with every call
+type apiKeyCredentials struct {
+	key    string
+	secure bool
+}
+
+func (a apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + a.key}, nil
+}
+
+// RequireTransportSecurity lets keys go over plaintext connections, e.g. to a
+// daemon on localhost or behind a TLS-terminating proxy
+func (a apiKeyCredentials) RequireTransportSecurity() bool {
+	return a.secure
+}
@@ -2,22 +2,32 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/osi4iot/mcphost/cmd"
 	"github.com/osi4iot/mcphost/internal/agent"
 	"github.com/osi4iot/mcphost/internal/config"
-	"github.com/osi4iot/mcphost/internal/models"
 	"github.com/osi4iot/mcphost/internal/session"
+	"github.com/osi4iot/mcphost/internal/tools"
+	"github.com/osi4iot/mcphost/internal/workspace"
 	"github.com/spf13/viper"
 )
 
 // MCPHost provides programmatic access to mcphost
 type MCPHost struct {
-	agent       *agent.Agent
-	sessionMgr  *session.Manager
-	modelString string
+	agent        *agent.Agent
+	sessionMgr   *session.Manager
+	sessionStore session.Store
+	modelString  string
+
+	mu         sync.Mutex
+	workspace  workspace.Workspace        // where prompts run their bash, fs and stdio servers
+	prompts    map[int]context.CancelFunc // cancel the in-flight prompts, by prompt number
+	lastPrompt int                        // number of the last prompt begun
+	submitted  map[string]ToolResult      // results for pending tool calls, by call ID
 }
 
 // Options for creating MCPHost (all optional - will use CLI defaults)
@@ -28,14 +38,57 @@ type Options struct {
 	MaxSteps     int    // Override max steps (0 = use default)
 	Streaming    bool   // Enable streaming (default from config)
 	Quiet        bool   // Suppress debug output
+	StateDir     string // Override the state directory (default ~/.mcphost)
+	Offline      bool   // Make no network lookups at startup, like --offline
+
+	// SessionStore backs LoadSession/SaveSession/ListSessions/DeleteSession
+	// (default: file store where session IDs are file paths)
+	SessionStore SessionStore
+
+	// OnToolApproval, when set, is called before every tool execution. Returning
+	// allow=false skips the tool and reports reason to the model instead.
+	OnToolApproval func(toolName, args string) (allow bool, reason string)
+
+	// OnSamplingApproval, when set, is called for MCP sampling requests from
+	// servers whose sampling-approval policy is ask. preview is an excerpt of
+	// the request. Without it those requests are denied.
+	OnSamplingApproval func(serverName, preview string) (allow bool, reason string)
+
+	// StopOnToolCalls ends a prompt as soon as the model asks for tool calls,
+	// without running them. PendingToolCalls returns them; run them yourself
+	// and pass their results to ContinueWithToolResults.
+	StopOnToolCalls bool
+
+	// WorkDir and Env set the directory and extra environment prompts run
+	// their bash, fs and stdio servers in (default: the process's own), e.g. to
+	// point several MCPHost instances at different checkouts. SetWorkspace
+	// changes them later.
+	WorkDir string
+	Env     map[string]string
 }
 
+// ErrToolCallsPending is returned by Prompt while the session ends in tool
+// calls that ContinueWithToolResults has not answered
+var ErrToolCallsPending = errors.New("tool calls are pending: answer them with SubmitToolResults or ContinueWithToolResults")
+
 // New creates MCPHost instance using the same initialization as CLI
 func New(ctx context.Context, opts *Options) (*MCPHost, error) {
 	if opts == nil {
 		opts = &Options{}
 	}
 
+	if opts.StateDir != "" {
+		viper.Set("state-dir", opts.StateDir)
+	}
+	if opts.Offline {
+		viper.Set("offline", true)
+	}
+
+	ws, err := workspace.New(opts.WorkDir, opts.Env)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize config exactly like CLI does
 	cmd.InitConfig()
 
@@ -72,26 +125,12 @@ func New(ctx context.Context, opts *Options) (*MCPHost, error) {
 		return nil, fmt.Errorf("failed to load system prompt: %v", err)
 	}
 
-	// Create model configuration (same as CLI in root.go:387-406)
-	temperature := float32(viper.GetFloat64("temperature"))
-	topP := float32(viper.GetFloat64("top-p"))
-	topK := int32(viper.GetInt("top-k"))
-	numGPU := int32(viper.GetInt("num-gpu-layers"))
-	mainGPU := int32(viper.GetInt("main-gpu"))
-
-	modelConfig := &models.ProviderConfig{
-		ModelString:    viper.GetString("model"),
-		SystemPrompt:   systemPrompt,
-		ProviderAPIKey: viper.GetString("provider-api-key"),
-		ProviderURL:    viper.GetString("provider-url"),
-		MaxTokens:      viper.GetInt("max-tokens"),
-		Temperature:    &temperature,
-		TopP:           &topP,
-		TopK:           &topK,
-		StopSequences:  viper.GetStringSlice("stop-sequences"),
-		NumGPU:         &numGPU,
-		MainGPU:        &mainGPU,
-		TLSSkipVerify:  viper.GetBool("tls-skip-verify"),
+	// Create model configuration (same as CLI)
+	modelConfig := cmd.BuildProviderConfig(systemPrompt)
+
+	scrubber, err := cmd.NewScrubber()
+	if err != nil {
+		return nil, err
 	}
 
 	// Create agent using existing factory (same as CLI in root.go:431-440)
@@ -103,25 +142,94 @@ func New(ctx context.Context, opts *Options) (*MCPHost, error) {
 		StreamingEnabled: viper.GetBool("stream"),
 		ShowSpinner:      false, // No spinner for SDK
 		Quiet:            opts.Quiet,
+		Scrubber:         scrubber,
+
+		// Cancellation goes through ctx and Abort, not the terminal
+		DisableESCListener:  true,
+		ToolApprovalHandler: opts.OnToolApproval,
+
+		SamplingApprovalHandler:  tools.NewSamplingApprover(mcpConfig, opts.OnSamplingApproval),
+		KeepDuplicateToolResults: viper.GetBool("keep-duplicate-tool-results"),
+		StopOnToolCalls:          opts.StopOnToolCalls,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent: %v", err)
 	}
 
 	// Create session manager
-	sessionMgr := session.NewManager("")
+	sessionStore := opts.SessionStore
+	if sessionStore == nil {
+		sessionStore = session.NewFileStore("")
+	}
+	sessionMgr := session.NewManagerWithStore(nil, sessionStore, "")
 
 	return &MCPHost{
-		agent:       a,
-		sessionMgr:  sessionMgr,
-		modelString: viper.GetString("model"),
+		agent:        a,
+		sessionMgr:   sessionMgr,
+		sessionStore: sessionStore,
+		modelString:  viper.GetString("model"),
+		workspace:    ws,
 	}, nil
 }
 
-// Prompt sends a message and returns the response
+// SetWorkspace sets the directory and extra environment later prompts run
+// their bash, fs and stdio servers in, e.g. after loading a session for
+// another checkout. An empty dir and nil env restore the process's own.
+func (m *MCPHost) SetWorkspace(dir string, env map[string]string) error {
+	ws, err := workspace.New(dir, env)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workspace = ws
+	return nil
+}
+
+// beginPrompt derives a cancellable context for a prompt so Abort can stop it.
+// The returned function must be called once the prompt finishes.
+func (m *MCPHost) beginPrompt(ctx context.Context) (context.Context, func()) {
+	m.mu.Lock()
+	promptCtx, cancel := context.WithCancel(workspace.With(ctx, m.workspace))
+	if m.prompts == nil {
+		m.prompts = make(map[int]context.CancelFunc)
+	}
+	m.lastPrompt++
+	id := m.lastPrompt
+	m.prompts[id] = cancel
+	m.mu.Unlock()
+
+	return promptCtx, func() {
+		m.mu.Lock()
+		delete(m.prompts, id)
+		m.mu.Unlock()
+		cancel()
+	}
+}
+
+// Abort cancels the in-flight prompts, if any. Each returns context.Canceled
+// and the session is left as it was before the prompt.
+// It is safe to call from another goroutine, e.g. a GUI stop button.
+func (m *MCPHost) Abort() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, cancel := range m.prompts {
+		cancel()
+	}
+}
+
+// Prompt sends a message and returns the response. Cancelling ctx or calling
+// Abort stops generation and any running tool call.
 func (m *MCPHost) Prompt(ctx context.Context, message string) (string, error) {
+	ctx, done := m.beginPrompt(ctx)
+	defer done()
+
 	// Get messages from session
 	messages := m.sessionMgr.GetMessages()
+	if len(pendingToolCalls(messages)) > 0 {
+		return "", ErrToolCallsPending
+	}
 
 	// Add new user message
 	userMsg := schema.UserMessage(message)
@@ -156,8 +264,14 @@ func (m *MCPHost) PromptWithCallbacks(
 	onToolResult func(name, args, result string, isError bool),
 	onStreaming func(chunk string),
 ) (string, error) {
+	ctx, done := m.beginPrompt(ctx)
+	defer done()
+
 	// Get messages from session
 	messages := m.sessionMgr.GetMessages()
+	if len(pendingToolCalls(messages)) > 0 {
+		return "", ErrToolCallsPending
+	}
 
 	// Add new user message
 	userMsg := schema.UserMessage(message)
@@ -189,24 +303,72 @@ func (m *MCPHost) GetSessionManager() *session.Manager {
 	return m.sessionMgr
 }
 
-// LoadSession loads a session from file
-func (m *MCPHost) LoadSession(path string) error {
-	s, err := session.LoadFromFile(path)
+// LoadSession loads a session from the session store. Subsequent prompts
+// auto-save back to the same ID.
+func (m *MCPHost) LoadSession(id string) error {
+	s, err := m.sessionStore.Load(context.Background(), id)
 	if err != nil {
 		return err
 	}
-	m.sessionMgr = session.NewManagerWithSession(s, path)
+	m.sessionMgr = session.NewManagerWithStore(s, m.sessionStore, id)
+	m.clearSubmitted()
 	return nil
 }
 
-// SaveSession saves the current session to file
-func (m *MCPHost) SaveSession(path string) error {
-	return m.sessionMgr.GetSession().SaveToFile(path)
+// SaveSession saves the current session to the session store
+func (m *MCPHost) SaveSession(id string) error {
+	return m.sessionStore.Save(context.Background(), id, m.sessionMgr.GetSession())
+}
+
+// ListSessions returns the IDs of the sessions in the session store
+func (m *MCPHost) ListSessions(ctx context.Context) ([]string, error) {
+	return m.sessionStore.List(ctx)
+}
+
+// DeleteSession removes a session from the session store
+func (m *MCPHost) DeleteSession(ctx context.Context, id string) error {
+	return m.sessionStore.Delete(ctx, id)
 }
 
 // ClearSession clears the current session history
 func (m *MCPHost) ClearSession() {
-	m.sessionMgr = session.NewManager("")
+	m.sessionMgr = session.NewManagerWithStore(nil, m.sessionStore, "")
+	m.clearSubmitted()
+}
+
+// Servers returns the status of every configured MCP server, including
+// servers that failed to start
+func (m *MCPHost) Servers() []ServerInfo {
+	statuses := m.agent.GetServerStatuses()
+	servers := make([]ServerInfo, len(statuses))
+	for i, status := range statuses {
+		servers[i] = ServerInfo{
+			Name:       status.Name,
+			Transport:  status.Transport,
+			Connected:  status.Connected,
+			Tools:      status.Tools,
+			LastError:  status.LastError,
+			ErrorCount: status.ErrorCount,
+			LastUsed:   status.LastUsed,
+		}
+	}
+	return servers
+}
+
+// Tools returns every tool available to the model with its input schema
+func (m *MCPHost) Tools() []ToolInfo {
+	details := m.agent.GetToolDetails()
+	tools := make([]ToolInfo, len(details))
+	for i, detail := range details {
+		tools[i] = ToolInfo{
+			Name:         detail.Name,
+			Server:       detail.Server,
+			OriginalName: detail.OriginalName,
+			Description:  detail.Description,
+			InputSchema:  detail.InputSchema,
+		}
+	}
+	return tools
 }
 
 // GetModelString returns the current model string
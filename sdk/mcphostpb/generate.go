@@ -0,0 +1,5 @@
+// Package mcphostpb is the generated Go code for the gRPC API of
+// "mcphost serve --grpc-addr", from proto/mcphost/v1/mcphost.proto
+package mcphostpb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/osi4iot/mcphost --go-grpc_out=../.. --go-grpc_opt=module=github.com/osi4iot/mcphost mcphost/v1/mcphost.proto
@@ -0,0 +1,331 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mcphost/v1/mcphost.proto
+
+package mcphostpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MCPHost_Prompt_FullMethodName        = "/mcphost.v1.MCPHost/Prompt"
+	MCPHost_StreamEvents_FullMethodName  = "/mcphost.v1.MCPHost/StreamEvents"
+	MCPHost_ListTools_FullMethodName     = "/mcphost.v1.MCPHost/ListTools"
+	MCPHost_ListSessions_FullMethodName  = "/mcphost.v1.MCPHost/ListSessions"
+	MCPHost_GetSession_FullMethodName    = "/mcphost.v1.MCPHost/GetSession"
+	MCPHost_DeleteSession_FullMethodName = "/mcphost.v1.MCPHost/DeleteSession"
+)
+
+// MCPHostClient is the client API for MCPHost service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MCPHost is the gRPC API of "mcphost serve --grpc-addr". It mirrors the JSON
+// HTTP API: API keys go in the "authorization" ("Bearer <key>") or
+// "x-api-key" metadata.
+type MCPHostClient interface {
+	// Prompt runs one agent turn and returns its final response
+	Prompt(ctx context.Context, in *PromptRequest, opts ...grpc.CallOption) (*PromptResponse, error)
+	// StreamEvents runs one agent turn like Prompt, streaming its tool calls and
+	// results as they happen. The last event carries the final response.
+	StreamEvents(ctx context.Context, in *PromptRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ListTools lists the tools of the connected MCP servers
+	ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*Session, error)
+	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
+}
+
+type mCPHostClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMCPHostClient(cc grpc.ClientConnInterface) MCPHostClient {
+	return &mCPHostClient{cc}
+}
+
+func (c *mCPHostClient) Prompt(ctx context.Context, in *PromptRequest, opts ...grpc.CallOption) (*PromptResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PromptResponse)
+	err := c.cc.Invoke(ctx, MCPHost_Prompt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCPHostClient) StreamEvents(ctx context.Context, in *PromptRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MCPHost_ServiceDesc.Streams[0], MCPHost_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PromptRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MCPHost_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *mCPHostClient) ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToolsResponse)
+	err := c.cc.Invoke(ctx, MCPHost_ListTools_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCPHostClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, MCPHost_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCPHostClient) GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, MCPHost_GetSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCPHostClient) DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSessionResponse)
+	err := c.cc.Invoke(ctx, MCPHost_DeleteSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MCPHostServer is the server API for MCPHost service.
+// All implementations must embed UnimplementedMCPHostServer
+// for forward compatibility.
+//
+// MCPHost is the gRPC API of "mcphost serve --grpc-addr". It mirrors the JSON
+// HTTP API: API keys go in the "authorization" ("Bearer <key>") or
+// "x-api-key" metadata.
+type MCPHostServer interface {
+	// Prompt runs one agent turn and returns its final response
+	Prompt(context.Context, *PromptRequest) (*PromptResponse, error)
+	// StreamEvents runs one agent turn like Prompt, streaming its tool calls and
+	// results as they happen. The last event carries the final response.
+	StreamEvents(*PromptRequest, grpc.ServerStreamingServer[Event]) error
+	// ListTools lists the tools of the connected MCP servers
+	ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	GetSession(context.Context, *GetSessionRequest) (*Session, error)
+	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	mustEmbedUnimplementedMCPHostServer()
+}
+
+// UnimplementedMCPHostServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMCPHostServer struct{}
+
+func (UnimplementedMCPHostServer) Prompt(context.Context, *PromptRequest) (*PromptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Prompt not implemented")
+}
+func (UnimplementedMCPHostServer) StreamEvents(*PromptRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedMCPHostServer) ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTools not implemented")
+}
+func (UnimplementedMCPHostServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedMCPHostServer) GetSession(context.Context, *GetSessionRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
+}
+func (UnimplementedMCPHostServer) DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
+}
+func (UnimplementedMCPHostServer) mustEmbedUnimplementedMCPHostServer() {}
+func (UnimplementedMCPHostServer) testEmbeddedByValue()                 {}
+
+// UnsafeMCPHostServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MCPHostServer will
+// result in compilation errors.
+type UnsafeMCPHostServer interface {
+	mustEmbedUnimplementedMCPHostServer()
+}
+
+func RegisterMCPHostServer(s grpc.ServiceRegistrar, srv MCPHostServer) {
+	// If the following call pancis, it indicates UnimplementedMCPHostServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MCPHost_ServiceDesc, srv)
+}
+
+func _MCPHost_Prompt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCPHostServer).Prompt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCPHost_Prompt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCPHostServer).Prompt(ctx, req.(*PromptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCPHost_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PromptRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MCPHostServer).StreamEvents(m, &grpc.GenericServerStream[PromptRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MCPHost_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _MCPHost_ListTools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCPHostServer).ListTools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCPHost_ListTools_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCPHostServer).ListTools(ctx, req.(*ListToolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCPHost_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCPHostServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCPHost_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCPHostServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCPHost_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCPHostServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCPHost_GetSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCPHostServer).GetSession(ctx, req.(*GetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCPHost_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCPHostServer).DeleteSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCPHost_DeleteSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCPHostServer).DeleteSession(ctx, req.(*DeleteSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MCPHost_ServiceDesc is the grpc.ServiceDesc for MCPHost service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MCPHost_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mcphost.v1.MCPHost",
+	HandlerType: (*MCPHostServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Prompt",
+			Handler:    _MCPHost_Prompt_Handler,
+		},
+		{
+			MethodName: "ListTools",
+			Handler:    _MCPHost_ListTools_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _MCPHost_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetSession",
+			Handler:    _MCPHost_GetSession_Handler,
+		},
+		{
+			MethodName: "DeleteSession",
+			Handler:    _MCPHost_DeleteSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _MCPHost_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mcphost/v1/mcphost.proto",
+}
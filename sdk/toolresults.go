@@ -0,0 +1,160 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/models/toolresult"
+)
+
+// PendingToolCalls returns the tool calls the last prompt stopped at with
+// Options.StopOnToolCalls that have no result yet, or nil when none are waiting
+func (m *MCPHost) PendingToolCalls() []PendingToolCall {
+	submitted := m.submittedResults()
+	var calls []PendingToolCall
+	for _, call := range pendingToolCalls(m.sessionMgr.GetMessages()) {
+		if _, ok := submitted[call.ID]; ok {
+			continue
+		}
+		calls = append(calls, PendingToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return calls
+}
+
+// SubmitToolResults records the results of pending tool calls as the caller's
+// executions finish, in any order and as many at a time as are ready. Once
+// every pending call has a result the prompt continues, as with
+// ContinueWithToolResults, and its response is returned with done set; until
+// then done is false and PendingToolCalls lists the calls still waiting.
+// Results held back this way are kept in memory, not in the session. It is
+// safe to call from the goroutines running the tool calls.
+func (m *MCPHost) SubmitToolResults(ctx context.Context, results []ToolResult) (response string, done bool, err error) {
+	return m.answerToolCalls(ctx, results, false)
+}
+
+// ContinueWithToolResults answers the pending tool calls with the results of
+// running them and continues the prompt, returning its response. Together
+// with results given earlier to SubmitToolResults there must be one for every
+// pending call. With Options.StopOnToolCalls the response may stop at new
+// tool calls again.
+func (m *MCPHost) ContinueWithToolResults(ctx context.Context, results []ToolResult) (string, error) {
+	response, _, err := m.answerToolCalls(ctx, results, true)
+	return response, err
+}
+
+// answerToolCalls adds results to those submitted for the pending tool calls
+// and continues the prompt once all have one, reporting whether it did. With
+// requireAll, calls left without a result are an error.
+func (m *MCPHost) answerToolCalls(ctx context.Context, results []ToolResult, requireAll bool) (string, bool, error) {
+	messages := m.sessionMgr.GetMessages()
+	pending := pendingToolCalls(messages)
+	if len(pending) == 0 {
+		return "", false, fmt.Errorf("no tool calls are pending")
+	}
+
+	submitted, err := m.addSubmitted(pending, results, requireAll)
+	if err != nil || submitted == nil {
+		return "", false, err
+	}
+
+	for _, call := range pending {
+		result := submitted[call.ID]
+		toolMessage := schema.ToolMessage(result.Content, call.ID)
+		if result.IsError {
+			toolresult.MarkError(toolMessage)
+		}
+		messages = append(messages, toolMessage)
+	}
+
+	ctx, done := m.beginPrompt(ctx)
+	defer done()
+
+	result, err := m.agent.GenerateWithLoop(ctx, messages, nil, nil, nil, nil, nil)
+	if err != nil {
+		return "", false, err
+	}
+	if err := m.sessionMgr.ReplaceAllMessages(result.ConversationMessages); err != nil {
+		return "", false, fmt.Errorf("failed to update session: %v", err)
+	}
+	m.clearSubmitted()
+	return result.FinalResponse.Content, true, nil
+}
+
+// addSubmitted adds results to those submitted for the pending calls and
+// returns them all once every call has one, or nil while some are missing.
+// The lock is held throughout so results submitted at the same time all
+// count. Results are kept until the run that uses them succeeds, so a failed
+// or aborted continuation can be retried.
+func (m *MCPHost) addSubmitted(pending []schema.ToolCall, results []ToolResult, requireAll bool) (map[string]ToolResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	isPending := make(map[string]bool, len(pending))
+	for _, call := range pending {
+		isPending[call.ID] = true
+	}
+	submitted := make(map[string]ToolResult, len(m.submitted)+len(results))
+	maps.Copy(submitted, m.submitted)
+	for _, result := range results {
+		if !isPending[result.ToolCallID] {
+			return nil, fmt.Errorf("tool call %s is not pending", result.ToolCallID)
+		}
+		if _, ok := submitted[result.ToolCallID]; ok {
+			return nil, fmt.Errorf("tool call %s already has a result", result.ToolCallID)
+		}
+		submitted[result.ToolCallID] = result
+	}
+
+	var missing []string
+	for _, call := range pending {
+		if _, ok := submitted[call.ID]; !ok {
+			missing = append(missing, fmt.Sprintf("%s (%s)", call.ID, call.Function.Name))
+		}
+	}
+	if len(missing) > 0 && requireAll {
+		return nil, fmt.Errorf("no result for tool calls %s", strings.Join(missing, ", "))
+	}
+	m.submitted = submitted
+	if len(missing) > 0 {
+		return nil, nil
+	}
+	return submitted, nil
+}
+
+// submittedResults returns a copy of the results submitted so far
+func (m *MCPHost) submittedResults() map[string]ToolResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	submitted := make(map[string]ToolResult, len(m.submitted))
+	maps.Copy(submitted, m.submitted)
+	return submitted
+}
+
+// clearSubmitted drops the submitted results once they are used or their
+// session is replaced
+func (m *MCPHost) clearSubmitted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.submitted = nil
+}
+
+// pendingToolCalls returns the tool calls of the last message when nothing
+// answered them yet
+func pendingToolCalls(messages []*schema.Message) []schema.ToolCall {
+	if len(messages) == 0 {
+		return nil
+	}
+	last := messages[len(messages)-1]
+	if last.Role != schema.Assistant {
+		return nil
+	}
+	return last.ToolCalls
+}
@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/osi4iot/mcphost/internal/session"
+)
+
+func TestSubmitToolResults(t *testing.T) {
+	m := &MCPHost{sessionMgr: session.NewManagerWithStore(nil, session.NewMemoryStore(), "")}
+	m.sessionMgr.ReplaceAllMessages([]*schema.Message{
+		schema.UserMessage("check both services"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call-1", Function: schema.FunctionCall{Name: "ops__status", Arguments: `{"name": "web"}`}},
+			{ID: "call-2", Function: schema.FunctionCall{Name: "ops__status", Arguments: `{"name": "db"}`}},
+		}),
+	})
+	ctx := context.Background()
+
+	if _, err := m.Prompt(ctx, "hello?"); err != ErrToolCallsPending {
+		t.Errorf("expected ErrToolCallsPending, got %v", err)
+	}
+	if _, _, err := m.SubmitToolResults(ctx, []ToolResult{{ToolCallID: "call-9"}}); err == nil {
+		t.Error("expected an error for a call that is not pending")
+	}
+	if _, err := m.ContinueWithToolResults(ctx, []ToolResult{{ToolCallID: "call-1", Content: "up"}}); err == nil {
+		t.Error("expected an error for a missing result")
+	}
+
+	response, done, err := m.SubmitToolResults(ctx, []ToolResult{{ToolCallID: "call-2", Content: "down", IsError: true}})
+	if err != nil || done || response != "" {
+		t.Fatalf("expected the run to wait for call-1, got %q, %v, %v", response, done, err)
+	}
+	pending := m.PendingToolCalls()
+	if len(pending) != 1 || pending[0].ID != "call-1" || pending[0].Arguments != `{"name": "web"}` {
+		t.Errorf("expected call-1 still pending, got %+v", pending)
+	}
+	if _, _, err := m.SubmitToolResults(ctx, []ToolResult{{ToolCallID: "call-2"}}); err == nil {
+		t.Error("expected an error for a call that already has a result")
+	}
+
+	m.ClearSession()
+	if m.PendingToolCalls() != nil || len(m.submittedResults()) != 0 {
+		t.Error("expected a cleared session to drop the pending calls and results")
+	}
+}
+
+func TestSubmitToolResultsConcurrently(t *testing.T) {
+	m := &MCPHost{sessionMgr: session.NewManagerWithStore(nil, session.NewMemoryStore(), "")}
+	var calls []schema.ToolCall
+	for i := range 20 {
+		calls = append(calls, schema.ToolCall{ID: fmt.Sprintf("call-%d", i), Function: schema.FunctionCall{Name: "ops__status"}})
+	}
+	calls = append(calls, schema.ToolCall{ID: "call-last", Function: schema.FunctionCall{Name: "ops__status"}})
+	m.sessionMgr.ReplaceAllMessages([]*schema.Message{schema.UserMessage("check them all"), schema.AssistantMessage("", calls)})
+
+	var wg sync.WaitGroup
+	for _, call := range calls[:20] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, done, err := m.SubmitToolResults(context.Background(), []ToolResult{{ToolCallID: call.ID, Content: "up"}}); err != nil || done {
+				t.Errorf("expected %s to be held, got %v, %v", call.ID, done, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if pending := m.PendingToolCalls(); len(pending) != 1 || pending[0].ID != "call-last" {
+		t.Errorf("expected every result submitted at once to count, got %d calls pending", len(pending))
+	}
+}
@@ -1,6 +1,9 @@
 package sdk
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/cloudwego/eino/schema"
 	"github.com/osi4iot/mcphost/internal/session"
 )
@@ -11,6 +14,63 @@ type Message = session.Message
 // ToolCall is an alias for session.ToolCall
 type ToolCall = session.ToolCall
 
+// ServerInfo describes a configured MCP server and its connection health
+type ServerInfo struct {
+	Name       string    `json:"name"`
+	Transport  string    `json:"transport"` // stdio, sse, streamable or inprocess
+	Connected  bool      `json:"connected"`
+	Tools      []string  `json:"tools"` // prefixed tool names exposed to the model
+	LastError  string    `json:"last_error,omitempty"`
+	ErrorCount int       `json:"error_count,omitempty"`
+	LastUsed   time.Time `json:"last_used,omitempty"`
+}
+
+// ToolInfo describes a tool available to the model
+type ToolInfo struct {
+	Name         string          `json:"name"` // prefixed name, e.g. "fs__read_file"
+	Server       string          `json:"server"`
+	OriginalName string          `json:"original_name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"input_schema,omitempty"` // JSON Schema of the arguments
+}
+
+// PendingToolCall is a tool call the model asked for that has not run, left
+// for the caller with Options.StopOnToolCalls
+type PendingToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`      // prefixed name, e.g. "fs__read_file"
+	Arguments string `json:"arguments"` // JSON object
+}
+
+// ToolResult is the outcome of a pending tool call the caller ran, passed to
+// ContinueWithToolResults
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error,omitempty"`
+}
+
+// Session is an alias for session.Session
+type Session = session.Session
+
+// SessionStore persists sessions by ID. Implement it to back sessions with a
+// database, object storage, etc., and pass it via Options.SessionStore.
+type SessionStore = session.Store
+
+// ErrSessionNotFound is returned by a SessionStore when no session exists for an ID
+var ErrSessionNotFound = session.ErrSessionNotFound
+
+// NewFileSessionStore returns a SessionStore that writes one JSON file per
+// session in dir. An empty dir treats session IDs as file paths.
+func NewFileSessionStore(dir string) SessionStore {
+	return session.NewFileStore(dir)
+}
+
+// NewMemorySessionStore returns a SessionStore that keeps sessions in memory
+func NewMemorySessionStore() SessionStore {
+	return session.NewMemoryStore()
+}
+
 // ConvertToSchemaMessage converts SDK message to schema message
 func ConvertToSchemaMessage(msg *Message) *schema.Message {
 	return msg.ConvertToSchemaMessage()